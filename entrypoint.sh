@@ -39,6 +39,7 @@ chmod 700 /run/gobastion
   [ -z "${DB_DRIVER:-}" ] || printf 'DB_DRIVER=%s\n' "$DB_DRIVER"
   [ -z "${DB_DSN:-}" ] || printf 'DB_DSN=%s\n' "$DB_DSN"
   [ -z "${EGRESS_ENC_KEY:-}" ] || printf 'EGRESS_ENC_KEY=%s\n' "$EGRESS_ENC_KEY"
+  [ -z "${EGRESS_ENC_KEY_PREVIOUS:-}" ] || printf 'EGRESS_ENC_KEY_PREVIOUS=%s\n' "$EGRESS_ENC_KEY_PREVIOUS"
   [ -z "${INSTANCE_ID:-}" ] || printf 'INSTANCE_ID=%s\n' "$INSTANCE_ID"
 } > /run/gobastion/db.conf
 # Only the small sudo session/sync wrappers read this file. The application
@@ -0,0 +1,586 @@
+package accessrequest
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"goBastion/internal/config"
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/validation"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Request files a just-in-time access request for a server through a group
+// the current user belongs to. A gatekeeper, aclkeeper or owner of the group
+// decides on it with accessRequestApprove or accessRequestDeny.
+func Request(db *gorm.DB, currentUser *models.User, args []string) error {
+	fs := flag.NewFlagSet("accessRequest", flag.ContinueOnError)
+	var groupName, server, remoteUser, protocol, durationStr, reason string
+	var port int64
+	fs.StringVar(&groupName, "group", "", "Group to request access through")
+	fs.StringVar(&server, "host", "", "Server to request access to")
+	fs.StringVar(&remoteUser, "user", "", "Remote username on the target server")
+	fs.Int64Var(&port, "port", 22, "Remote port")
+	fs.StringVar(&protocol, "protocol", "ssh", "Protocol: ssh, scpupload, scpdownload, sftp, rsync")
+	fs.StringVar(&durationStr, "duration", "", "Requested access duration, e.g. 2h")
+	fs.StringVar(&reason, "reason", "", "Why the access is needed")
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+
+	if err := fs.Parse(args); err != nil || strings.TrimSpace(groupName) == "" || strings.TrimSpace(server) == "" ||
+		strings.TrimSpace(remoteUser) == "" || strings.TrimSpace(durationStr) == "" || strings.TrimSpace(reason) == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Access Request",
+			BlockType: "error",
+			Sections: []console.SectionContent{{SubTitle: "Usage", Body: []string{
+				"Usage: accessRequest --group <group> --host <server> --user <remote_user> --duration <duration> --reason <text> [--port <port>] [--protocol <proto>]",
+			}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	if !validation.IsValidHost(server) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Access Request",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Server", Body: []string{"Server must be a valid hostname or IP address."}}},
+		})
+		return fmt.Errorf("invalid server: %s", server)
+	}
+	if !validation.IsValidUsername(remoteUser) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Access Request",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid User", Body: []string{"The remote user must be a valid account name (letters, digits, '.', '_' and '-')."}}},
+		})
+		return fmt.Errorf("invalid remote user: %s", remoteUser)
+	}
+	if !validation.IsValidPort(port) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Access Request",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Port", Body: []string{"Port must be between 1 and 65535"}}},
+		})
+		return fmt.Errorf("invalid port: %d", port)
+	}
+	if !validation.IsValidProtocol(protocol) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Access Request",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Protocol", Body: []string{"Protocol must be one of: ssh, scpupload, scpdownload, sftp, rsync"}}},
+		})
+		return fmt.Errorf("invalid protocol: %s", protocol)
+	}
+
+	duration, err := time.ParseDuration(strings.TrimSpace(durationStr))
+	maxDuration := time.Duration(config.Get().AccessRequests.MaxDuration)
+	if err != nil || duration < time.Minute || (maxDuration > 0 && duration > maxDuration) {
+		body := []string{"Duration must be a Go duration of at least 1m, e.g. 30m or 2h."}
+		if maxDuration > 0 {
+			body = append(body, fmt.Sprintf("The maximum allowed duration is %s.", maxDuration))
+		}
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Access Request",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Duration", Body: body}},
+		})
+		return fmt.Errorf("invalid duration: %s", durationStr)
+	}
+
+	var group models.Group
+	if err := db.Where("name = ?", groupName).First(&group).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Access Request",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("Group '%s' not found.", groupName)}}},
+		})
+		return err
+	}
+
+	var ug models.UserGroup
+	if err := db.Where("user_id = ? AND group_id = ? AND deleted_at IS NULL", currentUser.ID, group.ID).Where(models.MembershipActiveClause, time.Now()).First(&ug).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Access Request",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not a Member", Body: []string{fmt.Sprintf("You are not a member of group '%s'. Access requests can only be filed through your own groups.", groupName)}}},
+		})
+		return fmt.Errorf("user %q is not a member of group %q", currentUser.Username, groupName)
+	}
+
+	var existing models.AccessRequest
+	if err := db.Where("group_id = ? AND requester_id = ? AND server = ? AND port = ? AND username = ? AND status = ?",
+		group.ID, currentUser.ID, server, port, remoteUser, models.AccessRequestPending).First(&existing).Error; err == nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Access Request",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Already Pending", Body: []string{fmt.Sprintf("You already have a pending request for this access (ID %s).", existing.ID)}}},
+		})
+		return fmt.Errorf("pending request already exists: %s", existing.ID)
+	}
+
+	req := models.AccessRequest{
+		GroupID:         group.ID,
+		RequesterID:     currentUser.ID,
+		Username:        remoteUser,
+		Server:          server,
+		Port:            port,
+		Protocol:        protocol,
+		DurationSeconds: int64(duration / time.Second),
+		Reason:          strings.TrimSpace(reason),
+		Status:          models.AccessRequestPending,
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&req).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.AccessRequestEvent{
+			RequestID: req.ID,
+			ActorID:   currentUser.ID,
+			Action:    models.AccessRequestActionRequested,
+			Comment:   req.Reason,
+		}).Error
+	})
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Access Request",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Database Error", Body: []string{"Failed to record the access request."}}},
+		})
+		return err
+	}
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Access Request",
+		BlockType: "success",
+		Sections: []console.SectionContent{{SubTitle: "Submitted", Body: []string{
+			fmt.Sprintf("Requested %s access to %s@%s:%d through group '%s' for %s.", protocol, remoteUser, server, port, groupName, duration),
+			fmt.Sprintf("Request ID: %s", req.ID),
+			"A gatekeeper, aclkeeper or owner of the group must approve it. Track it with accessRequestList.",
+		}}},
+	})
+	return nil
+}
+
+// List shows access requests visible to the current user: their own, those of
+// groups they manage, or all of them for admins and superowners. With --id it
+// shows the full audit trail of a single request.
+func List(db *gorm.DB, currentUser *models.User, args []string) error {
+	fs := flag.NewFlagSet("accessRequestList", flag.ContinueOnError)
+	var groupName, status, idStr string
+	fs.StringVar(&groupName, "group", "", "Only show requests of this group")
+	fs.StringVar(&status, "status", models.AccessRequestPending, "Filter by status: pending, approved, denied, all")
+	fs.StringVar(&idStr, "id", "", "Show the audit trail of a single request")
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+
+	err := fs.Parse(args)
+	status = strings.ToLower(strings.TrimSpace(status))
+	if err != nil || !isValidStatusFilter(status) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Access Requests",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: accessRequestList [--group <group>] [--status pending|approved|denied|all] [--id <request_id>]"}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("invalid status filter: %s", status)
+	}
+
+	if strings.TrimSpace(idStr) != "" {
+		return showRequest(db, currentUser, idStr)
+	}
+
+	query := db.Preload("Group").Preload("Requester").Preload("Decider").Order("created_at desc")
+	if status != "all" {
+		query = query.Where("status = ?", status)
+	}
+	if strings.TrimSpace(groupName) != "" {
+		var group models.Group
+		if err := db.Where("name = ?", groupName).First(&group).Error; err != nil {
+			console.DisplayBlock(console.ContentBlock{
+				Title:     "Access Requests",
+				BlockType: "error",
+				Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("Group '%s' not found.", groupName)}}},
+			})
+			return err
+		}
+		query = query.Where("group_id = ?", group.ID)
+	}
+
+	var all []models.AccessRequest
+	if err := query.Find(&all).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Access Requests",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Database Error", Body: []string{"Failed to query access requests."}}},
+		})
+		return err
+	}
+
+	var requests []models.AccessRequest
+	for _, r := range all {
+		if canView(db, currentUser, &r) {
+			requests = append(requests, r)
+		}
+	}
+
+	if len(requests) == 0 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Access Requests",
+			BlockType: "info",
+			Sections:  []console.SectionContent{{SubTitle: "Info", Body: []string{"No access requests found."}}},
+		})
+		return nil
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tGroup\tRequester\tTarget\tProtocol\tDuration\tStatus\tDecided By\tRequested At\tReason")
+	for _, r := range requests {
+		decider := "-"
+		if r.Decider != nil {
+			decider = r.Decider.Username
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s@%s:%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.ID, r.Group.Name, r.Requester.Username, r.Username, r.Server, r.Port, r.Protocol,
+			r.Duration(), statusLabel(&r), decider, r.CreatedAt.Format("2006-01-02 15:04:05"), r.Reason)
+	}
+	_ = w.Flush()
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Access Requests",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Requests", Body: strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")}},
+	})
+	return nil
+}
+
+// showRequest prints a single request with its audit trail.
+func showRequest(db *gorm.DB, currentUser *models.User, idStr string) error {
+	req, err := loadRequest(db, "Access Requests", idStr)
+	if err != nil {
+		return err
+	}
+	if !canView(db, currentUser, req) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Access Requests",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You do not have permission to view this access request."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	var events []models.AccessRequestEvent
+	if err := db.Preload("Actor").Where("request_id = ?", req.ID).Order("created_at asc").Find(&events).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Access Requests",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Database Error", Body: []string{"Failed to query the audit trail."}}},
+		})
+		return err
+	}
+
+	details := []string{
+		fmt.Sprintf("Group:     %s", req.Group.Name),
+		fmt.Sprintf("Requester: %s", req.Requester.Username),
+		fmt.Sprintf("Target:    %s@%s:%d (%s)", req.Username, req.Server, req.Port, req.Protocol),
+		fmt.Sprintf("Duration:  %s", req.Duration()),
+		fmt.Sprintf("Reason:    %s", req.Reason),
+		fmt.Sprintf("Status:    %s", statusLabel(req)),
+	}
+	if req.GrantExpiresAt != nil {
+		details = append(details, fmt.Sprintf("Grant:     %s access for %s until %s", req.GrantType, req.Requester.Username, req.GrantExpiresAt.Format("2006-01-02 15:04:05")))
+	}
+
+	var trail []string
+	for _, e := range events {
+		line := fmt.Sprintf("%s  %-9s  by %s", e.CreatedAt.Format("2006-01-02 15:04:05"), e.Action, e.Actor.Username)
+		if e.Comment != "" {
+			line += "  (" + e.Comment + ")"
+		}
+		trail = append(trail, line)
+	}
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Access Requests",
+		BlockType: "info",
+		Sections: []console.SectionContent{
+			{SubTitle: fmt.Sprintf("Request %s", req.ID), Body: details},
+			{SubTitle: "Audit Trail", Body: trail},
+		},
+	})
+	return nil
+}
+
+// Approve approves a pending access request and creates the matching
+// time-boxed grant for the requester only: a GroupGuestAccess for guest-role
+// requesters, otherwise a GroupAccess on the group restricted to the
+// requester. The grant expires the requested duration after approval.
+func Approve(db *gorm.DB, currentUser *models.User, args []string) error {
+	const title = "Approve Access Request"
+	req, comment, err := parseDecision(db, currentUser, "accessRequestApprove", title, args)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	expires := now.Add(req.Duration())
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Re-check membership at decision time: the requester may have been
+		// removed from the group since filing the request.
+		var ug models.UserGroup
+		if err := tx.Where("user_id = ? AND group_id = ? AND deleted_at IS NULL", req.RequesterID, req.GroupID).Where(models.MembershipActiveClause, now).First(&ug).Error; err != nil {
+			return errRequesterNotMember
+		}
+
+		var grantID uuid.UUID
+		grantType := "group"
+		if ug.Role == models.GroupRoleGuest {
+			grantType = "guest"
+			grant := models.GroupGuestAccess{
+				GroupID:   req.GroupID,
+				UserID:    req.RequesterID,
+				Username:  req.Username,
+				Server:    req.Server,
+				Port:      req.Port,
+				Protocol:  req.Protocol,
+				Comment:   fmt.Sprintf("access request %s", req.ID),
+				ExpiresAt: &expires,
+			}
+			if err := tx.Create(&grant).Error; err != nil {
+				return err
+			}
+			grantID = grant.ID
+		} else {
+			grant := models.GroupAccess{
+				GroupID:   req.GroupID,
+				Username:  req.Username,
+				Server:    req.Server,
+				Port:      req.Port,
+				Protocol:  req.Protocol,
+				Comment:   fmt.Sprintf("access request %s, only %s", req.ID, req.Requester.Username),
+				ExpiresAt: &expires,
+				MemberID:  &req.RequesterID,
+			}
+			if err := tx.Create(&grant).Error; err != nil {
+				return err
+			}
+			grantID = grant.ID
+		}
+
+		res := tx.Model(&models.AccessRequest{}).
+			Where("id = ? AND status = ?", req.ID, models.AccessRequestPending).
+			Updates(map[string]any{
+				"status":           models.AccessRequestApproved,
+				"decider_id":       currentUser.ID,
+				"decision_comment": comment,
+				"decided_at":       now,
+				"grant_type":       grantType,
+				"grant_id":         grantID,
+				"grant_expires_at": expires,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errAlreadyDecided
+		}
+		return tx.Create(&models.AccessRequestEvent{
+			RequestID: req.ID,
+			ActorID:   currentUser.ID,
+			Action:    models.AccessRequestActionApproved,
+			Comment:   comment,
+		}).Error
+	})
+	if err != nil {
+		return decisionFailed(title, err)
+	}
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     title,
+		BlockType: "success",
+		Sections: []console.SectionContent{{SubTitle: "Approved", Body: []string{
+			fmt.Sprintf("Granted '%s' access to %s@%s:%d through group '%s'.", req.Requester.Username, req.Username, req.Server, req.Port, req.Group.Name),
+			fmt.Sprintf("The access expires at %s.", expires.Format("2006-01-02 15:04:05")),
+		}}},
+	})
+	return nil
+}
+
+// Deny rejects a pending access request.
+func Deny(db *gorm.DB, currentUser *models.User, args []string) error {
+	const title = "Deny Access Request"
+	req, comment, err := parseDecision(db, currentUser, "accessRequestDeny", title, args)
+	if err != nil {
+		return err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.AccessRequest{}).
+			Where("id = ? AND status = ?", req.ID, models.AccessRequestPending).
+			Updates(map[string]any{
+				"status":           models.AccessRequestDenied,
+				"decider_id":       currentUser.ID,
+				"decision_comment": comment,
+				"decided_at":       time.Now(),
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errAlreadyDecided
+		}
+		return tx.Create(&models.AccessRequestEvent{
+			RequestID: req.ID,
+			ActorID:   currentUser.ID,
+			Action:    models.AccessRequestActionDenied,
+			Comment:   comment,
+		}).Error
+	})
+	if err != nil {
+		return decisionFailed(title, err)
+	}
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     title,
+		BlockType: "success",
+		Sections: []console.SectionContent{{SubTitle: "Denied", Body: []string{
+			fmt.Sprintf("Denied the request of '%s' for %s@%s:%d in group '%s'.", req.Requester.Username, req.Username, req.Server, req.Port, req.Group.Name),
+		}}},
+	})
+	return nil
+}
+
+var (
+	errAlreadyDecided     = errors.New("access request has already been decided")
+	errRequesterNotMember = errors.New("requester is no longer a member of the group")
+)
+
+// parseDecision handles the flags and checks shared by Approve and Deny and
+// returns the pending request being decided.
+func parseDecision(db *gorm.DB, currentUser *models.User, right, title string, args []string) (*models.AccessRequest, string, error) {
+	fs := flag.NewFlagSet(right, flag.ContinueOnError)
+	var idStr, comment string
+	fs.StringVar(&idStr, "id", "", "Access request ID")
+	fs.StringVar(&comment, "comment", "", "Decision comment")
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+
+	if err := fs.Parse(args); err != nil || strings.TrimSpace(idStr) == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     title,
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{fmt.Sprintf("Usage: %s --id <request_id> [--comment <text>]", right)}}},
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		return nil, "", fmt.Errorf("missing required arguments")
+	}
+
+	req, err := loadRequest(db, title, idStr)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !currentUser.CanDo(db, right, req.Group.Name) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     title,
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"Only gatekeepers, aclkeepers and owners of the group can decide on its access requests."}}},
+		})
+		return nil, "", fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+	if req.RequesterID == currentUser.ID {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     title,
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You cannot decide on your own access request."}}},
+		})
+		return nil, "", fmt.Errorf("user %s cannot decide on their own request", currentUser.Username)
+	}
+	if req.Status != models.AccessRequestPending {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     title,
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Already Decided", Body: []string{fmt.Sprintf("This request is already %s.", req.Status)}}},
+		})
+		return nil, "", errAlreadyDecided
+	}
+	return req, strings.TrimSpace(comment), nil
+}
+
+// decisionFailed reports a failed approve/deny transaction.
+func decisionFailed(title string, err error) error {
+	msg := "Failed to record the decision."
+	switch {
+	case errors.Is(err, errAlreadyDecided):
+		msg = "This request was decided by someone else in the meantime."
+	case errors.Is(err, errRequesterNotMember):
+		msg = "The requester is no longer a member of the group; the request cannot be approved."
+	}
+	console.DisplayBlock(console.ContentBlock{
+		Title:     title,
+		BlockType: "error",
+		Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{msg}}},
+	})
+	return err
+}
+
+// loadRequest fetches a request by ID with its group, requester and decider.
+func loadRequest(db *gorm.DB, title, idStr string) (*models.AccessRequest, error) {
+	id, err := uuid.Parse(strings.TrimSpace(idStr))
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     title,
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid ID", Body: []string{"Invalid access request ID format."}}},
+		})
+		return nil, err
+	}
+	var req models.AccessRequest
+	if err := db.Preload("Group").Preload("Requester").Preload("Decider").Where("id = ?", id).First(&req).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     title,
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{"Access request not found."}}},
+		})
+		return nil, err
+	}
+	return &req, nil
+}
+
+// canView reports whether the user may see a request: requesters see their
+// own, deciders see those of their groups.
+func canView(db *gorm.DB, u *models.User, r *models.AccessRequest) bool {
+	return r.RequesterID == u.ID || u.CanDo(db, "accessRequestApprove", r.Group.Name)
+}
+
+func isValidStatusFilter(s string) bool {
+	switch s {
+	case models.AccessRequestPending, models.AccessRequestApproved, models.AccessRequestDenied, "all":
+		return true
+	}
+	return false
+}
+
+// statusLabel renders the status, marking approved requests whose grant has
+// since expired.
+func statusLabel(r *models.AccessRequest) string {
+	if r.Status == models.AccessRequestApproved && r.GrantExpiresAt != nil && r.GrantExpiresAt.Before(time.Now()) {
+		return "approved (expired)"
+	}
+	return r.Status
+}
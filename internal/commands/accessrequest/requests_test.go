@@ -0,0 +1,251 @@
+package accessrequest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"goBastion/internal/models"
+)
+
+func TestRequest_CreatesPendingRequestAndEvent(t *testing.T) {
+	db := newTestDB(t)
+	alice := newRegularUser(t, db, "alice")
+	g := newGroup(t, db, "infra")
+	addMember(t, db, alice, g, models.GroupRoleMember)
+
+	err := Request(db, alice, []string{
+		"--group", "infra", "--host", "10.0.0.5", "--user", "root",
+		"--duration", "2h", "--reason", "incident 42",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var req models.AccessRequest
+	if err := db.Where("requester_id = ?", alice.ID).First(&req).Error; err != nil {
+		t.Fatalf("request not stored: %v", err)
+	}
+	if req.Status != models.AccessRequestPending || req.Duration() != 2*time.Hour || req.Port != 22 {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+	var events []models.AccessRequestEvent
+	db.Where("request_id = ?", req.ID).Find(&events)
+	if len(events) != 1 || events[0].Action != models.AccessRequestActionRequested {
+		t.Fatalf("expected one 'requested' event, got %+v", events)
+	}
+
+	// A second identical pending request is refused.
+	err = Request(db, alice, []string{
+		"--group", "infra", "--host", "10.0.0.5", "--user", "root",
+		"--duration", "1h", "--reason", "again",
+	})
+	if err == nil {
+		t.Fatal("expected duplicate pending request to be refused")
+	}
+}
+
+func TestRequest_RefusesInvalidRemoteUser(t *testing.T) {
+	db := newTestDB(t)
+	alice := newRegularUser(t, db, "alice")
+	g := newGroup(t, db, "infra")
+	addMember(t, db, alice, g, models.GroupRoleMember)
+
+	for _, remoteUser := range []string{"*", "root;id", "-oProxyCommand"} {
+		if err := Request(db, alice, []string{
+			"--group", "infra", "--host", "10.0.0.5", "--user", remoteUser,
+			"--duration", "1h", "--reason", "x",
+		}); err == nil {
+			t.Fatalf("expected remote user %q to be refused", remoteUser)
+		}
+	}
+	var count int64
+	db.Model(&models.AccessRequest{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("invalid requests stored: %d", count)
+	}
+}
+
+func TestRequest_RefusesNonMemberAndExcessiveDuration(t *testing.T) {
+	db := newTestDB(t)
+	alice := newRegularUser(t, db, "alice")
+	g := newGroup(t, db, "infra")
+
+	if err := Request(db, alice, []string{
+		"--group", "infra", "--host", "10.0.0.5", "--user", "root",
+		"--duration", "1h", "--reason", "x",
+	}); err == nil {
+		t.Fatal("expected non-member request to be refused")
+	}
+
+	addMember(t, db, alice, g, models.GroupRoleMember)
+	if err := Request(db, alice, []string{
+		"--group", "infra", "--host", "10.0.0.5", "--user", "root",
+		"--duration", "720h", "--reason", "x",
+	}); err == nil {
+		t.Fatal("expected duration above max_duration to be refused")
+	}
+}
+
+func TestApprove_MemberGetsExpiringGroupAccess(t *testing.T) {
+	db := newTestDB(t)
+	alice := newRegularUser(t, db, "alice")
+	bob := newRegularUser(t, db, "bob")
+	g := newGroup(t, db, "infra")
+	addMember(t, db, alice, g, models.GroupRoleMember)
+	addMember(t, db, bob, g, models.GroupRoleGatekeeper)
+
+	if err := Request(db, alice, []string{
+		"--group", "infra", "--host", "db1.example", "--user", "postgres",
+		"--duration", "30m", "--reason", "migration",
+	}); err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	var req models.AccessRequest
+	db.First(&req)
+
+	if err := Approve(db, alice, []string{"--id", req.ID.String()}); err == nil {
+		t.Fatal("expected requester to be unable to approve their own request")
+	}
+
+	before := time.Now()
+	if err := Approve(db, bob, []string{"--id", req.ID.String(), "--comment", "ok"}); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+
+	var ga models.GroupAccess
+	if err := db.Where("group_id = ? AND server = ?", g.ID, "db1.example").First(&ga).Error; err != nil {
+		t.Fatalf("group access not created: %v", err)
+	}
+	if ga.ExpiresAt == nil || ga.ExpiresAt.Before(before.Add(29*time.Minute)) || ga.ExpiresAt.After(time.Now().Add(31*time.Minute)) {
+		t.Fatalf("unexpected expiry: %v", ga.ExpiresAt)
+	}
+	if ga.MemberID == nil || *ga.MemberID != alice.ID {
+		t.Fatalf("grant must be restricted to the requester: %+v", ga.MemberID)
+	}
+
+	db.First(&req, "id = ?", req.ID)
+	if req.Status != models.AccessRequestApproved || req.GrantType != "group" || req.GrantID == nil || *req.GrantID != ga.ID {
+		t.Fatalf("request not updated: %+v", req)
+	}
+	if req.DeciderID == nil || *req.DeciderID != bob.ID || req.DecisionComment != "ok" {
+		t.Fatalf("decision not recorded: %+v", req)
+	}
+
+	var events []models.AccessRequestEvent
+	db.Where("request_id = ?", req.ID).Order("created_at asc").Find(&events)
+	if len(events) != 2 || events[1].Action != models.AccessRequestActionApproved || events[1].ActorID != bob.ID {
+		t.Fatalf("unexpected audit trail: %+v", events)
+	}
+
+	if err := Deny(db, bob, []string{"--id", req.ID.String()}); !errors.Is(err, errAlreadyDecided) {
+		t.Fatalf("expected already-decided error, got %v", err)
+	}
+}
+
+func TestApprove_GuestGetsExpiringGuestGrant(t *testing.T) {
+	db := newTestDB(t)
+	guest := newRegularUser(t, db, "guest")
+	owner := newRegularUser(t, db, "owner")
+	g := newGroup(t, db, "infra")
+	addMember(t, db, guest, g, models.GroupRoleGuest)
+	addMember(t, db, owner, g, models.GroupRoleOwner)
+
+	if err := Request(db, guest, []string{
+		"--group", "infra", "--host", "web1", "--user", "deploy",
+		"--duration", "1h", "--reason", "deploy",
+	}); err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	var req models.AccessRequest
+	db.First(&req)
+
+	if err := Approve(db, owner, []string{"--id", req.ID.String()}); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+
+	var gga models.GroupGuestAccess
+	if err := db.Where("group_id = ? AND user_id = ?", g.ID, guest.ID).First(&gga).Error; err != nil {
+		t.Fatalf("guest grant not created: %v", err)
+	}
+	if gga.ExpiresAt == nil || gga.Server != "web1" || gga.Username != "deploy" {
+		t.Fatalf("unexpected guest grant: %+v", gga)
+	}
+	var count int64
+	db.Model(&models.GroupAccess{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("expected no group-wide access for a guest request, got %d", count)
+	}
+}
+
+func TestDeny_PlainMemberCannotDecide(t *testing.T) {
+	db := newTestDB(t)
+	alice := newRegularUser(t, db, "alice")
+	carol := newRegularUser(t, db, "carol")
+	dave := newRegularUser(t, db, "dave")
+	g := newGroup(t, db, "infra")
+	addMember(t, db, alice, g, models.GroupRoleMember)
+	addMember(t, db, carol, g, models.GroupRoleMember)
+	addMember(t, db, dave, g, models.GroupRoleACLKeeper)
+
+	if err := Request(db, alice, []string{
+		"--group", "infra", "--host", "web1", "--user", "root",
+		"--duration", "1h", "--reason", "x",
+	}); err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	var req models.AccessRequest
+	db.First(&req)
+
+	if err := Deny(db, carol, []string{"--id", req.ID.String()}); err == nil {
+		t.Fatal("expected plain member to be unable to deny")
+	}
+	if err := Deny(db, dave, []string{"--id", req.ID.String(), "--comment", "not now"}); err != nil {
+		t.Fatalf("deny: %v", err)
+	}
+	db.First(&req, "id = ?", req.ID)
+	if req.Status != models.AccessRequestDenied || req.GrantID != nil {
+		t.Fatalf("unexpected request after deny: %+v", req)
+	}
+}
+
+func TestRequest_ExpiredMembershipIsRefused(t *testing.T) {
+	db := newTestDB(t)
+	alice := newRegularUser(t, db, "alice")
+	bob := newRegularUser(t, db, "bob")
+	g := newGroup(t, db, "infra")
+	addMember(t, db, alice, g, models.GroupRoleMember)
+	addMember(t, db, bob, g, models.GroupRoleGatekeeper)
+
+	args := []string{
+		"--group", "infra", "--host", "10.0.0.5", "--user", "root",
+		"--duration", "1h", "--reason", "x",
+	}
+	if err := Request(db, alice, args); err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	var req models.AccessRequest
+	db.First(&req)
+
+	past := time.Now().Add(-time.Minute)
+	if err := db.Model(&models.UserGroup{}).
+		Where("user_id = ? AND group_id = ?", alice.ID, g.ID).
+		Update("expires_at", past).Error; err != nil {
+		t.Fatalf("expire membership: %v", err)
+	}
+	models.InvalidateGroupsCache(alice.ID)
+
+	if err := Approve(db, bob, []string{"--id", req.ID.String()}); !errors.Is(err, errRequesterNotMember) {
+		t.Fatalf("expected requester-not-member error, got %v", err)
+	}
+	var count int64
+	db.Model(&models.GroupAccess{}).Where("group_id = ?", g.ID).Count(&count)
+	if count != 0 {
+		t.Fatalf("expired member's request was granted: %d accesses", count)
+	}
+
+	db.Model(&models.AccessRequest{}).Where("id = ?", req.ID).Update("status", models.AccessRequestDenied)
+	if err := Request(db, alice, args); err == nil {
+		t.Fatal("expected request from an expired member to be refused")
+	}
+}
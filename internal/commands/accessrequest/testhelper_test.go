@@ -0,0 +1,53 @@
+package accessrequest
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"goBastion/internal/models"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open test DB: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.User{}, &models.Group{}, &models.UserGroup{},
+		&models.GroupAccess{}, &models.GroupGuestAccess{},
+		&models.AccessRequest{}, &models.AccessRequestEvent{},
+		&models.CustomRole{}, &models.CustomRoleAssignment{},
+	); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func newRegularUser(t *testing.T, db *gorm.DB, username string) *models.User {
+	t.Helper()
+	u := models.User{Username: username, Role: models.RoleUser, Enabled: true}
+	if err := db.Create(&u).Error; err != nil {
+		t.Fatalf("create regular user: %v", err)
+	}
+	return &u
+}
+
+func newGroup(t *testing.T, db *gorm.DB, name string) *models.Group {
+	t.Helper()
+	g := models.Group{Name: name}
+	if err := db.Create(&g).Error; err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	return &g
+}
+
+func addMember(t *testing.T, db *gorm.DB, u *models.User, g *models.Group, role string) {
+	t.Helper()
+	if err := db.Create(&models.UserGroup{UserID: u.ID, GroupID: g.ID, Role: role}).Error; err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	models.InvalidateGroupsCache(u.ID)
+}
@@ -9,6 +9,7 @@ import (
 
 	"goBastion/internal/models"
 	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/schedule"
 	"goBastion/internal/utils/validation"
 
 	"gorm.io/gorm"
@@ -17,16 +18,19 @@ import (
 // AddAccess adds a personal SSH access entry for a user.
 func AddAccess(db *gorm.DB, currentUser *models.User, args []string) error {
 	fs := flag.NewFlagSet("accountAddAccess", flag.ContinueOnError)
-	var targetUser, server, username, comment, allowedFrom, protocol string
+	var targetUser, server, username, comment, allowedFrom, scheduleSpec, protocol string
 	var port int64
 	var ttlDays int
+	var starts string
 	fs.StringVar(&targetUser, "user", "", "Target username")
 	fs.StringVar(&server, "server", "", "SSH Server")
 	fs.StringVar(&username, "username", "", "SSH Username")
 	fs.StringVar(&comment, "comment", "", "Comment")
 	fs.Int64Var(&port, "port", 22, "SSH Port")
 	fs.StringVar(&allowedFrom, "from", "", "Allowed source CIDRs (comma-separated)")
+	fs.StringVar(&scheduleSpec, "schedule", "", "Access window: <days> <HH:MM-HH:MM> [timezone], e.g. \"Mon-Fri 08:00-19:00 Europe/Paris\"")
 	fs.IntVar(&ttlDays, "ttl", 0, "Access expiry in days (0 = never)")
+	fs.StringVar(&starts, "starts", "", "Access start time (RFC 3339, \"YYYY-MM-DD HH:MM\" or YYYY-MM-DD); pending until then")
 	fs.StringVar(&protocol, "protocol", "ssh", "Protocol restriction: ssh (all), scpupload, scpdownload, sftp, rsync")
 	var flagOutput bytes.Buffer
 	fs.SetOutput(&flagOutput)
@@ -35,7 +39,7 @@ func AddAccess(db *gorm.DB, currentUser *models.User, args []string) error {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Add Personal Access",
 			BlockType: "error",
-			Sections:  []console.SectionContent{{SubTitle: "Usage Error", Body: []string{"Usage: accountAddAccess --user <username> --server <host> --username <user> --port <port> [--comment <comment>] [--from <CIDRs>] [--ttl <days>] [--protocol ssh|scpupload|scpdownload|sftp|rsync]"}}},
+			Sections:  []console.SectionContent{{SubTitle: "Usage Error", Body: []string{"Usage: accountAddAccess --user <username> --server <host> --username <user> --port <port> [--comment <comment>] [--from <CIDRs>] [--schedule <spec>] [--ttl <days>] [--starts <time>] [--protocol ssh|scpupload|scpdownload|sftp|rsync]"}}},
 		})
 		return err
 	}
@@ -44,7 +48,7 @@ func AddAccess(db *gorm.DB, currentUser *models.User, args []string) error {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Add Personal Access",
 			BlockType: "error",
-			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: accountAddAccess --user <username> --server <host> --username <user> --port <port> [--comment <comment>] [--from <CIDRs>] [--ttl <days>] [--protocol ssh|scpupload|scpdownload|sftp|rsync]"}}},
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: accountAddAccess --user <username> --server <host> --username <user> --port <port> [--comment <comment>] [--from <CIDRs>] [--schedule <spec>] [--ttl <days>] [--starts <time>] [--protocol ssh|scpupload|scpdownload|sftp|rsync]"}}},
 		})
 		return fmt.Errorf("missing required arguments")
 	}
@@ -58,6 +62,14 @@ func AddAccess(db *gorm.DB, currentUser *models.User, args []string) error {
 		return fmt.Errorf("access denied for %s", currentUser.Username)
 	}
 
+	if !validation.IsValidServerPattern(server) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Personal Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Server", Body: []string{"Server must be a hostname, IP, CIDR block (10.20.0.0/16) or hostname glob (*.db.example)."}}},
+		})
+		return fmt.Errorf("invalid server: %s", server)
+	}
 	if !validation.IsValidProtocol(protocol) {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Add Personal Access",
@@ -82,6 +94,27 @@ func AddAccess(db *gorm.DB, currentUser *models.User, args []string) error {
 		})
 		return fmt.Errorf("invalid CIDRs: %s", allowedFrom)
 	}
+	startsAt, err := validation.ParseStartsAt(starts, time.Now())
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Personal Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Start", Body: []string{err.Error()}}},
+		})
+		return fmt.Errorf("invalid start time: %w", err)
+	}
+	if scheduleSpec != "" {
+		sched, err := schedule.Parse(scheduleSpec)
+		if err != nil {
+			console.DisplayBlock(console.ContentBlock{
+				Title:     "Add Personal Access",
+				BlockType: "error",
+				Sections:  []console.SectionContent{{SubTitle: "Invalid Schedule", Body: []string{err.Error(), "Expected <days> <HH:MM-HH:MM> [timezone], e.g. \"Mon-Fri 08:00-19:00 Europe/Paris\""}}},
+			})
+			return fmt.Errorf("invalid schedule: %w", err)
+		}
+		scheduleSpec = sched.String()
+	}
 
 	var user models.User
 	if err := db.Where("username = ?", targetUser).First(&user).Error; err != nil {
@@ -93,9 +126,14 @@ func AddAccess(db *gorm.DB, currentUser *models.User, args []string) error {
 		return err
 	}
 
-	access := models.SelfAccess{UserID: user.ID, Server: server, Username: username, Port: port, Comment: comment, AllowedFrom: allowedFrom, Protocol: protocol}
+	access := models.SelfAccess{UserID: user.ID, Server: server, Username: username, Port: port, Comment: comment, AllowedFrom: allowedFrom, Schedule: scheduleSpec, Protocol: protocol, StartsAt: startsAt}
 	if ttlDays > 0 {
-		t := time.Now().AddDate(0, 0, ttlDays)
+		// The TTL of a scheduled access runs from its start.
+		from := time.Now()
+		if startsAt != nil {
+			from = *startsAt
+		}
+		t := from.AddDate(0, 0, ttlDays)
 		access.ExpiresAt = &t
 	}
 	if err := db.Create(&access).Error; err != nil {
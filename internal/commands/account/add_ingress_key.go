@@ -1,34 +1,204 @@
 package account
 
 import (
-	"crypto/sha256"
-	"encoding/base64"
+	"bytes"
 	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
 	"strings"
+	"time"
 
+	"goBastion/internal/config"
 	"goBastion/internal/models"
+	"goBastion/internal/osadapter"
+	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/keypolicy"
+	"goBastion/internal/utils/revocation"
 	"goBastion/internal/utils/sshkey"
+	gosync "goBastion/internal/utils/sync"
 	"goBastion/internal/utils/validation"
 
 	"golang.org/x/crypto/ssh"
 	"gorm.io/gorm"
 )
 
+// IngressKeyOptions are the optional attributes of a new ingress key.
+type IngressKeyOptions struct {
+	ExpiresAt         *time.Time
+	PIVAttested       bool
+	AllowedFrom       string
+	NoPortForwarding  bool
+	NoAgentForwarding bool
+}
+
+// IngressKeyFlagsUsage is the usage of the flags of IngressKeyFlags.
+const IngressKeyFlagsUsage = "[--expires <days>] [--from <CIDRs>] [--no-port-forwarding] [--no-agent-forwarding]"
+
+// IngressKeyFlags are the key option flags shared by the add-key commands.
+type IngressKeyFlags struct {
+	expiresDays       int
+	allowedFrom       string
+	noPortForwarding  bool
+	noAgentForwarding bool
+}
+
+// RegisterIngressKeyFlags adds the key option flags to fs.
+func RegisterIngressKeyFlags(fs *flag.FlagSet) *IngressKeyFlags {
+	f := &IngressKeyFlags{}
+	fs.IntVar(&f.expiresDays, "expires", 0, "Key expiry in days (0 = never)")
+	fs.StringVar(&f.allowedFrom, "from", "", "Source CIDRs the key may log in from (comma-separated, empty = anywhere)")
+	fs.BoolVar(&f.noPortForwarding, "no-port-forwarding", false, "Forbid port forwarding with this key")
+	fs.BoolVar(&f.noAgentForwarding, "no-agent-forwarding", false, "Forbid agent forwarding with this key")
+	return f
+}
+
+// Options validates the flags and returns the matching key options.
+func (f *IngressKeyFlags) Options(now time.Time) (IngressKeyOptions, error) {
+	opts := IngressKeyOptions{NoPortForwarding: f.noPortForwarding, NoAgentForwarding: f.noAgentForwarding}
+	if f.expiresDays < 0 {
+		return opts, fmt.Errorf("--expires must be zero (never) or a positive number of days")
+	}
+	if f.expiresDays > 0 {
+		expiresAt := now.AddDate(0, 0, f.expiresDays)
+		opts.ExpiresAt = &expiresAt
+	}
+	from, err := validation.NormalizeCIDRs(f.allowedFrom)
+	if err != nil {
+		return opts, fmt.Errorf("--from %w", err)
+	}
+	opts.AllowedFrom = from
+	return opts, nil
+}
+
+// AddIngressKey adds an ingress SSH key to another account, within the
+// ingress key policy.
+func AddIngressKey(db *gorm.DB, currentUser *models.User, args []string) error {
+	fs := flag.NewFlagSet("accountAddIngressKey", flag.ContinueOnError)
+	var username, pubKey string
+	fs.StringVar(&username, "user", "", "Username to add the key to")
+	fs.StringVar(&pubKey, "key", "", "SSH public key")
+	keyFlags := RegisterIngressKeyFlags(fs)
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	if err := fs.Parse(args); err != nil || strings.TrimSpace(username) == "" || strings.TrimSpace(pubKey) == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Account Ingress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: accountAddIngressKey --user <username> --key <ssh_public_key> " + IngressKeyFlagsUsage}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	if !currentUser.CanDo(db, "accountAddIngressKey", username) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Account Ingress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You do not have permission to add ingress keys to this account."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	var user models.User
+	if err := db.Where("username = ?", username).First(&user).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Account Ingress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("User '%s' not found. Check spelling or run accountList.", username)}}},
+		})
+		return err
+	}
+	// A key on an admin account is a login as that admin: only admins may add one.
+	if user.IsAdmin() && !currentUser.IsAdmin() {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Account Ingress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"Only admins can add ingress keys to an admin account."}}},
+		})
+		return fmt.Errorf("access denied for %s: %s is an admin", currentUser.Username, user.Username)
+	}
+
+	opts, err := keyFlags.Options(time.Now())
+	if err == nil {
+		err = CreateDBIngressKeyWithPolicy(db, &user, pubKey, opts)
+	}
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Account Ingress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{err.Error()}}},
+		})
+		return err
+	}
+
+	if err := gosync.New(db, osadapter.NewLinuxAdapter(), *slog.Default()).IngressKeyFromDB(user); err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Account Ingress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Key added, but authorized_keys could not be synced."}}},
+		})
+		return err
+	}
+	slog.Info("account_ingress_key_added", slog.String("user", currentUser.Username), slog.String("account", user.Username))
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Add Account Ingress Key",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Success", Body: []string{fmt.Sprintf("Ingress key added to '%s'.", user.Username)}}},
+	})
+	return nil
+}
+
 // CreateDBIngressKey validates and persists an SSH ingress public key for a user.
 func CreateDBIngressKey(db *gorm.DB, user *models.User, key string) error {
+	return createDBIngressKey(db, user, key, IngressKeyOptions{})
+}
+
+// CreateDBIngressKeyWithPolicy checks key and opts against the ingress key
+// policy for user, then persists the key like CreateDBIngressKey.
+func CreateDBIngressKeyWithPolicy(db *gorm.DB, user *models.User, key string, opts IngressKeyOptions) error {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(key)))
+	if err != nil || pub == nil {
+		return fmt.Errorf("invalid SSH key: %s", err)
+	}
+	policy := config.Get().IngressKeyPolicy
+	candidate := keypolicy.Key{
+		Type:        pub.Type(),
+		Size:        sshkey.GetKeySize(pub),
+		ExpiresAt:   opts.ExpiresAt,
+		PIVAttested: opts.PIVAttested,
+		CreatedAt:   time.Now(),
+	}
+	if err := keypolicy.Check(policy, candidate, user); err != nil {
+		return err
+	}
+	if err := keypolicy.CheckCount(db, policy, user); err != nil {
+		return err
+	}
+	return createDBIngressKey(db, user, key, opts)
+}
+
+func createDBIngressKey(db *gorm.DB, user *models.User, key string, opts IngressKeyOptions) error {
 	key = strings.TrimSpace(key)
 	if key == "" {
 		return fmt.Errorf("public SSH key cannot be empty")
 	}
 
-	pub, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+	pub, comment, options, _, err := ssh.ParseAuthorizedKey([]byte(key))
 	if err != nil || pub == nil {
 		return fmt.Errorf("invalid SSH key: %s", err)
 	}
+	if len(options) > 0 {
+		return fmt.Errorf("key options are not accepted in the key text, use the command flags (%s)", IngressKeyFlagsUsage)
+	}
 
-	sha256Fingerprint := sha256.Sum256(pub.Marshal())
-	fingerprint := base64.StdEncoding.EncodeToString(sha256Fingerprint[:])
+	fingerprint := revocation.Fingerprint(pub)
+	if err := revocation.Check(db, fingerprint); err != nil {
+		return err
+	}
 	keySize := sshkey.GetKeySize(pub)
 
 	if pub.Type() == "ssh-rsa" && keySize < 2048 {
@@ -45,12 +215,17 @@ func CreateDBIngressKey(db *gorm.DB, user *models.User, key string) error {
 	}
 
 	ingressKey := models.IngressKey{
-		UserID:      user.ID,
-		Type:        pub.Type(),
-		Key:         key,
-		Fingerprint: fingerprint,
-		Size:        keySize,
-		Comment:     comment,
+		UserID:            user.ID,
+		Type:              pub.Type(),
+		Key:               key,
+		Fingerprint:       fingerprint,
+		Size:              keySize,
+		Comment:           comment,
+		ExpiresAt:         opts.ExpiresAt,
+		PIVAttested:       opts.PIVAttested,
+		AllowedFrom:       opts.AllowedFrom,
+		NoPortForwarding:  opts.NoPortForwarding,
+		NoAgentForwarding: opts.NoAgentForwarding,
 	}
 	if err = db.Create(&ingressKey).Error; err != nil {
 		return fmt.Errorf("error creating ingress key in DB: %w", err)
@@ -1,9 +1,17 @@
 package account
 
 import (
+	"errors"
+	"flag"
+	"strings"
 	"testing"
+	"time"
 
+	"golang.org/x/crypto/ssh"
+
+	"goBastion/internal/config"
 	"goBastion/internal/models"
+	"goBastion/internal/utils/revocation"
 )
 
 func TestCreateDBIngressKey_Valid(t *testing.T) {
@@ -23,6 +31,23 @@ func TestCreateDBIngressKey_Valid(t *testing.T) {
 	}
 }
 
+func TestCreateDBIngressKey_Revoked(t *testing.T) {
+	db := newTestDB(t)
+	user := newRegularUser(t, db, "alice")
+	pub, _, _, _, _ := ssh.ParseAuthorizedKey([]byte(testPubKey))
+	db.Create(&models.RevokedKey{Fingerprint: revocation.Fingerprint(pub), Reason: "leaked", AddedByID: user.ID})
+
+	err := CreateDBIngressKey(db, user, testPubKey)
+	if !errors.Is(err, revocation.ErrRevoked) {
+		t.Fatalf("expected the revoked key to be refused, got %v", err)
+	}
+	var count int64
+	db.Model(&models.IngressKey{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("expected no key stored, got %d", count)
+	}
+}
+
 func TestCreateDBIngressKey_Empty(t *testing.T) {
 	db := newTestDB(t)
 	user := newRegularUser(t, db, "alice")
@@ -55,3 +80,102 @@ func TestCreateDBIngressKey_Duplicate(t *testing.T) {
 		t.Fatal("expected error on duplicate key, got nil")
 	}
 }
+
+func TestCreateDBIngressKeyWithPolicy(t *testing.T) {
+	db := newTestDB(t)
+	user := newRegularUser(t, db, "alice")
+	admin := newAdminUser(t, db, "root-admin")
+
+	config.ResetForTesting()
+	t.Cleanup(config.ResetForTesting)
+	policy := &config.Load().IngressKeyPolicy
+	policy.RequireExpiry = true
+	policy.MaxKeys = 1
+	policy.AdminHardwareKeys = true
+
+	if err := CreateDBIngressKeyWithPolicy(db, user, testPubKey, IngressKeyOptions{}); err == nil || !strings.Contains(err.Error(), "expiry") {
+		t.Fatalf("expected the missing expiry to be refused, got %v", err)
+	}
+	expiresAt := time.Now().AddDate(0, 0, 30)
+	if err := CreateDBIngressKeyWithPolicy(db, user, testPubKey, IngressKeyOptions{ExpiresAt: &expiresAt}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var key models.IngressKey
+	if err := db.Where("user_id = ?", user.ID).First(&key).Error; err != nil || key.ExpiresAt == nil {
+		t.Fatalf("expected the key to be stored with its expiry, got %+v (%v)", key, err)
+	}
+
+	second := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBxvzoiE2D0oCqPIi1wLfN0/5ZUa3cdTsk3JqsuyXWm0 second"
+	if err := CreateDBIngressKeyWithPolicy(db, user, second, IngressKeyOptions{ExpiresAt: &expiresAt}); err == nil || !strings.Contains(err.Error(), "at most 1") {
+		t.Fatalf("expected max_keys to be enforced, got %v", err)
+	}
+
+	if err := CreateDBIngressKeyWithPolicy(db, admin, testPubKey, IngressKeyOptions{ExpiresAt: &expiresAt}); err == nil || !strings.Contains(err.Error(), "PIV-attested") {
+		t.Fatalf("expected an admin software key to be refused, got %v", err)
+	}
+	if err := CreateDBIngressKeyWithPolicy(db, admin, testPubKey, IngressKeyOptions{ExpiresAt: &expiresAt, PIVAttested: true}); err != nil {
+		t.Fatalf("expected a PIV-attested admin key to be accepted: %v", err)
+	}
+}
+
+func TestCreateDBIngressKeyWithPolicy_Options(t *testing.T) {
+	db := newTestDB(t)
+	user := newRegularUser(t, db, "alice")
+
+	if err := CreateDBIngressKey(db, user, `from="10.0.0.0/8" `+testPubKey); err == nil {
+		t.Fatal("expected options in the key text to be refused")
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	keyFlags := RegisterIngressKeyFlags(fs)
+	if err := fs.Parse([]string{"--from", "10.0.0.0/8, 192.0.2.7/32", "--no-agent-forwarding"}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	opts, err := keyFlags.Options(time.Now())
+	if err != nil {
+		t.Fatalf("Options: %v", err)
+	}
+	if err := CreateDBIngressKeyWithPolicy(db, user, testPubKey, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var key models.IngressKey
+	if err := db.Where("user_id = ?", user.ID).First(&key).Error; err != nil {
+		t.Fatalf("key not found in DB: %v", err)
+	}
+	if key.AuthorizedKeysOptions() != `from="10.0.0.0/8,192.0.2.7/32",no-agent-forwarding` {
+		t.Fatalf("unexpected options %q", key.AuthorizedKeysOptions())
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	keyFlags = RegisterIngressKeyFlags(fs)
+	_ = fs.Parse([]string{"--from", "not-a-cidr"})
+	if _, err := keyFlags.Options(time.Now()); err == nil {
+		t.Fatal("expected an invalid --from to be refused")
+	}
+}
+
+func TestAddIngressKey_CustomRoleCannotAddKeyToAdmin(t *testing.T) {
+	db := newTestDB(t)
+	admin := newAdminUser(t, db, "root-admin")
+	helper := newRegularUser(t, db, "helper")
+
+	// A role stored before accountAddIngressKey became non-delegable.
+	role := models.CustomRole{Name: "keys", Permissions: "accountAddIngressKey"}
+	if err := db.Create(&role).Error; err != nil {
+		t.Fatalf("create role: %v", err)
+	}
+	if err := db.Create(&models.CustomRoleAssignment{RoleID: role.ID, UserID: helper.ID, GrantedByID: admin.ID}).Error; err != nil {
+		t.Fatalf("assign role: %v", err)
+	}
+	models.InvalidateCustomRolesCache()
+	t.Cleanup(models.InvalidateCustomRolesCache)
+
+	if err := AddIngressKey(db, helper, []string{"--user", "root-admin", "--key", testPubKey}); err == nil {
+		t.Fatal("expected a custom role holder to be refused")
+	}
+	var count int64
+	db.Model(&models.IngressKey{}).Where("user_id = ?", admin.ID).Count(&count)
+	if count != 0 {
+		t.Fatalf("key added to the admin account: %d keys", count)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"log/slog"
 	"os"
 	"strings"
+	"time"
 
 	"golang.org/x/crypto/ssh"
 	"gorm.io/gorm"
@@ -28,6 +29,7 @@ func Create(db *gorm.DB, adapter osadapter.SystemAdapter, currentUser *models.Us
 	fs.StringVar(&username, "user", "", "Username to create")
 	fs.BoolVar(&oshOnly, "osh-only", false, "Restrict this account to -osh command execution only")
 	fs.BoolVar(&superOwner, "superowner", false, "Grant implicit owner rights on all groups")
+	keyFlags := RegisterIngressKeyFlags(fs)
 	var flagOut strings.Builder
 	fs.SetOutput(&flagOut)
 
@@ -35,7 +37,7 @@ func Create(db *gorm.DB, adapter osadapter.SystemAdapter, currentUser *models.Us
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Account Create",
 			BlockType: "error",
-			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: accountCreate --user <username> [--osh-only] [--superowner]"}}},
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: accountCreate --user <username> [--osh-only] [--superowner] " + IngressKeyFlagsUsage}}},
 		})
 		if err != nil {
 			return err
@@ -52,6 +54,16 @@ func Create(db *gorm.DB, adapter osadapter.SystemAdapter, currentUser *models.Us
 		return fmt.Errorf("access denied for %s", currentUser.Username)
 	}
 
+	opts, err := keyFlags.Options(time.Now())
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Account Create",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Key Options", Body: []string{err.Error()}}},
+		})
+		return err
+	}
+
 	reader := bufio.NewReader(os.Stdin)
 	fmt.Print("Enter the complete public SSH key: ")
 	pubKey, err := reader.ReadString('\n')
@@ -72,7 +84,7 @@ func Create(db *gorm.DB, adapter osadapter.SystemAdapter, currentUser *models.Us
 		return fmt.Errorf("invalid SSH key: %w", err)
 	}
 
-	if err = CreateUser(db, adapter, username, pubKey); err != nil {
+	if err = CreateUser(db, adapter, username, pubKey, opts); err != nil {
 		title := "Error"
 		if strings.Contains(err.Error(), "exists") {
 			title = "User Exists"
@@ -109,8 +121,20 @@ func Create(db *gorm.DB, adapter osadapter.SystemAdapter, currentUser *models.Us
 	return nil
 }
 
-// CreateUser creates the DB record, registers the ingress key and creates the OS user.
-func CreateUser(db *gorm.DB, adapter osadapter.SystemAdapter, username string, pubKey string) error {
+// CreateUser creates the DB record, registers the ingress key within the
+// ingress key policy and creates the OS user.
+func CreateUser(db *gorm.DB, adapter osadapter.SystemAdapter, username string, pubKey string, opts IngressKeyOptions) error {
+	return createUser(db, adapter, username, models.RoleUser, pubKey, opts)
+}
+
+// CreateAdminUser is CreateUser for an account created with the admin role,
+// such as the first admin at bootstrap: the key is checked against the admin
+// rules of the ingress key policy.
+func CreateAdminUser(db *gorm.DB, adapter osadapter.SystemAdapter, username string, pubKey string, opts IngressKeyOptions) error {
+	return createUser(db, adapter, username, models.RoleAdmin, pubKey, opts)
+}
+
+func createUser(db *gorm.DB, adapter osadapter.SystemAdapter, username, role, pubKey string, opts IngressKeyOptions) error {
 	username = strings.ToLower(strings.TrimSpace(username))
 	if !validation.IsValidUsername(username) {
 		return fmt.Errorf("invalid username: %s", username)
@@ -133,7 +157,12 @@ func CreateUser(db *gorm.DB, adapter osadapter.SystemAdapter, username string, p
 		if err != nil {
 			return err
 		}
-		if err = CreateDBIngressKey(tx, newUser, pubKey); err != nil {
+		if role != newUser.Role {
+			if err = tx.Model(newUser).Update("role", role).Error; err != nil {
+				return validation.WrapDBError(err, "error setting user role")
+			}
+		}
+		if err = CreateDBIngressKeyWithPolicy(tx, newUser, pubKey, opts); err != nil {
 			return err
 		}
 		return nil
@@ -2,8 +2,10 @@ package account
 
 import (
 	"errors"
+	"strings"
 	"testing"
 
+	"goBastion/internal/config"
 	"goBastion/internal/models"
 	"goBastion/internal/osadapter"
 )
@@ -52,7 +54,7 @@ func TestCreateUser_AdapterCalled(t *testing.T) {
 	mock := osadapter.NewMockAdapter()
 	// CreateUser may fail on IngressKeyFromDB (filesystem ops on /home), but
 	// mock.CreateUser is called before the filesystem step, so CreatedUsers is populated.
-	_ = CreateUser(db, mock, "alice", testPubKey)
+	_ = CreateUser(db, mock, "alice", testPubKey, IngressKeyOptions{})
 	found := false
 	for _, u := range mock.CreatedUsers {
 		if u == "alice" {
@@ -69,7 +71,7 @@ func TestCreateUser_AdapterError(t *testing.T) {
 	db := newTestDB(t)
 	mock := osadapter.NewMockAdapter()
 	mock.ErrCreateUser = errors.New("fail")
-	err := CreateUser(db, mock, "alice", testPubKey)
+	err := CreateUser(db, mock, "alice", testPubKey, IngressKeyOptions{})
 	if err == nil {
 		t.Fatal("expected error from adapter, got nil")
 	}
@@ -83,3 +85,22 @@ func TestCreate_MissingArgs(t *testing.T) {
 		t.Fatal("expected missing required arguments error")
 	}
 }
+
+func TestCreateUser_EnforcesIngressKeyPolicy(t *testing.T) {
+	db := newTestDB(t)
+	mock := osadapter.NewMockAdapter()
+
+	config.ResetForTesting()
+	t.Cleanup(config.ResetForTesting)
+	config.Load().IngressKeyPolicy.RequireExpiry = true
+
+	err := CreateUser(db, mock, "alice", testPubKey, IngressKeyOptions{})
+	if err == nil || !strings.Contains(err.Error(), "expiry") {
+		t.Fatalf("expected the missing expiry to be refused, got %v", err)
+	}
+	var count int64
+	db.Model(&models.User{}).Where("username = ?", "alice").Count(&count)
+	if count != 0 || len(mock.CreatedUsers) != 0 {
+		t.Fatalf("account created despite the policy: %d rows, OS users %v", count, mock.CreatedUsers)
+	}
+}
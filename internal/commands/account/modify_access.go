@@ -0,0 +1,97 @@
+package account
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"time"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/accessedit"
+	"goBastion/internal/utils/console"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ModifyAccess changes fields of any account's personal SSH access entry in
+// place, keeping its ID and LastConnection.
+func ModifyAccess(db *gorm.DB, currentUser *models.User, args []string) error {
+	fs := flag.NewFlagSet("accountModifyAccess", flag.ContinueOnError)
+	var accessIDStr string
+	fs.StringVar(&accessIDStr, "access", "", "Access ID to modify")
+	fields := accessedit.Register(fs, false)
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	if err := fs.Parse(args); err != nil || accessIDStr == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Personal Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: accountModifyAccess --access <access_id> " + fields.Usage()}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	if !currentUser.CanDo(db, "accountModifyAccess", "") {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Personal Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You do not have permission to modify personal access."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	accessID, err := uuid.Parse(accessIDStr)
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Personal Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid ID", Body: []string{"Invalid access ID format."}}},
+		})
+		return err
+	}
+
+	// Non-admin holders of the right may only modify their own entries.
+	query := db.Where("id = ?", accessID)
+	if !currentUser.IsAdmin() {
+		query = query.Where("user_id = ?", currentUser.ID)
+	}
+	var access models.SelfAccess
+	if err := query.First(&access).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Personal Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{"Access entry not found."}}},
+		})
+		return err
+	}
+
+	updates, err := fields.Updates(access.StartsAt, access.ExpiresAt, time.Now())
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Personal Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Value", Body: []string{err.Error()}}},
+		})
+		return err
+	}
+	if err := db.Model(&access).Updates(updates).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Personal Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Failed to modify personal access."}}},
+		})
+		return err
+	}
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Modify Personal Access",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Success", Body: []string{"Personal access modified successfully."}}},
+	})
+	return nil
+}
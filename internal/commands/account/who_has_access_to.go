@@ -7,10 +7,12 @@ import (
 	"net"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"goBastion/internal/models"
 	"goBastion/internal/utils"
 	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/hostmatch"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -54,7 +56,7 @@ func WhoHasAccessTo(db *gorm.DB, currentUser *models.User, args []string) error
 	// then do Go-side filtering for CIDR containment.
 	var allSelfAccesses []models.SelfAccess
 	if err := db.Preload("User", "deleted_at IS NULL").
-		Where("deleted_at IS NULL AND server LIKE ?", "%"+server+"%").
+		Where("deleted_at IS NULL AND (server LIKE ? OR server LIKE '%/%' OR server LIKE '%*%')", "%"+server+"%").
 		Find(&allSelfAccesses).Error; err != nil {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Who Has Access",
@@ -67,7 +69,7 @@ func WhoHasAccessTo(db *gorm.DB, currentUser *models.User, args []string) error
 	// Filter group_accesses by server substring in SQL to reduce dataset.
 	var allGroupAccesses []models.GroupAccess
 	if err := db.Preload("Group", "deleted_at IS NULL").
-		Where("deleted_at IS NULL AND server LIKE ?", "%"+server+"%").
+		Where("deleted_at IS NULL AND (server LIKE ? OR server LIKE '%/%' OR server LIKE '%*%')", "%"+server+"%").
 		Find(&allGroupAccesses).Error; err != nil {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Who Has Access",
@@ -81,6 +83,7 @@ func WhoHasAccessTo(db *gorm.DB, currentUser *models.User, args []string) error
 	var allUserGroups []models.UserGroup
 	if err := db.Preload("User", "deleted_at IS NULL").
 		Where("deleted_at IS NULL").
+		Where(models.MembershipActiveClause, time.Now()).
 		Find(&allUserGroups).Error; err != nil {
 		allUserGroups = nil
 	}
@@ -101,6 +104,9 @@ func WhoHasAccessTo(db *gorm.DB, currentUser *models.User, args []string) error
 		}
 	}
 
+	// Nested groups: non-guest members of included groups inherit the
+	// accesses of the including group, shown with their inheritance path.
+	includedByGroup := make(map[uuid.UUID]map[uuid.UUID][]uuid.UUID)
 	for _, ga := range allGroupAccesses {
 		if !serverMatchesQuery(ga.Server, server) {
 			continue
@@ -108,7 +114,7 @@ func WhoHasAccessTo(db *gorm.DB, currentUser *models.User, args []string) error
 		userGroups := groupMemberships[ga.GroupID]
 
 		for _, ug := range userGroups {
-			if ug.User.ID == uuid.Nil {
+			if ug.User.ID == uuid.Nil || (ga.MemberID != nil && *ga.MemberID != ug.UserID) {
 				continue
 			}
 
@@ -121,6 +127,26 @@ func WhoHasAccessTo(db *gorm.DB, currentUser *models.User, args []string) error
 				ga.Server,
 			)
 		}
+
+		included, ok := includedByGroup[ga.GroupID]
+		if !ok {
+			included, _ = models.IncludedGroups(db, ga.GroupID)
+			includedByGroup[ga.GroupID] = included
+		}
+		for childID, path := range included {
+			inheritance := models.GroupPathNames(db, reversedPath(path))
+			for _, ug := range groupMemberships[childID] {
+				if ug.User.ID == uuid.Nil || ug.Role == models.GroupRoleGuest || (ga.MemberID != nil && *ga.MemberID != ug.UserID) {
+					continue
+				}
+				_, _ = fmt.Fprintf(w, "Group\t%s\t%s\t%-12s\t%s\n",
+					inheritance,
+					ug.User.Username,
+					utils.RoleColor(ug),
+					ga.Server,
+				)
+			}
+		}
 	}
 
 	_ = w.Flush()
@@ -136,15 +162,29 @@ func WhoHasAccessTo(db *gorm.DB, currentUser *models.User, args []string) error
 	return nil
 }
 
+// reversedPath turns a parent-to-child inclusion path into child-to-parent order.
+func reversedPath(path []uuid.UUID) []uuid.UUID {
+	out := make([]uuid.UUID, len(path))
+	for i, id := range path {
+		out[len(path)-1-i] = id
+	}
+	return out
+}
+
 // serverMatchesQuery returns true if the stored server string matches the query.
-// Supports exact match, substring match, and CIDR containment:
+// Supports exact match, substring match, CIDR containment and hostname globs:
 // - If query is an IP and storedServer is a CIDR, checks if the IP is in the CIDR.
 // - If storedServer is an IP/hostname and query is a CIDR, checks if the server IP is in the CIDR.
+// - If both are CIDRs, checks whether the networks overlap.
+// - If storedServer is a glob (*.db.example), checks whether it covers the query host.
 func serverMatchesQuery(storedServer, query string) bool {
 	// Exact or substring match
 	if strings.Contains(storedServer, query) || strings.Contains(query, storedServer) {
 		return true
 	}
+	if hostmatch.KindOf(storedServer) == hostmatch.KindGlob {
+		return hostmatch.Match(storedServer, query)
+	}
 	queryIP := net.ParseIP(query)
 	storedIP := net.ParseIP(storedServer)
 	// Query is an IP, stored is a CIDR
@@ -161,5 +201,11 @@ func serverMatchesQuery(storedServer, query string) bool {
 			return true
 		}
 	}
+	// Both are CIDRs: overlapping networks share at least one address.
+	_, storedCIDR, storedErr := net.ParseCIDR(storedServer)
+	_, queryCIDR, queryErr := net.ParseCIDR(query)
+	if storedErr == nil && queryErr == nil {
+		return storedCIDR.Contains(queryCIDR.IP) || queryCIDR.Contains(storedCIDR.IP)
+	}
 	return false
 }
@@ -0,0 +1,28 @@
+package account
+
+import "testing"
+
+func TestServerMatchesQuery(t *testing.T) {
+	tests := []struct {
+		stored string
+		query  string
+		want   bool
+	}{
+		{"web01.example.com", "web01", true},
+		{"web01.example.com", "db01", false},
+		{"10.20.0.0/16", "10.20.3.4", true},
+		{"10.20.0.0/16", "10.21.3.4", false},
+		{"10.20.3.4", "10.20.0.0/16", true},
+		{"10.20.0.0/16", "10.0.0.0/8", true},
+		{"10.20.0.0/16", "192.168.0.0/16", false},
+		{"*.db.prod.example", "pg1.db.prod.example", true},
+		{"*.db.prod.example", "pg1.db.staging.example", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.stored+"_"+tc.query, func(t *testing.T) {
+			if got := serverMatchesQuery(tc.stored, tc.query); got != tc.want {
+				t.Errorf("serverMatchesQuery(%q, %q) = %v, want %v", tc.stored, tc.query, got, tc.want)
+			}
+		})
+	}
+}
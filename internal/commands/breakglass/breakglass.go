@@ -0,0 +1,314 @@
+package breakglass
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log/slog"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"goBastion/internal/config"
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/hostmatch"
+	"goBastion/internal/utils/system"
+	"goBastion/internal/utils/validation"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BreakGlass declares an emergency access to a server no grant of the user
+// covers. Until it expires, the user may connect through any access entry
+// covering the target, TTY recording is forced on, and the declaration stays
+// on the admins' review list until acknowledged with breakGlassAck.
+func BreakGlass(db *gorm.DB, currentUser *models.User, log *slog.Logger, args []string) error {
+	fs := flag.NewFlagSet("breakGlass", flag.ContinueOnError)
+	var server, remoteUser, durationStr, reason string
+	var port int64
+	fs.StringVar(&server, "server", "", "Target server hostname or IP")
+	fs.Int64Var(&port, "port", 22, "Target port")
+	fs.StringVar(&remoteUser, "user", "", "Target username (optional, any when omitted)")
+	fs.StringVar(&durationStr, "duration", "", "How long the emergency access lasts (default and maximum: break_glass.max_duration)")
+	fs.StringVar(&reason, "reason", "", "Why emergency access is needed")
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+
+	if err := fs.Parse(args); err != nil || strings.TrimSpace(server) == "" || strings.TrimSpace(reason) == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Break Glass",
+			BlockType: "error",
+			Sections: []console.SectionContent{{SubTitle: "Usage", Body: []string{
+				"Usage: breakGlass --server <host> --reason <text> [--port <port>] [--user <remote_user>] [--duration <duration>]",
+			}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	if !validation.IsValidHost(server) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Break Glass",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Server", Body: []string{"Server must be a valid hostname or IP address."}}},
+		})
+		return fmt.Errorf("invalid server: %s", server)
+	}
+	if !validation.IsValidPort(port) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Break Glass",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Port", Body: []string{"Port must be between 1 and 65535"}}},
+		})
+		return fmt.Errorf("invalid port: %d", port)
+	}
+
+	maxDuration := time.Duration(config.Get().BreakGlass.MaxDuration)
+	duration := maxDuration
+	if strings.TrimSpace(durationStr) != "" {
+		d, err := time.ParseDuration(strings.TrimSpace(durationStr))
+		if err != nil || d < time.Minute || (maxDuration > 0 && d > maxDuration) {
+			console.DisplayBlock(console.ContentBlock{
+				Title:     "Break Glass",
+				BlockType: "error",
+				Sections: []console.SectionContent{{SubTitle: "Invalid Duration", Body: []string{
+					fmt.Sprintf("Duration must be between 1m and %s, e.g. 30m.", maxDuration),
+				}}},
+			})
+			return fmt.Errorf("invalid duration: %s", durationStr)
+		}
+		duration = d
+	}
+
+	if !targetCovered(db, server, port) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Break Glass",
+			BlockType: "error",
+			Sections: []console.SectionContent{{SubTitle: "No Egress Path", Body: []string{
+				fmt.Sprintf("No access entry on this bastion covers %s:%d, so no egress key is known to reach it.", server, port),
+				"Ask an administrator to add an access for this host.",
+			}}},
+		})
+		return fmt.Errorf("no access entry covers %s:%d", server, port)
+	}
+
+	now := time.Now()
+	bg := models.BreakGlass{
+		UserID:    currentUser.ID,
+		Server:    server,
+		Port:      port,
+		Username:  strings.TrimSpace(remoteUser),
+		Reason:    strings.TrimSpace(reason),
+		ClientIP:  system.ClientIPFromEnv(),
+		ExpiresAt: now.Add(duration),
+	}
+	if err := db.Create(&bg).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Break Glass",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Database Error", Body: []string{"Failed to record the break-glass declaration."}}},
+		})
+		return err
+	}
+
+	log.Error("break_glass",
+		slog.String("severity", "critical"),
+		slog.String("break_glass_id", bg.ID.String()),
+		slog.String("user", currentUser.Username),
+		slog.String("from", bg.ClientIP),
+		slog.String("target_host", server),
+		slog.Int64("target_port", port),
+		slog.String("target_user", bg.Username),
+		slog.String("reason", bg.Reason),
+		slog.Time("expires_at", bg.ExpiresAt),
+	)
+
+	target := fmt.Sprintf("%s:%d", server, port)
+	if bg.Username != "" {
+		target = bg.Username + "@" + target
+	}
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Break Glass",
+		BlockType: "warning",
+		Sections: []console.SectionContent{{SubTitle: "Emergency Access Granted", Body: []string{
+			fmt.Sprintf("You may connect to %s until %s.", target, bg.ExpiresAt.Format("2006-01-02 15:04:05")),
+			"Every session is recorded regardless of the TTY recording setting.",
+			fmt.Sprintf("This declaration (ID %s) has been logged and must be reviewed by an administrator.", bg.ID),
+		}}},
+	})
+	return nil
+}
+
+// List shows break-glass declarations, unacknowledged ones only unless --all.
+func List(db *gorm.DB, currentUser *models.User, args []string) error {
+	fs := flag.NewFlagSet("breakGlassList", flag.ContinueOnError)
+	var all bool
+	fs.BoolVar(&all, "all", false, "Include acknowledged declarations")
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+	if err := fs.Parse(args); err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Break Glass List",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: breakGlassList [--all]"}}},
+		})
+		return err
+	}
+
+	query := db.Preload("User").Preload("AcknowledgedBy").Order("created_at desc")
+	if !all {
+		query = query.Where("acknowledged_at IS NULL")
+	}
+	var records []models.BreakGlass
+	if err := query.Find(&records).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Break Glass List",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Failed to query break-glass declarations."}}},
+		})
+		return err
+	}
+	if len(records) == 0 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Break Glass List",
+			BlockType: "info",
+			Sections:  []console.SectionContent{{SubTitle: "Info", Body: []string{"No break-glass declarations awaiting review."}}},
+		})
+		return nil
+	}
+
+	now := time.Now()
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tUser\tTarget\tDeclared At\tExpires\tReview\tReason")
+	for _, r := range records {
+		target := fmt.Sprintf("%s:%d", r.Server, r.Port)
+		if r.Username != "" {
+			target = r.Username + "@" + target
+		}
+		expires := r.ExpiresAt.Format("2006-01-02 15:04:05")
+		if r.ExpiresAt.After(now) {
+			expires += " (active)"
+		}
+		review := "PENDING"
+		if r.AcknowledgedAt != nil && r.AcknowledgedBy != nil {
+			review = fmt.Sprintf("acknowledged by %s", r.AcknowledgedBy.Username)
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.ID, r.User.Username, target, r.CreatedAt.Format("2006-01-02 15:04:05"), expires, review, r.Reason)
+	}
+	_ = w.Flush()
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Break Glass List",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Declarations", Body: strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")}},
+	})
+	return nil
+}
+
+// Ack records an admin's review of a break-glass declaration. Nobody can
+// acknowledge their own declaration.
+func Ack(db *gorm.DB, currentUser *models.User, log *slog.Logger, args []string) error {
+	fs := flag.NewFlagSet("breakGlassAck", flag.ContinueOnError)
+	var idStr, comment string
+	fs.StringVar(&idStr, "id", "", "Break-glass declaration ID")
+	fs.StringVar(&comment, "comment", "", "Review comment")
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+	if err := fs.Parse(args); err != nil || strings.TrimSpace(idStr) == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Break Glass Ack",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: breakGlassAck --id <id> [--comment <text>]"}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	id, err := uuid.Parse(strings.TrimSpace(idStr))
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Break Glass Ack",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid ID", Body: []string{"Invalid break-glass ID format."}}},
+		})
+		return err
+	}
+	var bg models.BreakGlass
+	if err := db.Preload("User").Where("id = ?", id).First(&bg).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Break Glass Ack",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{"Break-glass declaration not found."}}},
+		})
+		return err
+	}
+	if bg.UserID == currentUser.ID {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Break Glass Ack",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You cannot acknowledge your own break-glass declaration."}}},
+		})
+		return fmt.Errorf("user %s cannot acknowledge their own break-glass", currentUser.Username)
+	}
+	if bg.AcknowledgedAt != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Break Glass Ack",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Already Acknowledged", Body: []string{"This declaration has already been acknowledged."}}},
+		})
+		return fmt.Errorf("break-glass %s already acknowledged", bg.ID)
+	}
+
+	now := time.Now()
+	res := db.Model(&models.BreakGlass{}).Where("id = ? AND acknowledged_at IS NULL", bg.ID).Updates(map[string]any{
+		"acknowledged_by_id": currentUser.ID,
+		"acknowledged_at":    now,
+		"ack_comment":        strings.TrimSpace(comment),
+	})
+	if res.Error != nil || res.RowsAffected == 0 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Break Glass Ack",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Failed to acknowledge the declaration."}}},
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		return fmt.Errorf("break-glass %s already acknowledged", bg.ID)
+	}
+
+	log.Warn("break_glass_acknowledged",
+		slog.String("break_glass_id", bg.ID.String()),
+		slog.String("admin", currentUser.Username),
+		slog.String("user", bg.User.Username),
+		slog.String("comment", strings.TrimSpace(comment)),
+	)
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Break Glass Ack",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Success", Body: []string{fmt.Sprintf("Acknowledged break-glass of '%s' on %s:%d.", bg.User.Username, bg.Server, bg.Port)}}},
+	})
+	return nil
+}
+
+// targetCovered reports whether any personal or group access entry covers
+// host:port, i.e. whether the bastion holds an egress key meant to reach it.
+func targetCovered(db *gorm.DB, host string, port int64) bool {
+	now := time.Now()
+	var selfAccesses []models.SelfAccess
+	db.Where(hostmatch.CandidateClause+" AND port = ? AND (expires_at IS NULL OR expires_at > ?)", host, port, now).Where(models.StartedClause, now).Find(&selfAccesses)
+	if len(hostmatch.Filter(selfAccesses, host, func(sa models.SelfAccess) string { return sa.Server })) > 0 {
+		return true
+	}
+	var groupAccesses []models.GroupAccess
+	db.Where(hostmatch.CandidateClause+" AND port = ? AND (expires_at IS NULL OR expires_at > ?)", host, port, now).Where(models.StartedClause, now).Find(&groupAccesses)
+	return len(hostmatch.Filter(groupAccesses, host, func(ga models.GroupAccess) string { return ga.Server })) > 0
+}
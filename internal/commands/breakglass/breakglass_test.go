@@ -0,0 +1,82 @@
+package breakglass
+
+import (
+	"testing"
+	"time"
+
+	"goBastion/internal/models"
+)
+
+func TestBreakGlass_RequiresRestrictedGrant(t *testing.T) {
+	db := newTestDB(t)
+	admin := newUser(t, db, "admin", models.RoleAdmin)
+	oncall := newUser(t, db, "oncall", models.RoleUser)
+
+	if oncall.CanDo(db, "breakGlass", "") {
+		t.Fatal("expected breakGlass to require a restricted grant")
+	}
+	if err := db.Create(&models.RestrictedCommandGrant{UserID: oncall.ID, Command: "breakGlass", GrantedByID: admin.ID}).Error; err != nil {
+		t.Fatalf("seed grant: %v", err)
+	}
+	if !oncall.CanDo(db, "breakGlass", "") {
+		t.Fatal("expected restricted grant to allow breakGlass")
+	}
+	if oncall.CanDo(db, "breakGlassAck", "") {
+		t.Fatal("expected breakGlassAck to be admin-only")
+	}
+}
+
+func TestBreakGlass_RecordsShortLivedDeclaration(t *testing.T) {
+	db := newTestDB(t)
+	oncall := newUser(t, db, "oncall", models.RoleUser)
+	g := models.Group{Name: "infra"}
+	db.Create(&g)
+	db.Create(&models.GroupAccess{GroupID: g.ID, Username: "root", Server: "10.1.0.0/16", Port: 22, Protocol: "ssh"})
+
+	if err := BreakGlass(db, oncall, discardLogger(), []string{"--server", "10.1.2.3", "--reason", "outage", "--duration", "15m"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var bg models.BreakGlass
+	if err := db.Where("user_id = ?", oncall.ID).First(&bg).Error; err != nil {
+		t.Fatalf("declaration not stored: %v", err)
+	}
+	if bg.Server != "10.1.2.3" || bg.Port != 22 || bg.AcknowledgedAt != nil {
+		t.Fatalf("unexpected declaration: %+v", bg)
+	}
+	if left := time.Until(bg.ExpiresAt); left <= 14*time.Minute || left > 15*time.Minute {
+		t.Fatalf("unexpected expiry in %s", left)
+	}
+
+	if err := BreakGlass(db, oncall, discardLogger(), []string{"--server", "10.1.2.3", "--reason", "outage", "--duration", "48h"}); err == nil {
+		t.Fatal("expected duration above break_glass.max_duration to be refused")
+	}
+	if err := BreakGlass(db, oncall, discardLogger(), []string{"--server", "192.168.9.9", "--reason", "outage"}); err == nil {
+		t.Fatal("expected a target no access entry covers to be refused")
+	}
+}
+
+func TestAck_AdminAcknowledgesOthersOnly(t *testing.T) {
+	db := newTestDB(t)
+	admin := newUser(t, db, "admin", models.RoleAdmin)
+	other := newUser(t, db, "admin2", models.RoleAdmin)
+
+	bg := models.BreakGlass{UserID: admin.ID, Server: "web1", Port: 22, Reason: "outage", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := db.Create(&bg).Error; err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	if err := Ack(db, admin, discardLogger(), []string{"--id", bg.ID.String()}); err == nil {
+		t.Fatal("expected self-acknowledgement to be refused")
+	}
+	if err := Ack(db, other, discardLogger(), []string{"--id", bg.ID.String(), "--comment", "reviewed recording"}); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+	db.First(&bg, "id = ?", bg.ID)
+	if bg.AcknowledgedAt == nil || bg.AcknowledgedByID == nil || *bg.AcknowledgedByID != other.ID || bg.AckComment != "reviewed recording" {
+		t.Fatalf("acknowledgement not recorded: %+v", bg)
+	}
+	if err := Ack(db, other, discardLogger(), []string{"--id", bg.ID.String()}); err == nil {
+		t.Fatal("expected second acknowledgement to be refused")
+	}
+}
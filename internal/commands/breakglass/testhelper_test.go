@@ -0,0 +1,42 @@
+package breakglass
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"goBastion/internal/models"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open test DB: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.User{}, &models.Group{}, &models.UserGroup{},
+		&models.SelfAccess{}, &models.GroupAccess{},
+		&models.RestrictedCommandGrant{}, &models.BreakGlass{},
+		&models.CustomRole{}, &models.CustomRoleAssignment{},
+	); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func newUser(t *testing.T, db *gorm.DB, username, role string) *models.User {
+	t.Helper()
+	u := models.User{Username: username, Role: role, Enabled: true}
+	if err := db.Create(&u).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return &u
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
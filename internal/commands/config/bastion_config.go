@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -50,11 +51,11 @@ type category struct {
 var categories = []category{
 	{"Access & Login", []string{"ssh", "mfa", "totp", "account", "security"}},
 	{"Connectivity", []string{"proxy", "interactive", "sftp", "scp", "rsync", "mosh", "realms"}},
-	{"Features", []string{"database", "guest_access", "pivs", "groups", "alias_self", "alias_group", "self_ingress", "egress_key", "known_hosts", "self_mfa", "self_password", "backup_codes", "tty_play", "restricted_grants", "restricted_cmds"}},
+	{"Features", []string{"database", "guest_access", "pivs", "groups", "alias_self", "alias_group", "self_ingress", "egress_key", "known_hosts", "self_mfa", "self_password", "backup_codes", "tty_play", "restricted_grants", "restricted_cmds", "access_requests", "break_glass"}},
 	{"Modes", []string{"readonly", "maintenance", "require_mfa", "force_osh_only"}},
 	{"Recording", []string{"ttyrec"}},
 	{"Sessions", []string{"session"}},
-	{"Connection Policy", []string{"deny_root_target"}},
+	{"Connection Policy", []string{"deny_root_target", "ingress_key_policy"}},
 }
 
 var sectionCategory = map[string]string{}
@@ -329,10 +330,16 @@ func displaySectionLabel(section string) string {
 		return "[restricted grants]"
 	case "restricted_cmds":
 		return "[restricted commands]"
+	case "access_requests":
+		return "[access requests]"
+	case "break_glass":
+		return "[break-glass access]"
 	case "ttyrec":
 		return "[TTY recording]"
 	case "session":
 		return "[session] (instance-wide)"
+	case "ingress_key_policy":
+		return "[ingress key policy]"
 	default:
 		return fmt.Sprintf("[%s]", section)
 	}
@@ -762,10 +769,26 @@ func applyValue(db *gorm.DB, key, newValue string) error {
 		if d, perr := parseDurationInput(newValue); perr == nil && d > 0 && d < 30*time.Second {
 			return fmt.Errorf("max_session_duration must be at least 30s (use 0 for unlimited)")
 		}
+	case "access_requests.max_duration", "break_glass.max_duration":
+		if d, perr := parseDurationInput(newValue); perr == nil && d < time.Minute {
+			return fmt.Errorf("max_duration must be at least 1m")
+		}
 	case "ttyrec.retention_days":
 		if n, perr := strconv.ParseInt(strings.TrimSpace(newValue), 10, 64); perr == nil && n < 0 {
 			return fmt.Errorf("retention_days must be 0 or greater (use 0 to keep forever)")
 		}
+	case "ingress_key_policy.min_rsa_bits":
+		if n, perr := strconv.ParseInt(strings.TrimSpace(newValue), 10, 64); perr == nil && n < 2048 {
+			return fmt.Errorf("min_rsa_bits must be at least 2048")
+		}
+	case "ingress_key_policy.max_expiry_days", "ingress_key_policy.max_keys":
+		if n, perr := strconv.ParseInt(strings.TrimSpace(newValue), 10, 64); perr == nil && n < 0 {
+			return fmt.Errorf("%s must be 0 or greater (use 0 for no limit)", field)
+		}
+	case "ssh.revoked_keys_file":
+		if v := strings.TrimSpace(newValue); v != "" && !filepath.IsAbs(v) {
+			return fmt.Errorf("revoked_keys_file must be an absolute path (empty to disable)")
+		}
 	case "security.group_visibility.mode":
 		switch strings.ToLower(strings.TrimSpace(newValue)) {
 		case "open", "members", "managers", "private":
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"log/slog"
+	"strings"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/sshHostKey"
+	"goBastion/internal/utils/sshca"
+
+	"gorm.io/gorm"
+)
+
+var hostCA = caCommands{
+	kind:        models.CertAuthorityHost,
+	title:       "Host CA",
+	showRight:   "bastionShowHostCA",
+	rotateRight: "bastionRotateHostCA",
+	started:     "Add the next @cert-authority line to the clients' known_hosts, then run: bastionRotateHostCA --promote",
+	promoted:    "The next CA now signs the host certificates. Reload sshd to serve them; the previous @cert-authority line can then be removed.",
+	cancelled:   "The rotation is cancelled. The next @cert-authority line can be removed from known_hosts.",
+	sections:    hostCASections,
+	afterPromote: func(db *gorm.DB) error {
+		return sshHostKey.WriteHostCertificates(db)
+	},
+}
+
+// ShowHostCA displays the @cert-authority lines clients add to known_hosts to
+// trust the bastion and its SFTP proxy: the active CA and, during a rotation,
+// the next one.
+func ShowHostCA(db *gorm.DB, currentUser *models.User) error {
+	return hostCA.show(db, currentUser)
+}
+
+// RotateHostCA starts a rotation of the host CA, or with --promote makes the
+// next CA the signing one and re-signs the sshd host certificates, or with
+// --cancel drops it.
+func RotateHostCA(db *gorm.DB, currentUser *models.User, log *slog.Logger, args []string) error {
+	return hostCA.rotate(db, currentUser, log, args)
+}
+
+// hostCASections lists the trusted host CAs as known_hosts lines.
+func hostCASections(cas []models.CertAuthority) []console.SectionContent {
+	pattern := "*"
+	if principals := sshca.HostPrincipals(); len(principals) > 0 {
+		pattern = strings.Join(principals, ",")
+	}
+	return []console.SectionContent{
+		{SubTitle: "known_hosts", Body: caKeyLines(cas, func(publicKey string) string {
+			return "@cert-authority " + pattern + " " + publicKey
+		})},
+		{SubTitle: "Client Setup", Body: []string{
+			"Add the line(s) above to ~/.ssh/known_hosts (or /etc/ssh/ssh_known_hosts) and drop the pinned bastion host keys.",
+			"sshd serves certificates for ssh.host_cert_principals; they are renewed at the sync after half of ssh.host_cert_validity and served once sshd is reloaded.",
+			"The SFTP proxy key is certified for any name: for sftp-session, the pattern must also match the host aliases used with sftp.",
+		}},
+	}
+}
@@ -39,6 +39,7 @@ func ShowSFTPProxyHostKey(db *gorm.DB, currentUser *models.User) error {
 				"Distribute this public key to client teams using sftp-session.",
 				"Each client must pin it in known_hosts for the final SSH host alias they use with sftp, not the bastion hostname inside ProxyCommand.",
 				"Rotate it with: /app/goBastion --regenerateSFTPProxyHostKey",
+				"It is also certified by the host CA: clients trusting the bastionShowHostCA line need no pinned key.",
 			}},
 		},
 	})
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/state"
+
+	"gorm.io/gorm"
+)
+
+// StateApply reads a state document (YAML or JSON) from in, shows the plan
+// against the database and applies it in one transaction unless --plan is
+// given. --prune also deletes what the document does not list.
+func StateApply(db *gorm.DB, currentUser *models.User, args []string, in io.Reader) error {
+	fs := flag.NewFlagSet("stateApply", flag.ContinueOnError)
+	var planOnly, prune bool
+	fs.BoolVar(&planOnly, "plan", false, "Only show the plan")
+	fs.BoolVar(&prune, "prune", false, "Delete groups, members, accesses and aliases missing from the document")
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	if err := fs.Parse(args); err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "State Apply",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: stateApply [--plan] [--prune] < state.yaml"}}},
+		})
+		return err
+	}
+
+	if !currentUser.CanDo(db, "stateApply", "") {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "State Apply",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You do not have permission to apply a state document."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	doc, err := state.Parse(in)
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "State Apply",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Document", Body: []string{err.Error()}}},
+		})
+		return err
+	}
+
+	opts := state.Options{Prune: prune}
+	changes, err := state.Plan(db, doc, opts)
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "State Apply",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Plan Failed", Body: []string{err.Error()}}},
+		})
+		return err
+	}
+	if len(changes) == 0 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "State Apply",
+			BlockType: "success",
+			Sections:  []console.SectionContent{{SubTitle: "Up To Date", Body: []string{"The database already matches the document."}}},
+		})
+		return nil
+	}
+	if planOnly {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "State Plan",
+			BlockType: "info",
+			Sections: []console.SectionContent{
+				{SubTitle: fmt.Sprintf("%d change(s)", len(changes)), Body: changeLines(changes)},
+				{SubTitle: "Next Step", Body: []string{"Run stateApply without --plan to apply these changes."}},
+			},
+		})
+		return nil
+	}
+
+	applied, err := state.Apply(db, doc, opts)
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "State Apply",
+			BlockType: "error",
+			Sections: []console.SectionContent{
+				{SubTitle: "Plan", Body: changeLines(changes)},
+				{SubTitle: "Rolled Back", Body: []string{err.Error()}},
+			},
+		})
+		return err
+	}
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "State Apply",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: fmt.Sprintf("%d change(s) applied", len(applied)), Body: changeLines(applied)}},
+	})
+	return nil
+}
+
+// StateExport writes the current groups, members, accesses and aliases to
+// out as a state document.
+func StateExport(db *gorm.DB, currentUser *models.User, out io.Writer) error {
+	if !currentUser.CanDo(db, "stateExport", "") {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "State Export",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You do not have permission to export the state."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	doc, err := state.Export(db)
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "State Export",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Failed to export the state."}}},
+		})
+		return err
+	}
+	return state.Write(out, doc)
+}
+
+func changeLines(changes []state.Change) []string {
+	lines := make([]string, 0, len(changes))
+	for _, c := range changes {
+		lines = append(lines, c.String())
+	}
+	return lines
+}
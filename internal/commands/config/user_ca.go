@@ -0,0 +1,173 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/sshca"
+
+	"gorm.io/gorm"
+)
+
+// caCommands describes the show and rotate commands of one CA kind.
+type caCommands struct {
+	kind        string
+	title       string // "User CA"
+	showRight   string
+	rotateRight string
+	// started, promoted and cancelled tell the admin what to do next.
+	started, promoted, cancelled string
+	// sections lists the trusted CAs with their setup.
+	sections func(cas []models.CertAuthority) []console.SectionContent
+	// afterPromote runs once the next CA signs, if set.
+	afterPromote func(db *gorm.DB) error
+}
+
+var userCA = caCommands{
+	kind:        models.CertAuthorityUser,
+	title:       "User CA",
+	showRight:   "bastionShowUserCA",
+	rotateRight: "bastionRotateUserCA",
+	started:     "Add the next CA to TrustedUserCAKeys on every target, then run: bastionRotateUserCA --promote",
+	promoted:    "The next CA now signs the egress certificates. The previous CA can be removed from TrustedUserCAKeys.",
+	cancelled:   "The rotation is cancelled. The next CA can be removed from TrustedUserCAKeys.",
+	sections:    userCASections,
+}
+
+// ShowUserCA displays the public keys of the user CA that targets must list
+// in TrustedUserCAKeys: the active CA and, during a rotation, the next one.
+func ShowUserCA(db *gorm.DB, currentUser *models.User) error {
+	return userCA.show(db, currentUser)
+}
+
+// RotateUserCA starts a rotation of the user CA, or with --promote makes the
+// next CA the signing one, or with --cancel drops it.
+func RotateUserCA(db *gorm.DB, currentUser *models.User, log *slog.Logger, args []string) error {
+	return userCA.rotate(db, currentUser, log, args)
+}
+
+func (c caCommands) show(db *gorm.DB, currentUser *models.User) error {
+	if !currentUser.CanDo(db, c.showRight, "") {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     c.title,
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{fmt.Sprintf("You do not have permission to view the %s CA.", c.kind)}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	cas, err := sshca.Trusted(db, c.kind)
+	if err != nil {
+		return fmt.Errorf("load %s CA: %w", c.kind, err)
+	}
+	console.DisplayBlock(console.ContentBlock{
+		Title:     c.title,
+		BlockType: "info",
+		Sections:  c.sections(cas),
+	})
+	return nil
+}
+
+func (c caCommands) rotate(db *gorm.DB, currentUser *models.User, log *slog.Logger, args []string) error {
+	fs := flag.NewFlagSet(c.rotateRight, flag.ContinueOnError)
+	var promote, cancel bool
+	fs.BoolVar(&promote, "promote", false, "Sign with the next CA and retire the current one")
+	fs.BoolVar(&cancel, "cancel", false, "Drop the next CA")
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	if err := fs.Parse(args); err != nil || (promote && cancel) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Rotate " + c.title,
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: " + c.rotateRight + " [--promote | --cancel]"}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("--promote and --cancel are exclusive")
+	}
+
+	if !currentUser.CanDo(db, c.rotateRight, "") {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Rotate " + c.title,
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{fmt.Sprintf("You do not have permission to rotate the %s CA.", c.kind)}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	var err error
+	var done string
+	switch {
+	case promote:
+		err = sshca.Promote(db, log, c.kind)
+		done = c.promoted
+	case cancel:
+		err = sshca.Cancel(db, log, c.kind)
+		done = c.cancelled
+	default:
+		_, err = sshca.StartRotation(db, log, c.kind)
+		done = c.started
+	}
+	switch {
+	case errors.Is(err, sshca.ErrRotationInProgress), errors.Is(err, sshca.ErrNoRotation):
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Rotate " + c.title,
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Rotation", Body: []string{err.Error() + "."}}},
+		})
+		return err
+	case err != nil:
+		return fmt.Errorf("rotate %s CA: %w", c.kind, err)
+	}
+	log.Info(c.kind+"_ca_rotated", slog.String("user", currentUser.Username), slog.Bool("promote", promote), slog.Bool("cancel", cancel))
+
+	if promote && c.afterPromote != nil {
+		if err := c.afterPromote(db); err != nil {
+			log.Warn(c.kind+"_ca_after_promote_failed", slog.Any("error", err))
+		}
+	}
+
+	cas, err := sshca.Trusted(db, c.kind)
+	if err != nil {
+		return fmt.Errorf("load %s CA: %w", c.kind, err)
+	}
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Rotate " + c.title,
+		BlockType: "success",
+		Sections:  append([]console.SectionContent{{SubTitle: "Done", Body: []string{done}}}, c.sections(cas)...),
+	})
+	return nil
+}
+
+// caKeyLines lists the fingerprint and public key of each trusted CA, with
+// format turning the public key into the line to install.
+func caKeyLines(cas []models.CertAuthority, format func(publicKey string) string) []string {
+	var keys []string
+	for _, ca := range cas {
+		label := "Active"
+		if ca.State == models.CertAuthorityNext {
+			label = "Next (not signing yet)"
+		}
+		keys = append(keys, fmt.Sprintf("%s: %s", label, ca.Fingerprint), format(ca.PublicKey))
+	}
+	return keys
+}
+
+// userCASections lists the trusted user CAs with the target setup.
+func userCASections(cas []models.CertAuthority) []console.SectionContent {
+	return []console.SectionContent{
+		{SubTitle: "TrustedUserCAKeys", Body: caKeyLines(cas, func(publicKey string) string { return publicKey })},
+		{SubTitle: "Target Setup", Body: []string{
+			"Write the public key line(s) above to /etc/ssh/gobastion_user_ca.pub on each target,",
+			"set 'TrustedUserCAKeys /etc/ssh/gobastion_user_ca.pub' in sshd_config and reload sshd.",
+			"Certificates name the target account as principal and carry the bastion user and session in their key ID.",
+		}},
+	}
+}
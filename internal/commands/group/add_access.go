@@ -12,6 +12,8 @@ import (
 	"goBastion/internal/config"
 	"goBastion/internal/models"
 	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/hostmatch"
+	"goBastion/internal/utils/schedule"
 	"goBastion/internal/utils/validation"
 
 	"gorm.io/gorm"
@@ -20,36 +22,51 @@ import (
 // AddAccess adds an SSH access entry to a group.
 func AddAccess(db *gorm.DB, currentUser *models.User, args []string) error {
 	fs := flag.NewFlagSet("groupAddAccess", flag.ContinueOnError)
-	var groupName, server, username, comment, allowedFrom, protocol string
+	var groupName, server, tags, username, comment, allowedFrom, scheduleSpec, protocol string
 	var port int64
 	var ttlDays int
+	var starts string
 	var force bool
 	fs.StringVar(&groupName, "group", "", "Group name")
 	fs.StringVar(&server, "server", "", "Server to add access for")
+	fs.StringVar(&tags, "tags", "", "Inventory tag selector instead of --server, e.g. env=prod,role=web")
 	fs.Int64Var(&port, "port", 22, "Port number")
 	fs.StringVar(&username, "username", "", "Connection username")
 	fs.StringVar(&comment, "comment", "", "Comment")
 	fs.StringVar(&allowedFrom, "from", "", "Allowed source CIDRs (comma-separated)")
+	fs.StringVar(&scheduleSpec, "schedule", "", "Access window: <days> <HH:MM-HH:MM> [timezone], e.g. \"Mon-Fri 08:00-19:00 Europe/Paris\"")
 	fs.IntVar(&ttlDays, "ttl", 0, "Access expiry in days (0 = never, must be positive if set)")
+	fs.StringVar(&starts, "starts", "", "Access start time (RFC 3339, \"YYYY-MM-DD HH:MM\" or YYYY-MM-DD); pending until then")
 	fs.StringVar(&protocol, "protocol", "ssh", "Protocol restriction: ssh (all), scpupload, scpdownload, sftp, rsync")
 	fs.BoolVar(&force, "force", false, "Skip TCP connectivity check")
 	var flagOutput bytes.Buffer
 	fs.SetOutput(&flagOutput)
 
-	if err := fs.Parse(args); err != nil || groupName == "" || server == "" || username == "" {
+	if err := fs.Parse(args); err != nil || groupName == "" || (server == "") == (tags == "") || username == "" {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Add Group Access",
 			BlockType: "error",
-			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: groupAddAccess --group <groupName> --server <server> --port <port> --username <username> [--comment <comment>] [--from <CIDRs>] [--ttl <days>] [--protocol ssh|scpupload|scpdownload|sftp|rsync] [--force]"}}},
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: groupAddAccess --group <groupName> --server <server> | --tags <key=value,...> --port <port> --username <username> [--comment <comment>] [--from <CIDRs>] [--schedule <spec>] [--ttl <days>] [--starts <time>] [--protocol ssh|scpupload|scpdownload|sftp|rsync] [--force]"}}},
 		})
 		return fmt.Errorf("missing required arguments")
 	}
 
-	if !validation.IsValidHost(server) {
+	if tags != "" {
+		_, selectorServer, err := models.ParseTagSelector(tags)
+		if err != nil {
+			console.DisplayBlock(console.ContentBlock{
+				Title:     "Add Group Access",
+				BlockType: "error",
+				Sections:  []console.SectionContent{{SubTitle: "Invalid Tag Selector", Body: []string{err.Error(), "Expected comma-separated key=value pairs, e.g. env=prod,role=web"}}},
+			})
+			return fmt.Errorf("invalid tag selector: %w", err)
+		}
+		server = selectorServer
+	} else if !validation.IsValidServerPattern(server) {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Add Group Access",
 			BlockType: "error",
-			Sections:  []console.SectionContent{{SubTitle: "Invalid Server", Body: []string{"Server hostname/IP contains invalid characters (e.g., '@')."}}},
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Server", Body: []string{"Server must be a hostname, IP, CIDR block (10.20.0.0/16) or hostname glob (*.db.example)."}}},
 		})
 		return fmt.Errorf("invalid server: %s", server)
 	}
@@ -69,12 +86,34 @@ func AddAccess(db *gorm.DB, currentUser *models.User, args []string) error {
 		})
 		return fmt.Errorf("invalid CIDRs: %s", allowedFrom)
 	}
+	startsAt, err := validation.ParseStartsAt(starts, time.Now())
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Group Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Start", Body: []string{err.Error()}}},
+		})
+		return fmt.Errorf("invalid start time: %w", err)
+	}
+	if scheduleSpec != "" {
+		sched, err := schedule.Parse(scheduleSpec)
+		if err != nil {
+			console.DisplayBlock(console.ContentBlock{
+				Title:     "Add Group Access",
+				BlockType: "error",
+				Sections:  []console.SectionContent{{SubTitle: "Invalid Schedule", Body: []string{err.Error(), "Expected <days> <HH:MM-HH:MM> [timezone], e.g. \"Mon-Fri 08:00-19:00 Europe/Paris\""}}},
+			})
+			return fmt.Errorf("invalid schedule: %w", err)
+		}
+		scheduleSpec = sched.String()
+	}
 
 	// Check TCP connectivity to server:port with 5s timeout (skip if --force).
 	// A failed connectivity check is a warning only — it must not block access creation.
 	// Network reachability can change after the access entry is saved.
 	// Restrict active checks to private/reserved targets to avoid scanner-like behavior.
-	if !force {
+	// CIDR, glob and tag selectors name no single target, so they are never probed.
+	if !force && !hostmatch.IsPattern(server) && !models.IsTagSelector(server) {
 		addr := net.JoinHostPort(server, strconv.FormatInt(port, 10))
 		shouldCheck := validation.IsPrivateOrReservedTarget(server)
 		if shouldCheck {
@@ -148,7 +187,7 @@ func AddAccess(db *gorm.DB, currentUser *models.User, args []string) error {
 	}
 
 	var existingAccess models.GroupAccess
-	if err := db.Where("group_id = ? AND server = ? AND port = ? AND username = ?", group.ID, server, port, username).First(&existingAccess).Error; err == nil {
+	if err := db.Where("group_id = ? AND server = ? AND port = ? AND username = ? AND member_id IS NULL", group.ID, server, port, username).First(&existingAccess).Error; err == nil {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Add Group Access",
 			BlockType: "error",
@@ -171,10 +210,17 @@ func AddAccess(db *gorm.DB, currentUser *models.User, args []string) error {
 		Username:    username,
 		Comment:     comment,
 		AllowedFrom: allowedFrom,
+		Schedule:    scheduleSpec,
+		StartsAt:    startsAt,
 		Protocol:    protocol,
 	}
 	if ttlDays > 0 {
-		t := time.Now().AddDate(0, 0, ttlDays)
+		// The TTL of a scheduled access runs from its start.
+		from := time.Now()
+		if startsAt != nil {
+			from = *startsAt
+		}
+		t := from.AddDate(0, 0, ttlDays)
 		access.ExpiresAt = &t
 	}
 	if err := db.Create(&access).Error; err != nil {
@@ -10,6 +10,7 @@ import (
 	"goBastion/internal/models"
 	"goBastion/internal/utils/console"
 	"goBastion/internal/utils/cryptokey"
+	"goBastion/internal/utils/schedule"
 	"goBastion/internal/utils/validation"
 
 	"gorm.io/gorm"
@@ -18,9 +19,10 @@ import (
 // AddDBAccess adds a database access entry to a group.
 func AddDBAccess(db *gorm.DB, currentUser *models.User, args []string) error {
 	fs := flag.NewFlagSet("groupAddDBAccess", flag.ContinueOnError)
-	var groupName, host, username, comment, allowedFrom, protocol, password, database string
+	var groupName, host, username, comment, allowedFrom, scheduleSpec, protocol, password, database string
 	var port int64
 	var ttlDays int
+	var starts string
 	fs.StringVar(&groupName, "group", "", "Group name")
 	fs.StringVar(&host, "host", "", "Database host")
 	fs.Int64Var(&port, "port", 0, "Port number")
@@ -30,7 +32,9 @@ func AddDBAccess(db *gorm.DB, currentUser *models.User, args []string) error {
 	fs.StringVar(&database, "database", "", "Specific database name (optional)")
 	fs.StringVar(&comment, "comment", "", "Comment")
 	fs.StringVar(&allowedFrom, "from", "", "Allowed source CIDRs (comma-separated)")
+	fs.StringVar(&scheduleSpec, "schedule", "", "Access window: <days> <HH:MM-HH:MM> [timezone], e.g. \"Mon-Fri 08:00-19:00 Europe/Paris\"")
 	fs.IntVar(&ttlDays, "ttl", 0, "Access expiry in days (0 = never, must be positive if set)")
+	fs.StringVar(&starts, "starts", "", "Access start time (RFC 3339, \"YYYY-MM-DD HH:MM\" or YYYY-MM-DD); pending until then")
 	var flagOutput bytes.Buffer
 	fs.SetOutput(&flagOutput)
 
@@ -38,7 +42,7 @@ func AddDBAccess(db *gorm.DB, currentUser *models.User, args []string) error {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Add Group DB Access",
 			BlockType: "error",
-			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: groupAddDBAccess --group <group> --host <host> --user <username> --protocol <mysql|postgres|redis> [--port <port>] [--password <password>] [--database <database>] [--comment <comment>] [--from <CIDRs>] [--ttl <days>]"}}},
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: groupAddDBAccess --group <group> --host <host> --user <username> --protocol <mysql|postgres|redis> [--port <port>] [--password <password>] [--database <database>] [--comment <comment>] [--from <CIDRs>] [--schedule <spec>] [--ttl <days>] [--starts <time>]"}}},
 		})
 		return fmt.Errorf("missing required arguments")
 	}
@@ -88,6 +92,27 @@ func AddDBAccess(db *gorm.DB, currentUser *models.User, args []string) error {
 		})
 		return fmt.Errorf("invalid CIDRs: %s", allowedFrom)
 	}
+	startsAt, err := validation.ParseStartsAt(starts, time.Now())
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Group DB Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Start", Body: []string{err.Error()}}},
+		})
+		return fmt.Errorf("invalid start time: %w", err)
+	}
+	if scheduleSpec != "" {
+		sched, err := schedule.Parse(scheduleSpec)
+		if err != nil {
+			console.DisplayBlock(console.ContentBlock{
+				Title:     "Add Group DB Access",
+				BlockType: "error",
+				Sections:  []console.SectionContent{{SubTitle: "Invalid Schedule", Body: []string{err.Error(), "Expected <days> <HH:MM-HH:MM> [timezone], e.g. \"Mon-Fri 08:00-19:00 Europe/Paris\""}}},
+			})
+			return fmt.Errorf("invalid schedule: %w", err)
+		}
+		scheduleSpec = sched.String()
+	}
 	// Validate TTL - must be zero (never) or positive
 	if ttlDays < 0 {
 		console.DisplayBlock(console.ContentBlock{
@@ -151,9 +176,16 @@ func AddDBAccess(db *gorm.DB, currentUser *models.User, args []string) error {
 		Database:    database,
 		Comment:     comment,
 		AllowedFrom: allowedFrom,
+		Schedule:    scheduleSpec,
+		StartsAt:    startsAt,
 	}
 	if ttlDays > 0 {
-		t := time.Now().AddDate(0, 0, ttlDays)
+		// The TTL of a scheduled access runs from its start.
+		from := time.Now()
+		if startsAt != nil {
+			from = *startsAt
+		}
+		t := from.AddDate(0, 0, ttlDays)
 		access.ExpiresAt = &t
 	}
 	if err := db.Create(&access).Error; err != nil {
@@ -11,6 +11,7 @@ import (
 	"goBastion/internal/config"
 	"goBastion/internal/models"
 	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/schedule"
 	"goBastion/internal/utils/validation"
 
 	"gorm.io/gorm"
@@ -20,9 +21,10 @@ import (
 // server within a group, using the group's egress key.
 func AddGuestAccess(db *gorm.DB, currentUser *models.User, args []string) error {
 	fs := flag.NewFlagSet("groupAddGuestAccess", flag.ContinueOnError)
-	var groupName, account, server, remoteUser, comment, allowedFrom, protocol string
+	var groupName, account, server, remoteUser, comment, allowedFrom, scheduleSpec, protocol string
 	var port int64
 	var ttlDays int
+	var starts string
 	fs.StringVar(&groupName, "group", "", "Group name")
 	fs.StringVar(&account, "account", "", "Username to grant guest access to")
 	fs.StringVar(&server, "host", "", "Server to grant access for")
@@ -30,7 +32,9 @@ func AddGuestAccess(db *gorm.DB, currentUser *models.User, args []string) error
 	fs.Int64Var(&port, "port", 22, "Remote port")
 	fs.StringVar(&comment, "comment", "", "Comment")
 	fs.StringVar(&allowedFrom, "from", "", "Allowed source CIDRs (comma-separated)")
+	fs.StringVar(&scheduleSpec, "schedule", "", "Access window: <days> <HH:MM-HH:MM> [timezone], e.g. \"Mon-Fri 08:00-19:00 Europe/Paris\"")
 	fs.IntVar(&ttlDays, "ttl", 0, "Access expiry in days (0 = never)")
+	fs.StringVar(&starts, "starts", "", "Access start time (RFC 3339, \"YYYY-MM-DD HH:MM\" or YYYY-MM-DD); pending until then")
 	fs.StringVar(&protocol, "protocol", "ssh", "Protocol: ssh, scpupload, scpdownload, sftp, rsync")
 	var flagOutput bytes.Buffer
 	fs.SetOutput(&flagOutput)
@@ -40,7 +44,7 @@ func AddGuestAccess(db *gorm.DB, currentUser *models.User, args []string) error
 			Title:     "Add Guest Access",
 			BlockType: "error",
 			Sections: []console.SectionContent{{SubTitle: "Usage", Body: []string{
-				"Usage: groupAddGuestAccess --group <group> --account <user> --host <server> --user <remote_user> [--port <port>] [--protocol <proto>] [--ttl <days>] [--comment <text>] [--from <CIDRs>]",
+				"Usage: groupAddGuestAccess --group <group> --account <user> --host <server> --user <remote_user> [--port <port>] [--protocol <proto>] [--ttl <days>] [--starts <time>] [--comment <text>] [--from <CIDRs>] [--schedule <spec>]",
 			}}},
 		})
 		if err != nil {
@@ -58,11 +62,11 @@ func AddGuestAccess(db *gorm.DB, currentUser *models.User, args []string) error
 		return fmt.Errorf("access denied for %s", currentUser.Username)
 	}
 
-	if !validation.IsValidHost(server) {
+	if !validation.IsValidServerPattern(server) {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Add Guest Access",
 			BlockType: "error",
-			Sections:  []console.SectionContent{{SubTitle: "Invalid Server", Body: []string{"Server hostname/IP contains invalid characters."}}},
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Server", Body: []string{"Server must be a hostname, IP, CIDR block (10.20.0.0/16) or hostname glob (*.db.example)."}}},
 		})
 		return fmt.Errorf("invalid server: %s", server)
 	}
@@ -90,6 +94,27 @@ func AddGuestAccess(db *gorm.DB, currentUser *models.User, args []string) error
 		})
 		return fmt.Errorf("invalid CIDRs: %s", allowedFrom)
 	}
+	startsAt, err := validation.ParseStartsAt(starts, time.Now())
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Guest Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Start", Body: []string{err.Error()}}},
+		})
+		return fmt.Errorf("invalid start time: %w", err)
+	}
+	if scheduleSpec != "" {
+		sched, err := schedule.Parse(scheduleSpec)
+		if err != nil {
+			console.DisplayBlock(console.ContentBlock{
+				Title:     "Add Guest Access",
+				BlockType: "error",
+				Sections:  []console.SectionContent{{SubTitle: "Invalid Schedule", Body: []string{err.Error(), "Expected <days> <HH:MM-HH:MM> [timezone], e.g. \"Mon-Fri 08:00-19:00 Europe/Paris\""}}},
+			})
+			return fmt.Errorf("invalid schedule: %w", err)
+		}
+		scheduleSpec = sched.String()
+	}
 	if ttlDays < 0 {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Add Guest Access",
@@ -139,7 +164,7 @@ func AddGuestAccess(db *gorm.DB, currentUser *models.User, args []string) error
 
 	// Verify the target user is a guest in this group.
 	var ug models.UserGroup
-	if err := db.Where("user_id = ? AND group_id = ? AND deleted_at IS NULL", targetUser.ID, group.ID).First(&ug).Error; err != nil {
+	if err := db.Where("user_id = ? AND group_id = ? AND deleted_at IS NULL", targetUser.ID, group.ID).Where(models.MembershipActiveClause, time.Now()).First(&ug).Error; err != nil {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Add Guest Access",
 			BlockType: "error",
@@ -177,9 +202,16 @@ func AddGuestAccess(db *gorm.DB, currentUser *models.User, args []string) error
 		Protocol:    protocol,
 		Comment:     comment,
 		AllowedFrom: allowedFrom,
+		Schedule:    scheduleSpec,
+		StartsAt:    startsAt,
 	}
 	if ttlDays > 0 {
-		t := time.Now().AddDate(0, 0, ttlDays)
+		// The TTL of a scheduled access runs from its start.
+		from := time.Now()
+		if startsAt != nil {
+			from = *startsAt
+		}
+		t := from.AddDate(0, 0, ttlDays)
 		guestAccess.ExpiresAt = &t
 	}
 
@@ -2,6 +2,7 @@ package group
 
 import (
 	"testing"
+	"time"
 
 	"goBastion/internal/models"
 )
@@ -65,3 +66,67 @@ func TestAddGuestAccess_DuplicateReturnsError(t *testing.T) {
 		t.Fatal("expected duplicate guest access error")
 	}
 }
+
+func TestAddGuestAccess_StartsSchedulesGrant(t *testing.T) {
+	db := newTestDB(t)
+	admin := newAdminUser(t, db, "admin")
+	alice := newRegularUser(t, db, "alice")
+
+	group := models.Group{Name: "mygroup"}
+	if err := db.Create(&group).Error; err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	if err := db.Create(&models.UserGroup{UserID: alice.ID, GroupID: group.ID, Role: models.GroupRoleGuest}).Error; err != nil {
+		t.Fatalf("seed membership: %v", err)
+	}
+
+	args := []string{"--group", "mygroup", "--account", "alice", "--host", "10.0.0.2", "--user", "deploy", "--ttl", "2"}
+	if err := AddGuestAccess(db, admin, append(args, "--starts", "2001-01-01")); err == nil {
+		t.Fatal("expected a past --starts to be rejected")
+	}
+
+	starts := time.Now().Add(48 * time.Hour).Truncate(time.Minute)
+	if err := AddGuestAccess(db, admin, append(args, "--starts", starts.Format("2006-01-02 15:04"))); err != nil {
+		t.Fatalf("AddGuestAccess: %v", err)
+	}
+	var grant models.GroupGuestAccess
+	if err := db.Where("user_id = ?", alice.ID).First(&grant).Error; err != nil {
+		t.Fatalf("load grant: %v", err)
+	}
+	if grant.StartsAt == nil || !grant.StartsAt.Equal(starts) {
+		t.Fatalf("StartsAt = %v, want %v", grant.StartsAt, starts)
+	}
+	if grant.ExpiresAt == nil || !grant.ExpiresAt.Equal(starts.AddDate(0, 0, 2)) {
+		t.Fatalf("ExpiresAt = %v, want the TTL to run from the start", grant.ExpiresAt)
+	}
+}
+
+func TestAddGuestAccess_ExpiredGuestReturnsError(t *testing.T) {
+	db := newTestDB(t)
+	admin := newAdminUser(t, db, "admin")
+	alice := newRegularUser(t, db, "alice")
+
+	group := models.Group{Name: "mygroup"}
+	if err := db.Create(&group).Error; err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := db.Create(&models.UserGroup{UserID: alice.ID, GroupID: group.ID, Role: models.GroupRoleGuest, ExpiresAt: &past}).Error; err != nil {
+		t.Fatalf("seed membership: %v", err)
+	}
+
+	err := AddGuestAccess(db, admin, []string{
+		"--group", "mygroup",
+		"--account", "alice",
+		"--host", "10.0.0.2",
+		"--user", "deploy",
+	})
+	if err == nil {
+		t.Fatal("expected expired guest to be refused")
+	}
+	var count int64
+	db.Model(&models.GroupGuestAccess{}).Where("user_id = ?", alice.ID).Count(&count)
+	if count != 0 {
+		t.Fatalf("grant created for expired guest: %d", count)
+	}
+}
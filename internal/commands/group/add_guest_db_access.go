@@ -10,6 +10,7 @@ import (
 	"goBastion/internal/models"
 	"goBastion/internal/utils/console"
 	"goBastion/internal/utils/cryptokey"
+	"goBastion/internal/utils/schedule"
 	"goBastion/internal/utils/validation"
 
 	"gorm.io/gorm"
@@ -18,9 +19,10 @@ import (
 // AddGuestDBAccess grants a guest-role user database access within a group.
 func AddGuestDBAccess(db *gorm.DB, currentUser *models.User, args []string) error {
 	fs := flag.NewFlagSet("groupAddGuestDBAccess", flag.ContinueOnError)
-	var groupName, account, host, username, comment, allowedFrom, protocol, password, database string
+	var groupName, account, host, username, comment, allowedFrom, scheduleSpec, protocol, password, database string
 	var port int64
 	var ttlDays int
+	var starts string
 	fs.StringVar(&groupName, "group", "", "Group name")
 	fs.StringVar(&account, "account", "", "Username to grant guest DB access to")
 	fs.StringVar(&host, "host", "", "Database host")
@@ -31,7 +33,9 @@ func AddGuestDBAccess(db *gorm.DB, currentUser *models.User, args []string) erro
 	fs.StringVar(&database, "database", "", "Specific database name (optional)")
 	fs.StringVar(&comment, "comment", "", "Comment")
 	fs.StringVar(&allowedFrom, "from", "", "Allowed source CIDRs (comma-separated)")
+	fs.StringVar(&scheduleSpec, "schedule", "", "Access window: <days> <HH:MM-HH:MM> [timezone], e.g. \"Mon-Fri 08:00-19:00 Europe/Paris\"")
 	fs.IntVar(&ttlDays, "ttl", 0, "Access expiry in days (0 = never)")
+	fs.StringVar(&starts, "starts", "", "Access start time (RFC 3339, \"YYYY-MM-DD HH:MM\" or YYYY-MM-DD); pending until then")
 	var flagOutput bytes.Buffer
 	fs.SetOutput(&flagOutput)
 
@@ -40,7 +44,7 @@ func AddGuestDBAccess(db *gorm.DB, currentUser *models.User, args []string) erro
 			Title:     "Add Guest DB Access",
 			BlockType: "error",
 			Sections: []console.SectionContent{{SubTitle: "Usage", Body: []string{
-				"Usage: groupAddGuestDBAccess --group <group> --account <user> --host <host> --user <username> --protocol <mysql|postgres|redis> [--port <port>] [--password <password>] [--database <database>] [--comment <text>] [--from <CIDRs>] [--ttl <days>]",
+				"Usage: groupAddGuestDBAccess --group <group> --account <user> --host <host> --user <username> --protocol <mysql|postgres|redis> [--port <port>] [--password <password>] [--database <database>] [--comment <text>] [--from <CIDRs>] [--schedule <spec>] [--ttl <days>] [--starts <time>]",
 			}}},
 		})
 		return err
@@ -91,6 +95,27 @@ func AddGuestDBAccess(db *gorm.DB, currentUser *models.User, args []string) erro
 		})
 		return fmt.Errorf("invalid CIDRs: %s", allowedFrom)
 	}
+	startsAt, err := validation.ParseStartsAt(starts, time.Now())
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Guest DB Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Start", Body: []string{err.Error()}}},
+		})
+		return fmt.Errorf("invalid start time: %w", err)
+	}
+	if scheduleSpec != "" {
+		sched, err := schedule.Parse(scheduleSpec)
+		if err != nil {
+			console.DisplayBlock(console.ContentBlock{
+				Title:     "Add Guest DB Access",
+				BlockType: "error",
+				Sections:  []console.SectionContent{{SubTitle: "Invalid Schedule", Body: []string{err.Error(), "Expected <days> <HH:MM-HH:MM> [timezone], e.g. \"Mon-Fri 08:00-19:00 Europe/Paris\""}}},
+			})
+			return fmt.Errorf("invalid schedule: %w", err)
+		}
+		scheduleSpec = sched.String()
+	}
 	if ttlDays < 0 {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Add Guest DB Access",
@@ -122,7 +147,7 @@ func AddGuestDBAccess(db *gorm.DB, currentUser *models.User, args []string) erro
 
 	// Verify the target user is a guest in this group.
 	var ug models.UserGroup
-	if err := db.Where("user_id = ? AND group_id = ? AND deleted_at IS NULL", targetUser.ID, group.ID).First(&ug).Error; err != nil {
+	if err := db.Where("user_id = ? AND group_id = ? AND deleted_at IS NULL", targetUser.ID, group.ID).Where(models.MembershipActiveClause, time.Now()).First(&ug).Error; err != nil {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Add Guest DB Access",
 			BlockType: "error",
@@ -184,9 +209,16 @@ func AddGuestDBAccess(db *gorm.DB, currentUser *models.User, args []string) erro
 		Database:    database,
 		Comment:     comment,
 		AllowedFrom: allowedFrom,
+		Schedule:    scheduleSpec,
+		StartsAt:    startsAt,
 	}
 	if ttlDays > 0 {
-		t := time.Now().AddDate(0, 0, ttlDays)
+		// The TTL of a scheduled access runs from its start.
+		from := time.Now()
+		if startsAt != nil {
+			from = *startsAt
+		}
+		t := from.AddDate(0, 0, ttlDays)
 		guestAccess.ExpiresAt = &t
 	}
 
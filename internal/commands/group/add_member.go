@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"strings"
+	"time"
 
 	"goBastion/internal/models"
 	"goBastion/internal/utils/console"
@@ -15,10 +16,13 @@ import (
 // AddMember adds a user to a group with a specified role.
 func AddMember(db *gorm.DB, currentUser *models.User, args []string) error {
 	fs := flag.NewFlagSet("groupAddMember", flag.ContinueOnError)
-	var groupName, username, role string
+	var groupName, username, role, until string
+	var ttlDays int
 	fs.StringVar(&groupName, "group", "", "Group name")
 	fs.StringVar(&username, "user", "", "Username to add")
 	fs.StringVar(&role, "role", "", "Role (owner, aclkeeper, gatekeeper, member, guest)")
+	fs.IntVar(&ttlDays, "ttl", 0, "Membership expiry in days (0 = never)")
+	fs.StringVar(&until, "until", "", "Membership expiry date (YYYY-MM-DD or RFC 3339)")
 	var flagOutput bytes.Buffer
 	fs.SetOutput(&flagOutput)
 
@@ -26,7 +30,7 @@ func AddMember(db *gorm.DB, currentUser *models.User, args []string) error {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Add Member",
 			BlockType: "error",
-			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: groupAddMember --group <groupName> --user <username> --role <role>"}}},
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: groupAddMember --group <groupName> --user <username> --role <role> [--ttl <days> | --until <date>]"}}},
 		})
 		return err
 	}
@@ -86,7 +90,17 @@ func AddMember(db *gorm.DB, currentUser *models.User, args []string) error {
 		return fmt.Errorf("invalid group role: %s", role)
 	}
 
-	newUG := models.UserGroup{UserID: u.ID, GroupID: g.ID, Role: role}
+	expiresAt, err := parseMembershipExpiry(ttlDays, until, time.Now())
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Member",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Expiry", Body: []string{err.Error()}}},
+		})
+		return err
+	}
+
+	newUG := models.UserGroup{UserID: u.ID, GroupID: g.ID, Role: role, ExpiresAt: expiresAt}
 	if err := db.Create(&newUG).Error; err != nil {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Add Member",
@@ -98,10 +112,43 @@ func AddMember(db *gorm.DB, currentUser *models.User, args []string) error {
 	models.InvalidateGroupsCache(currentUser.ID)
 	models.InvalidateGroupsCache(u.ID)
 
+	body := []string{fmt.Sprintf("User '%s' added to group '%s' as '%s'.", username, groupName, role)}
+	if expiresAt != nil {
+		body = append(body, fmt.Sprintf("The membership expires at %s.", expiresAt.Format("2006-01-02 15:04:05")))
+	}
 	console.DisplayBlock(console.ContentBlock{
 		Title:     "Add Member",
 		BlockType: "success",
-		Sections:  []console.SectionContent{{SubTitle: "Success", Body: []string{fmt.Sprintf("User '%s' added to group '%s' as '%s'.", username, groupName, role)}}},
+		Sections:  []console.SectionContent{{SubTitle: "Success", Body: body}},
 	})
 	return nil
 }
+
+// parseMembershipExpiry turns --ttl (days) or --until (a date, end of that
+// day UTC, or an RFC 3339 time) into a membership expiry; nil means never.
+func parseMembershipExpiry(ttlDays int, until string, now time.Time) (*time.Time, error) {
+	until = strings.TrimSpace(until)
+	switch {
+	case ttlDays < 0:
+		return nil, fmt.Errorf("TTL must be a positive number of days")
+	case ttlDays > 0 && until != "":
+		return nil, fmt.Errorf("use either --ttl or --until, not both")
+	case ttlDays > 0:
+		t := now.AddDate(0, 0, ttlDays)
+		return &t, nil
+	case until == "":
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, until)
+	if err != nil {
+		d, dErr := time.Parse("2006-01-02", until)
+		if dErr != nil {
+			return nil, fmt.Errorf("--until must be a date (YYYY-MM-DD) or an RFC 3339 time")
+		}
+		t = d.Add(24*time.Hour - time.Second)
+	}
+	if !t.After(now) {
+		return nil, fmt.Errorf("the membership expiry must be in the future")
+	}
+	return &t, nil
+}
@@ -1,7 +1,9 @@
 package group
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"goBastion/internal/models"
 )
@@ -76,3 +78,90 @@ func TestAddMember_DuplicateReturnsError(t *testing.T) {
 		t.Fatal("expected duplicate member error")
 	}
 }
+
+func TestAddMember_WithTTLAndUntil(t *testing.T) {
+	db := newTestDB(t)
+	admin := newAdminUser(t, db, "admin")
+	alice := newRegularUser(t, db, "alice")
+	bob := newRegularUser(t, db, "bob")
+
+	g := models.Group{Name: "mygroup"}
+	if err := db.Create(&g).Error; err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+
+	if err := AddMember(db, admin, []string{"--group", "mygroup", "--user", "alice", "--role", "member", "--ttl", "7"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var ug models.UserGroup
+	db.Where("user_id = ?", alice.ID).First(&ug)
+	if ug.ExpiresAt == nil || time.Until(*ug.ExpiresAt) < 6*24*time.Hour || time.Until(*ug.ExpiresAt) > 7*24*time.Hour {
+		t.Fatalf("expected a 7-day expiry, got %v", ug.ExpiresAt)
+	}
+
+	until := time.Now().AddDate(0, 1, 0).Format("2006-01-02")
+	if err := AddMember(db, admin, []string{"--group", "mygroup", "--user", "bob", "--role", "member", "--until", until}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var bobUG models.UserGroup
+	db.Where("user_id = ?", bob.ID).First(&bobUG)
+	if bobUG.ExpiresAt == nil || bobUG.ExpiresAt.Format("2006-01-02") != until {
+		t.Fatalf("expected expiry on %s, got %v", until, bobUG.ExpiresAt)
+	}
+
+	for _, extra := range [][]string{
+		{"--ttl", "-1"},
+		{"--until", "2000-01-01"},
+		{"--ttl", "3", "--until", until},
+	} {
+		newRegularUser(t, db, "carol"+extra[1])
+		args := append([]string{"--group", "mygroup", "--user", "carol" + extra[1], "--role", "member"}, extra...)
+		if err := AddMember(db, admin, args); err == nil {
+			t.Errorf("expected %v to be refused", extra)
+		}
+	}
+}
+
+func TestExtendMember_OwnerChangesExpiry(t *testing.T) {
+	db := newTestDB(t)
+	owner := newRegularUser(t, db, "olga")
+	keeper := newRegularUser(t, db, "kim")
+	alice := newRegularUser(t, db, "alice")
+
+	g := models.Group{Name: "mygroup"}
+	if err := db.Create(&g).Error; err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	soon := time.Now().Add(time.Hour)
+	db.Create(&models.UserGroup{UserID: owner.ID, GroupID: g.ID, Role: models.GroupRoleOwner})
+	db.Create(&models.UserGroup{UserID: keeper.ID, GroupID: g.ID, Role: models.GroupRoleACLKeeper})
+	db.Create(&models.UserGroup{UserID: alice.ID, GroupID: g.ID, Role: models.GroupRoleMember, ExpiresAt: &soon})
+
+	if err := ExtendMember(db, keeper, []string{"--group", "mygroup", "--user", "alice", "--ttl", "30"}); err == nil {
+		t.Fatal("expected an aclkeeper to be refused")
+	}
+	if err := ExtendMember(db, owner, []string{"--group", "mygroup", "--user", "alice", "--ttl", "30", "--permanent"}); err == nil {
+		t.Fatal("expected conflicting flags to be refused")
+	}
+
+	if err := ExtendMember(db, owner, []string{"--group", "mygroup", "--user", "alice", "--ttl", "30"}); err != nil {
+		t.Fatalf("extend: %v", err)
+	}
+	var ug models.UserGroup
+	db.Where("user_id = ?", alice.ID).First(&ug)
+	if ug.ExpiresAt == nil || time.Until(*ug.ExpiresAt) < 29*24*time.Hour {
+		t.Fatalf("expected the expiry to move 30 days out, got %v", ug.ExpiresAt)
+	}
+	if !strings.Contains(ug.ExpiryLabel(time.Now()), "left") {
+		t.Fatalf("unexpected label %q", ug.ExpiryLabel(time.Now()))
+	}
+
+	if err := ExtendMember(db, owner, []string{"--group", "mygroup", "--user", "alice", "--permanent"}); err != nil {
+		t.Fatalf("make permanent: %v", err)
+	}
+	var permanent models.UserGroup
+	db.Where("user_id = ?", alice.ID).First(&permanent)
+	if permanent.ExpiresAt != nil {
+		t.Fatalf("expected no expiry, got %v", permanent.ExpiresAt)
+	}
+}
@@ -0,0 +1,175 @@
+package group
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/cryptokey"
+	"goBastion/internal/utils/egresskey"
+	"goBastion/internal/utils/revocation"
+	"goBastion/internal/utils/sshConnector"
+
+	"gorm.io/gorm"
+)
+
+// DeployEgressKey adds the group's active and next egress public keys to
+// the authorized_keys of the accounts of the group's accesses, or of the
+// given servers only. It logs in with a group key the target already
+// accepts, or asks once for a password. --remove-retired also takes out the
+// retired keys.
+func DeployEgressKey(db *gorm.DB, currentUser *models.User, log *slog.Logger, args []string) error {
+	fs := flag.NewFlagSet("groupDeployEgressKey", flag.ContinueOnError)
+	var groupName, servers string
+	var removeRetired bool
+	fs.StringVar(&groupName, "group", "", "Group name")
+	fs.StringVar(&servers, "server", "", "Comma-separated servers to deploy to (default: all group accesses)")
+	fs.BoolVar(&removeRetired, "remove-retired", false, "Remove retired group keys from authorized_keys")
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	if err := fs.Parse(args); err != nil || strings.TrimSpace(groupName) == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Deploy Egress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: groupDeployEgressKey --group <groupName> [--server <host>[,<host>...]] [--remove-retired]"}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	if !currentUser.CanDo(db, "groupDeployEgressKey", groupName) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Deploy Egress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You do not have permission to deploy egress keys for this group."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	var group models.Group
+	if err := db.Where("name = ?", groupName).First(&group).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Deploy Egress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("Group '%s' not found. Check spelling or run groupList.", groupName)}}},
+		})
+		return err
+	}
+
+	var keys []models.GroupEgressKey
+	if err := db.Where("group_id = ?", group.ID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Deploy Egress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Error fetching egress keys."}}},
+		})
+		return err
+	}
+	revoked, err := revocation.Revoked(db)
+	if err != nil {
+		return err
+	}
+	d := egresskey.Deployment{Password: egresskey.PromptPassword()}
+	// The keys in use log in first, in the order connections offer them.
+	for _, state := range []string{models.EgressKeyActive, models.EgressKeyNext, models.EgressKeyRetiring, models.EgressKeyRetired} {
+		for _, k := range keys {
+			if k.State != state {
+				continue
+			}
+			// A revoked key is taken off the targets and never logs in.
+			if revoked[k.Fingerprint] {
+				d.Remove = append(d.Remove, k.PubKey)
+				continue
+			}
+			switch state {
+			case models.EgressKeyActive, models.EgressKeyNext:
+				d.Add = append(d.Add, k.PubKey)
+			case models.EgressKeyRetired:
+				if removeRetired {
+					d.Remove = append(d.Remove, k.PubKey)
+				}
+				continue
+			}
+			d.Auth = append(d.Auth, cryptokey.DecryptOrPassThrough(k.PrivKey))
+		}
+	}
+	if len(d.Add) == 0 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Deploy Egress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "No Egress Key", Body: []string{fmt.Sprintf("Group '%s' has no egress key to deploy. Use groupGenerateEgressKey.", groupName)}}},
+		})
+		return fmt.Errorf("no egress key for group %s", groupName)
+	}
+
+	accesses, err := egresskey.GroupAccesses(db, group.ID, time.Now())
+	if err != nil {
+		return err
+	}
+	targets, skipped, err := egresskey.Expand(db, accesses, splitServers(servers))
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Deploy Egress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Server", Body: []string{err.Error() + ". Check groupListAccesses."}}},
+		})
+		return err
+	}
+
+	reports := egresskey.DeployAll(db, targets, d, func(t egresskey.Target) error {
+		return sshConnector.CheckAndUpdateHostKey(db, *currentUser, t.Server, t.Port)
+	})
+
+	var lines []string
+	failed := 0
+	for _, r := range reports {
+		lines = append(lines, r.String())
+		if r.Err != nil {
+			failed++
+			log.Warn("group_egress_key_deploy_failed", slog.String("user", currentUser.Username), slog.String("group", groupName),
+				slog.String("target", r.Target.String()), slog.String("error", r.Err.Error()))
+			continue
+		}
+		log.Info("group_egress_key_deployed", slog.String("user", currentUser.Username), slog.String("group", groupName),
+			slog.String("target", r.Target.String()), slog.Int("added", r.Added), slog.Int("removed", r.Removed), slog.String("method", r.Method))
+	}
+	for _, s := range skipped {
+		lines = append(lines, fmt.Sprintf("⚠️ %s: pattern access, name the host with --server", s))
+	}
+	if len(lines) == 0 {
+		lines = []string{fmt.Sprintf("Group '%s' has no accesses to deploy to.", groupName)}
+	}
+
+	blockType := "success"
+	if failed > 0 {
+		blockType = "warning"
+	}
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Deploy Egress Key",
+		BlockType: blockType,
+		Sections:  []console.SectionContent{{SubTitle: fmt.Sprintf("%d host(s), %d failed", len(reports), failed), Body: lines}},
+	})
+	if failed > 0 {
+		return fmt.Errorf("egress key deployment failed on %d host(s)", failed)
+	}
+	return nil
+}
+
+// splitServers splits a comma-separated --server value.
+func splitServers(value string) []string {
+	var servers []string
+	for _, s := range strings.Split(value, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			servers = append(servers, s)
+		}
+	}
+	return servers
+}
@@ -0,0 +1,122 @@
+package group
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+
+	"gorm.io/gorm"
+)
+
+// ExtendMember changes the expiry of a group membership in place: --ttl and
+// --until set a new expiry, --permanent removes it.
+func ExtendMember(db *gorm.DB, currentUser *models.User, args []string) error {
+	fs := flag.NewFlagSet("groupExtendMember", flag.ContinueOnError)
+	var groupName, username, until string
+	var ttlDays int
+	var permanent bool
+	fs.StringVar(&groupName, "group", "", "Group name")
+	fs.StringVar(&username, "user", "", "Member username")
+	fs.IntVar(&ttlDays, "ttl", 0, "New expiry in days from now")
+	fs.StringVar(&until, "until", "", "New expiry date (YYYY-MM-DD or RFC 3339)")
+	fs.BoolVar(&permanent, "permanent", false, "Remove the expiry")
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	err := fs.Parse(args)
+	given := 0
+	for _, set := range []bool{ttlDays != 0, strings.TrimSpace(until) != "", permanent} {
+		if set {
+			given++
+		}
+	}
+	if err != nil || strings.TrimSpace(groupName) == "" || strings.TrimSpace(username) == "" || given != 1 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Extend Membership",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: groupExtendMember --group <groupName> --user <username> --ttl <days> | --until <date> | --permanent"}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	if !currentUser.CanDo(db, "groupExtendMember", groupName) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Extend Membership",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"Only owners of the group can change membership expiry."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	var g models.Group
+	if err := db.Where("name = ?", groupName).First(&g).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Extend Membership",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("Group '%s' not found. Check spelling or run groupList.", groupName)}}},
+		})
+		return err
+	}
+
+	var u models.User
+	if err := db.Where("username = ?", username).First(&u).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Extend Membership",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{"User \"" + username + "\" not found. Check spelling or run accountList."}}},
+		})
+		return err
+	}
+
+	var membership models.UserGroup
+	if err := db.Where("user_id = ? AND group_id = ?", u.ID, g.ID).First(&membership).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Extend Membership",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("User '%s' is not a member of group '%s'.", username, groupName)}}},
+		})
+		return err
+	}
+
+	now := time.Now()
+	var expiresAt *time.Time
+	if !permanent {
+		if expiresAt, err = parseMembershipExpiry(ttlDays, until, now); err != nil {
+			console.DisplayBlock(console.ContentBlock{
+				Title:     "Extend Membership",
+				BlockType: "error",
+				Sections:  []console.SectionContent{{SubTitle: "Invalid Expiry", Body: []string{err.Error()}}},
+			})
+			return err
+		}
+	}
+
+	if err := db.Model(&membership).Update("expires_at", expiresAt).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Extend Membership",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Failed to update the membership."}}},
+		})
+		return err
+	}
+	models.InvalidateGroupsCache(u.ID)
+
+	msg := fmt.Sprintf("The membership of '%s' in group '%s' no longer expires.", username, groupName)
+	if expiresAt != nil {
+		msg = fmt.Sprintf("The membership of '%s' in group '%s' now expires at %s.", username, groupName, expiresAt.Format("2006-01-02 15:04:05"))
+	}
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Extend Membership",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Success", Body: []string{msg}}},
+	})
+	return nil
+}
@@ -82,9 +82,29 @@ func GenerateEgressKey(db *gorm.DB, currentUser *models.User, args []string) err
 		return err
 	}
 
+	newKey, err := newGroupEgressKey(currentUser, groupName, keyType, keySize)
+	if err != nil {
+		return err
+	}
+	newKey.GroupID = group.ID
+	if err = db.Create(&newKey).Error; err != nil {
+		return validation.WrapDBError(err, "error storing group egress key in database")
+	}
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Generate Egress Key",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Success", Body: []string{fmt.Sprintf("Egress key successfully generated for group '%s'.", groupName)}}},
+	})
+	return nil
+}
+
+// newGroupEgressKey generates a key pair for a group with ssh-keygen and
+// returns it with its private key encrypted, ready to be stored.
+func newGroupEgressKey(currentUser *models.User, groupName, keyType string, keySize int) (models.GroupEgressKey, error) {
 	tmpDir := filepath.Join(config.Get().Paths.HomeBaseDir, currentUser.Username, ".tmp")
 	if err := os.MkdirAll(tmpDir, 0700); err != nil {
-		return fmt.Errorf("error creating temporary directory: %w", err)
+		return models.GroupEgressKey{}, fmt.Errorf("error creating temporary directory: %w", err)
 	}
 	defer func() { _ = os.RemoveAll(tmpDir) }()
 	tmpFile := fmt.Sprintf("%s/sshkey_%s.pem", tmpDir, uuid.New().String())
@@ -93,46 +113,34 @@ func GenerateEgressKey(db *gorm.DB, currentUser *models.User, args []string) err
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("error generating SSH key: %v, %s", err, stderr.String())
+		return models.GroupEgressKey{}, fmt.Errorf("error generating SSH key: %v, %s", err, stderr.String())
 	}
 
 	privKeyBytes, err := os.ReadFile(tmpFile)
 	pubKeyBytes, errPub := os.ReadFile(tmpFile + ".pub")
 	if err != nil || errPub != nil {
-		return fmt.Errorf("error reading keys: %v %v", err, errPub)
+		return models.GroupEgressKey{}, fmt.Errorf("error reading keys: %v %v", err, errPub)
 	}
 
 	parsedKey, _, _, _, err := ssh.ParseAuthorizedKey(pubKeyBytes)
 	if err != nil {
-		return fmt.Errorf("invalid SSH key: %w", err)
+		return models.GroupEgressKey{}, fmt.Errorf("invalid SSH key: %w", err)
 	}
 
 	sha256Fingerprint := sha256.Sum256(parsedKey.Marshal())
 	fingerprint := base64.StdEncoding.EncodeToString(sha256Fingerprint[:])
-	keySize = sshkey.GetKeySize(parsedKey)
 
 	privKey := strings.TrimSpace(string(privKeyBytes))
 	encrypted, encErr := cryptokey.ReEncryptIfNeeded(privKey)
 	if encErr != nil {
-		return fmt.Errorf("error encrypting private key: %v", encErr)
+		return models.GroupEgressKey{}, fmt.Errorf("error encrypting private key: %v", encErr)
 	}
 
-	newKey := models.GroupEgressKey{
-		GroupID:     group.ID,
+	return models.GroupEgressKey{
 		PubKey:      strings.TrimSpace(string(pubKeyBytes)),
 		PrivKey:     encrypted,
 		Type:        keyType,
-		Size:        keySize,
+		Size:        sshkey.GetKeySize(parsedKey),
 		Fingerprint: fingerprint,
-	}
-	if err = db.Create(&newKey).Error; err != nil {
-		return validation.WrapDBError(err, "error storing group egress key in database")
-	}
-
-	console.DisplayBlock(console.ContentBlock{
-		Title:     "Generate Egress Key",
-		BlockType: "success",
-		Sections:  []console.SectionContent{{SubTitle: "Success", Body: []string{fmt.Sprintf("Egress key successfully generated for group '%s'.", groupName)}}},
-	})
-	return nil
+	}, nil
 }
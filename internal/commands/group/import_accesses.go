@@ -0,0 +1,227 @@
+package group
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/schedule"
+	"goBastion/internal/utils/validation"
+
+	"gorm.io/gorm"
+)
+
+// Columns of a groupImportAccesses file; they match the groupAddAccess
+// flags.
+var (
+	accessImportRequired = []string{"server", "username"}
+	accessImportOptional = []string{"port", "protocol", "comment", "from", "schedule", "ttl", "starts"}
+)
+
+// ImportAccesses adds the SSH accesses listed in a CSV file read from in to
+// a group. Every line is validated like groupAddAccess (without the
+// connectivity check); if any line is invalid nothing is added, otherwise all
+// accesses are created in one transaction. --dry-run only reports.
+func ImportAccesses(db *gorm.DB, currentUser *models.User, args []string, in io.Reader) error {
+	fs := flag.NewFlagSet("groupImportAccesses", flag.ContinueOnError)
+	var groupName string
+	var dryRun bool
+	fs.StringVar(&groupName, "group", "", "Group name")
+	fs.BoolVar(&dryRun, "dry-run", false, "Validate and report without adding anything")
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	if err := fs.Parse(args); err != nil || groupName == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Import Group Accesses",
+			BlockType: "error",
+			Sections: []console.SectionContent{{SubTitle: "Usage", Body: []string{
+				"Usage: groupImportAccesses --group <groupName> [--dry-run] < accesses.csv",
+				"Header line: server,username[,port][,protocol][,comment][,from][,schedule][,ttl][,starts]",
+			}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	if !currentUser.CanDo(db, "groupImportAccesses", groupName) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Import Group Accesses",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You do not have permission to add access for this group."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	var group models.Group
+	if err := db.Where("name = ?", groupName).First(&group).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Import Group Accesses",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("Group '%s' not found. Check spelling or run groupList.", groupName)}}},
+		})
+		return err
+	}
+
+	rows, lineErrs, err := readImportCSV(in, accessImportRequired, accessImportOptional)
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Import Group Accesses",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Input", Body: []string{err.Error()}}},
+		})
+		return err
+	}
+
+	var existing []models.GroupAccess
+	if err := db.Where("group_id = ? AND member_id IS NULL", group.ID).Find(&existing).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Import Group Accesses",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Database Error", Body: []string{"Database error while checking for existing accesses. Please try again."}}},
+		})
+		return err
+	}
+	seen := make(map[string]int)
+	for _, a := range existing {
+		seen[fmt.Sprintf("%s@%s:%d", a.Username, a.Server, a.Port)] = 0
+	}
+
+	now := time.Now()
+	var accesses []models.GroupAccess
+	var summary []string
+	for _, row := range rows {
+		access, err := parseAccessRow(row, now)
+		if err != nil {
+			lineErrs = append(lineErrs, lineError(row.line, "%v", err))
+			continue
+		}
+		key := fmt.Sprintf("%s@%s:%d", access.Username, access.Server, access.Port)
+		if first, dup := seen[key]; dup {
+			if first == 0 {
+				lineErrs = append(lineErrs, lineError(row.line, "access %s already exists in group '%s'", key, groupName))
+			} else {
+				lineErrs = append(lineErrs, lineError(row.line, "access %s already listed on line %d", key, first))
+			}
+			continue
+		}
+		seen[key] = row.line
+		access.GroupID = group.ID
+		accesses = append(accesses, access)
+		summary = append(summary, fmt.Sprintf("line %d: %s (%s)", row.line, key, access.Protocol))
+	}
+
+	if len(lineErrs) > 0 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Import Group Accesses",
+			BlockType: "error",
+			Sections: []console.SectionContent{
+				{SubTitle: fmt.Sprintf("%d invalid line(s)", len(lineErrs)), Body: lineErrs},
+				{SubTitle: "Nothing Imported", Body: []string{"Fix the lines above and run the import again."}},
+			},
+		})
+		return fmt.Errorf("%d invalid line(s) in import", len(lineErrs))
+	}
+
+	if dryRun {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Import Group Accesses",
+			BlockType: "info",
+			Sections:  []console.SectionContent{{SubTitle: fmt.Sprintf("Dry run: %d access(es) would be added to '%s'", len(accesses), groupName), Body: summary}},
+		})
+		return nil
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		for i := range accesses {
+			if err := tx.Create(&accesses[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Import Group Accesses",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Database Error", Body: []string{"Failed to create group accesses; nothing was imported."}}},
+		})
+		return err
+	}
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Import Group Accesses",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: fmt.Sprintf("%d access(es) added to '%s'", len(accesses), groupName), Body: summary}},
+	})
+	return nil
+}
+
+// parseAccessRow validates an import line the way groupAddAccess validates
+// its flags.
+func parseAccessRow(row csvRow, now time.Time) (models.GroupAccess, error) {
+	access := models.GroupAccess{
+		Server:      row.get("server"),
+		Username:    row.get("username"),
+		Port:        22,
+		Protocol:    "ssh",
+		Comment:     row.get("comment"),
+		AllowedFrom: row.get("from"),
+	}
+	if !validation.IsValidServerPattern(access.Server) {
+		return access, fmt.Errorf("server must be a hostname, IP, CIDR block (10.20.0.0/16) or hostname glob (*.db.example)")
+	}
+	if !validation.IsValidUsername(access.Username) {
+		return access, fmt.Errorf("invalid username %q", access.Username)
+	}
+	if p := row.get("port"); p != "" {
+		port, err := strconv.ParseInt(p, 10, 64)
+		if err != nil || !validation.IsValidPort(port) {
+			return access, fmt.Errorf("port must be between 1 and 65535")
+		}
+		access.Port = port
+	}
+	if p := row.get("protocol"); p != "" {
+		if !validation.IsValidProtocol(p) {
+			return access, fmt.Errorf("protocol must be one of: ssh, scpupload, scpdownload, sftp, rsync")
+		}
+		access.Protocol = p
+	}
+	if !validation.IsValidCIDRs(access.AllowedFrom) {
+		return access, fmt.Errorf("from must be a comma-separated list of valid CIDR notation (e.g. 10.0.0.0/8,192.168.1.0/24)")
+	}
+	if spec := row.get("schedule"); spec != "" {
+		sched, err := schedule.Parse(spec)
+		if err != nil {
+			return access, fmt.Errorf("invalid schedule: %v", err)
+		}
+		access.Schedule = sched.String()
+	}
+	startsAt, err := validation.ParseStartsAt(row.get("starts"), now)
+	if err != nil {
+		return access, err
+	}
+	access.StartsAt = startsAt
+	if t := row.get("ttl"); t != "" {
+		ttlDays, err := strconv.Atoi(t)
+		if err != nil || ttlDays < 0 {
+			return access, fmt.Errorf("TTL must be zero (never) or a positive number of days")
+		}
+		if ttlDays > 0 {
+			// The TTL of a scheduled access runs from its start.
+			from := now
+			if startsAt != nil {
+				from = *startsAt
+			}
+			expiresAt := from.AddDate(0, 0, ttlDays)
+			access.ExpiresAt = &expiresAt
+		}
+	}
+	return access, nil
+}
@@ -0,0 +1,90 @@
+package group
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// csvRow is a data line of an import file, its cells keyed by column name.
+type csvRow struct {
+	line  int
+	cells map[string]string
+}
+
+func (r csvRow) get(column string) string {
+	return strings.TrimSpace(r.cells[column])
+}
+
+// lineError is the report entry of an invalid line.
+func lineError(line int, format string, args ...any) string {
+	return fmt.Sprintf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// readImportCSV reads a CSV file whose first line names the columns. Column
+// names are case-insensitive; every required column must be present and
+// unknown ones are refused. Blank lines and lines starting with '#' are
+// ignored. Lines with the wrong number of fields are reported in lineErrs.
+func readImportCSV(in io.Reader, required, optional []string) (rows []csvRow, lineErrs []string, err error) {
+	r := csv.NewReader(in)
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, fmt.Errorf("empty input: expected a header line naming the columns")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	all := append(append([]string{}, required...), optional...)
+	known := make(map[string]bool, len(all))
+	for _, c := range all {
+		known[c] = true
+	}
+	columns := make([]string, len(header))
+	seen := make(map[string]bool, len(header))
+	for i, h := range header {
+		c := strings.ToLower(strings.TrimSpace(h))
+		if !known[c] {
+			return nil, nil, fmt.Errorf("unknown column %q (expected %s)", h, strings.Join(all, ", "))
+		}
+		if seen[c] {
+			return nil, nil, fmt.Errorf("column %q given twice", c)
+		}
+		seen[c] = true
+		columns[i] = c
+	}
+	for _, c := range required {
+		if !seen[c] {
+			return nil, nil, fmt.Errorf("missing required column %q", c)
+		}
+	}
+
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		line, _ := r.FieldPos(0)
+		if len(record) != len(columns) {
+			lineErrs = append(lineErrs, lineError(line, "expected %d fields, got %d", len(columns), len(record)))
+			continue
+		}
+		row := csvRow{line: line, cells: make(map[string]string, len(columns))}
+		for i, c := range columns {
+			row.cells[c] = record[i]
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 && len(lineErrs) == 0 {
+		return nil, nil, fmt.Errorf("no data lines after the header")
+	}
+	return rows, lineErrs, nil
+}
@@ -0,0 +1,200 @@
+package group
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/validation"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Columns of a groupImportMembers file; they match the groupAddMember flags.
+var (
+	memberImportRequired = []string{"user", "role"}
+	memberImportOptional = []string{"ttl", "until"}
+)
+
+// ImportMembers adds the users listed in a CSV file read from in to a group.
+// Every line is validated like groupAddMember; if any line is invalid nobody
+// is added, otherwise all memberships are created in one transaction.
+// --dry-run only reports.
+func ImportMembers(db *gorm.DB, currentUser *models.User, args []string, in io.Reader) error {
+	fs := flag.NewFlagSet("groupImportMembers", flag.ContinueOnError)
+	var groupName string
+	var dryRun bool
+	fs.StringVar(&groupName, "group", "", "Group name")
+	fs.BoolVar(&dryRun, "dry-run", false, "Validate and report without adding anything")
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	if err := fs.Parse(args); err != nil || strings.TrimSpace(groupName) == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Import Members",
+			BlockType: "error",
+			Sections: []console.SectionContent{{SubTitle: "Usage", Body: []string{
+				"Usage: groupImportMembers --group <groupName> [--dry-run] < members.csv",
+				"Header line: user,role[,ttl][,until]",
+			}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	if !currentUser.CanDo(db, "groupImportMembers", groupName) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Import Members",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You do not have permission to add members to this group."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	var g models.Group
+	if err := db.Where("name = ?", groupName).First(&g).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Import Members",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("Group not found: %s", groupName)}}},
+		})
+		return err
+	}
+
+	rows, lineErrs, err := readImportCSV(in, memberImportRequired, memberImportOptional)
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Import Members",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Input", Body: []string{err.Error()}}},
+		})
+		return err
+	}
+
+	var current []models.UserGroup
+	if err := db.Where("group_id = ?", g.ID).Find(&current).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Import Members",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Database Error", Body: []string{"Database error while checking for existing members. Please try again."}}},
+		})
+		return err
+	}
+	seen := make(map[uuid.UUID]int)
+	for _, ug := range current {
+		seen[ug.UserID] = 0
+	}
+
+	now := time.Now()
+	var memberships []models.UserGroup
+	var summary []string
+	for _, row := range rows {
+		username, role := row.get("user"), row.get("role")
+		if !validation.IsValidUsername(username) {
+			lineErrs = append(lineErrs, lineError(row.line, "invalid username %q", username))
+			continue
+		}
+		if !isValidGroupRole(role) {
+			lineErrs = append(lineErrs, lineError(row.line, "role must be one of: owner, aclkeeper, gatekeeper, member, guest"))
+			continue
+		}
+		ttlDays := 0
+		if t := row.get("ttl"); t != "" {
+			if ttlDays, err = strconv.Atoi(t); err != nil {
+				lineErrs = append(lineErrs, lineError(row.line, "TTL must be a positive number of days"))
+				continue
+			}
+		}
+		expiresAt, err := parseMembershipExpiry(ttlDays, row.get("until"), now)
+		if err != nil {
+			lineErrs = append(lineErrs, lineError(row.line, "%v", err))
+			continue
+		}
+		var u models.User
+		if err := db.Where("username = ?", username).First(&u).Error; err != nil {
+			lineErrs = append(lineErrs, lineError(row.line, "user not found: %s", username))
+			continue
+		}
+		if first, dup := seen[u.ID]; dup {
+			if first == 0 {
+				lineErrs = append(lineErrs, lineError(row.line, "user '%s' is already in group '%s'", username, groupName))
+			} else {
+				lineErrs = append(lineErrs, lineError(row.line, "user '%s' already listed on line %d", username, first))
+			}
+			continue
+		}
+		seen[u.ID] = row.line
+		memberships = append(memberships, models.UserGroup{UserID: u.ID, GroupID: g.ID, Role: role, ExpiresAt: expiresAt})
+		entry := fmt.Sprintf("line %d: %s as '%s'", row.line, username, role)
+		if expiresAt != nil {
+			entry += ", expires " + expiresAt.Format("2006-01-02 15:04:05")
+		}
+		summary = append(summary, entry)
+	}
+
+	if len(lineErrs) > 0 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Import Members",
+			BlockType: "error",
+			Sections: []console.SectionContent{
+				{SubTitle: fmt.Sprintf("%d invalid line(s)", len(lineErrs)), Body: lineErrs},
+				{SubTitle: "Nothing Imported", Body: []string{"Fix the lines above and run the import again."}},
+			},
+		})
+		return fmt.Errorf("%d invalid line(s) in import", len(lineErrs))
+	}
+
+	if dryRun {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Import Members",
+			BlockType: "info",
+			Sections:  []console.SectionContent{{SubTitle: fmt.Sprintf("Dry run: %d member(s) would be added to '%s'", len(memberships), groupName), Body: summary}},
+		})
+		return nil
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		for i := range memberships {
+			if err := tx.Create(&memberships[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Import Members",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Failed to add members to group; nothing was imported."}}},
+		})
+		return err
+	}
+	models.InvalidateGroupsCache(currentUser.ID)
+	for _, ug := range memberships {
+		models.InvalidateGroupsCache(ug.UserID)
+	}
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Import Members",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: fmt.Sprintf("%d member(s) added to '%s'", len(memberships), groupName), Body: summary}},
+	})
+	return nil
+}
+
+// isValidGroupRole reports whether role is one of the group roles.
+func isValidGroupRole(role string) bool {
+	switch role {
+	case models.GroupRoleOwner, models.GroupRoleACLKeeper, models.GroupRoleGatekeeper, models.GroupRoleMember, models.GroupRoleGuest:
+		return true
+	}
+	return false
+}
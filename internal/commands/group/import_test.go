@@ -0,0 +1,173 @@
+package group
+
+import (
+	"strings"
+	"testing"
+
+	"goBastion/internal/models"
+)
+
+func TestImportAccesses_AddsAllLines(t *testing.T) {
+	db := newTestDB(t)
+	admin := newAdminUser(t, db, "admin")
+	g := models.Group{Name: "mygroup"}
+	if err := db.Create(&g).Error; err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+
+	csv := "server,username,port,protocol,comment,ttl\n" +
+		"# migrated from the old bastion\n" +
+		"10.0.0.1,root,22,ssh,web,\n" +
+		"\n" +
+		"db1.example,postgres,2222,sftp,\"dump, weekly\",7\n"
+	if err := ImportAccesses(db, admin, []string{"--group", "mygroup"}, strings.NewReader(csv)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var accesses []models.GroupAccess
+	db.Where("group_id = ?", g.ID).Order("server").Find(&accesses)
+	if len(accesses) != 2 {
+		t.Fatalf("expected 2 accesses, got %d", len(accesses))
+	}
+	if a := accesses[1]; a.Server != "db1.example" || a.Port != 2222 || a.Protocol != "sftp" || a.Comment != "dump, weekly" || a.ExpiresAt == nil {
+		t.Fatalf("unexpected access %+v", a)
+	}
+	if accesses[0].ExpiresAt != nil {
+		t.Fatalf("an empty ttl must not set an expiry")
+	}
+}
+
+func TestImportAccesses_InvalidLineAddsNothing(t *testing.T) {
+	db := newTestDB(t)
+	admin := newAdminUser(t, db, "admin")
+	g := models.Group{Name: "mygroup"}
+	if err := db.Create(&g).Error; err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	db.Create(&models.GroupAccess{GroupID: g.ID, Server: "10.0.0.9", Username: "root", Port: 22})
+
+	csv := "server,username,port\n" +
+		"10.0.0.1,root,22\n" +
+		"10.0.0.2,root,99999\n" +
+		"10.0.0.1,root,22\n" +
+		"10.0.0.9,root,22\n" +
+		"10.0.0.3\n"
+	var err error
+	out := captureStdout(t, func() {
+		err = ImportAccesses(db, admin, []string{"--group", "mygroup"}, strings.NewReader(csv))
+	})
+	if err == nil {
+		t.Fatal("expected an error for invalid lines")
+	}
+	for _, want := range []string{
+		"line 3: port must be between 1 and 65535",
+		"line 4: access root@10.0.0.1:22 already listed on line 2",
+		"line 5: access root@10.0.0.9:22 already exists",
+		"line 6: expected 3 fields, got 1",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("report misses %q:\n%s", want, out)
+		}
+	}
+
+	var count int64
+	db.Model(&models.GroupAccess{}).Where("group_id = ?", g.ID).Count(&count)
+	if count != 1 {
+		t.Fatalf("nothing must be imported when a line is invalid, got %d accesses", count)
+	}
+}
+
+func TestImportAccesses_DryRunAndColumns(t *testing.T) {
+	db := newTestDB(t)
+	admin := newAdminUser(t, db, "admin")
+	if err := db.Create(&models.Group{Name: "mygroup"}).Error; err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+
+	if err := ImportAccesses(db, admin, []string{"--group", "mygroup", "--dry-run"}, strings.NewReader("Server,Username\nsrv1,root\n")); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	var count int64
+	db.Model(&models.GroupAccess{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("dry run must not add accesses, got %d", count)
+	}
+
+	if err := ImportAccesses(db, admin, []string{"--group", "mygroup"}, strings.NewReader("server,host\nsrv1,root\n")); err == nil {
+		t.Fatal("expected an error for an unknown column")
+	}
+	if err := ImportAccesses(db, admin, []string{"--group", "mygroup"}, strings.NewReader("server\nsrv1\n")); err == nil {
+		t.Fatal("expected an error for a missing required column")
+	}
+}
+
+func TestImportAccesses_RequiresGroupRight(t *testing.T) {
+	db := newTestDB(t)
+	user := newRegularUser(t, db, "alice")
+	if err := db.Create(&models.Group{Name: "mygroup"}).Error; err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+
+	if err := ImportAccesses(db, user, []string{"--group", "mygroup"}, strings.NewReader("server,username\nsrv1,root\n")); err == nil {
+		t.Fatal("expected access denied for a non-member")
+	}
+}
+
+func TestImportMembers_AddsAllLines(t *testing.T) {
+	db := newTestDB(t)
+	admin := newAdminUser(t, db, "admin")
+	alice := newRegularUser(t, db, "alice")
+	newRegularUser(t, db, "bob")
+	g := models.Group{Name: "mygroup"}
+	if err := db.Create(&g).Error; err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+
+	csv := "user,role,ttl\nalice,owner,\nbob,member,30\n"
+	if err := ImportMembers(db, admin, []string{"--group", "mygroup"}, strings.NewReader(csv)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var ug models.UserGroup
+	if err := db.Where("group_id = ? AND user_id = ?", g.ID, alice.ID).First(&ug).Error; err != nil || ug.Role != "owner" || ug.ExpiresAt != nil {
+		t.Fatalf("unexpected membership for alice: %+v, %v", ug, err)
+	}
+	var count int64
+	db.Model(&models.UserGroup{}).Where("group_id = ? AND expires_at IS NOT NULL", g.ID).Count(&count)
+	if count != 1 {
+		t.Fatalf("expected bob's membership to expire, got %d expiring memberships", count)
+	}
+}
+
+func TestImportMembers_InvalidLineAddsNothing(t *testing.T) {
+	db := newTestDB(t)
+	admin := newAdminUser(t, db, "admin")
+	newRegularUser(t, db, "alice")
+	if err := db.Create(&models.Group{Name: "mygroup"}).Error; err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+
+	csv := "user,role,until\nalice,member,\nghost,member,\nalice,owner,\nalice,boss,\n"
+	var err error
+	out := captureStdout(t, func() {
+		err = ImportMembers(db, admin, []string{"--group", "mygroup"}, strings.NewReader(csv))
+	})
+	if err == nil {
+		t.Fatal("expected an error for invalid lines")
+	}
+	for _, want := range []string{
+		"line 3: user not found: ghost",
+		"line 4: user 'alice' already listed on line 2",
+		"line 5: role must be one of",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("report misses %q:\n%s", want, out)
+		}
+	}
+
+	var count int64
+	db.Model(&models.UserGroup{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("nothing must be imported when a line is invalid, got %d memberships", count)
+	}
+}
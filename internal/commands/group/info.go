@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"goBastion/internal/models"
 	"goBastion/internal/utils"
 	"goBastion/internal/utils/console"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -64,18 +67,47 @@ func Info(db *gorm.DB, currentUser *models.User, args []string) error {
 		fmt.Sprintf("Name: %s", g.Name),
 		fmt.Sprintf("JIT MFA: %s", map[bool]string{true: "✅ Required", false: "❌ Not required"}[g.MFARequired]),
 	}
+	switch {
+	case g.JustificationRequired && g.JustificationPattern != "":
+		infoLines = append(infoLines, fmt.Sprintf("Justification: ✅ Required (format: %s)", g.JustificationPattern))
+	case g.JustificationRequired:
+		infoLines = append(infoLines, "Justification: ✅ Required")
+	default:
+		infoLines = append(infoLines, "Justification: ❌ Not required")
+	}
 
 	if len(userGroups) > 0 {
 		infoLines = append(infoLines, "Members:")
+		now := time.Now()
 		for _, ug := range userGroups {
 			roleColored := utils.RoleColor(ug)
 
-			infoLines = append(infoLines, fmt.Sprintf(" - %s - %s", ug.User.Username, roleColored))
+			line := fmt.Sprintf(" - %s - %s", ug.User.Username, roleColored)
+			if ug.ExpiresAt != nil {
+				line += " - " + ug.ExpiryLabel(now)
+			}
+			infoLines = append(infoLines, line)
 		}
 	} else {
 		infoLines = append(infoLines, "Members: None")
 	}
 
+	inherited, err := models.InheritedGroups(db, []uuid.UUID{g.ID})
+	var included map[uuid.UUID][]uuid.UUID
+	if err == nil {
+		included, err = models.IncludedGroups(db, g.ID)
+	}
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Group Info",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Database Error", Body: []string{"Failed to load nested groups."}}},
+		})
+		return err
+	}
+	infoLines = append(infoLines, inclusionLines(db, "Inherits accesses from:", inherited)...)
+	infoLines = append(infoLines, inclusionLines(db, "Included groups (inherit these accesses):", included)...)
+
 	console.DisplayBlock(console.ContentBlock{
 		Title:     "Group Info",
 		BlockType: "success",
@@ -83,3 +115,16 @@ func Info(db *gorm.DB, currentUser *models.User, args []string) error {
 	})
 	return nil
 }
+
+// inclusionLines renders nested-group paths under title, sorted by path.
+func inclusionLines(db *gorm.DB, title string, paths map[uuid.UUID][]uuid.UUID) []string {
+	if len(paths) == 0 {
+		return nil
+	}
+	rendered := make([]string, 0, len(paths))
+	for _, path := range paths {
+		rendered = append(rendered, " - "+models.GroupPathNames(db, path))
+	}
+	sort.Strings(rendered)
+	return append([]string{title}, rendered...)
+}
@@ -49,7 +49,7 @@ func ListEgressKeys(db *gorm.DB, currentUser *models.User, args []string) error
 	}
 
 	var keys []models.GroupEgressKey
-	if err := db.Where("group_id = ?", group.ID).Find(&keys).Error; err != nil {
+	if err := db.Where("group_id = ?", group.ID).Order("created_at").Find(&keys).Error; err != nil {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "List Egress Keys",
 			BlockType: "error",
@@ -72,6 +72,7 @@ func ListEgressKeys(db *gorm.DB, currentUser *models.User, args []string) error
 		section := console.SectionContent{
 			SubTitle: fmt.Sprintf("Key ID: %s", key.ID.String()),
 			Body: []string{
+				fmt.Sprintf("State: %s", key.State),
 				fmt.Sprintf("Type: %s", key.Type),
 				fmt.Sprintf("Fingerprint: %s", key.Fingerprint),
 				fmt.Sprintf("Size: %d", key.Size),
@@ -79,6 +80,9 @@ func ListEgressKeys(db *gorm.DB, currentUser *models.User, args []string) error
 				fmt.Sprintf("Public Key: %s", key.PubKey),
 			},
 		}
+		if key.RetireAfter != nil && (key.State == models.EgressKeyActive || key.State == models.EgressKeyRetiring) {
+			section.Body = append(section.Body, fmt.Sprintf("Usable Until: %s at least", key.RetireAfter.Format("2006-01-02 15:04:05")))
+		}
 		sections = append(sections, section)
 	}
 
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"goBastion/internal/models"
+	"goBastion/internal/utils"
 	"goBastion/internal/utils/console"
 
 	"gorm.io/gorm"
@@ -90,14 +91,7 @@ func ListGuestAccesses(db *gorm.DB, currentUser *models.User, args []string) err
 	var bodyLines []string
 	now := time.Now()
 	for _, g := range grants {
-		expires := "Never"
-		if g.ExpiresAt != nil {
-			if g.ExpiresAt.Before(now) {
-				expires = "EXPIRED(" + g.ExpiresAt.Format("2006-01-02") + ")"
-			} else {
-				expires = g.ExpiresAt.Format("2006-01-02")
-			}
-		}
+		expires := utils.ExpiryLabel(g.StartsAt, g.ExpiresAt, now)
 		proto := g.Protocol
 		if proto == "" {
 			proto = "ssh"
@@ -106,8 +100,8 @@ func ListGuestAccesses(db *gorm.DB, currentUser *models.User, args []string) err
 		if allowedFrom == "" {
 			allowedFrom = "*"
 		}
-		line := fmt.Sprintf("  %s  %s@%s:%d  proto=%s  from=%s  expires=%s",
-			g.ID.String()[:8], g.Username, g.Server, g.Port, proto, allowedFrom, expires)
+		line := fmt.Sprintf("  %s  %s@%s:%d  proto=%s  from=%s  schedule=%s  expires=%s",
+			g.ID.String()[:8], g.Username, g.Server, g.Port, proto, allowedFrom, utils.ScheduleLabel(g.Schedule), expires)
 		if g.Comment != "" {
 			line += "  (" + g.Comment + ")"
 		}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"goBastion/internal/models"
+	"goBastion/internal/utils"
 	"goBastion/internal/utils/console"
 
 	"gorm.io/gorm"
@@ -90,14 +91,7 @@ func ListGuestDBAccesses(db *gorm.DB, currentUser *models.User, args []string) e
 	var bodyLines []string
 	now := time.Now()
 	for _, g := range grants {
-		expires := "Never"
-		if g.ExpiresAt != nil {
-			if g.ExpiresAt.Before(now) {
-				expires = "EXPIRED(" + g.ExpiresAt.Format("2006-01-02") + ")"
-			} else {
-				expires = g.ExpiresAt.Format("2006-01-02")
-			}
-		}
+		expires := utils.ExpiryLabel(g.StartsAt, g.ExpiresAt, now)
 		dbName := g.Database
 		if dbName == "" {
 			dbName = "*"
@@ -106,8 +100,8 @@ func ListGuestDBAccesses(db *gorm.DB, currentUser *models.User, args []string) e
 		if allowedFrom == "" {
 			allowedFrom = "*"
 		}
-		line := fmt.Sprintf("  %s  %s@%s:%d  proto=%s  db=%s  from=%s  expires=%s",
-			g.ID.String()[:8], g.Username, g.Host, g.Port, g.Protocol, dbName, allowedFrom, expires)
+		line := fmt.Sprintf("  %s  %s@%s:%d  proto=%s  db=%s  from=%s  schedule=%s  expires=%s",
+			g.ID.String()[:8], g.Username, g.Host, g.Port, g.Protocol, dbName, allowedFrom, utils.ScheduleLabel(g.Schedule), expires)
 		if g.Comment != "" {
 			line += "  (" + g.Comment + ")"
 		}
@@ -0,0 +1,41 @@
+package group
+
+import (
+	"testing"
+
+	"goBastion/internal/models"
+)
+
+func TestModifyAccess_ManagerOnly(t *testing.T) {
+	db := newTestDB(t)
+	admin := newAdminUser(t, db, "admin")
+	alice := newRegularUser(t, db, "alice")
+
+	g := models.Group{Name: "mygroup"}
+	if err := db.Create(&g).Error; err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	if err := db.Create(&models.UserGroup{UserID: alice.ID, GroupID: g.ID, Role: models.GroupRoleMember}).Error; err != nil {
+		t.Fatalf("seed membership: %v", err)
+	}
+	access := models.GroupAccess{GroupID: g.ID, Server: "10.0.0.1", Username: "deploy", Port: 22, Protocol: "ssh"}
+	if err := db.Create(&access).Error; err != nil {
+		t.Fatalf("seed access: %v", err)
+	}
+
+	args := []string{"--group", "mygroup", "--access", access.ID.String(), "--schedule", "Mon-Fri 08:00-19:00"}
+	if err := ModifyAccess(db, alice, args); err == nil {
+		t.Fatal("expected a plain member to be denied")
+	}
+	if err := ModifyAccess(db, admin, args); err != nil {
+		t.Fatalf("ModifyAccess: %v", err)
+	}
+
+	var got models.GroupAccess
+	if err := db.Where("id = ?", access.ID).First(&got).Error; err != nil {
+		t.Fatalf("reload access: %v", err)
+	}
+	if got.Schedule != "Mon-Fri 08:00-19:00" {
+		t.Fatalf("Schedule = %q, want the new window", got.Schedule)
+	}
+}
@@ -0,0 +1,103 @@
+package group
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"time"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/accessedit"
+	"goBastion/internal/utils/console"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ModifyDBAccess changes fields of a group database access entry in place,
+// keeping its ID and LastConnection.
+func ModifyDBAccess(db *gorm.DB, currentUser *models.User, args []string) error {
+	fs := flag.NewFlagSet("groupModifyDBAccess", flag.ContinueOnError)
+	var groupName, accessIDStr string
+	fs.StringVar(&groupName, "group", "", "Group name")
+	fs.StringVar(&accessIDStr, "id", "", "Access ID to modify")
+	fields := accessedit.Register(fs, true)
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	if err := fs.Parse(args); err != nil || groupName == "" || accessIDStr == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Group DB Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: groupModifyDBAccess --group <groupName> --id <access_id> " + fields.Usage()}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	if !currentUser.CanDo(db, "groupModifyDBAccess", groupName) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Group DB Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You do not have permission to modify database access for this group."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	var group models.Group
+	if err := db.Where("name = ?", groupName).First(&group).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Group DB Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("Group '%s' not found. Check spelling or run groupList.", groupName)}}},
+		})
+		return err
+	}
+
+	accessID, err := uuid.Parse(accessIDStr)
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Group DB Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid ID", Body: []string{"Invalid access ID format."}}},
+		})
+		return err
+	}
+
+	var access models.GroupDBAccess
+	if err := db.Where("id = ? AND group_id = ?", accessID, group.ID).First(&access).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Group DB Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{"Access entry not found or does not belong to this group."}}},
+		})
+		return err
+	}
+
+	updates, err := fields.Updates(access.StartsAt, access.ExpiresAt, time.Now())
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Group DB Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Value", Body: []string{err.Error()}}},
+		})
+		return err
+	}
+	if err := db.Model(&access).Updates(updates).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Group DB Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Database Error", Body: []string{"Error modifying group DB access."}}},
+		})
+		return err
+	}
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Modify Group DB Access",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Success", Body: []string{fmt.Sprintf("Group DB access modified for group '%s'.", groupName)}}},
+	})
+	return nil
+}
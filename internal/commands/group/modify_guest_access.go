@@ -0,0 +1,103 @@
+package group
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"time"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/accessedit"
+	"goBastion/internal/utils/console"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ModifyGuestAccess changes fields of a guest access grant in place, keeping
+// its ID.
+func ModifyGuestAccess(db *gorm.DB, currentUser *models.User, args []string) error {
+	fs := flag.NewFlagSet("groupModifyGuestAccess", flag.ContinueOnError)
+	var groupName, grantIDStr string
+	fs.StringVar(&groupName, "group", "", "Group name")
+	fs.StringVar(&grantIDStr, "grant", "", "Grant ID to modify (from groupListGuestAccesses)")
+	fields := accessedit.Register(fs, false)
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	if err := fs.Parse(args); err != nil || groupName == "" || grantIDStr == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Guest Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: groupModifyGuestAccess --group <groupName> --grant <grant_id> " + fields.Usage()}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	if !currentUser.CanDo(db, "groupModifyGuestAccess", groupName) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Guest Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You do not have permission to modify guest access for this group."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	var group models.Group
+	if err := db.Where("name = ?", groupName).First(&group).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Guest Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("Group '%s' not found. Check spelling or run groupList.", groupName)}}},
+		})
+		return err
+	}
+
+	grantID, err := uuid.Parse(grantIDStr)
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Guest Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid ID", Body: []string{"Invalid grant ID format."}}},
+		})
+		return err
+	}
+
+	var grant models.GroupGuestAccess
+	if err := db.Where("id = ? AND group_id = ?", grantID, group.ID).First(&grant).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Guest Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{"Guest access grant not found in this group."}}},
+		})
+		return err
+	}
+
+	updates, err := fields.Updates(grant.StartsAt, grant.ExpiresAt, time.Now())
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Guest Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Value", Body: []string{err.Error()}}},
+		})
+		return err
+	}
+	if err := db.Model(&grant).Updates(updates).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Guest Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Database Error", Body: []string{"Error modifying guest access."}}},
+		})
+		return err
+	}
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Modify Guest Access",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Success", Body: []string{fmt.Sprintf("Guest access grant modified in group '%s'.", groupName)}}},
+	})
+	return nil
+}
@@ -0,0 +1,103 @@
+package group
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"time"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/accessedit"
+	"goBastion/internal/utils/console"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ModifyGuestDBAccess changes fields of a guest database access grant in
+// place, keeping its ID.
+func ModifyGuestDBAccess(db *gorm.DB, currentUser *models.User, args []string) error {
+	fs := flag.NewFlagSet("groupModifyGuestDBAccess", flag.ContinueOnError)
+	var groupName, grantIDStr string
+	fs.StringVar(&groupName, "group", "", "Group name")
+	fs.StringVar(&grantIDStr, "grant", "", "Grant ID to modify (from groupListGuestDBAccesses)")
+	fields := accessedit.Register(fs, true)
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	if err := fs.Parse(args); err != nil || groupName == "" || grantIDStr == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Guest DB Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: groupModifyGuestDBAccess --group <groupName> --grant <grant_id> " + fields.Usage()}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	if !currentUser.CanDo(db, "groupModifyGuestDBAccess", groupName) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Guest DB Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You do not have permission to modify guest database access for this group."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	var group models.Group
+	if err := db.Where("name = ?", groupName).First(&group).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Guest DB Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("Group '%s' not found. Check spelling or run groupList.", groupName)}}},
+		})
+		return err
+	}
+
+	grantID, err := uuid.Parse(grantIDStr)
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Guest DB Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid ID", Body: []string{"Invalid grant ID format."}}},
+		})
+		return err
+	}
+
+	var grant models.GroupGuestDBAccess
+	if err := db.Where("id = ? AND group_id = ?", grantID, group.ID).First(&grant).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Guest DB Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{"Guest DB access grant not found in this group."}}},
+		})
+		return err
+	}
+
+	updates, err := fields.Updates(grant.StartsAt, grant.ExpiresAt, time.Now())
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Guest DB Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Value", Body: []string{err.Error()}}},
+		})
+		return err
+	}
+	if err := db.Model(&grant).Updates(updates).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Guest DB Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Database Error", Body: []string{"Error modifying guest DB access."}}},
+		})
+		return err
+	}
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Modify Guest DB Access",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Success", Body: []string{fmt.Sprintf("Guest DB access grant modified in group '%s'.", groupName)}}},
+	})
+	return nil
+}
@@ -0,0 +1,259 @@
+package group
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/egresskey"
+	"goBastion/internal/utils/validation"
+
+	"gorm.io/gorm"
+)
+
+// RotateEgressKey rotates a group's egress key. Without a mode flag it
+// creates the group's next key; both keys are offered to targets until the
+// next key is verified on each of them (by the sync, or --verify), then it
+// becomes active and the previous key is retired once the grace period is
+// over. --status shows the verification progress, --promote makes the next
+// key active without waiting for verification and --cancel abandons it.
+func RotateEgressKey(db *gorm.DB, currentUser *models.User, log *slog.Logger, args []string) error {
+	fs := flag.NewFlagSet("groupRotateEgressKey", flag.ContinueOnError)
+	var groupName, keyType string
+	var keySize, graceDays int
+	var status, verify, promote, cancel bool
+	fs.StringVar(&groupName, "group", "", "Group name")
+	fs.StringVar(&keyType, "type", "ed25519", "Key type (e.g., rsa, ed25519)")
+	fs.IntVar(&keySize, "size", 256, "Key size (e.g., 2048)")
+	fs.IntVar(&graceDays, "grace", 7, "Days the previous key stays usable at least")
+	fs.BoolVar(&status, "status", false, "Show the rotation progress")
+	fs.BoolVar(&verify, "verify", false, "Probe the targets with the next key now")
+	fs.BoolVar(&promote, "promote", false, "Make the next key active without waiting for verification")
+	fs.BoolVar(&cancel, "cancel", false, "Abandon the next key")
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	err := fs.Parse(args)
+	modes := 0
+	for _, m := range []bool{status, verify, promote, cancel} {
+		if m {
+			modes++
+		}
+	}
+	if err != nil || strings.TrimSpace(groupName) == "" || modes > 1 || graceDays < 0 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Rotate Egress Key",
+			BlockType: "error",
+			Sections: []console.SectionContent{{SubTitle: "Usage", Body: []string{
+				"Usage: groupRotateEgressKey --group <groupName> [--type <keyType>] [--size <keySize>] [--grace <days>]",
+				"       groupRotateEgressKey --group <groupName> --status | --verify | --promote | --cancel",
+			}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	if !currentUser.CanDo(db, "groupRotateEgressKey", groupName) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Rotate Egress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You do not have permission to rotate egress keys for this group."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	var group models.Group
+	if err := db.Where("name = ?", groupName).First(&group).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Rotate Egress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("Group '%s' not found. Check spelling or run groupList.", groupName)}}},
+		})
+		return err
+	}
+
+	log = log.With(slog.String("user", currentUser.Username))
+	now := time.Now()
+	switch {
+	case status:
+		return showRotation(db, group, nil, now)
+	case verify:
+		if err := showRotation(db, group, egresskey.DialProbe, now); err != nil {
+			return err
+		}
+		return promoteIfVerified(db, log, group, now)
+	case promote:
+		err := egresskey.Promote(db, log, group, "promoted by "+currentUser.Username, now)
+		return reportRotationChange(err, groupName, "Next key promoted",
+			"The next key is now active; the previous key is retired once its grace period is over.")
+	case cancel:
+		err := egresskey.Cancel(db, log, group, now)
+		return reportRotationChange(err, groupName, "Rotation cancelled",
+			"The next key is retired; the active key stays in use.")
+	}
+
+	validKeyTypes := map[string]bool{"ed25519": true, "rsa": true, "ecdsa": true}
+	if !validKeyTypes[keyType] {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Rotate Egress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Key Type", Body: []string{"Key type must be one of: ed25519, rsa, ecdsa"}}},
+		})
+		return nil
+	}
+	if keyType == "rsa" && keySize < 2048 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Rotate Egress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Weak Key Size", Body: []string{"RSA key size must be at least 2048 bits."}}},
+		})
+		return nil
+	}
+
+	var active int64
+	if err := db.Model(&models.GroupEgressKey{}).Where("group_id = ? AND state = ?", group.ID, models.EgressKeyActive).Count(&active).Error; err != nil {
+		return validation.WrapDBError(err, "error retrieving group egress keys")
+	}
+	if active == 0 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Rotate Egress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "No Active Key", Body: []string{fmt.Sprintf("Group '%s' has no active egress key to rotate. Use groupGenerateEgressKey.", groupName)}}},
+		})
+		return fmt.Errorf("no active egress key for group %s", groupName)
+	}
+
+	next, err := newGroupEgressKey(currentUser, groupName, keyType, keySize)
+	if err != nil {
+		return err
+	}
+	if err := egresskey.StartRotation(db, log, group, &next, time.Duration(graceDays)*24*time.Hour, now); err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Rotate Egress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{err.Error()}}},
+		})
+		return err
+	}
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Rotate Egress Key",
+		BlockType: "success",
+		Sections: []console.SectionContent{
+			{SubTitle: fmt.Sprintf("Next key created for group '%s'", groupName), Body: []string{
+				fmt.Sprintf("Fingerprint: %s", next.Fingerprint),
+				fmt.Sprintf("Public Key: %s", next.PubKey),
+			}},
+			{SubTitle: "Next Steps", Body: []string{
+				"Add the public key above to the authorized_keys of every target of the group.",
+				"Both keys are offered when connecting until the next key is verified on every target.",
+				fmt.Sprintf("The previous key then stays usable until %s at least, and is retired after.", now.AddDate(0, 0, graceDays).Format("2006-01-02 15:04")),
+				"Follow the progress with: groupRotateEgressKey --group " + groupName + " --status",
+			}},
+		},
+	})
+	return nil
+}
+
+// showRotation displays the verification progress of a group's next key,
+// probing the targets not verified yet when probe is set.
+func showRotation(db *gorm.DB, group models.Group, probe egresskey.Probe, now time.Time) error {
+	st, err := egresskey.Check(db, group, probe, now)
+	if errors.Is(err, egresskey.ErrNoRotation) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Rotate Egress Key",
+			BlockType: "info",
+			Sections:  []console.SectionContent{{SubTitle: "No Rotation", Body: []string{fmt.Sprintf("No key rotation in progress for group '%s'.", group.Name)}}},
+		})
+		return nil
+	}
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Rotate Egress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Error checking the key rotation."}}},
+		})
+		return err
+	}
+
+	var targets []string
+	for _, r := range st.Results {
+		switch {
+		case r.VerifiedAt != nil:
+			targets = append(targets, fmt.Sprintf("✅ %s (verified %s)", r.Target, r.VerifiedAt.Format("2006-01-02 15:04")))
+		case r.Err != nil:
+			targets = append(targets, fmt.Sprintf("❌ %s: %v", r.Target, r.Err))
+		default:
+			targets = append(targets, fmt.Sprintf("⏳ %s (not verified yet)", r.Target))
+		}
+	}
+	for _, p := range st.Unprobable {
+		targets = append(targets, fmt.Sprintf("⚠️ %s: pattern access, verify it manually and use --promote", p))
+	}
+	if len(targets) == 0 {
+		targets = []string{"The group has no accesses: the next key is promoted at the next sync."}
+	}
+
+	blockType := "info"
+	if st.Verified() {
+		blockType = "success"
+	}
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Rotate Egress Key",
+		BlockType: blockType,
+		Sections: []console.SectionContent{
+			{SubTitle: fmt.Sprintf("Next key of group '%s'", group.Name), Body: []string{
+				fmt.Sprintf("Fingerprint: %s", st.Next.Fingerprint),
+				fmt.Sprintf("Public Key: %s", st.Next.PubKey),
+			}},
+			{SubTitle: "Targets", Body: targets},
+		},
+	})
+	return nil
+}
+
+// promoteIfVerified promotes the group's next key when it reached every
+// target.
+func promoteIfVerified(db *gorm.DB, log *slog.Logger, group models.Group, now time.Time) error {
+	st, err := egresskey.Check(db, group, nil, now)
+	if err != nil || !st.Verified() {
+		return nil
+	}
+	err = egresskey.Promote(db, log, group, "verified on all targets", now)
+	return reportRotationChange(err, group.Name, "Next key promoted",
+		"The next key is now active; the previous key is retired once its grace period is over.")
+}
+
+// reportRotationChange displays the outcome of a promotion or cancellation.
+func reportRotationChange(err error, groupName, subTitle, body string) error {
+	if errors.Is(err, egresskey.ErrNoRotation) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Rotate Egress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "No Rotation", Body: []string{fmt.Sprintf("No key rotation in progress for group '%s'.", groupName)}}},
+		})
+		return err
+	}
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Rotate Egress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Error changing the key states."}}},
+		})
+		return err
+	}
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Rotate Egress Key",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: subTitle, Body: []string{body}}},
+	})
+	return nil
+}
@@ -0,0 +1,98 @@
+package group
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log/slog"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/justification"
+
+	"gorm.io/gorm"
+)
+
+// SetJustification enables or disables the connection justification requirement for a group.
+// When enabled, users connecting via this group must give a reason or ticket ID matching the
+// optional pattern, either at a prompt or with --reason.
+func SetJustification(db *gorm.DB, currentUser *models.User, log *slog.Logger, args []string) error {
+	fs := flag.NewFlagSet("groupSetJustification", flag.ContinueOnError)
+	var groupName, pattern string
+	var required, optional bool
+	fs.StringVar(&groupName, "group", "", "Group name")
+	fs.BoolVar(&required, "required", false, "Require a justification for this group")
+	fs.BoolVar(&optional, "optional", false, "Remove the justification requirement for this group")
+	fs.StringVar(&pattern, "pattern", "", "Regular expression the whole justification must match")
+	var buf bytes.Buffer
+	fs.SetOutput(&buf)
+
+	if err := fs.Parse(args); err != nil || groupName == "" || required == optional || (optional && pattern != "") {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Group Set Justification",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"groupSetJustification --group <name> --required [--pattern <regex>] | --optional"}}},
+		})
+		return nil
+	}
+
+	if _, err := justification.CompilePattern(pattern); err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Group Set Justification",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Pattern", Body: []string{err.Error()}}},
+		})
+		return err
+	}
+
+	if !currentUser.CanDo(db, "groupSetJustification", groupName) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Group Set Justification",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"Only group owners or admins can set the justification policy."}}},
+		})
+		return nil
+	}
+
+	var group models.Group
+	if err := db.Where("name = ?", groupName).First(&group).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Group Set Justification",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("Group '%s' not found. Check spelling or run groupList.", groupName)}}},
+		})
+		return err
+	}
+
+	if err := db.Model(&group).Updates(map[string]interface{}{
+		"justification_required": required,
+		"justification_pattern":  pattern,
+	}).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Group Set Justification",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Failed to update justification setting."}}},
+		})
+		return err
+	}
+
+	log.Info("group_justification_policy_updated",
+		slog.String("admin", currentUser.Username),
+		slog.String("group", groupName),
+		slog.Bool("justification_required", required),
+		slog.String("justification_pattern", pattern),
+	)
+	message := "Connection justification disabled for group " + groupName
+	if required {
+		message = "Connection justification required for group " + groupName
+		if pattern != "" {
+			message += fmt.Sprintf(" (format: %s)", pattern)
+		}
+	}
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Group Set Justification",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Success", Body: []string{message}}},
+	})
+	return nil
+}
@@ -0,0 +1,188 @@
+package group
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// errInclusionCycle is returned when including a group would make it inherit from itself.
+var errInclusionCycle = errors.New("group inclusion would create a cycle")
+
+// AddSubgroup includes a group in another one: non-guest members of the
+// subgroup inherit the parent group's SSH and database accesses.
+func AddSubgroup(db *gorm.DB, currentUser *models.User, args []string) error {
+	fs := flag.NewFlagSet("groupAddSubgroup", flag.ContinueOnError)
+	var groupName, subgroupName string
+	fs.StringVar(&groupName, "group", "", "Parent group name")
+	fs.StringVar(&subgroupName, "subgroup", "", "Group to include")
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	if err := fs.Parse(args); err != nil || strings.TrimSpace(groupName) == "" || strings.TrimSpace(subgroupName) == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Subgroup",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: groupAddSubgroup --group <parentGroup> --subgroup <childGroup>"}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	if !currentUser.CanDo(db, "groupAddSubgroup", groupName) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Subgroup",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You do not have permission to add subgroups to this group."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	parent, child, err := loadSubgroupPair(db, "Add Subgroup", groupName, subgroupName)
+	if err != nil {
+		return err
+	}
+
+	var existing models.GroupInclusion
+	if err := db.Where("parent_id = ? AND child_id = ?", parent.ID, child.ID).First(&existing).Error; err == nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Subgroup",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Already Exists", Body: []string{fmt.Sprintf("Group '%s' is already included in group '%s'.", subgroupName, groupName)}}},
+		})
+		return fmt.Errorf("group %q is already included in group %q", subgroupName, groupName)
+	}
+
+	// The parent must not already inherit from the child, directly or not.
+	inherited, err := models.InheritedGroups(db, []uuid.UUID{parent.ID})
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Subgroup",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Failed to check existing group inclusions."}}},
+		})
+		return err
+	}
+	if path, ok := inherited[child.ID]; ok || parent.ID == child.ID {
+		body := []string{fmt.Sprintf("Group '%s' cannot include itself.", groupName)}
+		if ok {
+			body = []string{
+				fmt.Sprintf("Group '%s' already inherits from '%s':", groupName, subgroupName),
+				"  " + models.GroupPathNames(db, path),
+			}
+		}
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Subgroup",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Cycle Rejected", Body: body}},
+		})
+		return errInclusionCycle
+	}
+
+	inclusion := models.GroupInclusion{ParentID: parent.ID, ChildID: child.ID}
+	if err := db.Create(&inclusion).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Subgroup",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Failed to add subgroup."}}},
+		})
+		return err
+	}
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Add Subgroup",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Success", Body: []string{fmt.Sprintf("Members of '%s' now inherit the accesses of '%s'.", subgroupName, groupName)}}},
+	})
+	return nil
+}
+
+// DelSubgroup removes a group inclusion created by AddSubgroup.
+func DelSubgroup(db *gorm.DB, currentUser *models.User, args []string) error {
+	fs := flag.NewFlagSet("groupDelSubgroup", flag.ContinueOnError)
+	var groupName, subgroupName string
+	fs.StringVar(&groupName, "group", "", "Parent group name")
+	fs.StringVar(&subgroupName, "subgroup", "", "Included group to remove")
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	if err := fs.Parse(args); err != nil || strings.TrimSpace(groupName) == "" || strings.TrimSpace(subgroupName) == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Delete Subgroup",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: groupDelSubgroup --group <parentGroup> --subgroup <childGroup>"}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	if !currentUser.CanDo(db, "groupDelSubgroup", groupName) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Delete Subgroup",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You do not have permission to remove subgroups from this group."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	parent, child, err := loadSubgroupPair(db, "Delete Subgroup", groupName, subgroupName)
+	if err != nil {
+		return err
+	}
+
+	result := db.Where("parent_id = ? AND child_id = ?", parent.ID, child.ID).Delete(&models.GroupInclusion{})
+	if result.Error != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Delete Subgroup",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Failed to remove subgroup."}}},
+		})
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Delete Subgroup",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("Group '%s' is not included in group '%s'.", subgroupName, groupName)}}},
+		})
+		return fmt.Errorf("group %q is not included in group %q", subgroupName, groupName)
+	}
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Delete Subgroup",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Success", Body: []string{fmt.Sprintf("Group '%s' removed from group '%s'.", subgroupName, groupName)}}},
+	})
+	return nil
+}
+
+// loadSubgroupPair loads the parent and child groups named on the command line.
+func loadSubgroupPair(db *gorm.DB, title, groupName, subgroupName string) (models.Group, models.Group, error) {
+	var parent, child models.Group
+	for _, lookup := range []struct {
+		name string
+		dst  *models.Group
+	}{{groupName, &parent}, {subgroupName, &child}} {
+		if err := db.Where("name = ?", lookup.name).First(lookup.dst).Error; err != nil {
+			console.DisplayBlock(console.ContentBlock{
+				Title:     title,
+				BlockType: "error",
+				Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("Group '%s' not found. Check spelling or run groupList.", lookup.name)}}},
+			})
+			return models.Group{}, models.Group{}, err
+		}
+	}
+	return parent, child, nil
+}
@@ -0,0 +1,87 @@
+package group
+
+import (
+	"errors"
+	"testing"
+
+	"goBastion/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func mustCreateGroups(t *testing.T, db *gorm.DB, names ...string) map[string]models.Group {
+	t.Helper()
+	groups := make(map[string]models.Group, len(names))
+	for _, name := range names {
+		g := models.Group{Name: name}
+		if err := db.Create(&g).Error; err != nil {
+			t.Fatalf("create group %s: %v", name, err)
+		}
+		groups[name] = g
+	}
+	return groups
+}
+
+func TestAddSubgroup_InheritancePathAndCycles(t *testing.T) {
+	db := newTestDB(t)
+	admin := newAdminUser(t, db, "admin")
+	groups := mustCreateGroups(t, db, "infra", "infra-db", "infra-db-ro")
+
+	for _, pair := range [][2]string{{"infra", "infra-db"}, {"infra-db", "infra-db-ro"}} {
+		if err := AddSubgroup(db, admin, []string{"--group", pair[0], "--subgroup", pair[1]}); err != nil {
+			t.Fatalf("AddSubgroup(%s, %s): %v", pair[0], pair[1], err)
+		}
+	}
+
+	inherited, err := models.InheritedGroups(db, []uuid.UUID{groups["infra-db-ro"].ID})
+	if err != nil {
+		t.Fatalf("InheritedGroups: %v", err)
+	}
+	if got := models.GroupPathNames(db, inherited[groups["infra"].ID]); got != "infra-db-ro → infra-db → infra" {
+		t.Fatalf("unexpected inheritance path %q", got)
+	}
+
+	for _, pair := range [][2]string{{"infra-db-ro", "infra"}, {"infra-db", "infra"}, {"infra", "infra"}} {
+		if err := AddSubgroup(db, admin, []string{"--group", pair[0], "--subgroup", pair[1]}); !errors.Is(err, errInclusionCycle) {
+			t.Fatalf("AddSubgroup(%s, %s) = %v, want cycle error", pair[0], pair[1], err)
+		}
+	}
+	if err := AddSubgroup(db, admin, []string{"--group", "infra", "--subgroup", "infra-db"}); err == nil {
+		t.Fatal("expected duplicate inclusion to be refused")
+	}
+}
+
+func TestAddSubgroup_RequiresOwnerOrACLKeeperOfParent(t *testing.T) {
+	db := newTestDB(t)
+	alice := newRegularUser(t, db, "alice")
+	groups := mustCreateGroups(t, db, "infra", "infra-db")
+	if err := db.Create(&models.UserGroup{UserID: alice.ID, GroupID: groups["infra-db"].ID, Role: models.GroupRoleOwner}).Error; err != nil {
+		t.Fatalf("seed membership: %v", err)
+	}
+	models.InvalidateGroupsCache(alice.ID)
+
+	if err := AddSubgroup(db, alice, []string{"--group", "infra", "--subgroup", "infra-db"}); err == nil {
+		t.Fatal("expected owner of the child only to be refused")
+	}
+}
+
+func TestDelSubgroup_RemovesInheritance(t *testing.T) {
+	db := newTestDB(t)
+	admin := newAdminUser(t, db, "admin")
+	groups := mustCreateGroups(t, db, "infra", "infra-net")
+
+	if err := AddSubgroup(db, admin, []string{"--group", "infra", "--subgroup", "infra-net"}); err != nil {
+		t.Fatalf("AddSubgroup: %v", err)
+	}
+	if err := DelSubgroup(db, admin, []string{"--group", "infra", "--subgroup", "infra-net"}); err != nil {
+		t.Fatalf("DelSubgroup: %v", err)
+	}
+	inherited, err := models.InheritedGroups(db, []uuid.UUID{groups["infra-net"].ID})
+	if err != nil || len(inherited) != 0 {
+		t.Fatalf("expected no inherited groups after removal, got %v (%v)", inherited, err)
+	}
+	if err := DelSubgroup(db, admin, []string{"--group", "infra", "--subgroup", "infra-net"}); err == nil {
+		t.Fatal("expected removing a missing inclusion to fail")
+	}
+}
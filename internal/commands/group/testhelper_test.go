@@ -24,7 +24,8 @@ func newTestDB(t *testing.T) *gorm.DB {
 		&models.Group{}, &models.UserGroup{}, &models.Aliases{},
 		&models.KnownHostsEntry{}, &models.PIVTrustAnchor{},
 		&models.GroupGuestAccess{}, &models.SelfDBAccess{}, &models.GroupDBAccess{},
-		&models.GroupGuestDBAccess{}, &models.DatabaseAlias{},
+		&models.GroupGuestDBAccess{}, &models.DatabaseAlias{}, &models.GroupInclusion{},
+		&models.CustomRole{}, &models.CustomRoleAssignment{},
 	); err != nil {
 		t.Fatalf("migrate: %v", err)
 	}
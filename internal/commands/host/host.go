@@ -0,0 +1,538 @@
+// Package host implements the server inventory commands: hosts with
+// addresses, tags, an owner group and a description.
+package host
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log/slog"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/hostmatch"
+	"goBastion/internal/utils/validation"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Add registers a host in the inventory.
+func Add(db *gorm.DB, currentUser *models.User, log *slog.Logger, args []string) error {
+	fs := flag.NewFlagSet("hostAdd", flag.ContinueOnError)
+	var name, addresses, tags, owner, description string
+	fs.StringVar(&name, "name", "", "Inventory name")
+	fs.StringVar(&addresses, "address", "", "Comma-separated hostnames/IPs (default: the name)")
+	fs.StringVar(&tags, "tags", "", "Comma-separated key=value tags")
+	fs.StringVar(&owner, "owner", "", "Owner group")
+	fs.StringVar(&description, "description", "", "Free-text description")
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	if err := fs.Parse(args); err != nil || strings.TrimSpace(name) == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Host",
+			BlockType: "error",
+			Sections: []console.SectionContent{{SubTitle: "Usage", Body: []string{
+				"Usage: hostAdd --name <name> [--address <addr1,addr2>] [--tags <key=value,...>] [--owner <group>] [--description <text>]",
+			}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	name = strings.TrimSpace(name)
+	if addresses == "" {
+		addresses = name
+	}
+	addrList, err := parseAddresses(name, addresses)
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Host",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Address", Body: []string{err.Error()}}},
+		})
+		return err
+	}
+	tagMap, err := models.ParseTags(tags)
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Host",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Tags", Body: []string{err.Error(), "Expected comma-separated key=value pairs, e.g. env=prod,role=web"}}},
+		})
+		return err
+	}
+
+	var existing models.Host
+	if err := db.Where("name = ?", name).First(&existing).Error; err == nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Host",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Already Exists", Body: []string{fmt.Sprintf("Host '%s' is already in the inventory.", name)}}},
+		})
+		return fmt.Errorf("host %q already exists", name)
+	}
+
+	host := models.Host{
+		Name:        name,
+		Addresses:   strings.Join(addrList, ","),
+		Tags:        models.FormatTags(tagMap),
+		Description: strings.TrimSpace(description),
+	}
+	if owner != "" {
+		var group models.Group
+		if err := db.Where("name = ?", owner).First(&group).Error; err != nil {
+			console.DisplayBlock(console.ContentBlock{
+				Title:     "Add Host",
+				BlockType: "error",
+				Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("Group '%s' not found. Check spelling or run groupList.", owner)}}},
+			})
+			return err
+		}
+		host.OwnerGroupID = &group.ID
+	}
+	if err := db.Create(&host).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Host",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Database Error", Body: []string{"Failed to add host."}}},
+		})
+		return err
+	}
+
+	log.Info("host_added", slog.String("admin", currentUser.Username), slog.String("host", name),
+		slog.String("addresses", host.Addresses), slog.String("tags", host.Tags), slog.String("owner", owner))
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Add Host",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Success", Body: []string{fmt.Sprintf("Host '%s' added to the inventory.", name)}}},
+	})
+	return nil
+}
+
+// List shows the inventory, optionally filtered by tag selector or owner group.
+// Users other than admins and superowners only see the hosts they can reach.
+func List(db *gorm.DB, currentUser *models.User, args []string) error {
+	fs := flag.NewFlagSet("hostList", flag.ContinueOnError)
+	var tags, owner string
+	fs.StringVar(&tags, "tags", "", "Only hosts matching this tag selector")
+	fs.StringVar(&owner, "owner", "", "Only hosts owned by this group")
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	if err := fs.Parse(args); err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Host List",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: hostList [--tags <key=value,...>] [--owner <group>]"}}},
+		})
+		return err
+	}
+
+	var selector map[string]string
+	if tags != "" {
+		var err error
+		if selector, _, err = models.ParseTagSelector(tags); err != nil {
+			console.DisplayBlock(console.ContentBlock{
+				Title:     "Host List",
+				BlockType: "error",
+				Sections:  []console.SectionContent{{SubTitle: "Invalid Tag Selector", Body: []string{err.Error()}}},
+			})
+			return err
+		}
+	}
+
+	query := db.Preload("OwnerGroup").Order("name asc")
+	if owner != "" {
+		var group models.Group
+		if err := db.Where("name = ?", owner).First(&group).Error; err != nil {
+			console.DisplayBlock(console.ContentBlock{
+				Title:     "Host List",
+				BlockType: "error",
+				Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("Group '%s' not found. Check spelling or run groupList.", owner)}}},
+			})
+			return err
+		}
+		query = query.Where("owner_group_id = ?", group.ID)
+	}
+	var hosts []models.Host
+	if err := query.Find(&hosts).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Host List",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Database Error", Body: []string{"Failed to query the inventory."}}},
+		})
+		return err
+	}
+	visible, err := hostVisibility(db, currentUser)
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Host List",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Database Error", Body: []string{"Failed to query your accesses."}}},
+		})
+		return err
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "Name\tAddresses\tTags\tOwner")
+	shown := 0
+	for i := range hosts {
+		h := &hosts[i]
+		if (selector != nil && !h.MatchesSelector(selector)) || !visible(h) {
+			continue
+		}
+		shown++
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", h.Name, strings.Join(h.AddressList(), ", "), dash(h.Tags), ownerName(h))
+	}
+	_ = w.Flush()
+
+	if shown == 0 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Host List",
+			BlockType: "info",
+			Sections:  []console.SectionContent{{SubTitle: "Hosts", Body: []string{"No hosts found."}}},
+		})
+		return nil
+	}
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Host List",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: fmt.Sprintf("Hosts (%d)", shown), Body: strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")}},
+	})
+	return nil
+}
+
+// Info shows one host and the tag-based group accesses selecting it. Hosts
+// the caller cannot see in hostList are reported as not found.
+func Info(db *gorm.DB, currentUser *models.User, args []string) error {
+	fs := flag.NewFlagSet("hostInfo", flag.ContinueOnError)
+	var name string
+	fs.StringVar(&name, "host", "", "Inventory name")
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	if err := fs.Parse(args); err != nil || strings.TrimSpace(name) == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Host Info",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: hostInfo --host <name>"}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	host, err := loadHost(db, "Host Info", name)
+	if err != nil {
+		return err
+	}
+	visible, err := hostVisibility(db, currentUser)
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Host Info",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Database Error", Body: []string{"Failed to query your accesses."}}},
+		})
+		return err
+	}
+	if !visible(&host) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Host Info",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("Host '%s' not found. Run hostList.", name)}}},
+		})
+		return fmt.Errorf("host %q not found", name)
+	}
+
+	details := []string{
+		fmt.Sprintf("Name: %s", host.Name),
+		fmt.Sprintf("Addresses: %s", strings.Join(host.AddressList(), ", ")),
+		fmt.Sprintf("Tags: %s", dash(host.Tags)),
+		fmt.Sprintf("Owner group: %s", ownerName(&host)),
+		fmt.Sprintf("Description: %s", dash(host.Description)),
+		fmt.Sprintf("Added: %s", host.CreatedAt.Format("2006-01-02 15:04:05")),
+	}
+	sections := []console.SectionContent{{SubTitle: "Details", Body: details}}
+
+	// Tag-based grants, limited to the groups the caller may inspect.
+	var tagAccesses []models.GroupAccess
+	if err := db.Preload("Group").Where(models.TagServerClause).Order("created_at asc").Find(&tagAccesses).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Host Info",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Database Error", Body: []string{"Failed to query tag-based accesses."}}},
+		})
+		return err
+	}
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "Group\tUsername\tPort\tProtocol\tSelector")
+	granted := 0
+	for _, ga := range tagAccesses {
+		if !models.TagSelectorCovers(ga.Server, []models.Host{host}) || !currentUser.CanViewGroupInfo(db, ga.Group.Name) {
+			continue
+		}
+		granted++
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", ga.Group.Name, ga.Username, ga.Port, ga.Protocol, strings.TrimPrefix(ga.Server, models.TagSelectorPrefix))
+	}
+	_ = w.Flush()
+	if granted > 0 {
+		sections = append(sections, console.SectionContent{SubTitle: "Group accesses by tag", Body: strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")})
+	} else {
+		sections = append(sections, console.SectionContent{SubTitle: "Group accesses by tag", Body: []string{"None"}})
+	}
+
+	console.DisplayBlock(console.ContentBlock{Title: "Host Info", BlockType: "success", Sections: sections})
+	return nil
+}
+
+// Tag sets or removes tags on a host. Owners of the host's owner group may
+// retag it; hosts without an owner need the hostAdd right.
+func Tag(db *gorm.DB, currentUser *models.User, log *slog.Logger, args []string) error {
+	fs := flag.NewFlagSet("hostTag", flag.ContinueOnError)
+	var name, set, remove string
+	fs.StringVar(&name, "host", "", "Inventory name")
+	fs.StringVar(&set, "set", "", "Tags to add or overwrite (key=value,...)")
+	fs.StringVar(&remove, "remove", "", "Tag keys to remove (comma-separated)")
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	if err := fs.Parse(args); err != nil || strings.TrimSpace(name) == "" || (set == "" && remove == "") {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Tag Host",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: hostTag --host <name> [--set <key=value,...>] [--remove <key1,key2>]"}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	host, err := loadHost(db, "Tag Host", name)
+	if err != nil {
+		return err
+	}
+
+	allowed := currentUser.CanDo(db, "hostAdd", "")
+	if host.OwnerGroup != nil {
+		allowed = currentUser.CanDo(db, "hostTag", host.OwnerGroup.Name)
+	}
+	if !allowed {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Tag Host",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You do not have permission to tag this host."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	added, err := models.ParseTags(set)
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Tag Host",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Tags", Body: []string{err.Error(), "Expected comma-separated key=value pairs, e.g. env=prod,role=web"}}},
+		})
+		return err
+	}
+	tags := host.TagMap()
+	for _, k := range strings.Split(remove, ",") {
+		delete(tags, strings.ToLower(strings.TrimSpace(k)))
+	}
+	for k, v := range added {
+		tags[k] = v
+	}
+
+	before := host.Tags
+	host.Tags = models.FormatTags(tags)
+	if err := db.Model(&host).Update("tags", host.Tags).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Tag Host",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Database Error", Body: []string{"Failed to update host tags."}}},
+		})
+		return err
+	}
+
+	log.Info("host_tagged", slog.String("user", currentUser.Username), slog.String("host", host.Name),
+		slog.String("before", before), slog.String("after", host.Tags))
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Tag Host",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Success", Body: []string{fmt.Sprintf("Host '%s' tags: %s", host.Name, dash(host.Tags))}}},
+	})
+	return nil
+}
+
+// Delete removes a host from the inventory. Tag-based accesses are kept and
+// simply stop selecting it.
+func Delete(db *gorm.DB, currentUser *models.User, log *slog.Logger, args []string) error {
+	fs := flag.NewFlagSet("hostDelete", flag.ContinueOnError)
+	var name string
+	fs.StringVar(&name, "host", "", "Inventory name")
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	if err := fs.Parse(args); err != nil || strings.TrimSpace(name) == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Delete Host",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: hostDelete --host <name>"}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	host, err := loadHost(db, "Delete Host", name)
+	if err != nil {
+		return err
+	}
+	if err := db.Delete(&host).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Delete Host",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Database Error", Body: []string{"Failed to delete host."}}},
+		})
+		return err
+	}
+
+	log.Info("host_deleted", slog.String("admin", currentUser.Username), slog.String("host", host.Name))
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Delete Host",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Success", Body: []string{fmt.Sprintf("Host '%s' removed from the inventory.", host.Name)}}},
+	})
+	return nil
+}
+
+// parseAddresses validates a comma-separated address list. Addresses already
+// used by another inventory entry are accepted: the same IP may legitimately
+// carry several names.
+func parseAddresses(name, addresses string) ([]string, error) {
+	if !validation.IsValidHost(name) {
+		return nil, fmt.Errorf("invalid host name %q", name)
+	}
+	seen := make(map[string]bool)
+	var out []string
+	for _, a := range strings.Split(addresses, ",") {
+		a = strings.TrimSpace(a)
+		if a == "" || seen[strings.ToLower(a)] {
+			continue
+		}
+		if !validation.IsValidHost(a) {
+			return nil, fmt.Errorf("invalid address %q: must be a hostname or IP", a)
+		}
+		seen[strings.ToLower(a)] = true
+		out = append(out, a)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("at least one address is required")
+	}
+	return out, nil
+}
+
+// hostVisibility returns the predicate deciding which inventory hosts u may
+// see. Admins and superowners see the whole inventory. Other users see the
+// hosts owned by one of their groups and the hosts covered by one of their
+// unexpired self or group accesses, by name, address or tag selector.
+func hostVisibility(db *gorm.DB, u *models.User) (func(*models.Host) bool, error) {
+	if u.IsAdmin() || u.IsSuperOwner() {
+		return func(*models.Host) bool { return true }, nil
+	}
+	now := time.Now()
+
+	var userGroups []models.UserGroup
+	if err := db.Where("user_id = ?", u.ID).Where(models.MembershipActiveClause, now).Find(&userGroups).Error; err != nil {
+		return nil, err
+	}
+	roles, err := models.EffectiveGroupRoles(db, userGroups)
+	if err != nil {
+		return nil, err
+	}
+	var servers []string
+	if len(roles) > 0 {
+		groupIDs := make([]uuid.UUID, 0, len(roles))
+		for id := range roles {
+			groupIDs = append(groupIDs, id)
+		}
+		var groupAccesses []models.GroupAccess
+		if err := db.Where("group_id IN ? AND (expires_at IS NULL OR expires_at > ?)", groupIDs, now).
+			Where(models.GroupAccessMemberClause, u.ID).Find(&groupAccesses).Error; err != nil {
+			return nil, err
+		}
+		for _, ga := range groupAccesses {
+			servers = append(servers, ga.Server)
+		}
+	}
+	var selfAccesses []models.SelfAccess
+	if err := db.Where("user_id = ? AND (expires_at IS NULL OR expires_at > ?)", u.ID, now).Find(&selfAccesses).Error; err != nil {
+		return nil, err
+	}
+	for _, sa := range selfAccesses {
+		servers = append(servers, sa.Server)
+	}
+
+	return func(h *models.Host) bool {
+		if h.OwnerGroupID != nil {
+			if _, ok := roles[*h.OwnerGroupID]; ok {
+				return true
+			}
+		}
+		for _, server := range servers {
+			if models.IsTagSelector(server) {
+				if models.TagSelectorCovers(server, []models.Host{*h}) {
+					return true
+				}
+				continue
+			}
+			if hostmatch.Match(server, h.Name) {
+				return true
+			}
+			for _, addr := range h.AddressList() {
+				if hostmatch.Match(server, addr) {
+					return true
+				}
+			}
+		}
+		return false
+	}, nil
+}
+
+func loadHost(db *gorm.DB, title, name string) (models.Host, error) {
+	var host models.Host
+	if err := db.Preload("OwnerGroup").Where("name = ?", strings.TrimSpace(name)).First(&host).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     title,
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("Host '%s' not found. Run hostList.", name)}}},
+		})
+		return models.Host{}, err
+	}
+	return host, nil
+}
+
+func ownerName(h *models.Host) string {
+	if h.OwnerGroup == nil {
+		return "-"
+	}
+	return h.OwnerGroup.Name
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
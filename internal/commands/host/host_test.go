@@ -0,0 +1,145 @@
+package host
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"goBastion/internal/models"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open test DB: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.Group{}, &models.UserGroup{}, &models.GroupAccess{}, &models.SelfAccess{}, &models.GroupInclusion{}, &models.Host{},
+		&models.CustomRole{}, &models.CustomRoleAssignment{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func newUser(t *testing.T, db *gorm.DB, username, role string) *models.User {
+	t.Helper()
+	u := models.User{Username: username, Role: role, Enabled: true}
+	if err := db.Create(&u).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return &u
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestAdd_StoresCanonicalTagsAndOwner(t *testing.T) {
+	db := newTestDB(t)
+	admin := newUser(t, db, "admin", models.RoleAdmin)
+	web := models.Group{Name: "web"}
+	db.Create(&web)
+
+	if err := Add(db, admin, discardLogger(), []string{"--name", "web01", "--address", "10.0.0.5, web01.example.com", "--tags", "role=web,env=prod", "--owner", "web"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	var h models.Host
+	if err := db.Where("name = ?", "web01").First(&h).Error; err != nil {
+		t.Fatalf("host not stored: %v", err)
+	}
+	if h.Addresses != "10.0.0.5,web01.example.com" || h.Tags != "env=prod,role=web" || h.OwnerGroupID == nil || *h.OwnerGroupID != web.ID {
+		t.Fatalf("unexpected host %+v", h)
+	}
+
+	if err := Add(db, admin, discardLogger(), []string{"--name", "web01"}); err == nil {
+		t.Fatal("expected duplicate name to be refused")
+	}
+	if err := Add(db, admin, discardLogger(), []string{"--name", "bad", "--tags", "env"}); err == nil {
+		t.Fatal("expected malformed tags to be refused")
+	}
+}
+
+func TestTag_OwnerOfOwnerGroupOnly(t *testing.T) {
+	db := newTestDB(t)
+	admin := newUser(t, db, "admin", models.RoleAdmin)
+	alice := newUser(t, db, "alice", models.RoleUser)
+	web := models.Group{Name: "web"}
+	other := models.Group{Name: "other"}
+	db.Create(&web)
+	db.Create(&other)
+	db.Create(&models.UserGroup{UserID: alice.ID, GroupID: other.ID, Role: models.GroupRoleOwner})
+	models.InvalidateGroupsCache(alice.ID)
+
+	if err := Add(db, admin, discardLogger(), []string{"--name", "web01", "--tags", "env=prod,role=web", "--owner", "web"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := Tag(db, alice, discardLogger(), []string{"--host", "web01", "--set", "env=dev"}); err == nil {
+		t.Fatal("expected owner of another group to be refused")
+	}
+
+	db.Create(&models.UserGroup{UserID: alice.ID, GroupID: web.ID, Role: models.GroupRoleOwner})
+	models.InvalidateGroupsCache(alice.ID)
+	if err := Tag(db, alice, discardLogger(), []string{"--host", "web01", "--set", "env=dev,team=a", "--remove", "role"}); err != nil {
+		t.Fatalf("Tag: %v", err)
+	}
+	var h models.Host
+	db.Where("name = ?", "web01").First(&h)
+	if h.Tags != "env=dev,team=a" {
+		t.Fatalf("unexpected tags %q", h.Tags)
+	}
+}
+
+func TestHostVisibility_LimitedToReachableHosts(t *testing.T) {
+	db := newTestDB(t)
+	admin := newUser(t, db, "admin", models.RoleAdmin)
+	alice := newUser(t, db, "alice", models.RoleUser)
+	web := models.Group{Name: "web"}
+	ops := models.Group{Name: "ops"}
+	db.Create(&web)
+	db.Create(&ops)
+	db.Create(&models.UserGroup{UserID: alice.ID, GroupID: web.ID, Role: models.GroupRoleMember})
+	models.InvalidateGroupsCache(alice.ID)
+
+	for _, args := range [][]string{
+		{"--name", "owned", "--owner", "web"},
+		{"--name", "tagged", "--tags", "env=prod"},
+		{"--name", "direct", "--address", "10.0.0.7"},
+		{"--name", "hidden", "--address", "10.0.1.1", "--owner", "ops"},
+	} {
+		if err := Add(db, admin, discardLogger(), args); err != nil {
+			t.Fatalf("Add %v: %v", args, err)
+		}
+	}
+	db.Create(&models.GroupAccess{GroupID: web.ID, Server: models.TagSelectorPrefix + "env=prod", Port: 22, Username: "root", Protocol: "ssh"})
+	db.Create(&models.SelfAccess{UserID: alice.ID, Server: "10.0.0.0/24", Port: 22, Username: "root", Protocol: "ssh"})
+
+	visible, err := hostVisibility(db, alice)
+	if err != nil {
+		t.Fatalf("hostVisibility: %v", err)
+	}
+	var hosts []models.Host
+	db.Order("name asc").Find(&hosts)
+	var seen []string
+	for i := range hosts {
+		if visible(&hosts[i]) {
+			seen = append(seen, hosts[i].Name)
+		}
+	}
+	if strings.Join(seen, ",") != "direct,owned,tagged" {
+		t.Fatalf("alice sees %v, want direct, owned and tagged", seen)
+	}
+
+	if err := Info(db, alice, []string{"--host", "hidden"}); err == nil {
+		t.Fatal("expected hostInfo on an unreachable host to be refused")
+	}
+	if err := Info(db, alice, []string{"--host", "owned"}); err != nil {
+		t.Fatalf("Info on owned host: %v", err)
+	}
+	if err := Info(db, admin, []string{"--host", "hidden"}); err != nil {
+		t.Fatalf("admin Info: %v", err)
+	}
+}
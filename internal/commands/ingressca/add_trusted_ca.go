@@ -0,0 +1,116 @@
+package ingressca
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/ingressca"
+	"goBastion/internal/utils/validation"
+
+	"gorm.io/gorm"
+)
+
+// AddTrustedCA trusts an SSH user CA for logins to the bastion (admin only).
+// Its certificates are accepted for an account when one of their principals
+// matches --principals, where %u stands for the bastion username.
+// Usage: ingressAddTrustedCA --name <name> --key "<CA public key>" [--principals <list>]
+func AddTrustedCA(db *gorm.DB, currentUser *models.User, log *slog.Logger, args []string) error {
+	fs := flag.NewFlagSet("ingressAddTrustedCA", flag.ContinueOnError)
+	var name, key, principals string
+	fs.StringVar(&name, "name", "", "Friendly name for this CA")
+	fs.StringVar(&key, "key", "", "CA public key in authorized_keys format")
+	fs.StringVar(&principals, "principals", ingressca.DefaultPrincipals, "Comma-separated accepted principals, %u is the bastion username")
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	if err := fs.Parse(args); err != nil || name == "" || strings.TrimSpace(key) == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Trusted Ingress CA",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{`Usage: ingressAddTrustedCA --name <name> --key "<CA public key>" [--principals "%u,%u@corp.example"]`}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	if !currentUser.CanDo(db, "ingressAddTrustedCA", "") {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Trusted Ingress CA",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You do not have permission to add trusted ingress CAs."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	if !validation.EntityNameRegexp.MatchString(name) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Trusted Ingress CA",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Name", Body: []string{"Name must contain only letters, digits, dots, hyphens, and underscores."}}},
+		})
+		return fmt.Errorf("invalid name %q", name)
+	}
+	if err := ingressca.ValidatePrincipals(principals); err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Trusted Ingress CA",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Principals", Body: []string{err.Error()}}},
+		})
+		return err
+	}
+	publicKey, fingerprint, err := ingressca.ParseCAKey(key)
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Trusted Ingress CA",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Key", Body: []string{err.Error()}}},
+		})
+		return err
+	}
+
+	var count int64
+	db.Model(&models.IngressCA{}).Where("name = ? OR fingerprint = ?", name, fingerprint).Count(&count)
+	if count > 0 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Trusted Ingress CA",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Already Trusted", Body: []string{fmt.Sprintf("A CA named '%s' or with fingerprint %s is already trusted.", name, fingerprint)}}},
+		})
+		return fmt.Errorf("ingress CA %s already trusted", name)
+	}
+
+	ca := models.IngressCA{
+		Name:        name,
+		PublicKey:   publicKey,
+		Fingerprint: fingerprint,
+		Principals:  principals,
+		AddedByID:   currentUser.ID,
+	}
+	if err := db.Create(&ca).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Trusted Ingress CA",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{fmt.Sprintf("Failed to store CA: %v", err)}}},
+		})
+		return err
+	}
+	log.Info("ingress_ca_added", slog.String("user", currentUser.Username), slog.String("name", name),
+		slog.String("fingerprint", fingerprint), slog.String("principals", principals))
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Add Trusted Ingress CA",
+		BlockType: "success",
+		Sections: []console.SectionContent{{SubTitle: "Success", Body: []string{
+			fmt.Sprintf("CA '%s' (%s) trusted for principals %s.", name, fingerprint, principals),
+			"Accounts accept its certificates after the next sync.",
+		}}},
+	})
+	return nil
+}
@@ -0,0 +1,60 @@
+package ingressca
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+
+	"gorm.io/gorm"
+)
+
+// ListTrustedCAs lists the SSH user CAs trusted for logins (admin only).
+func ListTrustedCAs(db *gorm.DB, currentUser *models.User) error {
+	if !currentUser.CanDo(db, "ingressListTrustedCAs", "") {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Trusted Ingress CAs",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You do not have permission to list trusted ingress CAs."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	var cas []models.IngressCA
+	if err := db.Preload("AddedBy").Order("name").Find(&cas).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Trusted Ingress CAs",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Failed to query trusted CAs."}}},
+		})
+		return err
+	}
+
+	if len(cas) == 0 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Trusted Ingress CAs",
+			BlockType: "info",
+			Sections:  []console.SectionContent{{SubTitle: "Info", Body: []string{"No trusted CAs configured."}}},
+		})
+		return nil
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "Name\tFingerprint\tPrincipals\tAdded By\tCreated At")
+	for _, ca := range cas {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			ca.Name, ca.Fingerprint, ca.Principals, ca.AddedBy.Username, ca.CreatedAt.Format("2006-01-02 15:04:05"))
+	}
+	_ = w.Flush()
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Trusted Ingress CAs",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "CAs", Body: strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")}},
+	})
+	return nil
+}
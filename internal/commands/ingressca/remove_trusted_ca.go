@@ -0,0 +1,68 @@
+package ingressca
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log/slog"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+
+	"gorm.io/gorm"
+)
+
+// RemoveTrustedCA stops trusting an SSH user CA for logins (admin only).
+// Usage: ingressRemoveTrustedCA --name <name>
+func RemoveTrustedCA(db *gorm.DB, currentUser *models.User, log *slog.Logger, args []string) error {
+	fs := flag.NewFlagSet("ingressRemoveTrustedCA", flag.ContinueOnError)
+	var name string
+	fs.StringVar(&name, "name", "", "Name of the CA to remove")
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	if err := fs.Parse(args); err != nil || name == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Remove Trusted Ingress CA",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: ingressRemoveTrustedCA --name <name>"}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	if !currentUser.CanDo(db, "ingressRemoveTrustedCA", "") {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Remove Trusted Ingress CA",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You do not have permission to remove trusted ingress CAs."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	res := db.Where("name = ?", name).Delete(&models.IngressCA{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Remove Trusted Ingress CA",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("CA '%s' not found. Run ingressListTrustedCAs.", name)}}},
+		})
+		return fmt.Errorf("ingress CA %s not found", name)
+	}
+	log.Info("ingress_ca_removed", slog.String("user", currentUser.Username), slog.String("name", name))
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Remove Trusted Ingress CA",
+		BlockType: "success",
+		Sections: []console.SectionContent{{SubTitle: "Success", Body: []string{
+			fmt.Sprintf("CA '%s' removed.", name),
+			"Its certificates are refused after the next sync.",
+		}}},
+	})
+	return nil
+}
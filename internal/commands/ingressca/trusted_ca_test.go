@@ -0,0 +1,106 @@
+package ingressca
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+
+	"goBastion/internal/models"
+)
+
+var testLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open test DB: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.IngressCA{}, &models.RestrictedCommandGrant{}, &models.CustomRole{}, &models.CustomRoleAssignment{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func newUser(t *testing.T, db *gorm.DB, username, role string) *models.User {
+	t.Helper()
+	u := models.User{Username: username, Role: role, Enabled: true}
+	if err := db.Create(&u).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return &u
+}
+
+func caKey(t *testing.T) string {
+	t.Helper()
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	key, _ := ssh.NewPublicKey(pub)
+	return string(ssh.MarshalAuthorizedKey(key))
+}
+
+func TestAddListRemoveTrustedCA(t *testing.T) {
+	db := newTestDB(t)
+	admin := newUser(t, db, "admin", models.RoleAdmin)
+	key := caKey(t)
+
+	if err := AddTrustedCA(db, admin, testLog, []string{"--name", "corp", "--key", key, "--principals", "%u@corp.example"}); err != nil {
+		t.Fatalf("AddTrustedCA: %v", err)
+	}
+	var ca models.IngressCA
+	if err := db.Where("name = ?", "corp").First(&ca).Error; err != nil {
+		t.Fatalf("CA not stored: %v", err)
+	}
+	if ca.Principals != "%u@corp.example" || ca.AddedByID != admin.ID {
+		t.Fatalf("unexpected CA %+v", ca)
+	}
+
+	// The same key cannot be trusted twice, even under another name.
+	if err := AddTrustedCA(db, admin, testLog, []string{"--name", "corp2", "--key", key}); err == nil {
+		t.Fatal("expected an error for an already trusted key")
+	}
+	if err := ListTrustedCAs(db, admin); err != nil {
+		t.Fatalf("ListTrustedCAs: %v", err)
+	}
+
+	if err := RemoveTrustedCA(db, admin, testLog, []string{"--name", "corp"}); err != nil {
+		t.Fatalf("RemoveTrustedCA: %v", err)
+	}
+	var count int64
+	db.Model(&models.IngressCA{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("expected the CA to be removed, %d left", count)
+	}
+	if err := RemoveTrustedCA(db, admin, testLog, []string{"--name", "corp"}); err == nil {
+		t.Fatal("expected an error for an unknown CA")
+	}
+}
+
+func TestAddTrustedCA_Refused(t *testing.T) {
+	db := newTestDB(t)
+	admin := newUser(t, db, "admin", models.RoleAdmin)
+	regular := newUser(t, db, "bob", models.RoleUser)
+
+	if err := AddTrustedCA(db, regular, testLog, []string{"--name", "corp", "--key", caKey(t)}); err == nil {
+		t.Fatal("a regular user must not trust a CA")
+	}
+	if err := AddTrustedCA(db, admin, testLog, []string{"--name", "corp", "--key", caKey(t), "--principals", `%u" command="/bin/sh`}); err == nil {
+		t.Fatal("principals that break out of the option must be refused")
+	}
+	if err := AddTrustedCA(db, admin, testLog, []string{"--name", "corp", "--key", caKey(t), "--principals", "ops"}); err == nil {
+		t.Fatal("a static principal, valid for every account, must be refused")
+	}
+	if err := AddTrustedCA(db, admin, testLog, []string{"--name", "corp", "--key", "not a key"}); err == nil {
+		t.Fatal("an invalid key must be refused")
+	}
+	var count int64
+	db.Model(&models.IngressCA{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("nothing must be stored, got %d CAs", count)
+	}
+}
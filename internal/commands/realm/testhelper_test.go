@@ -21,6 +21,7 @@ func newTestDB(t *testing.T) *gorm.DB {
 		&models.Group{}, &models.UserGroup{}, &models.Aliases{},
 		&models.KnownHostsEntry{}, &models.PIVTrustAnchor{},
 		&models.Realm{}, &models.RestrictedCommandGrant{},
+		&models.CustomRole{}, &models.CustomRoleAssignment{},
 	); err != nil {
 		t.Fatalf("migrate: %v", err)
 	}
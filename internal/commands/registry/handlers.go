@@ -2,16 +2,25 @@ package registry
 
 import (
 	"log/slog"
+	"os"
 
 	"gorm.io/gorm"
 
+	cmdaccessrequest "goBastion/internal/commands/accessrequest"
 	cmdaccount "goBastion/internal/commands/account"
+	cmdbreakglass "goBastion/internal/commands/breakglass"
 	cmdconfig "goBastion/internal/commands/config"
 	cmdgroup "goBastion/internal/commands/group"
+	cmdhost "goBastion/internal/commands/host"
+	cmdingressca "goBastion/internal/commands/ingressca"
 	cmdpiv "goBastion/internal/commands/piv"
 	cmdrealm "goBastion/internal/commands/realm"
 	cmdrestricted "goBastion/internal/commands/restricted"
+	cmdreview "goBastion/internal/commands/review"
+	cmdrevocation "goBastion/internal/commands/revocation"
+	cmdrole "goBastion/internal/commands/role"
 	cmdself "goBastion/internal/commands/self"
+	cmdssh "goBastion/internal/commands/ssh"
 	cmdtotp "goBastion/internal/commands/totp"
 	cmdtty "goBastion/internal/commands/tty"
 	"goBastion/internal/models"
@@ -22,20 +31,24 @@ import (
 func buildHandlers(db *gorm.DB, user *models.User, log *slog.Logger, adapter osadapter.SystemAdapter, args []string, exitFunc func()) map[string]func() error {
 	return map[string]func() error{
 		// Self: Ingress
-		"selfListIngressKeys": func() error { return cmdself.ListIngressKeys(db, user) },
-		"selfAddIngressKey":   func() error { return cmdself.AddIngressKey(db, user, args) },
-		"selfDelIngressKey":   func() error { return cmdself.DelIngressKey(db, user, args) },
+		"selfListIngressKeys":  func() error { return cmdself.ListIngressKeys(db, user) },
+		"selfAddIngressKey":    func() error { return cmdself.AddIngressKey(db, user, args) },
+		"selfDelIngressKey":    func() error { return cmdself.DelIngressKey(db, user, args) },
+		"selfModifyIngressKey": func() error { return cmdself.ModifyIngressKey(db, user, args) },
 
 		// Self: Egress
 		"selfListEgressKeys":           func() error { return cmdself.ListEgressKeys(db, user) },
 		"selfGenerateEgressKey":        func() error { return cmdself.GenerateEgressKey(db, user, args) },
+		"selfDeployEgressKey":          func() error { return cmdself.DeployEgressKey(db, user, log, args) },
 		"selfRemoveHostFromKnownHosts": func() error { return cmdself.RemoveHostFromKnownHosts(db, user, args) },
 		"selfReplaceKnownHost":         func() error { return cmdself.ReplaceKnownHost(db, user, args) },
 
 		// Self: Accesses
-		"selfListAccesses": func() error { return cmdself.ListAccesses(db, user) },
-		"selfAddAccess":    func() error { return cmdself.AddAccess(db, user, args) },
-		"selfDelAccess":    func() error { return cmdself.DelAccess(db, user, args) },
+		"selfListAccesses":  func() error { return cmdself.ListAccesses(db, user) },
+		"selfExplainAccess": func() error { return cmdssh.SelfExplainAccess(db, user, args) },
+		"selfAddAccess":     func() error { return cmdself.AddAccess(db, user, args) },
+		"selfDelAccess":     func() error { return cmdself.DelAccess(db, user, args) },
+		"selfModifyAccess":  func() error { return cmdself.ModifyAccess(db, user, args) },
 
 		// Self: Aliases
 		"selfListAliases": func() error { return cmdself.ListAliases(db, user) },
@@ -59,10 +72,13 @@ func buildHandlers(db *gorm.DB, user *models.User, log *slog.Logger, adapter osa
 		"accountModify":          func() error { return cmdaccount.Modify(db, user, args) },
 		"accountDelete":          func() error { return cmdaccount.Delete(db, adapter, user, args) },
 		"accountListIngressKeys": func() error { return cmdaccount.ListIngressKeys(db, user, args) },
+		"accountAddIngressKey":   func() error { return cmdaccount.AddIngressKey(db, user, args) },
 		"accountListEgressKeys":  func() error { return cmdaccount.ListEgressKeys(db, user, args) },
 		"accountListAccess":      func() error { return cmdaccount.ListAccess(db, user, args) },
+		"accountExplainAccess":   func() error { return cmdssh.AccountExplainAccess(db, user, args) },
 		"accountAddAccess":       func() error { return cmdaccount.AddAccess(db, user, args) },
 		"accountDelAccess":       func() error { return cmdaccount.DelAccess(db, user, args) },
+		"accountModifyAccess":    func() error { return cmdaccount.ModifyAccess(db, user, args) },
 		"whoHasAccessTo":         func() error { return cmdaccount.WhoHasAccessTo(db, user, args) },
 		"accountDisableTOTP":     func() error { return cmdaccount.DisableTOTP(db, user, log, args) },
 		"accountSetPassword":     func() error { return cmdaccount.SetPassword(db, user, log, args) },
@@ -75,17 +91,45 @@ func buildHandlers(db *gorm.DB, user *models.User, log *slog.Logger, adapter osa
 		"pivListTrustAnchors":  func() error { return cmdpiv.ListTrustAnchors(db, user, args) },
 		"pivRemoveTrustAnchor": func() error { return cmdpiv.RemoveTrustAnchor(db, user, args) },
 
+		// Ingress CAs
+		"ingressAddTrustedCA":    func() error { return cmdingressca.AddTrustedCA(db, user, log, args) },
+		"ingressListTrustedCAs":  func() error { return cmdingressca.ListTrustedCAs(db, user) },
+		"ingressRemoveTrustedCA": func() error { return cmdingressca.RemoveTrustedCA(db, user, log, args) },
+		"revocationAddKey":       func() error { return cmdrevocation.AddKey(db, user, log, args) },
+		"revocationListKeys":     func() error { return cmdrevocation.ListKeys(db, user) },
+		"revocationRemoveKey":    func() error { return cmdrevocation.RemoveKey(db, user, log, args) },
+
 		// Realms
 		"realmCreate": func() error { return cmdrealm.Create(db, user, args) },
 		"realmList":   func() error { return cmdrealm.List(db, user, args) },
 		"realmInfo":   func() error { return cmdrealm.Info(db, user, args) },
 		"realmDelete": func() error { return cmdrealm.Delete(db, user, args) },
 
+		// Custom roles
+		"roleList":     func() error { return cmdrole.List(db, user, args) },
+		"roleCreate":   func() error { return cmdrole.Create(db, user, log, args, PermissionNames()) },
+		"roleModify":   func() error { return cmdrole.Modify(db, user, log, args, PermissionNames()) },
+		"roleDelete":   func() error { return cmdrole.Delete(db, user, log, args) },
+		"roleAssign":   func() error { return cmdrole.Assign(db, user, log, args) },
+		"roleUnassign": func() error { return cmdrole.Unassign(db, user, log, args) },
+
+		// Host inventory
+		"hostList":   func() error { return cmdhost.List(db, user, args) },
+		"hostInfo":   func() error { return cmdhost.Info(db, user, args) },
+		"hostAdd":    func() error { return cmdhost.Add(db, user, log, args) },
+		"hostTag":    func() error { return cmdhost.Tag(db, user, log, args) },
+		"hostDelete": func() error { return cmdhost.Delete(db, user, log, args) },
+
 		// Restricted grants
 		"restrictedGrantAdd":  func() error { return cmdrestricted.GrantAdd(db, user, args) },
 		"restrictedGrantDel":  func() error { return cmdrestricted.GrantDel(db, user, args) },
 		"restrictedGrantList": func() error { return cmdrestricted.GrantList(db, user, args) },
 
+		// Break-glass
+		"breakGlass":     func() error { return cmdbreakglass.BreakGlass(db, user, log, args) },
+		"breakGlassList": func() error { return cmdbreakglass.List(db, user, args) },
+		"breakGlassAck":  func() error { return cmdbreakglass.Ack(db, user, log, args) },
+
 		// Groups: Overview
 		"groupInfo":   func() error { return cmdgroup.Info(db, user, args) },
 		"groupList":   func() error { return cmdgroup.List(db, user, args) },
@@ -93,24 +137,46 @@ func buildHandlers(db *gorm.DB, user *models.User, log *slog.Logger, adapter osa
 		"groupDelete": func() error { return cmdgroup.Delete(db, user, args) },
 
 		// Groups: Members
-		"groupAddMember": func() error { return cmdgroup.AddMember(db, user, args) },
-		"groupDelMember": func() error { return cmdgroup.DelMember(db, user, args) },
+		"groupAddMember":     func() error { return cmdgroup.AddMember(db, user, args) },
+		"groupImportMembers": func() error { return cmdgroup.ImportMembers(db, user, args, os.Stdin) },
+		"groupDelMember":     func() error { return cmdgroup.DelMember(db, user, args) },
+		"groupExtendMember":  func() error { return cmdgroup.ExtendMember(db, user, args) },
+		"groupAddSubgroup":   func() error { return cmdgroup.AddSubgroup(db, user, args) },
+		"groupDelSubgroup":   func() error { return cmdgroup.DelSubgroup(db, user, args) },
 
 		// Groups: Egress
 		"groupListEgressKeys":    func() error { return cmdgroup.ListEgressKeys(db, user, args) },
 		"groupGenerateEgressKey": func() error { return cmdgroup.GenerateEgressKey(db, user, args) },
+		"groupRotateEgressKey":   func() error { return cmdgroup.RotateEgressKey(db, user, log, args) },
+		"groupDeployEgressKey":   func() error { return cmdgroup.DeployEgressKey(db, user, log, args) },
 
 		// Groups: Accesses
-		"groupListAccesses": func() error { return cmdgroup.ListAccesses(db, user, args) },
-		"groupAddAccess":    func() error { return cmdgroup.AddAccess(db, user, args) },
-		"groupDelAccess":    func() error { return cmdgroup.DelAccess(db, user, args) },
-		"groupSetMFA":       func() error { return cmdgroup.SetMFA(db, user, log, args) },
+		"groupListAccesses":     func() error { return cmdgroup.ListAccesses(db, user, args) },
+		"groupAddAccess":        func() error { return cmdgroup.AddAccess(db, user, args) },
+		"groupImportAccesses":   func() error { return cmdgroup.ImportAccesses(db, user, args, os.Stdin) },
+		"groupDelAccess":        func() error { return cmdgroup.DelAccess(db, user, args) },
+		"groupModifyAccess":     func() error { return cmdgroup.ModifyAccess(db, user, args) },
+		"groupSetMFA":           func() error { return cmdgroup.SetMFA(db, user, log, args) },
+		"groupSetJustification": func() error { return cmdgroup.SetJustification(db, user, log, args) },
 
 		// Groups: Guest Accesses
 		"groupAddGuestAccess":    func() error { return cmdgroup.AddGuestAccess(db, user, args) },
 		"groupDelGuestAccess":    func() error { return cmdgroup.DelGuestAccess(db, user, args) },
+		"groupModifyGuestAccess": func() error { return cmdgroup.ModifyGuestAccess(db, user, args) },
 		"groupListGuestAccesses": func() error { return cmdgroup.ListGuestAccesses(db, user, args) },
 
+		// Groups: Access requests
+		"accessRequest":        func() error { return cmdaccessrequest.Request(db, user, args) },
+		"accessRequestList":    func() error { return cmdaccessrequest.List(db, user, args) },
+		"accessRequestApprove": func() error { return cmdaccessrequest.Approve(db, user, args) },
+		"accessRequestDeny":    func() error { return cmdaccessrequest.Deny(db, user, args) },
+
+		// Groups: Access reviews
+		"reviewStart":  func() error { return cmdreview.Start(db, user, args) },
+		"reviewList":   func() error { return cmdreview.List(db, user, args) },
+		"reviewDecide": func() error { return cmdreview.Decide(db, user, args) },
+		"reviewReport": func() error { return cmdreview.Report(db, user, args) },
+
 		// Groups: Aliases
 		"groupAddAlias":    func() error { return cmdgroup.AddAlias(db, user, args) },
 		"groupDelAlias":    func() error { return cmdgroup.DelAlias(db, user, args) },
@@ -120,6 +186,7 @@ func buildHandlers(db *gorm.DB, user *models.User, log *slog.Logger, adapter osa
 		"selfListDBAccesses": func() error { return cmdself.ListDBAccesses(db, user) },
 		"selfAddDBAccess":    func() error { return cmdself.AddDBAccess(db, user, args) },
 		"selfDelDBAccess":    func() error { return cmdself.DelDBAccess(db, user, args) },
+		"selfModifyDBAccess": func() error { return cmdself.ModifyDBAccess(db, user, args) },
 		"selfListDBAliases":  func() error { return cmdself.ListDBAliases(db, user) },
 		"selfAddDBAlias":     func() error { return cmdself.AddDBAlias(db, user, args) },
 		"selfDelDBAlias":     func() error { return cmdself.DelDBAlias(db, user, args) },
@@ -128,6 +195,7 @@ func buildHandlers(db *gorm.DB, user *models.User, log *slog.Logger, adapter osa
 		"groupListDBAccesses": func() error { return cmdgroup.ListDBAccesses(db, user, args) },
 		"groupAddDBAccess":    func() error { return cmdgroup.AddDBAccess(db, user, args) },
 		"groupDelDBAccess":    func() error { return cmdgroup.DelDBAccess(db, user, args) },
+		"groupModifyDBAccess": func() error { return cmdgroup.ModifyDBAccess(db, user, args) },
 		"groupListDBAliases":  func() error { return cmdgroup.ListDBAliases(db, user, args) },
 		"groupAddDBAlias":     func() error { return cmdgroup.AddDBAlias(db, user, args) },
 		"groupDelDBAlias":     func() error { return cmdgroup.DelDBAlias(db, user, args) },
@@ -135,6 +203,7 @@ func buildHandlers(db *gorm.DB, user *models.User, log *slog.Logger, adapter osa
 		// Groups: Guest DB Accesses
 		"groupAddGuestDBAccess":    func() error { return cmdgroup.AddGuestDBAccess(db, user, args) },
 		"groupDelGuestDBAccess":    func() error { return cmdgroup.DelGuestDBAccess(db, user, args) },
+		"groupModifyGuestDBAccess": func() error { return cmdgroup.ModifyGuestDBAccess(db, user, args) },
 		"groupListGuestDBAccesses": func() error { return cmdgroup.ListGuestDBAccesses(db, user, args) },
 
 		// TTY
@@ -149,5 +218,11 @@ func buildHandlers(db *gorm.DB, user *models.User, log *slog.Logger, adapter osa
 		// Bastion Config
 		"bastionConfig":          func() error { return cmdconfig.BastionConfig(db, user) },
 		"bastionShowSFTPHostKey": func() error { return cmdconfig.ShowSFTPProxyHostKey(db, user) },
+		"bastionShowUserCA":      func() error { return cmdconfig.ShowUserCA(db, user) },
+		"bastionRotateUserCA":    func() error { return cmdconfig.RotateUserCA(db, user, log, args) },
+		"bastionShowHostCA":      func() error { return cmdconfig.ShowHostCA(db, user) },
+		"bastionRotateHostCA":    func() error { return cmdconfig.RotateHostCA(db, user, log, args) },
+		"stateApply":             func() error { return cmdconfig.StateApply(db, user, args, os.Stdin) },
+		"stateExport":            func() error { return cmdconfig.StateExport(db, user, os.Stdout) },
 	}
 }
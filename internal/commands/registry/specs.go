@@ -25,7 +25,23 @@ var commandRegistry = []CommandMeta{
 	{Name: "selfAddIngressKey", Description: "Add a new ingress key", Permission: "selfAddIngressKey",
 		Category: "MANAGE YOUR ACCOUNT", SubCategory: "Ingress (you → bastion)", Mutating: true,
 		Features: []string{"self_ingress"},
-		Args:     []ArgSpec{{"--key", "SSH public key"}, {"--expires", "Key expiry in days"}}},
+		Args: []ArgSpec{
+			{"--key", "SSH public key"},
+			{"--expires", "Key expiry in days"},
+			{"--from", "Source CIDRs the key may log in from"},
+			{"--no-port-forwarding", "Forbid port forwarding"},
+			{"--no-agent-forwarding", "Forbid agent forwarding"},
+		}},
+	{Name: "selfModifyIngressKey", Description: "Modify the options of an ingress key", Permission: "selfModifyIngressKey",
+		Category: "MANAGE YOUR ACCOUNT", SubCategory: "Ingress (you → bastion)", Mutating: true,
+		Features: []string{"self_ingress"},
+		Args: []ArgSpec{
+			{"--id", "SSH public key ID"},
+			{"--expires", "New expiry in days (0 = never)"},
+			{"--from", "New source CIDRs (empty = anywhere)"},
+			{"--no-port-forwarding", "true/false"},
+			{"--no-agent-forwarding", "true/false"},
+		}},
 	{Name: "selfDelIngressKey", Description: "Delete an ingress key", Permission: "selfDelIngressKey",
 		Category: "MANAGE YOUR ACCOUNT", SubCategory: "Ingress (you → bastion)", Mutating: true,
 		Features: []string{"self_ingress"},
@@ -38,6 +54,13 @@ var commandRegistry = []CommandMeta{
 		Category: "MANAGE YOUR ACCOUNT", SubCategory: "Egress (bastion → server)", Mutating: true,
 		Features: []string{"egress_key"},
 		Args:     []ArgSpec{{"--type", "Key type (e.g., rsa, ed25519)"}, {"--size", "Key size"}}},
+	{Name: "selfDeployEgressKey", Description: "Add your egress public keys to the authorized_keys of your targets", Permission: "selfDeployEgressKey",
+		Category: "MANAGE YOUR ACCOUNT", SubCategory: "Egress (bastion → server)", Mutating: true,
+		Features: []string{"egress_key"},
+		Args: []ArgSpec{
+			{"--server", "Comma-separated servers (default: all personal accesses)"},
+			{"--remove-retired", "Remove deleted egress keys"},
+		}},
 	{Name: "selfRemoveHostFromKnownHosts", Description: "Remove a host from known hosts", Permission: "selfRemoveHostFromKnownHosts",
 		Category: "MANAGE YOUR ACCOUNT", SubCategory: "Egress (bastion → server)", Mutating: true,
 		Features: []string{"known_hosts"},
@@ -50,16 +73,25 @@ var commandRegistry = []CommandMeta{
 	// --- Self: Accesses ---
 	{Name: "selfListAccesses", Description: "List your personal accesses", Permission: "selfListAccesses",
 		Category: "MANAGE YOUR ACCOUNT", SubCategory: "Server accesses (personal)"},
+	{Name: "selfExplainAccess", Description: "Explain how the bastion decides on a target, without connecting", Permission: "selfExplainAccess",
+		Category: "MANAGE YOUR ACCOUNT", SubCategory: "Server accesses (personal)",
+		Args: []ArgSpec{{"--protocol", "Protocol (default ssh)"}, {"--db", "Explain a database target instead"}}},
 	{Name: "selfAddAccess", Description: "Add a personal access", Permission: "selfAddAccess",
 		Category: "MANAGE YOUR ACCOUNT", SubCategory: "Server accesses (personal)", Mutating: true,
 		Args: []ArgSpec{
 			{"--server", "Server name"}, {"--username", "SSH username"}, {"--port", "Port number"},
-			{"--comment", "Comment"}, {"--from", "Allowed source CIDRs (comma-separated)"},
-			{"--ttl", "Access expiry in days"}, {"--protocol", "Protocol restriction: ssh, scpupload, scpdownload, sftp, rsync"},
+			{"--comment", "Comment"}, {"--from", "Allowed source CIDRs (comma-separated)"}, {"--schedule", "Access window, e.g. \"Mon-Fri 08:00-19:00 Europe/Paris\""},
+			{"--ttl", "Access expiry in days"}, {"--starts", "Access start time (pending until then)"}, {"--protocol", "Protocol restriction: ssh, scpupload, scpdownload, sftp, rsync"},
 		}},
 	{Name: "selfDelAccess", Description: "Delete a personal access", Permission: "selfDelAccess",
 		Category: "MANAGE YOUR ACCOUNT", SubCategory: "Server accesses (personal)", Mutating: true,
 		Args: []ArgSpec{{"--id", "Access ID"}}},
+	{Name: "selfModifyAccess", Description: "Modify a personal access in place", Permission: "selfModifyAccess",
+		Category: "MANAGE YOUR ACCOUNT", SubCategory: "Server accesses (personal)", Mutating: true,
+		Args: []ArgSpec{
+			{"--id", "Access ID"}, {"--comment", "New comment"}, {"--from", "New allowed source CIDRs (empty = any)"}, {"--schedule", "New access window (empty = always)"},
+			{"--ttl", "New expiry in days (0 = never)"}, {"--starts", "New start time (now = immediately)"}, {"--protocol", "New protocol restriction"},
+		}},
 
 	// --- Self: Aliases ---
 	{Name: "selfListAliases", Description: "List your personal aliases", Permission: "selfListAliases",
@@ -85,13 +117,20 @@ var commandRegistry = []CommandMeta{
 			{"--protocol", "Protocol: mysql, postgres, redis"},
 			{"--user", "Database username"}, {"--password", "Database password (encrypted if EGRESS_ENC_KEY is configured, optional)"},
 			{"--database", "Database name (optional)"},
-			{"--comment", "Comment"}, {"--from", "Allowed source CIDRs"},
-			{"--ttl", "Access expiry in days"},
+			{"--comment", "Comment"}, {"--from", "Allowed source CIDRs"}, {"--schedule", "Access window, e.g. \"Mon-Fri 08:00-19:00 Europe/Paris\""},
+			{"--ttl", "Access expiry in days"}, {"--starts", "Access start time (pending until then)"},
 		}},
 	{Name: "selfDelDBAccess", Description: "Delete a personal database access", Permission: "selfDelDBAccess",
 		Category: "MANAGE YOUR ACCOUNT", SubCategory: "Database accesses (personal)", Mutating: true,
 		Features: []string{"database"},
 		Args:     []ArgSpec{{"--id", "Access ID"}}},
+	{Name: "selfModifyDBAccess", Description: "Modify a personal database access in place", Permission: "selfModifyDBAccess",
+		Category: "MANAGE YOUR ACCOUNT", SubCategory: "Database accesses (personal)", Mutating: true,
+		Features: []string{"database"},
+		Args: []ArgSpec{
+			{"--id", "Access ID"}, {"--comment", "New comment"}, {"--from", "New allowed source CIDRs (empty = any)"}, {"--schedule", "New access window (empty = always)"},
+			{"--ttl", "New expiry in days (0 = never)"}, {"--starts", "New start time (now = immediately)"}, {"--database", "New database name"}, {"--password", "New password"},
+		}},
 	{Name: "selfListDBAliases", Description: "List your personal database aliases", Permission: "selfListDBAliases",
 		Category: "MANAGE YOUR ACCOUNT", SubCategory: "Database aliases (personal)",
 		Features: []string{"database"}},
@@ -130,6 +169,10 @@ var commandRegistry = []CommandMeta{
 			{"--attest", "Path to PIV attestation certificate (PEM)"},
 			{"--intermediate", "Path to intermediate certificate (PEM)"},
 			{"--comment", "Comment for this key"},
+			{"--expires", "Key expiry in days"},
+			{"--from", "Source CIDRs the key may log in from"},
+			{"--no-port-forwarding", "Forbid port forwarding"},
+			{"--no-agent-forwarding", "Forbid agent forwarding"},
 		}},
 	{Name: "selfGenerateBackupCodes", Description: "Generate backup codes", Permission: "selfSetupTOTP",
 		Category: "MANAGE YOUR ACCOUNT", SubCategory: "Login security (you → bastion)", Mutating: true,
@@ -160,24 +203,46 @@ var commandRegistry = []CommandMeta{
 	{Name: "accountListIngressKeys", Description: "List account ingress keys", Permission: "accountListIngressKeys",
 		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Account keys",
 		Args: []ArgSpec{{"--user", "Username"}}},
+	{Name: "accountAddIngressKey", Description: "Add an ingress key to an account", Permission: "accountAddIngressKey",
+		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Account keys", Mutating: true,
+		Args: []ArgSpec{
+			{"--user", "Username"},
+			{"--key", "SSH public key"},
+			{"--expires", "Key expiry in days"},
+			{"--from", "Source CIDRs the key may log in from"},
+			{"--no-port-forwarding", "Forbid port forwarding"},
+			{"--no-agent-forwarding", "Forbid agent forwarding"},
+		}},
 	{Name: "accountListEgressKeys", Description: "List account egress keys", Permission: "accountListEgressKeys",
 		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Account keys",
 		Args: []ArgSpec{{"--user", "Username"}}},
 	{Name: "accountListAccess", Description: "List account accesses", Permission: "accountListAccess",
 		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Account accesses",
 		Args: []ArgSpec{{"--user", "Username"}}},
+	{Name: "accountExplainAccess", Description: "Explain how the bastion decides on a target for an account", Permission: "accountExplainAccess",
+		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Account accesses",
+		Args: []ArgSpec{
+			{"--user", "Username"}, {"--protocol", "Protocol (default ssh)"},
+			{"--from-ip", "Client IP to evaluate --from restrictions against"}, {"--db", "Explain a database target instead"},
+		}},
 	{Name: "accountAddAccess", Description: "Add access to an account", Permission: "accountAddAccess",
 		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Account accesses", Mutating: true,
 		Args: []ArgSpec{
 			{"--user", "Username"}, {"--server", "SSH Server"}, {"--port", "SSH Port"},
 			{"--username", "SSH Username"}, {"--comment", "Comment"},
-			{"--from", "Allowed source CIDRs (comma-separated)"},
-			{"--ttl", "Access expiry in days"},
+			{"--from", "Allowed source CIDRs (comma-separated)"}, {"--schedule", "Access window, e.g. \"Mon-Fri 08:00-19:00 Europe/Paris\""},
+			{"--ttl", "Access expiry in days"}, {"--starts", "Access start time (pending until then)"},
 			{"--protocol", "Protocol restriction: ssh, scpupload, scpdownload, sftp, rsync"},
 		}},
 	{Name: "accountDelAccess", Description: "Remove access from an account", Permission: "accountDelAccess",
 		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Account accesses", Mutating: true,
 		Args: []ArgSpec{{"--access", "Access ID"}}},
+	{Name: "accountModifyAccess", Description: "Modify an account access in place", Permission: "accountModifyAccess",
+		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Account accesses", Mutating: true,
+		Args: []ArgSpec{
+			{"--access", "Access ID"}, {"--comment", "New comment"}, {"--from", "New allowed source CIDRs (empty = any)"}, {"--schedule", "New access window (empty = always)"},
+			{"--ttl", "New expiry in days (0 = never)"}, {"--starts", "New start time (now = immediately)"}, {"--protocol", "New protocol restriction"},
+		}},
 	{Name: "whoHasAccessTo", Description: "List accounts with access to a server", Permission: "whoHasAccessTo",
 		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Account accesses",
 		Args: []ArgSpec{{"--server", "Server"}}},
@@ -197,6 +262,33 @@ var commandRegistry = []CommandMeta{
 		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Accounts", Mutating: true,
 		Args: []ArgSpec{{"--user", "Username to lock"}}},
 
+	// --- Custom roles ---
+	{Name: "roleList", Description: "List built-in and custom roles", Permission: "roleList",
+		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Custom roles",
+		Args: []ArgSpec{{"--name", "Show one custom role with its assignments"}}},
+	{Name: "roleCreate", Description: "Create a custom role from a set of permissions", Permission: "roleCreate",
+		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Custom roles", Mutating: true,
+		Args: []ArgSpec{
+			{"--name", "Role name"}, {"--permissions", "Comma-separated command permissions"},
+			{"--description", "Optional description"},
+		}},
+	{Name: "roleModify", Description: "Change the permissions or description of a custom role", Permission: "roleModify",
+		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Custom roles", Mutating: true,
+		Args: []ArgSpec{
+			{"--name", "Role name"}, {"--permissions", "Replace all permissions"},
+			{"--add", "Permissions to add"}, {"--remove", "Permissions to remove"},
+			{"--description", "New description"},
+		}},
+	{Name: "roleDelete", Description: "Delete a custom role and its assignments", Permission: "roleDelete",
+		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Custom roles", Mutating: true,
+		Args: []ArgSpec{{"--name", "Role name"}}},
+	{Name: "roleAssign", Description: "Assign a custom role to an account", Permission: "roleAssign",
+		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Custom roles", Mutating: true,
+		Args: []ArgSpec{{"--name", "Role name"}, {"--user", "Target username"}, {"--group", "Limit the role to one group (optional)"}}},
+	{Name: "roleUnassign", Description: "Remove a custom role from an account", Permission: "roleUnassign",
+		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Custom roles", Mutating: true,
+		Args: []ArgSpec{{"--name", "Role name"}, {"--user", "Target username"}, {"--group", "Group scope of the assignment"}}},
+
 	// --- PIV ---
 	{Name: "pivAddTrustAnchor", Description: "Add a PIV/YubiKey CA trust anchor", Permission: "pivAddTrustAnchor",
 		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Account accesses", Mutating: true,
@@ -209,6 +301,26 @@ var commandRegistry = []CommandMeta{
 		Features: []string{"pivs"},
 		Args:     []ArgSpec{{"--name", "Name of the trust anchor to remove"}}},
 
+	// --- Ingress CAs ---
+	{Name: "ingressAddTrustedCA", Description: "Trust an SSH user CA for logins to the bastion", Permission: "ingressAddTrustedCA",
+		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Account keys", Mutating: true,
+		Args: []ArgSpec{{"--name", "Friendly name for this CA"}, {"--key", "CA public key"}, {"--principals", "Accepted principals, %u is the username"}}},
+	{Name: "ingressListTrustedCAs", Description: "List the SSH user CAs trusted for logins", Permission: "ingressListTrustedCAs",
+		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Account keys"},
+	{Name: "ingressRemoveTrustedCA", Description: "Stop trusting an SSH user CA for logins", Permission: "ingressRemoveTrustedCA",
+		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Account keys", Mutating: true,
+		Args: []ArgSpec{{"--name", "Name of the CA to remove"}}},
+
+	// --- Key revocation ---
+	{Name: "revocationAddKey", Description: "Revoke a compromised SSH key bastion-wide", Permission: "revocationAddKey",
+		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Account keys", Mutating: true,
+		Args: []ArgSpec{{"--key", "Public key to revoke"}, {"--fingerprint", "Or its SHA256 fingerprint"}, {"--reason", "Why the key is revoked"}}},
+	{Name: "revocationListKeys", Description: "List the revoked SSH keys", Permission: "revocationListKeys",
+		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Account keys"},
+	{Name: "revocationRemoveKey", Description: "Take an SSH key off the revocation list", Permission: "revocationRemoveKey",
+		Category: "MANAGE OTHER ACCOUNTS", SubCategory: "Account keys", Mutating: true,
+		Args: []ArgSpec{{"--fingerprint", "SHA256 fingerprint of the revoked key"}}},
+
 	// --- Realms ---
 	{Name: "realmCreate", Description: "Create a trusted realm configuration", Permission: "realmCreate",
 		Category: "RESTRICTED OPERATIONS", SubCategory: "Realms", Mutating: true,
@@ -244,6 +356,45 @@ var commandRegistry = []CommandMeta{
 		Features: []string{"restricted_cmds", "restricted_grants"},
 		Args:     []ArgSpec{{"--user", "Optional username filter"}}},
 
+	// --- Break-glass ---
+	{Name: "breakGlass", Description: "Declare emergency access to a server no grant covers (recorded and reviewed)", Permission: "breakGlass",
+		Category: "RESTRICTED OPERATIONS", SubCategory: "Break-glass",
+		Features: []string{"restricted_cmds", "break_glass"},
+		Args: []ArgSpec{
+			{"--server", "Target server hostname/IP"}, {"--reason", "Why emergency access is needed"},
+			{"--port", "Target port (default 22)"}, {"--user", "Target username (optional)"},
+			{"--duration", "Access duration (default and max: break_glass.max_duration)"},
+		}},
+	{Name: "breakGlassList", Description: "List break-glass declarations awaiting review", Permission: "breakGlassList",
+		Category: "RESTRICTED OPERATIONS", SubCategory: "Break-glass",
+		Features: []string{"break_glass"},
+		Args:     []ArgSpec{{"--all", "Include acknowledged declarations"}}},
+	{Name: "breakGlassAck", Description: "Acknowledge a reviewed break-glass declaration", Permission: "breakGlassAck",
+		Category: "RESTRICTED OPERATIONS", SubCategory: "Break-glass", Mutating: true,
+		Features: []string{"break_glass"},
+		Args:     []ArgSpec{{"--id", "Break-glass declaration ID"}, {"--comment", "Review comment"}}},
+
+	// --- Host inventory ---
+	{Name: "hostList", Description: "List inventory hosts", Permission: "hostList",
+		Category: "HOST INVENTORY", SubCategory: "Hosts",
+		Args: []ArgSpec{{"--tags", "Tag selector (key=value,...)"}, {"--owner", "Owner group"}}},
+	{Name: "hostInfo", Description: "Show an inventory host and the tag-based accesses selecting it", Permission: "hostInfo",
+		Category: "HOST INVENTORY", SubCategory: "Hosts",
+		Args: []ArgSpec{{"--host", "Inventory name"}}},
+	{Name: "hostAdd", Description: "Add a host to the inventory", Permission: "hostAdd",
+		Category: "HOST INVENTORY", SubCategory: "Hosts", Mutating: true,
+		Args: []ArgSpec{
+			{"--name", "Inventory name"}, {"--address", "Comma-separated hostnames/IPs (default: the name)"},
+			{"--tags", "Comma-separated key=value tags"}, {"--owner", "Owner group"},
+			{"--description", "Free-text description"},
+		}},
+	{Name: "hostTag", Description: "Set or remove tags on an inventory host", Permission: "hostTag",
+		Category: "HOST INVENTORY", SubCategory: "Hosts", Mutating: true,
+		Args: []ArgSpec{{"--host", "Inventory name"}, {"--set", "Tags to add or overwrite"}, {"--remove", "Tag keys to remove"}}},
+	{Name: "hostDelete", Description: "Remove a host from the inventory", Permission: "hostDelete",
+		Category: "HOST INVENTORY", SubCategory: "Hosts", Mutating: true,
+		Args: []ArgSpec{{"--host", "Inventory name"}}},
+
 	// --- Groups: Overview ---
 	{Name: "groupInfo", Description: "Show group info", Permission: "groupInfo",
 		Category: "MANAGE GROUPS", SubCategory: "Groups", Features: []string{"groups"},
@@ -267,11 +418,31 @@ var commandRegistry = []CommandMeta{
 		Args: []ArgSpec{
 			{"--group", "Group name"}, {"--user", "Username to add"},
 			{"--role", "Role (owner, aclkeeper, gatekeeper, member, guest)"},
+			{"--ttl", "Membership expiry in days (optional)"}, {"--until", "Membership expiry date (optional)"},
 		}},
 	{Name: "groupDelMember", Description: "Remove a member from a group", Permission: "groupDelMember",
 		Category: "MANAGE GROUPS", SubCategory: "Group member management", Mutating: true,
 		Features: []string{"groups"},
 		Args:     []ArgSpec{{"--group", "Group name"}, {"--user", "Username to remove"}}},
+	{Name: "groupImportMembers", Description: "Add the members listed in a CSV read from stdin", Permission: "groupImportMembers",
+		Category: "MANAGE GROUPS", SubCategory: "Group member management", Mutating: true,
+		Features: []string{"groups"},
+		Args:     []ArgSpec{{"--group", "Group name"}, {"--dry-run", "Validate and report without adding anything"}}},
+	{Name: "groupExtendMember", Description: "Change the expiry of a group membership", Permission: "groupExtendMember",
+		Category: "MANAGE GROUPS", SubCategory: "Group member management", Mutating: true,
+		Features: []string{"groups"},
+		Args: []ArgSpec{
+			{"--group", "Group name"}, {"--user", "Member username"}, {"--ttl", "New expiry in days from now"},
+			{"--until", "New expiry date (YYYY-MM-DD)"}, {"--permanent", "Remove the expiry"},
+		}},
+	{Name: "groupAddSubgroup", Description: "Include a group in another; its members inherit the parent's accesses", Permission: "groupAddSubgroup",
+		Category: "MANAGE GROUPS", SubCategory: "Group member management", Mutating: true,
+		Features: []string{"groups"},
+		Args:     []ArgSpec{{"--group", "Parent group name"}, {"--subgroup", "Group to include"}}},
+	{Name: "groupDelSubgroup", Description: "Remove an included group", Permission: "groupDelSubgroup",
+		Category: "MANAGE GROUPS", SubCategory: "Group member management", Mutating: true,
+		Features: []string{"groups"},
+		Args:     []ArgSpec{{"--group", "Parent group name"}, {"--subgroup", "Included group to remove"}}},
 
 	// --- Groups: Egress ---
 	{Name: "groupListEgressKeys", Description: "List group egress keys", Permission: "groupListEgressKeys",
@@ -284,6 +455,23 @@ var commandRegistry = []CommandMeta{
 			{"--group", "Group name"}, {"--type", "Key type"}, {"--size", "Key size"},
 			{"--comment", "Key comment"},
 		}},
+	{Name: "groupRotateEgressKey", Description: "Rotate the group egress key with an overlap period", Permission: "groupRotateEgressKey",
+		Category: "MANAGE GROUPS", SubCategory: "Group egress (bastion → server)", Mutating: true,
+		Features: []string{"groups"},
+		Args: []ArgSpec{
+			{"--group", "Group name"}, {"--type", "Key type"}, {"--size", "Key size"},
+			{"--grace", "Days the previous key stays usable at least"},
+			{"--status", "Show the rotation progress"}, {"--verify", "Probe the targets with the next key now"},
+			{"--promote", "Make the next key active without waiting for verification"},
+			{"--cancel", "Abandon the next key"},
+		}},
+	{Name: "groupDeployEgressKey", Description: "Add the group egress public keys to the authorized_keys of its targets", Permission: "groupDeployEgressKey",
+		Category: "MANAGE GROUPS", SubCategory: "Group egress (bastion → server)", Mutating: true,
+		Features: []string{"groups"},
+		Args: []ArgSpec{
+			{"--group", "Group name"}, {"--server", "Comma-separated servers (default: all group accesses)"},
+			{"--remove-retired", "Remove retired group keys"},
+		}},
 
 	// --- Groups: Accesses ---
 	{Name: "groupListAccesses", Description: "List accesses of the group", Permission: "groupListAccesses",
@@ -293,17 +481,29 @@ var commandRegistry = []CommandMeta{
 		Category: "MANAGE GROUPS", SubCategory: "Group accesses", Mutating: true,
 		Features: []string{"groups"},
 		Args: []ArgSpec{
-			{"--group", "Group name"}, {"--server", "SSH Server"}, {"--port", "SSH Port"},
+			{"--group", "Group name"}, {"--server", "SSH Server"},
+			{"--tags", "Inventory tag selector instead of --server (key=value,...)"}, {"--port", "SSH Port"},
 			{"--username", "SSH username"}, {"--comment", "Comment"},
-			{"--from", "Allowed source CIDRs (comma-separated)"},
-			{"--ttl", "Access expiry in days"},
+			{"--from", "Allowed source CIDRs (comma-separated)"}, {"--schedule", "Access window, e.g. \"Mon-Fri 08:00-19:00 Europe/Paris\""},
+			{"--ttl", "Access expiry in days"}, {"--starts", "Access start time (pending until then)"},
 			{"--protocol", "Protocol restriction: ssh, scpupload, scpdownload, sftp, rsync"},
 			{"--force", "Skip connectivity check"},
 		}},
+	{Name: "groupImportAccesses", Description: "Add the accesses listed in a CSV read from stdin", Permission: "groupImportAccesses",
+		Category: "MANAGE GROUPS", SubCategory: "Group accesses", Mutating: true,
+		Features: []string{"groups"},
+		Args:     []ArgSpec{{"--group", "Group name"}, {"--dry-run", "Validate and report without adding anything"}}},
 	{Name: "groupDelAccess", Description: "Remove access from a group", Permission: "groupDelAccess",
 		Category: "MANAGE GROUPS", SubCategory: "Group accesses", Mutating: true,
 		Features: []string{"groups"},
 		Args:     []ArgSpec{{"--group", "Group name"}, {"--access", "Access ID to remove"}}},
+	{Name: "groupModifyAccess", Description: "Modify a group access in place", Permission: "groupModifyAccess",
+		Category: "MANAGE GROUPS", SubCategory: "Group accesses", Mutating: true,
+		Features: []string{"groups"},
+		Args: []ArgSpec{
+			{"--group", "Group name"}, {"--access", "Access ID to modify"}, {"--comment", "New comment"}, {"--from", "New allowed source CIDRs (empty = any)"}, {"--schedule", "New access window (empty = always)"},
+			{"--ttl", "New expiry in days (0 = never)"}, {"--starts", "New start time (now = immediately)"}, {"--protocol", "New protocol restriction"},
+		}},
 	{Name: "groupSetMFA", Description: "Enable/disable JIT MFA requirement for a group", Permission: "groupSetMFA",
 		Category: "MANAGE GROUPS", SubCategory: "Group accesses", Mutating: true,
 		Features: []string{"groups"},
@@ -311,6 +511,14 @@ var commandRegistry = []CommandMeta{
 			{"--group", "Group name"}, {"--required", "Require MFA for this group"},
 			{"--optional", "Remove MFA requirement for this group"},
 		}},
+	{Name: "groupSetJustification", Description: "Require a reason or ticket ID to connect through a group", Permission: "groupSetJustification",
+		Category: "MANAGE GROUPS", SubCategory: "Group accesses", Mutating: true,
+		Features: []string{"groups"},
+		Args: []ArgSpec{
+			{"--group", "Group name"}, {"--required", "Require a justification for this group"},
+			{"--pattern", "Regex the whole justification must match (optional)"},
+			{"--optional", "Remove the justification requirement for this group"},
+		}},
 
 	// --- Groups: Guest Accesses ---
 	{Name: "groupAddGuestAccess", Description: "Grant guest access to a specific server in a group", Permission: "groupAddGuestAccess",
@@ -320,18 +528,75 @@ var commandRegistry = []CommandMeta{
 			{"--group", "Group name"}, {"--account", "Username to grant access to"},
 			{"--host", "Server hostname/IP"}, {"--user", "Remote username"},
 			{"--port", "Remote port (default 22)"}, {"--protocol", "Protocol: ssh, scpupload, scpdownload, sftp, rsync"},
-			{"--ttl", "Access expiry in days"}, {"--comment", "Comment"},
-			{"--from", "Allowed source CIDRs"},
+			{"--ttl", "Access expiry in days"}, {"--starts", "Access start time (pending until then)"}, {"--comment", "Comment"},
+			{"--from", "Allowed source CIDRs"}, {"--schedule", "Access window, e.g. \"Mon-Fri 08:00-19:00 Europe/Paris\""},
 		}},
 	{Name: "groupDelGuestAccess", Description: "Remove a guest access grant from a group", Permission: "groupDelGuestAccess",
 		Category: "MANAGE GROUPS", SubCategory: "Group guest accesses", Mutating: true,
 		Features: []string{"guest_access", "groups"},
 		Args:     []ArgSpec{{"--group", "Group name"}, {"--account", "Username"}, {"--grant", "Grant ID (optional, removes all if omitted)"}}},
+	{Name: "groupModifyGuestAccess", Description: "Modify a guest access grant in place", Permission: "groupModifyGuestAccess",
+		Category: "MANAGE GROUPS", SubCategory: "Group guest accesses", Mutating: true,
+		Features: []string{"guest_access", "groups"},
+		Args: []ArgSpec{
+			{"--group", "Group name"}, {"--grant", "Grant ID to modify"}, {"--comment", "New comment"}, {"--from", "New allowed source CIDRs (empty = any)"}, {"--schedule", "New access window (empty = always)"},
+			{"--ttl", "New expiry in days (0 = never)"}, {"--starts", "New start time (now = immediately)"}, {"--protocol", "New protocol restriction"},
+		}},
 	{Name: "groupListGuestAccesses", Description: "List guest accesses for a user in a group", Permission: "groupListGuestAccesses",
 		Category: "MANAGE GROUPS", SubCategory: "Group guest accesses",
 		Features: []string{"guest_access", "groups"},
 		Args:     []ArgSpec{{"--group", "Group name"}, {"--account", "Username"}}},
 
+	// --- Groups: Access Requests ---
+	{Name: "accessRequest", Description: "Request time-boxed access to a server through one of your groups", Permission: "accessRequest",
+		Category: "MANAGE GROUPS", SubCategory: "Group access requests", Mutating: true,
+		Features: []string{"access_requests", "groups"},
+		Args: []ArgSpec{
+			{"--group", "Group name"}, {"--host", "Server hostname/IP"}, {"--user", "Remote username"},
+			{"--port", "Remote port (default 22)"}, {"--protocol", "Protocol: ssh, scpupload, scpdownload, sftp, rsync"},
+			{"--duration", "Requested duration, e.g. 2h"}, {"--reason", "Why the access is needed"},
+		}},
+	{Name: "accessRequestList", Description: "List access requests you filed or can decide on", Permission: "accessRequestList",
+		Category: "MANAGE GROUPS", SubCategory: "Group access requests",
+		Features: []string{"access_requests", "groups"},
+		Args: []ArgSpec{
+			{"--group", "Group name (optional)"}, {"--status", "pending (default), approved, denied or all"},
+			{"--id", "Show the audit trail of one request"},
+		}},
+	{Name: "accessRequestApprove", Description: "Approve an access request and grant the time-boxed access", Permission: "accessRequestApprove",
+		Category: "MANAGE GROUPS", SubCategory: "Group access requests", Mutating: true,
+		Features: []string{"access_requests", "groups"},
+		Args:     []ArgSpec{{"--id", "Access request ID"}, {"--comment", "Decision comment"}}},
+	{Name: "accessRequestDeny", Description: "Deny an access request", Permission: "accessRequestDeny",
+		Category: "MANAGE GROUPS", SubCategory: "Group access requests", Mutating: true,
+		Features: []string{"access_requests", "groups"},
+		Args:     []ArgSpec{{"--id", "Access request ID"}, {"--comment", "Decision comment"}}},
+
+	// --- Groups: Access reviews ---
+	{Name: "reviewStart", Description: "Start an access recertification campaign", Permission: "reviewStart",
+		Category: "MANAGE GROUPS", SubCategory: "Access reviews", Mutating: true,
+		Features: []string{"groups"},
+		Args: []ArgSpec{
+			{"--name", "Campaign name"}, {"--deadline", "Date (YYYY-MM-DD), RFC 3339 time or duration (14d)"},
+			{"--on-expiry", "revoke or flag (default) undecided items"}, {"--group", "Only review this group (optional)"},
+			{"--description", "Campaign description (optional)"},
+		}},
+	{Name: "reviewList", Description: "List review items of the groups you own", Permission: "reviewList",
+		Category: "MANAGE GROUPS", SubCategory: "Access reviews",
+		Features: []string{"groups"},
+		Args: []ArgSpec{
+			{"--campaign", "Campaign name (optional)"}, {"--group", "Group name (optional)"},
+			{"--status", "pending (default), confirmed, revoked, auto-revoked, flagged or all"},
+		}},
+	{Name: "reviewDecide", Description: "Confirm or revoke a review item", Permission: "reviewDecide",
+		Category: "MANAGE GROUPS", SubCategory: "Access reviews", Mutating: true,
+		Features: []string{"groups"},
+		Args:     []ArgSpec{{"--id", "Review item ID"}, {"--decision", "confirm or revoke"}, {"--comment", "Decision comment"}}},
+	{Name: "reviewReport", Description: "Report the outcome of review campaigns", Permission: "reviewReport",
+		Category: "MANAGE GROUPS", SubCategory: "Access reviews",
+		Features: []string{"groups"},
+		Args:     []ArgSpec{{"--campaign", "Campaign name (optional, lists campaigns when omitted)"}}},
+
 	// --- Groups: DB Accesses ---
 	{Name: "groupListDBAccesses", Description: "List database accesses of the group", Permission: "groupListDBAccesses",
 		Category: "MANAGE GROUPS", SubCategory: "Group database accesses", Features: []string{"database", "groups"},
@@ -345,13 +610,20 @@ var commandRegistry = []CommandMeta{
 			{"--protocol", "Protocol: mysql, postgres, redis"},
 			{"--user", "Database username"}, {"--password", "Database password (encrypted if EGRESS_ENC_KEY is configured, optional)"},
 			{"--database", "Database name (optional)"},
-			{"--comment", "Comment"}, {"--from", "Allowed source CIDRs"},
-			{"--ttl", "Access expiry in days"},
+			{"--comment", "Comment"}, {"--from", "Allowed source CIDRs"}, {"--schedule", "Access window, e.g. \"Mon-Fri 08:00-19:00 Europe/Paris\""},
+			{"--ttl", "Access expiry in days"}, {"--starts", "Access start time (pending until then)"},
 		}},
 	{Name: "groupDelDBAccess", Description: "Remove database access from a group", Permission: "groupDelDBAccess",
 		Category: "MANAGE GROUPS", SubCategory: "Group database accesses", Mutating: true,
 		Features: []string{"database", "groups"},
 		Args:     []ArgSpec{{"--group", "Group name"}, {"--id", "Access ID to remove"}}},
+	{Name: "groupModifyDBAccess", Description: "Modify a group database access in place", Permission: "groupModifyDBAccess",
+		Category: "MANAGE GROUPS", SubCategory: "Group database accesses", Mutating: true,
+		Features: []string{"database", "groups"},
+		Args: []ArgSpec{
+			{"--group", "Group name"}, {"--id", "Access ID to modify"}, {"--comment", "New comment"}, {"--from", "New allowed source CIDRs (empty = any)"}, {"--schedule", "New access window (empty = always)"},
+			{"--ttl", "New expiry in days (0 = never)"}, {"--starts", "New start time (now = immediately)"}, {"--database", "New database name"}, {"--password", "New password"},
+		}},
 	{Name: "groupListDBAliases", Description: "List group database aliases", Permission: "groupListDBAliases",
 		Category: "MANAGE GROUPS", SubCategory: "Group database aliases",
 		Features: []string{"database", "groups"},
@@ -378,13 +650,20 @@ var commandRegistry = []CommandMeta{
 			{"--protocol", "Protocol: mysql, postgres, redis"},
 			{"--user", "Database username"}, {"--password", "Database password (encrypted if EGRESS_ENC_KEY is configured, optional)"},
 			{"--database", "Database name (optional)"},
-			{"--comment", "Comment"}, {"--from", "Allowed source CIDRs"},
-			{"--ttl", "Access expiry in days"},
+			{"--comment", "Comment"}, {"--from", "Allowed source CIDRs"}, {"--schedule", "Access window, e.g. \"Mon-Fri 08:00-19:00 Europe/Paris\""},
+			{"--ttl", "Access expiry in days"}, {"--starts", "Access start time (pending until then)"},
 		}},
 	{Name: "groupDelGuestDBAccess", Description: "Remove guest database access grant from a group", Permission: "groupDelGuestDBAccess",
 		Category: "MANAGE GROUPS", SubCategory: "Group guest database accesses", Mutating: true,
 		Features: []string{"database", "guest_access", "groups"},
 		Args:     []ArgSpec{{"--group", "Group name"}, {"--account", "Username"}, {"--grant", "Grant ID (optional)"}}},
+	{Name: "groupModifyGuestDBAccess", Description: "Modify a guest database access grant in place", Permission: "groupModifyGuestDBAccess",
+		Category: "MANAGE GROUPS", SubCategory: "Group guest database accesses", Mutating: true,
+		Features: []string{"database", "guest_access", "groups"},
+		Args: []ArgSpec{
+			{"--group", "Group name"}, {"--grant", "Grant ID to modify"}, {"--comment", "New comment"}, {"--from", "New allowed source CIDRs (empty = any)"}, {"--schedule", "New access window (empty = always)"},
+			{"--ttl", "New expiry in days (0 = never)"}, {"--starts", "New start time (now = immediately)"}, {"--database", "New database name"}, {"--password", "New password"},
+		}},
 	{Name: "groupListGuestDBAccesses", Description: "List guest database accesses for a user in a group", Permission: "groupListGuestDBAccesses",
 		Category: "MANAGE GROUPS", SubCategory: "Group guest database accesses",
 		Features: []string{"database", "guest_access", "groups"},
@@ -430,4 +709,32 @@ var commandRegistry = []CommandMeta{
 		Category: "BASTION CONFIG", SubCategory: "Configuration"},
 	{Name: "bastionShowSFTPHostKey", Description: "Show the stable SFTP proxy host key for client distribution", Permission: "bastionConfig",
 		Category: "BASTION CONFIG", SubCategory: "Configuration"},
+	{Name: "bastionShowUserCA", Description: "Show the user CA public keys to trust on targets", Permission: "bastionShowUserCA",
+		Category: "BASTION CONFIG", SubCategory: "Certificate authority"},
+	{Name: "bastionRotateUserCA", Description: "Rotate the user CA that signs egress certificates", Permission: "bastionRotateUserCA",
+		Category: "BASTION CONFIG", SubCategory: "Certificate authority", Mutating: true,
+		Args: []ArgSpec{{"--promote", "Sign with the next CA"}, {"--cancel", "Drop the next CA"}}},
+	{Name: "bastionShowHostCA", Description: "Show the host CA lines clients add to known_hosts", Permission: "bastionShowHostCA",
+		Category: "BASTION CONFIG", SubCategory: "Certificate authority"},
+	{Name: "bastionRotateHostCA", Description: "Rotate the host CA that signs the bastion host keys", Permission: "bastionRotateHostCA",
+		Category: "BASTION CONFIG", SubCategory: "Certificate authority", Mutating: true,
+		Args: []ArgSpec{{"--promote", "Sign with the next CA"}, {"--cancel", "Drop the next CA"}}},
+	{Name: "stateApply", Description: "Apply a YAML/JSON state document read from stdin", Permission: "stateApply",
+		Category: "BASTION CONFIG", SubCategory: "Declarative state", Mutating: true,
+		Args: []ArgSpec{{"--plan", "Only show the plan"}, {"--prune", "Delete what the document does not list"}}},
+	{Name: "stateExport", Description: "Export groups, members, accesses and aliases as a state document", Permission: "stateExport",
+		Category: "BASTION CONFIG", SubCategory: "Declarative state"},
+}
+
+// PermissionNames returns the distinct permissions used by registered commands.
+func PermissionNames() []string {
+	seen := make(map[string]bool, len(commandRegistry))
+	var names []string
+	for _, meta := range commandRegistry {
+		if meta.Permission != "" && !seen[meta.Permission] {
+			seen[meta.Permission] = true
+			names = append(names, meta.Permission)
+		}
+	}
+	return names
 }
@@ -0,0 +1,636 @@
+package review
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Start opens a recertification campaign: every membership, group access and
+// guest grant of the groups in scope becomes a pending review item for the
+// group owners. Items still pending at the deadline are revoked or flagged by
+// the sync cycle, depending on --on-expiry.
+func Start(db *gorm.DB, currentUser *models.User, args []string) error {
+	fs := flag.NewFlagSet("reviewStart", flag.ContinueOnError)
+	var name, description, deadlineStr, onExpiry, groupName string
+	fs.StringVar(&name, "name", "", "Campaign name, e.g. 2026-Q4")
+	fs.StringVar(&description, "description", "", "Campaign description")
+	fs.StringVar(&deadlineStr, "deadline", "", "Deadline: a date (YYYY-MM-DD), an RFC 3339 time or a duration (14d, 336h)")
+	fs.StringVar(&onExpiry, "on-expiry", models.ReviewOnExpiryFlag, "What happens to undecided items at the deadline: revoke or flag")
+	fs.StringVar(&groupName, "group", "", "Only review this group (default: all groups)")
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+
+	err := fs.Parse(args)
+	name = strings.TrimSpace(name)
+	onExpiry = strings.ToLower(strings.TrimSpace(onExpiry))
+	if err != nil || name == "" || strings.TrimSpace(deadlineStr) == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Start Review",
+			BlockType: "error",
+			Sections: []console.SectionContent{{SubTitle: "Usage", Body: []string{
+				"Usage: reviewStart --name <name> --deadline <date|duration> [--on-expiry revoke|flag] [--group <group>] [--description <text>]",
+			}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+	if onExpiry != models.ReviewOnExpiryRevoke && onExpiry != models.ReviewOnExpiryFlag {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Start Review",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Value", Body: []string{"--on-expiry must be revoke or flag."}}},
+		})
+		return fmt.Errorf("invalid on-expiry: %s", onExpiry)
+	}
+
+	now := time.Now()
+	deadline, err := parseDeadline(deadlineStr, now)
+	if err != nil || !deadline.After(now) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Start Review",
+			BlockType: "error",
+			Sections: []console.SectionContent{{SubTitle: "Invalid Deadline", Body: []string{
+				"The deadline must be in the future: a date (2026-12-31), an RFC 3339 time or a duration (14d, 336h).",
+			}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("deadline %s is in the past", deadline.Format(time.RFC3339))
+	}
+
+	var existing models.ReviewCampaign
+	if err := db.Where("name = ?", name).First(&existing).Error; err == nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Start Review",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Already Exists", Body: []string{fmt.Sprintf("A review campaign named '%s' already exists.", name)}}},
+		})
+		return fmt.Errorf("review campaign %q already exists", name)
+	}
+
+	var groups []models.Group
+	query := db.Order("name")
+	if strings.TrimSpace(groupName) != "" {
+		query = query.Where("name = ?", strings.TrimSpace(groupName))
+	}
+	if err := query.Find(&groups).Error; err != nil || len(groups) == 0 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Start Review",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{"No group to review. Check spelling or run groupList."}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("no group to review")
+	}
+
+	campaign := models.ReviewCampaign{
+		Name:        name,
+		Description: strings.TrimSpace(description),
+		Deadline:    deadline,
+		OnExpiry:    onExpiry,
+		Status:      models.ReviewCampaignOpen,
+		CreatedByID: currentUser.ID,
+	}
+	var count int
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&campaign).Error; err != nil {
+			return err
+		}
+		for _, g := range groups {
+			items, err := collectItems(tx, campaign.ID, g)
+			if err != nil {
+				return err
+			}
+			if len(items) == 0 {
+				continue
+			}
+			if err := tx.Create(&items).Error; err != nil {
+				return err
+			}
+			count += len(items)
+		}
+		return nil
+	})
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Start Review",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Database Error", Body: []string{"Failed to create the review campaign."}}},
+		})
+		return err
+	}
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Start Review",
+		BlockType: "success",
+		Sections: []console.SectionContent{{SubTitle: "Started", Body: []string{
+			fmt.Sprintf("Campaign '%s' covers %d item(s) in %d group(s).", campaign.Name, count, len(groups)),
+			fmt.Sprintf("Deadline: %s. Undecided items will be %s.", deadline.Format("2006-01-02 15:04:05 MST"), expiryLabel(onExpiry)),
+			"Group owners review their items with reviewList and reviewDecide.",
+		}}},
+	})
+	return nil
+}
+
+// collectItems snapshots the memberships, accesses and guest grants of group.
+func collectItems(tx *gorm.DB, campaignID uuid.UUID, group models.Group) ([]models.ReviewItem, error) {
+	var items []models.ReviewItem
+	add := func(kind string, subject uuid.UUID, summary string) {
+		items = append(items, models.ReviewItem{
+			CampaignID: campaignID, GroupID: group.ID, Kind: kind, SubjectID: subject,
+			Summary: summary, Status: models.ReviewItemPending,
+		})
+	}
+
+	var members []models.UserGroup
+	if err := tx.Preload("User").Where("group_id = ?", group.ID).Find(&members).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range members {
+		add(models.ReviewItemMember, m.ID, fmt.Sprintf("%s (%s)", m.User.Username, m.Role))
+	}
+
+	var accesses []models.GroupAccess
+	if err := tx.Where("group_id = ?", group.ID).Find(&accesses).Error; err != nil {
+		return nil, err
+	}
+	for _, a := range accesses {
+		add(models.ReviewItemAccess, a.ID, fmt.Sprintf("%s@%s:%d (%s)", a.Username, a.Server, a.Port, a.Protocol))
+	}
+
+	var guests []models.GroupGuestAccess
+	if err := tx.Preload("User").Where("group_id = ?", group.ID).Find(&guests).Error; err != nil {
+		return nil, err
+	}
+	for _, g := range guests {
+		add(models.ReviewItemGuest, g.ID, fmt.Sprintf("%s → %s@%s:%d (%s)", g.User.Username, g.Username, g.Server, g.Port, g.Protocol))
+	}
+	return items, nil
+}
+
+// List shows the review items of the groups the current user owns (all
+// groups for admins and superowners), pending ones by default.
+func List(db *gorm.DB, currentUser *models.User, args []string) error {
+	fs := flag.NewFlagSet("reviewList", flag.ContinueOnError)
+	var campaignName, groupName, status string
+	fs.StringVar(&campaignName, "campaign", "", "Only show items of this campaign")
+	fs.StringVar(&groupName, "group", "", "Only show items of this group")
+	fs.StringVar(&status, "status", models.ReviewItemPending, "Filter by status: pending, confirmed, revoked, auto-revoked, flagged, all")
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+
+	err := fs.Parse(args)
+	status = strings.ToLower(strings.TrimSpace(status))
+	if err != nil || !isValidStatusFilter(status) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Review Items",
+			BlockType: "error",
+			Sections: []console.SectionContent{{SubTitle: "Usage", Body: []string{
+				"Usage: reviewList [--campaign <name>] [--group <group>] [--status pending|confirmed|revoked|auto-revoked|flagged|all]",
+			}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("invalid status filter: %s", status)
+	}
+
+	query := db.Preload("Campaign").Preload("Group").Preload("Decider").Order("created_at asc")
+	if status != "all" {
+		query = query.Where("status = ?", status)
+	}
+	if strings.TrimSpace(campaignName) != "" {
+		campaign, err := loadCampaign(db, "Review Items", campaignName)
+		if err != nil {
+			return err
+		}
+		query = query.Where("campaign_id = ?", campaign.ID)
+	}
+	if strings.TrimSpace(groupName) != "" {
+		var group models.Group
+		if err := db.Where("name = ?", strings.TrimSpace(groupName)).First(&group).Error; err != nil {
+			console.DisplayBlock(console.ContentBlock{
+				Title:     "Review Items",
+				BlockType: "error",
+				Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("Group '%s' not found.", groupName)}}},
+			})
+			return err
+		}
+		query = query.Where("group_id = ?", group.ID)
+	}
+
+	var all []models.ReviewItem
+	if err := query.Find(&all).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Review Items",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Database Error", Body: []string{"Failed to query review items."}}},
+		})
+		return err
+	}
+
+	var items []models.ReviewItem
+	for _, it := range all {
+		if it.Campaign.ID != uuid.Nil && currentUser.CanDo(db, "reviewDecide", it.Group.Name) {
+			items = append(items, it)
+		}
+	}
+	if len(items) == 0 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Review Items",
+			BlockType: "info",
+			Sections:  []console.SectionContent{{SubTitle: "Info", Body: []string{"No review items found."}}},
+		})
+		return nil
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tCampaign\tDeadline\tGroup\tKind\tItem\tStatus\tDecided By")
+	for _, it := range items {
+		decider := "-"
+		if it.Decider != nil {
+			decider = it.Decider.Username
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			it.ID, it.Campaign.Name, it.Campaign.Deadline.Format("2006-01-02 15:04"), it.Group.Name,
+			it.Kind, it.Summary, it.Status, decider)
+	}
+	_ = w.Flush()
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Review Items",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Items", Body: strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")}},
+	})
+	return nil
+}
+
+var (
+	errAlreadyDecided = errors.New("review item has already been decided")
+	errCampaignClosed = errors.New("review campaign is closed")
+)
+
+// Decide records an owner's decision on a pending review item. Revoking
+// removes the membership or grant immediately.
+func Decide(db *gorm.DB, currentUser *models.User, args []string) error {
+	const title = "Review Decision"
+	fs := flag.NewFlagSet("reviewDecide", flag.ContinueOnError)
+	var idStr, decision, comment string
+	fs.StringVar(&idStr, "id", "", "Review item ID")
+	fs.StringVar(&decision, "decision", "", "confirm or revoke")
+	fs.StringVar(&comment, "comment", "", "Decision comment")
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+
+	err := fs.Parse(args)
+	decision = strings.ToLower(strings.TrimSpace(decision))
+	if err != nil || strings.TrimSpace(idStr) == "" || (decision != "confirm" && decision != "revoke") {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     title,
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: reviewDecide --id <item_id> --decision confirm|revoke [--comment <text>]"}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	id, err := uuid.Parse(strings.TrimSpace(idStr))
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     title,
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid ID", Body: []string{"Invalid review item ID format."}}},
+		})
+		return err
+	}
+	var item models.ReviewItem
+	if err := db.Preload("Campaign").Preload("Group").Where("id = ?", id).First(&item).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     title,
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{"Review item not found."}}},
+		})
+		return err
+	}
+
+	if !currentUser.CanDo(db, "reviewDecide", item.Group.Name) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     title,
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"Only owners of the group can review its items."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+	if item.Kind == models.ReviewItemMember && !currentUser.IsAdmin() {
+		var ug models.UserGroup
+		if err := db.Unscoped().Where("id = ?", item.SubjectID).First(&ug).Error; err == nil && ug.UserID == currentUser.ID {
+			console.DisplayBlock(console.ContentBlock{
+				Title:     title,
+				BlockType: "error",
+				Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You cannot review your own membership."}}},
+			})
+			return fmt.Errorf("user %s cannot review their own membership", currentUser.Username)
+		}
+	}
+	if item.Status != models.ReviewItemPending {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     title,
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Already Decided", Body: []string{fmt.Sprintf("This item is already %s.", item.Status)}}},
+		})
+		return errAlreadyDecided
+	}
+
+	status := models.ReviewItemConfirmed
+	if decision == "revoke" {
+		status = models.ReviewItemRevoked
+	}
+	var affected *uuid.UUID
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var campaign models.ReviewCampaign
+		if err := tx.Where("id = ?", item.CampaignID).First(&campaign).Error; err != nil {
+			return err
+		}
+		if campaign.Status != models.ReviewCampaignOpen {
+			return errCampaignClosed
+		}
+		if status == models.ReviewItemRevoked {
+			var rErr error
+			if affected, rErr = models.RevokeReviewSubject(tx, &item); rErr != nil {
+				return rErr
+			}
+		}
+		res := tx.Model(&models.ReviewItem{}).
+			Where("id = ? AND status = ?", item.ID, models.ReviewItemPending).
+			Updates(map[string]any{
+				"status":     status,
+				"decider_id": currentUser.ID,
+				"comment":    strings.TrimSpace(comment),
+				"decided_at": time.Now(),
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errAlreadyDecided
+		}
+		return nil
+	})
+	if err != nil {
+		msg := "Failed to record the decision."
+		switch {
+		case errors.Is(err, errAlreadyDecided):
+			msg = "This item was decided by someone else in the meantime."
+		case errors.Is(err, errCampaignClosed):
+			msg = "The campaign is closed; its items can no longer be decided."
+		case errors.Is(err, models.ErrLastOwner):
+			msg = "Cannot revoke the last owner of the group. Promote another member to owner first."
+		}
+		console.DisplayBlock(console.ContentBlock{
+			Title:     title,
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{msg}}},
+		})
+		return err
+	}
+	if affected != nil {
+		models.InvalidateGroupsCache(*affected)
+	}
+
+	verb := "Confirmed"
+	if status == models.ReviewItemRevoked {
+		verb = "Revoked"
+	}
+	console.DisplayBlock(console.ContentBlock{
+		Title:     title,
+		BlockType: "success",
+		Sections: []console.SectionContent{{SubTitle: verb, Body: []string{
+			fmt.Sprintf("%s %s %s in group '%s'.", verb, item.Kind, item.Summary, item.Group.Name),
+		}}},
+	})
+	return nil
+}
+
+// Report summarises the outcome of a campaign, or lists all campaigns when
+// --campaign is omitted.
+func Report(db *gorm.DB, currentUser *models.User, args []string) error {
+	fs := flag.NewFlagSet("reviewReport", flag.ContinueOnError)
+	var campaignName string
+	fs.StringVar(&campaignName, "campaign", "", "Campaign to report on")
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+
+	if err := fs.Parse(args); err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Review Report",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: reviewReport [--campaign <name>]"}}},
+		})
+		return err
+	}
+	if strings.TrimSpace(campaignName) == "" {
+		return listCampaigns(db)
+	}
+
+	campaign, err := loadCampaign(db, "Review Report", campaignName)
+	if err != nil {
+		return err
+	}
+	var items []models.ReviewItem
+	if err := db.Preload("Group").Preload("Decider").Where("campaign_id = ?", campaign.ID).Find(&items).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Review Report",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Database Error", Body: []string{"Failed to query review items."}}},
+		})
+		return err
+	}
+
+	statuses := []string{models.ReviewItemPending, models.ReviewItemConfirmed, models.ReviewItemRevoked, models.ReviewItemAutoRevoked, models.ReviewItemFlagged}
+	perGroup := make(map[string]map[string]int)
+	totals := make(map[string]int)
+	for _, it := range items {
+		if perGroup[it.Group.Name] == nil {
+			perGroup[it.Group.Name] = make(map[string]int)
+		}
+		perGroup[it.Group.Name][it.Status]++
+		totals[it.Status]++
+	}
+
+	summary := []string{
+		fmt.Sprintf("Status:    %s", campaign.Status),
+		fmt.Sprintf("Deadline:  %s (undecided items %s)", campaign.Deadline.Format("2006-01-02 15:04:05 MST"), expiryLabel(campaign.OnExpiry)),
+		fmt.Sprintf("Started:   %s by %s", campaign.CreatedAt.Format("2006-01-02 15:04:05"), campaign.CreatedBy.Username),
+	}
+	if campaign.ClosedAt != nil {
+		summary = append(summary, fmt.Sprintf("Closed:    %s", campaign.ClosedAt.Format("2006-01-02 15:04:05")))
+	}
+	var counts []string
+	for _, s := range statuses {
+		counts = append(counts, fmt.Sprintf("%s %d", s, totals[s]))
+	}
+	summary = append(summary, fmt.Sprintf("Items:     %d (%s)", len(items), strings.Join(counts, ", ")))
+
+	var groupNames []string
+	for g := range perGroup {
+		groupNames = append(groupNames, g)
+	}
+	sort.Strings(groupNames)
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "Group\tPending\tConfirmed\tRevoked\tAuto-revoked\tFlagged")
+	for _, g := range groupNames {
+		c := perGroup[g]
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\n", g,
+			c[models.ReviewItemPending], c[models.ReviewItemConfirmed], c[models.ReviewItemRevoked],
+			c[models.ReviewItemAutoRevoked], c[models.ReviewItemFlagged])
+	}
+	_ = w.Flush()
+
+	sections := []console.SectionContent{
+		{SubTitle: fmt.Sprintf("Campaign %s", campaign.Name), Body: summary},
+	}
+	if len(groupNames) > 0 {
+		sections = append(sections, console.SectionContent{SubTitle: "Per Group", Body: strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")})
+	}
+
+	var outcomes bytes.Buffer
+	w = tabwriter.NewWriter(&outcomes, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "Group\tKind\tItem\tOutcome\tBy\tAt\tComment")
+	n := 0
+	for _, it := range items {
+		if it.Status == models.ReviewItemPending || it.Status == models.ReviewItemConfirmed {
+			continue
+		}
+		by, at := "sync", "-"
+		if it.Decider != nil {
+			by = it.Decider.Username
+		}
+		if it.DecidedAt != nil {
+			at = it.DecidedAt.Format("2006-01-02 15:04:05")
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", it.Group.Name, it.Kind, it.Summary, it.Status, by, at, it.Comment)
+		n++
+	}
+	_ = w.Flush()
+	if n > 0 {
+		sections = append(sections, console.SectionContent{SubTitle: "Revoked and Flagged", Body: strings.Split(strings.TrimRight(outcomes.String(), "\n"), "\n")})
+	}
+
+	console.DisplayBlock(console.ContentBlock{Title: "Review Report", BlockType: "info", Sections: sections})
+	return nil
+}
+
+// listCampaigns prints every campaign with its progress.
+func listCampaigns(db *gorm.DB) error {
+	var campaigns []models.ReviewCampaign
+	if err := db.Order("created_at desc").Find(&campaigns).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Review Report",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Database Error", Body: []string{"Failed to query review campaigns."}}},
+		})
+		return err
+	}
+	if len(campaigns) == 0 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Review Report",
+			BlockType: "info",
+			Sections:  []console.SectionContent{{SubTitle: "Info", Body: []string{"No review campaigns found."}}},
+		})
+		return nil
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "Name\tStatus\tDeadline\tOn Expiry\tItems\tPending")
+	for _, c := range campaigns {
+		var total, pending int64
+		db.Model(&models.ReviewItem{}).Where("campaign_id = ?", c.ID).Count(&total)
+		db.Model(&models.ReviewItem{}).Where("campaign_id = ? AND status = ?", c.ID, models.ReviewItemPending).Count(&pending)
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\n", c.Name, c.Status, c.Deadline.Format("2006-01-02 15:04"), c.OnExpiry, total, pending)
+	}
+	_ = w.Flush()
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Review Report",
+		BlockType: "info",
+		Sections:  []console.SectionContent{{SubTitle: "Campaigns", Body: strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")}},
+	})
+	return nil
+}
+
+// loadCampaign fetches a campaign by name.
+func loadCampaign(db *gorm.DB, title, name string) (*models.ReviewCampaign, error) {
+	var campaign models.ReviewCampaign
+	if err := db.Preload("CreatedBy").Where("name = ?", strings.TrimSpace(name)).First(&campaign).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     title,
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("Review campaign '%s' not found.", name)}}},
+		})
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+// parseDeadline accepts a date (end of that day, UTC), an RFC 3339 time, a
+// Go duration or a number of days ("14d") from now.
+func parseDeadline(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t.Add(24*time.Hour - time.Second), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return time.Time{}, fmt.Errorf("invalid deadline: %s", s)
+		}
+		return now.AddDate(0, 0, n), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid deadline: %s", s)
+	}
+	return now.Add(d), nil
+}
+
+func expiryLabel(onExpiry string) string {
+	if onExpiry == models.ReviewOnExpiryRevoke {
+		return "revoked"
+	}
+	return "flagged"
+}
+
+func isValidStatusFilter(s string) bool {
+	switch s {
+	case models.ReviewItemPending, models.ReviewItemConfirmed, models.ReviewItemRevoked,
+		models.ReviewItemAutoRevoked, models.ReviewItemFlagged, "all":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package review
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"goBastion/internal/models"
+)
+
+func TestStart_SnapshotsMembersAccessesAndGuests(t *testing.T) {
+	db := newTestDB(t)
+	admin := newUser(t, db, "root", models.RoleAdmin)
+	owner := newUser(t, db, "olga", models.RoleUser)
+	guest := newUser(t, db, "gus", models.RoleUser)
+	g := newGroup(t, db, "infra")
+	newGroup(t, db, "other")
+	addMember(t, db, owner, g, models.GroupRoleOwner)
+	addMember(t, db, guest, g, models.GroupRoleGuest)
+	access := models.GroupAccess{GroupID: g.ID, Username: "deploy", Server: "web1", Port: 22, Protocol: "ssh"}
+	db.Create(&access)
+	grant := models.GroupGuestAccess{GroupID: g.ID, UserID: guest.ID, Username: "deploy", Server: "web1", Port: 22, Protocol: "ssh"}
+	db.Create(&grant)
+
+	if err := Start(db, admin, []string{"--name", "2026-Q4", "--deadline", "14d", "--group", "infra"}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	var items []models.ReviewItem
+	db.Find(&items)
+	if len(items) != 4 {
+		t.Fatalf("expected 2 members, 1 access and 1 guest grant under review, got %+v", items)
+	}
+	if it := itemFor(t, db, models.ReviewItemAccess, access.ID); it.Summary != "deploy@web1:22 (ssh)" || it.Status != models.ReviewItemPending {
+		t.Fatalf("unexpected access item: %+v", it)
+	}
+	itemFor(t, db, models.ReviewItemGuest, grant.ID)
+
+	if err := Start(db, admin, []string{"--name", "2026-Q4", "--deadline", "14d"}); err == nil {
+		t.Fatal("expected a duplicate campaign name to be refused")
+	}
+	if err := Start(db, admin, []string{"--name", "past", "--deadline", "2000-01-01"}); err == nil {
+		t.Fatal("expected a past deadline to be refused")
+	}
+}
+
+func TestDecide_OwnerConfirmsAndRevokes(t *testing.T) {
+	db := newTestDB(t)
+	admin := newUser(t, db, "root", models.RoleAdmin)
+	owner := newUser(t, db, "olga", models.RoleUser)
+	member := newUser(t, db, "mike", models.RoleUser)
+	outsider := newUser(t, db, "eve", models.RoleUser)
+	g := newGroup(t, db, "infra")
+	ownership := addMember(t, db, owner, g, models.GroupRoleOwner)
+	membership := addMember(t, db, member, g, models.GroupRoleMember)
+	access := models.GroupAccess{GroupID: g.ID, Username: "deploy", Server: "web1", Port: 22, Protocol: "ssh"}
+	db.Create(&access)
+
+	if err := Start(db, admin, []string{"--name", "q4", "--deadline", "24h"}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	memberItem := itemFor(t, db, models.ReviewItemMember, membership.ID)
+	accessItem := itemFor(t, db, models.ReviewItemAccess, access.ID)
+	ownerItem := itemFor(t, db, models.ReviewItemMember, ownership.ID)
+
+	if err := Decide(db, outsider, []string{"--id", memberItem.ID.String(), "--decision", "revoke"}); err == nil {
+		t.Fatal("expected a non-owner decision to be refused")
+	}
+	if err := Decide(db, owner, []string{"--id", ownerItem.ID.String(), "--decision", "confirm"}); err == nil {
+		t.Fatal("expected an owner reviewing their own membership to be refused")
+	}
+
+	if err := Decide(db, owner, []string{"--id", accessItem.ID.String(), "--decision", "confirm"}); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	if err := Decide(db, owner, []string{"--id", memberItem.ID.String(), "--decision", "revoke", "--comment", "left the team"}); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if err := db.Where("id = ?", membership.ID).First(&models.UserGroup{}).Error; err == nil {
+		t.Fatal("revoked membership should be deleted")
+	}
+	if err := db.Where("id = ?", access.ID).First(&models.GroupAccess{}).Error; err != nil {
+		t.Fatalf("confirmed access should remain: %v", err)
+	}
+	if got := itemFor(t, db, models.ReviewItemMember, membership.ID); got.Status != models.ReviewItemRevoked || got.DeciderID == nil || *got.DeciderID != owner.ID {
+		t.Fatalf("unexpected revoked item: %+v", got)
+	}
+
+	if err := Decide(db, owner, []string{"--id", accessItem.ID.String(), "--decision", "revoke"}); !errors.Is(err, errAlreadyDecided) {
+		t.Fatalf("expected errAlreadyDecided, got %v", err)
+	}
+}
+
+func TestEnforceReviewDeadlines_RevokesUndecidedItems(t *testing.T) {
+	db := newTestDB(t)
+	admin := newUser(t, db, "root", models.RoleAdmin)
+	owner := newUser(t, db, "olga", models.RoleUser)
+	member := newUser(t, db, "mike", models.RoleUser)
+	g := newGroup(t, db, "infra")
+	ownership := addMember(t, db, owner, g, models.GroupRoleOwner)
+	membership := addMember(t, db, member, g, models.GroupRoleMember)
+	access := models.GroupAccess{GroupID: g.ID, Username: "deploy", Server: "web1", Port: 22, Protocol: "ssh"}
+	db.Create(&access)
+
+	if err := Start(db, admin, []string{"--name", "q4", "--deadline", "1h", "--on-expiry", "revoke"}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	accessItem := itemFor(t, db, models.ReviewItemAccess, access.ID)
+	if err := Decide(db, owner, []string{"--id", itemFor(t, db, models.ReviewItemMember, membership.ID).ID.String(), "--decision", "confirm"}); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+
+	// Not due yet.
+	if revoked, flagged, err := models.EnforceReviewDeadlines(db, time.Now()); err != nil || revoked+flagged != 0 {
+		t.Fatalf("nothing should be enforced before the deadline: %d %d %v", revoked, flagged, err)
+	}
+
+	revoked, flagged, err := models.EnforceReviewDeadlines(db, time.Now().Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("EnforceReviewDeadlines: %v", err)
+	}
+	// The access is revoked; the last owner cannot be and is flagged instead.
+	if revoked != 1 || flagged != 1 {
+		t.Fatalf("expected 1 revoked and 1 flagged, got %d and %d", revoked, flagged)
+	}
+	if err := db.Where("id = ?", access.ID).First(&models.GroupAccess{}).Error; err == nil {
+		t.Fatal("undecided access should have been revoked")
+	}
+	if err := db.Where("id = ?", ownership.ID).First(&models.UserGroup{}).Error; err != nil {
+		t.Fatalf("last owner must be kept: %v", err)
+	}
+	if got := itemFor(t, db, models.ReviewItemMember, ownership.ID); got.Status != models.ReviewItemFlagged {
+		t.Fatalf("expected the owner item to be flagged, got %s", got.Status)
+	}
+	if got := itemFor(t, db, models.ReviewItemMember, membership.ID); got.Status != models.ReviewItemConfirmed {
+		t.Fatalf("decided items are left alone, got %s", got.Status)
+	}
+
+	var campaign models.ReviewCampaign
+	db.First(&campaign)
+	if campaign.Status != models.ReviewCampaignClosed || campaign.ClosedAt == nil {
+		t.Fatalf("campaign should be closed: %+v", campaign)
+	}
+	if err := Decide(db, owner, []string{"--id", accessItem.ID.String(), "--decision", "confirm"}); err == nil {
+		t.Fatal("items of a closed campaign cannot be decided")
+	}
+	if err := Report(db, admin, []string{"--campaign", "q4"}); err != nil {
+		t.Fatalf("Report: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package review
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"goBastion/internal/models"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open test DB: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.User{}, &models.Group{}, &models.UserGroup{},
+		&models.GroupAccess{}, &models.GroupGuestAccess{},
+		&models.ReviewCampaign{}, &models.ReviewItem{},
+		&models.CustomRole{}, &models.CustomRoleAssignment{},
+	); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func newUser(t *testing.T, db *gorm.DB, username, role string) *models.User {
+	t.Helper()
+	u := models.User{Username: username, Role: role, Enabled: true}
+	if err := db.Create(&u).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return &u
+}
+
+func newGroup(t *testing.T, db *gorm.DB, name string) *models.Group {
+	t.Helper()
+	g := models.Group{Name: name}
+	if err := db.Create(&g).Error; err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	return &g
+}
+
+func addMember(t *testing.T, db *gorm.DB, u *models.User, g *models.Group, role string) *models.UserGroup {
+	t.Helper()
+	ug := models.UserGroup{UserID: u.ID, GroupID: g.ID, Role: role}
+	if err := db.Create(&ug).Error; err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	models.InvalidateGroupsCache(u.ID)
+	return &ug
+}
+
+func itemFor(t *testing.T, db *gorm.DB, kind string, subject any) models.ReviewItem {
+	t.Helper()
+	var item models.ReviewItem
+	if err := db.Where("kind = ? AND subject_id = ?", kind, subject).First(&item).Error; err != nil {
+		t.Fatalf("review item %s %v not found: %v", kind, subject, err)
+	}
+	return item
+}
@@ -0,0 +1,116 @@
+package revocation
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/revocation"
+
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+// AddKey revokes an SSH key bastion-wide (admin only), by public key or by
+// SHA256 fingerprint, and lists the accounts and groups that registered it.
+// Usage: revocationAddKey (--key "<public key>" | --fingerprint <SHA256:...>) --reason <text>
+func AddKey(db *gorm.DB, currentUser *models.User, log *slog.Logger, args []string) error {
+	fs := flag.NewFlagSet("revocationAddKey", flag.ContinueOnError)
+	var key, fingerprint, reason string
+	fs.StringVar(&key, "key", "", "Public key to revoke, in authorized_keys format")
+	fs.StringVar(&fingerprint, "fingerprint", "", "SHA256 fingerprint of the key to revoke")
+	fs.StringVar(&reason, "reason", "", "Why the key is revoked")
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	err := fs.Parse(args)
+	key, fingerprint, reason = strings.TrimSpace(key), strings.TrimSpace(fingerprint), strings.TrimSpace(reason)
+	if err != nil || (key == "") == (fingerprint == "") || reason == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Revoke Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{`Usage: revocationAddKey (--key "<public key>" | --fingerprint <SHA256:...>) --reason <text>`}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	if !currentUser.CanDo(db, "revocationAddKey", "") {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Revoke Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You do not have permission to revoke keys."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	revoked := models.RevokedKey{Reason: reason, AddedByID: currentUser.ID}
+	if key != "" {
+		var pub ssh.PublicKey
+		pub, _, _, _, err = ssh.ParseAuthorizedKey([]byte(key))
+		if err == nil {
+			revoked.Fingerprint = revocation.Fingerprint(pub)
+			revoked.PublicKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub)))
+		} else {
+			err = fmt.Errorf("invalid SSH public key: %w", err)
+		}
+	} else {
+		revoked.Fingerprint, err = revocation.NormalizeFingerprint(fingerprint)
+	}
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Revoke Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Key", Body: []string{err.Error()}}},
+		})
+		return err
+	}
+	display := revocation.Display(revoked.Fingerprint)
+
+	var count int64
+	db.Model(&models.RevokedKey{}).Where("fingerprint = ?", revoked.Fingerprint).Count(&count)
+	if count > 0 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Revoke Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Already Revoked", Body: []string{fmt.Sprintf("Key %s is already revoked.", display)}}},
+		})
+		return fmt.Errorf("key %s already revoked", display)
+	}
+
+	if err := db.Create(&revoked).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Revoke Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{fmt.Sprintf("Failed to store revocation: %v", err)}}},
+		})
+		return err
+	}
+	log.Warn("key_revoked", slog.String("user", currentUser.Username), slog.String("fingerprint", display), slog.String("reason", reason))
+
+	holders, err := revocation.Holders(db, revoked.Fingerprint)
+	if err != nil {
+		return err
+	}
+	if len(holders) == 0 {
+		holders = []string{"No account or group has registered this key."}
+	}
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Revoke Key",
+		BlockType: "success",
+		Sections: []console.SectionContent{
+			{SubTitle: "Success", Body: []string{
+				fmt.Sprintf("Key %s revoked.", display),
+				"It can no longer be added, egress connections stop using it and it leaves authorized_keys at the next sync.",
+			}},
+			{SubTitle: "Registered By", Body: holders},
+		},
+	})
+	return nil
+}
@@ -0,0 +1,61 @@
+package revocation
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/revocation"
+
+	"gorm.io/gorm"
+)
+
+// ListKeys lists the revoked SSH keys with their reason (admin only).
+func ListKeys(db *gorm.DB, currentUser *models.User) error {
+	if !currentUser.CanDo(db, "revocationListKeys", "") {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Revoked Keys",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You do not have permission to list revoked keys."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	var keys []models.RevokedKey
+	if err := db.Preload("AddedBy").Order("created_at").Find(&keys).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Revoked Keys",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Failed to query revoked keys."}}},
+		})
+		return err
+	}
+
+	if len(keys) == 0 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Revoked Keys",
+			BlockType: "info",
+			Sections:  []console.SectionContent{{SubTitle: "Info", Body: []string{"No key is revoked."}}},
+		})
+		return nil
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "Fingerprint\tReason\tAdded By\tCreated At")
+	for _, k := range keys {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			revocation.Display(k.Fingerprint), k.Reason, k.AddedBy.Username, k.CreatedAt.Format("2006-01-02 15:04:05"))
+	}
+	_ = w.Flush()
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Revoked Keys",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Keys", Body: strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")}},
+	})
+	return nil
+}
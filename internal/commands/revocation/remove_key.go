@@ -0,0 +1,81 @@
+package revocation
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log/slog"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/revocation"
+
+	"gorm.io/gorm"
+)
+
+// RemoveKey takes a key off the revocation list (admin only). The accounts
+// and groups that registered it can use it again after the next sync.
+// Usage: revocationRemoveKey --fingerprint <SHA256:...>
+func RemoveKey(db *gorm.DB, currentUser *models.User, log *slog.Logger, args []string) error {
+	fs := flag.NewFlagSet("revocationRemoveKey", flag.ContinueOnError)
+	var fingerprint string
+	fs.StringVar(&fingerprint, "fingerprint", "", "SHA256 fingerprint of the revoked key")
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	if err := fs.Parse(args); err != nil || fingerprint == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Remove Revoked Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: revocationRemoveKey --fingerprint <SHA256:...>"}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	if !currentUser.CanDo(db, "revocationRemoveKey", "") {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Remove Revoked Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You do not have permission to remove revoked keys."}}},
+		})
+		return fmt.Errorf("access denied for %s", currentUser.Username)
+	}
+
+	normalized, err := revocation.NormalizeFingerprint(fingerprint)
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Remove Revoked Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Fingerprint", Body: []string{err.Error()}}},
+		})
+		return err
+	}
+	display := revocation.Display(normalized)
+
+	res := db.Where("fingerprint = ?", normalized).Delete(&models.RevokedKey{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Remove Revoked Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("Key %s is not revoked. Run revocationListKeys.", display)}}},
+		})
+		return fmt.Errorf("key %s not revoked", display)
+	}
+	log.Info("key_unrevoked", slog.String("user", currentUser.Username), slog.String("fingerprint", display))
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Remove Revoked Key",
+		BlockType: "success",
+		Sections: []console.SectionContent{{SubTitle: "Success", Body: []string{
+			fmt.Sprintf("Key %s is no longer revoked.", display),
+			"It is accepted again after the next sync.",
+		}}},
+	})
+	return nil
+}
@@ -0,0 +1,111 @@
+package revocation
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+
+	"goBastion/internal/models"
+)
+
+var testLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open test DB: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.User{}, &models.Group{}, &models.IngressKey{}, &models.SelfEgressKey{}, &models.GroupEgressKey{},
+		&models.RevokedKey{}, &models.RestrictedCommandGrant{}, &models.CustomRole{}, &models.CustomRoleAssignment{},
+	); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func newUser(t *testing.T, db *gorm.DB, username, role string) *models.User {
+	t.Helper()
+	u := models.User{Username: username, Role: role, Enabled: true}
+	if err := db.Create(&u).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return &u
+}
+
+func newKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	key, _ := ssh.NewPublicKey(pub)
+	return key
+}
+
+func TestAddListRemoveKey(t *testing.T) {
+	db := newTestDB(t)
+	admin := newUser(t, db, "admin", models.RoleAdmin)
+	key := newKey(t)
+	line := string(ssh.MarshalAuthorizedKey(key))
+
+	if err := AddKey(db, admin, testLog, []string{"--key", line, "--reason", "laptop stolen"}); err != nil {
+		t.Fatalf("AddKey: %v", err)
+	}
+	var revoked models.RevokedKey
+	if err := db.First(&revoked).Error; err != nil {
+		t.Fatalf("revocation not stored: %v", err)
+	}
+	if revoked.Reason != "laptop stolen" || revoked.AddedByID != admin.ID || revoked.PublicKey == "" {
+		t.Fatalf("unexpected revocation %+v", revoked)
+	}
+
+	// The same key cannot be revoked twice, even by fingerprint.
+	if err := AddKey(db, admin, testLog, []string{"--fingerprint", ssh.FingerprintSHA256(key), "--reason", "again"}); err == nil {
+		t.Fatal("expected an error for an already revoked key")
+	}
+	if err := ListKeys(db, admin); err != nil {
+		t.Fatalf("ListKeys: %v", err)
+	}
+
+	if err := RemoveKey(db, admin, testLog, []string{"--fingerprint", ssh.FingerprintSHA256(key)}); err != nil {
+		t.Fatalf("RemoveKey: %v", err)
+	}
+	var count int64
+	db.Model(&models.RevokedKey{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("expected the revocation to be removed, %d left", count)
+	}
+	if err := RemoveKey(db, admin, testLog, []string{"--fingerprint", ssh.FingerprintSHA256(key)}); err == nil {
+		t.Fatal("expected an error for a key that is not revoked")
+	}
+}
+
+func TestAddKey_Refused(t *testing.T) {
+	db := newTestDB(t)
+	admin := newUser(t, db, "admin", models.RoleAdmin)
+	regular := newUser(t, db, "bob", models.RoleUser)
+	fingerprint := ssh.FingerprintSHA256(newKey(t))
+
+	if err := AddKey(db, regular, testLog, []string{"--fingerprint", fingerprint, "--reason", "leak"}); err == nil {
+		t.Fatal("a regular user must not revoke keys")
+	}
+	if err := AddKey(db, admin, testLog, []string{"--fingerprint", fingerprint}); err == nil {
+		t.Fatal("a reason must be required")
+	}
+	if err := AddKey(db, admin, testLog, []string{"--fingerprint", fingerprint, "--key", "ssh-ed25519 AAAA", "--reason", "leak"}); err == nil {
+		t.Fatal("--key and --fingerprint must be exclusive")
+	}
+	if err := AddKey(db, admin, testLog, []string{"--fingerprint", "SHA256:short", "--reason", "leak"}); err == nil {
+		t.Fatal("an invalid fingerprint must be refused")
+	}
+	var count int64
+	db.Model(&models.RevokedKey{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("nothing must be stored, got %d revocations", count)
+	}
+}
@@ -0,0 +1,603 @@
+// Package role implements administration of custom RBAC roles: named sets of
+// registry permissions assigned to accounts on top of the built-in roles.
+package role
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var roleNameRe = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,63}$`)
+
+// builtinRoles describes the hard-coded roles shown by roleList.
+var builtinRoles = [][2]string{
+	{models.RoleAdmin, "Account role: every administrative command"},
+	{"superowner", "Account flag: owner rights on every group"},
+	{models.RoleUser, "Account role: self-service commands"},
+	{models.GroupRoleOwner, "Group role: full group management"},
+	{models.GroupRoleACLKeeper, "Group role: manage members and accesses"},
+	{models.GroupRoleGatekeeper, "Group role: manage accesses and guest grants"},
+	{models.GroupRoleMember, "Group role: connect with the group accesses"},
+	{models.GroupRoleGuest, "Group role: connect with explicit guest grants only"},
+}
+
+var errUnknownPermission = errors.New("unknown or non-delegable permission")
+
+var errNotGroupScopable = errors.New("permission cannot be scoped to a group")
+
+// Create defines a new custom role. known lists the registry permissions.
+func Create(db *gorm.DB, currentUser *models.User, log *slog.Logger, args []string, known []string) error {
+	fs := flag.NewFlagSet("roleCreate", flag.ContinueOnError)
+	var name, permissions, description string
+	fs.StringVar(&name, "name", "", "Role name")
+	fs.StringVar(&permissions, "permissions", "", "Comma-separated registry permissions")
+	fs.StringVar(&description, "description", "", "Optional description")
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+	if err := fs.Parse(args); err != nil || strings.TrimSpace(name) == "" || strings.TrimSpace(permissions) == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Role Create",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: roleCreate --name <role> --permissions <perm1,perm2,...> [--description <text>]"}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	if err := validateRoleName(name); err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Role Create",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Name", Body: []string{err.Error()}}},
+		})
+		return err
+	}
+	perms, err := validatePermissions(permissions, known)
+	if err != nil {
+		return permissionError("Role Create", err)
+	}
+
+	var existing models.CustomRole
+	if err := db.Where("name = ?", name).First(&existing).Error; err == nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Role Create",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Already Exists", Body: []string{fmt.Sprintf("Role '%s' already exists. Use roleModify to change it.", name)}}},
+		})
+		return fmt.Errorf("role %q already exists", name)
+	}
+
+	role := models.CustomRole{Name: name, Description: strings.TrimSpace(description), Permissions: strings.Join(perms, ",")}
+	if err := db.Create(&role).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Role Create",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Failed to create role."}}},
+		})
+		return err
+	}
+	models.InvalidateCustomRolesCache()
+
+	log.Info("role_created", slog.String("admin", currentUser.Username), slog.String("role", name), slog.String("permissions", role.Permissions))
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Role Create",
+		BlockType: "success",
+		Sections: []console.SectionContent{{SubTitle: "Success", Body: []string{
+			fmt.Sprintf("Role '%s' created with %d permission(s).", name, len(perms)),
+			"Assign it with: roleAssign --name " + name + " --user <username> [--group <group>]",
+		}}},
+	})
+	return nil
+}
+
+// Modify changes the permissions or description of a custom role.
+func Modify(db *gorm.DB, currentUser *models.User, log *slog.Logger, args []string, known []string) error {
+	fs := flag.NewFlagSet("roleModify", flag.ContinueOnError)
+	var name, permissions, add, remove, description string
+	fs.StringVar(&name, "name", "", "Role name")
+	fs.StringVar(&permissions, "permissions", "", "Replace the permissions (comma-separated)")
+	fs.StringVar(&add, "add", "", "Permissions to add (comma-separated)")
+	fs.StringVar(&remove, "remove", "", "Permissions to remove (comma-separated)")
+	fs.StringVar(&description, "description", "", "New description")
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+	err := fs.Parse(args)
+	if err != nil || strings.TrimSpace(name) == "" || (permissions == "" && add == "" && remove == "" && description == "") ||
+		(permissions != "" && (add != "" || remove != "")) {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Role Modify",
+			BlockType: "error",
+			Sections: []console.SectionContent{{SubTitle: "Usage", Body: []string{
+				"Usage: roleModify --name <role> [--permissions <list> | --add <list> --remove <list>] [--description <text>]",
+			}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	role, err := loadRole(db, "Role Modify", name)
+	if err != nil {
+		return err
+	}
+
+	perms := role.PermissionList()
+	switch {
+	case permissions != "":
+		if perms, err = validatePermissions(permissions, known); err != nil {
+			return permissionError("Role Modify", err)
+		}
+	case add != "" || remove != "":
+		if add != "" {
+			added, err := validatePermissions(add, known)
+			if err != nil {
+				return permissionError("Role Modify", err)
+			}
+			perms = append(perms, added...)
+		}
+		removed := make(map[string]bool)
+		for _, p := range models.NormalizePermissions(remove) {
+			removed[p] = true
+		}
+		var kept []string
+		for _, p := range perms {
+			if !removed[p] {
+				kept = append(kept, p)
+			}
+		}
+		perms = models.NormalizePermissions(strings.Join(kept, ","))
+	}
+	if len(perms) == 0 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Role Modify",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Permissions", Body: []string{"A role needs at least one permission. Use roleDelete to remove it."}}},
+		})
+		return fmt.Errorf("role %q would have no permissions", role.Name)
+	}
+
+	var scoped int64
+	if err := db.Model(&models.CustomRoleAssignment{}).Where("role_id = ? AND group_id IS NOT NULL", role.ID).Count(&scoped).Error; err != nil {
+		return err
+	}
+	if scoped > 0 {
+		if err := checkGroupScopable(perms); err != nil {
+			return scopeError("Role Modify", err)
+		}
+	}
+
+	updates := map[string]interface{}{"permissions": strings.Join(perms, ",")}
+	if description != "" {
+		updates["description"] = strings.TrimSpace(description)
+	}
+	if err := db.Model(&role).Updates(updates).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Role Modify",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Failed to update role."}}},
+		})
+		return err
+	}
+	models.InvalidateCustomRolesCache()
+
+	log.Info("role_modified", slog.String("admin", currentUser.Username), slog.String("role", role.Name), slog.String("permissions", strings.Join(perms, ",")))
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Role Modify",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Success", Body: []string{fmt.Sprintf("Role '%s' now grants: %s", role.Name, strings.Join(perms, ", "))}}},
+	})
+	return nil
+}
+
+// Delete removes a custom role and all its assignments.
+func Delete(db *gorm.DB, currentUser *models.User, log *slog.Logger, args []string) error {
+	fs := flag.NewFlagSet("roleDelete", flag.ContinueOnError)
+	var name string
+	fs.StringVar(&name, "name", "", "Role name")
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+	if err := fs.Parse(args); err != nil || strings.TrimSpace(name) == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Role Delete",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: roleDelete --name <role>"}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	role, err := loadRole(db, "Role Delete", name)
+	if err != nil {
+		return err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_id = ?", role.ID).Delete(&models.CustomRoleAssignment{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&role).Error
+	})
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Role Delete",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Failed to delete role."}}},
+		})
+		return err
+	}
+	models.InvalidateCustomRolesCache()
+
+	log.Info("role_deleted", slog.String("admin", currentUser.Username), slog.String("role", role.Name))
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Role Delete",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Success", Body: []string{fmt.Sprintf("Role '%s' and its assignments deleted.", role.Name)}}},
+	})
+	return nil
+}
+
+// List shows the built-in roles and the custom roles, or the details and
+// assignments of one custom role with --name.
+func List(db *gorm.DB, currentUser *models.User, args []string) error {
+	fs := flag.NewFlagSet("roleList", flag.ContinueOnError)
+	var name string
+	fs.StringVar(&name, "name", "", "Show one custom role with its assignments")
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+	if err := fs.Parse(args); err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Role List",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: roleList [--name <role>]"}}},
+		})
+		return err
+	}
+
+	if strings.TrimSpace(name) != "" {
+		return showRole(db, name)
+	}
+
+	var roles []models.CustomRole
+	if err := db.Order("name asc").Find(&roles).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Role List",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Failed to query roles."}}},
+		})
+		return err
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "Role\tType\tAssignments\tPermissions / Description")
+	for _, b := range builtinRoles {
+		_, _ = fmt.Fprintf(w, "%s\tbuilt-in\t-\t%s\n", b[0], b[1])
+	}
+	for _, r := range roles {
+		var count int64
+		db.Model(&models.CustomRoleAssignment{}).Where("role_id = ?", r.ID).Count(&count)
+		_, _ = fmt.Fprintf(w, "%s\tcustom\t%d\t%s\n", r.Name, count, strings.Join(r.PermissionList(), ", "))
+	}
+	_ = w.Flush()
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Role List",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Roles", Body: strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")}},
+	})
+	return nil
+}
+
+// showRole displays one custom role and its assignments.
+func showRole(db *gorm.DB, name string) error {
+	role, err := loadRole(db, "Role List", name)
+	if err != nil {
+		return err
+	}
+	var assignments []models.CustomRoleAssignment
+	if err := db.Preload("User").Preload("Group").Where("role_id = ?", role.ID).Order("created_at asc").Find(&assignments).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Role List",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Failed to query role assignments."}}},
+		})
+		return err
+	}
+
+	details := []string{
+		fmt.Sprintf("Name: %s", role.Name),
+		fmt.Sprintf("Description: %s", role.Description),
+		"Permissions:",
+	}
+	for _, p := range role.PermissionList() {
+		details = append(details, " - "+p)
+	}
+	sections := []console.SectionContent{{SubTitle: "Details", Body: details}}
+
+	if len(assignments) > 0 {
+		var buf bytes.Buffer
+		w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "User\tScope\tAssigned At")
+		for _, a := range assignments {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", a.User.Username, scopeLabel(a), a.CreatedAt.Format("2006-01-02 15:04:05"))
+		}
+		_ = w.Flush()
+		sections = append(sections, console.SectionContent{SubTitle: "Assignments", Body: strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")})
+	} else {
+		sections = append(sections, console.SectionContent{SubTitle: "Assignments", Body: []string{"None"}})
+	}
+
+	console.DisplayBlock(console.ContentBlock{Title: "Role List", BlockType: "success", Sections: sections})
+	return nil
+}
+
+// Assign gives a custom role to an account, optionally scoped to one group.
+func Assign(db *gorm.DB, currentUser *models.User, log *slog.Logger, args []string) error {
+	fs := flag.NewFlagSet("roleAssign", flag.ContinueOnError)
+	var name, username, groupName string
+	fs.StringVar(&name, "name", "", "Role name")
+	fs.StringVar(&username, "user", "", "Target username")
+	fs.StringVar(&groupName, "group", "", "Optional group scope")
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+	if err := fs.Parse(args); err != nil || strings.TrimSpace(name) == "" || strings.TrimSpace(username) == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Role Assign",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: roleAssign --name <role> --user <username> [--group <group>]"}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	role, user, groupID, err := loadAssignmentTarget(db, "Role Assign", name, username, groupName)
+	if err != nil {
+		return err
+	}
+
+	if groupID != nil {
+		if err := checkGroupScopable(role.PermissionList()); err != nil {
+			return scopeError("Role Assign", err)
+		}
+	}
+
+	if _, err := findAssignment(db, role.ID, user.ID, groupID); err == nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Role Assign",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Already Exists", Body: []string{fmt.Sprintf("User '%s' already has role '%s' for this scope.", user.Username, role.Name)}}},
+		})
+		return fmt.Errorf("role %q is already assigned to %q", role.Name, user.Username)
+	}
+
+	assignment := models.CustomRoleAssignment{RoleID: role.ID, UserID: user.ID, GroupID: groupID, GrantedByID: currentUser.ID}
+	if err := db.Create(&assignment).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Role Assign",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Failed to assign role."}}},
+		})
+		return err
+	}
+	models.InvalidateCustomRolesCache()
+
+	scope := "all targets"
+	if groupID != nil {
+		scope = "group " + groupName
+	}
+	log.Info("role_assigned", slog.String("admin", currentUser.Username), slog.String("role", role.Name),
+		slog.String("user", user.Username), slog.String("scope", scope))
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Role Assign",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Success", Body: []string{fmt.Sprintf("Role '%s' assigned to '%s' (%s).", role.Name, user.Username, scope)}}},
+	})
+	return nil
+}
+
+// Unassign removes a custom role assignment.
+func Unassign(db *gorm.DB, currentUser *models.User, log *slog.Logger, args []string) error {
+	fs := flag.NewFlagSet("roleUnassign", flag.ContinueOnError)
+	var name, username, groupName string
+	fs.StringVar(&name, "name", "", "Role name")
+	fs.StringVar(&username, "user", "", "Target username")
+	fs.StringVar(&groupName, "group", "", "Group scope of the assignment")
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+	if err := fs.Parse(args); err != nil || strings.TrimSpace(name) == "" || strings.TrimSpace(username) == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Role Unassign",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: roleUnassign --name <role> --user <username> [--group <group>]"}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	role, user, groupID, err := loadAssignmentTarget(db, "Role Unassign", name, username, groupName)
+	if err != nil {
+		return err
+	}
+
+	assignment, err := findAssignment(db, role.ID, user.ID, groupID)
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Role Unassign",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("User '%s' does not have role '%s' for this scope.", user.Username, role.Name)}}},
+		})
+		return err
+	}
+	if err := db.Delete(&assignment).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Role Unassign",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Failed to remove role assignment."}}},
+		})
+		return err
+	}
+	models.InvalidateCustomRolesCache()
+
+	log.Info("role_unassigned", slog.String("admin", currentUser.Username), slog.String("role", role.Name),
+		slog.String("user", user.Username), slog.String("group", groupName))
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Role Unassign",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Success", Body: []string{fmt.Sprintf("Role '%s' removed from '%s'.", role.Name, user.Username)}}},
+	})
+	return nil
+}
+
+// validateRoleName checks the role name format and refuses built-in names.
+func validateRoleName(name string) error {
+	if !roleNameRe.MatchString(name) {
+		return fmt.Errorf("role name must start with a letter and contain only a-z, 0-9, '-' or '_' (max 64)")
+	}
+	for _, builtin := range models.BuiltinRoleNames {
+		if name == builtin {
+			return fmt.Errorf("'%s' is a built-in role name", name)
+		}
+	}
+	return nil
+}
+
+// validatePermissions normalises list and checks every entry is a known,
+// delegable registry permission.
+func validatePermissions(list string, known []string) ([]string, error) {
+	knownSet := make(map[string]bool, len(known))
+	for _, k := range known {
+		knownSet[k] = true
+	}
+	perms := models.NormalizePermissions(list)
+	if len(perms) == 0 {
+		return nil, fmt.Errorf("%w: empty permission list", errUnknownPermission)
+	}
+	for _, p := range perms {
+		if !knownSet[p] || !models.IsDelegableRight(p) {
+			return nil, fmt.Errorf("%w: %s", errUnknownPermission, p)
+		}
+	}
+	return perms, nil
+}
+
+func permissionError(title string, err error) error {
+	console.DisplayBlock(console.ContentBlock{
+		Title:     title,
+		BlockType: "error",
+		Sections: []console.SectionContent{{SubTitle: "Invalid Permissions", Body: []string{
+			err.Error(),
+			"Use the permission names shown by help; role and grant management cannot be delegated.",
+		}}},
+	})
+	return err
+}
+
+// checkGroupScopable checks that every permission is checked against a group,
+// so that a group scope actually restricts it.
+func checkGroupScopable(perms []string) error {
+	for _, p := range perms {
+		if !models.IsGroupTargetedRight(p) {
+			return fmt.Errorf("%w: %s", errNotGroupScopable, p)
+		}
+	}
+	return nil
+}
+
+func scopeError(title string, err error) error {
+	console.DisplayBlock(console.ContentBlock{
+		Title:     title,
+		BlockType: "error",
+		Sections: []console.SectionContent{{SubTitle: "Invalid Scope", Body: []string{
+			err.Error(),
+			"A role assigned with --group may only hold permissions checked against a group (group commands, accessRequestApprove, accessRequestDeny, reviewDecide, hostTag).",
+		}}},
+	})
+	return err
+}
+
+func loadRole(db *gorm.DB, title, name string) (models.CustomRole, error) {
+	var role models.CustomRole
+	if err := db.Where("name = ?", strings.ToLower(strings.TrimSpace(name))).First(&role).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     title,
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("Role '%s' not found. Run roleList.", name)}}},
+		})
+		return models.CustomRole{}, err
+	}
+	return role, nil
+}
+
+// loadAssignmentTarget resolves the role, user and optional group of an assignment.
+func loadAssignmentTarget(db *gorm.DB, title, name, username, groupName string) (models.CustomRole, models.User, *uuid.UUID, error) {
+	role, err := loadRole(db, title, name)
+	if err != nil {
+		return models.CustomRole{}, models.User{}, nil, err
+	}
+	var user models.User
+	if err := db.Where("username = ?", strings.ToLower(strings.TrimSpace(username))).First(&user).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     title,
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("User '%s' not found.", username)}}},
+		})
+		return models.CustomRole{}, models.User{}, nil, err
+	}
+	if strings.TrimSpace(groupName) == "" {
+		return role, user, nil, nil
+	}
+	var group models.Group
+	if err := db.Where("name = ?", strings.TrimSpace(groupName)).First(&group).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     title,
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("Group '%s' not found. Check spelling or run groupList.", groupName)}}},
+		})
+		return models.CustomRole{}, models.User{}, nil, err
+	}
+	return role, user, &group.ID, nil
+}
+
+func findAssignment(db *gorm.DB, roleID, userID uuid.UUID, groupID *uuid.UUID) (models.CustomRoleAssignment, error) {
+	var a models.CustomRoleAssignment
+	q := db.Where("role_id = ? AND user_id = ?", roleID, userID)
+	if groupID == nil {
+		q = q.Where("group_id IS NULL")
+	} else {
+		q = q.Where("group_id = ?", *groupID)
+	}
+	err := q.First(&a).Error
+	return a, err
+}
+
+func scopeLabel(a models.CustomRoleAssignment) string {
+	if a.GroupID == nil {
+		return "all targets"
+	}
+	if a.Group == nil {
+		return "group (deleted)"
+	}
+	return "group " + a.Group.Name
+}
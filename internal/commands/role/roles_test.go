@@ -0,0 +1,182 @@
+package role
+
+import (
+	"errors"
+	"testing"
+
+	"goBastion/internal/models"
+)
+
+func TestHelpdeskRole_GrantsOnlyListedRights(t *testing.T) {
+	db := newTestDB(t)
+	admin := newUser(t, db, "admin", models.RoleAdmin)
+	helper := newUser(t, db, "helper", models.RoleUser)
+
+	if helper.CanDo(db, "accountUnexpire", "bob") {
+		t.Fatal("expected accountUnexpire to be admin-only without a custom role")
+	}
+	if err := Create(db, admin, discardLogger(), []string{"--name", "helpdesk", "--permissions", "accountUnexpire, accountDisableTOTP"}, knownPermissions); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := Assign(db, admin, discardLogger(), []string{"--name", "helpdesk", "--user", "helper"}); err != nil {
+		t.Fatalf("Assign: %v", err)
+	}
+
+	for _, right := range []string{"accountUnexpire", "accountDisableTOTP"} {
+		if !helper.CanDo(db, right, "bob") {
+			t.Fatalf("expected helpdesk role to grant %s", right)
+		}
+	}
+	if helper.CanDo(db, "accountDelete", "bob") {
+		t.Fatal("expected helpdesk role not to grant accountDelete")
+	}
+
+	if err := Unassign(db, admin, discardLogger(), []string{"--name", "helpdesk", "--user", "helper"}); err != nil {
+		t.Fatalf("Unassign: %v", err)
+	}
+	if helper.CanDo(db, "accountUnexpire", "bob") {
+		t.Fatal("expected unassigned role to stop granting accountUnexpire")
+	}
+}
+
+func TestGroupScopedRole_OnlyAppliesToItsGroup(t *testing.T) {
+	db := newTestDB(t)
+	admin := newUser(t, db, "admin", models.RoleAdmin)
+	alice := newUser(t, db, "alice", models.RoleUser)
+	for _, name := range []string{"infra", "web"} {
+		if err := db.Create(&models.Group{Name: name}).Error; err != nil {
+			t.Fatalf("create group: %v", err)
+		}
+	}
+
+	if err := Create(db, admin, discardLogger(), []string{"--name", "infra-operator", "--permissions", "groupAddAccess"}, knownPermissions); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := Assign(db, admin, discardLogger(), []string{"--name", "infra-operator", "--user", "alice", "--group", "infra"}); err != nil {
+		t.Fatalf("Assign: %v", err)
+	}
+	if !alice.CanDo(db, "groupAddAccess", "infra") {
+		t.Fatal("expected scoped role to apply to its group")
+	}
+	if alice.CanDo(db, "groupAddAccess", "web") {
+		t.Fatal("expected scoped role not to apply to another group")
+	}
+
+	if err := Delete(db, admin, discardLogger(), []string{"--name", "infra-operator"}); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if alice.CanDo(db, "groupAddAccess", "infra") {
+		t.Fatal("expected deleted role to stop granting rights")
+	}
+}
+
+func TestGroupScopedRole_DoesNotLeakOutsideItsGroup(t *testing.T) {
+	db := newTestDB(t)
+	admin := newUser(t, db, "admin", models.RoleAdmin)
+	alice := newUser(t, db, "alice", models.RoleUser)
+	infra := models.Group{Name: "infra"}
+	if err := db.Create(&infra).Error; err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+
+	if err := Create(db, admin, discardLogger(), []string{"--name", "infra-operator", "--permissions", "groupAddAccess"}, knownPermissions); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := Assign(db, admin, discardLogger(), []string{"--name", "infra-operator", "--user", "alice", "--group", "infra"}); err != nil {
+		t.Fatalf("Assign: %v", err)
+	}
+	if alice.CanDo(db, "groupAddAccess", "") {
+		t.Fatal("a group-scoped role must not apply to an empty target")
+	}
+	if !alice.CanUse(db, "groupAddAccess") {
+		t.Fatal("a group-scoped role must make its commands available")
+	}
+
+	if err := Create(db, admin, discardLogger(), []string{"--name", "helpdesk", "--permissions", "accountUnexpire"}, knownPermissions); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := Assign(db, admin, discardLogger(), []string{"--name", "helpdesk", "--user", "alice", "--group", "infra"}); !errors.Is(err, errNotGroupScopable) {
+		t.Fatalf("Assign(--group) of an account role = %v, want errNotGroupScopable", err)
+	}
+	if err := Modify(db, admin, discardLogger(), []string{"--name", "infra-operator", "--add", "accountUnexpire"}, knownPermissions); !errors.Is(err, errNotGroupScopable) {
+		t.Fatalf("Modify of a group-scoped role = %v, want errNotGroupScopable", err)
+	}
+
+	// An assignment stored before the check still grants nothing outside the group.
+	var helpdesk models.CustomRole
+	db.Where("name = ?", "helpdesk").First(&helpdesk)
+	db.Create(&models.CustomRoleAssignment{RoleID: helpdesk.ID, UserID: alice.ID, GroupID: &infra.ID, GrantedByID: admin.ID})
+	models.InvalidateCustomRolesCache()
+	for _, target := range []string{"", "infra"} {
+		if alice.CanDo(db, "accountUnexpire", target) {
+			t.Fatalf("a group-scoped account right must not apply to target %q", target)
+		}
+	}
+	if alice.CanUse(db, "accountUnexpire") {
+		t.Fatal("a group-scoped account right must not make its command available")
+	}
+}
+
+func TestCreate_RejectsInvalidDefinitions(t *testing.T) {
+	db := newTestDB(t)
+	admin := newUser(t, db, "admin", models.RoleAdmin)
+
+	for _, perms := range []string{
+		"roleAssign", "accountModify", "stateApply", "bastionConfig",
+		"accountCreate", "accountAddIngressKey", "accountAddAccess",
+		"ingressAddTrustedCA", "ingressRemoveTrustedCA", "bastionRotateUserCA", "bastionRotateHostCA",
+		"revocationAddKey", "revocationRemoveKey",
+		"noSuchCommand", " , ",
+	} {
+		err := Create(db, admin, discardLogger(), []string{"--name", "bad", "--permissions", perms}, knownPermissions)
+		if !errors.Is(err, errUnknownPermission) {
+			t.Fatalf("Create(--permissions %q) = %v, want errUnknownPermission", perms, err)
+		}
+	}
+	if err := Create(db, admin, discardLogger(), []string{"--name", "admin", "--permissions", "groupInfo"}, knownPermissions); err == nil {
+		t.Fatal("expected a built-in role name to be refused")
+	}
+}
+
+func TestModify_AddAndRemovePermissions(t *testing.T) {
+	db := newTestDB(t)
+	admin := newUser(t, db, "admin", models.RoleAdmin)
+
+	if err := Create(db, admin, discardLogger(), []string{"--name", "support", "--permissions", "accountUnexpire"}, knownPermissions); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := Modify(db, admin, discardLogger(), []string{"--name", "support", "--add", "groupInfo", "--remove", "accountUnexpire"}, knownPermissions); err != nil {
+		t.Fatalf("Modify: %v", err)
+	}
+	var role models.CustomRole
+	if err := db.Where("name = ?", "support").First(&role).Error; err != nil {
+		t.Fatalf("load role: %v", err)
+	}
+	if role.Permissions != "groupInfo" {
+		t.Fatalf("unexpected permissions %q", role.Permissions)
+	}
+	if err := Modify(db, admin, discardLogger(), []string{"--name", "support", "--remove", "groupInfo"}, knownPermissions); err == nil {
+		t.Fatal("expected removing the last permission to be refused")
+	}
+}
+
+func TestRestrictedRights_StillNeedKillSwitch(t *testing.T) {
+	db := newTestDB(t)
+	admin := newUser(t, db, "admin", models.RoleAdmin)
+	ops := newUser(t, db, "ops", models.RoleUser)
+
+	if err := Create(db, admin, discardLogger(), []string{"--name", "realm-ops", "--permissions", "realmCreate"}, knownPermissions); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := Assign(db, admin, discardLogger(), []string{"--name", "realm-ops", "--user", "ops"}); err != nil {
+		t.Fatalf("Assign: %v", err)
+	}
+	if !ops.CanDo(db, "realmCreate", "") {
+		t.Fatal("expected custom role to grant realmCreate")
+	}
+	models.SetRestrictedCmdsEnabled(false)
+	t.Cleanup(func() { models.SetRestrictedCmdsEnabled(true) })
+	if ops.CanDo(db, "realmCreate", "") {
+		t.Fatal("expected restricted commands kill-switch to override custom roles")
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"goBastion/internal/config"
 	"goBastion/internal/models"
 	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/hostmatch"
+	"goBastion/internal/utils/schedule"
 	"goBastion/internal/utils/validation"
 
 	"gorm.io/gorm"
@@ -21,16 +23,19 @@ import (
 func AddAccess(db *gorm.DB, user *models.User, args []string) error {
 
 	fs := flag.NewFlagSet("selfAddAccess", flag.ContinueOnError)
-	var server, username, comment, allowedFrom, protocol string
+	var server, username, comment, allowedFrom, scheduleSpec, protocol string
 	var port int64
 	var ttlDays int
+	var starts string
 	var force bool
 	fs.StringVar(&server, "server", "", "Server name")
 	fs.StringVar(&username, "username", "", "SSH username")
 	fs.Int64Var(&port, "port", 22, "Port number")
 	fs.StringVar(&comment, "comment", "", "Comment")
 	fs.StringVar(&allowedFrom, "from", "", "Allowed source CIDRs (comma-separated, e.g. 10.0.0.0/8,192.168.1.0/24)")
+	fs.StringVar(&scheduleSpec, "schedule", "", "Access window: <days> <HH:MM-HH:MM> [timezone], e.g. \"Mon-Fri 08:00-19:00 Europe/Paris\"")
 	fs.IntVar(&ttlDays, "ttl", 0, "Access expiry in days (0 = never, must be positive if set)")
+	fs.StringVar(&starts, "starts", "", "Access start time (RFC 3339, \"YYYY-MM-DD HH:MM\" or YYYY-MM-DD); pending until then")
 	fs.StringVar(&protocol, "protocol", "ssh", "Protocol restriction: ssh (all), scpupload, scpdownload, sftp, rsync")
 	fs.BoolVar(&force, "force", false, "Skip TCP connectivity check")
 	if err := fs.Parse(args); err != nil {
@@ -38,7 +43,7 @@ func AddAccess(db *gorm.DB, user *models.User, args []string) error {
 			Title:     "Add Personal Access",
 			BlockType: "error",
 			Sections: []console.SectionContent{
-				{SubTitle: "Usage Error", Body: []string{"Usage: selfAddAccess --server <server> --username <username> --port <port> [--comment <comment>] [--from <CIDRs>] [--ttl <days>] [--protocol ssh|scpupload|scpdownload|sftp|rsync]"}},
+				{SubTitle: "Usage Error", Body: []string{"Usage: selfAddAccess --server <server> --username <username> --port <port> [--comment <comment>] [--from <CIDRs>] [--schedule <spec>] [--ttl <days>] [--starts <time>] [--protocol ssh|scpupload|scpdownload|sftp|rsync]"}},
 			},
 		})
 		return err
@@ -48,7 +53,7 @@ func AddAccess(db *gorm.DB, user *models.User, args []string) error {
 			Title:     "Add Personal Access",
 			BlockType: "error",
 			Sections: []console.SectionContent{
-				{SubTitle: "Usage", Body: []string{"selfAddAccess --server <server> --username <username> --port <port> [--comment <comment>] [--from <CIDRs>] [--ttl <days>] [--protocol ssh|scpupload|scpdownload|sftp|rsync] [--force]"}},
+				{SubTitle: "Usage", Body: []string{"selfAddAccess --server <server> --username <username> --port <port> [--comment <comment>] [--from <CIDRs>] [--schedule <spec>] [--ttl <days>] [--starts <time>] [--protocol ssh|scpupload|scpdownload|sftp|rsync] [--force]"}},
 			},
 		})
 		return fmt.Errorf("missing required arguments")
@@ -71,11 +76,11 @@ func AddAccess(db *gorm.DB, user *models.User, args []string) error {
 		return fmt.Errorf("invalid TTL: %d", ttlDays)
 	}
 	// Validate server host
-	if !validation.IsValidHost(server) {
+	if !validation.IsValidServerPattern(server) {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Add Personal Access",
 			BlockType: "error",
-			Sections:  []console.SectionContent{{SubTitle: "Invalid Server", Body: []string{"Server hostname/IP contains invalid characters (e.g., '@')."}}},
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Server", Body: []string{"Server must be a hostname, IP, CIDR block (10.20.0.0/16) or hostname glob (*.db.example)."}}},
 		})
 		return fmt.Errorf("invalid server: %s", server)
 	}
@@ -97,12 +102,34 @@ func AddAccess(db *gorm.DB, user *models.User, args []string) error {
 		})
 		return fmt.Errorf("invalid CIDRs: %s", allowedFrom)
 	}
+	startsAt, err := validation.ParseStartsAt(starts, time.Now())
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Personal Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Start", Body: []string{err.Error()}}},
+		})
+		return fmt.Errorf("invalid start time: %w", err)
+	}
+	if scheduleSpec != "" {
+		sched, err := schedule.Parse(scheduleSpec)
+		if err != nil {
+			console.DisplayBlock(console.ContentBlock{
+				Title:     "Add Personal Access",
+				BlockType: "error",
+				Sections:  []console.SectionContent{{SubTitle: "Invalid Schedule", Body: []string{err.Error(), "Expected <days> <HH:MM-HH:MM> [timezone], e.g. \"Mon-Fri 08:00-19:00 Europe/Paris\""}}},
+			})
+			return fmt.Errorf("invalid schedule: %w", err)
+		}
+		scheduleSpec = sched.String()
+	}
 
 	// Check TCP connectivity to server:port with 5s timeout (skip if --force).
 	// A failed connectivity check is a warning only — it must not block access creation.
 	// Network reachability can change after the access entry is saved.
 	// Restrict active checks to private/reserved targets to avoid scanner-like behavior.
-	if !force {
+	// CIDR and glob patterns name no single target, so they are never probed.
+	if !force && !hostmatch.IsPattern(server) {
 		addr := net.JoinHostPort(server, strconv.FormatInt(port, 10))
 		shouldCheck := validation.IsPrivateOrReservedTarget(server)
 		if shouldCheck {
@@ -166,10 +193,17 @@ func AddAccess(db *gorm.DB, user *models.User, args []string) error {
 		Port:        port,
 		Comment:     comment,
 		AllowedFrom: allowedFrom,
+		Schedule:    scheduleSpec,
+		StartsAt:    startsAt,
 		Protocol:    protocol,
 	}
 	if ttlDays > 0 {
-		t := time.Now().AddDate(0, 0, ttlDays)
+		// The TTL of a scheduled access runs from its start.
+		from := time.Now()
+		if startsAt != nil {
+			from = *startsAt
+		}
+		t := from.AddDate(0, 0, ttlDays)
 		access.ExpiresAt = &t
 	}
 	if err := db.Create(&access).Error; err != nil {
@@ -10,6 +10,7 @@ import (
 	"goBastion/internal/models"
 	"goBastion/internal/utils/console"
 	"goBastion/internal/utils/cryptokey"
+	"goBastion/internal/utils/schedule"
 	"goBastion/internal/utils/validation"
 
 	"gorm.io/gorm"
@@ -19,9 +20,10 @@ import (
 func AddDBAccess(db *gorm.DB, user *models.User, args []string) error {
 
 	fs := flag.NewFlagSet("selfAddDBAccess", flag.ContinueOnError)
-	var host, username, comment, allowedFrom, protocol, password, database string
+	var host, username, comment, allowedFrom, scheduleSpec, protocol, password, database string
 	var port int64
 	var ttlDays int
+	var starts string
 	fs.StringVar(&host, "host", "", "Database host")
 	fs.Int64Var(&port, "port", 0, "Port number")
 	fs.StringVar(&protocol, "protocol", "", "Protocol: mysql, postgres, redis")
@@ -30,13 +32,15 @@ func AddDBAccess(db *gorm.DB, user *models.User, args []string) error {
 	fs.StringVar(&database, "database", "", "Specific database name (optional)")
 	fs.StringVar(&comment, "comment", "", "Comment")
 	fs.StringVar(&allowedFrom, "from", "", "Allowed source CIDRs (comma-separated, e.g. 10.0.0.0/8,192.168.1.0/24)")
+	fs.StringVar(&scheduleSpec, "schedule", "", "Access window: <days> <HH:MM-HH:MM> [timezone], e.g. \"Mon-Fri 08:00-19:00 Europe/Paris\"")
 	fs.IntVar(&ttlDays, "ttl", 0, "Access expiry in days (0 = never, must be positive if set)")
+	fs.StringVar(&starts, "starts", "", "Access start time (RFC 3339, \"YYYY-MM-DD HH:MM\" or YYYY-MM-DD); pending until then")
 	if err := fs.Parse(args); err != nil {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Add Personal DB Access",
 			BlockType: "error",
 			Sections: []console.SectionContent{
-				{SubTitle: "Usage Error", Body: []string{"Usage: selfAddDBAccess --host <host> --user <username> --protocol <mysql|postgres|redis> [--port <port>] [--password <password>] [--database <database>] [--comment <comment>] [--from <CIDRs>] [--ttl <days>]"}},
+				{SubTitle: "Usage Error", Body: []string{"Usage: selfAddDBAccess --host <host> --user <username> --protocol <mysql|postgres|redis> [--port <port>] [--password <password>] [--database <database>] [--comment <comment>] [--from <CIDRs>] [--schedule <spec>] [--ttl <days>] [--starts <time>]"}},
 			},
 		})
 		return err
@@ -46,7 +50,7 @@ func AddDBAccess(db *gorm.DB, user *models.User, args []string) error {
 			Title:     "Add Personal DB Access",
 			BlockType: "error",
 			Sections: []console.SectionContent{
-				{SubTitle: "Usage", Body: []string{"selfAddDBAccess --host <host> --user <username> --protocol <mysql|postgres|redis> [--port <port>] [--password <password>] [--database <database>] [--comment <comment>] [--from <CIDRs>] [--ttl <days>]"}},
+				{SubTitle: "Usage", Body: []string{"selfAddDBAccess --host <host> --user <username> --protocol <mysql|postgres|redis> [--port <port>] [--password <password>] [--database <database>] [--comment <comment>] [--from <CIDRs>] [--schedule <spec>] [--ttl <days>] [--starts <time>]"}},
 			},
 		})
 		return fmt.Errorf("missing required arguments")
@@ -99,6 +103,27 @@ func AddDBAccess(db *gorm.DB, user *models.User, args []string) error {
 		})
 		return fmt.Errorf("invalid CIDRs: %s", allowedFrom)
 	}
+	startsAt, err := validation.ParseStartsAt(starts, time.Now())
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add Personal DB Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Start", Body: []string{err.Error()}}},
+		})
+		return fmt.Errorf("invalid start time: %w", err)
+	}
+	if scheduleSpec != "" {
+		sched, err := schedule.Parse(scheduleSpec)
+		if err != nil {
+			console.DisplayBlock(console.ContentBlock{
+				Title:     "Add Personal DB Access",
+				BlockType: "error",
+				Sections:  []console.SectionContent{{SubTitle: "Invalid Schedule", Body: []string{err.Error(), "Expected <days> <HH:MM-HH:MM> [timezone], e.g. \"Mon-Fri 08:00-19:00 Europe/Paris\""}}},
+			})
+			return fmt.Errorf("invalid schedule: %w", err)
+		}
+		scheduleSpec = sched.String()
+	}
 
 	// Encrypt password if provided
 	var encryptedPassword string
@@ -146,9 +171,16 @@ func AddDBAccess(db *gorm.DB, user *models.User, args []string) error {
 		Database:    database,
 		Comment:     comment,
 		AllowedFrom: allowedFrom,
+		Schedule:    scheduleSpec,
+		StartsAt:    startsAt,
 	}
 	if ttlDays > 0 {
-		t := time.Now().AddDate(0, 0, ttlDays)
+		// The TTL of a scheduled access runs from its start.
+		from := time.Now()
+		if startsAt != nil {
+			from = *startsAt
+		}
+		t := from.AddDate(0, 0, ttlDays)
 		access.ExpiresAt = &t
 	}
 	if err := db.Create(&access).Error; err != nil {
@@ -22,15 +22,14 @@ import (
 func AddIngressKey(db *gorm.DB, user *models.User, args []string) error {
 	fs := flag.NewFlagSet("selfAddIngressKey", flag.ContinueOnError)
 	var pubKey string
-	var expiresDays int
 	fs.StringVar(&pubKey, "key", "", "SSH public key")
-	fs.IntVar(&expiresDays, "expires", 0, "Key expiry in days (0 = never)")
+	keyFlags := account.RegisterIngressKeyFlags(fs)
 	if err := fs.Parse(args); err != nil {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Add Ingress Key",
 			BlockType: "error",
 			Sections: []console.SectionContent{
-				{SubTitle: "Usage Error", Body: []string{"Error parsing flags. Usage: selfAddIngressKey --key <ssh_public_key> [--expires <days>]"}},
+				{SubTitle: "Usage Error", Body: []string{"Error parsing flags. Usage: selfAddIngressKey --key <ssh_public_key> " + account.IngressKeyFlagsUsage}},
 			},
 		})
 		return err
@@ -42,7 +41,7 @@ func AddIngressKey(db *gorm.DB, user *models.User, args []string) error {
 			Title:     "Add Ingress Key",
 			BlockType: "error",
 			Sections: []console.SectionContent{
-				{SubTitle: "Usage", Body: []string{"selfAddIngressKey --key <ssh_public_key> [--expires <days>]"}},
+				{SubTitle: "Usage", Body: []string{"selfAddIngressKey --key <ssh_public_key> " + account.IngressKeyFlagsUsage}},
 			},
 		})
 		return nil
@@ -59,34 +58,21 @@ func AddIngressKey(db *gorm.DB, user *models.User, args []string) error {
 		return fmt.Errorf("invalid ssh key: %w", err)
 	}
 
-	if err := account.CreateDBIngressKey(db, user, pubKey); err != nil {
+	opts, err := keyFlags.Options(time.Now())
+	if err == nil {
+		err = account.CreateDBIngressKeyWithPolicy(db, user, pubKey, opts)
+	}
+	if err != nil {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Add Ingress Key",
 			BlockType: "error",
 			Sections: []console.SectionContent{
-				{SubTitle: "Error", Body: []string{"Failed to add ingress key. Please contact admin."}},
+				{SubTitle: "Error", Body: []string{fmt.Sprintf("Failed to add ingress key: %v", err)}},
 			},
 		})
 		return err
 	}
 
-	// Apply expiry if requested
-	if expiresDays > 0 {
-		expiresAt := time.Now().AddDate(0, 0, expiresDays)
-		if err := db.Model(&models.IngressKey{}).
-			Where("user_id = ? AND key = ?", user.ID, pubKey).
-			Update("expires_at", expiresAt).Error; err != nil {
-			console.DisplayBlock(console.ContentBlock{
-				Title:     "Add Ingress Key",
-				BlockType: "error",
-				Sections: []console.SectionContent{
-					{SubTitle: "Error", Body: []string{fmt.Sprintf("Failed to set key expiry: %v", err)}},
-				},
-			})
-			return fmt.Errorf("failed to set key expiry: %w", err)
-		}
-	}
-
 	if err := gosync.New(db, osadapter.NewLinuxAdapter(), *slog.Default()).IngressKeyFromDB(*user); err != nil {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Add Ingress Key",
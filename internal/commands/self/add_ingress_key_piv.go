@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"goBastion/internal/commands/account"
 	"goBastion/internal/models"
@@ -18,10 +19,10 @@ import (
 	"gorm.io/gorm"
 )
 
-// addIngressKey is a shared helper: calls CreateDBIngressKey and optionally marks the
-// resulting IngressKey as PIV-attested before syncing authorized_keys.
-func addIngressKey(db *gorm.DB, user *models.User, pubKeyText, comment string, pivAttested bool) error {
-	if err := account.CreateDBIngressKey(db, user, pubKeyText); err != nil {
+// addIngressKey is a shared helper: calls CreateDBIngressKeyWithPolicy with opts
+// (e.g. marking the resulting IngressKey as PIV-attested) before syncing authorized_keys.
+func addIngressKey(db *gorm.DB, user *models.User, pubKeyText, comment string, opts account.IngressKeyOptions) error {
+	if err := account.CreateDBIngressKeyWithPolicy(db, user, pubKeyText, opts); err != nil {
 		console.DisplayBlock(console.ContentBlock{
 			Title:     "Add PIV Ingress Key",
 			BlockType: "error",
@@ -30,14 +31,6 @@ func addIngressKey(db *gorm.DB, user *models.User, pubKeyText, comment string, p
 		return fmt.Errorf("failed to add ingress key: %w", err)
 	}
 
-	if pivAttested {
-		if err := db.Model(&models.IngressKey{}).
-			Where("user_id = ? AND key = ?", user.ID, strings.TrimSpace(pubKeyText)).
-			Update("piv_attested", true).Error; err != nil {
-			return fmt.Errorf("key added but PIV attestation flag could not be set: %w", err)
-		}
-	}
-
 	// Re-sync authorized_keys
 	if err := gosync.New(db, osadapter.NewLinuxAdapter(), *slog.Default()).IngressKeyFromDB(*user); err != nil {
 		console.DisplayBlock(console.ContentBlock{
@@ -59,13 +52,14 @@ func addIngressKey(db *gorm.DB, user *models.User, pubKeyText, comment string, p
 // The full attestation chain is verified against a stored trust anchor before
 // the key is accepted.
 //
-// Usage: selfAddIngressKeyPIV --attest <path> --intermediate <path> [--comment <comment>]
+// Usage: selfAddIngressKeyPIV --attest <path> --intermediate <path> [--comment <comment>] [key option flags] <ssh_public_key>
 func AddIngressKeyPIV(db *gorm.DB, currentUser *models.User, args []string) error {
 	fs := flag.NewFlagSet("selfAddIngressKeyPIV", flag.ContinueOnError)
 	var attestFile, intermediateFile, comment string
 	fs.StringVar(&attestFile, "attest", "", "Path to PIV attestation certificate (PEM)")
 	fs.StringVar(&intermediateFile, "intermediate", "", "Path to intermediate certificate (PEM)")
 	fs.StringVar(&comment, "comment", "", "Comment for this key")
+	keyFlags := account.RegisterIngressKeyFlags(fs)
 	var flagOutput strings.Builder
 	fs.SetOutput(&flagOutput)
 
@@ -74,7 +68,7 @@ func AddIngressKeyPIV(db *gorm.DB, currentUser *models.User, args []string) erro
 			Title:     "Add PIV Ingress Key",
 			BlockType: "error",
 			Sections: []console.SectionContent{{SubTitle: "Usage", Body: []string{
-				"Usage: selfAddIngressKeyPIV --attest <path> --intermediate <path> [--comment <comment>]",
+				"Usage: selfAddIngressKeyPIV --attest <path> --intermediate <path> [--comment <comment>] " + account.IngressKeyFlagsUsage + " <ssh_public_key>",
 				"",
 				"Generate attestation data on a YubiKey:",
 				"  yubico-piv-tool --action=attest --slot=9a > attest.pem",
@@ -143,5 +137,15 @@ func AddIngressKeyPIV(db *gorm.DB, currentUser *models.User, args []string) erro
 	}
 
 	// Attestation OK - add the key, marked as PIV-attested.
-	return addIngressKey(db, currentUser, sshKeyText, comment, true)
+	opts, err := keyFlags.Options(time.Now())
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Add PIV Ingress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{err.Error()}}},
+		})
+		return nil
+	}
+	opts.PIVAttested = true
+	return addIngressKey(db, currentUser, sshKeyText, comment, opts)
 }
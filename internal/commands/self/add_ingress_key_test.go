@@ -1,7 +1,10 @@
 package self
 
 import (
+	"strings"
 	"testing"
+
+	"goBastion/internal/models"
 )
 
 func TestAddIngressKey_InvalidKeyNoArgs(t *testing.T) {
@@ -11,3 +14,18 @@ func TestAddIngressKey_InvalidKeyNoArgs(t *testing.T) {
 	// No args — should not panic; returns nil (empty key path returns nil)
 	_ = AddIngressKey(db, user, []string{})
 }
+
+func TestAddIngressKey_RefusesOptionsInKeyText(t *testing.T) {
+	db := newTestDB(t)
+	user := newRegularUser(t, db, "alice")
+
+	err := AddIngressKey(db, user, []string{"--key", `from="10.0.0.0/8" ` + testIngressPubKey})
+	if err == nil || !strings.Contains(err.Error(), "--from") {
+		t.Fatalf("expected options in the key text to be refused with a hint at the flags, got %v", err)
+	}
+	var count int64
+	db.Model(&models.IngressKey{}).Where("user_id = ?", user.ID).Count(&count)
+	if count != 0 {
+		t.Fatalf("key with inline options stored: %d keys", count)
+	}
+}
@@ -0,0 +1,159 @@
+package self
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/cryptokey"
+	"goBastion/internal/utils/egresskey"
+	"goBastion/internal/utils/revocation"
+	"goBastion/internal/utils/sshConnector"
+
+	"gorm.io/gorm"
+)
+
+// DeployEgressKey adds the user's egress public keys to the authorized_keys
+// of the accounts of their personal accesses, or of the given servers only.
+// It logs in with an egress key the target already accepts, or asks once
+// for a password. --remove-retired also takes out the keys of deleted
+// egress keys.
+func DeployEgressKey(db *gorm.DB, user *models.User, log *slog.Logger, args []string) error {
+	fs := flag.NewFlagSet("selfDeployEgressKey", flag.ContinueOnError)
+	var servers string
+	var removeRetired bool
+	fs.StringVar(&servers, "server", "", "Comma-separated servers to deploy to (default: all personal accesses)")
+	fs.BoolVar(&removeRetired, "remove-retired", false, "Remove deleted egress keys from authorized_keys")
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	if err := fs.Parse(args); err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Deploy Egress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: selfDeployEgressKey [--server <host>[,<host>...]] [--remove-retired]"}}},
+		})
+		return err
+	}
+
+	if !user.CanDo(db, "selfDeployEgressKey", "") {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Deploy Egress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Access Denied", Body: []string{"You do not have permission to deploy egress keys."}}},
+		})
+		return fmt.Errorf("access denied for %s", user.Username)
+	}
+
+	var keys []models.SelfEgressKey
+	if err := db.Where("user_id = ?", user.ID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Deploy Egress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"An error occurred while retrieving keys. Please contact support."}}},
+		})
+		return err
+	}
+	if len(keys) == 0 {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Deploy Egress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "No Egress Key", Body: []string{"You have no egress key to deploy. Generate one with selfGenerateEgressKey."}}},
+		})
+		return fmt.Errorf("no egress key for %s", user.Username)
+	}
+	revoked, err := revocation.Revoked(db)
+	if err != nil {
+		return err
+	}
+	d := egresskey.Deployment{Password: egresskey.PromptPassword()}
+	for _, k := range keys {
+		// A revoked key is taken off the targets and never logs in.
+		if revoked[k.Fingerprint] {
+			d.Remove = append(d.Remove, k.PubKey)
+			continue
+		}
+		d.Add = append(d.Add, k.PubKey)
+		d.Auth = append(d.Auth, cryptokey.DecryptOrPassThrough(k.PrivKey))
+	}
+	if removeRetired {
+		var deleted []models.SelfEgressKey
+		if err := db.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", user.ID).Find(&deleted).Error; err != nil {
+			return fmt.Errorf("error retrieving deleted egress keys: %w", err)
+		}
+		for _, k := range deleted {
+			d.Remove = append(d.Remove, k.PubKey)
+		}
+	}
+
+	accesses, err := egresskey.SelfAccesses(db, user.ID, time.Now())
+	if err != nil {
+		return err
+	}
+	targets, skipped, err := egresskey.Expand(db, accesses, splitServers(servers))
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Deploy Egress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Server", Body: []string{err.Error() + ". Check selfListAccesses."}}},
+		})
+		return err
+	}
+
+	reports := egresskey.DeployAll(db, targets, d, func(t egresskey.Target) error {
+		return sshConnector.CheckAndUpdateHostKey(db, *user, t.Server, t.Port)
+	})
+	return displayDeployReport(log, user.Username, reports, skipped)
+}
+
+// splitServers splits a comma-separated --server value.
+func splitServers(value string) []string {
+	var servers []string
+	for _, s := range strings.Split(value, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			servers = append(servers, s)
+		}
+	}
+	return servers
+}
+
+// displayDeployReport logs and shows the per-host outcome of a deployment.
+func displayDeployReport(log *slog.Logger, username string, reports []egresskey.Report, skipped []string) error {
+	var lines []string
+	failed := 0
+	for _, r := range reports {
+		lines = append(lines, r.String())
+		if r.Err != nil {
+			failed++
+			log.Warn("self_egress_key_deploy_failed", slog.String("user", username), slog.String("target", r.Target.String()), slog.String("error", r.Err.Error()))
+			continue
+		}
+		log.Info("self_egress_key_deployed", slog.String("user", username), slog.String("target", r.Target.String()),
+			slog.Int("added", r.Added), slog.Int("removed", r.Removed), slog.String("method", r.Method))
+	}
+	for _, s := range skipped {
+		lines = append(lines, fmt.Sprintf("⚠️ %s: pattern access, name the host with --server", s))
+	}
+	if len(lines) == 0 {
+		lines = []string{"You have no personal accesses to deploy to."}
+	}
+
+	blockType := "success"
+	if failed > 0 {
+		blockType = "warning"
+	}
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Deploy Egress Key",
+		BlockType: blockType,
+		Sections:  []console.SectionContent{{SubTitle: fmt.Sprintf("%d host(s), %d failed", len(reports), failed), Body: lines}},
+	})
+	if failed > 0 {
+		return fmt.Errorf("egress key deployment failed on %d host(s)", failed)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package self
+
+import (
+	"testing"
+	"time"
+
+	"goBastion/internal/models"
+)
+
+func TestModifyAccess_KeepsIDAndLastConnection(t *testing.T) {
+	db := newTestDB(t)
+	user := newRegularUser(t, db, "alice")
+
+	lastUsed := time.Now().Add(-time.Hour).Truncate(time.Second)
+	access := models.SelfAccess{UserID: user.ID, Server: "10.0.0.5", Username: "root", Port: 22, Protocol: "ssh", Comment: "old", LastConnection: lastUsed}
+	if err := db.Create(&access).Error; err != nil {
+		t.Fatalf("seed access: %v", err)
+	}
+
+	err := ModifyAccess(db, user, []string{"--id", access.ID.String(), "--comment", "new", "--from", "10.0.0.0/8", "--protocol", "sftp", "--ttl", "7"})
+	if err != nil {
+		t.Fatalf("ModifyAccess: %v", err)
+	}
+
+	var got models.SelfAccess
+	if err := db.Where("id = ?", access.ID).First(&got).Error; err != nil {
+		t.Fatalf("reload access: %v", err)
+	}
+	if got.Comment != "new" || got.AllowedFrom != "10.0.0.0/8" || got.Protocol != "sftp" || got.ExpiresAt == nil {
+		t.Fatalf("fields not modified: %+v", got)
+	}
+	if !got.LastConnection.Equal(lastUsed) {
+		t.Fatalf("LastConnection changed: %v, want %v", got.LastConnection, lastUsed)
+	}
+}
+
+func TestModifyAccess_RejectsInvalidValueAndForeignEntry(t *testing.T) {
+	db := newTestDB(t)
+	alice := newRegularUser(t, db, "alice")
+	bob := newRegularUser(t, db, "bob")
+
+	access := models.SelfAccess{UserID: alice.ID, Server: "10.0.0.5", Username: "root", Port: 22, Protocol: "ssh"}
+	if err := db.Create(&access).Error; err != nil {
+		t.Fatalf("seed access: %v", err)
+	}
+
+	if err := ModifyAccess(db, alice, []string{"--id", access.ID.String(), "--from", "not-a-cidr"}); err == nil {
+		t.Fatal("expected invalid CIDRs to be rejected")
+	}
+	if err := ModifyAccess(db, alice, []string{"--id", access.ID.String()}); err == nil {
+		t.Fatal("expected an error without any field flag")
+	}
+	if err := ModifyAccess(db, bob, []string{"--id", access.ID.String(), "--comment", "mine"}); err == nil {
+		t.Fatal("expected another user's access to be out of reach")
+	}
+
+	var got models.SelfAccess
+	if err := db.Where("id = ?", access.ID).First(&got).Error; err != nil {
+		t.Fatalf("reload access: %v", err)
+	}
+	if got.AllowedFrom != "" || got.Comment != "" {
+		t.Fatalf("access modified despite errors: %+v", got)
+	}
+}
@@ -0,0 +1,88 @@
+package self
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/accessedit"
+	"goBastion/internal/utils/console"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ModifyDBAccess changes fields of a personal database access entry in place,
+// keeping its ID and LastConnection.
+func ModifyDBAccess(db *gorm.DB, user *models.User, args []string) error {
+	fs := flag.NewFlagSet("selfModifyDBAccess", flag.ContinueOnError)
+	var accessIDStr string
+	fs.StringVar(&accessIDStr, "id", "", "Access ID")
+	fields := accessedit.Register(fs, true)
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	if err := fs.Parse(args); err != nil || accessIDStr == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Personal DB Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: selfModifyDBAccess --id <access_id> " + fields.Usage()}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	accessID, err := uuid.Parse(accessIDStr)
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Personal DB Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid ID", Body: []string{"Invalid access ID format."}}},
+		})
+		return err
+	}
+
+	var access models.SelfDBAccess
+	if err := db.Where("id = ? AND user_id = ?", accessID, user.ID).First(&access).Error; err != nil {
+		body := "Database error while looking up access entry. Please try again."
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			body = "No such access found."
+		}
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Personal DB Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{body}}},
+		})
+		return err
+	}
+
+	updates, err := fields.Updates(access.StartsAt, access.ExpiresAt, time.Now())
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Personal DB Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Value", Body: []string{err.Error()}}},
+		})
+		return err
+	}
+	if err := db.Model(&access).Updates(updates).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Personal DB Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Failed to modify personal DB access. Please contact admin."}}},
+		})
+		return fmt.Errorf("error modifying personal DB access: %w", err)
+	}
+
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Modify Personal DB Access",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Success", Body: []string{"Personal DB access modified successfully."}}},
+	})
+	return nil
+}
@@ -0,0 +1,156 @@
+package self
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"goBastion/internal/config"
+	"goBastion/internal/models"
+	"goBastion/internal/osadapter"
+	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/keypolicy"
+	gosync "goBastion/internal/utils/sync"
+	"goBastion/internal/utils/validation"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ModifyIngressKey changes the options of one of the current user's ingress
+// keys. Only the flags given are applied; --expires 0 removes the expiry, an
+// empty --from lifts the source restriction and --no-port-forwarding=false
+// or --no-agent-forwarding=false lift the forwarding restrictions.
+func ModifyIngressKey(db *gorm.DB, user *models.User, args []string) error {
+	fs := flag.NewFlagSet("selfModifyIngressKey", flag.ContinueOnError)
+	var keyID, allowedFrom string
+	var expiresDays int
+	var noPortForwarding, noAgentForwarding bool
+	fs.StringVar(&keyID, "id", "", "Ingress key ID")
+	fs.IntVar(&expiresDays, "expires", 0, "New expiry in days from now (0 = never)")
+	fs.StringVar(&allowedFrom, "from", "", "New source CIDRs (comma-separated, empty = anywhere)")
+	fs.BoolVar(&noPortForwarding, "no-port-forwarding", false, "Forbid port forwarding with this key")
+	fs.BoolVar(&noAgentForwarding, "no-agent-forwarding", false, "Forbid agent forwarding with this key")
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	usage := "Usage: selfModifyIngressKey --id <key_id> [--expires <days>] [--from <CIDRs>] [--no-port-forwarding=true|false] [--no-agent-forwarding=true|false]"
+	if err := fs.Parse(args); err != nil || keyID == "" {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Ingress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{usage}}},
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("missing required arguments")
+	}
+
+	id, err := uuid.Parse(keyID)
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Ingress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid ID", Body: []string{"Invalid Ingress Key ID format."}}},
+		})
+		return err
+	}
+
+	var key models.IngressKey
+	if err := db.Where("id = ? AND user_id = ?", id, user.ID).First(&key).Error; err != nil {
+		body := "Database error while looking up the key. Please try again."
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			body = "No such ingress key found."
+		}
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Ingress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{body}}},
+		})
+		return err
+	}
+
+	updates, err := ingressKeyUpdates(fs, &key, expiresDays, allowedFrom, noPortForwarding, noAgentForwarding, time.Now())
+	if err == nil {
+		err = keypolicy.Check(config.Get().IngressKeyPolicy, keypolicy.Key{
+			Type: key.Type, Size: key.Size, ExpiresAt: key.ExpiresAt, PIVAttested: key.PIVAttested, CreatedAt: time.Now(),
+		}, user)
+	}
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Ingress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Invalid Value", Body: []string{err.Error()}}},
+		})
+		return err
+	}
+	if err := db.Model(&key).Updates(updates).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Ingress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Failed to modify the ingress key. Please contact admin."}}},
+		})
+		return fmt.Errorf("error modifying ingress key %s: %w", key.ID, err)
+	}
+
+	if err := gosync.New(db, osadapter.NewLinuxAdapter(), *slog.Default()).IngressKeyFromDB(*user); err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Modify Ingress Key",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{"Failed to sync ingress key. Please contact admin."}}},
+		})
+		return err
+	}
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Modify Ingress Key",
+		BlockType: "success",
+		Sections:  []console.SectionContent{{SubTitle: "Success", Body: []string{"Ingress key modified successfully."}}},
+	})
+	return nil
+}
+
+// ingressKeyUpdates returns the columns to update for the flags given on fs,
+// applying them to key as well so that it can be checked against the policy.
+func ingressKeyUpdates(fs *flag.FlagSet, key *models.IngressKey, expiresDays int, allowedFrom string, noPortForwarding, noAgentForwarding bool, now time.Time) (map[string]any, error) {
+	given := make(map[string]bool)
+	fs.Visit(func(fl *flag.Flag) { given[fl.Name] = true })
+
+	updates := make(map[string]any)
+	if given["expires"] {
+		switch {
+		case expiresDays < 0:
+			return nil, fmt.Errorf("--expires must be zero (never) or a positive number of days")
+		case expiresDays == 0:
+			key.ExpiresAt = nil
+			updates["expires_at"] = nil
+		default:
+			t := now.AddDate(0, 0, expiresDays)
+			key.ExpiresAt = &t
+			updates["expires_at"] = t
+		}
+	}
+	if given["from"] {
+		from, err := validation.NormalizeCIDRs(allowedFrom)
+		if err != nil {
+			return nil, fmt.Errorf("--from %w", err)
+		}
+		key.AllowedFrom = from
+		updates["allowed_from"] = from
+	}
+	if given["no-port-forwarding"] {
+		key.NoPortForwarding = noPortForwarding
+		updates["no_port_forwarding"] = noPortForwarding
+	}
+	if given["no-agent-forwarding"] {
+		key.NoAgentForwarding = noAgentForwarding
+		updates["no_agent_forwarding"] = noAgentForwarding
+	}
+	if len(updates) == 0 {
+		return nil, fmt.Errorf("nothing to modify: give at least one of --expires, --from, --no-port-forwarding, --no-agent-forwarding")
+	}
+	return updates, nil
+}
@@ -0,0 +1,52 @@
+package self
+
+import (
+	"testing"
+
+	"goBastion/internal/commands/account"
+	"goBastion/internal/config"
+	"goBastion/internal/models"
+)
+
+const testIngressPubKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl test-key"
+
+func TestModifyIngressKey(t *testing.T) {
+	config.ResetForTesting()
+	t.Cleanup(config.ResetForTesting)
+	config.Load().Paths.HomeBaseDir = t.TempDir()
+
+	db := newTestDB(t)
+	user := newRegularUser(t, db, "alice")
+	if err := account.CreateDBIngressKey(db, user, testIngressPubKey); err != nil {
+		t.Fatalf("create key: %v", err)
+	}
+	var key models.IngressKey
+	if err := db.Where("user_id = ?", user.ID).First(&key).Error; err != nil {
+		t.Fatalf("load key: %v", err)
+	}
+
+	if err := ModifyIngressKey(db, user, []string{"--id", key.ID.String()}); err == nil {
+		t.Fatal("expected an error when no field flag is given")
+	}
+	if err := ModifyIngressKey(db, user, []string{"--id", key.ID.String(), "--from", "bad"}); err == nil {
+		t.Fatal("expected an invalid --from to be refused")
+	}
+
+	// The authorized_keys sync may fail in tests; the DB update happens before it.
+	_ = ModifyIngressKey(db, user, []string{"--id", key.ID.String(), "--from", "10.0.0.0/8", "--no-port-forwarding", "--expires", "7"})
+	if err := db.First(&key, "id = ?", key.ID).Error; err != nil {
+		t.Fatalf("reload key: %v", err)
+	}
+	if key.AllowedFrom != "10.0.0.0/8" || !key.NoPortForwarding || key.NoAgentForwarding || key.ExpiresAt == nil {
+		t.Fatalf("unexpected key after modification: %+v", key)
+	}
+
+	_ = ModifyIngressKey(db, user, []string{"--id", key.ID.String(), "--from", "", "--no-port-forwarding=false", "--expires", "0"})
+	var lifted models.IngressKey
+	if err := db.First(&lifted, "id = ?", key.ID).Error; err != nil {
+		t.Fatalf("reload key: %v", err)
+	}
+	if lifted.AuthorizedKeysOptions() != "" {
+		t.Fatalf("expected all options lifted, got %q", lifted.AuthorizedKeysOptions())
+	}
+}
@@ -16,7 +16,7 @@ func newTestDB(t *testing.T) *gorm.DB {
 		t.Fatalf("open test DB: %v", err)
 	}
 	if err := db.AutoMigrate(
-		&models.User{}, &models.IngressKey{}, &models.SelfEgressKey{},
+		&models.User{}, &models.IngressKey{}, &models.SelfEgressKey{}, &models.RevokedKey{},
 		&models.GroupEgressKey{}, &models.SelfAccess{}, &models.GroupAccess{},
 		&models.Group{}, &models.UserGroup{}, &models.Aliases{},
 		&models.KnownHostsEntry{}, &models.PIVTrustAnchor{},
@@ -16,6 +16,10 @@ import (
 	"goBastion/internal/models"
 	"goBastion/internal/utils"
 	"goBastion/internal/utils/cryptokey"
+	"goBastion/internal/utils/hostmatch"
+	"goBastion/internal/utils/justification"
+	"goBastion/internal/utils/revocation"
+	"goBastion/internal/utils/schedule"
 	"goBastion/internal/utils/sftpProxy"
 	"goBastion/internal/utils/sshConnector"
 	"goBastion/internal/utils/system"
@@ -43,6 +47,35 @@ type accessCandidate struct {
 	groupAccess *models.GroupAccess
 	score       int
 	reason      string
+	excluded    string // rule that rejected the entry; empty when it applies
+	// guestSchedule is the window of the guest grant that admitted a guest-role
+	// user to groupAccess; it applies on top of the entry's own schedule.
+	guestSchedule string
+}
+
+// server returns the stored server value (literal host, CIDR or glob) of the candidate.
+func (c accessCandidate) server() string {
+	if c.selfAccess != nil {
+		return c.selfAccess.Server
+	}
+	return c.groupAccess.Server
+}
+
+// scheduleAllows reports whether the candidate's access windows are open at t.
+func (c accessCandidate) scheduleAllows(t time.Time) (bool, string) {
+	spec := ""
+	if c.selfAccess != nil {
+		spec = c.selfAccess.Schedule
+	} else {
+		spec = c.groupAccess.Schedule
+	}
+	if !schedule.Allows(spec, t) {
+		return false, spec
+	}
+	if !schedule.Allows(c.guestSchedule, t) {
+		return false, c.guestSchedule
+	}
+	return true, ""
 }
 
 type sshLogTarget struct {
@@ -78,6 +111,10 @@ func Connect(db *gorm.DB, user models.User, logger slog.Logger, params string) e
 	if err != nil {
 		return fmt.Errorf("invalid -F hop: %w", err)
 	}
+	cleanParams, reason, err := justification.Extract(cleanParams)
+	if err != nil {
+		return fmt.Errorf("invalid SSH command: %w", err)
+	}
 
 	sshUser, sshHost, sshPort, remoteCmd, err := parseSSHCommand(cleanParams)
 	if err != nil {
@@ -197,7 +234,27 @@ func Connect(db *gorm.DB, user models.User, logger slog.Logger, params string) e
 				}
 			}
 
-			log.Info("ssh_connect", slog.String("to", access.Source), slog.String("key_id", access.KeyId.String()))
+			// Justification: interactive sessions are prompted, binary
+			// protocols (scp/rsync) carry their stream on stdin and need --reason.
+			if access.JustificationRequired {
+				answer, err := resolveJustification(access, reason, protocol == "ssh" && remoteCmd == "", log)
+				if err != nil {
+					return err
+				}
+				reason = answer
+				access.Justification = answer
+			}
+
+			if access.BreakGlassID != uuid.Nil {
+				log.Error("break_glass_connect", slog.String("severity", "critical"),
+					slog.String("break_glass_id", access.BreakGlassID.String()), slog.String("to", access.Source))
+				fmt.Println("🚨 Break-glass access: this session is recorded and will be reviewed by an administrator.")
+			}
+			connectLog := log
+			if access.Justification != "" {
+				connectLog = log.With(slog.String("justification", access.Justification))
+			}
+			connectLog.Info("ssh_connect", slog.String("to", access.Source), slog.String("key_id", access.KeyId.String()))
 			access.RemoteCmd = remoteCmd
 			access.JumpHosts = formatJumpHosts(hops)
 			err = sshConnector.SshConnection(db, user, access)
@@ -230,26 +287,33 @@ func inferSSHUsername(db *gorm.DB, user models.User, host string, port int64) (s
 	now := time.Now()
 
 	// Self accesses first (higher priority than group).
-	var sa models.SelfAccess
-	if err := db.Where("user_id = ? AND server = ? AND port = ? AND (expires_at IS NULL OR expires_at > ?)",
-		user.ID, host, port, now).
-		Order("CASE WHEN username != '*' THEN 0 ELSE 1 END"). // exact before wildcard
-		First(&sa).Error; err == nil {
-		if u, ok := resolveAccessUsername(sa.Username); ok {
+	var selfAccesses []models.SelfAccess
+	if err := db.Where("user_id = ? AND "+hostmatch.CandidateClause+" AND port = ? AND (expires_at IS NULL OR expires_at > ?)",
+		user.ID, host, port, now).Where(models.StartedClause, now).
+		Find(&selfAccesses).Error; err == nil {
+		if u, ok := bestInferredUsername(openSelfAccesses(selfAccesses, host, now),
+			func(sa models.SelfAccess) (string, string) { return sa.Username, sa.Server }); ok {
 			return u, true
 		}
 	}
 
-	// Group accesses.
+	// Group accesses, including those inherited through nested groups.
 	var groupIDs []uuid.UUID
-	if err := db.Model(&models.UserGroup{}).Where("user_id = ?", user.ID).
-		Pluck("group_id", &groupIDs).Error; err == nil && len(groupIDs) > 0 {
-		var ga models.GroupAccess
-		if err := db.Where("group_id IN ? AND server = ? AND port = ? AND (expires_at IS NULL OR expires_at > ?)",
-			groupIDs, host, port, now).
-			Order("CASE WHEN username != '*' THEN 0 ELSE 1 END").
-			First(&ga).Error; err == nil {
-			if u, ok := resolveAccessUsername(ga.Username); ok {
+	var userGroups []models.UserGroup
+	if err := db.Where("user_id = ?", user.ID).Where(models.MembershipActiveClause, time.Now()).Find(&userGroups).Error; err == nil {
+		if roles, err := models.EffectiveGroupRoles(db, userGroups); err == nil {
+			for groupID := range roles {
+				groupIDs = append(groupIDs, groupID)
+			}
+		}
+	}
+	if len(groupIDs) > 0 {
+		var groupAccesses []models.GroupAccess
+		if err := db.Where("group_id IN ? AND "+groupServerClause+" AND port = ? AND (expires_at IS NULL OR expires_at > ?)",
+			groupIDs, host, port, now).Where(models.StartedClause, now).Where(models.GroupAccessMemberClause, user.ID).
+			Find(&groupAccesses).Error; err == nil {
+			if u, ok := bestInferredUsername(openGroupAccesses(db, groupAccesses, host, now),
+				func(ga models.GroupAccess) (string, string) { return ga.Username, ga.Server }); ok {
 				return u, true
 			}
 		}
@@ -257,17 +321,19 @@ func inferSSHUsername(db *gorm.DB, user models.User, host string, port int64) (s
 
 	// Admin override: any access entry in the system for this host.
 	if user.Role == models.RoleAdmin {
-		var ga models.GroupAccess
-		if err := db.Where("server = ? AND port = ? AND (expires_at IS NULL OR expires_at > ?)", host, port, now).
-			First(&ga).Error; err == nil {
-			if u, ok := resolveAccessUsername(ga.Username); ok {
+		var groupAccesses []models.GroupAccess
+		if err := db.Where(groupServerClause+" AND port = ? AND (expires_at IS NULL OR expires_at > ?)", host, port, now).Where(models.StartedClause, now).
+			Find(&groupAccesses).Error; err == nil {
+			if u, ok := bestInferredUsername(openGroupAccesses(db, groupAccesses, host, now),
+				func(ga models.GroupAccess) (string, string) { return ga.Username, ga.Server }); ok {
 				return u, true
 			}
 		}
-		var sa2 models.SelfAccess
-		if err := db.Where("server = ? AND port = ? AND (expires_at IS NULL OR expires_at > ?)", host, port, now).
-			First(&sa2).Error; err == nil {
-			if u, ok := resolveAccessUsername(sa2.Username); ok {
+		var selfAccesses []models.SelfAccess
+		if err := db.Where(hostmatch.CandidateClause+" AND port = ? AND (expires_at IS NULL OR expires_at > ?)", host, port, now).Where(models.StartedClause, now).
+			Find(&selfAccesses).Error; err == nil {
+			if u, ok := bestInferredUsername(openSelfAccesses(selfAccesses, host, now),
+				func(sa models.SelfAccess) (string, string) { return sa.Username, sa.Server }); ok {
 				return u, true
 			}
 		}
@@ -276,6 +342,50 @@ func inferSSHUsername(db *gorm.DB, user models.User, host string, port int64) (s
 	return "", false
 }
 
+// openSelfAccesses keeps the self accesses covering host whose schedule
+// window is open at now.
+func openSelfAccesses(accesses []models.SelfAccess, host string, now time.Time) []models.SelfAccess {
+	out := accesses[:0]
+	for _, sa := range hostmatch.Filter(accesses, host, func(sa models.SelfAccess) string { return sa.Server }) {
+		if schedule.Allows(sa.Schedule, now) {
+			out = append(out, sa)
+		}
+	}
+	return out
+}
+
+// openGroupAccesses keeps the group accesses covering host whose schedule
+// window is open at now.
+func openGroupAccesses(db *gorm.DB, accesses []models.GroupAccess, host string, now time.Time) []models.GroupAccess {
+	out := accesses[:0]
+	for _, ga := range filterGroupAccesses(db, accesses, host) {
+		if schedule.Allows(ga.Schedule, now) {
+			out = append(out, ga)
+		}
+	}
+	return out
+}
+
+// bestInferredUsername picks the username of the best entry: exact usernames before
+// the '*' wildcard, then the most specific server match.
+func bestInferredUsername[T any](entries []T, fields func(T) (username, server string)) (string, bool) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		ui, si := fields(entries[i])
+		uj, sj := fields(entries[j])
+		if (ui != "*") != (uj != "*") {
+			return ui != "*"
+		}
+		return hostmatch.Specificity(si) > hostmatch.Specificity(sj)
+	})
+	for _, e := range entries {
+		username, _ := fields(e)
+		if u, ok := resolveAccessUsername(username); ok {
+			return u, true
+		}
+	}
+	return "", false
+}
+
 func resolveAccessUsername(username string) (string, bool) {
 	switch {
 	case username == "*":
@@ -287,6 +397,38 @@ func resolveAccessUsername(username string) (string, bool) {
 	}
 }
 
+// groupServerClause prefilters group accesses: server patterns that may cover
+// the host, plus every tag selector.
+const groupServerClause = "(" + hostmatch.CandidateClause + " OR " + models.TagServerClause + ")"
+
+// filterGroupAccesses keeps the group accesses covering host, either through
+// their server pattern or through a tag selector matching an inventory host
+// designated by host. Tag selectors are dropped when the inventory cannot be read.
+func filterGroupAccesses(db *gorm.DB, accesses []models.GroupAccess, host string) []models.GroupAccess {
+	var inventory []models.Host
+	inventoryLoaded := false
+	out := accesses[:0]
+	for _, ga := range accesses {
+		if !models.IsTagSelector(ga.Server) {
+			if hostmatch.Match(ga.Server, host) {
+				out = append(out, ga)
+			}
+			continue
+		}
+		if !inventoryLoaded {
+			hosts, err := models.HostsForTarget(db, host)
+			if err != nil {
+				slog.Warn("filterGroupAccesses: inventory lookup failed", "error", err, "host", host)
+			}
+			inventory, inventoryLoaded = hosts, true
+		}
+		if models.TagSelectorCovers(ga.Server, inventory) {
+			out = append(out, ga)
+		}
+	}
+	return out
+}
+
 // accessFilter returns the best-matching access right for the given user, target and protocol.
 //
 // Priority order (highest first):
@@ -296,200 +438,314 @@ func resolveAccessUsername(username string) (string, bool) {
 //  4. Group access, wildcard '*' match        (score 1)
 //  5. Admin override: any matching system access (score 0, admin only)
 //
-// Within the same score, ordering is stable (database insertion order).
+// The stored server may be a literal host, a CIDR block or a hostname glob;
+// group accesses may also hold a tag selector resolved against the host
+// inventory (see filterGroupAccesses). Within the same score, the most
+// specific server match wins (exact host, then longest CIDR prefix, then the
+// glob with most literal characters); remaining ties keep database insertion
+// order. Entries whose Schedule window is closed are skipped; when none is
+// left the denial names the window. Candidate collection and scoring live in
+// evaluateAccess, shared with the explain commands.
 func accessFilter(DB *gorm.DB, user models.User, username, host, port, protocol string) ([]models.AccessRight, error) {
 	portInt, err := strconv.ParseInt(port, 10, 64)
 	if err != nil {
 		return nil, validation.WrapDBError(err, "error retrieving user groups")
 	}
 
-	clientIP := system.ClientIPFromEnv()
-	now := time.Now()
+	eval, err := evaluateAccess(DB, user, username, host, portInt, protocol, system.ClientIPFromEnv(), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if eval.denial != nil {
+		return nil, eval.denial
+	}
+	best := eval.candidates[eval.best]
+
+	// Build the AccessRight from the best candidate.
+	var access models.AccessRight
+	if best.selfAccess != nil {
+		access, err = buildSelfAccessRight(DB, slog.Default(), *best.selfAccess, host, username, best.reason)
+	} else {
+		access, err = buildGroupAccessRight(DB, slog.Default(), *best.groupAccess, host, username, best.reason)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if eval.breakGlass != nil && best.score == scoreAdminOverride {
+		access.BreakGlassID = eval.breakGlass.ID
+	}
 
-	var candidates []accessCandidate
+	return []models.AccessRight{access}, nil
+}
+
+// accessEvaluation is the outcome of candidate collection and scoring for one
+// connection request. Candidates are sorted best first; excluded ones carry
+// the rule that rejected them.
+type accessEvaluation struct {
+	candidates []accessCandidate
+	best       int // index of the selected candidate; -1 when denied
+	breakGlass *models.BreakGlass
+	denial     error
+}
+
+// evaluateAccess collects every access entry covering host, scores it and
+// records why each rejected entry does not apply, without connecting.
+func evaluateAccess(DB *gorm.DB, user models.User, username, host string, port int64, protocol, clientIP string, now time.Time) (accessEvaluation, error) {
+	eval := accessEvaluation{best: -1}
 
 	// --- Self accesses (scores 2 and 4) ---
 	var selfAccesses []models.SelfAccess
-	if err = DB.Where(
-		"user_id = ? AND (username = ? OR username = '*') AND server = ? AND port = ? "+
-			"AND (expires_at IS NULL OR expires_at > ?) AND (protocol = 'ssh' OR protocol = ?)",
-		user.ID, username, host, portInt, now, protocol,
-	).Find(&selfAccesses).Error; err != nil {
-		return nil, fmt.Errorf("error retrieving self accesses: %w", err)
+	if err := DB.Where("user_id = ? AND "+hostmatch.CandidateClause, user.ID, host).Find(&selfAccesses).Error; err != nil {
+		return eval, fmt.Errorf("error retrieving self accesses: %w", err)
 	}
+	selfAccesses = hostmatch.Filter(selfAccesses, host, func(sa models.SelfAccess) string { return sa.Server })
 	for i := range selfAccesses {
 		sa := &selfAccesses[i]
-		score := scoreSelfWildcard
-		reason := "self-wildcard"
+		score, reason := scoreSelfWildcard, "self-wildcard"
 		if sa.Username != "*" {
-			score = scoreSelfExact
-			reason = "self-exact"
+			score, reason = scoreSelfExact, "self-exact"
 		}
-		candidates = append(candidates, accessCandidate{selfAccess: sa, score: score, reason: reason})
+		eval.candidates = append(eval.candidates, accessCandidate{
+			selfAccess: sa, score: score, reason: reason,
+			excluded: entryExclusion(sa.Username, sa.Port, sa.Protocol, sa.StartsAt, sa.ExpiresAt, username, port, protocol, now),
+		})
 	}
 
 	// --- Group accesses (scores 1 and 3) ---
 	var userGroups []models.UserGroup
-	if err = DB.Where("user_id = ?", user.ID).Preload("Group").Find(&userGroups).Error; err != nil {
-		return nil, fmt.Errorf("error retrieving user groups: %w", err)
+	if err := DB.Where("user_id = ?", user.ID).Where(models.MembershipActiveClause, now).Preload("Group").Find(&userGroups).Error; err != nil {
+		return eval, fmt.Errorf("error retrieving user groups: %w", err)
+	}
+	// Groups inherited through nested groups count as plain memberships.
+	groupRoles, err := models.EffectiveGroupRoles(DB, userGroups)
+	if err != nil {
+		return eval, validation.WrapDBError(err, "error resolving nested groups")
 	}
-	groupIDs := make([]uuid.UUID, 0, len(userGroups))
-	groupRoles := make(map[uuid.UUID]string, len(userGroups))
+	groupIDs := make([]uuid.UUID, 0, len(groupRoles))
 	hasGuestRole := false
-	for _, ug := range userGroups {
-		groupIDs = append(groupIDs, ug.GroupID)
-		groupRoles[ug.GroupID] = ug.Role
-		if ug.Role == models.GroupRoleGuest {
+	for groupID, role := range groupRoles {
+		groupIDs = append(groupIDs, groupID)
+		if role == models.GroupRoleGuest {
 			hasGuestRole = true
 		}
 	}
 
 	if len(groupIDs) > 0 {
 		var groupAccesses []models.GroupAccess
-		if err = DB.Where(
-			"group_id IN ? AND (username = ? OR username = '*') AND server = ? AND port = ? "+
-				"AND (expires_at IS NULL OR expires_at > ?) AND (protocol = 'ssh' OR protocol = ?)",
-			groupIDs, username, host, portInt, now, protocol,
-		).Preload("Group").Find(&groupAccesses).Error; err != nil {
-			return nil, validation.WrapDBError(err, "error retrieving group accesses")
+		if err := DB.Where("group_id IN ? AND "+groupServerClause, groupIDs, host).Where(models.GroupAccessMemberClause, user.ID).
+			Preload("Group").Find(&groupAccesses).Error; err != nil {
+			return eval, validation.WrapDBError(err, "error retrieving group accesses")
 		}
+		groupAccesses = filterGroupAccesses(DB, groupAccesses, host)
 
-		// For guest-role users, collect their granular grants.
-		var guestGrants []models.GroupGuestAccess
+		// For guest-role users, map the group IDs for which they have a
+		// matching grant to the grant's schedule; a grant whose window is open
+		// now takes precedence.
+		var grantSchedules map[uuid.UUID]string
 		if hasGuestRole {
+			var guestGrants []models.GroupGuestAccess
 			if err := DB.Where(
-				"user_id = ? AND server = ? AND port = ? AND username = ? AND (expires_at IS NULL OR expires_at > ?)",
-				user.ID, host, portInt, username, now,
-			).Find(&guestGrants).Error; err != nil {
-				return nil, fmt.Errorf("error retrieving guest grants: %w", err)
+				"user_id = ? AND "+hostmatch.CandidateClause+" AND port = ? AND username = ? AND (expires_at IS NULL OR expires_at > ?)",
+				user.ID, host, port, username, now,
+			).Where(models.StartedClause, now).Find(&guestGrants).Error; err != nil {
+				return eval, fmt.Errorf("error retrieving guest grants: %w", err)
 			}
-			// Build a set of group IDs for which this user has a matching grant.
-			grantGroupIDs := make(map[uuid.UUID]bool, len(guestGrants))
+			guestGrants = hostmatch.Filter(guestGrants, host, func(gg models.GroupGuestAccess) string { return gg.Server })
+			grantSchedules = make(map[uuid.UUID]string, len(guestGrants))
 			for i := range guestGrants {
-				grantGroupIDs[guestGrants[i].GroupID] = true
+				g := guestGrants[i]
+				if cur, seen := grantSchedules[g.GroupID]; !seen || (cur != "" && schedule.Allows(g.Schedule, now)) {
+					grantSchedules[g.GroupID] = g.Schedule
+				}
 			}
+		}
 
-			for i := range groupAccesses {
-				ga := &groupAccesses[i]
-				if groupRoles[ga.GroupID] == models.GroupRoleGuest && !grantGroupIDs[ga.GroupID] {
-					continue
-				}
-				score := scoreGroupWildcard
-				reason := "group-wildcard"
-				if ga.Username != "*" {
-					score = scoreGroupExact
-					reason = "group-exact"
-				}
-				candidates = append(candidates, accessCandidate{groupAccess: ga, score: score, reason: reason})
+		for i := range groupAccesses {
+			ga := &groupAccesses[i]
+			score, reason := scoreGroupWildcard, "group-wildcard"
+			if ga.Username != "*" {
+				score, reason = scoreGroupExact, "group-exact"
 			}
-		} else {
-			for i := range groupAccesses {
-				ga := &groupAccesses[i]
-				score := scoreGroupWildcard
-				reason := "group-wildcard"
-				if ga.Username != "*" {
-					score = scoreGroupExact
-					reason = "group-exact"
+			c := accessCandidate{
+				groupAccess: ga, score: score, reason: reason,
+				excluded: entryExclusion(ga.Username, ga.Port, ga.Protocol, ga.StartsAt, ga.ExpiresAt, username, port, protocol, now),
+			}
+			if groupRoles[ga.GroupID] == models.GroupRoleGuest && c.excluded == "" {
+				spec, ok := grantSchedules[ga.GroupID]
+				if !ok {
+					c.excluded = "guest of the group without a matching guest grant"
 				}
-				candidates = append(candidates, accessCandidate{groupAccess: ga, score: score, reason: reason})
+				c.guestSchedule = spec
 			}
+			eval.candidates = append(eval.candidates, c)
 		}
 	}
 
-	// --- Admin override: any matching system access (score 0, last resort) ---
-	if user.Role == models.RoleAdmin && len(candidates) == 0 {
+	// --- Overrides: any matching system access (score 0, last resort) ---
+	// Admins always get it; other users only while a break-glass declaration
+	// for this exact target is active.
+	if eval.eligible() == 0 && config.Get().BreakGlass.Enabled {
+		eval.breakGlass = models.ActiveBreakGlass(DB, user.ID, host, port, username, now)
+	}
+	overrideReason := "admin-override"
+	if eval.breakGlass != nil {
+		overrideReason = "break-glass"
+	}
+	if eval.eligible() == 0 && (eval.breakGlass != nil || user.Role == models.RoleAdmin) {
 		var adminSelfAccesses []models.SelfAccess
-		if err = DB.Where(
-			"(username = ? OR username = '*') AND server = ? AND port = ? "+
-				"AND (expires_at IS NULL OR expires_at > ?) AND (protocol = 'ssh' OR protocol = ?)",
-			username, host, portInt, now, protocol,
-		).Find(&adminSelfAccesses).Error; err == nil {
+		if err := DB.Where(hostmatch.CandidateClause, host).Find(&adminSelfAccesses).Error; err == nil {
+			adminSelfAccesses = hostmatch.Filter(adminSelfAccesses, host, func(sa models.SelfAccess) string { return sa.Server })
 			for i := range adminSelfAccesses {
-				candidates = append(candidates, accessCandidate{
-					selfAccess: &adminSelfAccesses[i],
-					score:      scoreAdminOverride,
-					reason:     "admin-override-self",
+				sa := &adminSelfAccesses[i]
+				eval.candidates = append(eval.candidates, accessCandidate{
+					selfAccess: sa, score: scoreAdminOverride, reason: overrideReason + "-self",
+					excluded: entryExclusion(sa.Username, sa.Port, sa.Protocol, sa.StartsAt, sa.ExpiresAt, username, port, protocol, now),
 				})
 			}
 		}
 		var adminGroupAccesses []models.GroupAccess
-		if err = DB.Where(
-			"(username = ? OR username = '*') AND server = ? AND port = ? "+
-				"AND (expires_at IS NULL OR expires_at > ?) AND (protocol = 'ssh' OR protocol = ?)",
-			username, host, portInt, now, protocol,
-		).Preload("Group").Find(&adminGroupAccesses).Error; err == nil {
+		if err := DB.Where(groupServerClause, host).Preload("Group").Find(&adminGroupAccesses).Error; err == nil {
+			adminGroupAccesses = filterGroupAccesses(DB, adminGroupAccesses, host)
 			for i := range adminGroupAccesses {
-				candidates = append(candidates, accessCandidate{
-					groupAccess: &adminGroupAccesses[i],
-					score:       scoreAdminOverride,
-					reason:      "admin-override-group",
+				ga := &adminGroupAccesses[i]
+				eval.candidates = append(eval.candidates, accessCandidate{
+					groupAccess: ga, score: scoreAdminOverride, reason: overrideReason + "-group",
+					excluded: entryExclusion(ga.Username, ga.Port, ga.Protocol, ga.StartsAt, ga.ExpiresAt, username, port, protocol, now),
 				})
 			}
 		}
 	}
 
-	if len(candidates) == 0 {
-		return nil, fmt.Errorf("⛔ Access denied: no access entry found for %s@%s:%s.\n"+
-			"Run: selfAddAccess --server %s --port %s --username %s",
-			user.Username, username, host, host, port, username)
-	}
-
-	// Sort by score descending; stable so DB insertion order breaks ties.
+	// Sort by score descending, then by server specificity; stable so DB insertion order breaks ties.
+	candidates := eval.candidates
 	sort.SliceStable(candidates, func(i, j int) bool {
-		return candidates[i].score > candidates[j].score
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return hostmatch.Specificity(candidates[i].server()) > hostmatch.Specificity(candidates[j].server())
 	})
 
-	best := candidates[0]
+	if eval.eligible() == 0 {
+		eval.denial = fmt.Errorf("⛔ Access denied: no access entry found for %s@%s:%s.\n"+
+			"Run: selfAddAccess --server %s --port %d --username %s",
+			user.Username, username, host, host, port, username)
+		return eval, nil
+	}
 
-	// IP allowance check.
+	// Schedule check: skip entries whose access window is closed right now.
+	// When every entry is closed, report the window of the best one.
+	closedSchedule := ""
+	for i := range candidates {
+		c := &candidates[i]
+		if c.excluded != "" {
+			continue
+		}
+		if ok, spec := c.scheduleAllows(now); !ok {
+			c.excluded = "outside its access window (" + spec + ")"
+			if closedSchedule == "" {
+				closedSchedule = spec
+			}
+			continue
+		}
+		if eval.best == -1 {
+			eval.best = i
+		} else {
+			c.excluded = "lower priority than the selected entry"
+		}
+	}
+	if eval.best == -1 {
+		eval.denial = errors.New(schedule.DenialMessage(closedSchedule, now))
+		return eval, nil
+	}
+
+	// IP allowance check, on the best candidate only.
+	best := &candidates[eval.best]
 	allowedFrom := ""
+	src := "personal"
 	if best.selfAccess != nil {
 		allowedFrom = best.selfAccess.AllowedFrom
-	} else if best.groupAccess != nil {
+	} else {
 		allowedFrom = best.groupAccess.AllowedFrom
+		src = best.groupAccess.Group.Name
 	}
 	if !ipAllowed(clientIP, allowedFrom) {
-		src := "personal"
-		if best.groupAccess != nil {
-			src = best.groupAccess.Group.Name
+		best.excluded = fmt.Sprintf("source IP %s not in allowed CIDRs (%s)", clientIP, allowedFrom)
+		for i := eval.best + 1; i < len(candidates); i++ {
+			if candidates[i].excluded == "lower priority than the selected entry" {
+				candidates[i].excluded = "not tried: the best entry was rejected"
+			}
 		}
-		return nil, fmt.Errorf("⛔ Access denied: your IP %s is not in the allowed CIDRs for this access entry (%s)"+
+		eval.best = -1
+		eval.denial = fmt.Errorf("⛔ Access denied: your IP %s is not in the allowed CIDRs for this access entry (%s)"+
 			" — contact your admin to update the --from restriction",
 			clientIP, src)
 	}
+	return eval, nil
+}
 
-	// Build the AccessRight from the best candidate.
-	var access models.AccessRight
-	if best.selfAccess != nil {
-		access, err = buildSelfAccessRight(DB, slog.Default(), *best.selfAccess, username, best.reason)
-	} else {
-		access, err = buildGroupAccessRight(DB, slog.Default(), *best.groupAccess, username, best.reason)
-	}
-	if err != nil {
-		return nil, err
+// eligible counts the candidates not excluded so far.
+func (e accessEvaluation) eligible() int {
+	n := 0
+	for _, c := range e.candidates {
+		if c.excluded == "" {
+			n++
+		}
 	}
+	return n
+}
 
-	return []models.AccessRight{access}, nil
+// entryExclusion applies the per-entry rules (username, port, protocol, start, TTL)
+// and returns the first one the entry fails, or "" when it applies.
+func entryExclusion(entryUser string, entryPort int64, entryProtocol string, startsAt, expiresAt *time.Time,
+	username string, port int64, protocol string, now time.Time) string {
+	switch {
+	case entryUser != username && entryUser != "*":
+		return "username mismatch (entry: " + entryUser + ")"
+	case entryPort != port:
+		return fmt.Sprintf("port mismatch (entry: %d)", entryPort)
+	case entryProtocol != "ssh" && entryProtocol != protocol:
+		return "protocol restricted to " + entryProtocol
+	case startsAt != nil && startsAt.After(now):
+		return "pending (starts " + startsAt.Format("2006-01-02 15:04") + ")"
+	case expiresAt != nil && !expiresAt.After(now):
+		return "expired on " + expiresAt.Format("2006-01-02 15:04")
+	}
+	return ""
 }
 
 // buildGroupAccessRight constructs an AccessRight from a GroupAccess entry and its egress key.
-func buildGroupAccessRight(db *gorm.DB, log *slog.Logger, ga models.GroupAccess, requestedUsername, reason string) (models.AccessRight, error) {
-	var key models.GroupEgressKey
-	if err := db.Where("group_id = ?", ga.GroupID).First(&key).Error; err != nil &&
-		!errors.Is(err, gorm.ErrRecordNotFound) {
+// host is the concrete target: the entry's server may be a CIDR block or glob.
+func buildGroupAccessRight(db *gorm.DB, log *slog.Logger, ga models.GroupAccess, host, requestedUsername, reason string) (models.AccessRight, error) {
+	var keys []models.GroupEgressKey
+	if err := db.Scopes(revocation.NotRevoked).Where("group_id = ? AND state IN ?", ga.GroupID, models.UsableEgressKeyStates).
+		Order("created_at DESC").Find(&keys).Error; err != nil {
 		return models.AccessRight{}, validation.WrapDBError(fmt.Errorf("error retrieving egress key for group %v: %w", ga.GroupID, err), "database error")
 	}
+	orderGroupKeys(keys)
+	var key models.GroupEgressKey
+	var fallbackKeys []string
+	for i, k := range keys {
+		if i == 0 {
+			key = k
+		} else {
+			fallbackKeys = append(fallbackKeys, decryptPrivKey(k.PrivKey))
+		}
+		maybeReEncryptKey(db, log, "group", k.ID, k.PrivKey)
+	}
 
 	sourcePrefix := "group"
-	if reason == "admin-override-group" {
+	switch reason {
+	case "admin-override-group":
 		sourcePrefix = "admin-group"
+	case "break-glass-group":
+		sourcePrefix = "break-glass-group"
 	}
 
 	access := models.AccessRight{
 		ID:             ga.ID,
 		Source:         sourcePrefix + "-" + ga.Group.Name,
 		Username:       ga.Username,
-		Server:         ga.Server,
+		Server:         host,
 		Port:           ga.Port,
 		Type:           "group",
 		KeyId:          key.ID,
@@ -499,31 +755,46 @@ func buildGroupAccessRight(db *gorm.DB, log *slog.Logger, ga models.GroupAccess,
 		KeyUpdatedAt:   key.UpdatedAt,
 		PublicKey:      key.PubKey,
 		PrivateKey:     decryptPrivKey(key.PrivKey),
+		FallbackKeys:   fallbackKeys,
 		MFARequired:    ga.Group.MFARequired,
+
+		JustificationRequired: ga.Group.JustificationRequired,
+		JustificationPattern:  ga.Group.JustificationPattern,
 	}
 	access.Username = normalizeWildcardUsername(access.Username, requestedUsername)
-	maybeReEncryptKey(db, log, "group", key.ID, key.PrivKey)
 	return access, nil
 }
 
+// orderGroupKeys sorts a group's usable egress keys in the order they are
+// offered: active keys first, then the next key of a rotation, then the
+// retiring keys it replaces. Keys of the same state keep their order.
+func orderGroupKeys(keys []models.GroupEgressKey) {
+	rank := map[string]int{models.EgressKeyActive: 0, models.EgressKeyNext: 1, models.EgressKeyRetiring: 2}
+	sort.SliceStable(keys, func(i, j int) bool { return rank[keys[i].State] < rank[keys[j].State] })
+}
+
 // buildSelfAccessRight constructs an AccessRight from a SelfAccess entry and its egress key.
-func buildSelfAccessRight(db *gorm.DB, log *slog.Logger, sa models.SelfAccess, requestedUsername, reason string) (models.AccessRight, error) {
+// host is the concrete target: the entry's server may be a CIDR block or glob.
+func buildSelfAccessRight(db *gorm.DB, log *slog.Logger, sa models.SelfAccess, host, requestedUsername, reason string) (models.AccessRight, error) {
 	var key models.SelfEgressKey
-	if err := db.Where("user_id = ?", sa.UserID).First(&key).Error; err != nil &&
+	if err := db.Scopes(revocation.NotRevoked).Where("user_id = ?", sa.UserID).First(&key).Error; err != nil &&
 		!errors.Is(err, gorm.ErrRecordNotFound) {
 		return models.AccessRight{}, validation.WrapDBError(fmt.Errorf("error retrieving egress key for user %v: %w", sa.UserID, err), "database error")
 	}
 
 	sourcePrefix := "account"
-	if reason == "admin-override-self" {
+	switch reason {
+	case "admin-override-self":
 		sourcePrefix = "admin-account"
+	case "break-glass-self":
+		sourcePrefix = "break-glass-account"
 	}
 
 	access := models.AccessRight{
 		ID:             sa.ID,
 		Source:         sourcePrefix + "-" + sa.Username,
 		Username:       sa.Username,
-		Server:         sa.Server,
+		Server:         host,
 		Port:           sa.Port,
 		Type:           "self",
 		KeyId:          key.ID,
@@ -544,20 +815,21 @@ func decryptPrivKey(raw string) string {
 	return cryptokey.DecryptOrPassThrough(raw)
 }
 
-// maybeReEncryptKey re-encrypts a plaintext egress key if EGRESS_ENC_KEY is now set.
+// maybeReEncryptKey re-encrypts a plaintext egress key if EGRESS_ENC_KEY is now set,
+// or one encrypted with a key listed in EGRESS_ENC_KEY_PREVIOUS.
 // Runs synchronously to ensure the re-encryption is persisted before the session ends.
 func maybeReEncryptKey(db *gorm.DB, log *slog.Logger, keyType string, keyID uuid.UUID, currentPrivKey string) {
 	if !cryptokey.Enabled() {
 		return
 	}
-	if cryptokey.IsEncrypted(currentPrivKey) {
-		return // already encrypted
-	}
-	encrypted, err := cryptokey.Encrypt(currentPrivKey)
+	encrypted, changed, err := cryptokey.Refresh(currentPrivKey)
 	if err != nil {
 		log.Warn("re_encrypt_key", slog.String("error", err.Error()))
 		return
 	}
+	if !changed {
+		return // already on the current key
+	}
 	var updateErr error
 	switch keyType {
 	case "self":
@@ -617,7 +889,7 @@ func promptTOTP(db *gorm.DB, user *models.User, log *slog.Logger) bool {
 			return false
 		}
 		code = strings.TrimSpace(code)
-		if totpUtil.Verify(user.TOTPSecret, code) {
+		if totpUtil.VerifyUser(db, log, user, code) {
 			log.Info("mfa_success", slog.String("event", "mfa_totp"), slog.String("user", user.Username))
 			return true
 		}
@@ -646,6 +918,29 @@ func promptTOTP(db *gorm.DB, user *models.User, log *slog.Logger) bool {
 	return false
 }
 
+// resolveJustification returns the justification for a connection through an
+// access that requires one: the --reason value when given, otherwise an answer
+// prompted on stdin when interactive is true.
+func resolveJustification(access models.AccessRight, provided string, interactive bool, log *slog.Logger) (string, error) {
+	if provided != "" {
+		if err := justification.Validate(access.JustificationPattern, provided); err != nil {
+			log.Warn("justification_failure", slog.String("to", access.Source), slog.String("error", err.Error()))
+			return "", fmt.Errorf("⛔ %v", err)
+		}
+		return strings.TrimSpace(provided), nil
+	}
+	if !interactive {
+		log.Warn("justification_failure", slog.String("to", access.Source), slog.String("reason", "missing"))
+		return "", fmt.Errorf("⛔ This access requires a justification: pass %s <reason or ticket ID>", justification.Flag)
+	}
+	answer, err := justification.Prompt(os.Stdin, os.Stdout, access.JustificationPattern, config.Get().MFA.MaxAttempts)
+	if err != nil {
+		log.Warn("justification_failure", slog.String("to", access.Source), slog.String("error", err.Error()))
+		return "", fmt.Errorf("⛔ Access denied: %v", err)
+	}
+	return answer, nil
+}
+
 // ipAllowed checks whether clientIP is permitted by an allowedFrom CIDR list.
 // Empty allowedFrom means unrestricted.
 // Returns false when the clientIP cannot be parsed (fail-closed).
@@ -870,6 +1165,7 @@ func resolveForcedHost(db *gorm.DB, user models.User, forcedHostname string) (mo
 	var groupIDs []uuid.UUID
 	err = db.Model(&models.UserGroup{}).
 		Where("user_id = ?", user.ID).
+		Where(models.MembershipActiveClause, time.Now()).
 		Pluck("group_id", &groupIDs).Error
 	if err != nil {
 		return host, validation.WrapDBError(err, "error retrieving self accesses")
@@ -969,6 +1265,10 @@ func TCPProxy(db *gorm.DB, user models.User, logger slog.Logger, host, port stri
 		log.Warn("mfa_unavailable", slog.String("event", "mfa_totp"), slog.String("reason", "tcp_proxy_jit_mfa"), slog.String("to", access.Source))
 		return fmt.Errorf("⛔ TCP proxy (-W) is unavailable when this access requires JIT MFA. Use an interactive SSH/SFTP flow instead")
 	}
+	if access.JustificationRequired {
+		log.Warn("justification_unavailable", slog.String("reason", "tcp_proxy_justification"), slog.String("to", access.Source))
+		return fmt.Errorf("⛔ TCP proxy (-W) is unavailable when this access requires a justification. Use an SSH/SFTP flow with --reason instead")
+	}
 
 	log.Info("tcp_proxy")
 	if err := tcpProxy.Proxy(host, port); err != nil {
@@ -988,6 +1288,10 @@ func TCPProxy(db *gorm.DB, user models.User, logger slog.Logger, host, port stri
 // SSH host alias used on the client side (e.g. "my-server"), not for the
 // bastion hostname used inside ProxyCommand.
 func SFTPSession(db *gorm.DB, user models.User, logger slog.Logger, params string) error {
+	params, reason, err := justification.Extract(params)
+	if err != nil {
+		return fmt.Errorf("invalid sftp-session command: %w", err)
+	}
 	sshUser, sshHost, sshPort, _, err := parseSSHCommand(params)
 	if err != nil {
 		return fmt.Errorf("invalid sftp-session command: %w", err)
@@ -1037,11 +1341,25 @@ func SFTPSession(db *gorm.DB, user models.User, logger slog.Logger, params strin
 		}
 	}
 
+	// stdin carries the SFTP stream, so the justification must come from --reason.
+	if access.JustificationRequired {
+		answer, err := resolveJustification(access, reason, false, log)
+		if err != nil {
+			return err
+		}
+		access.Justification = answer
+		log = log.With(slog.String("justification", answer))
+	}
+
 	if err := sshConnector.CheckAndUpdateHostKey(db, user, access.Server, access.Port); err != nil {
 		log.Warn("sftp_session", slog.String("reason", "host_key_verification_failed"), slog.String("error", err.Error()))
 		return err
 	}
 
+	if access.BreakGlassID != uuid.Nil {
+		log.Error("break_glass_connect", slog.String("severity", "critical"),
+			slog.String("break_glass_id", access.BreakGlassID.String()), slog.String("to", access.Source))
+	}
 	log.Info("sftp_session", slog.String("to", access.Source))
 	if err = sftpProxy.Proxy(db, access); err != nil {
 		log.Error("sftp_session", slog.String("error", err.Error()))
@@ -1053,64 +1371,73 @@ func SFTPSession(db *gorm.DB, user models.User, logger slog.Logger, params strin
 
 // tcpProxyAccessFilter resolves a policy-compliant access entry for raw TCP proxying (-W).
 // Because SSH payload is opaque in raw tunnel mode, the proxy only accepts accesses declared
-// for protocol=ssh and still enforces TTL, IP CIDR and group JIT MFA policy.
+// for protocol=ssh and still enforces TTL, schedule, IP CIDR and group JIT MFA policy.
 func tcpProxyAccessFilter(db *gorm.DB, log *slog.Logger, user models.User, host string, port int64) (models.AccessRight, error) {
 	now := time.Now()
 	clientIP := system.ClientIPFromEnv()
 
 	var selfAccesses []models.SelfAccess
 	if err := db.Where(
-		"user_id = ? AND server = ? AND port = ? AND protocol = 'ssh' AND (expires_at IS NULL OR expires_at > ?)",
+		"user_id = ? AND "+hostmatch.CandidateClause+" AND port = ? AND protocol = 'ssh' AND (expires_at IS NULL OR expires_at > ?)",
 		user.ID, host, port, now,
-	).Find(&selfAccesses).Error; err != nil {
+	).Where(models.StartedClause, now).Find(&selfAccesses).Error; err != nil {
 		return models.AccessRight{}, fmt.Errorf("⛔ Access denied: failed to resolve personal access: %w", err)
 	}
 
 	var groupAccesses []models.GroupAccess
 	var groupIDs []uuid.UUID
 	var userGroups []models.UserGroup
-	if err := db.Where("user_id = ?", user.ID).Find(&userGroups).Error; err != nil {
+	if err := db.Where("user_id = ?", user.ID).Where(models.MembershipActiveClause, time.Now()).Find(&userGroups).Error; err != nil {
 		return models.AccessRight{}, fmt.Errorf("⛔ Access denied: failed to resolve user groups: %w", err)
 	}
-	groupRoles := make(map[uuid.UUID]string, len(userGroups))
+	groupRoles, err := models.EffectiveGroupRoles(db, userGroups)
+	if err != nil {
+		return models.AccessRight{}, fmt.Errorf("⛔ Access denied: failed to resolve nested groups: %w", err)
+	}
 	hasGuestRole := false
-	for _, ug := range userGroups {
-		groupIDs = append(groupIDs, ug.GroupID)
-		groupRoles[ug.GroupID] = ug.Role
-		if ug.Role == models.GroupRoleGuest {
+	for groupID, role := range groupRoles {
+		groupIDs = append(groupIDs, groupID)
+		if role == models.GroupRoleGuest {
 			hasGuestRole = true
 		}
 	}
 	if len(groupIDs) > 0 {
 		if err := db.Where(
-			"group_id IN ? AND server = ? AND port = ? AND protocol = 'ssh' AND (expires_at IS NULL OR expires_at > ?)",
+			"group_id IN ? AND "+groupServerClause+" AND port = ? AND protocol = 'ssh' AND (expires_at IS NULL OR expires_at > ?)",
 			groupIDs, host, port, now,
-		).Preload("Group").Find(&groupAccesses).Error; err != nil {
+		).Where(models.StartedClause, now).Where(models.GroupAccessMemberClause, user.ID).Preload("Group").Find(&groupAccesses).Error; err != nil {
 			return models.AccessRight{}, fmt.Errorf("⛔ Access denied: failed to resolve group access: %w", err)
 		}
 	}
 
+	selfAccesses = hostmatch.Filter(selfAccesses, host, func(sa models.SelfAccess) string { return sa.Server })
+	groupAccesses = filterGroupAccesses(db, groupAccesses, host)
+
 	if len(selfAccesses) == 0 && len(groupAccesses) == 0 && user.Role == models.RoleAdmin {
 		// Admin override, but still constrained by protocol, TTL and source CIDR.
 		if err := db.Where(
-			"server = ? AND port = ? AND protocol = 'ssh' AND (expires_at IS NULL OR expires_at > ?)",
+			hostmatch.CandidateClause+" AND port = ? AND protocol = 'ssh' AND (expires_at IS NULL OR expires_at > ?)",
 			host, port, now,
-		).Find(&selfAccesses).Error; err != nil {
+		).Where(models.StartedClause, now).Find(&selfAccesses).Error; err != nil {
 			return models.AccessRight{}, fmt.Errorf("⛔ Access denied: failed to resolve admin self access: %w", err)
 		}
 		if err := db.Where(
-			"server = ? AND port = ? AND protocol = 'ssh' AND (expires_at IS NULL OR expires_at > ?)",
+			groupServerClause+" AND port = ? AND protocol = 'ssh' AND (expires_at IS NULL OR expires_at > ?)",
 			host, port, now,
-		).Preload("Group").Find(&groupAccesses).Error; err != nil {
+		).Where(models.StartedClause, now).Preload("Group").Find(&groupAccesses).Error; err != nil {
 			return models.AccessRight{}, fmt.Errorf("⛔ Access denied: failed to resolve admin group access: %w", err)
 		}
+		selfAccesses = hostmatch.Filter(selfAccesses, host, func(sa models.SelfAccess) string { return sa.Server })
+		groupAccesses = filterGroupAccesses(db, groupAccesses, host)
 	}
+	hostmatch.SortBySpecificity(selfAccesses, func(sa models.SelfAccess) string { return sa.Server })
+	hostmatch.SortBySpecificity(groupAccesses, func(ga models.GroupAccess) string { return ga.Server })
 
 	for _, sa := range selfAccesses {
-		if !ipAllowed(clientIP, sa.AllowedFrom) {
+		if !ipAllowed(clientIP, sa.AllowedFrom) || !schedule.Allows(sa.Schedule, now) {
 			continue
 		}
-		access, err := buildSelfAccessRight(db, log, sa, "", "tcp-proxy-self")
+		access, err := buildSelfAccessRight(db, log, sa, host, "", "tcp-proxy-self")
 		if err == nil {
 			return access, nil
 		}
@@ -1121,14 +1448,17 @@ func tcpProxyAccessFilter(db *gorm.DB, log *slog.Logger, user models.User, host
 	if hasGuestRole {
 		var guestGrants []models.GroupGuestAccess
 		if err := db.Where(
-			"user_id = ? AND server = ? AND port = ? AND (expires_at IS NULL OR expires_at > ?)",
+			"user_id = ? AND "+hostmatch.CandidateClause+" AND port = ? AND (expires_at IS NULL OR expires_at > ?)",
 			user.ID, host, port, now,
-		).Find(&guestGrants).Error; err != nil {
+		).Where(models.StartedClause, now).Find(&guestGrants).Error; err != nil {
 			return models.AccessRight{}, fmt.Errorf("error retrieving guest grants: %w", err)
 		}
+		guestGrants = hostmatch.Filter(guestGrants, host, func(gg models.GroupGuestAccess) string { return gg.Server })
 		guestGrantGroupIDs = make(map[uuid.UUID]bool, len(guestGrants))
 		for i := range guestGrants {
-			guestGrantGroupIDs[guestGrants[i].GroupID] = true
+			if schedule.Allows(guestGrants[i].Schedule, now) {
+				guestGrantGroupIDs[guestGrants[i].GroupID] = true
+			}
 		}
 	}
 
@@ -1136,14 +1466,14 @@ func tcpProxyAccessFilter(db *gorm.DB, log *slog.Logger, user models.User, host
 		if hasGuestRole && groupRoles[ga.GroupID] == models.GroupRoleGuest && !guestGrantGroupIDs[ga.GroupID] {
 			continue
 		}
-		if !ipAllowed(clientIP, ga.AllowedFrom) {
+		if !ipAllowed(clientIP, ga.AllowedFrom) || !schedule.Allows(ga.Schedule, now) {
 			continue
 		}
 		reason := "tcp-proxy-group"
 		if user.Role == models.RoleAdmin && len(groupIDs) == 0 {
 			reason = "admin-override-group"
 		}
-		access, err := buildGroupAccessRight(db, log, ga, "", reason)
+		access, err := buildGroupAccessRight(db, log, ga, host, "", reason)
 		if err == nil {
 			return access, nil
 		}
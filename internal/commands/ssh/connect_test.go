@@ -29,8 +29,12 @@ func newTestDB(t *testing.T) *gorm.DB {
 	if err := db.AutoMigrate(
 		&models.User{}, &models.Group{}, &models.UserGroup{},
 		&models.SelfAccess{}, &models.GroupAccess{},
-		&models.SelfEgressKey{}, &models.GroupEgressKey{},
+		&models.SelfEgressKey{}, &models.GroupEgressKey{}, &models.RevokedKey{},
 		&models.Aliases{}, &models.KnownHostsEntry{}, &models.Realm{},
+		&models.BreakGlass{},
+		&models.GroupInclusion{},
+		&models.GroupGuestAccess{},
+		&models.Host{},
 	); err != nil {
 		t.Fatalf("migrate: %v", err)
 	}
@@ -291,6 +295,50 @@ func TestAccessFilter_AdminOverride(t *testing.T) {
 	}
 }
 
+// TestAccessFilter_BreakGlass verifies an active break-glass declaration lets a
+// non-admin use any entry covering the target, and that the resulting access
+// is flagged for forced recording.
+func TestAccessFilter_BreakGlass(t *testing.T) {
+	db := newTestDB(t)
+	oncall := mustCreateUser(t, db, "oncall", models.RoleUser)
+	group := mustCreateGroup(t, db, "infra")
+	mustCreateGroupAccess(t, db, group.ID, "deploy", "targetserver", 22)
+	mustCreateGroupEgressKey(t, db, group.ID)
+
+	t.Setenv("SSH_CLIENT", "")
+
+	if _, err := accessFilter(db, oncall, "deploy", "targetserver", "22", "ssh"); err == nil {
+		t.Fatal("expected access denied without a break-glass declaration")
+	}
+
+	expired := models.BreakGlass{UserID: oncall.ID, Server: "targetserver", Port: 22, Reason: "old", ExpiresAt: time.Now().Add(-time.Minute)}
+	if err := db.Create(&expired).Error; err != nil {
+		t.Fatalf("create break-glass: %v", err)
+	}
+	if _, err := accessFilter(db, oncall, "deploy", "targetserver", "22", "ssh"); err == nil {
+		t.Fatal("expected an expired break-glass to be ignored")
+	}
+
+	bg := models.BreakGlass{UserID: oncall.ID, Server: "targetserver", Port: 22, Reason: "outage", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := db.Create(&bg).Error; err != nil {
+		t.Fatalf("create break-glass: %v", err)
+	}
+	accesses, err := accessFilter(db, oncall, "deploy", "targetserver", "22", "ssh")
+	if err != nil {
+		t.Fatalf("expected break-glass access to succeed: %v", err)
+	}
+	if len(accesses) != 1 || accesses[0].BreakGlassID != bg.ID {
+		t.Fatalf("expected access flagged with break-glass %s, got %+v", bg.ID, accesses)
+	}
+	if accesses[0].Source != "break-glass-group-infra" {
+		t.Errorf("unexpected source %q", accesses[0].Source)
+	}
+
+	if _, err := accessFilter(db, oncall, "deploy", "otherserver", "22", "ssh"); err == nil {
+		t.Fatal("expected break-glass to be limited to its declared server")
+	}
+}
+
 // TestAccessFilter_IPBlocked verifies IP-restricted access returns an actionable error.
 func TestAccessFilter_IPBlocked(t *testing.T) {
 	db := newTestDB(t)
@@ -330,9 +378,196 @@ func TestAccessFilter_ExpiredAccessIgnored(t *testing.T) {
 	}
 }
 
+// TestAccessFilter_PendingAccessIgnored verifies an access whose StartsAt is
+// in the future grants nothing, and no username is inferred from it, until
+// it starts.
+func TestAccessFilter_PendingAccessIgnored(t *testing.T) {
+	db := newTestDB(t)
+	user := mustCreateUser(t, db, "grace", models.RoleUser)
+	mustCreateSelfEgressKey(t, db, user.ID)
+
+	starts := time.Now().Add(24 * time.Hour)
+	sa := models.SelfAccess{
+		UserID: user.ID, Username: "deploy", Server: "myserver",
+		Port: 22, Protocol: "ssh", StartsAt: &starts,
+	}
+	if err := db.Create(&sa).Error; err != nil {
+		t.Fatalf("create pending access: %v", err)
+	}
+	t.Setenv("SSH_CLIENT", "")
+
+	if _, err := accessFilter(db, user, "deploy", "myserver", "22", "ssh"); err == nil {
+		t.Fatal("expected error for pending access, got nil")
+	}
+	if u, ok := inferSSHUsername(db, user, "myserver", 22); ok {
+		t.Fatalf("expected no username inferred from a pending access, got %q", u)
+	}
+
+	started := time.Now().Add(-time.Minute)
+	if err := db.Model(&sa).Update("starts_at", started).Error; err != nil {
+		t.Fatalf("update starts_at: %v", err)
+	}
+	if _, err := accessFilter(db, user, "deploy", "myserver", "22", "ssh"); err != nil {
+		t.Fatalf("expected access once started, got %v", err)
+	}
+}
+
 // --- inferSSHUsername tests ---
 
 // TestInferSSHUsername_NoFallbackToRoot verifies no silent "root" fallback.
+// TestAccessFilter_CIDRAndGlobMatch verifies that CIDR and hostname glob
+// entries match concrete targets and that AccessRight.Server is the target.
+func TestAccessFilter_CIDRAndGlobMatch(t *testing.T) {
+	db := newTestDB(t)
+	user := mustCreateUser(t, db, "carol", models.RoleUser)
+	group := mustCreateGroup(t, db, "dba")
+	mustAddUserToGroup(t, db, user.ID, group.ID, "member")
+
+	mustCreateSelfAccess(t, db, user.ID, "deploy", "10.20.0.0/16", 22)
+	mustCreateGroupAccess(t, db, group.ID, "postgres", "*.db.prod.example", 22)
+	mustCreateSelfEgressKey(t, db, user.ID)
+	mustCreateGroupEgressKey(t, db, group.ID)
+
+	t.Setenv("SSH_CLIENT", "")
+
+	accesses, err := accessFilter(db, user, "deploy", "10.20.3.4", "22", "ssh")
+	if err != nil {
+		t.Fatalf("CIDR: unexpected error: %v", err)
+	}
+	if accesses[0].Type != "self" || accesses[0].Server != "10.20.3.4" {
+		t.Errorf("CIDR: got type=%q server=%q, want self on 10.20.3.4", accesses[0].Type, accesses[0].Server)
+	}
+
+	accesses, err = accessFilter(db, user, "postgres", "pg1.db.prod.example", "22", "ssh")
+	if err != nil {
+		t.Fatalf("glob: unexpected error: %v", err)
+	}
+	if accesses[0].Type != "group" || accesses[0].Server != "pg1.db.prod.example" {
+		t.Errorf("glob: got type=%q server=%q, want group on pg1.db.prod.example", accesses[0].Type, accesses[0].Server)
+	}
+
+	if _, err := accessFilter(db, user, "deploy", "10.21.3.4", "22", "ssh"); err == nil {
+		t.Error("expected denial for an IP outside the CIDR")
+	}
+	if _, err := accessFilter(db, user, "postgres", "pg1.db.staging.example", "22", "ssh"); err == nil {
+		t.Error("expected denial for a host outside the glob")
+	}
+}
+
+// TestAccessFilter_MostSpecificServerWins verifies that within the same score
+// an exact host beats a narrow CIDR, which beats a wider CIDR.
+func TestAccessFilter_MostSpecificServerWins(t *testing.T) {
+	db := newTestDB(t)
+	user := mustCreateUser(t, db, "dave", models.RoleUser)
+	wide := mustCreateGroup(t, db, "wide")
+	narrow := mustCreateGroup(t, db, "narrow")
+	exact := mustCreateGroup(t, db, "exact")
+	for _, g := range []models.Group{wide, narrow, exact} {
+		mustAddUserToGroup(t, db, user.ID, g.ID, "member")
+		mustCreateGroupEgressKey(t, db, g.ID)
+	}
+
+	mustCreateGroupAccess(t, db, wide.ID, "deploy", "10.0.0.0/8", 22)
+	mustCreateGroupAccess(t, db, narrow.ID, "deploy", "10.20.0.0/16", 22)
+
+	t.Setenv("SSH_CLIENT", "")
+
+	accesses, err := accessFilter(db, user, "deploy", "10.20.3.4", "22", "ssh")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if accesses[0].Source != "group-narrow" {
+		t.Errorf("narrower CIDR should win: got source=%q", accesses[0].Source)
+	}
+
+	mustCreateGroupAccess(t, db, exact.ID, "deploy", "10.20.3.4", 22)
+	accesses, err = accessFilter(db, user, "deploy", "10.20.3.4", "22", "ssh")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if accesses[0].Source != "group-exact" {
+		t.Errorf("exact host should win over CIDR: got source=%q", accesses[0].Source)
+	}
+}
+
+// TestInferSSHUsername_PatternMatch verifies username inference through a
+// CIDR entry, preferring the most specific server.
+func TestInferSSHUsername_PatternMatch(t *testing.T) {
+	db := newTestDB(t)
+	user := mustCreateUser(t, db, "erin", models.RoleUser)
+
+	mustCreateSelfAccess(t, db, user.ID, "wide", "10.0.0.0/8", 22)
+	mustCreateSelfAccess(t, db, user.ID, "narrow", "10.20.0.0/16", 22)
+
+	resolved, ok := inferSSHUsername(db, user, "10.20.3.4", 22)
+	if !ok {
+		t.Fatal("expected resolution, got false")
+	}
+	if resolved != "narrow" {
+		t.Errorf("expected 'narrow', got %q", resolved)
+	}
+}
+
+// closedScheduleNow returns a one-day schedule that does not include the current UTC day.
+func closedScheduleNow() string {
+	return time.Now().UTC().Add(48*time.Hour).Format("Mon") + " 00:00-24:00 UTC"
+}
+
+// TestAccessFilter_ScheduleClosedDenied verifies that an entry outside its
+// window is refused with a message naming the window.
+func TestAccessFilter_ScheduleClosedDenied(t *testing.T) {
+	db := newTestDB(t)
+	user := mustCreateUser(t, db, "frank", models.RoleUser)
+	group := mustCreateGroup(t, db, "contractors")
+	mustAddUserToGroup(t, db, user.ID, group.ID, "member")
+	mustCreateGroupEgressKey(t, db, group.ID)
+
+	ga := models.GroupAccess{GroupID: group.ID, Username: "deploy", Server: "myserver", Port: 22, Protocol: "ssh", Schedule: closedScheduleNow()}
+	if err := db.Create(&ga).Error; err != nil {
+		t.Fatalf("create group access: %v", err)
+	}
+
+	t.Setenv("SSH_CLIENT", "")
+
+	_, err := accessFilter(db, user, "deploy", "myserver", "22", "ssh")
+	if err == nil {
+		t.Fatal("expected schedule denial, got nil")
+	}
+	if !strings.Contains(err.Error(), "only allowed during "+ga.Schedule) {
+		t.Errorf("denial should name the window, got: %v", err)
+	}
+}
+
+// TestAccessFilter_ScheduleFallsBackToOpenEntry verifies that a closed
+// higher-priority entry yields to a lower-priority entry whose window is open.
+func TestAccessFilter_ScheduleFallsBackToOpenEntry(t *testing.T) {
+	db := newTestDB(t)
+	user := mustCreateUser(t, db, "gina", models.RoleUser)
+	group := mustCreateGroup(t, db, "ops")
+	mustAddUserToGroup(t, db, user.ID, group.ID, "member")
+	mustCreateSelfEgressKey(t, db, user.ID)
+	mustCreateGroupEgressKey(t, db, group.ID)
+
+	sa := models.SelfAccess{UserID: user.ID, Username: "deploy", Server: "myserver", Port: 22, Protocol: "ssh", Schedule: closedScheduleNow()}
+	if err := db.Create(&sa).Error; err != nil {
+		t.Fatalf("create self access: %v", err)
+	}
+	ga := models.GroupAccess{GroupID: group.ID, Username: "deploy", Server: "myserver", Port: 22, Protocol: "ssh", Schedule: "* 00:00-24:00"}
+	if err := db.Create(&ga).Error; err != nil {
+		t.Fatalf("create group access: %v", err)
+	}
+
+	t.Setenv("SSH_CLIENT", "")
+
+	accesses, err := accessFilter(db, user, "deploy", "myserver", "22", "ssh")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if accesses[0].Type != "group" {
+		t.Errorf("expected open group entry, got type=%q", accesses[0].Type)
+	}
+}
+
 func TestInferSSHUsername_NoFallbackToRoot(t *testing.T) {
 	db := newTestDB(t)
 	user := mustCreateUser(t, db, "grace", models.RoleUser)
@@ -362,6 +597,31 @@ func TestInferSSHUsername_ExactBeforeWildcard(t *testing.T) {
 	}
 }
 
+// TestInferSSHUsername_SkipsClosedSchedule verifies no username is inferred
+// from an access outside its schedule window, which accessFilter would deny.
+func TestInferSSHUsername_SkipsClosedSchedule(t *testing.T) {
+	db := newTestDB(t)
+	user := mustCreateUser(t, db, "ivy", models.RoleUser)
+	group := mustCreateGroup(t, db, "ops")
+	mustAddUserToGroup(t, db, user.ID, group.ID, "member")
+
+	closed := time.Now().UTC().Add(48*time.Hour).Format("Mon") + " 00:00-24:00 UTC"
+	if err := db.Create(&models.SelfAccess{UserID: user.ID, Username: "night", Server: "myserver", Port: 22, Protocol: "ssh", Schedule: closed}).Error; err != nil {
+		t.Fatalf("create self access: %v", err)
+	}
+	if err := db.Create(&models.GroupAccess{GroupID: group.ID, Username: "night", Server: "myserver", Port: 22, Protocol: "ssh", Schedule: closed}).Error; err != nil {
+		t.Fatalf("create group access: %v", err)
+	}
+	if u, ok := inferSSHUsername(db, user, "myserver", 22); ok {
+		t.Fatalf("expected no username inferred outside the access window, got %q", u)
+	}
+
+	mustCreateGroupAccess(t, db, group.ID, "deploy", "myserver", 22)
+	if u, ok := inferSSHUsername(db, user, "myserver", 22); !ok || u != "deploy" {
+		t.Fatalf("expected the open group access to be used, got %q, %v", u, ok)
+	}
+}
+
 // --- normalizeWildcardUsername tests ---
 
 func TestNormalizeWildcardUsername(t *testing.T) {
@@ -428,8 +688,314 @@ func TestTCPProxyRejectsJITMFAProtectedAccess(t *testing.T) {
 	}
 }
 
+func TestAccessFilter_NestedGroupInheritance(t *testing.T) {
+	db := newTestDB(t)
+	alice := mustCreateUser(t, db, "alice", models.RoleUser)
+	guest := mustCreateUser(t, db, "guest", models.RoleUser)
+	infra := mustCreateGroup(t, db, "infra")
+	infraDB := mustCreateGroup(t, db, "infra-db")
+	if err := db.Create(&models.GroupInclusion{ParentID: infra.ID, ChildID: infraDB.ID}).Error; err != nil {
+		t.Fatalf("create inclusion: %v", err)
+	}
+	mustAddUserToGroup(t, db, alice.ID, infraDB.ID, "member")
+	mustAddUserToGroup(t, db, guest.ID, infraDB.ID, "guest")
+	mustCreateGroupAccess(t, db, infra.ID, "deploy", "myserver", 22)
+	mustCreateGroupEgressKey(t, db, infra.ID)
+
+	t.Setenv("SSH_CLIENT", "")
+
+	accesses, err := accessFilter(db, alice, "deploy", "myserver", "22", "ssh")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(accesses) != 1 || accesses[0].Source != "group-infra" {
+		t.Fatalf("expected access inherited from infra, got %+v", accesses)
+	}
+
+	if accesses, err = accessFilter(db, guest, "deploy", "myserver", "22", "ssh"); err == nil {
+		t.Fatalf("guests of the child group must not inherit, got %+v", accesses)
+	}
+}
+
+func TestAccessFilter_TagSelectorResolvesInventory(t *testing.T) {
+	db := newTestDB(t)
+	alice := mustCreateUser(t, db, "alice", models.RoleUser)
+	web := mustCreateGroup(t, db, "web")
+	mustAddUserToGroup(t, db, alice.ID, web.ID, "member")
+	mustCreateGroupAccess(t, db, web.ID, "deploy", "tag:env=prod,role=web", 22)
+	mustCreateGroupEgressKey(t, db, web.ID)
+	for _, h := range []models.Host{
+		{Name: "web01", Addresses: "10.0.0.5,web01.example.com", Tags: "env=prod,role=web"},
+		{Name: "web-staging", Addresses: "10.0.1.5", Tags: "env=staging,role=web"},
+	} {
+		if err := db.Create(&h).Error; err != nil {
+			t.Fatalf("create host: %v", err)
+		}
+	}
+
+	t.Setenv("SSH_CLIENT", "")
+
+	for _, target := range []string{"10.0.0.5", "web01", "WEB01.example.com"} {
+		accesses, err := accessFilter(db, alice, "deploy", target, "22", "ssh")
+		if err != nil {
+			t.Fatalf("accessFilter(%s): %v", target, err)
+		}
+		if len(accesses) != 1 || accesses[0].Source != "group-web" {
+			t.Fatalf("accessFilter(%s): expected the tag grant, got %+v", target, accesses)
+		}
+	}
+	for _, target := range []string{"10.0.1.5", "10.0.0.6"} {
+		if accesses, err := accessFilter(db, alice, "deploy", target, "22", "ssh"); err == nil {
+			t.Fatalf("accessFilter(%s): expected denial, got %+v", target, accesses)
+		}
+	}
+}
+
+// TestEvaluateAccess_ExplainsExclusions verifies that every entry covering
+// the host is reported with the rule that excluded it.
+func TestEvaluateAccess_ExplainsExclusions(t *testing.T) {
+	db := newTestDB(t)
+	user := mustCreateUser(t, db, "ivan", models.RoleUser)
+	group := mustCreateGroup(t, db, "ops")
+	mustAddUserToGroup(t, db, user.ID, group.ID, "member")
+	mustCreateGroupEgressKey(t, db, group.ID)
+
+	mustCreateGroupAccess(t, db, group.ID, "deploy", "myserver", 22)
+	mustCreateSelfAccess(t, db, user.ID, "deploy", "myserver", 2222)
+	mustCreateSelfAccess(t, db, user.ID, "backup", "myserver", 22)
+	expired := time.Now().Add(-time.Hour)
+	if err := db.Create(&models.SelfAccess{UserID: user.ID, Username: "deploy", Server: "myserver", Port: 22, Protocol: "ssh", ExpiresAt: &expired}).Error; err != nil {
+		t.Fatalf("create expired access: %v", err)
+	}
+	if err := db.Create(&models.SelfAccess{UserID: user.ID, Username: "deploy", Server: "myserver", Port: 22, Protocol: "sftp"}).Error; err != nil {
+		t.Fatalf("create sftp access: %v", err)
+	}
+
+	eval, err := evaluateAccess(db, user, "deploy", "myserver", 22, "ssh", "", time.Now())
+	if err != nil {
+		t.Fatalf("evaluateAccess: %v", err)
+	}
+	if eval.denial != nil {
+		t.Fatalf("expected access, got denial: %v", eval.denial)
+	}
+	if got := eval.candidates[eval.best]; got.groupAccess == nil || got.reason != "group-exact" {
+		t.Fatalf("expected the group entry to be selected, got %+v", got)
+	}
+
+	var excluded []string
+	for i, c := range eval.candidates {
+		if i != eval.best {
+			excluded = append(excluded, c.excluded)
+		}
+	}
+	for _, want := range []string{"port mismatch", "username mismatch", "expired on", "protocol restricted to sftp"} {
+		found := false
+		for _, e := range excluded {
+			if strings.Contains(e, want) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected an exclusion containing %q, got %q", want, excluded)
+		}
+	}
+}
+
+// TestEvaluateAccess_MemberRestrictedGroupAccess verifies that a group access
+// restricted to one member, as created by an approved access request, is not
+// usable by the other members of the group.
+func TestEvaluateAccess_MemberRestrictedGroupAccess(t *testing.T) {
+	db := newTestDB(t)
+	alice := mustCreateUser(t, db, "alice", models.RoleUser)
+	bob := mustCreateUser(t, db, "bob", models.RoleUser)
+	group := mustCreateGroup(t, db, "ops")
+	mustAddUserToGroup(t, db, alice.ID, group.ID, "member")
+	mustAddUserToGroup(t, db, bob.ID, group.ID, "member")
+	mustCreateGroupEgressKey(t, db, group.ID)
+	if err := db.Create(&models.GroupAccess{GroupID: group.ID, Username: "deploy", Server: "myserver", Port: 22, Protocol: "ssh", MemberID: &alice.ID}).Error; err != nil {
+		t.Fatalf("create member access: %v", err)
+	}
+
+	eval, err := evaluateAccess(db, alice, "deploy", "myserver", 22, "ssh", "", time.Now())
+	if err != nil || eval.denial != nil {
+		t.Fatalf("expected the member to be granted, got %v / %v", err, eval.denial)
+	}
+	eval, err = evaluateAccess(db, bob, "deploy", "myserver", 22, "ssh", "", time.Now())
+	if err != nil {
+		t.Fatalf("evaluateAccess: %v", err)
+	}
+	if eval.denial == nil {
+		t.Fatal("expected another member to be denied")
+	}
+	if u, ok := inferSSHUsername(db, bob, "myserver", 22); ok {
+		t.Fatalf("expected no username inferred for another member, got %q", u)
+	}
+}
+
+// TestAccountExplainAccess_ParsesTargetAroundFlags verifies the positional
+// target is accepted before or after the flags.
+func TestAccountExplainAccess_ParsesTargetAroundFlags(t *testing.T) {
+	db := newTestDB(t)
+	admin := mustCreateUser(t, db, "root-admin", models.RoleAdmin)
+	user := mustCreateUser(t, db, "judy", models.RoleUser)
+	mustCreateSelfAccess(t, db, user.ID, "deploy", "myserver", 22)
+
+	for _, args := range [][]string{
+		{"--user", "judy", "deploy@myserver:22"},
+		{"deploy@myserver", "--user", "judy", "--from-ip", "10.0.0.1", "--protocol", "ssh"},
+	} {
+		if err := AccountExplainAccess(db, &admin, args); err != nil {
+			t.Errorf("AccountExplainAccess(%q): %v", args, err)
+		}
+	}
+	if err := AccountExplainAccess(db, &admin, []string{"deploy@myserver"}); err == nil {
+		t.Error("expected an error without --user")
+	}
+	if err := AccountExplainAccess(db, &admin, []string{"--user", "nobody", "deploy@myserver"}); err == nil {
+		t.Error("expected an error for an unknown user")
+	}
+}
+
+func mustRequireJustification(t *testing.T, db *gorm.DB, group *models.Group, pattern string) {
+	t.Helper()
+	group.JustificationRequired = true
+	group.JustificationPattern = pattern
+	if err := db.Save(group).Error; err != nil {
+		t.Fatalf("save group: %v", err)
+	}
+}
+
+func TestAccessFilter_GroupJustificationPolicy(t *testing.T) {
+	db := newTestDB(t)
+	user := mustCreateUser(t, db, "alice", models.RoleUser)
+	group := mustCreateGroup(t, db, "prod")
+	mustRequireJustification(t, db, &group, "CHG-[0-9]+")
+	mustAddUserToGroup(t, db, user.ID, group.ID, "member")
+	mustCreateGroupAccess(t, db, group.ID, "deploy", "myserver", 22)
+	mustCreateGroupEgressKey(t, db, group.ID)
+
+	t.Setenv("SSH_CLIENT", "")
+
+	accesses, err := accessFilter(db, user, "deploy", "myserver", "22", "ssh")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(accesses) != 1 || !accesses[0].JustificationRequired || accesses[0].JustificationPattern != "CHG-[0-9]+" {
+		t.Fatalf("justification policy not propagated: %+v", accesses)
+	}
+}
+
+func TestSFTPSessionRequiresValidReason(t *testing.T) {
+	db := newTestDB(t)
+	user := mustCreateUser(t, db, "alice", models.RoleUser)
+	group := mustCreateGroup(t, db, "prod")
+	mustRequireJustification(t, db, &group, "CHG-[0-9]+")
+	mustAddUserToGroup(t, db, user.ID, group.ID, "member")
+	mustCreateGroupAccess(t, db, group.ID, "deploy", "myserver", 22)
+	mustCreateGroupEgressKey(t, db, group.ID)
+
+	t.Setenv("SSH_CLIENT", "")
+
+	var logBuf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logBuf, nil))
+
+	err := SFTPSession(db, user, *logger, "deploy@myserver:22")
+	if err == nil || !strings.Contains(err.Error(), "--reason") {
+		t.Fatalf("expected missing --reason error, got %v", err)
+	}
+	err = SFTPSession(db, user, *logger, "deploy@myserver:22 --reason fixing-stuff")
+	if err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("expected pattern mismatch error, got %v", err)
+	}
+	if findLogEntry(parseJSONLogLines(t, &logBuf), "justification_failure", func(map[string]any) bool { return true }) == nil {
+		t.Fatal("expected justification_failure log entry")
+	}
+}
+
+func TestTCPProxyRejectsJustificationProtectedAccess(t *testing.T) {
+	db := newTestDB(t)
+	user := mustCreateUser(t, db, "alice", models.RoleUser)
+	group := mustCreateGroup(t, db, "prod")
+	mustRequireJustification(t, db, &group, "")
+	mustAddUserToGroup(t, db, user.ID, group.ID, "member")
+	mustCreateGroupAccess(t, db, group.ID, "deploy", "myserver", 22)
+	mustCreateGroupEgressKey(t, db, group.ID)
+
+	err := TCPProxy(db, user, *slog.Default(), "myserver", "22")
+	if err == nil || !strings.Contains(err.Error(), "requires a justification") {
+		t.Fatalf("expected TCPProxy to reject justification protected access, got %v", err)
+	}
+}
+
 // --- parseSSHCommand tests ---
 
+// TestAccessFilter_GroupKeysByState verifies that during a rotation the
+// active key is offered first, the next and retiring keys after it, and
+// retired keys never.
+func TestAccessFilter_GroupKeysByState(t *testing.T) {
+	db := newTestDB(t)
+	user := mustCreateUser(t, db, "alice", models.RoleUser)
+	group := mustCreateGroup(t, db, "ops")
+	mustAddUserToGroup(t, db, user.ID, group.ID, "member")
+	mustCreateGroupAccess(t, db, group.ID, "deploy", "myserver", 22)
+	for _, k := range []models.GroupEgressKey{
+		{PrivKey: "next", State: models.EgressKeyNext},
+		{PrivKey: "retired", State: models.EgressKeyRetired},
+		{PrivKey: "active", State: models.EgressKeyActive},
+		{PrivKey: "retiring", State: models.EgressKeyRetiring},
+	} {
+		k.GroupID, k.PubKey, k.Type, k.Size, k.Fingerprint = group.ID, "pub-"+k.PrivKey, "ed25519", 256, k.PrivKey
+		if err := db.Create(&k).Error; err != nil {
+			t.Fatalf("create group egress key: %v", err)
+		}
+	}
+
+	t.Setenv("SSH_CLIENT", "")
+
+	accesses, err := accessFilter(db, user, "deploy", "myserver", "22", "ssh")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := append([]string{accesses[0].PrivateKey}, accesses[0].FallbackKeys...)
+	if strings.Join(got, ",") != "active,next,retiring" {
+		t.Fatalf("unexpected key order %v", got)
+	}
+	if accesses[0].KeyFingerprint != "active" {
+		t.Errorf("the access should report the active key, got %q", accesses[0].KeyFingerprint)
+	}
+}
+
+func TestAccessFilter_RevokedGroupKeySkipped(t *testing.T) {
+	db := newTestDB(t)
+	user := mustCreateUser(t, db, "alice", models.RoleUser)
+	group := mustCreateGroup(t, db, "ops")
+	mustAddUserToGroup(t, db, user.ID, group.ID, "member")
+	mustCreateGroupAccess(t, db, group.ID, "deploy", "myserver", 22)
+	for _, k := range []models.GroupEgressKey{
+		{PrivKey: "active", State: models.EgressKeyActive},
+		{PrivKey: "next", State: models.EgressKeyNext},
+	} {
+		k.GroupID, k.PubKey, k.Type, k.Size, k.Fingerprint = group.ID, "pub-"+k.PrivKey, "ed25519", 256, k.PrivKey
+		if err := db.Create(&k).Error; err != nil {
+			t.Fatalf("create group egress key: %v", err)
+		}
+	}
+	if err := db.Create(&models.RevokedKey{Fingerprint: "active", Reason: "leaked", AddedByID: user.ID}).Error; err != nil {
+		t.Fatalf("revoke key: %v", err)
+	}
+
+	t.Setenv("SSH_CLIENT", "")
+
+	accesses, err := accessFilter(db, user, "deploy", "myserver", "22", "ssh")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := append([]string{accesses[0].PrivateKey}, accesses[0].FallbackKeys...)
+	if strings.Join(got, ",") != "next" {
+		t.Fatalf("the revoked key must not be offered, got %v", got)
+	}
+}
+
 func TestParseSSHCommand(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -0,0 +1,279 @@
+package ssh
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/console"
+	"goBastion/internal/utils/dbConnector"
+	"goBastion/internal/utils/system"
+	"goBastion/internal/utils/validation"
+
+	"gorm.io/gorm"
+)
+
+// SelfExplainAccess shows how accessFilter (or the database resolver with
+// --db) decides on a target for the current user, without connecting.
+func SelfExplainAccess(db *gorm.DB, currentUser *models.User, args []string) error {
+	return explainAccess(db, currentUser, "selfExplainAccess", system.ClientIPFromEnv(), args)
+}
+
+// AccountExplainAccess is SelfExplainAccess on behalf of another account.
+// The client address defaults to "unknown", so --from restrictions fail
+// unless --from-ip simulates one.
+func AccountExplainAccess(db *gorm.DB, currentUser *models.User, args []string) error {
+	// Only --user and --from-ip are ours; the rest is handed to explainAccess.
+	var username, fromIP string
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch a := args[i]; {
+		case (a == "--user" || a == "-user") && i+1 < len(args):
+			username = args[i+1]
+			i++
+		case (a == "--from-ip" || a == "-from-ip") && i+1 < len(args):
+			fromIP = args[i+1]
+			i++
+		case strings.HasPrefix(a, "--user="):
+			username = strings.TrimPrefix(a, "--user=")
+		case strings.HasPrefix(a, "--from-ip="):
+			fromIP = strings.TrimPrefix(a, "--from-ip=")
+		default:
+			rest = append(rest, a)
+		}
+	}
+	if strings.TrimSpace(username) == "" {
+		explainUsage("accountExplainAccess --user <username> [--from-ip <ip>] <user@host[:port]> [--protocol <protocol>] | --db <target>")
+		return fmt.Errorf("missing required arguments")
+	}
+
+	var subject models.User
+	if err := db.Where("username = ?", strings.ToLower(strings.TrimSpace(username))).First(&subject).Error; err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Explain Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Not Found", Body: []string{fmt.Sprintf("User '%s' not found.", username)}}},
+		})
+		return err
+	}
+	if fromIP == "" {
+		fromIP = "unknown"
+	}
+	return explainAccess(db, &subject, "accountExplainAccess", fromIP, rest)
+}
+
+// explainAccess parses the target and prints the evaluation for subject.
+func explainAccess(db *gorm.DB, subject *models.User, cmd, clientIP string, args []string) error {
+	usage := cmd + " <user@host[:port]> [--protocol <protocol>] | --db <target> [--mysql|--pg|--redis] [--dbname <name>]"
+	if cmd == "accountExplainAccess" {
+		usage = "accountExplainAccess --user <username> [--from-ip <ip>] <user@host[:port]> [--protocol <protocol>] | --db <target>"
+	}
+
+	// --db takes the rest of the line with the same syntax as a database
+	// session, whose own flags (--pg, --dbname…) are not ours to parse.
+	for i, a := range args {
+		if a == "--db" || a == "-db" {
+			if i+1 >= len(args) || args[i+1] == "" {
+				explainUsage(usage)
+				return fmt.Errorf("missing required arguments")
+			}
+			extra := append(append([]string{}, args[:i]...), args[i+2:]...)
+			return explainDBAccess(db, subject, args[i+1], clientIP, extra)
+		}
+	}
+
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	var protocol string
+	fs.StringVar(&protocol, "protocol", "ssh", "Protocol: ssh, scpupload, scpdownload, sftp, rsync")
+	var flagOutput bytes.Buffer
+	fs.SetOutput(&flagOutput)
+
+	target, rest := splitExplainTarget(args)
+	if err := fs.Parse(rest); err != nil {
+		explainUsage(usage)
+		return err
+	}
+	if target == "" || !validation.IsValidProtocol(protocol) {
+		explainUsage(usage)
+		return fmt.Errorf("missing required arguments")
+	}
+
+	username, host, port, _, err := parseSSHCommand(target)
+	if err != nil {
+		explainUsage(usage)
+		return err
+	}
+	portInt, _ := strconv.ParseInt(port, 10, 64)
+	request := []string{
+		fmt.Sprintf("Account: %s", subject.Username),
+		fmt.Sprintf("Client IP: %s", clientIP),
+		fmt.Sprintf("Protocol: %s", protocol),
+	}
+	if username == "" {
+		resolved, ok := inferSSHUsername(db, *subject, host, portInt)
+		if !ok {
+			console.DisplayBlock(console.ContentBlock{
+				Title:     "Explain Access",
+				BlockType: "error",
+				Sections: []console.SectionContent{
+					{SubTitle: "Request", Body: request},
+					{SubTitle: "Decision", Body: []string{fmt.Sprintf("No SSH username could be resolved for %s:%s — specify one (user@%s).", host, port, host)}},
+				},
+			})
+			return nil
+		}
+		username = resolved
+		request = append(request, "Username: inferred from the stored accesses")
+	}
+	request = append([]string{fmt.Sprintf("Target: %s@%s:%s", username, host, port)}, request...)
+
+	eval, err := evaluateAccess(db, *subject, username, host, portInt, protocol, clientIP, time.Now())
+	if err != nil {
+		console.DisplayBlock(console.ContentBlock{
+			Title:     "Explain Access",
+			BlockType: "error",
+			Sections:  []console.SectionContent{{SubTitle: "Error", Body: []string{err.Error()}}},
+		})
+		return err
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "Score\tReason\tSource\tEntry\tResult")
+	for i, c := range eval.candidates {
+		source, entry := "personal", ""
+		if c.selfAccess != nil {
+			sa := c.selfAccess
+			entry = fmt.Sprintf("%s@%s:%d (%s)", sa.Username, sa.Server, sa.Port, sa.Protocol)
+		} else {
+			ga := c.groupAccess
+			source = "group " + ga.Group.Name
+			entry = fmt.Sprintf("%s@%s:%d (%s)", ga.Username, ga.Server, ga.Port, ga.Protocol)
+		}
+		result := "selected"
+		if i != eval.best {
+			result = "excluded: " + c.excluded
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", c.score, c.reason, source, entry, result)
+	}
+	_ = w.Flush()
+
+	sections := []console.SectionContent{{SubTitle: "Request", Body: request}}
+	if len(eval.candidates) > 0 {
+		sections = append(sections, console.SectionContent{SubTitle: "Candidates (best first)", Body: strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")})
+	} else {
+		sections = append(sections, console.SectionContent{SubTitle: "Candidates", Body: []string{"No access entry covers this host."}})
+	}
+
+	blockType := "success"
+	var decision []string
+	if eval.denial != nil {
+		blockType = "warning"
+		decision = []string{"Denied: " + eval.denial.Error()}
+	} else {
+		best := eval.candidates[eval.best]
+		decision = []string{fmt.Sprintf("Allowed through %s (score %d).", best.reason, best.score)}
+		if eval.breakGlass != nil && best.score == scoreAdminOverride {
+			decision = append(decision, "Reached through an active break-glass declaration: the session is recorded.")
+		}
+		if g := best.groupAccess; g != nil {
+			if g.Group.MFARequired {
+				decision = append(decision, "The group requires a TOTP check at connection time.")
+			}
+			if g.Group.JustificationRequired {
+				decision = append(decision, "The group requires a justification (--reason) at connection time.")
+			}
+		}
+	}
+	sections = append(sections, console.SectionContent{SubTitle: "Decision", Body: decision})
+
+	console.DisplayBlock(console.ContentBlock{Title: "Explain Access", BlockType: blockType, Sections: sections})
+	return nil
+}
+
+// explainDBAccess prints the database resolver trace for target.
+func explainDBAccess(db *gorm.DB, subject *models.User, target, clientIP string, extraArgs []string) error {
+	access, details, err := dbConnector.ExplainTarget(db, *subject, target, clientIP, extraArgs...)
+
+	request := []string{
+		fmt.Sprintf("Target: %s", target),
+		fmt.Sprintf("Account: %s", subject.Username),
+		fmt.Sprintf("Client IP: %s", clientIP),
+	}
+	if details.AliasResolved {
+		request = append(request, fmt.Sprintf("Alias: %s → %s:%d (%s)", details.AliasName, details.AliasHost, details.AliasPort, details.AliasProtocol))
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "Score\tReason\tSource\tEntry\tResult")
+	for _, c := range details.Candidates {
+		entry := fmt.Sprintf("%s@%s:%d (%s)", c.Username, c.Host, c.Port, c.Protocol)
+		if c.Database != "" {
+			entry += " db=" + c.Database
+		}
+		result := "retained"
+		switch {
+		case c.Selected:
+			result = "selected"
+		case c.Excluded != "":
+			result = "excluded: " + c.Excluded
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", c.Score, c.Reason, c.Source, entry, result)
+	}
+	_ = w.Flush()
+
+	sections := []console.SectionContent{{SubTitle: "Request", Body: request}}
+	if len(details.Candidates) > 0 {
+		sections = append(sections, console.SectionContent{SubTitle: "Candidates (best first)", Body: strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")})
+	} else {
+		sections = append(sections, console.SectionContent{SubTitle: "Candidates", Body: []string{"No database access entry covers this target."}})
+	}
+
+	blockType := "success"
+	decision := []string{fmt.Sprintf("Allowed through %s: %s@%s:%d (%s).", access.Source, access.Username, access.Host, access.Port, access.Protocol)}
+	if err != nil {
+		blockType = "warning"
+		decision = []string{"Denied: " + err.Error()}
+	} else if access.JustificationRequired {
+		decision = append(decision, "The group requires a justification (--reason) at connection time.")
+	}
+	sections = append(sections, console.SectionContent{SubTitle: "Decision", Body: decision})
+
+	console.DisplayBlock(console.ContentBlock{Title: "Explain Database Access", BlockType: blockType, Sections: sections})
+	return nil
+}
+
+// splitExplainTarget pulls the positional target out of args, wherever it
+// sits relative to the flags.
+func splitExplainTarget(args []string) (string, []string) {
+	valueFlags := map[string]bool{"--protocol": true, "-protocol": true}
+	var rest []string
+	target := ""
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case valueFlags[a] && i+1 < len(args):
+			rest = append(rest, a, args[i+1])
+			i++
+		case strings.HasPrefix(a, "-") || target != "":
+			rest = append(rest, a)
+		default:
+			target = a
+		}
+	}
+	return target, rest
+}
+
+func explainUsage(usage string) {
+	console.DisplayBlock(console.ContentBlock{
+		Title:     "Explain Access",
+		BlockType: "error",
+		Sections:  []console.SectionContent{{SubTitle: "Usage", Body: []string{"Usage: " + usage}}},
+	})
+}
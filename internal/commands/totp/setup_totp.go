@@ -77,7 +77,11 @@ func SetupTOTP(db *gorm.DB, user *models.User, log *slog.Logger) error {
 		return nil
 	}
 
-	user.TOTPSecret = secret
+	sealed, err := totp.SealSecret(secret)
+	if err != nil {
+		return err
+	}
+	user.TOTPSecret = sealed
 	user.TOTPEnabled = true
 	if err := db.Save(user).Error; err != nil {
 		return fmt.Errorf("failed to save TOTP settings: %w", err)
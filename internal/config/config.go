@@ -111,9 +111,14 @@ type Config struct {
 	SelfMFA          SelfMFAConfig          `json:"self_mfa" toml:"self_mfa"`
 	SelfPassword     SelfPasswordConfig     `json:"self_password" toml:"self_password"`
 	BackupCodes      BackupCodesConfig      `json:"backup_codes" toml:"backup_codes"`
+	AccessRequests   AccessRequestsConfig   `json:"access_requests" toml:"access_requests"`
+	BreakGlass       BreakGlassConfig       `json:"break_glass" toml:"break_glass"`
 
 	// Connection policy.
 	DenyRootTarget DenyRootTargetConfig `json:"deny_root_target" toml:"deny_root_target"`
+
+	// Key policy.
+	IngressKeyPolicy IngressKeyPolicyConfig `json:"ingress_key_policy" toml:"ingress_key_policy"`
 }
 
 type DatabaseConfig struct {
@@ -148,6 +153,18 @@ type SSHConfig struct {
 	DefaultPort    int64    `json:"default_port" toml:"default_port"`
 	HostKeyTTL     Duration `json:"host_key_ttl" toml:"host_key_ttl"`
 	KeyscanTimeout Duration `json:"keyscan_timeout" toml:"keyscan_timeout"`
+	// UserCertValidity is the lifetime of the certificates the user CA
+	// issues for egress connections (0 = no certificate).
+	UserCertValidity Duration `json:"user_cert_validity" toml:"user_cert_validity"`
+	// HostCertValidity is the lifetime of the host certificates of the sshd
+	// and SFTP proxy keys; they are renewed at the sync after half of it.
+	HostCertValidity Duration `json:"host_cert_validity" toml:"host_cert_validity"`
+	// HostCertPrincipals lists, comma-separated, the names clients use for
+	// the bastion, written in the sshd host certificates ("" = any name).
+	HostCertPrincipals string `json:"host_cert_principals" toml:"host_cert_principals"`
+	// RevokedKeysFile is where the sync writes the revoked keys as an
+	// OpenSSH KRL for sshd's RevokedKeys ("" = no KRL).
+	RevokedKeysFile string `json:"revoked_keys_file" toml:"revoked_keys_file"`
 }
 
 type MFAConfig struct {
@@ -316,10 +333,37 @@ type BackupCodesConfig struct {
 	Enabled bool `json:"enabled" toml:"enabled"`
 }
 
+// AccessRequestsConfig controls just-in-time access requests. MaxDuration caps
+// the lifetime of the access created on approval.
+type AccessRequestsConfig struct {
+	Enabled     bool     `json:"enabled" toml:"enabled"`
+	MaxDuration Duration `json:"max_duration" toml:"max_duration"`
+}
+
+// BreakGlassConfig controls emergency access. MaxDuration caps how long a
+// breakGlass declaration keeps the target reachable.
+type BreakGlassConfig struct {
+	Enabled     bool     `json:"enabled" toml:"enabled"`
+	MaxDuration Duration `json:"max_duration" toml:"max_duration"`
+}
+
 type DenyRootTargetConfig struct {
 	Enabled bool `json:"enabled" toml:"enabled"`
 }
 
+// IngressKeyPolicyConfig restricts the ingress keys accounts may add. The
+// sync reports the existing keys that break it and, with DisableViolating,
+// disables them.
+type IngressKeyPolicyConfig struct {
+	MinRSABits        int    `json:"min_rsa_bits" toml:"min_rsa_bits"`
+	AllowedAlgorithms string `json:"allowed_algorithms" toml:"allowed_algorithms"` // comma-separated key types, "" = any
+	RequireExpiry     bool   `json:"require_expiry" toml:"require_expiry"`
+	MaxExpiryDays     int    `json:"max_expiry_days" toml:"max_expiry_days"`         // 0 = no limit
+	MaxKeys           int    `json:"max_keys" toml:"max_keys"`                       // per account, 0 = unlimited
+	AdminHardwareKeys bool   `json:"admin_hardware_keys" toml:"admin_hardware_keys"` // admins need sk- or PIV-attested keys
+	DisableViolating  bool   `json:"disable_violating" toml:"disable_violating"`
+}
+
 // Bootstrap holds env-only config that cannot be changed at runtime.
 type Bootstrap struct {
 	DBDriver   string
@@ -362,10 +406,12 @@ func defaultConfig() *Config {
 			SshHostKeyDir: "/etc/ssh",
 		},
 		SSH: SSHConfig{
-			Enabled:        true,
-			DefaultPort:    22,
-			HostKeyTTL:     Duration(24 * time.Hour),
-			KeyscanTimeout: Duration(5 * time.Second),
+			Enabled:          true,
+			DefaultPort:      22,
+			HostKeyTTL:       Duration(24 * time.Hour),
+			KeyscanTimeout:   Duration(5 * time.Second),
+			UserCertValidity: Duration(5 * time.Minute),
+			HostCertValidity: Duration(365 * 24 * time.Hour),
 		},
 		MFA: MFAConfig{
 			MaxAttempts: 3,
@@ -437,9 +483,14 @@ func defaultConfig() *Config {
 		SelfMFA:          SelfMFAConfig{Enabled: true},
 		SelfPassword:     SelfPasswordConfig{Enabled: true},
 		BackupCodes:      BackupCodesConfig{Enabled: true},
+		AccessRequests:   AccessRequestsConfig{Enabled: true, MaxDuration: Duration(24 * time.Hour)},
+		BreakGlass:       BreakGlassConfig{Enabled: true, MaxDuration: Duration(time.Hour)},
 
 		// Connection policy (default: off).
 		DenyRootTarget: DenyRootTargetConfig{Enabled: false},
+
+		// Key policy (default: only the 2048-bit RSA floor).
+		IngressKeyPolicy: IngressKeyPolicyConfig{MinRSABits: 2048},
 	}
 }
 
@@ -801,6 +852,10 @@ func ConfigDiff() []ConfigEntry {
 	add("ssh", "default_port", fmt.Sprintf("%d", cfg.SSH.DefaultPort), fmt.Sprintf("%d", def.SSH.DefaultPort))
 	add("ssh", "host_key_ttl", cfg.SSH.HostKeyTTL.String(), def.SSH.HostKeyTTL.String())
 	add("ssh", "keyscan_timeout", cfg.SSH.KeyscanTimeout.String(), def.SSH.KeyscanTimeout.String())
+	add("ssh", "user_cert_validity", cfg.SSH.UserCertValidity.String(), def.SSH.UserCertValidity.String())
+	add("ssh", "host_cert_validity", cfg.SSH.HostCertValidity.String(), def.SSH.HostCertValidity.String())
+	add("ssh", "host_cert_principals", cfg.SSH.HostCertPrincipals, def.SSH.HostCertPrincipals)
+	add("ssh", "revoked_keys_file", cfg.SSH.RevokedKeysFile, def.SSH.RevokedKeysFile)
 
 	// MFA
 	add("mfa", "max_attempts", fmt.Sprintf("%d", cfg.MFA.MaxAttempts), fmt.Sprintf("%d", def.MFA.MaxAttempts))
@@ -867,10 +922,24 @@ func ConfigDiff() []ConfigEntry {
 	add("self_mfa", "enabled", fmt.Sprintf("%t", cfg.SelfMFA.Enabled), fmt.Sprintf("%t", def.SelfMFA.Enabled))
 	add("self_password", "enabled", fmt.Sprintf("%t", cfg.SelfPassword.Enabled), fmt.Sprintf("%t", def.SelfPassword.Enabled))
 	add("backup_codes", "enabled", fmt.Sprintf("%t", cfg.BackupCodes.Enabled), fmt.Sprintf("%t", def.BackupCodes.Enabled))
+	add("access_requests", "enabled", fmt.Sprintf("%t", cfg.AccessRequests.Enabled), fmt.Sprintf("%t", def.AccessRequests.Enabled))
+	add("access_requests", "max_duration", cfg.AccessRequests.MaxDuration.String(), def.AccessRequests.MaxDuration.String())
+	add("break_glass", "enabled", fmt.Sprintf("%t", cfg.BreakGlass.Enabled), fmt.Sprintf("%t", def.BreakGlass.Enabled))
+	add("break_glass", "max_duration", cfg.BreakGlass.MaxDuration.String(), def.BreakGlass.MaxDuration.String())
 
 	// Connection policy
 	add("deny_root_target", "enabled", fmt.Sprintf("%t", cfg.DenyRootTarget.Enabled), fmt.Sprintf("%t", def.DenyRootTarget.Enabled))
 
+	// Key policy
+	kp, defKP := cfg.IngressKeyPolicy, def.IngressKeyPolicy
+	add("ingress_key_policy", "min_rsa_bits", fmt.Sprintf("%d", kp.MinRSABits), fmt.Sprintf("%d", defKP.MinRSABits))
+	add("ingress_key_policy", "allowed_algorithms", kp.AllowedAlgorithms, defKP.AllowedAlgorithms)
+	add("ingress_key_policy", "require_expiry", fmt.Sprintf("%t", kp.RequireExpiry), fmt.Sprintf("%t", defKP.RequireExpiry))
+	add("ingress_key_policy", "max_expiry_days", fmt.Sprintf("%d", kp.MaxExpiryDays), fmt.Sprintf("%d", defKP.MaxExpiryDays))
+	add("ingress_key_policy", "max_keys", fmt.Sprintf("%d", kp.MaxKeys), fmt.Sprintf("%d", defKP.MaxKeys))
+	add("ingress_key_policy", "admin_hardware_keys", fmt.Sprintf("%t", kp.AdminHardwareKeys), fmt.Sprintf("%t", defKP.AdminHardwareKeys))
+	add("ingress_key_policy", "disable_violating", fmt.Sprintf("%t", kp.DisableViolating), fmt.Sprintf("%t", defKP.DisableViolating))
+
 	return entries
 }
 
@@ -468,5 +468,18 @@ func ManagedModelsInDependencyOrder() []any {
 		&models.GroupDBAccess{},
 		&models.GroupGuestDBAccess{},
 		&models.DatabaseAlias{},
+		&models.AccessRequest{},
+		&models.AccessRequestEvent{},
+		&models.BreakGlass{},
+		&models.GroupInclusion{},
+		&models.CustomRole{},
+		&models.CustomRoleAssignment{},
+		&models.Host{},
+		&models.ReviewCampaign{},
+		&models.ReviewItem{},
+		&models.GroupEgressKeyVerification{},
+		&models.CertAuthority{},
+		&models.IngressCA{},
+		&models.RevokedKey{},
 	}
 }
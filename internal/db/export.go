@@ -22,6 +22,7 @@ import (
 	"gorm.io/gorm/schema"
 
 	"goBastion/internal/config"
+	"goBastion/internal/utils/cryptokey"
 )
 
 const (
@@ -384,6 +385,12 @@ func Import(db *gorm.DB, r io.Reader, log *slog.Logger) (importErr error) {
 					_ = tx.Rollback()
 					return fmt.Errorf("decode %s.%s: %w", sch.Table, col, err)
 				}
+				if sc, ok := secretColumn(sch.Table, col); ok {
+					if value, err = sealSecret(sc, value); err != nil {
+						_ = tx.Rollback()
+						return fmt.Errorf("encrypt %s.%s: %w", sch.Table, col, err)
+					}
+				}
 				insertRow[col] = value
 			}
 
@@ -468,6 +475,9 @@ func exportTableRows(db *gorm.DB, sch *schema.Schema, log *slog.Logger) ([]map[s
 		rowObj := make(map[string]encodedCell, len(cols))
 		for i, col := range cols {
 			field := sch.FieldsByDBName[col]
+			if sc, ok := secretColumn(sch.Table, col); ok {
+				values[i] = openSecret(sc, values[i], log)
+			}
 			cell, err := encodeCell(field, values[i])
 			if err != nil {
 				return nil, fmt.Errorf("encode %s.%s: %w", sch.Table, col, err)
@@ -485,6 +495,59 @@ func exportTableRows(db *gorm.DB, sch *schema.Schema, log *slog.Logger) ([]map[s
 	return out, nil
 }
 
+// secretColumn returns the cryptokey secret column table.col is, if any.
+func secretColumn(table, col string) (cryptokey.SecretColumn, bool) {
+	for _, sc := range cryptokey.SecretColumns {
+		if sc.Table == table && sc.Column == col {
+			return sc, true
+		}
+	}
+	return cryptokey.SecretColumn{}, false
+}
+
+// openSecret returns the plaintext of a secret column value for the export,
+// so that the dump, itself encrypted with DB_EXPORT_KEY, can be imported on a
+// bastion with another EGRESS_ENC_KEY. A value no configured key opens is
+// exported as it is.
+func openSecret(sc cryptokey.SecretColumn, value any, log *slog.Logger) any {
+	if value == nil {
+		return nil
+	}
+	raw := asString(value)
+	if !cryptokey.IsEncrypted(raw) {
+		return value
+	}
+	plain, err := cryptokey.Decrypt(raw)
+	if err != nil {
+		if log != nil {
+			log.Warn("db_export_secret_kept_encrypted",
+				slog.String("table", sc.Table),
+				slog.String("column", sc.Column),
+				slog.String("error", err.Error()),
+			)
+		}
+		return value
+	}
+	return sc.Stored(plain)
+}
+
+// sealSecret encrypts an imported secret column value with the current
+// EGRESS_ENC_KEY, if set. Values still encrypted in the dump are kept.
+func sealSecret(sc cryptokey.SecretColumn, value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	raw := asString(value)
+	if raw == "" {
+		return value, nil
+	}
+	sealed, err := cryptokey.ReEncryptIfNeeded(raw)
+	if err != nil {
+		return nil, err
+	}
+	return sc.Stored(sealed), nil
+}
+
 func encodeCell(field *schema.Field, value any) (encodedCell, error) {
 	if value == nil {
 		return encodedCell{Type: "null"}, nil
@@ -2,6 +2,8 @@ package db
 
 import (
 	"bytes"
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
 	"os"
 	"strings"
@@ -9,6 +11,9 @@ import (
 
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/cryptokey"
 )
 
 func openTestDB(t *testing.T) *gorm.DB {
@@ -111,8 +116,71 @@ func TestDeriveKeyForExportWithPassphrase(t *testing.T) {
 	}
 }
 
+func TestExportImportSecrets(t *testing.T) {
+	t.Setenv("DB_EXPORT_KEY", "0123456789abcdef0123456789abcdef")
+
+	open := func() *gorm.DB {
+		db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+		if err != nil {
+			t.Fatalf("open sqlite memory db: %v", err)
+		}
+		if err := db.AutoMigrate(ManagedModelsInDependencyOrder()...); err != nil {
+			t.Fatalf("auto migrate: %v", err)
+		}
+		return db
+	}
+	src := open()
+	totpSecret, _ := cryptokey.Encrypt("JBSWY3DPEHPK3PXP")
+	hostKey, _ := cryptokey.Encrypt("host-private-key")
+	user := models.User{Username: "alice", Role: models.RoleUser, Enabled: true, TOTPSecret: totpSecret}
+	if err := src.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if err := src.Create(&models.SshHostKey{Type: "ed25519", PrivateKey: []byte(hostKey), PublicKey: []byte("pub")}).Error; err != nil {
+		t.Fatalf("create host key: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := Export(src, &buf, nil); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	// The secrets travel in plaintext inside the encrypted dump, so that
+	// the target bastion can use another EGRESS_ENC_KEY.
+	var env exportEnvelope
+	_ = json.Unmarshal(buf.Bytes(), &env)
+	key, _ := deriveKeyForImport("0123456789abcdef0123456789abcdef", env.KDF)
+	nonce, _ := base64.StdEncoding.DecodeString(env.Nonce)
+	ciphertext, _ := base64.StdEncoding.DecodeString(env.Payload)
+	plain, err := decryptAESGCM(key, nonce, ciphertext)
+	if err != nil {
+		t.Fatalf("decrypt payload: %v", err)
+	}
+	if !strings.Contains(string(plain), "JBSWY3DPEHPK3PXP") || strings.Contains(string(plain), totpSecret) {
+		t.Fatal("the dump must carry the decrypted TOTP secret")
+	}
+
+	dst := open()
+	if err := Import(dst, &buf, nil); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	var imported models.User
+	dst.First(&imported, "id = ?", user.ID)
+	if got, err := cryptokey.Decrypt(imported.TOTPSecret); err != nil || got != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("the imported TOTP secret must be encrypted, got %q (%v)", imported.TOTPSecret, err)
+	}
+	var importedKey models.SshHostKey
+	dst.First(&importedKey, "type = ?", "ed25519")
+	if got, err := cryptokey.Decrypt(string(importedKey.PrivateKey)); err != nil || got != "host-private-key" {
+		t.Fatalf("the imported host key must be encrypted, got %q (%v)", importedKey.PrivateKey, err)
+	}
+}
+
 func TestMain(m *testing.M) {
 	// Avoid accidental leakage from caller environment
 	_ = os.Unsetenv("DB_EXPORT_KEY")
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	_ = os.Setenv("EGRESS_ENC_KEY", base64.StdEncoding.EncodeToString(key))
 	os.Exit(m.Run())
 }
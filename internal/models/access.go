@@ -17,6 +17,8 @@ type SelfAccess struct {
 	Protocol       string     `gorm:"default:ssh"` // ssh, scpupload, scpdownload, sftp, rsync
 	Comment        string     `gorm:"default:null"`
 	AllowedFrom    string     `gorm:"default:null"`
+	Schedule       string     `gorm:"default:null"` // weekly window, e.g. "Mon-Fri 08:00-19:00 Europe/Paris"
+	StartsAt       *time.Time `gorm:"default:null"` // not usable before this time
 	ExpiresAt      *time.Time `gorm:"default:null"`
 	LastConnection time.Time  `gorm:"default:null"`
 	CreatedAt      time.Time
@@ -40,7 +42,10 @@ type GroupAccess struct {
 	Protocol       string     `gorm:"default:ssh"` // ssh, scpupload, scpdownload, sftp, rsync
 	Comment        string     `gorm:"default:null"`
 	AllowedFrom    string     `gorm:"default:null"`
+	Schedule       string     `gorm:"default:null"` // weekly window, e.g. "Mon-Fri 08:00-19:00 Europe/Paris"
+	StartsAt       *time.Time `gorm:"default:null"` // not usable before this time
 	ExpiresAt      *time.Time `gorm:"default:null"`
+	MemberID       *uuid.UUID `gorm:"type:uuid;index"` // when set, only this member may use the access (approved access requests)
 	LastConnection time.Time  `gorm:"default:null"`
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
@@ -53,6 +58,15 @@ func (ga *GroupAccess) BeforeCreate(*gorm.DB) (err error) {
 	return
 }
 
+// StartedClause selects access rows whose StartsAt has passed; it takes the
+// current time as its only argument. Rows scheduled for later are "pending".
+const StartedClause = "(starts_at IS NULL OR starts_at <= ?)"
+
+// GroupAccessMemberClause selects the group accesses a user may use: those
+// open to every member and those restricted to that user. It takes the user
+// ID as its only argument.
+const GroupAccessMemberClause = "(member_id IS NULL OR member_id = ?)"
+
 type AccessRight struct {
 	ID             uuid.UUID
 	Source         string
@@ -67,7 +81,13 @@ type AccessRight struct {
 	KeyUpdatedAt   time.Time
 	PublicKey      string
 	PrivateKey     string
-	RemoteCmd      string   // non-empty for non-interactive sessions (e.g. SCP commands)
-	MFARequired    bool     // JIT MFA required for this access (from group policy)
-	JumpHosts      []string // SSH -J ProxyJump chain: ["user@hop1:port", "user@hop2:port", ...]
+	FallbackKeys   []string  // further egress keys offered after PrivateKey while a group key rotates
+	RemoteCmd      string    // non-empty for non-interactive sessions (e.g. SCP commands)
+	MFARequired    bool      // JIT MFA required for this access (from group policy)
+	JumpHosts      []string  // SSH -J ProxyJump chain: ["user@hop1:port", "user@hop2:port", ...]
+	BreakGlassID   uuid.UUID // set when reached through a break-glass declaration; forces TTY recording
+	// Justification policy (from group policy) and the answer given for this connection.
+	JustificationRequired bool
+	JustificationPattern  string
+	Justification         string
 }
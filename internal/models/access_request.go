@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Access request statuses.
+const (
+	AccessRequestPending  = "pending"
+	AccessRequestApproved = "approved"
+	AccessRequestDenied   = "denied"
+)
+
+// Access request audit actions.
+const (
+	AccessRequestActionRequested = "requested"
+	AccessRequestActionApproved  = "approved"
+	AccessRequestActionDenied    = "denied"
+)
+
+// AccessRequest is a just-in-time request by a group member for time-boxed
+// access to a server through that group. Approval by a gatekeeper, aclkeeper
+// or owner creates a GroupGuestAccess (guest-role requesters) or a GroupAccess
+// restricted to the requester (other roles) expiring Duration after the
+// decision.
+type AccessRequest struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	GroupID         uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Group           Group      `gorm:"foreignKey:GroupID"`
+	RequesterID     uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Requester       User       `gorm:"foreignKey:RequesterID"`
+	Username        string     `gorm:"not null"`
+	Server          string     `gorm:"not null"`
+	Port            int64      `gorm:"not null"`
+	Protocol        string     `gorm:"default:ssh"`
+	DurationSeconds int64      `gorm:"not null"`
+	Reason          string     `gorm:"not null"`
+	Status          string     `gorm:"not null;default:pending;index"`
+	DeciderID       *uuid.UUID `gorm:"type:uuid"`
+	Decider         *User      `gorm:"foreignKey:DeciderID"`
+	DecisionComment string     `gorm:"default:null"`
+	DecidedAt       *time.Time `gorm:"default:null"`
+	GrantType       string     `gorm:"default:null"` // "guest" (GroupGuestAccess) or "group" (GroupAccess)
+	GrantID         *uuid.UUID `gorm:"type:uuid"`
+	GrantExpiresAt  *time.Time `gorm:"default:null"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       gorm.DeletedAt `gorm:"index"`
+}
+
+// BeforeCreate generates a UUID for AccessRequest before insertion.
+func (r *AccessRequest) BeforeCreate(*gorm.DB) (err error) {
+	r.ID = uuid.New()
+	return
+}
+
+// Duration returns the requested access duration.
+func (r *AccessRequest) Duration() time.Duration {
+	return time.Duration(r.DurationSeconds) * time.Second
+}
+
+// AccessRequestEvent is an append-only audit record of one step in the
+// lifecycle of an AccessRequest. Rows are never updated or soft-deleted.
+type AccessRequestEvent struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	RequestID uuid.UUID `gorm:"type:uuid;not null;index"`
+	ActorID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	Actor     User      `gorm:"foreignKey:ActorID"`
+	Action    string    `gorm:"not null"` // requested, approved, denied
+	Comment   string    `gorm:"default:null"`
+	CreatedAt time.Time
+}
+
+// BeforeCreate generates a UUID for AccessRequestEvent before insertion.
+func (e *AccessRequestEvent) BeforeCreate(*gorm.DB) (err error) {
+	e.ID = uuid.New()
+	return
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BreakGlass records an emergency access declared with the breakGlass
+// command. Until ExpiresAt, the user may connect to Server:Port through any
+// access entry covering it, with TTY recording forced on. Every record stays
+// unacknowledged until an admin reviews it with breakGlassAck.
+type BreakGlass struct {
+	ID               uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	UserID           uuid.UUID  `gorm:"type:uuid;not null;index"`
+	User             User       `gorm:"foreignKey:UserID"`
+	Server           string     `gorm:"not null"`
+	Port             int64      `gorm:"not null"`
+	Username         string     `gorm:"default:null"` // target username; empty = any
+	Reason           string     `gorm:"not null"`
+	ClientIP         string     `gorm:"default:null"`
+	ExpiresAt        time.Time  `gorm:"not null;index"`
+	AcknowledgedByID *uuid.UUID `gorm:"type:uuid"`
+	AcknowledgedBy   *User      `gorm:"foreignKey:AcknowledgedByID"`
+	AcknowledgedAt   *time.Time `gorm:"default:null;index"`
+	AckComment       string     `gorm:"default:null"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	DeletedAt        gorm.DeletedAt `gorm:"index"`
+}
+
+// BeforeCreate generates a UUID for BreakGlass before insertion.
+func (b *BreakGlass) BeforeCreate(*gorm.DB) (err error) {
+	b.ID = uuid.New()
+	return
+}
+
+// ActiveBreakGlass returns the most recent unexpired break-glass record of the
+// user for host:port and target username, or nil when there is none.
+func ActiveBreakGlass(db *gorm.DB, userID uuid.UUID, host string, port int64, username string, now time.Time) *BreakGlass {
+	var bg BreakGlass
+	err := db.Where("user_id = ? AND server = ? AND port = ? AND expires_at > ? AND (username IS NULL OR username = '' OR username = ?)",
+		userID, host, port, now, username).
+		Order("created_at desc").First(&bg).Error
+	if err != nil {
+		return nil
+	}
+	return &bg
+}
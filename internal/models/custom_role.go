@@ -0,0 +1,240 @@
+package models
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BuiltinRoleNames are the hard-coded roles CanDo knows about. Custom roles
+// may not reuse these names.
+var BuiltinRoleNames = []string{
+	RoleAdmin, "superowner", RoleUser,
+	GroupRoleOwner, GroupRoleACLKeeper, GroupRoleGatekeeper, GroupRoleMember, GroupRoleGuest,
+}
+
+// nonDelegableRights can never be granted through a custom role: they manage
+// roles and grants themselves, change an account's system role, the bastion
+// config or the whole state, hand out logins or access to any account, or
+// control the CAs and the revocation list, so delegating them would allow
+// self-escalation or locking out the admins.
+var nonDelegableRights = map[string]bool{
+	"roleCreate": true, "roleModify": true, "roleDelete": true,
+	"roleAssign": true, "roleUnassign": true,
+	"restrictedGrantAdd": true, "restrictedGrantDel": true,
+	"accountModify": true, "stateApply": true, "bastionConfig": true,
+	"accountCreate": true, "accountAddIngressKey": true, "accountAddAccess": true,
+	"ingressAddTrustedCA": true, "ingressRemoveTrustedCA": true,
+	"bastionRotateUserCA": true, "bastionRotateHostCA": true,
+	"revocationAddKey": true, "revocationRemoveKey": true,
+}
+
+// groupTargetedRights are the rights whose commands check CanDo with a group
+// name as target. Only they can be held through a group-scoped assignment.
+var groupTargetedRights = map[string]bool{
+	"groupAddAccess": true, "groupDelAccess": true, "groupModifyAccess": true, "groupImportAccesses": true,
+	"groupListAccesses": true, "groupAddAlias": true, "groupDelAlias": true, "groupListAliases": true,
+	"groupSetMFA": true, "groupSetJustification": true, "groupExtendMember": true,
+	"groupAddMember": true, "groupDelMember": true, "groupImportMembers": true,
+	"groupAddSubgroup": true, "groupDelSubgroup": true,
+	"groupAddGuestAccess": true, "groupDelGuestAccess": true, "groupModifyGuestAccess": true, "groupListGuestAccesses": true,
+	"groupAddDBAccess": true, "groupDelDBAccess": true, "groupModifyDBAccess": true, "groupListDBAccesses": true,
+	"groupAddGuestDBAccess": true, "groupDelGuestDBAccess": true, "groupModifyGuestDBAccess": true, "groupListGuestDBAccesses": true,
+	"groupAddDBAlias": true, "groupDelDBAlias": true, "groupListDBAliases": true,
+	"groupGenerateEgressKey": true, "groupRotateEgressKey": true, "groupDeployEgressKey": true,
+	"groupInfo": true, "groupListEgressKeys": true,
+	"accessRequestApprove": true, "accessRequestDeny": true, "reviewDecide": true, "hostTag": true,
+}
+
+// restrictedRights are the rights subject to the restricted-commands
+// kill-switch; custom roles do not bypass it.
+var restrictedRights = map[string]bool{
+	"pivAddTrustAnchor": true, "pivListTrustAnchors": true, "pivRemoveTrustAnchor": true,
+	"realmCreate": true, "realmDelete": true, "realmList": true, "realmInfo": true,
+	"breakGlass": true,
+}
+
+// IsDelegableRight reports whether right may be included in a custom role.
+func IsDelegableRight(right string) bool {
+	return !nonDelegableRights[right]
+}
+
+// IsGroupTargetedRight reports whether right is checked against a group, so
+// that a role holding it may be assigned with a group scope.
+func IsGroupTargetedRight(right string) bool {
+	return groupTargetedRights[right]
+}
+
+// CustomRole is an administrator-defined named set of registry permissions.
+// Custom roles only add rights on top of the built-in roles.
+type CustomRole struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Name        string    `gorm:"not null;index:idx_customrolename_deletedat,unique"`
+	Description string    `gorm:"default:null"`
+	Permissions string    `gorm:"not null"` // comma-separated registry permission names
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index:idx_customrolename_deletedat"`
+}
+
+// BeforeCreate generates a UUID for CustomRole before insertion.
+func (r *CustomRole) BeforeCreate(*gorm.DB) (err error) {
+	r.ID = uuid.New()
+	return
+}
+
+// PermissionList returns the role permissions, sorted and de-duplicated.
+func (r *CustomRole) PermissionList() []string {
+	return NormalizePermissions(r.Permissions)
+}
+
+// NormalizePermissions splits a comma-separated permission list, dropping
+// blanks and duplicates, and returns it sorted.
+func NormalizePermissions(list string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, p := range strings.Split(list, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// CustomRoleAssignment grants a CustomRole to a user. With GroupID set the
+// role only applies to commands targeting that group.
+type CustomRoleAssignment struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	RoleID      uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Role        CustomRole `gorm:"foreignKey:RoleID"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;index"`
+	User        User       `gorm:"foreignKey:UserID"`
+	GroupID     *uuid.UUID `gorm:"type:uuid;index"`
+	Group       *Group     `gorm:"foreignKey:GroupID"`
+	GrantedByID uuid.UUID  `gorm:"type:uuid;not null"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+// BeforeCreate generates a UUID for CustomRoleAssignment before insertion.
+func (a *CustomRoleAssignment) BeforeCreate(*gorm.DB) (err error) {
+	a.ID = uuid.New()
+	return
+}
+
+// customRoleGrant is one right a user holds through a custom role; group is
+// empty for unscoped assignments.
+type customRoleGrant struct {
+	right string
+	group string
+}
+
+// customRolesCache mirrors groupsCache for custom role grants.
+var (
+	customRolesCacheMu sync.RWMutex
+	customRolesCache   = make(map[uuid.UUID]customRolesCacheEntry)
+)
+
+type customRolesCacheEntry struct {
+	grants    []customRoleGrant
+	createdAt time.Time
+}
+
+// getCustomRoleGrants returns the rights granted to the user by custom roles.
+func (u *User) getCustomRoleGrants(db *gorm.DB) ([]customRoleGrant, error) {
+	customRolesCacheMu.RLock()
+	entry, ok := customRolesCache[u.ID]
+	customRolesCacheMu.RUnlock()
+
+	if ok && time.Since(entry.createdAt) < groupsCacheTTL {
+		return entry.grants, nil
+	}
+
+	var assignments []CustomRoleAssignment
+	if err := db.Preload("Role").Preload("Group").Where("user_id = ?", u.ID).Find(&assignments).Error; err != nil {
+		return nil, fmt.Errorf("error retrieving custom roles: %w", err)
+	}
+	var grants []customRoleGrant
+	for _, a := range assignments {
+		if a.Role.ID == uuid.Nil {
+			continue // role deleted
+		}
+		group := ""
+		if a.GroupID != nil {
+			if a.Group == nil {
+				continue // scoped to a deleted group
+			}
+			group = a.Group.Name
+		}
+		for _, right := range a.Role.PermissionList() {
+			grants = append(grants, customRoleGrant{right: right, group: group})
+		}
+	}
+
+	customRolesCacheMu.Lock()
+	customRolesCache[u.ID] = customRolesCacheEntry{grants: grants, createdAt: time.Now()}
+	customRolesCacheMu.Unlock()
+
+	return grants, nil
+}
+
+// canDoCustom reports whether one of the user's custom roles grants right on
+// target. A group-scoped grant only matches a group-targeted right checked on
+// exactly its group; an empty target never matches it.
+func (u *User) canDoCustom(db *gorm.DB, right, target string) bool {
+	for _, g := range u.customGrantsFor(db, right) {
+		if g.group == "" || (target != "" && g.group == target) {
+			return true
+		}
+	}
+	return false
+}
+
+// canUseCustom reports whether one of the user's custom roles grants right on
+// some target; see CanUse.
+func (u *User) canUseCustom(db *gorm.DB, right string) bool {
+	return len(u.customGrantsFor(db, right)) > 0
+}
+
+// customGrantsFor returns the user's custom role grants of right. Group-scoped
+// grants of a right that is not group-targeted are dropped: such a right is
+// checked on an account or on no target, which the scope cannot restrict.
+func (u *User) customGrantsFor(db *gorm.DB, right string) []customRoleGrant {
+	if db == nil || !IsDelegableRight(right) {
+		return nil
+	}
+	if restrictedRights[right] && !restrictedCmdsEnabled.Load() {
+		return nil
+	}
+	grants, err := u.getCustomRoleGrants(db)
+	if err != nil {
+		slog.Warn("canDoCustom: db query failed", "error", err, "user", u.Username, "right", right)
+		return nil
+	}
+	var out []customRoleGrant
+	for _, g := range grants {
+		if g.right == right && (g.group == "" || groupTargetedRights[right]) {
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+// InvalidateCustomRolesCache drops all cached custom role grants. Role
+// definitions are shared, so any change invalidates every user.
+func InvalidateCustomRolesCache() {
+	customRolesCacheMu.Lock()
+	customRolesCache = make(map[uuid.UUID]customRolesCacheEntry)
+	customRolesCacheMu.Unlock()
+}
@@ -23,6 +23,8 @@ type SelfDBAccess struct {
 	Database       string     `gorm:"default:null"` // specific DB name (nullable = connect without selecting)
 	Comment        string     `gorm:"default:null"`
 	AllowedFrom    string     `gorm:"default:null"` // CIDRs
+	Schedule       string     `gorm:"default:null"` // weekly window, e.g. "Mon-Fri 08:00-19:00 Europe/Paris"
+	StartsAt       *time.Time `gorm:"default:null"` // not usable before this time
 	ExpiresAt      *time.Time `gorm:"default:null"`
 	LastConnection time.Time  `gorm:"default:null"`
 	CreatedAt      time.Time
@@ -48,6 +50,8 @@ type GroupDBAccess struct {
 	Database       string     `gorm:"default:null"`
 	Comment        string     `gorm:"default:null"`
 	AllowedFrom    string     `gorm:"default:null"`
+	Schedule       string     `gorm:"default:null"` // weekly window, e.g. "Mon-Fri 08:00-19:00 Europe/Paris"
+	StartsAt       *time.Time `gorm:"default:null"` // not usable before this time
 	ExpiresAt      *time.Time `gorm:"default:null"`
 	LastConnection time.Time  `gorm:"default:null"`
 	CreatedAt      time.Time
@@ -75,6 +79,8 @@ type GroupGuestDBAccess struct {
 	Database    string     `gorm:"default:null"`
 	Comment     string     `gorm:"default:null"`
 	AllowedFrom string     `gorm:"default:null"`
+	Schedule    string     `gorm:"default:null"` // weekly window, e.g. "Mon-Fri 08:00-19:00 Europe/Paris"
+	StartsAt    *time.Time `gorm:"default:null"` // not usable before this time
 	ExpiresAt   *time.Time `gorm:"default:null"`
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
@@ -100,7 +106,12 @@ type DBAccessRight struct {
 	Password    string // decrypted, empty if not stored
 	Database    string
 	AllowedFrom string
+	Schedule    string
 	MFARequired bool
+	// Justification policy (from group policy) and the answer given for this connection.
+	JustificationRequired bool
+	JustificationPattern  string
+	Justification         string
 }
 
 // DatabaseAlias maps a friendly name to a real database host:port:protocol.
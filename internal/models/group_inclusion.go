@@ -0,0 +1,124 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GroupInclusion makes ChildID a member of ParentID: every non-guest member
+// of the child group inherits the parent's GroupAccess and GroupDBAccess
+// entries. Inclusions are transitive and must never form a cycle.
+type GroupInclusion struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ParentID  uuid.UUID `gorm:"type:uuid;not null;index"`
+	Parent    Group     `gorm:"foreignKey:ParentID"`
+	ChildID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	Child     Group     `gorm:"foreignKey:ChildID"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// BeforeCreate generates a UUID for GroupInclusion before insertion.
+func (gi *GroupInclusion) BeforeCreate(*gorm.DB) (err error) {
+	gi.ID = uuid.New()
+	return
+}
+
+// InheritedGroups returns every group reached upwards through inclusions from
+// groupIDs, mapped to its inheritance path: the starting group first, the
+// inherited group last. Starting groups themselves are not returned.
+func InheritedGroups(db *gorm.DB, groupIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	return walkInclusions(db, groupIDs, "child_id", func(gi GroupInclusion) (uuid.UUID, uuid.UUID) { return gi.ChildID, gi.ParentID })
+}
+
+// IncludedGroups returns every group reached downwards through inclusions
+// from groupID (whose members therefore inherit groupID's accesses), mapped to
+// the path from groupID to that group.
+func IncludedGroups(db *gorm.DB, groupID uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	return walkInclusions(db, []uuid.UUID{groupID}, "parent_id", func(gi GroupInclusion) (uuid.UUID, uuid.UUID) { return gi.ParentID, gi.ChildID })
+}
+
+// walkInclusions runs a breadth-first search over the inclusion graph. column
+// selects the edge end matched against the frontier and edge maps a row to
+// (from, to). The first path found is the shortest one.
+func walkInclusions(db *gorm.DB, start []uuid.UUID, column string, edge func(GroupInclusion) (uuid.UUID, uuid.UUID)) (map[uuid.UUID][]uuid.UUID, error) {
+	paths := make(map[uuid.UUID][]uuid.UUID, len(start))
+	frontier := make([]uuid.UUID, 0, len(start))
+	for _, id := range start {
+		if _, seen := paths[id]; !seen {
+			paths[id] = []uuid.UUID{id}
+			frontier = append(frontier, id)
+		}
+	}
+	// Inclusions touching a soft-deleted group are ignored.
+	liveGroups := db.Model(&Group{}).Select("id")
+	for len(frontier) > 0 {
+		var rows []GroupInclusion
+		if err := db.Where(column+" IN ? AND parent_id IN (?) AND child_id IN (?)", frontier, liveGroups, liveGroups).
+			Find(&rows).Error; err != nil {
+			return nil, fmt.Errorf("error retrieving group inclusions: %w", err)
+		}
+		var next []uuid.UUID
+		for _, gi := range rows {
+			from, to := edge(gi)
+			if _, seen := paths[to]; seen {
+				continue
+			}
+			path := append(append([]uuid.UUID{}, paths[from]...), to)
+			paths[to] = path
+			next = append(next, to)
+		}
+		frontier = next
+	}
+	for _, id := range start {
+		delete(paths, id)
+	}
+	return paths, nil
+}
+
+// EffectiveGroupRoles returns the user's role in each group, given their
+// direct memberships. Groups inherited through inclusions from non-guest
+// memberships are added with GroupRoleMember (overriding a direct guest role).
+func EffectiveGroupRoles(db *gorm.DB, userGroups []UserGroup) (map[uuid.UUID]string, error) {
+	roles := make(map[uuid.UUID]string, len(userGroups))
+	var inheriting []uuid.UUID
+	for _, ug := range userGroups {
+		roles[ug.GroupID] = ug.Role
+		if ug.Role != GroupRoleGuest {
+			inheriting = append(inheriting, ug.GroupID)
+		}
+	}
+	if len(inheriting) == 0 {
+		return roles, nil
+	}
+	inherited, err := InheritedGroups(db, inheriting)
+	if err != nil {
+		return nil, err
+	}
+	for id := range inherited {
+		if role, ok := roles[id]; !ok || role == GroupRoleGuest {
+			roles[id] = GroupRoleMember
+		}
+	}
+	return roles, nil
+}
+
+// GroupPathNames renders an inheritance path as "child → parent → ...".
+func GroupPathNames(db *gorm.DB, path []uuid.UUID) string {
+	var groups []Group
+	db.Unscoped().Where("id IN ?", path).Find(&groups)
+	names := make(map[uuid.UUID]string, len(groups))
+	for _, g := range groups {
+		names[g.ID] = g.Name
+	}
+	parts := make([]string, len(path))
+	for i, id := range path {
+		parts[i] = names[id]
+	}
+	return strings.Join(parts, " → ")
+}
@@ -22,6 +22,8 @@ type GroupGuestAccess struct {
 	Protocol     string         `gorm:"default:ssh"`
 	Comment      string         `gorm:"default:null"`
 	AllowedFrom  string         `gorm:"default:null"`
+	Schedule     string         `gorm:"default:null"` // weekly window, e.g. "Mon-Fri 08:00-19:00 Europe/Paris"
+	StartsAt     *time.Time     `gorm:"default:null"` // not usable before this time
 	ExpiresAt    *time.Time     `gorm:"default:null"`
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
@@ -0,0 +1,179 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TagSelectorPrefix marks GroupAccess.Server values that select inventory
+// hosts by tag ("tag:env=prod,role=web") instead of naming a server.
+const TagSelectorPrefix = "tag:"
+
+// TagServerClause is a SQL fragment selecting access rows holding a tag selector.
+const TagServerClause = "server LIKE 'tag:%'"
+
+var (
+	tagKeyRe   = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,62}$`)
+	tagValueRe = regexp.MustCompile(`^[A-Za-z0-9_.:-]{1,128}$`)
+)
+
+// Host is a server inventory entry. Addresses and Tags are stored as
+// comma-separated lists; tags are "key=value" pairs sorted by key.
+type Host struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	Name         string     `gorm:"not null;index:idx_hostname_deletedat,unique"`
+	Addresses    string     `gorm:"not null"`
+	Tags         string     `gorm:"default:null"`
+	OwnerGroupID *uuid.UUID `gorm:"type:uuid;index"`
+	OwnerGroup   *Group     `gorm:"foreignKey:OwnerGroupID"`
+	Description  string     `gorm:"default:null"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index:idx_hostname_deletedat"`
+}
+
+// BeforeCreate generates a UUID for Host before insertion.
+func (h *Host) BeforeCreate(*gorm.DB) (err error) {
+	h.ID = uuid.New()
+	return
+}
+
+// AddressList returns the host addresses.
+func (h *Host) AddressList() []string {
+	var out []string
+	for _, a := range strings.Split(h.Addresses, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// TagMap returns the host tags. Stored tags are always well-formed.
+func (h *Host) TagMap() map[string]string {
+	tags, _ := ParseTags(h.Tags)
+	return tags
+}
+
+// Covers reports whether target (as typed by a user) designates this host:
+// its inventory name or one of its addresses, compared case-insensitively.
+func (h *Host) Covers(target string) bool {
+	target = strings.TrimSuffix(strings.TrimPrefix(target, "["), "]")
+	if strings.EqualFold(h.Name, target) {
+		return true
+	}
+	for _, a := range h.AddressList() {
+		if strings.EqualFold(strings.TrimSuffix(strings.TrimPrefix(a, "["), "]"), target) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesSelector reports whether the host carries every key=value of selector.
+func (h *Host) MatchesSelector(selector map[string]string) bool {
+	if len(selector) == 0 {
+		return false
+	}
+	tags := h.TagMap()
+	for k, v := range selector {
+		if tags[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseTags parses "key=value,key=value". Keys are lower-case; a key may
+// appear only once.
+func ParseTags(s string) (map[string]string, error) {
+	tags := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		k, v = strings.ToLower(strings.TrimSpace(k)), strings.TrimSpace(v)
+		if !ok || !tagKeyRe.MatchString(k) || !tagValueRe.MatchString(v) {
+			return nil, fmt.Errorf("invalid tag %q: expected key=value", pair)
+		}
+		if _, dup := tags[k]; dup {
+			return nil, fmt.Errorf("duplicate tag key %q", k)
+		}
+		tags[k] = v
+	}
+	return tags, nil
+}
+
+// FormatTags renders tags in their canonical stored form, sorted by key.
+func FormatTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + tags[k]
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseTagSelector parses a non-empty tag selector and returns it with its
+// canonical GroupAccess.Server value ("tag:" + sorted pairs).
+func ParseTagSelector(s string) (map[string]string, string, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), TagSelectorPrefix)
+	selector, err := ParseTags(s)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(selector) == 0 {
+		return nil, "", fmt.Errorf("empty tag selector")
+	}
+	return selector, TagSelectorPrefix + FormatTags(selector), nil
+}
+
+// IsTagSelector reports whether an access server value is a tag selector.
+func IsTagSelector(server string) bool {
+	return strings.HasPrefix(server, TagSelectorPrefix)
+}
+
+// HostsForTarget returns the inventory hosts designated by target, by name
+// or address.
+func HostsForTarget(db *gorm.DB, target string) ([]Host, error) {
+	bare := strings.TrimSuffix(strings.TrimPrefix(target, "["), "]")
+	var candidates []Host
+	if err := db.Where("LOWER(name) = LOWER(?) OR LOWER(addresses) LIKE LOWER(?)", bare, "%"+bare+"%").
+		Find(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("error retrieving inventory hosts: %w", err)
+	}
+	hosts := candidates[:0]
+	for _, h := range candidates {
+		if h.Covers(bare) {
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts, nil
+}
+
+// TagSelectorCovers reports whether the tag-selector server value selects
+// one of hosts.
+func TagSelectorCovers(server string, hosts []Host) bool {
+	selector, _, err := ParseTagSelector(server)
+	if err != nil {
+		return false
+	}
+	for i := range hosts {
+		if hosts[i].MatchesSelector(selector) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// IngressCA is an SSH user CA whose certificates are accepted to log in to
+// the bastion. Principals lists the certificate principals accepted for an
+// account, comma-separated, where %u stands for the bastion username.
+type IngressCA struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Name        string    `gorm:"not null;uniqueIndex"`
+	PublicKey   string    `gorm:"not null"`
+	Fingerprint string    `gorm:"not null"`
+	Principals  string    `gorm:"not null"`
+	AddedByID   uuid.UUID `gorm:"type:uuid;not null"`
+	AddedBy     User      `gorm:"foreignKey:AddedByID"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// BeforeCreate generates a UUID for IngressCA before insertion.
+func (ca *IngressCA) BeforeCreate(*gorm.DB) (err error) {
+	ca.ID = uuid.New()
+	return
+}
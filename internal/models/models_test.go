@@ -2,6 +2,7 @@ package models
 
 import (
 	"testing"
+	"time"
 
 	"github.com/glebarez/sqlite"
 	"github.com/google/uuid"
@@ -129,6 +130,23 @@ func TestUser_CanDo_UnknownCommand(t *testing.T) {
 	}
 }
 
+func TestIngressKey_AuthorizedKeysOptions(t *testing.T) {
+	if got := (&IngressKey{}).AuthorizedKeysOptions(); got != "" {
+		t.Fatalf("a key without options must render none, got %q", got)
+	}
+	expires := time.Date(2030, 1, 2, 3, 4, 0, 0, time.Local)
+	key := &IngressKey{
+		AllowedFrom:       "10.0.0.0/8,192.0.2.7/32",
+		ExpiresAt:         &expires,
+		NoPortForwarding:  true,
+		NoAgentForwarding: true,
+	}
+	want := `from="10.0.0.0/8,192.0.2.7/32",expiry-time="203001020304",no-port-forwarding,no-agent-forwarding`
+	if got := key.AuthorizedKeysOptions(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
 func TestUserGroup_RoleMethods(t *testing.T) {
 	cases := []struct {
 		role     string
@@ -333,7 +351,7 @@ func newRightsTestDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatalf("open test DB: %v", err)
 	}
-	if err := db.AutoMigrate(&User{}, &Group{}, &UserGroup{}); err != nil {
+	if err := db.AutoMigrate(&User{}, &Group{}, &UserGroup{}, &CustomRole{}, &CustomRoleAssignment{}); err != nil {
 		t.Fatalf("migrate rights test DB: %v", err)
 	}
 	return db
@@ -368,3 +386,70 @@ func seedVisibilityTestData(t *testing.T, db *gorm.DB) (*User, *User, *User, *Us
 
 	return owner, member, outsider, admin, group.Name
 }
+
+func TestUser_CanDo_IgnoresExpiredMembership(t *testing.T) {
+	db := newRightsTestDB(t)
+	group := Group{Name: "interns"}
+	if err := db.Create(&group).Error; err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	intern := &User{Username: "intern", Role: RoleUser, Enabled: true}
+	if err := db.Create(intern).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	past := time.Now().Add(-time.Minute)
+	if err := db.Create(&UserGroup{UserID: intern.ID, GroupID: group.ID, Role: GroupRoleOwner, ExpiresAt: &past}).Error; err != nil {
+		t.Fatalf("create membership: %v", err)
+	}
+	InvalidateGroupsCache(intern.ID)
+
+	if intern.CanDo(db, "groupAddMember", "interns") {
+		t.Fatal("an expired ownership must not grant group rights")
+	}
+}
+
+func TestUserGroup_ExpiryLabel(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	in := func(d time.Duration) *time.Time { t := now.Add(d); return &t }
+	cases := []struct {
+		expires *time.Time
+		want    string
+	}{
+		{nil, "permanent"},
+		{in(-time.Second), "expired"},
+		{in(50 * time.Hour), "2d2h left (2026-10-19 14:00)"},
+		{in(90 * time.Minute), "1h30m left (2026-10-17 13:30)"},
+	}
+	for _, c := range cases {
+		ug := UserGroup{ExpiresAt: c.expires}
+		if got := ug.ExpiryLabel(now); got != c.want {
+			t.Errorf("ExpiryLabel(%v) = %q, want %q", c.expires, got, c.want)
+		}
+	}
+}
+
+func TestParseTagSelector_Canonical(t *testing.T) {
+	selector, server, err := ParseTagSelector(" Role=web , env=prod ")
+	if err != nil {
+		t.Fatalf("ParseTagSelector: %v", err)
+	}
+	if server != "tag:env=prod,role=web" || selector["role"] != "web" {
+		t.Fatalf("unexpected selector %v / %q", selector, server)
+	}
+	for _, bad := range []string{"", "env", "env=prod,env=dev", "env=pr od", "=prod"} {
+		if _, _, err := ParseTagSelector(bad); err == nil {
+			t.Fatalf("ParseTagSelector(%q): expected error", bad)
+		}
+	}
+
+	h := Host{Name: "web01", Addresses: "10.0.0.5,[fd00::5]", Tags: "env=prod,role=web,tier=1"}
+	if !TagSelectorCovers(server, []Host{h}) {
+		t.Fatal("expected selector to cover a host carrying all its tags")
+	}
+	if TagSelectorCovers("tag:env=prod,role=db", []Host{h}) {
+		t.Fatal("expected selector with a mismatched tag not to cover the host")
+	}
+	if !h.Covers("fd00::5") || !h.Covers("WEB01") || h.Covers("10.0.0.50") {
+		t.Fatal("unexpected address matching")
+	}
+}
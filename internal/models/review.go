@@ -0,0 +1,178 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Review campaign statuses.
+const (
+	ReviewCampaignOpen   = "open"
+	ReviewCampaignClosed = "closed"
+)
+
+// What happens to items still pending when a campaign reaches its deadline.
+const (
+	ReviewOnExpiryRevoke = "revoke"
+	ReviewOnExpiryFlag   = "flag"
+)
+
+// Review item kinds.
+const (
+	ReviewItemMember = "member"
+	ReviewItemAccess = "access"
+	ReviewItemGuest  = "guest"
+)
+
+// Review item statuses. Auto-revoked and flagged items were left undecided
+// past the campaign deadline.
+const (
+	ReviewItemPending     = "pending"
+	ReviewItemConfirmed   = "confirmed"
+	ReviewItemRevoked     = "revoked"
+	ReviewItemAutoRevoked = "auto-revoked"
+	ReviewItemFlagged     = "flagged"
+)
+
+// ErrLastOwner is returned when a review would remove the last owner of a group.
+var ErrLastOwner = errors.New("cannot remove the last owner of the group")
+
+// ReviewCampaign is a recertification campaign started by an admin. Every
+// membership, group access and guest grant in scope becomes a ReviewItem
+// that a group owner must confirm or revoke before Deadline.
+type ReviewCampaign struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	Name        string     `gorm:"not null;index:idx_reviewcampaignname_deletedat,unique"`
+	Description string     `gorm:"default:null"`
+	Deadline    time.Time  `gorm:"not null"`
+	OnExpiry    string     `gorm:"not null;default:flag"` // revoke or flag
+	Status      string     `gorm:"not null;default:open;index"`
+	CreatedByID uuid.UUID  `gorm:"type:uuid;not null"`
+	CreatedBy   User       `gorm:"foreignKey:CreatedByID"`
+	ClosedAt    *time.Time `gorm:"default:null"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index:idx_reviewcampaignname_deletedat"`
+}
+
+// BeforeCreate generates a UUID for ReviewCampaign before insertion.
+func (c *ReviewCampaign) BeforeCreate(*gorm.DB) (err error) {
+	c.ID = uuid.New()
+	return
+}
+
+// ReviewItem is one membership (UserGroup), GroupAccess or GroupGuestAccess
+// under review. Summary is a snapshot taken when the campaign started, so the
+// report stays readable after the subject is revoked.
+type ReviewItem struct {
+	ID         uuid.UUID      `gorm:"type:uuid;primaryKey"`
+	CampaignID uuid.UUID      `gorm:"type:uuid;not null;index"`
+	Campaign   ReviewCampaign `gorm:"foreignKey:CampaignID"`
+	GroupID    uuid.UUID      `gorm:"type:uuid;not null;index"`
+	Group      Group          `gorm:"foreignKey:GroupID"`
+	Kind       string         `gorm:"not null"` // member, access, guest
+	SubjectID  uuid.UUID      `gorm:"type:uuid;not null"`
+	Summary    string         `gorm:"not null"`
+	Status     string         `gorm:"not null;default:pending;index"`
+	DeciderID  *uuid.UUID     `gorm:"type:uuid"`
+	Decider    *User          `gorm:"foreignKey:DeciderID"`
+	Comment    string         `gorm:"default:null"`
+	DecidedAt  *time.Time     `gorm:"default:null"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// BeforeCreate generates a UUID for ReviewItem before insertion.
+func (i *ReviewItem) BeforeCreate(*gorm.DB) (err error) {
+	i.ID = uuid.New()
+	return
+}
+
+// RevokeReviewSubject deletes the membership or grant an item refers to. A
+// subject already gone is not an error. It returns the ID of the user whose
+// group cache must be invalidated, if any.
+func RevokeReviewSubject(tx *gorm.DB, item *ReviewItem) (*uuid.UUID, error) {
+	switch item.Kind {
+	case ReviewItemMember:
+		var ug UserGroup
+		if err := tx.Where("id = ?", item.SubjectID).First(&ug).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		if ug.Role == GroupRoleOwner {
+			var owners int64
+			if err := tx.Model(&UserGroup{}).Where("group_id = ? AND role = ?", ug.GroupID, GroupRoleOwner).Count(&owners).Error; err != nil {
+				return nil, err
+			}
+			if owners <= 1 {
+				return nil, ErrLastOwner
+			}
+		}
+		if err := tx.Delete(&ug).Error; err != nil {
+			return nil, err
+		}
+		return &ug.UserID, nil
+	case ReviewItemAccess:
+		return nil, tx.Where("id = ?", item.SubjectID).Delete(&GroupAccess{}).Error
+	case ReviewItemGuest:
+		return nil, tx.Where("id = ?", item.SubjectID).Delete(&GroupGuestAccess{}).Error
+	}
+	return nil, fmt.Errorf("unknown review item kind %q", item.Kind)
+}
+
+// EnforceReviewDeadlines closes the open campaigns whose deadline has passed.
+// Their pending items are revoked or flagged according to the campaign
+// OnExpiry; an item that cannot be revoked (last owner) is flagged instead.
+func EnforceReviewDeadlines(db *gorm.DB, now time.Time) (revoked, flagged int, err error) {
+	var campaigns []ReviewCampaign
+	if err := db.Where("status = ? AND deadline <= ?", ReviewCampaignOpen, now).Find(&campaigns).Error; err != nil {
+		return 0, 0, fmt.Errorf("error retrieving review campaigns: %w", err)
+	}
+	for _, c := range campaigns {
+		var items []ReviewItem
+		if err := db.Where("campaign_id = ? AND status = ?", c.ID, ReviewItemPending).Find(&items).Error; err != nil {
+			return revoked, flagged, fmt.Errorf("error retrieving review items: %w", err)
+		}
+		for i := range items {
+			item := &items[i]
+			status, comment := ReviewItemFlagged, "not reviewed before the deadline"
+			var affected *uuid.UUID
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if c.OnExpiry == ReviewOnExpiryRevoke {
+					var rErr error
+					affected, rErr = RevokeReviewSubject(tx, item)
+					switch {
+					case errors.Is(rErr, ErrLastOwner):
+						comment = "not reviewed before the deadline; kept as the last owner"
+					case rErr != nil:
+						return rErr
+					default:
+						status = ReviewItemAutoRevoked
+					}
+				}
+				return tx.Model(item).Updates(map[string]any{"status": status, "comment": comment, "decided_at": now}).Error
+			})
+			if err != nil {
+				return revoked, flagged, fmt.Errorf("error enforcing review item %s: %w", item.ID, err)
+			}
+			if affected != nil {
+				InvalidateGroupsCache(*affected)
+			}
+			if status == ReviewItemAutoRevoked {
+				revoked++
+			} else {
+				flagged++
+			}
+		}
+		if err := db.Model(&c).Updates(map[string]any{"status": ReviewCampaignClosed, "closed_at": now}).Error; err != nil {
+			return revoked, flagged, fmt.Errorf("error closing review campaign %s: %w", c.Name, err)
+		}
+	}
+	return revoked, flagged, nil
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RevokedKey is an SSH public key revoked bastion-wide, for instance after a
+// leak. Fingerprint is the base64 SHA256 of the key, in the format of the
+// Fingerprint columns of the key tables; PublicKey is empty when the key was
+// revoked by fingerprint only.
+type RevokedKey struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Fingerprint string    `gorm:"not null;uniqueIndex"`
+	PublicKey   string
+	Reason      string    `gorm:"not null"`
+	AddedByID   uuid.UUID `gorm:"type:uuid;not null"`
+	AddedBy     User      `gorm:"foreignKey:AddedByID"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// BeforeCreate generates a UUID for RevokedKey before insertion.
+func (rk *RevokedKey) BeforeCreate(*gorm.DB) (err error) {
+	rk.ID = uuid.New()
+	return
+}
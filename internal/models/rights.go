@@ -52,18 +52,34 @@ func isOwnerOrACLKeeper(ug *UserGroup) bool {
 }
 
 // CanDo returns true if the user has permission to perform the given right on the target.
+// The built-in roles are checked first; custom roles assigned to the account
+// (see CustomRole) can only grant additional rights.
 func (u *User) CanDo(db *gorm.DB, right string, target string) bool {
 	if u == nil {
 		return false
 	}
+	return u.canDoBuiltin(db, right, target) || u.canDoCustom(db, right, target)
+}
 
+// CanUse reports whether right is available to the user on some target. It is
+// the pre-dispatch check of the session and filters the help and
+// autocompletion; the command itself then checks CanDo on its actual target.
+func (u *User) CanUse(db *gorm.DB, right string) bool {
+	if u == nil {
+		return false
+	}
+	return u.canDoBuiltin(db, right, "") || u.canUseCustom(db, right)
+}
+
+// canDoBuiltin implements the default roles: admin, superowner, user and the group roles.
+func (u *User) canDoBuiltin(db *gorm.DB, right string, target string) bool {
 	switch right {
 	// Account
 	case "accountAddAccess":
 		return u.IsAdmin()
 	case "accountCreate":
 		return u.IsAdmin()
-	case "accountDelAccess":
+	case "accountDelAccess", "accountModifyAccess":
 		return u.IsAdmin()
 	case "accountDelete":
 		return u.IsAdmin()
@@ -73,9 +89,11 @@ func (u *User) CanDo(db *gorm.DB, right string, target string) bool {
 		return u.IsAdmin()
 	case "accountListAccess":
 		return u.IsAdmin()
+	case "accountExplainAccess":
+		return u.IsAdmin()
 	case "accountListEgressKeys":
 		return u.IsAdmin()
-	case "accountListIngressKeys":
+	case "accountListIngressKeys", "accountAddIngressKey":
 		return u.IsAdmin()
 	case "accountModify":
 		return u.IsAdmin()
@@ -87,6 +105,10 @@ func (u *User) CanDo(db *gorm.DB, right string, target string) bool {
 		return u.IsAdmin()
 	case "pivAddTrustAnchor", "pivListTrustAnchors", "pivRemoveTrustAnchor":
 		return u.canDoRestricted(db, right)
+	case "ingressAddTrustedCA", "ingressListTrustedCAs", "ingressRemoveTrustedCA":
+		return u.IsAdmin()
+	case "revocationAddKey", "revocationListKeys", "revocationRemoveKey":
+		return u.IsAdmin()
 	case "whoHasAccessTo":
 		return u.IsAdmin()
 
@@ -96,6 +118,10 @@ func (u *User) CanDo(db *gorm.DB, right string, target string) bool {
 	// Bastion Config
 	case "bastionConfig":
 		return u.IsAdmin()
+	case "stateApply", "stateExport":
+		return u.IsAdmin()
+	case "bastionShowUserCA", "bastionRotateUserCA", "bastionShowHostCA", "bastionRotateHostCA":
+		return u.IsAdmin()
 
 	case "realmCreate", "realmDelete", "realmList", "realmInfo":
 		return u.canDoRestricted(db, right)
@@ -103,8 +129,31 @@ func (u *User) CanDo(db *gorm.DB, right string, target string) bool {
 	case "restrictedGrantAdd", "restrictedGrantDel", "restrictedGrantList":
 		return u.IsAdmin() || u.IsSuperOwner()
 
+	case "roleCreate", "roleModify", "roleDelete", "roleList", "roleAssign", "roleUnassign":
+		return u.IsAdmin()
+
+	// Host inventory
+	case "hostAdd", "hostDelete":
+		return u.IsAdmin()
+	case "hostTag":
+		if u.IsAdmin() || u.IsSuperOwner() {
+			return true
+		}
+		userGroups, err := u.getGroups(db)
+		if err != nil {
+			return false
+		}
+		return u.canDoInGroup(userGroups, target, func(ug *UserGroup) bool { return ug.IsOwner() })
+	case "hostList", "hostInfo":
+		return true
+
+	case "breakGlass":
+		return u.canDoRestricted(db, right)
+	case "breakGlassList", "breakGlassAck":
+		return u.IsAdmin()
+
 	// Group
-	case "groupAddAccess", "groupDelAccess":
+	case "groupAddAccess", "groupDelAccess", "groupModifyAccess", "groupImportAccesses":
 		if u.IsAdmin() {
 			return true
 		}
@@ -136,7 +185,7 @@ func (u *User) CanDo(db *gorm.DB, right string, target string) bool {
 	case "groupListAliases":
 		return u.CanViewGroupInfo(db, target)
 
-	case "groupSetMFA":
+	case "groupSetMFA", "groupSetJustification", "groupExtendMember":
 		if u.IsAdmin() {
 			return true
 		}
@@ -149,7 +198,7 @@ func (u *User) CanDo(db *gorm.DB, right string, target string) bool {
 		}
 		return u.canDoInGroup(userGroups, target, func(ug *UserGroup) bool { return ug.IsOwner() })
 
-	case "groupAddMember", "groupDelMember":
+	case "groupAddMember", "groupDelMember", "groupImportMembers", "groupAddSubgroup", "groupDelSubgroup":
 		if u.IsAdmin() {
 			return true
 		}
@@ -162,7 +211,7 @@ func (u *User) CanDo(db *gorm.DB, right string, target string) bool {
 		}
 		return u.canDoInGroup(userGroups, target, isOwnerOrACLKeeper)
 
-	case "groupAddGuestAccess", "groupDelGuestAccess":
+	case "groupAddGuestAccess", "groupDelGuestAccess", "groupModifyGuestAccess":
 		if u.IsAdmin() {
 			return true
 		}
@@ -179,7 +228,7 @@ func (u *User) CanDo(db *gorm.DB, right string, target string) bool {
 		return u.CanViewGuestGrantList(db, target)
 
 	// Group: DB Accesses
-	case "groupAddDBAccess", "groupDelDBAccess":
+	case "groupAddDBAccess", "groupDelDBAccess", "groupModifyDBAccess":
 		if u.IsAdmin() {
 			return true
 		}
@@ -196,7 +245,7 @@ func (u *User) CanDo(db *gorm.DB, right string, target string) bool {
 		return u.CanViewGroupInfo(db, target)
 
 	// Group: Guest DB Accesses
-	case "groupAddGuestDBAccess", "groupDelGuestDBAccess":
+	case "groupAddGuestDBAccess", "groupDelGuestDBAccess", "groupModifyGuestDBAccess":
 		if u.IsAdmin() {
 			return true
 		}
@@ -229,10 +278,41 @@ func (u *User) CanDo(db *gorm.DB, right string, target string) bool {
 	case "groupListDBAliases":
 		return u.CanViewGroupInfo(db, target)
 
+	// Group: Access requests
+	case "accessRequest", "accessRequestList":
+		return true
+	case "accessRequestApprove", "accessRequestDeny":
+		if u.IsAdmin() {
+			return true
+		}
+		if u.IsSuperOwner() {
+			return true
+		}
+		userGroups, err := u.getGroups(db)
+		if err != nil {
+			return false
+		}
+		return u.canDoInGroup(userGroups, target, isManagerOrAbove)
+
+	// Group: Access reviews
+	case "reviewStart", "reviewReport":
+		return u.IsAdmin()
+	case "reviewList":
+		return true
+	case "reviewDecide":
+		if u.IsAdmin() || u.IsSuperOwner() {
+			return true
+		}
+		userGroups, err := u.getGroups(db)
+		if err != nil {
+			return false
+		}
+		return u.canDoInGroup(userGroups, target, func(ug *UserGroup) bool { return ug.IsOwner() })
+
 	case "groupCreate", "groupDelete":
 		return u.IsAdmin()
 
-	case "groupGenerateEgressKey":
+	case "groupGenerateEgressKey", "groupRotateEgressKey", "groupDeployEgressKey":
 		if u.IsAdmin() {
 			return true
 		}
@@ -261,16 +341,18 @@ func (u *User) CanDo(db *gorm.DB, right string, target string) bool {
 		return true
 	case "selfAddIngressKey":
 		return true
-	case "selfDelAccess":
+	case "selfDelAccess", "selfModifyAccess":
 		return true
 	case "selfDelAlias":
 		return true
-	case "selfDelIngressKey":
+	case "selfDelIngressKey", "selfModifyIngressKey":
 		return true
-	case "selfGenerateEgressKey":
+	case "selfGenerateEgressKey", "selfDeployEgressKey":
 		return true
 	case "selfListAccesses":
 		return true
+	case "selfExplainAccess":
+		return true
 	case "selfListAliases":
 		return true
 	case "selfListEgressKeys":
@@ -291,7 +373,7 @@ func (u *User) CanDo(db *gorm.DB, right string, target string) bool {
 		return true
 
 	// Self: DB Accesses
-	case "selfAddDBAccess", "selfDelDBAccess", "selfListDBAccesses":
+	case "selfAddDBAccess", "selfDelDBAccess", "selfModifyDBAccess", "selfListDBAccesses":
 		return true
 
 	// Self: DB Aliases
@@ -386,7 +468,7 @@ func (u *User) getGroups(db *gorm.DB) ([]UserGroup, error) {
 	}
 
 	var userGroups []UserGroup
-	if err := db.Preload("Group").Where("user_id = ?", u.ID).Find(&userGroups).Error; err != nil {
+	if err := db.Preload("Group").Where("user_id = ?", u.ID).Where(MembershipActiveClause, time.Now()).Find(&userGroups).Error; err != nil {
 		return nil, fmt.Errorf("error retrieving user groups: %w", err)
 	}
 
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -39,10 +41,19 @@ type IngressKey struct {
 	Comment     string
 	ExpiresAt   *time.Time `gorm:"default:null"`
 	PIVAttested bool       `gorm:"type:boolean;default:false"`
-	User        User       `gorm:"foreignKey:UserID"`
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	// AllowedFrom lists the source CIDRs the key may log in from
+	// (comma-separated, empty = anywhere).
+	AllowedFrom       string
+	NoPortForwarding  bool `gorm:"type:boolean;default:false"`
+	NoAgentForwarding bool `gorm:"type:boolean;default:false"`
+	// DisabledAt is set when the sync disables the key for breaking the
+	// ingress key policy; DisabledReason says which rule.
+	DisabledAt     *time.Time `gorm:"default:null"`
+	DisabledReason string
+	User           User `gorm:"foreignKey:UserID"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
 }
 
 // BeforeCreate generates a UUID for IngressKey before insertion.
@@ -51,6 +62,26 @@ func (ik *IngressKey) BeforeCreate(*gorm.DB) (err error) {
 	return
 }
 
+// AuthorizedKeysOptions returns the options written before the key in
+// authorized_keys, or "" when it has none. The expiry is repeated as
+// expiry-time so that sshd refuses the key even before the next sync.
+func (ik *IngressKey) AuthorizedKeysOptions() string {
+	var opts []string
+	if ik.AllowedFrom != "" {
+		opts = append(opts, fmt.Sprintf(`from="%s"`, ik.AllowedFrom))
+	}
+	if ik.ExpiresAt != nil {
+		opts = append(opts, fmt.Sprintf(`expiry-time="%s"`, ik.ExpiresAt.Local().Format("200601021504")))
+	}
+	if ik.NoPortForwarding {
+		opts = append(opts, "no-port-forwarding")
+	}
+	if ik.NoAgentForwarding {
+		opts = append(opts, "no-agent-forwarding")
+	}
+	return strings.Join(opts, ",")
+}
+
 type SelfEgressKey struct {
 	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
 	UserID      uuid.UUID `gorm:"type:uuid;not null;index;constraint:OnDelete:CASCADE"`
@@ -71,22 +102,91 @@ func (sek *SelfEgressKey) BeforeCreate(*gorm.DB) (err error) {
 	return
 }
 
+// Group egress key states. A rotation adds a "next" key next to the
+// "active" one; once the next key is verified on every target it becomes
+// active and the previous key "retiring" until its grace period ends, after
+// which it is "retired" and never offered again.
+const (
+	EgressKeyActive   = "active"
+	EgressKeyNext     = "next"
+	EgressKeyRetiring = "retiring"
+	EgressKeyRetired  = "retired"
+)
+
+// UsableEgressKeyStates lists the states whose keys are offered to targets.
+var UsableEgressKeyStates = []string{EgressKeyActive, EgressKeyNext, EgressKeyRetiring}
+
 type GroupEgressKey struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	GroupID        uuid.UUID  `gorm:"type:uuid;not null;index;constraint:OnDelete:CASCADE"`
+	PubKey         string     `gorm:"not null"`
+	PrivKey        string     `gorm:"not null"`
+	Type           string     `gorm:"not null"`
+	Size           int        `gorm:"not null"`
+	Fingerprint    string     `gorm:"not null"`
+	State          string     `gorm:"not null;default:active;index"`
+	StateChangedAt *time.Time `gorm:"default:null"`
+	RetireAfter    *time.Time `gorm:"default:null"` // end of the overlap granted by a rotation
+	Group          Group      `gorm:"foreignKey:GroupID"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+}
+
+// BeforeCreate generates a UUID for GroupEgressKey before insertion.
+func (gek *GroupEgressKey) BeforeCreate(*gorm.DB) (err error) {
+	gek.ID = uuid.New()
+	if gek.State == "" {
+		gek.State = EgressKeyActive
+	}
+	return
+}
+
+// GroupEgressKeyVerification records that a next group egress key
+// authenticated on a target, which is required before it is promoted.
+type GroupEgressKeyVerification struct {
+	ID         uuid.UUID      `gorm:"type:uuid;primaryKey"`
+	KeyID      uuid.UUID      `gorm:"type:uuid;not null;index;constraint:OnDelete:CASCADE"`
+	Server     string         `gorm:"not null"`
+	Port       int64          `gorm:"not null"`
+	Username   string         `gorm:"not null"`
+	Key        GroupEgressKey `gorm:"foreignKey:KeyID"`
+	VerifiedAt time.Time      `gorm:"not null"`
+	CreatedAt  time.Time
+}
+
+// BeforeCreate generates a UUID for GroupEgressKeyVerification before insertion.
+func (v *GroupEgressKeyVerification) BeforeCreate(*gorm.DB) (err error) {
+	v.ID = uuid.New()
+	return
+}
+
+// Certificate authority kinds and states. A rotation adds a "next" CA that
+// is published for targets to trust but does not sign yet; promoting it
+// makes it "active" and the previous one "retired".
+const (
+	CertAuthorityUser    = "user"
+	CertAuthorityHost    = "host"
+	CertAuthorityActive  = "active"
+	CertAuthorityNext    = "next"
+	CertAuthorityRetired = "retired"
+)
+
+// CertAuthority is an SSH certificate authority key of the bastion. The
+// private key is encrypted with EGRESS_ENC_KEY when it is set.
+type CertAuthority struct {
 	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
-	GroupID     uuid.UUID `gorm:"type:uuid;not null;index;constraint:OnDelete:CASCADE"`
-	PubKey      string    `gorm:"not null"`
-	PrivKey     string    `gorm:"not null"`
-	Type        string    `gorm:"not null"`
-	Size        int       `gorm:"not null"`
+	Kind        string    `gorm:"not null;index"`
+	State       string    `gorm:"not null;index"`
+	PublicKey   string    `gorm:"not null"`
+	PrivateKey  string    `gorm:"not null"`
 	Fingerprint string    `gorm:"not null"`
-	Group       Group     `gorm:"foreignKey:GroupID"`
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
-	DeletedAt   gorm.DeletedAt `gorm:"index"`
 }
 
-// BeforeCreate generates a UUID for GroupEgressKey before insertion.
-func (gek *GroupEgressKey) BeforeCreate(*gorm.DB) (err error) {
-	gek.ID = uuid.New()
+// BeforeCreate generates a UUID for CertAuthority before insertion.
+func (ca *CertAuthority) BeforeCreate(*gorm.DB) (err error) {
+	ca.ID = uuid.New()
 	return
 }
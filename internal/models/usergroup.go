@@ -76,12 +76,14 @@ func (u *User) BeforeCreate(*gorm.DB) (err error) {
 }
 
 type Group struct {
-	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
-	Name        string    `gorm:"not null;index:idx_groupname_deletedat,unique"`
-	MFARequired bool      `gorm:"type:boolean;default:false"` // JIT MFA: require TOTP when connecting via this group
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	DeletedAt   gorm.DeletedAt `gorm:"index:idx_groupname_deletedat"`
+	ID                    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Name                  string    `gorm:"not null;index:idx_groupname_deletedat,unique"`
+	MFARequired           bool      `gorm:"type:boolean;default:false"` // JIT MFA: require TOTP when connecting via this group
+	JustificationRequired bool      `gorm:"type:boolean;default:false"` // require a reason / ticket ID when connecting via this group
+	JustificationPattern  string    `gorm:"default:null"`               // regex the whole justification must match; empty accepts any
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	DeletedAt             gorm.DeletedAt `gorm:"index:idx_groupname_deletedat"`
 }
 
 // BeforeCreate generates a UUID for Group before insertion.
@@ -91,17 +93,23 @@ func (g *Group) BeforeCreate(*gorm.DB) (err error) {
 }
 
 type UserGroup struct {
-	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
-	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
-	GroupID   uuid.UUID `gorm:"type:uuid;not null;index"`
-	Group     Group     `gorm:"foreignKey:GroupID;references:ID"`
-	Role      string    `gorm:"not null"` // "owner", "gatekeeper", "aclkeeper", "member", "guest"
-	User      User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;where:deleted_at IS NULL"`
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index"`
+	GroupID   uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Group     Group      `gorm:"foreignKey:GroupID;references:ID"`
+	Role      string     `gorm:"not null"` // "owner", "gatekeeper", "aclkeeper", "member", "guest"
+	User      User       `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;where:deleted_at IS NULL"`
+	ExpiresAt *time.Time `gorm:"default:null;index"` // membership removed by the sync cycle after this time
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
+// MembershipActiveClause selects user_groups rows that have not expired; it
+// takes the current time as its only argument. Expired rows are removed by
+// the sync cycle but must not grant anything in the meantime.
+const MembershipActiveClause = "(user_groups.expires_at IS NULL OR user_groups.expires_at > ?)"
+
 // BeforeCreate generates a UUID for UserGroup before insertion.
 func (ug *UserGroup) BeforeCreate(*gorm.DB) (err error) {
 	ug.ID = uuid.New()
@@ -132,3 +140,25 @@ func (ug *UserGroup) IsMember() bool {
 func (ug *UserGroup) IsGuest() bool {
 	return ug.Role == GroupRoleGuest
 }
+
+// ExpiryLabel describes when the membership ends: "permanent", "expired" or
+// the remaining time with the expiry date, e.g. "2d5h left (2026-10-20 14:00)".
+func (ug *UserGroup) ExpiryLabel(now time.Time) string {
+	if ug.ExpiresAt == nil {
+		return "permanent"
+	}
+	left := ug.ExpiresAt.Sub(now)
+	if left <= 0 {
+		return "expired"
+	}
+	var remaining string
+	switch days := int(left.Hours()) / 24; {
+	case days > 0:
+		remaining = fmt.Sprintf("%dd%dh", days, int(left.Hours())%24)
+	case left >= time.Hour:
+		remaining = fmt.Sprintf("%dh%dm", int(left.Hours()), int(left.Minutes())%60)
+	default:
+		remaining = fmt.Sprintf("%dm", int(left.Minutes())+1)
+	}
+	return fmt.Sprintf("%s left (%s)", remaining, ug.ExpiresAt.Format("2006-01-02 15:04"))
+}
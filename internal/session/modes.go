@@ -33,6 +33,7 @@ import (
 	"goBastion/internal/utils"
 	"goBastion/internal/utils/autocomplete"
 	"goBastion/internal/utils/dbConnector"
+	"goBastion/internal/utils/justification"
 	"goBastion/internal/utils/system"
 	"goBastion/internal/utils/totp"
 )
@@ -243,7 +244,12 @@ func runNonInteractiveMode(db *gorm.DB, currentUser *models.User, log *slog.Logg
 		runMoshServer(command, args, log)
 	} else if dbArgs, ok := parseDBRequest(command, args); ok {
 		if len(dbArgs) < 1 {
-			fmt.Fprintln(os.Stderr, "⛔ Usage: bastion --db|-db [user@]host[:port[:protocol]] [--mysql|--pg|--redis] [--dbname name]")
+			fmt.Fprintln(os.Stderr, "⛔ Usage: bastion --db|-db [user@]host[:port[:protocol]] [--mysql|--pg|--redis] [--dbname name] [--reason text]")
+			return
+		}
+		dbArgs, reason, err := justification.ExtractArgs(dbArgs)
+		if err != nil || len(dbArgs) < 1 {
+			fmt.Fprintln(os.Stderr, "⛔ Usage: bastion --db|-db [user@]host[:port[:protocol]] [--mysql|--pg|--redis] [--dbname name] [--reason text]")
 			return
 		}
 		requestedTarget := dbArgs[0]
@@ -279,6 +285,21 @@ func runNonInteractiveMode(db *gorm.DB, currentUser *models.User, log *slog.Logg
 			slog.String("access_source", details.AccessSource),
 		)
 		dbLog.Info("db_target_resolved")
+		if access.JustificationRequired {
+			if reason != "" {
+				err = justification.Validate(access.JustificationPattern, reason)
+				reason = strings.TrimSpace(reason)
+			} else {
+				reason, err = justification.Prompt(os.Stdin, os.Stdout, access.JustificationPattern, config.Get().MFA.MaxAttempts)
+			}
+			if err != nil {
+				dbLog.Warn("justification_failure", slog.String("error", err.Error()))
+				fmt.Fprintf(os.Stderr, "⛔ %v\n", err)
+				return
+			}
+			access.Justification = reason
+			dbLog = dbLog.With(slog.String("justification", reason))
+		}
 		dbLog.Info("db_session_start")
 		if err := dbConnector.Connect(db, *currentUser, access); err != nil {
 			dbLog.Warn("db_session_failed", slog.String("error", err.Error()))
@@ -661,6 +682,10 @@ func featureEnabled(feat string, cfg *config.Config) bool {
 		return cfg.TTYPlay.Enabled
 	case "alias_group":
 		return cfg.AliasGroup.Enabled
+	case "access_requests":
+		return cfg.AccessRequests.Enabled
+	case "break_glass":
+		return cfg.BreakGlass.Enabled
 	case "mosh":
 		return cfg.Mosh.Enabled && config.MoshAvailable()
 	}
@@ -702,6 +727,10 @@ func featureLabel(feat string) string {
 		return "TTY replay"
 	case "alias_group":
 		return "Group aliases"
+	case "access_requests":
+		return "Access requests"
+	case "break_glass":
+		return "Break-glass access"
 	}
 	return feat
 }
@@ -730,7 +759,7 @@ func executeCommand(db *gorm.DB, currentUser *models.User, log *slog.Logger, cmd
 
 	log = log.With(slog.String("user", currentUser.Username))
 	hasPerm := func(perm string) bool {
-		return currentUser.CanDo(db, perm, "")
+		return currentUser.CanUse(db, perm)
 	}
 
 	adapter := osadapter.NewLinuxAdapter()
@@ -873,7 +902,7 @@ func checkMFA(db *gorm.DB, user *models.User, log *slog.Logger) bool {
 		code = strings.TrimSpace(code)
 
 		// Try TOTP first
-		if totp.Verify(user.TOTPSecret, code) {
+		if totp.VerifyUser(db, log, user, code) {
 			log.Info("mfa_success", slog.String("event", "mfa_totp"), slog.String("user", user.Username), slog.String("from", ip))
 			return true
 		}
@@ -7,6 +7,7 @@ import (
 
 	"goBastion/internal/models"
 	"goBastion/internal/utils"
+	"goBastion/internal/utils/ingressca"
 	"goBastion/internal/utils/system"
 
 	"log/slog"
@@ -56,7 +57,14 @@ func preConnectionCheck(db *gorm.DB, currentUser models.User, log *slog.Logger)
 		log.Warn("last_login_update_failed", slog.String("user", currentUser.Username), slog.String("error", err.Error()))
 	}
 
-	log.Info("login", slog.String("user", currentUser.Username), slog.String("from", ip), slog.String("role", currentUser.Role))
+	attrs := []any{slog.String("user", currentUser.Username), slog.String("from", ip), slog.String("role", currentUser.Role)}
+	// Logins with a certificate of a trusted ingress CA record which one.
+	if cert, err := ingressca.SessionCertificate(); err != nil {
+		log.Warn("login_certificate_unreadable", slog.String("user", currentUser.Username), slog.String("error", err.Error()))
+	} else if cert != nil {
+		attrs = append(attrs, ingressca.LogAttrs(cert)...)
+	}
+	log.Info("login", attrs...)
 
 	return true
 }
@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/crypto/ssh"
 	"gorm.io/gorm"
@@ -15,7 +18,9 @@ import (
 	internaldb "goBastion/internal/db"
 	"goBastion/internal/models"
 	"goBastion/internal/osadapter"
+	"goBastion/internal/utils/cryptokey"
 	"goBastion/internal/utils/sshHostKey"
+	"goBastion/internal/utils/state"
 	gosync "goBastion/internal/utils/sync"
 	"goBastion/internal/utils/validation"
 )
@@ -33,6 +38,11 @@ func Run(db *gorm.DB, log *slog.Logger, adapter osadapter.SystemAdapter) int {
 	disableTOTPUser := flag.String("disableTOTP", "", "Disable TOTP + backup codes for a user (recovery)")
 	disablePasswordUser := flag.String("disablePassword", "", "Disable password MFA for a user (recovery)")
 	syncUserFlag := flag.String("syncUser", "", "Sync one DB user to the OS (privileged helper)")
+	applyStateFile := flag.String("applyState", "", "Apply a YAML/JSON state document (groups, members, accesses, aliases)")
+	planFlag := flag.Bool("plan", false, "With --applyState: only print the plan")
+	pruneFlag := flag.Bool("prune", false, "With --applyState: delete what the document does not list")
+	exportStateFlag := flag.Bool("exportState", false, "Export groups, members, accesses and aliases as a YAML state document to stdout")
+	rotateEncryptionKeyFlag := flag.Bool("rotateEncryptionKey", false, "Re-encrypt every stored secret with the current EGRESS_ENC_KEY")
 	flag.Parse()
 
 	syncer := gosync.New(db, adapter, *log)
@@ -82,6 +92,15 @@ func Run(db *gorm.DB, log *slog.Logger, adapter osadapter.SystemAdapter) int {
 	case *dbImportFlag:
 		return runDBImport(db, log)
 
+	case *applyStateFile != "":
+		return runApplyState(db, log, *applyStateFile, *planFlag, *pruneFlag)
+
+	case *exportStateFlag:
+		return runExportState(db, log)
+
+	case *rotateEncryptionKeyFlag:
+		return runRotateEncryptionKey(db, log)
+
 	default:
 		return runStartup(db, log, syncer)
 	}
@@ -112,6 +131,99 @@ func runDBImport(db *gorm.DB, log *slog.Logger) int {
 	return 0
 }
 
+// runApplyState prints the plan turning the database into the state document
+// at path and, unless planOnly, applies it in one transaction.
+func runApplyState(db *gorm.DB, log *slog.Logger, path string, planOnly, prune bool) int {
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot open state document: %v\n", err)
+		return 2
+	}
+	defer f.Close()
+	doc, err := state.Parse(f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid state document:\n%v\n", err)
+		return 2
+	}
+
+	opts := state.Options{Prune: prune}
+	changes, err := state.Plan(db, doc, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Plan failed:\n%v\n", err)
+		return 1
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(os.Stderr, "✅ The database already matches the document.")
+		return 0
+	}
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	fmt.Fprintf(os.Stderr, "Plan: %d change(s).\n", len(changes))
+	if planOnly {
+		return 0
+	}
+
+	applied, err := state.Apply(db, doc, opts)
+	if err != nil {
+		log.Error("state_apply_failed", slog.String("file", path), slog.Any("error", err))
+		fmt.Fprintf(os.Stderr, "Apply failed, nothing was changed: %v\n", err)
+		return 1
+	}
+	log.Info("state_applied", slog.String("file", path), slog.Int("changes", len(applied)), slog.Bool("prune", prune))
+	fmt.Fprintf(os.Stderr, "✅ %d change(s) applied.\n", len(applied))
+	return 0
+}
+
+// runExportState writes the current state document to stdout.
+func runExportState(db *gorm.DB, log *slog.Logger) int {
+	doc, err := state.Export(db)
+	if err == nil {
+		err = state.Write(os.Stdout, doc)
+	}
+	if err != nil {
+		log.Error("state_export_failed", slog.Any("error", err))
+		fmt.Fprintf(os.Stderr, "Export failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runRotateEncryptionKey re-encrypts every stored secret with the current
+// EGRESS_ENC_KEY in one transaction and reports the values still on an old
+// key, which no key of EGRESS_ENC_KEY or EGRESS_ENC_KEY_PREVIOUS opens.
+func runRotateEncryptionKey(db *gorm.DB, log *slog.Logger) int {
+	fmt.Fprintf(os.Stderr, "Re-encrypting stored secrets with key %s...\n", cryptokey.CurrentKeyID())
+	results, err := cryptokey.Rotate(db)
+	if err != nil {
+		log.Error("encryption_key_rotation_failed", slog.Any("error", err))
+		fmt.Fprintf(os.Stderr, "Rotation failed, nothing was changed: %v\n", err)
+		return 1
+	}
+	remaining := 0
+	for _, r := range results {
+		fmt.Printf("%s.%s: %d re-encrypted, %d already current, %d still on old keys\n",
+			r.Table, r.Column, r.ReEncrypted, r.Current, r.RemainingCount())
+		ids := make([]string, 0, len(r.Remaining))
+		for id := range r.Remaining {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			fmt.Printf("  key %s: %d\n", id, r.Remaining[id])
+		}
+		remaining += r.RemainingCount()
+		log.Info("encryption_key_rotated", slog.String("table", r.Table), slog.Int("re_encrypted", r.ReEncrypted),
+			slog.Int("current", r.Current), slog.Int("remaining", r.RemainingCount()))
+	}
+	if remaining > 0 {
+		fmt.Fprintf(os.Stderr, "⚠️ %d value(s) are still on old keys: add those keys to EGRESS_ENC_KEY_PREVIOUS and run again.\n", remaining)
+		return 1
+	}
+	fmt.Fprintln(os.Stderr, "✅ Every stored secret is on the current key. EGRESS_ENC_KEY_PREVIOUS can be cleared.")
+	return 0
+}
+
 // runStartup is the automatic startup sequence:
 //  1. Sync DB → OS if data already exists (container restart).
 //  2. Return 0 if an admin user exists, return 3 otherwise.
@@ -214,7 +326,17 @@ func createFirstAdminUser(db *gorm.DB, log *slog.Logger, syncer *gosync.Syncer,
 		return fmt.Errorf("error syncing system users: %w", err)
 	}
 
-	if err = cmdaccount.CreateUser(db, adapter, username, pubKey); err != nil {
+	fmt.Print("Enter the key expiry in days (empty or 0 = never): ")
+	expiry, err := reader.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("error reading key expiry: %w", err)
+	}
+	opts, err := firstAdminKeyOptions(strings.TrimSpace(expiry), time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err = cmdaccount.CreateAdminUser(db, adapter, username, pubKey, opts); err != nil {
 		return fmt.Errorf("error creating user: %w", err)
 	}
 	if err = switchToAdmin(db, adapter, username); err != nil {
@@ -226,6 +348,24 @@ func createFirstAdminUser(db *gorm.DB, log *slog.Logger, syncer *gosync.Syncer,
 	return nil
 }
 
+// firstAdminKeyOptions returns the key options of the first admin for an
+// expiry given in days, where empty or 0 means the key never expires.
+func firstAdminKeyOptions(days string, now time.Time) (cmdaccount.IngressKeyOptions, error) {
+	var opts cmdaccount.IngressKeyOptions
+	if days == "" {
+		return opts, nil
+	}
+	n, err := strconv.Atoi(days)
+	if err != nil || n < 0 {
+		return opts, fmt.Errorf("invalid key expiry %q: use a number of days", days)
+	}
+	if n > 0 {
+		expiresAt := now.AddDate(0, 0, n)
+		opts.ExpiresAt = &expiresAt
+	}
+	return opts, nil
+}
+
 // switchToAdmin toggles the user's role to admin and updates sudoers.
 func switchToAdmin(db *gorm.DB, adapter osadapter.SystemAdapter, username string) error {
 	var u models.User
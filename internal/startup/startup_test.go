@@ -6,11 +6,13 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
 
 	cmdaccount "goBastion/internal/commands/account"
+	"goBastion/internal/config"
 	"goBastion/internal/models"
 	"goBastion/internal/osadapter"
 )
@@ -24,7 +26,7 @@ func newTestDB(t *testing.T) *gorm.DB {
 		t.Fatalf("open test DB: %v", err)
 	}
 	if err := db.AutoMigrate(
-		&models.User{}, &models.IngressKey{}, &models.SelfEgressKey{},
+		&models.User{}, &models.IngressKey{}, &models.SelfEgressKey{}, &models.RevokedKey{},
 		&models.GroupEgressKey{}, &models.SelfAccess{}, &models.GroupAccess{},
 		&models.Group{}, &models.UserGroup{}, &models.Aliases{},
 		&models.KnownHostsEntry{}, &models.PIVTrustAnchor{},
@@ -80,6 +82,38 @@ func TestBootstrap_CreateDBIngressKey_Invalid(t *testing.T) {
 	}
 }
 
+func TestBootstrap_FirstAdminKeyFollowsAdminPolicy(t *testing.T) {
+	now := time.Now()
+	if opts, err := firstAdminKeyOptions("", now); err != nil || opts.ExpiresAt != nil {
+		t.Fatalf("empty expiry = %+v, %v, want no expiry", opts, err)
+	}
+	opts, err := firstAdminKeyOptions("30", now)
+	if err != nil || opts.ExpiresAt == nil || !opts.ExpiresAt.Equal(now.AddDate(0, 0, 30)) {
+		t.Fatalf("30 days = %+v, %v", opts, err)
+	}
+	if _, err := firstAdminKeyOptions("-1", now); err == nil {
+		t.Fatal("expected a negative expiry to be refused")
+	}
+
+	config.ResetForTesting()
+	t.Cleanup(config.ResetForTesting)
+	policy := &config.Load().IngressKeyPolicy
+	policy.RequireExpiry = true
+	policy.AdminHardwareKeys = true
+
+	db := newTestDB(t)
+	mock := osadapter.NewMockAdapter()
+	err = cmdaccount.CreateAdminUser(db, mock, "admin", testPubKey, opts)
+	if err == nil || !strings.Contains(err.Error(), "admin keys") {
+		t.Fatalf("expected the admin hardware key rule to apply to the first admin, got %v", err)
+	}
+	var count int64
+	db.Model(&models.User{}).Where("username = ?", "admin").Count(&count)
+	if count != 0 {
+		t.Fatalf("first admin created despite the policy")
+	}
+}
+
 func TestRunDisableTOTP_PreservesPasswordMFA(t *testing.T) {
 	db := newTestDB(t)
 	user := models.User{
@@ -0,0 +1,150 @@
+// Package accessedit holds the optional field flags of the access
+// modification commands (selfModifyAccess, groupModifyDBAccess…) and
+// validates them the way the matching add commands do.
+//
+// Only the flags given on the command line are applied; --ttl 0 removes the
+// expiry, --starts now makes a pending access usable immediately and an
+// empty --schedule, --from or --password clears the field.
+package accessedit
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"goBastion/internal/utils/cryptokey"
+	"goBastion/internal/utils/schedule"
+	"goBastion/internal/utils/validation"
+)
+
+// ErrNoField is returned by Updates when no field flag was given.
+var ErrNoField = errors.New("nothing to modify: give at least one field flag")
+
+// Fields is the set of field flags registered on a modify command.
+type Fields struct {
+	fs          *flag.FlagSet
+	dbAccess    bool
+	comment     string
+	allowedFrom string
+	schedule    string
+	protocol    string
+	starts      string
+	database    string
+	password    string
+	ttlDays     int
+}
+
+// Register adds the field flags to fs. SSH accesses get --protocol, database
+// accesses --database and --password.
+func Register(fs *flag.FlagSet, dbAccess bool) *Fields {
+	f := &Fields{fs: fs, dbAccess: dbAccess}
+	fs.StringVar(&f.comment, "comment", "", "New comment")
+	fs.StringVar(&f.allowedFrom, "from", "", "New allowed source CIDRs (comma-separated, empty = any)")
+	fs.StringVar(&f.schedule, "schedule", "", "New access window (empty = always)")
+	fs.IntVar(&f.ttlDays, "ttl", 0, "New expiry in days from now, or from the start of a pending access (0 = never)")
+	fs.StringVar(&f.starts, "starts", "", "New start time (RFC 3339, \"YYYY-MM-DD HH:MM\" or YYYY-MM-DD; now = immediately)")
+	if dbAccess {
+		fs.StringVar(&f.database, "database", "", "New database name (empty = any)")
+		fs.StringVar(&f.password, "password", "", "New password (empty = prompt at connection)")
+	} else {
+		fs.StringVar(&f.protocol, "protocol", "", "New protocol restriction: ssh (all), scpupload, scpdownload, sftp, rsync")
+	}
+	return f
+}
+
+// Usage returns the field flags part of a usage line.
+func (f *Fields) Usage() string {
+	usage := "[--comment <comment>] [--from <CIDRs>] [--schedule <spec>] [--ttl <days>] [--starts <time>|now]"
+	if f.dbAccess {
+		return usage + " [--database <database>] [--password <password>]"
+	}
+	return usage + " [--protocol ssh|scpupload|scpdownload|sftp|rsync]"
+}
+
+// Updates validates the field flags given on the command line and returns
+// the columns to update. startsAt and expiresAt are the current values of
+// the entry, used to check that the result still starts before it expires.
+func (f *Fields) Updates(startsAt, expiresAt *time.Time, now time.Time) (map[string]any, error) {
+	given := make(map[string]bool)
+	f.fs.Visit(func(fl *flag.Flag) { given[fl.Name] = true })
+
+	updates := make(map[string]any)
+	if given["comment"] {
+		updates["comment"] = f.comment
+	}
+	if given["from"] {
+		if !validation.IsValidCIDRs(f.allowedFrom) {
+			return nil, fmt.Errorf("--from must be a comma-separated list of valid CIDR notation (e.g. 10.0.0.0/8,192.168.1.0/24)")
+		}
+		updates["allowed_from"] = f.allowedFrom
+	}
+	if given["schedule"] {
+		spec := strings.TrimSpace(f.schedule)
+		if spec != "" {
+			sched, err := schedule.Parse(spec)
+			if err != nil {
+				return nil, fmt.Errorf("invalid schedule: %w", err)
+			}
+			spec = sched.String()
+		}
+		updates["schedule"] = spec
+	}
+	if given["protocol"] {
+		if !validation.IsValidProtocol(f.protocol) {
+			return nil, fmt.Errorf("protocol must be one of: ssh, scpupload, scpdownload, sftp, rsync")
+		}
+		updates["protocol"] = f.protocol
+	}
+	if given["database"] {
+		updates["database"] = f.database
+	}
+	if given["password"] {
+		password := f.password
+		if password != "" {
+			enc, err := cryptokey.ReEncryptIfNeeded(password)
+			if err != nil {
+				return nil, fmt.Errorf("failed to process password: %w", err)
+			}
+			password = enc
+		}
+		updates["password"] = password
+	}
+	if given["starts"] {
+		startsAt = nil
+		if s := strings.TrimSpace(f.starts); s != "now" {
+			parsed, err := validation.ParseStartsAt(s, now)
+			if err != nil {
+				return nil, err
+			}
+			startsAt = parsed
+		}
+		updates["starts_at"] = startsAt
+	}
+	if given["ttl"] {
+		switch {
+		case f.ttlDays < 0:
+			return nil, fmt.Errorf("TTL must be zero (never) or a positive number of days")
+		case f.ttlDays == 0:
+			expiresAt = nil
+		default:
+			// As on creation, the TTL of a pending access runs from its start.
+			from := now
+			if startsAt != nil && startsAt.After(now) {
+				from = *startsAt
+			}
+			t := from.AddDate(0, 0, f.ttlDays)
+			expiresAt = &t
+		}
+		updates["expires_at"] = expiresAt
+	}
+	if len(updates) == 0 {
+		return nil, ErrNoField
+	}
+	if startsAt != nil && expiresAt != nil && !expiresAt.After(*startsAt) {
+		return nil, fmt.Errorf("the access would expire (%s) before it starts (%s)",
+			expiresAt.Format("2006-01-02 15:04"), startsAt.Format("2006-01-02 15:04"))
+	}
+	return updates, nil
+}
@@ -0,0 +1,87 @@
+package accessedit_test
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"testing"
+	"time"
+
+	"goBastion/internal/utils/accessedit"
+)
+
+func parse(t *testing.T, dbAccess bool, args ...string) *accessedit.Fields {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(&bytes.Buffer{})
+	fields := accessedit.Register(fs, dbAccess)
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("parse %v: %v", args, err)
+	}
+	return fields
+}
+
+func TestUpdates_OnlyGivenFields(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	updates, err := parse(t, false, "--comment", "", "--from", "10.0.0.0/8", "--protocol", "sftp").Updates(nil, nil, now)
+	if err != nil {
+		t.Fatalf("Updates: %v", err)
+	}
+	want := map[string]any{"comment": "", "allowed_from": "10.0.0.0/8", "protocol": "sftp"}
+	if len(updates) != len(want) {
+		t.Fatalf("updates = %v, want %v", updates, want)
+	}
+	for k, v := range want {
+		if updates[k] != v {
+			t.Errorf("updates[%q] = %v, want %v", k, updates[k], v)
+		}
+	}
+
+	if _, err := parse(t, false).Updates(nil, nil, now); !errors.Is(err, accessedit.ErrNoField) {
+		t.Fatalf("expected ErrNoField without field flags, got %v", err)
+	}
+}
+
+func TestUpdates_Validation(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	for _, args := range [][]string{
+		{"--from", "10.0.0.0"},
+		{"--schedule", "Funday 08:00-19:00"},
+		{"--protocol", "telnet"},
+		{"--ttl", "-1"},
+		{"--starts", "2001-01-01"},
+	} {
+		if _, err := parse(t, false, args...).Updates(nil, nil, now); err == nil {
+			t.Errorf("Updates(%v) should fail", args)
+		}
+	}
+}
+
+func TestUpdates_TTLAndStart(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	start := now.Add(48 * time.Hour)
+
+	// The TTL of a pending access runs from its start.
+	updates, err := parse(t, true, "--ttl", "3").Updates(&start, nil, now)
+	if err != nil {
+		t.Fatalf("Updates: %v", err)
+	}
+	if got := updates["expires_at"].(*time.Time); !got.Equal(start.AddDate(0, 0, 3)) {
+		t.Fatalf("expires_at = %v, want %v", got, start.AddDate(0, 0, 3))
+	}
+
+	// --ttl 0 removes the expiry, --starts now the start.
+	expiry := now.Add(time.Hour)
+	updates, err = parse(t, true, "--ttl", "0", "--starts", "now").Updates(&start, &expiry, now)
+	if err != nil {
+		t.Fatalf("Updates: %v", err)
+	}
+	if updates["expires_at"].(*time.Time) != nil || updates["starts_at"].(*time.Time) != nil {
+		t.Fatalf("expected both bounds cleared, got %v", updates)
+	}
+
+	// Moving the start past the current expiry is refused.
+	if _, err := parse(t, true, "--starts", "2026-05-20").Updates(nil, &expiry, now); err == nil {
+		t.Fatal("expected an error when the access would expire before it starts")
+	}
+}
@@ -14,7 +14,7 @@ import (
 // Completion returns autocomplete suggestions based on the current input and user permissions.
 func Completion(d prompt.Document, user *models.User, db *gorm.DB) []prompt.Suggest {
 	hasPerm := func(perm string) bool {
-		return user.CanDo(db, perm, "")
+		return user.CanUse(db, perm)
 	}
 
 	text := d.TextBeforeCursor()
@@ -5,7 +5,9 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"log/slog"
@@ -18,11 +20,27 @@ import (
 
 const (
 	envKey = "EGRESS_ENC_KEY"
+	// envPreviousKeys lists, comma-separated, the keys EGRESS_ENC_KEY
+	// replaced. They only decrypt, until --rotateEncryptionKey re-encrypts
+	// every value with the current key.
+	envPreviousKeys = "EGRESS_ENC_KEY_PREVIOUS"
+	// taggedPrefix starts the values encrypted with a known key ID:
+	// "enc:<key ID>:<base64(nonce || ciphertext)>". Older values are the
+	// bare base64 and are tried against every key.
+	taggedPrefix = "enc:"
 )
 
+// keyEntry is an AES-GCM key with its ID.
+type keyEntry struct {
+	id   string
+	aead cipher.AEAD
+}
+
 var (
-	gcm     cipher.AEAD
-	gcmOnce sync.Once
+	gcm      cipher.AEAD // current key, encrypts
+	gcmID    string
+	previous []keyEntry // decrypt only
+	gcmOnce  sync.Once
 )
 
 // Enabled reports whether egress key encryption is configured.
@@ -42,29 +60,107 @@ func Encrypt(plaintext string) (string, error) {
 		return "", fmt.Errorf("generate nonce: %w", err)
 	}
 	ct := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
-	return base64.StdEncoding.EncodeToString(ct), nil
+	return taggedPrefix + gcmID + ":" + base64.StdEncoding.EncodeToString(ct), nil
 }
 
-// Decrypt decrypts a base64(nonce || ciphertext) string produced by Encrypt.
-// Returns the plaintext or an error.
+// Decrypt decrypts a value produced by Encrypt with the current key or one
+// of the previous keys. Returns the plaintext or an error.
 func Decrypt(encoded string) (string, error) {
 	if !Enabled() {
 		return "", fmt.Errorf("egress key encryption not configured (set %s)", envKey)
 	}
-	data, err := base64.StdEncoding.DecodeString(encoded)
+	id, b64 := splitTag(encoded)
+	data, err := base64.StdEncoding.DecodeString(b64)
 	if err != nil {
 		return "", fmt.Errorf("base64 decode: %w", err)
 	}
-	ns := gcm.NonceSize()
+	keys := append([]keyEntry{{id: gcmID, aead: gcm}}, previous...)
+	if id != "" {
+		var found []keyEntry
+		for _, k := range keys {
+			if k.id == id {
+				found = append(found, k)
+			}
+		}
+		if len(found) == 0 {
+			return "", fmt.Errorf("encrypted with unknown key %s (set it in %s)", id, envPreviousKeys)
+		}
+		keys = found
+	}
+	for _, k := range keys {
+		var pt []byte
+		if pt, err = open(k.aead, data); err == nil {
+			return string(pt), nil
+		}
+	}
+	return "", err
+}
+
+func open(aead cipher.AEAD, data []byte) ([]byte, error) {
+	ns := aead.NonceSize()
 	if len(data) < ns {
-		return "", fmt.Errorf("ciphertext too short")
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	pt, err := aead.Open(nil, data[:ns], data[ns:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: %w", err)
+	}
+	return pt, nil
+}
+
+// splitTag returns the key ID and base64 payload of an encrypted value, with
+// an empty ID for the untagged values written before key IDs.
+func splitTag(raw string) (string, string) {
+	if rest, ok := strings.CutPrefix(raw, taggedPrefix); ok {
+		if id, b64, ok := strings.Cut(rest, ":"); ok {
+			return id, b64
+		}
+	}
+	return "", raw
+}
+
+// CurrentKeyID returns the ID of EGRESS_ENC_KEY, or "" when it is not set.
+func CurrentKeyID() string {
+	initGCM()
+	return gcmID
+}
+
+// KeyID returns the ID of the key an encrypted value names, or "" for a
+// plaintext value or an untagged one written before key IDs.
+func KeyID(raw string) string {
+	if !IsEncrypted(raw) {
+		return ""
 	}
-	nonce, ct := data[:ns], data[ns:]
-	pt, err := gcm.Open(nil, nonce, ct, nil)
+	id, _ := splitTag(raw)
+	return id
+}
+
+// Refresh returns raw encrypted with the current key. Values already
+// encrypted with it are returned unchanged with false; plaintext values are
+// encrypted and values of a previous key re-encrypted. It fails when
+// encryption is not configured or no configured key opens raw.
+func Refresh(raw string) (string, bool, error) {
+	if raw == "" {
+		return raw, false, nil
+	}
+	if !Enabled() {
+		return raw, false, fmt.Errorf("egress key encryption not configured (set %s)", envKey)
+	}
+	plaintext := raw
+	if IsEncrypted(raw) {
+		if KeyID(raw) == gcmID {
+			return raw, false, nil
+		}
+		var err error
+		if plaintext, err = Decrypt(raw); err != nil {
+			return raw, false, err
+		}
+	}
+	enc, err := Encrypt(plaintext)
 	if err != nil {
-		return "", fmt.Errorf("decrypt: %w", err)
+		return raw, false, err
 	}
-	return string(pt), nil
+	return enc, true, nil
 }
 
 // DecryptOrPassThrough returns the plaintext for both encrypted and legacy plaintext values.
@@ -95,11 +191,15 @@ func ReEncryptIfNeeded(plaintext string) (string, error) {
 	return Encrypt(plaintext)
 }
 
-// IsEncrypted returns true if the value looks like a valid encrypted blob.
-// A valid encrypted blob is base64-encoded, decodable, and has the exact size
+// IsEncrypted returns true if the value looks like a valid encrypted blob:
+// a tagged value, or an untagged blob that is base64-encoded, decodable, and has the exact size
 // of nonce (12) + ciphertext + tag (16) for AES-GCM, i.e. at least 28 bytes
 // but also must NOT look like a standard PEM or OpenSSH key.
 func IsEncrypted(raw string) bool {
+	if id, b64 := splitTag(raw); id != "" {
+		data, err := base64.StdEncoding.DecodeString(b64)
+		return err == nil && len(data) >= 28
+	}
 	data, err := base64.StdEncoding.DecodeString(raw)
 	if err != nil {
 		return false
@@ -126,9 +226,9 @@ func IsEncrypted(raw string) bool {
 	return true
 }
 
-// readKeyFromEnv reads EGRESS_ENC_KEY from env or fallback config file.
-func readKeyFromEnv() string {
-	raw := os.Getenv(envKey)
+// readSetting reads name from env or fallback config file.
+func readSetting(name string) string {
+	raw := os.Getenv(name)
 	if raw != "" {
 		return raw
 	}
@@ -140,39 +240,60 @@ func readKeyFromEnv() string {
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
 		line := scanner.Text()
-		if k, v, ok := strings.Cut(line, "="); ok && k == envKey {
+		if k, v, ok := strings.Cut(line, "="); ok && k == name {
 			return v
 		}
 	}
 	return ""
 }
 
-// initGCM lazily reads the key from environment or config file.
+// initGCM lazily reads the current and previous keys from environment or
+// config file. The previous keys are ignored without a current key.
 func initGCM() {
 	gcmOnce.Do(func() {
-		raw := readKeyFromEnv()
+		raw := readSetting(envKey)
 		if raw == "" {
 			return
 		}
-		key, err := decodeKey(raw)
+		current, err := newKeyEntry(raw)
 		if err != nil {
 			slog.Error("egress_enc_key_invalid", slog.String("error", err.Error()))
 			return
 		}
-		block, err := aes.NewCipher(key)
-		if err != nil {
-			slog.Error("egress_enc_cipher_failed", slog.String("error", err.Error()))
-			return
-		}
-		aead, err := cipher.NewGCM(block)
-		if err != nil {
-			slog.Error("egress_enc_gcm_failed", slog.String("error", err.Error()))
-			return
+		for _, p := range strings.Split(readSetting(envPreviousKeys), ",") {
+			if p = strings.TrimSpace(p); p == "" {
+				continue
+			}
+			k, err := newKeyEntry(p)
+			if err != nil {
+				slog.Error("egress_enc_previous_key_invalid", slog.String("error", err.Error()))
+				continue
+			}
+			previous = append(previous, k)
 		}
-		gcm = aead
+		gcm, gcmID = current.aead, current.id
 	})
 }
 
+// newKeyEntry builds the AES-GCM cipher of a raw key setting. The key ID is
+// the start of the SHA256 of the key, so it names the key without revealing it.
+func newKeyEntry(raw string) (keyEntry, error) {
+	key, err := decodeKey(raw)
+	if err != nil {
+		return keyEntry{}, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return keyEntry{}, fmt.Errorf("cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return keyEntry{}, fmt.Errorf("gcm: %w", err)
+	}
+	sum := sha256.Sum256(key)
+	return keyEntry{id: hex.EncodeToString(sum[:4]), aead: aead}, nil
+}
+
 // decodeKey accepts a base64-encoded key (16/24/32 bytes) or a 32-byte raw passphrase.
 func decodeKey(raw string) ([]byte, error) {
 	if decoded, err := base64.StdEncoding.DecodeString(raw); err == nil {
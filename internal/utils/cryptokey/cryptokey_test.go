@@ -11,7 +11,7 @@ const testKey = "PvHlWvN638cmg6oz6ixYa/gsYFNBqD6K1d87+A+8DMo="
 // resetGCM clears the lazy init state so a new key can be loaded.
 func resetGCM() {
 	gcmOnce = sync.Once{}
-	gcm = nil
+	gcm, gcmID, previous = nil, "", nil
 }
 
 func TestReEncryptIfNeeded_NoKey(t *testing.T) {
@@ -99,3 +99,51 @@ func TestReEncryptIfNeeded_WithKey(t *testing.T) {
 		t.Fatal("already encrypted value should not be re-encrypted")
 	}
 }
+
+// otherKey is a second base64-encoded AES-256 key, for rotations.
+const otherKey = "q4M0bTt3pYl0Zsh1cpX8kQHi5w5mC0v7ybTMq8oQ0yE="
+
+func TestDecrypt_PreviousKey(t *testing.T) {
+	resetGCM()
+	t.Setenv(envKey, otherKey)
+	old, err := Encrypt("secret")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	oldID := KeyID(old)
+	if oldID == "" || oldID != CurrentKeyID() {
+		t.Fatalf("expected the value to name the key, got %q (current %q)", oldID, CurrentKeyID())
+	}
+
+	resetGCM()
+	t.Setenv(envKey, testKey)
+	if _, err := Decrypt(old); err == nil {
+		t.Fatal("a value of a key that is not configured must not decrypt")
+	}
+
+	resetGCM()
+	t.Setenv(envPreviousKeys, otherKey)
+	if got, err := Decrypt(old); err != nil || got != "secret" {
+		t.Fatalf("the previous key must decrypt, got %q, %v", got, err)
+	}
+	refreshed, changed, err := Refresh(old)
+	if err != nil || !changed || KeyID(refreshed) != CurrentKeyID() || KeyID(refreshed) == oldID {
+		t.Fatalf("Refresh must move the value to the current key, got %q, %v, %v", refreshed, changed, err)
+	}
+	if _, changed, _ := Refresh(refreshed); changed {
+		t.Fatal("a value on the current key must be left as is")
+	}
+}
+
+func TestDecrypt_Untagged(t *testing.T) {
+	resetGCM()
+	t.Setenv(envKey, testKey)
+	tagged, _ := Encrypt("secret")
+	_, untagged := splitTag(tagged)
+	if !IsEncrypted(untagged) || KeyID(untagged) != "" {
+		t.Fatal("a value written before key IDs must look encrypted and untagged")
+	}
+	if got, err := Decrypt(untagged); err != nil || got != "secret" {
+		t.Fatalf("an untagged value must decrypt, got %q, %v", got, err)
+	}
+}
@@ -0,0 +1,128 @@
+package cryptokey
+
+import (
+	"database/sql"
+	"fmt"
+
+	"goBastion/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SecretColumn is a column holding values encrypted with EGRESS_ENC_KEY.
+type SecretColumn struct {
+	Model  any
+	Table  string
+	Column string
+	// Key is the primary key column, "id" when empty.
+	Key string
+	// Binary columns are []byte fields; the encrypted value is stored as
+	// its bytes.
+	Binary bool
+}
+
+// SecretColumns lists every column encrypted with EGRESS_ENC_KEY.
+var SecretColumns = []SecretColumn{
+	{Model: &models.SelfEgressKey{}, Table: "self_egress_keys", Column: "priv_key"},
+	{Model: &models.GroupEgressKey{}, Table: "group_egress_keys", Column: "priv_key"},
+	{Model: &models.SelfDBAccess{}, Table: "self_db_accesses", Column: "password"},
+	{Model: &models.GroupDBAccess{}, Table: "group_db_accesses", Column: "password"},
+	{Model: &models.GroupGuestDBAccess{}, Table: "group_guest_db_accesses", Column: "password"},
+	{Model: &models.CertAuthority{}, Table: "cert_authorities", Column: "private_key"},
+	{Model: &models.User{}, Table: "users", Column: "totp_secret"},
+	{Model: &models.SshHostKey{}, Table: "ssh_host_keys", Column: "private_key", Key: "type", Binary: true},
+}
+
+// KeyColumn returns the primary key column of sc.
+func (sc SecretColumn) KeyColumn() string {
+	if sc.Key == "" {
+		return "id"
+	}
+	return sc.Key
+}
+
+// Stored returns value as it is written to the column of sc.
+func (sc SecretColumn) Stored(value string) any {
+	if sc.Binary {
+		return []byte(value)
+	}
+	return value
+}
+
+// RotationResult counts the values of one secret column after a rotation.
+type RotationResult struct {
+	Table, Column string
+	// ReEncrypted values were plaintext or on a previous key and are now on
+	// the current key; Current values already were.
+	ReEncrypted, Current int
+	// Remaining values are still on an old key that no configured key opens,
+	// by key ID ("untagged" for values written before key IDs).
+	Remaining map[string]int
+}
+
+// RemainingCount returns the number of values still on an old key.
+func (r RotationResult) RemainingCount() int {
+	n := 0
+	for _, c := range r.Remaining {
+		n += c
+	}
+	return n
+}
+
+// Rotate re-encrypts every value of SecretColumns with the current key, in
+// one transaction, including soft-deleted rows. Values no configured key
+// opens are left as they are and reported as remaining.
+func Rotate(db *gorm.DB) ([]RotationResult, error) {
+	if !Enabled() {
+		return nil, fmt.Errorf("egress key encryption not configured (set %s)", envKey)
+	}
+	var results []RotationResult
+	err := db.Transaction(func(tx *gorm.DB) error {
+		results = results[:0]
+		for _, sc := range SecretColumns {
+			res, err := rotateColumn(tx, sc)
+			if err != nil {
+				return err
+			}
+			results = append(results, res)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func rotateColumn(tx *gorm.DB, sc SecretColumn) (RotationResult, error) {
+	res := RotationResult{Table: sc.Table, Column: sc.Column, Remaining: map[string]int{}}
+	var rows []struct {
+		ID    string
+		Value sql.NullString
+	}
+	if err := tx.Unscoped().Model(sc.Model).Select(sc.KeyColumn() + " AS id, " + sc.Column + " AS value").Scan(&rows).Error; err != nil {
+		return res, fmt.Errorf("read %s.%s: %w", sc.Table, sc.Column, err)
+	}
+	for _, row := range rows {
+		if row.Value.String == "" {
+			continue
+		}
+		enc, changed, err := Refresh(row.Value.String)
+		switch {
+		case err != nil:
+			id := KeyID(row.Value.String)
+			if id == "" {
+				id = "untagged"
+			}
+			res.Remaining[id]++
+		case !changed:
+			res.Current++
+		default:
+			if err := tx.Unscoped().Model(sc.Model).Where(sc.KeyColumn()+" = ?", row.ID).UpdateColumn(sc.Column, sc.Stored(enc)).Error; err != nil {
+				return res, fmt.Errorf("update %s %s: %w", sc.Table, row.ID, err)
+			}
+			res.ReEncrypted++
+		}
+	}
+	return res, nil
+}
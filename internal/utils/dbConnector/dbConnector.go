@@ -20,6 +20,8 @@ import (
 	"goBastion/internal/models"
 	"goBastion/internal/utils"
 	"goBastion/internal/utils/cryptokey"
+	"goBastion/internal/utils/justification"
+	"goBastion/internal/utils/schedule"
 	"goBastion/internal/utils/system"
 	"goBastion/internal/utils/validation"
 
@@ -37,9 +39,25 @@ const (
 )
 
 type dbCandidate struct {
-	access  models.DBAccessRight
-	score   int
-	expired bool
+	access models.DBAccessRight
+	score  int
+	trace  int // index in the resolution trace
+}
+
+// CandidateTrace describes one database access entry considered by the
+// resolver and, when it was not used, the rule that rejected it.
+type CandidateTrace struct {
+	AccessID uuid.UUID
+	Source   string
+	Reason   string // self-exact, self-wildcard, group-exact, group-wildcard, admin-override
+	Score    int
+	Username string
+	Host     string
+	Port     int64
+	Protocol string
+	Database string
+	Excluded string
+	Selected bool
 }
 
 type ResolutionDetails struct {
@@ -63,6 +81,9 @@ type ResolutionDetails struct {
 	EffectiveDatabase string
 
 	AccessSource string
+
+	// Candidates lists every access entry considered, best score first.
+	Candidates []CandidateTrace
 }
 
 // Connect launches a database client wrapped in ttyrec for session recording.
@@ -142,6 +163,8 @@ func Connect(db *gorm.DB, user models.User, access models.DBAccessRight) error {
 	tmpGzPath := tmpGz.Name()
 	defer func() { _ = os.Remove(tmpGzPath) }()
 	gzipWriter := gzip.NewWriter(tmpGz)
+	// The justification travels with the recording in the gzip header comment.
+	gzipWriter.Comment = justification.RecordingComment(access.Justification)
 
 	defer func() { _ = os.Remove(ttyrecFile) }()
 
@@ -290,7 +313,31 @@ func ResolveTarget(db *gorm.DB, user models.User, target string, extraArgs ...st
 	return access, err
 }
 
+// ResolveTargetDetailed is ResolveTarget, also reporting how the target was
+// resolved and every candidate considered.
 func ResolveTargetDetailed(db *gorm.DB, user models.User, target string, extraArgs ...string) (models.DBAccessRight, ResolutionDetails, error) {
+	return resolveTarget(db, user, target, clientIPFromEnv(), extraArgs...)
+}
+
+// ExplainTarget runs the resolver for user as if connecting from clientIP,
+// without connecting. details.Candidates holds the decision trace.
+func ExplainTarget(db *gorm.DB, user models.User, target, clientIP string, extraArgs ...string) (models.DBAccessRight, ResolutionDetails, error) {
+	return resolveTarget(db, user, target, clientIP, extraArgs...)
+}
+
+// clientIPFromEnv returns the SSH client address, or "" when unknown.
+func clientIPFromEnv() string {
+	clientIP := os.Getenv("SSH_CLIENT")
+	if clientIP == "" {
+		clientIP = os.Getenv("SSH_CONNECTION")
+	}
+	if parts := strings.Fields(clientIP); len(parts) > 0 {
+		return parts[0]
+	}
+	return ""
+}
+
+func resolveTarget(db *gorm.DB, user models.User, target, clientIP string, extraArgs ...string) (models.DBAccessRight, ResolutionDetails, error) {
 	details := ResolutionDetails{RequestedTarget: target}
 	details.RequestedUser, details.RequestedHost, details.RequestedPort, details.RequestedProtocol, details.RequestedDatabase = parseDBTarget(target, extraArgs)
 
@@ -339,7 +386,8 @@ func ResolveTargetDetailed(db *gorm.DB, user models.User, target string, extraAr
 		return models.DBAccessRight{}, details, fmt.Errorf("no database access found for '%s'", target)
 	}
 
-	accesses, err := dbAccessFilter(db, user, host, port, protocol)
+	accesses, traces, err := dbAccessFilter(db, user, host, port, protocol, clientIP)
+	details.Candidates = traces
 	if err != nil {
 		return models.DBAccessRight{}, details, err
 	}
@@ -353,6 +401,8 @@ func ResolveTargetDetailed(db *gorm.DB, user models.User, target string, extraAr
 		for _, a := range accesses {
 			if a.Database == database {
 				filtered = append(filtered, a)
+			} else {
+				details.exclude(a, "database mismatch (entry: "+a.Database+")")
 			}
 		}
 		if len(filtered) == 0 {
@@ -367,6 +417,8 @@ func ResolveTargetDetailed(db *gorm.DB, user models.User, target string, extraAr
 		for _, a := range accesses {
 			if strings.EqualFold(a.Username, dbUser) {
 				filtered = append(filtered, a)
+			} else {
+				details.exclude(a, "username mismatch (entry: "+a.Username+")")
 			}
 		}
 		if len(filtered) == 0 {
@@ -382,12 +434,16 @@ func ResolveTargetDetailed(db *gorm.DB, user models.User, target string, extraAr
 		details.EffectiveProtocol = accesses[0].Protocol
 		details.EffectiveDatabase = accesses[0].Database
 		details.AccessSource = accesses[0].Source
+		details.selected(accesses[0])
 		return accesses[0], details, nil
 	}
 
 	// Multiple matches remain — disambiguate
 	access, err := disambiguateDBAccess(target, accesses, protocol, dbUser, database)
 	if err != nil {
+		for _, a := range accesses {
+			details.exclude(a, "ambiguous: several entries remain")
+		}
 		return models.DBAccessRight{}, details, err
 	}
 	details.EffectiveUser = access.Username
@@ -396,9 +452,28 @@ func ResolveTargetDetailed(db *gorm.DB, user models.User, target string, extraAr
 	details.EffectiveProtocol = access.Protocol
 	details.EffectiveDatabase = access.Database
 	details.AccessSource = access.Source
+	details.selected(access)
 	return access, details, nil
 }
 
+// exclude records why a retained access was dropped after dbAccessFilter.
+func (d *ResolutionDetails) exclude(a models.DBAccessRight, rule string) {
+	for i := range d.Candidates {
+		if d.Candidates[i].AccessID == a.ID && d.Candidates[i].Source == a.Source && d.Candidates[i].Excluded == "" {
+			d.Candidates[i].Excluded = rule
+		}
+	}
+}
+
+// selected marks the access finally returned by the resolver.
+func (d *ResolutionDetails) selected(a models.DBAccessRight) {
+	for i := range d.Candidates {
+		if d.Candidates[i].AccessID == a.ID && d.Candidates[i].Source == a.Source {
+			d.Candidates[i].Selected = true
+		}
+	}
+}
+
 func shouldResolveDBAliasFirst(target string) bool {
 	target = strings.TrimSpace(target)
 	if target == "" {
@@ -542,7 +617,7 @@ func resolveDBAlias(db *gorm.DB, user models.User, target string) (models.Databa
 
 	// Tier 2: group aliases
 	var userGroupIDs []uuid.UUID
-	db.Model(&models.UserGroup{}).Where("user_id = ?", user.ID).Pluck("group_id", &userGroupIDs)
+	db.Model(&models.UserGroup{}).Where("user_id = ?", user.ID).Where(models.MembershipActiveClause, time.Now()).Pluck("group_id", &userGroupIDs)
 	if len(userGroupIDs) > 0 {
 		var aliases []models.DatabaseAlias
 		if err := db.Preload("Group").Where("group_id IN (?) AND deleted_at IS NULL",
@@ -585,74 +660,98 @@ func resolveDBAlias(db *gorm.DB, user models.User, target string) (models.Databa
 	return models.DatabaseAlias{}, nil
 }
 
-// dbAccessFilter resolves database access rights following the SSH priority scoring system.
-func dbAccessFilter(db *gorm.DB, user models.User, host string, port int64, protocol string) ([]models.DBAccessRight, error) {
+// dbAccessFilter resolves database access rights following the SSH priority
+// scoring system. It also returns a trace of every entry considered, with the
+// rule that rejected it, for the explain commands.
+func dbAccessFilter(db *gorm.DB, user models.User, host string, port int64, protocol, clientIP string) ([]models.DBAccessRight, []CandidateTrace, error) {
 	now := time.Now()
 	var candidates []dbCandidate
+	var traces []CandidateTrace
+
+	// consider records an entry in the trace and keeps it as a candidate
+	// unless the port, protocol, start or TTL rules reject it.
+	consider := func(access models.DBAccessRight, reason string, score int, startsAt, expiresAt *time.Time) {
+		t := CandidateTrace{
+			AccessID: access.ID, Source: access.Source, Reason: reason, Score: score,
+			Username: access.Username, Host: access.Host, Port: access.Port,
+			Protocol: access.Protocol, Database: access.Database,
+		}
+		switch {
+		case port != 0 && access.Port != port:
+			t.Excluded = fmt.Sprintf("port mismatch (entry: %d)", access.Port)
+		case protocol != "" && access.Protocol != protocol:
+			t.Excluded = "protocol mismatch (entry: " + access.Protocol + ")"
+		case startsAt != nil && startsAt.After(now):
+			t.Excluded = "pending (starts " + startsAt.Format("2006-01-02 15:04") + ")"
+		case expiresAt != nil && !expiresAt.After(now):
+			t.Excluded = "expired on " + expiresAt.Format("2006-01-02 15:04")
+		}
+		traces = append(traces, t)
+		if t.Excluded == "" {
+			candidates = append(candidates, dbCandidate{access: access, score: score, trace: len(traces) - 1})
+		}
+	}
 
 	// Step 1: Self accesses
 	var selfAccesses []models.SelfDBAccess
-	db.Where(
-		"user_id = ? AND (port = ? OR ? = 0) AND (host = ? OR ? = '') AND (expires_at IS NULL OR expires_at > ?)",
-		user.ID, port, port, host, host, now,
-	).Find(&selfAccesses)
+	db.Where("user_id = ? AND (host = ? OR ? = '')", user.ID, host, host).Find(&selfAccesses)
 	for _, a := range selfAccesses {
-		if protocol != "" && a.Protocol != protocol {
-			continue
-		}
-		score := scoreDBSelfWildcard
+		score, reason := scoreDBSelfWildcard, "self-wildcard"
 		if a.Host == host && (port == 0 || a.Port == port) {
-			score = scoreDBSelfExact
+			score, reason = scoreDBSelfExact, "self-exact"
 		}
-		candidates = append(candidates, dbCandidate{
-			access:  buildSelfDBAccessRight("account-"+user.Username, a),
-			score:   score,
-			expired: a.ExpiresAt != nil && a.ExpiresAt.Before(now),
-		})
+		consider(buildSelfDBAccessRight("account-"+user.Username, a), reason, score, a.StartsAt, a.ExpiresAt)
 	}
 
-	// Step 2: Group accesses
+	// Step 2: Group accesses, including those inherited through nested groups
 	var userGroups []models.UserGroup
-	db.Where("user_id = ?", user.ID).Find(&userGroups)
+	db.Where("user_id = ?", user.ID).Where(models.MembershipActiveClause, time.Now()).Find(&userGroups)
 	if len(userGroups) > 0 {
+		groupRoles, err := models.EffectiveGroupRoles(db, userGroups)
+		if err != nil {
+			return nil, traces, fmt.Errorf("error resolving nested groups: %w", err)
+		}
 		var groupIDs []uuid.UUID
-		for _, ug := range userGroups {
-			groupIDs = append(groupIDs, ug.GroupID)
+		for groupID := range groupRoles {
+			groupIDs = append(groupIDs, groupID)
 		}
 		var groupAccesses []models.GroupDBAccess
-		db.Where(
-			"group_id IN (?) AND (port = ? OR ? = 0) AND (host = ? OR ? = '') AND (expires_at IS NULL OR expires_at > ?)",
-			groupIDs, port, port, host, host, now,
-		).Find(&groupAccesses)
+		db.Where("group_id IN (?) AND (host = ? OR ? = '')", groupIDs, host, host).Find(&groupAccesses)
 		for _, a := range groupAccesses {
-			if protocol != "" && a.Protocol != protocol {
-				continue
-			}
-			score := scoreDBGroupWildcard
+			score, reason := scoreDBGroupWildcard, "group-wildcard"
 			if a.Host == host && (port == 0 || a.Port == port) {
-				score = scoreDBGroupExact
+				score, reason = scoreDBGroupExact, "group-exact"
+			}
+			consider(buildGroupDBAccessRight(db, a), reason, score, a.StartsAt, a.ExpiresAt)
+		}
+
+		// Step 2b: Guest DB grants of the groups the user is a guest of, with
+		// their start, expiry and schedule.
+		var guestGroupIDs []uuid.UUID
+		for groupID, role := range groupRoles {
+			if role == models.GroupRoleGuest {
+				guestGroupIDs = append(guestGroupIDs, groupID)
+			}
+		}
+		if len(guestGroupIDs) > 0 {
+			var guestAccesses []models.GroupGuestDBAccess
+			db.Where("user_id = ? AND group_id IN (?) AND (host = ? OR ? = '')", user.ID, guestGroupIDs, host, host).Find(&guestAccesses)
+			for _, a := range guestAccesses {
+				score, reason := scoreDBGroupWildcard, "guest-wildcard"
+				if a.Host == host && (port == 0 || a.Port == port) {
+					score, reason = scoreDBGroupExact, "guest-exact"
+				}
+				consider(buildGuestDBAccessRight(db, a), reason, score, a.StartsAt, a.ExpiresAt)
 			}
-			candidates = append(candidates, dbCandidate{
-				access:  buildGroupDBAccessRight(db, a),
-				score:   score,
-				expired: a.ExpiresAt != nil && a.ExpiresAt.Before(now),
-			})
 		}
 	}
 
 	// Step 3: Admin override
 	if len(candidates) == 0 && user.IsAdmin() {
 		var allSelf []models.SelfDBAccess
-		db.Where("(host = ? OR ? = '') AND (port = ? OR ? = 0)", host, host, port, port).Find(&allSelf)
+		db.Where("(host = ? OR ? = '')", host, host).Find(&allSelf)
 		for _, a := range allSelf {
-			if protocol != "" && a.Protocol != protocol {
-				continue
-			}
-			candidates = append(candidates, dbCandidate{
-				access:  buildSelfDBAccessRight("admin-override", a),
-				score:   scoreDBAdminOverride,
-				expired: a.ExpiresAt != nil && a.ExpiresAt.Before(now),
-			})
+			consider(buildSelfDBAccessRight("admin-override", a), "admin-override", scoreDBAdminOverride, a.StartsAt, a.ExpiresAt)
 		}
 	}
 
@@ -665,28 +764,30 @@ func dbAccessFilter(db *gorm.DB, user models.User, host string, port int64, prot
 		}
 	}
 
-	// Step 5: Filter expired and check IP allowlist
+	// Step 5: Filter closed schedule windows and check IP allowlist
 	var results []models.DBAccessRight
+	closedSchedule := ""
 	for _, c := range candidates {
-		if c.expired {
+		if !schedule.Allows(c.access.Schedule, now) {
+			traces[c.trace].Excluded = "outside its access window (" + c.access.Schedule + ")"
+			if closedSchedule == "" {
+				closedSchedule = c.access.Schedule
+			}
 			continue
 		}
-		if c.access.AllowedFrom != "" {
-			clientIP := os.Getenv("SSH_CLIENT")
-			if clientIP == "" {
-				clientIP = os.Getenv("SSH_CONNECTION")
-			}
-			if clientIP != "" {
-				parts := strings.Fields(clientIP)
-				if len(parts) > 0 && !ipAllowed(parts[0], c.access.AllowedFrom) {
-					continue
-				}
-			}
+		// An unknown client address (no SSH environment) skips the allowlist.
+		if c.access.AllowedFrom != "" && clientIP != "" && !ipAllowed(clientIP, c.access.AllowedFrom) {
+			traces[c.trace].Excluded = fmt.Sprintf("source IP %s not in allowed CIDRs (%s)", clientIP, c.access.AllowedFrom)
+			continue
 		}
 		results = append(results, c.access)
 	}
 
-	return results, nil
+	sort.SliceStable(traces, func(i, j int) bool { return traces[i].Score > traces[j].Score })
+	if len(results) == 0 && closedSchedule != "" {
+		return nil, traces, errors.New(schedule.DenialMessage(closedSchedule, now))
+	}
+	return results, traces, nil
 }
 
 func buildSelfDBAccessRight(source string, a models.SelfDBAccess) models.DBAccessRight {
@@ -704,6 +805,7 @@ func buildSelfDBAccessRight(source string, a models.SelfDBAccess) models.DBAcces
 		Password:    password,
 		Database:    a.Database,
 		AllowedFrom: a.AllowedFrom,
+		Schedule:    a.Schedule,
 	}
 }
 
@@ -724,10 +826,24 @@ func buildGroupDBAccessRight(db *gorm.DB, a models.GroupDBAccess) models.DBAcces
 		Password:    password,
 		Database:    a.Database,
 		AllowedFrom: a.AllowedFrom,
+		Schedule:    a.Schedule,
 		MFARequired: group.MFARequired,
+
+		JustificationRequired: group.JustificationRequired,
+		JustificationPattern:  group.JustificationPattern,
 	}
 }
 
+func buildGuestDBAccessRight(db *gorm.DB, a models.GroupGuestDBAccess) models.DBAccessRight {
+	access := buildGroupDBAccessRight(db, models.GroupDBAccess{
+		ID: a.ID, GroupID: a.GroupID, Host: a.Host, Port: a.Port, Protocol: a.Protocol,
+		Username: a.Username, Password: a.Password, Database: a.Database,
+		AllowedFrom: a.AllowedFrom, Schedule: a.Schedule,
+	})
+	access.Source = "guest-" + strings.TrimPrefix(access.Source, "group-")
+	return access
+}
+
 // buildClientArgs constructs the command-line arguments for the database client.
 func buildClientArgs(access models.DBAccessRight) []string {
 	var args []string
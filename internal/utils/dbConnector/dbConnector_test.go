@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"goBastion/internal/config"
 	"goBastion/internal/models"
@@ -220,10 +221,10 @@ func newAliasTestDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatalf("open test DB: %v", err)
 	}
-	if err := db.AutoMigrate(&models.User{}, &models.Group{}, &models.UserGroup{}, &models.DatabaseAlias{}, &models.GroupDBAccess{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.Group{}, &models.UserGroup{}, &models.DatabaseAlias{}, &models.GroupDBAccess{}, &models.GroupInclusion{}); err != nil {
 		t.Fatalf("migrate: %v", err)
 	}
-	if err := db.AutoMigrate(&models.SelfDBAccess{}); err != nil {
+	if err := db.AutoMigrate(&models.SelfDBAccess{}, &models.GroupGuestDBAccess{}); err != nil {
 		t.Fatalf("migrate: %v", err)
 	}
 	return db
@@ -265,6 +266,122 @@ func TestResolveTargetFindsGroupDBAccessByHost(t *testing.T) {
 	}
 }
 
+func TestResolveTargetRefusesClosedSchedule(t *testing.T) {
+	db := newAliasTestDB(t)
+
+	user := models.User{Username: "alice", Role: models.RoleUser, Enabled: true}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	closed := time.Now().UTC().Add(48*time.Hour).Format("Mon") + " 00:00-24:00 UTC"
+	access := models.SelfDBAccess{
+		UserID:   user.ID,
+		Host:     "db-main.internal",
+		Port:     5432,
+		Protocol: "postgres",
+		Username: "dbuser",
+		Schedule: closed,
+	}
+	if err := db.Create(&access).Error; err != nil {
+		t.Fatalf("create self db access: %v", err)
+	}
+
+	_, err := ResolveTarget(db, user, "db-main.internal")
+	if err == nil {
+		t.Fatal("expected schedule denial, got nil")
+	}
+	if !strings.Contains(err.Error(), "only allowed during "+closed) {
+		t.Fatalf("denial should name the window, got: %v", err)
+	}
+}
+
+func TestResolveTargetEnforcesGuestDBGrants(t *testing.T) {
+	db := newAliasTestDB(t)
+
+	user := models.User{Username: "guest", Role: models.RoleUser, Enabled: true}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	group := models.Group{Name: "infra"}
+	if err := db.Create(&group).Error; err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	if err := db.Create(&models.UserGroup{UserID: user.ID, GroupID: group.ID, Role: models.GroupRoleGuest}).Error; err != nil {
+		t.Fatalf("create membership: %v", err)
+	}
+	grant := models.GroupGuestDBAccess{GroupID: group.ID, UserID: user.ID, Host: "db-main.internal", Port: 5432, Protocol: "postgres", Username: "report"}
+	if err := db.Create(&grant).Error; err != nil {
+		t.Fatalf("create guest db access: %v", err)
+	}
+	got, err := ResolveTarget(db, user, "db-main.internal")
+	if err != nil || got.Username != "report" || got.Source != "guest-infra" {
+		t.Fatalf("expected the guest DB grant, got %+v (%v)", got, err)
+	}
+
+	closed := time.Now().UTC().Add(48*time.Hour).Format("Mon") + " 00:00-24:00 UTC"
+	db.Model(&grant).Update("schedule", closed)
+	if _, err := ResolveTarget(db, user, "db-main.internal"); err == nil || !strings.Contains(err.Error(), "only allowed during "+closed) {
+		t.Fatalf("expected the guest grant schedule to be enforced, got %v", err)
+	}
+
+	starts := time.Now().Add(time.Hour)
+	db.Model(&grant).Updates(map[string]any{"schedule": "", "starts_at": starts})
+	if _, err := ResolveTarget(db, user, "db-main.internal"); err == nil {
+		t.Fatal("a guest DB grant must not be usable before it starts")
+	}
+}
+
+func TestExplainTargetTracesExcludedCandidates(t *testing.T) {
+	db := newAliasTestDB(t)
+
+	user := models.User{Username: "alice", Role: models.RoleUser, Enabled: true}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	closed := time.Now().UTC().Add(48*time.Hour).Format("Mon") + " 00:00-24:00 UTC"
+	for _, a := range []models.SelfDBAccess{
+		{UserID: user.ID, Host: "db-main.internal", Port: 5432, Protocol: "postgres", Username: "dbuser"},
+		{UserID: user.ID, Host: "db-main.internal", Port: 3306, Protocol: "mysql", Username: "dbuser"},
+		{UserID: user.ID, Host: "db-main.internal", Port: 5432, Protocol: "postgres", Username: "report", Schedule: closed},
+	} {
+		if err := db.Create(&a).Error; err != nil {
+			t.Fatalf("create self db access: %v", err)
+		}
+	}
+
+	got, details, err := ExplainTarget(db, user, "db-main.internal", "", "--pg")
+	if err != nil {
+		t.Fatalf("ExplainTarget returned error: %v", err)
+	}
+	if got.Username != "dbuser" || got.Port != 5432 {
+		t.Fatalf("unexpected resolved access: %+v", got)
+	}
+	if len(details.Candidates) != 3 {
+		t.Fatalf("expected 3 traced candidates, got %+v", details.Candidates)
+	}
+	selected := 0
+	for _, c := range details.Candidates {
+		switch {
+		case c.Selected:
+			selected++
+			if c.Username != "dbuser" || c.Protocol != "postgres" {
+				t.Errorf("wrong candidate selected: %+v", c)
+			}
+		case c.Protocol == "mysql":
+			if !strings.Contains(c.Excluded, "protocol") {
+				t.Errorf("mysql entry should be excluded on protocol, got %q", c.Excluded)
+			}
+		case c.Username == "report":
+			if !strings.Contains(c.Excluded, closed) {
+				t.Errorf("scheduled entry should name its window, got %q", c.Excluded)
+			}
+		}
+	}
+	if selected != 1 {
+		t.Fatalf("expected exactly one selected candidate, got %d", selected)
+	}
+}
+
 func TestResolveTargetKeepsPlaintextStoredPassword(t *testing.T) {
 	db := newAliasTestDB(t)
 
@@ -95,6 +95,8 @@ type AccessRow struct {
 	Protocol       string
 	Comment        string
 	AllowedFrom    string
+	Schedule       string
+	StartsAt       *time.Time
 	ExpiresAt      *time.Time
 	LastConnection time.Time
 	CreatedAt      time.Time
@@ -110,6 +112,8 @@ func SelfAccessToRow(a models.SelfAccess) AccessRow {
 		Protocol:       a.Protocol,
 		Comment:        a.Comment,
 		AllowedFrom:    a.AllowedFrom,
+		Schedule:       a.Schedule,
+		StartsAt:       a.StartsAt,
 		ExpiresAt:      a.ExpiresAt,
 		LastConnection: a.LastConnection,
 		CreatedAt:      a.CreatedAt,
@@ -126,6 +130,8 @@ func GroupAccessToRow(a models.GroupAccess) AccessRow {
 		Protocol:       a.Protocol,
 		Comment:        a.Comment,
 		AllowedFrom:    a.AllowedFrom,
+		Schedule:       a.Schedule,
+		StartsAt:       a.StartsAt,
 		ExpiresAt:      a.ExpiresAt,
 		LastConnection: a.LastConnection,
 		CreatedAt:      a.CreatedAt,
@@ -142,6 +148,8 @@ type DBAccessRow struct {
 	Database       string
 	Comment        string
 	AllowedFrom    string
+	Schedule       string
+	StartsAt       *time.Time
 	ExpiresAt      *time.Time
 	LastConnection time.Time
 	CreatedAt      time.Time
@@ -158,6 +166,8 @@ func SelfDBAccessToRow(a models.SelfDBAccess) DBAccessRow {
 		Database:       a.Database,
 		Comment:        a.Comment,
 		AllowedFrom:    a.AllowedFrom,
+		Schedule:       a.Schedule,
+		StartsAt:       a.StartsAt,
 		ExpiresAt:      a.ExpiresAt,
 		LastConnection: a.LastConnection,
 		CreatedAt:      a.CreatedAt,
@@ -175,30 +185,50 @@ func GroupDBAccessToRow(a models.GroupDBAccess) DBAccessRow {
 		Database:       a.Database,
 		Comment:        a.Comment,
 		AllowedFrom:    a.AllowedFrom,
+		Schedule:       a.Schedule,
+		StartsAt:       a.StartsAt,
 		ExpiresAt:      a.ExpiresAt,
 		LastConnection: a.LastConnection,
 		CreatedAt:      a.CreatedAt,
 	}
 }
 
+// ScheduleLabel returns the display value of an access schedule ("Always" when unset).
+func ScheduleLabel(spec string) string {
+	if spec == "" {
+		return "Always"
+	}
+	return spec
+}
+
+// ExpiryLabel returns the display value of an access validity: PENDING
+// before its start, EXPIRED after its expiry, else the expiry date or "Never".
+func ExpiryLabel(startsAt, expiresAt *time.Time, now time.Time) string {
+	switch {
+	case startsAt != nil && startsAt.After(now):
+		if expiresAt != nil {
+			return "PENDING(" + startsAt.Format("2006-01-02 15:04") + " → " + expiresAt.Format("2006-01-02") + ")"
+		}
+		return "PENDING(" + startsAt.Format("2006-01-02 15:04") + ")"
+	case expiresAt == nil:
+		return "Never"
+	case expiresAt.Before(now):
+		return "EXPIRED(" + expiresAt.Format("2006-01-02") + ")"
+	}
+	return expiresAt.Format("2006-01-02")
+}
+
 // RenderDBAccessTable renders a formatted table of DBAccessRow entries and returns body lines.
 func RenderDBAccessTable(rows []DBAccessRow) []string {
 	var buf bytes.Buffer
 	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
-	_, _ = fmt.Fprintln(w, "ID\tUsername\tHost\tPort\tProtocol\tDatabase\tComment\tFrom\tExpires\tLast Used\tCreated At")
+	_, _ = fmt.Fprintln(w, "ID\tUsername\tHost\tPort\tProtocol\tDatabase\tComment\tFrom\tSchedule\tExpires\tLast Used\tCreated At")
 	for _, row := range rows {
 		lastUsed := "Never"
 		if !row.LastConnection.IsZero() {
 			lastUsed = row.LastConnection.Format("2006-01-02 15:04:05")
 		}
-		expires := "Never"
-		if row.ExpiresAt != nil {
-			if row.ExpiresAt.Before(time.Now()) {
-				expires = "EXPIRED(" + row.ExpiresAt.Format("2006-01-02") + ")"
-			} else {
-				expires = row.ExpiresAt.Format("2006-01-02")
-			}
-		}
+		expires := ExpiryLabel(row.StartsAt, row.ExpiresAt, time.Now())
 		allowedFrom := row.AllowedFrom
 		if allowedFrom == "" {
 			allowedFrom = "*"
@@ -207,7 +237,7 @@ func RenderDBAccessTable(rows []DBAccessRow) []string {
 		if database == "" {
 			database = "*"
 		}
-		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
 			row.ID.String(),
 			row.Username,
 			row.Host,
@@ -216,6 +246,7 @@ func RenderDBAccessTable(rows []DBAccessRow) []string {
 			database,
 			row.Comment,
 			allowedFrom,
+			ScheduleLabel(row.Schedule),
 			expires,
 			lastUsed,
 			row.CreatedAt.Format("2006-01-02 15:04:05"),
@@ -229,20 +260,13 @@ func RenderDBAccessTable(rows []DBAccessRow) []string {
 func RenderAccessTable(rows []AccessRow) []string {
 	var buf bytes.Buffer
 	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
-	_, _ = fmt.Fprintln(w, "ID\tUsername\tServer\tPort\tProtocol\tComment\tFrom\tExpires\tLast Used\tCreated At")
+	_, _ = fmt.Fprintln(w, "ID\tUsername\tServer\tPort\tProtocol\tComment\tFrom\tSchedule\tExpires\tLast Used\tCreated At")
 	for _, row := range rows {
 		lastUsed := "Never"
 		if !row.LastConnection.IsZero() {
 			lastUsed = row.LastConnection.Format("2006-01-02 15:04:05")
 		}
-		expires := "Never"
-		if row.ExpiresAt != nil {
-			if row.ExpiresAt.Before(time.Now()) {
-				expires = "EXPIRED(" + row.ExpiresAt.Format("2006-01-02") + ")"
-			} else {
-				expires = row.ExpiresAt.Format("2006-01-02")
-			}
-		}
+		expires := ExpiryLabel(row.StartsAt, row.ExpiresAt, time.Now())
 		allowedFrom := row.AllowedFrom
 		if allowedFrom == "" {
 			allowedFrom = "*"
@@ -251,7 +275,7 @@ func RenderAccessTable(rows []AccessRow) []string {
 		if proto == "" {
 			proto = "ssh"
 		}
-		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
 			row.ID.String(),
 			row.Username,
 			row.Server,
@@ -259,6 +283,7 @@ func RenderAccessTable(rows []AccessRow) []string {
 			proto,
 			row.Comment,
 			allowedFrom,
+			ScheduleLabel(row.Schedule),
 			expires,
 			lastUsed,
 			row.CreatedAt.Format("2006-01-02 15:04:05"),
@@ -310,16 +335,24 @@ func RenderIngressKeysTable(keys []models.IngressKey) []KeySection {
 		if key.PIVAttested {
 			pivLabel = " 🔐 PIV-attested"
 		}
+		body := []string{
+			fmt.Sprintf("Type: %s%s", key.Type, pivLabel),
+			fmt.Sprintf("Fingerprint: %s", key.Fingerprint),
+			fmt.Sprintf("Size: %d", key.Size),
+			fmt.Sprintf("Expires: %s", expires),
+		}
+		if opts := key.AuthorizedKeysOptions(); opts != "" {
+			body = append(body, fmt.Sprintf("Options: %s", opts))
+		}
+		if key.DisabledAt != nil {
+			body = append(body, fmt.Sprintf("⛔ Disabled by policy (%s): %s", key.DisabledAt.Format("2006-01-02"), key.DisabledReason))
+		}
 		sections[i] = KeySection{
 			SubTitle: fmt.Sprintf("Key ID: %s", key.ID.String()),
-			Body: []string{
-				fmt.Sprintf("Type: %s%s", key.Type, pivLabel),
-				fmt.Sprintf("Fingerprint: %s", key.Fingerprint),
-				fmt.Sprintf("Size: %d", key.Size),
-				fmt.Sprintf("Expires: %s", expires),
+			Body: append(body,
 				fmt.Sprintf("Last Update: %s", key.UpdatedAt.Format("2006-01-02 15:04:05")),
 				fmt.Sprintf("Public Key: %s", key.Key),
-			},
+			),
 		}
 	}
 	return sections
@@ -0,0 +1,256 @@
+package egresskey
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/term"
+	"gorm.io/gorm"
+)
+
+// Deployment describes the public keys to install on and remove from the
+// authorized_keys of targets, and how to log in to do it.
+type Deployment struct {
+	Add    []string // public keys that must be present
+	Remove []string // public keys that must be absent
+	// Auth holds the private keys tried to log in, in order. Password is
+	// asked for when none of them is accepted; an empty answer skips it.
+	Auth     []string
+	Password func() (string, error)
+}
+
+// Report is the outcome of a deployment on one target.
+type Report struct {
+	Target
+	Method  string // "egress key" or "password"
+	Added   int
+	Removed int
+	Err     error
+}
+
+// Read and replace the authorized_keys of the target account. Only a
+// missing file reads as empty: any other read error fails the target, so
+// an unreadable file is never replaced by one holding only our keys. The
+// new file is written next to the old one and renamed over it.
+const (
+	readAuthorizedKeys  = "[ -e ~/.ssh/authorized_keys ] || exit 0; cat ~/.ssh/authorized_keys"
+	writeAuthorizedKeys = "umask 077 && mkdir -p ~/.ssh && cat > ~/.ssh/authorized_keys.gobastion && mv -f ~/.ssh/authorized_keys.gobastion ~/.ssh/authorized_keys"
+)
+
+// Deploy logs in to t, accepting only hostKeys, and updates the account's
+// authorized_keys as d describes. The file is only rewritten when it
+// changes, so deploying twice is harmless.
+func Deploy(t Target, d Deployment, hostKeys []ssh.PublicKey) Report {
+	r := Report{Target: t}
+	client, method, err := dial(t, d, hostKeys)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	defer func() { _ = client.Close() }()
+	r.Method = method
+
+	current, err := run(client, readAuthorizedKeys, "")
+	if err != nil {
+		r.Err = fmt.Errorf("read authorized_keys: %w", err)
+		return r
+	}
+	updated, added, removed := UpdateAuthorizedKeys(current, d.Add, d.Remove)
+	if added == 0 && removed == 0 {
+		return r
+	}
+	if _, err := run(client, writeAuthorizedKeys, updated); err != nil {
+		r.Err = fmt.Errorf("write authorized_keys: %w", err)
+		return r
+	}
+	r.Added, r.Removed = added, removed
+	return r
+}
+
+// DeployAll deploys d on every target in order. learnHostKey is called
+// first on each target so that the bastion records the host key of a target
+// it never connected to.
+func DeployAll(db *gorm.DB, targets []Target, d Deployment, learnHostKey func(Target) error) []Report {
+	reports := make([]Report, 0, len(targets))
+	for _, t := range targets {
+		if err := learnHostKey(t); err != nil {
+			reports = append(reports, Report{Target: t, Err: err})
+			continue
+		}
+		hostKeys, err := KnownHostKeys(db, t.Server, t.Port)
+		if err == nil && len(hostKeys) == 0 {
+			err = fmt.Errorf("host key unknown: the target could not be scanned")
+		}
+		if err != nil {
+			reports = append(reports, Report{Target: t, Err: err})
+			continue
+		}
+		reports = append(reports, Deploy(t, d, hostKeys))
+	}
+	return reports
+}
+
+// String describes the outcome for the per-host report.
+func (r Report) String() string {
+	switch {
+	case r.Err != nil:
+		return fmt.Sprintf("❌ %s: %v", r.Target, r.Err)
+	case r.Added == 0 && r.Removed == 0:
+		return fmt.Sprintf("➖ %s: already up to date (via %s)", r.Target, r.Method)
+	}
+	return fmt.Sprintf("✅ %s: %d key(s) added, %d removed (via %s)", r.Target, r.Added, r.Removed, r.Method)
+}
+
+// PromptPassword returns a Deployment.Password reading the password from
+// the terminal the first time it is needed and giving the same answer
+// afterwards.
+func PromptPassword() func() (string, error) {
+	var once sync.Once
+	var password string
+	var err error
+	return func() (string, error) {
+		once.Do(func() {
+			fmt.Print("No egress key is accepted by a target. Password of the remote accounts (asked once, empty to skip): ")
+			var p []byte
+			p, err = term.ReadPassword(int(os.Stdin.Fd()))
+			fmt.Println()
+			password = string(p)
+		})
+		return password, err
+	}
+}
+
+// dial opens an SSH connection to t with the deployment's keys, then its
+// password.
+func dial(t Target, d Deployment, hostKeys []ssh.PublicKey) (*ssh.Client, string, error) {
+	var signers []ssh.Signer
+	for _, k := range d.Auth {
+		signer, err := ssh.ParsePrivateKey([]byte(k))
+		if err != nil {
+			return nil, "", fmt.Errorf("parse egress key: %w", err)
+		}
+		signers = append(signers, signer)
+	}
+	method := "egress key"
+	var once sync.Once
+	var password string
+	var passwordErr error
+	askPassword := func() (string, error) {
+		once.Do(func() {
+			method = "password"
+			if d.Password == nil {
+				passwordErr = fmt.Errorf("no egress key accepted")
+				return
+			}
+			password, passwordErr = d.Password()
+			if passwordErr == nil && password == "" {
+				passwordErr = fmt.Errorf("no egress key accepted and no password given")
+			}
+		})
+		return password, passwordErr
+	}
+	auth := []ssh.AuthMethod{
+		ssh.PublicKeys(signers...),
+		ssh.PasswordCallback(askPassword),
+		ssh.KeyboardInteractive(func(_, _ string, questions []string, echos []bool) ([]string, error) {
+			answers := make([]string, len(questions))
+			for i := range questions {
+				if echos[i] {
+					return nil, fmt.Errorf("unexpected keyboard-interactive question %q", questions[i])
+				}
+				p, err := askPassword()
+				if err != nil {
+					return nil, err
+				}
+				answers[i] = p
+			}
+			return answers, nil
+		}),
+	}
+
+	client, err := ssh.Dial("tcp", net.JoinHostPort(t.Server, strconv.FormatInt(t.Port, 10)), clientConfig(t, auth, hostKeys))
+	if err != nil {
+		return nil, "", err
+	}
+	return client, method, nil
+}
+
+// run executes cmd on client with stdin and returns its output.
+func run(client *ssh.Client, cmd, stdin string) (string, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = session.Close() }()
+	var stdout, stderr bytes.Buffer
+	session.Stdin = strings.NewReader(stdin)
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	if err := session.Run(cmd); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// UpdateAuthorizedKeys returns current with the keys of remove taken out
+// and the missing keys of add appended. Keys compare by type and blob, so
+// options and comments do not matter; other lines are kept as they are.
+func UpdateAuthorizedKeys(current string, add, remove []string) (updated string, added, removed int) {
+	blob := func(line string) string {
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+		if err != nil {
+			return ""
+		}
+		return string(key.Marshal())
+	}
+	wanted := make(map[string]bool)
+	for _, k := range add {
+		if b := blob(k); b != "" {
+			wanted[b] = true
+		}
+	}
+	unwanted := make(map[string]bool)
+	for _, k := range remove {
+		if b := blob(k); b != "" && !wanted[b] {
+			unwanted[b] = true
+		}
+	}
+
+	var lines []string
+	present := make(map[string]bool)
+	if current = strings.TrimRight(current, "\n"); current != "" {
+		for _, line := range strings.Split(current, "\n") {
+			b := blob(line)
+			if unwanted[b] {
+				removed++
+				continue
+			}
+			if b != "" {
+				present[b] = true
+			}
+			lines = append(lines, line)
+		}
+	}
+	for _, k := range add {
+		b := blob(k)
+		if b == "" || present[b] {
+			continue
+		}
+		present[b] = true
+		lines = append(lines, strings.TrimSpace(k))
+		added++
+	}
+	if len(lines) == 0 {
+		return "", added, removed
+	}
+	return strings.Join(lines, "\n") + "\n", added, removed
+}
@@ -0,0 +1,254 @@
+package egresskey
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+
+	"goBastion/internal/models"
+)
+
+// testKey returns an authorized_keys line and the PEM private key of a new
+// ed25519 key.
+func testKey(t *testing.T, comment string) (pub, priv string) {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	pub = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey()))) + " " + comment
+	return pub, string(pem.EncodeToMemory(block))
+}
+
+func TestUpdateAuthorizedKeys(t *testing.T) {
+	oldPub, _ := testKey(t, "old")
+	newPub, _ := testKey(t, "new")
+	retiredPub, _ := testKey(t, "retired")
+	current := "# managed by hand\n" +
+		`from="10.0.0.0/8" ` + oldPub + "\n" +
+		retiredPub + "\n"
+
+	updated, added, removed := UpdateAuthorizedKeys(current, []string{oldPub, newPub}, []string{retiredPub, oldPub})
+	if added != 1 || removed != 1 {
+		t.Fatalf("expected 1 added and 1 removed, got %d and %d", added, removed)
+	}
+	want := "# managed by hand\n" + `from="10.0.0.0/8" ` + oldPub + "\n" + newPub + "\n"
+	if updated != want {
+		t.Fatalf("unexpected authorized_keys:\n%s", updated)
+	}
+
+	// A second run changes nothing, whatever the comments.
+	again, added, removed := UpdateAuthorizedKeys(updated, []string{strings.TrimSuffix(newPub, " new")}, []string{retiredPub})
+	if again != updated || added != 0 || removed != 0 {
+		t.Fatalf("expected no change, got %d added, %d removed:\n%s", added, removed, again)
+	}
+
+	if empty, added, _ := UpdateAuthorizedKeys("", []string{newPub}, nil); empty != newPub+"\n" || added != 1 {
+		t.Fatalf("unexpected result for a missing file: %q", empty)
+	}
+}
+
+func TestExpandServers(t *testing.T) {
+	db := newTestDB(t)
+	db.Create(&models.Host{Name: "web01", Addresses: "10.0.0.5", Tags: "env=prod"})
+	accesses := []Access{
+		{Server: "10.0.0.0/24", Port: 22, Username: "root"},
+		{Server: "tag:env=prod", Port: 2222, Username: "deploy"},
+		{Server: "db1", Port: 22, Username: "*"},
+	}
+
+	targets, skipped, err := Expand(db, accesses, []string{"10.0.0.5", "db1"})
+	if err != nil {
+		t.Fatalf("Expand: %v", err)
+	}
+	var got []string
+	for _, tg := range targets {
+		got = append(got, tg.String())
+	}
+	if strings.Join(got, ",") != "deploy@10.0.0.5:2222,root@10.0.0.5:22" {
+		t.Fatalf("unexpected targets %v", got)
+	}
+	if len(skipped) != 1 || skipped[0] != "*@db1:22" {
+		t.Fatalf("a wildcard account cannot be deployed to, got %v", skipped)
+	}
+
+	if _, _, err := Expand(db, accesses, []string{"10.1.0.1"}); err == nil {
+		t.Fatal("expected an error for a server no access covers")
+	}
+}
+
+// fakeTarget is an SSH server holding one authorized_keys file.
+type fakeTarget struct {
+	mu      sync.Mutex
+	file    string
+	readErr bool // reading authorized_keys fails, as with a permission error
+	writes  int
+	hostKey ssh.PublicKey
+	addr    *net.TCPAddr
+}
+
+func startFakeTarget(t *testing.T, authorized, file string) *fakeTarget {
+	t.Helper()
+	_, hostPriv, _ := ed25519.GenerateKey(rand.Reader)
+	hostSigner, _ := ssh.NewSignerFromKey(hostPriv)
+	allowed, _, _, _, err := ssh.ParseAuthorizedKey([]byte(authorized))
+	if err != nil {
+		t.Fatalf("parse key: %v", err)
+	}
+	cfg := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), allowed.Marshal()) {
+				return nil, nil
+			}
+			return nil, io.EOF
+		},
+		PasswordCallback: func(_ ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, io.EOF
+		},
+	}
+	cfg.AddHostKey(hostSigner)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	ft := &fakeTarget{file: file, hostKey: hostSigner.PublicKey(), addr: l.Addr().(*net.TCPAddr)}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go ft.serve(conn, cfg)
+		}
+	}()
+	return ft
+}
+
+func (ft *fakeTarget) serve(conn net.Conn, cfg *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, cfg)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for nc := range chans {
+		ch, requests, err := nc.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range requests {
+				var exec struct{ Command string }
+				if req.Type != "exec" || ssh.Unmarshal(req.Payload, &exec) != nil {
+					_ = req.Reply(false, nil)
+					continue
+				}
+				_ = req.Reply(true, nil)
+				ft.mu.Lock()
+				status := uint32(0)
+				switch exec.Command {
+				case readAuthorizedKeys:
+					if ft.readErr {
+						_, _ = io.WriteString(ch.Stderr(), "cat: .ssh/authorized_keys: Permission denied")
+						status = 1
+						break
+					}
+					_, _ = io.WriteString(ch, ft.file)
+				case writeAuthorizedKeys:
+					b, _ := io.ReadAll(ch)
+					ft.file = string(b)
+					ft.writes++
+				}
+				ft.mu.Unlock()
+				_, _ = ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+				_ = ch.Close()
+			}
+		}()
+	}
+}
+
+func TestDeployIsIdempotent(t *testing.T) {
+	oldPub, oldPriv := testKey(t, "old")
+	newPub, _ := testKey(t, "new")
+	retiredPub, _ := testKey(t, "retired")
+	ft := startFakeTarget(t, oldPub, oldPub+"\n"+retiredPub+"\n")
+	target := Target{Server: "127.0.0.1", Port: int64(ft.addr.Port), Username: "root"}
+	d := Deployment{Add: []string{oldPub, newPub}, Remove: []string{retiredPub}, Auth: []string{oldPriv}}
+
+	r := Deploy(target, d, []ssh.PublicKey{ft.hostKey})
+	if r.Err != nil || r.Added != 1 || r.Removed != 1 || r.Method != "egress key" {
+		t.Fatalf("unexpected report %+v", r)
+	}
+	if ft.file != oldPub+"\n"+newPub+"\n" {
+		t.Fatalf("unexpected authorized_keys:\n%s", ft.file)
+	}
+
+	r = Deploy(target, d, []ssh.PublicKey{ft.hostKey})
+	if r.Err != nil || r.Added != 0 || r.Removed != 0 || ft.writes != 1 {
+		t.Fatalf("a second deployment must not rewrite the file: %+v, %d writes", r, ft.writes)
+	}
+	if !strings.Contains(r.String(), "already up to date") {
+		t.Fatalf("unexpected report line %q", r.String())
+	}
+}
+
+func TestDeployKeepsUnreadableAuthorizedKeys(t *testing.T) {
+	oldPub, oldPriv := testKey(t, "old")
+	newPub, _ := testKey(t, "new")
+	otherPub, _ := testKey(t, "other")
+	ft := startFakeTarget(t, oldPub, oldPub+"\n"+otherPub+"\n")
+	ft.readErr = true
+	target := Target{Server: "127.0.0.1", Port: int64(ft.addr.Port), Username: "root"}
+	d := Deployment{Add: []string{newPub}, Auth: []string{oldPriv}}
+
+	r := Deploy(target, d, []ssh.PublicKey{ft.hostKey})
+	if r.Err == nil || !strings.Contains(r.Err.Error(), "Permission denied") {
+		t.Fatalf("expected the read error to fail the target, got %+v", r)
+	}
+	if ft.writes != 0 || ft.file != oldPub+"\n"+otherPub+"\n" {
+		t.Fatalf("authorized_keys must be left alone after a read error:\n%s", ft.file)
+	}
+}
+
+func TestDeployFallsBackToPassword(t *testing.T) {
+	oldPub, _ := testKey(t, "old")
+	newPub, newPriv := testKey(t, "new")
+	ft := startFakeTarget(t, oldPub, "")
+	target := Target{Server: "127.0.0.1", Port: int64(ft.addr.Port), Username: "root"}
+	asked := 0
+	d := Deployment{Add: []string{newPub}, Auth: []string{newPriv}, Password: func() (string, error) {
+		asked++
+		return "secret", nil
+	}}
+
+	r := Deploy(target, d, []ssh.PublicKey{ft.hostKey})
+	if r.Err != nil || r.Added != 1 || r.Method != "password" || asked != 1 {
+		t.Fatalf("unexpected report %+v (password asked %d times)", r, asked)
+	}
+
+	// An unknown host key is refused before authenticating.
+	other, _ := testKey(t, "other")
+	otherKey, _, _, _, _ := ssh.ParseAuthorizedKey([]byte(other))
+	if r := Deploy(target, d, []ssh.PublicKey{otherKey}); r.Err == nil {
+		t.Fatal("expected a host key mismatch")
+	}
+}
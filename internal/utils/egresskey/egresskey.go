@@ -0,0 +1,458 @@
+// Package egresskey manages the lifecycle of group egress keys. A rotation
+// adds a "next" key beside the "active" one; both are offered to targets
+// while the next key is probed on every target of the group. Once all
+// targets accept it, the next key is promoted and the previous one becomes
+// "retiring" until the grace period granted by the rotation ends, after
+// which it is "retired" and never offered again.
+package egresskey
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"goBastion/internal/config"
+	"goBastion/internal/models"
+	"goBastion/internal/utils/cryptokey"
+	"goBastion/internal/utils/hostmatch"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+// Target is a concrete server, port and account a group key must reach.
+type Target struct {
+	Server   string
+	Port     int64
+	Username string
+}
+
+func (t Target) String() string {
+	return fmt.Sprintf("%s@%s:%d", t.Username, t.Server, t.Port)
+}
+
+// Result is the verification status of one target for a next key.
+type Result struct {
+	Target
+	VerifiedAt *time.Time
+	Err        error // last probe failure; nil when verified or not probed
+}
+
+// Status describes a rotation in progress.
+type Status struct {
+	Next       models.GroupEgressKey
+	Results    []Result
+	Unprobable []string // pattern accesses that cannot be verified automatically
+}
+
+// Verified reports whether the next key reached every target, which allows
+// its automatic promotion.
+func (st Status) Verified() bool {
+	if len(st.Unprobable) > 0 {
+		return false
+	}
+	for _, r := range st.Results {
+		if r.VerifiedAt == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Probe authenticates on a target with privKey alone, accepting only the
+// given host keys.
+type Probe func(t Target, privKey string, hostKeys []ssh.PublicKey) error
+
+// ErrNoRotation is returned when a group has no next key.
+var ErrNoRotation = errors.New("no key rotation in progress")
+
+// SetState moves a key to a new state and logs the transition.
+func SetState(db *gorm.DB, log *slog.Logger, groupName string, key *models.GroupEgressKey, state, reason string, now time.Time) error {
+	from := key.State
+	if err := db.Model(&models.GroupEgressKey{}).Where("id = ?", key.ID).
+		Updates(map[string]any{"state": state, "state_changed_at": now}).Error; err != nil {
+		return fmt.Errorf("error changing state of egress key %s: %w", key.ID, err)
+	}
+	key.State = state
+	key.StateChangedAt = &now
+	log.Info("group_egress_key_state",
+		slog.String("group", groupName),
+		slog.String("key_id", key.ID.String()),
+		slog.String("fingerprint", key.Fingerprint),
+		slog.String("from", from),
+		slog.String("to", state),
+		slog.String("reason", reason),
+	)
+	return nil
+}
+
+// StartRotation stores next as the group's next key. The keys active at
+// that time stay usable at least until now+grace.
+func StartRotation(db *gorm.DB, log *slog.Logger, group models.Group, next *models.GroupEgressKey, grace time.Duration, now time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var pending int64
+		if err := tx.Model(&models.GroupEgressKey{}).
+			Where("group_id = ? AND state = ?", group.ID, models.EgressKeyNext).
+			Count(&pending).Error; err != nil {
+			return err
+		}
+		if pending > 0 {
+			return fmt.Errorf("a key rotation is already in progress for group '%s'", group.Name)
+		}
+		retireAfter := now.Add(grace)
+		if err := tx.Model(&models.GroupEgressKey{}).
+			Where("group_id = ? AND state = ?", group.ID, models.EgressKeyActive).
+			Update("retire_after", retireAfter).Error; err != nil {
+			return err
+		}
+		next.GroupID = group.ID
+		next.State = models.EgressKeyNext
+		next.StateChangedAt = &now
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		log.Info("group_egress_key_state",
+			slog.String("group", group.Name),
+			slog.String("key_id", next.ID.String()),
+			slog.String("fingerprint", next.Fingerprint),
+			slog.String("from", ""),
+			slog.String("to", models.EgressKeyNext),
+			slog.String("reason", "rotation"),
+			slog.Time("retire_after", retireAfter),
+		)
+		return nil
+	})
+}
+
+// Promote makes the group's next key active and moves the keys active until
+// then to retiring.
+func Promote(db *gorm.DB, log *slog.Logger, group models.Group, reason string, now time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var next models.GroupEgressKey
+		if err := tx.Where("group_id = ? AND state = ?", group.ID, models.EgressKeyNext).First(&next).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNoRotation
+			}
+			return err
+		}
+		var active []models.GroupEgressKey
+		if err := tx.Where("group_id = ? AND state = ?", group.ID, models.EgressKeyActive).Find(&active).Error; err != nil {
+			return err
+		}
+		for i := range active {
+			if err := SetState(tx, log, group.Name, &active[i], models.EgressKeyRetiring, reason, now); err != nil {
+				return err
+			}
+		}
+		return SetState(tx, log, group.Name, &next, models.EgressKeyActive, reason, now)
+	})
+}
+
+// Cancel abandons the group's next key: it is retired and the keys still
+// active lose the retirement date set by the rotation.
+func Cancel(db *gorm.DB, log *slog.Logger, group models.Group, now time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var next models.GroupEgressKey
+		if err := tx.Where("group_id = ? AND state = ?", group.ID, models.EgressKeyNext).First(&next).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNoRotation
+			}
+			return err
+		}
+		if err := tx.Model(&models.GroupEgressKey{}).
+			Where("group_id = ? AND state = ?", group.ID, models.EgressKeyActive).
+			Update("retire_after", nil).Error; err != nil {
+			return err
+		}
+		return SetState(tx, log, group.Name, &next, models.EgressKeyRetired, "rotation cancelled", now)
+	})
+}
+
+// Access is the server, port and account of an access entry.
+type Access struct {
+	Server   string
+	Port     int64
+	Username string
+}
+
+// GroupAccesses returns the group's accesses that have not expired.
+func GroupAccesses(db *gorm.DB, groupID uuid.UUID, now time.Time) ([]Access, error) {
+	var rows []models.GroupAccess
+	if err := db.Where("group_id = ?", groupID).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("error querying group accesses: %w", err)
+	}
+	accesses := make([]Access, len(rows))
+	for i, a := range rows {
+		accesses[i] = Access{Server: a.Server, Port: a.Port, Username: a.Username}
+	}
+	return accesses, nil
+}
+
+// SelfAccesses returns the user's personal accesses that have not expired.
+func SelfAccesses(db *gorm.DB, userID uuid.UUID, now time.Time) ([]Access, error) {
+	var rows []models.SelfAccess
+	if err := db.Where("user_id = ?", userID).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("error querying personal accesses: %w", err)
+	}
+	accesses := make([]Access, len(rows))
+	for i, a := range rows {
+		accesses[i] = Access{Server: a.Server, Port: a.Port, Username: a.Username}
+	}
+	return accesses, nil
+}
+
+// Targets returns the distinct targets of the group's current accesses, and
+// the pattern entries that cannot be probed.
+func Targets(db *gorm.DB, groupID uuid.UUID, now time.Time) (targets []Target, unprobable []string, err error) {
+	accesses, err := GroupAccesses(db, groupID, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	return Expand(db, accesses, nil)
+}
+
+// Expand turns accesses into distinct concrete targets. Tag selectors are
+// expanded to the first address of each inventory host they select; CIDR
+// blocks, globs and wildcard accounts are returned in unprobable. With
+// servers, only those servers are returned, once per account and port of
+// the accesses covering them, and a server no access covers is an error.
+func Expand(db *gorm.DB, accesses []Access, servers []string) (targets []Target, unprobable []string, err error) {
+	var hosts []models.Host
+	seen := make(map[string]bool)
+	add := func(server string, a Access) {
+		t := Target{Server: server, Port: a.Port, Username: a.Username}
+		if seen[t.String()] {
+			return
+		}
+		seen[t.String()] = true
+		if hostmatch.IsPattern(server) || models.IsTagSelector(server) || a.Username == "*" {
+			unprobable = append(unprobable, t.String())
+			return
+		}
+		targets = append(targets, t)
+	}
+
+	for _, server := range servers {
+		var inventory []models.Host
+		covered := false
+		for _, a := range accesses {
+			ok := hostmatch.Match(a.Server, server)
+			if models.IsTagSelector(a.Server) {
+				if inventory == nil {
+					if inventory, err = models.HostsForTarget(db, server); err != nil {
+						return nil, nil, err
+					}
+				}
+				ok = models.TagSelectorCovers(a.Server, inventory)
+			}
+			if ok {
+				covered = true
+				add(server, a)
+			}
+		}
+		if !covered {
+			return nil, nil, fmt.Errorf("no access covers server %q", server)
+		}
+	}
+	if len(servers) > 0 {
+		accesses = nil
+	}
+
+	for _, a := range accesses {
+		if !models.IsTagSelector(a.Server) || a.Username == "*" {
+			add(a.Server, a)
+			continue
+		}
+		selector, _, err := models.ParseTagSelector(a.Server)
+		if err != nil {
+			add(a.Server, a)
+			continue
+		}
+		if hosts == nil {
+			if err := db.Find(&hosts).Error; err != nil {
+				return nil, nil, fmt.Errorf("error retrieving inventory hosts: %w", err)
+			}
+		}
+		for i := range hosts {
+			if addresses := hosts[i].AddressList(); len(addresses) > 0 && hosts[i].MatchesSelector(selector) {
+				add(addresses[0], a)
+			}
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].String() < targets[j].String() })
+	sort.Strings(unprobable)
+	return targets, unprobable, nil
+}
+
+// Check returns the state of the group's rotation. With a non-nil probe,
+// the targets not verified yet are probed with the next key and those it
+// reaches are recorded.
+func Check(db *gorm.DB, group models.Group, probe Probe, now time.Time) (Status, error) {
+	var st Status
+	if err := db.Where("group_id = ? AND state = ?", group.ID, models.EgressKeyNext).First(&st.Next).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return st, ErrNoRotation
+		}
+		return st, err
+	}
+	targets, unprobable, err := Targets(db, group.ID, now)
+	if err != nil {
+		return st, err
+	}
+	st.Unprobable = unprobable
+
+	var verifications []models.GroupEgressKeyVerification
+	if err := db.Where("key_id = ?", st.Next.ID).Find(&verifications).Error; err != nil {
+		return st, fmt.Errorf("error querying key verifications: %w", err)
+	}
+	verified := make(map[string]time.Time, len(verifications))
+	for _, v := range verifications {
+		verified[Target{Server: v.Server, Port: v.Port, Username: v.Username}.String()] = v.VerifiedAt
+	}
+
+	privKey := cryptokey.DecryptOrPassThrough(st.Next.PrivKey)
+	for _, t := range targets {
+		r := Result{Target: t}
+		if at, ok := verified[t.String()]; ok {
+			r.VerifiedAt = &at
+		} else if probe != nil {
+			r.Err = probeTarget(db, probe, t, privKey)
+			if r.Err == nil {
+				if err := db.Create(&models.GroupEgressKeyVerification{
+					KeyID: st.Next.ID, Server: t.Server, Port: t.Port, Username: t.Username, VerifiedAt: now,
+				}).Error; err != nil {
+					return st, fmt.Errorf("error recording key verification: %w", err)
+				}
+				at := now
+				r.VerifiedAt = &at
+			}
+		}
+		st.Results = append(st.Results, r)
+	}
+	return st, nil
+}
+
+// probeTarget probes t with the host keys the bastion recorded for it.
+func probeTarget(db *gorm.DB, probe Probe, t Target, privKey string) error {
+	hostKeys, err := KnownHostKeys(db, t.Server, t.Port)
+	if err != nil {
+		return err
+	}
+	if len(hostKeys) == 0 {
+		return fmt.Errorf("host key unknown: connect to the target through the bastion once first")
+	}
+	return probe(t, privKey, hostKeys)
+}
+
+// KnownHostKeys returns the host keys recorded for server:port in any
+// account's known hosts.
+func KnownHostKeys(db *gorm.DB, server string, port int64) ([]ssh.PublicKey, error) {
+	hostToken := server
+	if port != 22 {
+		hostToken = fmt.Sprintf("[%s]:%d", server, port)
+	}
+	var entries []models.KnownHostsEntry
+	if err := db.Where("entry LIKE ?", hostToken+" %").Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("error retrieving known hosts: %w", err)
+	}
+	var keys []ssh.PublicKey
+	for _, e := range entries {
+		parts := strings.Fields(e.Entry)
+		if len(parts) < 3 || parts[0] != hostToken {
+			continue
+		}
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(parts[1] + " " + parts[2]))
+		if err != nil {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// DialProbe is the Probe used in production: it opens an SSH connection to
+// the target and closes it once authenticated.
+func DialProbe(t Target, privKey string, hostKeys []ssh.PublicKey) error {
+	signer, err := ssh.ParsePrivateKey([]byte(privKey))
+	if err != nil {
+		return fmt.Errorf("parse egress key: %w", err)
+	}
+	auth := []ssh.AuthMethod{ssh.PublicKeys(signer)}
+	client, err := ssh.Dial("tcp", net.JoinHostPort(t.Server, strconv.FormatInt(t.Port, 10)), clientConfig(t, auth, hostKeys))
+	if err != nil {
+		return err
+	}
+	return client.Close()
+}
+
+// clientConfig logs in to t with auth, accepting only the given host keys.
+func clientConfig(t Target, auth []ssh.AuthMethod, hostKeys []ssh.PublicKey) *ssh.ClientConfig {
+	var algorithms []string
+	for _, k := range hostKeys {
+		if k.Type() == ssh.KeyAlgoRSA {
+			algorithms = append(algorithms, ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256)
+		}
+		algorithms = append(algorithms, k.Type())
+	}
+	return &ssh.ClientConfig{
+		User: t.Username,
+		Auth: auth,
+		HostKeyCallback: func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			for _, k := range hostKeys {
+				if bytes.Equal(k.Marshal(), key.Marshal()) {
+					return nil
+				}
+			}
+			return fmt.Errorf("host key does not match the recorded one")
+		},
+		HostKeyAlgorithms: algorithms,
+		Timeout:           time.Duration(config.Get().SSH.KeyscanTimeout),
+	}
+}
+
+// Advance moves every group's rotation forward: next keys are probed and
+// promoted once verified on all targets, and retiring keys past their grace
+// period are retired.
+func Advance(db *gorm.DB, log *slog.Logger, probe Probe, now time.Time) error {
+	var pending []models.GroupEgressKey
+	if err := db.Preload("Group").Where("state = ?", models.EgressKeyNext).Find(&pending).Error; err != nil {
+		return fmt.Errorf("error querying next egress keys: %w", err)
+	}
+	for _, next := range pending {
+		st, err := Check(db, next.Group, probe, now)
+		if err != nil {
+			log.Error("group_egress_key_verify_failed", slog.String("group", next.Group.Name), slog.Any("error", err))
+			continue
+		}
+		if !st.Verified() {
+			continue
+		}
+		if err := Promote(db, log, next.Group, "verified on all targets", now); err != nil {
+			log.Error("group_egress_key_promote_failed", slog.String("group", next.Group.Name), slog.Any("error", err))
+		}
+	}
+
+	var retiring []models.GroupEgressKey
+	if err := db.Preload("Group").
+		Where("state = ? AND (retire_after IS NULL OR retire_after <= ?)", models.EgressKeyRetiring, now).
+		Find(&retiring).Error; err != nil {
+		return fmt.Errorf("error querying retiring egress keys: %w", err)
+	}
+	for i := range retiring {
+		if err := SetState(db, log, retiring[i].Group.Name, &retiring[i], models.EgressKeyRetired, "grace period over", now); err != nil {
+			log.Error("group_egress_key_retire_failed", slog.String("group", retiring[i].Group.Name), slog.Any("error", err))
+		}
+	}
+	return nil
+}
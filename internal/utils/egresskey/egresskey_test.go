@@ -0,0 +1,194 @@
+package egresskey
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+
+	"goBastion/internal/models"
+)
+
+var testLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open test DB: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sql DB: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(
+		&models.User{}, &models.Group{}, &models.GroupAccess{}, &models.KnownHostsEntry{}, &models.Host{},
+		&models.GroupEgressKey{}, &models.GroupEgressKeyVerification{},
+	); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// setup creates a group with an active key and one access per server, each
+// with a recorded host key.
+func setup(t *testing.T, db *gorm.DB, servers ...string) (models.Group, models.GroupEgressKey) {
+	t.Helper()
+	g := models.Group{Name: "ops"}
+	if err := db.Create(&g).Error; err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	u := models.User{Username: "alice", Role: models.RoleUser, Enabled: true}
+	if err := db.Create(&u).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	hostKey, _ := ssh.NewPublicKey(pub)
+	for _, s := range servers {
+		db.Create(&models.GroupAccess{GroupID: g.ID, Server: s, Username: "root", Port: 22})
+		db.Create(&models.KnownHostsEntry{UserID: u.ID, Entry: s + " " + string(ssh.MarshalAuthorizedKey(hostKey))})
+	}
+	active := models.GroupEgressKey{GroupID: g.ID, PubKey: "old", PrivKey: "old", Type: "ed25519", Size: 256, Fingerprint: "OLD"}
+	if err := db.Create(&active).Error; err != nil {
+		t.Fatalf("create key: %v", err)
+	}
+	return g, active
+}
+
+func states(t *testing.T, db *gorm.DB) map[string]string {
+	t.Helper()
+	var keys []models.GroupEgressKey
+	db.Find(&keys)
+	m := make(map[string]string)
+	for _, k := range keys {
+		m[k.Fingerprint] = k.State
+	}
+	return m
+}
+
+func TestRotationPromotesOnceEveryTargetIsVerified(t *testing.T) {
+	db := newTestDB(t)
+	g, _ := setup(t, db, "web1", "web2")
+	now := time.Now()
+	next := models.GroupEgressKey{PubKey: "new", PrivKey: "new", Type: "ed25519", Size: 256, Fingerprint: "NEW"}
+	if err := StartRotation(db, testLog, g, &next, 48*time.Hour, now); err != nil {
+		t.Fatalf("StartRotation: %v", err)
+	}
+	if err := StartRotation(db, testLog, g, &models.GroupEgressKey{Fingerprint: "X"}, 0, now); err == nil {
+		t.Fatal("a second rotation must be refused while one is in progress")
+	}
+
+	// web2 refuses the key: nothing is promoted.
+	probe := func(tg Target, _ string, hostKeys []ssh.PublicKey) error {
+		if len(hostKeys) != 1 {
+			t.Fatalf("expected the recorded host key, got %d", len(hostKeys))
+		}
+		if tg.Server == "web2" {
+			return errors.New("permission denied")
+		}
+		return nil
+	}
+	if err := Advance(db, testLog, probe, now); err != nil {
+		t.Fatalf("Advance: %v", err)
+	}
+	if got := states(t, db); got["OLD"] != models.EgressKeyActive || got["NEW"] != models.EgressKeyNext {
+		t.Fatalf("unexpected states after a partial verification: %v", got)
+	}
+
+	// Only the target not verified yet is probed again.
+	probed := 0
+	if err := Advance(db, testLog, func(Target, string, []ssh.PublicKey) error { probed++; return nil }, now); err != nil {
+		t.Fatalf("Advance: %v", err)
+	}
+	if probed != 1 {
+		t.Fatalf("expected 1 probe, got %d", probed)
+	}
+	if got := states(t, db); got["OLD"] != models.EgressKeyRetiring || got["NEW"] != models.EgressKeyActive {
+		t.Fatalf("unexpected states after verification: %v", got)
+	}
+
+	// The previous key is retired once the grace period is over.
+	if err := Advance(db, testLog, nil, now.Add(time.Hour)); err != nil {
+		t.Fatalf("Advance: %v", err)
+	}
+	if got := states(t, db); got["OLD"] != models.EgressKeyRetiring {
+		t.Fatalf("the previous key must stay usable during the grace period: %v", got)
+	}
+	if err := Advance(db, testLog, nil, now.Add(49*time.Hour)); err != nil {
+		t.Fatalf("Advance: %v", err)
+	}
+	if got := states(t, db); got["OLD"] != models.EgressKeyRetired {
+		t.Fatalf("the previous key must be retired after the grace period: %v", got)
+	}
+}
+
+func TestPatternsAndUnknownHostsBlockPromotion(t *testing.T) {
+	db := newTestDB(t)
+	g, _ := setup(t, db, "web1")
+	db.Create(&models.GroupAccess{GroupID: g.ID, Server: "10.0.0.0/24", Username: "root", Port: 22})
+	db.Create(&models.GroupAccess{GroupID: g.ID, Server: "db1", Username: "root", Port: 2222})
+	db.Create(&models.GroupAccess{GroupID: g.ID, Server: "tag:env=prod", Username: "root", Port: 22})
+	db.Create(&models.Host{Name: "web01", Addresses: "web1,10.0.0.5", Tags: "env=prod"})
+	now := time.Now()
+	next := models.GroupEgressKey{PubKey: "new", PrivKey: "new", Fingerprint: "NEW"}
+	if err := StartRotation(db, testLog, g, &next, 0, now); err != nil {
+		t.Fatalf("StartRotation: %v", err)
+	}
+
+	st, err := Check(db, g, func(Target, string, []ssh.PublicKey) error { return nil }, now)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if len(st.Unprobable) != 1 || st.Unprobable[0] != "root@10.0.0.0/24:22" {
+		t.Fatalf("expected the CIDR access to be unprobable, got %v", st.Unprobable)
+	}
+	if len(st.Results) != 2 {
+		t.Fatalf("expected the tag selector to expand to web1, got %+v", st.Results)
+	}
+	for _, r := range st.Results {
+		if r.Server == "db1" && (r.VerifiedAt != nil || r.Err == nil) {
+			t.Fatalf("a target without a recorded host key must not be verified: %+v", r)
+		}
+	}
+	if st.Verified() {
+		t.Fatal("rotation must not count as verified")
+	}
+
+	if err := Promote(db, testLog, g, "manual", now); err != nil {
+		t.Fatalf("Promote: %v", err)
+	}
+	if got := states(t, db); got["OLD"] != models.EgressKeyRetiring || got["NEW"] != models.EgressKeyActive {
+		t.Fatalf("unexpected states after a forced promotion: %v", got)
+	}
+	if err := Promote(db, testLog, g, "manual", now); !errors.Is(err, ErrNoRotation) {
+		t.Fatalf("expected ErrNoRotation, got %v", err)
+	}
+}
+
+func TestCancelRetiresNextKey(t *testing.T) {
+	db := newTestDB(t)
+	g, active := setup(t, db, "web1")
+	now := time.Now()
+	next := models.GroupEgressKey{PubKey: "new", PrivKey: "new", Fingerprint: "NEW"}
+	if err := StartRotation(db, testLog, g, &next, time.Hour, now); err != nil {
+		t.Fatalf("StartRotation: %v", err)
+	}
+	if err := Cancel(db, testLog, g, now); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if got := states(t, db); got["OLD"] != models.EgressKeyActive || got["NEW"] != models.EgressKeyRetired {
+		t.Fatalf("unexpected states after cancel: %v", got)
+	}
+	db.First(&active, "id = ?", active.ID)
+	if active.RetireAfter != nil {
+		t.Fatal("cancel must clear the retirement date of the active key")
+	}
+}
@@ -0,0 +1,126 @@
+// Package hostmatch matches the server column of access entries against the
+// host a user is connecting to. A server can be a literal hostname or IP, a
+// CIDR block (10.20.0.0/16) or a hostname glob (*.db.prod.example).
+package hostmatch
+
+import (
+	"net"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kind identifies how a server value is interpreted.
+type Kind int
+
+const (
+	// KindExact is a literal hostname or IP address.
+	KindExact Kind = iota
+	// KindCIDR is an IPv4 or IPv6 network in CIDR notation.
+	KindCIDR
+	// KindGlob is a hostname containing one or more '*' wildcards.
+	KindGlob
+)
+
+// globRegexp restricts globs to hostname characters plus '*'.
+var globRegexp = regexp.MustCompile(`^[a-zA-Z0-9._*-]+$`)
+
+// CandidateClause is a SQL fragment selecting rows whose server column is
+// either the literal host (bound to the single '?') or a pattern that may
+// cover it. Rows returned still have to be checked with Match.
+const CandidateClause = "(server = ? OR server LIKE '%/%' OR server LIKE '%*%')"
+
+// KindOf classifies a server value.
+func KindOf(server string) Kind {
+	if strings.Contains(server, "/") {
+		if _, _, err := net.ParseCIDR(server); err == nil {
+			return KindCIDR
+		}
+	}
+	if strings.Contains(server, "*") {
+		return KindGlob
+	}
+	return KindExact
+}
+
+// IsPattern returns true when server is a CIDR block or a hostname glob.
+func IsPattern(server string) bool {
+	return KindOf(server) != KindExact
+}
+
+// IsValidPattern returns true when server is a well-formed CIDR block or
+// hostname glob. A glob must contain at least one literal character so that
+// a bare "*" cannot silently grant every host.
+func IsValidPattern(server string) bool {
+	switch KindOf(server) {
+	case KindCIDR:
+		return true
+	case KindGlob:
+		if !globRegexp.MatchString(server) || strings.Trim(server, "*.") == "" {
+			return false
+		}
+		_, err := path.Match(server, "")
+		return err == nil
+	default:
+		return false
+	}
+}
+
+// Match reports whether the stored server value covers host.
+// Hostnames compare case-insensitively. CIDR blocks only match literal IP
+// addresses: no DNS resolution is performed, so a hostname never matches a
+// CIDR entry.
+func Match(server, host string) bool {
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	switch KindOf(server) {
+	case KindCIDR:
+		ip := net.ParseIP(host)
+		if ip == nil {
+			return false
+		}
+		_, network, _ := net.ParseCIDR(server)
+		return network.Contains(ip)
+	case KindGlob:
+		ok, err := path.Match(strings.ToLower(server), strings.ToLower(host))
+		return err == nil && ok
+	default:
+		return strings.EqualFold(strings.TrimSuffix(strings.TrimPrefix(server, "["), "]"), host)
+	}
+}
+
+// Specificity ranks how narrowly a server value matches; higher is more
+// specific. Exact entries always outrank patterns, CIDR blocks outrank globs
+// and rank by prefix length, globs rank by their number of literal characters.
+func Specificity(server string) int {
+	switch KindOf(server) {
+	case KindCIDR:
+		_, network, _ := net.ParseCIDR(server)
+		ones, _ := network.Mask.Size()
+		return 1000 + ones
+	case KindGlob:
+		return len(strings.ReplaceAll(server, "*", ""))
+	default:
+		return 10000
+	}
+}
+
+// SortBySpecificity stably orders items so the most specific server comes
+// first. server extracts the stored server value from an item.
+func SortBySpecificity[T any](items []T, server func(T) string) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return Specificity(server(items[i])) > Specificity(server(items[j]))
+	})
+}
+
+// Filter returns the items whose server covers host, preserving order.
+// server extracts the stored server value from an item.
+func Filter[T any](items []T, host string, server func(T) string) []T {
+	out := items[:0]
+	for _, item := range items {
+		if Match(server(item), host) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,73 @@
+package hostmatch_test
+
+import (
+	"testing"
+
+	"goBastion/internal/utils/hostmatch"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		server string
+		host   string
+		want   bool
+	}{
+		{"web01.example.com", "web01.example.com", true},
+		{"WEB01.example.com", "web01.EXAMPLE.com", true},
+		{"web01.example.com", "web02.example.com", false},
+		{"10.20.0.0/16", "10.20.3.4", true},
+		{"10.20.0.0/16", "10.21.3.4", false},
+		{"10.20.0.0/16", "db.internal", false},
+		{"fd00::/8", "fd00::1", true},
+		{"fd00::/8", "[fd00::1]", true},
+		{"*.db.prod.example", "pg1.db.prod.example", true},
+		{"*.db.prod.example", "a.b.db.prod.example", true},
+		{"*.db.prod.example", "db.prod.example", false},
+		{"*.db.prod.example", "pg1.db.staging.example", false},
+		{"web-*.example.com", "web-42.example.com", true},
+		{"[::1]", "::1", true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.server+"_"+tc.host, func(t *testing.T) {
+			if got := hostmatch.Match(tc.server, tc.host); got != tc.want {
+				t.Errorf("Match(%q, %q) = %v, want %v", tc.server, tc.host, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsValidPattern(t *testing.T) {
+	tests := []struct {
+		server string
+		want   bool
+	}{
+		{"10.0.0.0/8", true},
+		{"2001:db8::/32", true},
+		{"*.example.com", true},
+		{"web-*", true},
+		{"*", false},
+		{"*.*", false},
+		{"10.0.0.0/33", false},
+		{"*.exa mple.com", false},
+		{"[a-z].example.com", false},
+		{"example.com", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.server, func(t *testing.T) {
+			if got := hostmatch.IsValidPattern(tc.server); got != tc.want {
+				t.Errorf("IsValidPattern(%q) = %v, want %v", tc.server, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSpecificityOrdering(t *testing.T) {
+	servers := []string{"*.example.com", "10.0.0.0/8", "*.db.example.com", "10.20.0.0/16", "10.20.3.4"}
+	hostmatch.SortBySpecificity(servers, func(s string) string { return s })
+	want := []string{"10.20.3.4", "10.20.0.0/16", "10.0.0.0/8", "*.db.example.com", "*.example.com"}
+	for i := range want {
+		if servers[i] != want[i] {
+			t.Fatalf("SortBySpecificity order = %v, want %v", servers, want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Package ingressca handles the SSH user CAs trusted to log in to the
+// bastion: the cert-authority lines of authorized_keys and the certificate
+// a session was opened with.
+package ingressca
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+
+	"goBastion/internal/models"
+
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+// DefaultPrincipals accepts certificates whose principal is the bastion
+// username.
+const DefaultPrincipals = "%u"
+
+// principalsRegexp restricts principal templates to characters that cannot
+// break out of the principals="..." option.
+var principalsRegexp = regexp.MustCompile(`^[A-Za-z0-9._@%+=-]+(,[A-Za-z0-9._@%+=-]+)*$`)
+
+// ValidatePrincipals checks a comma-separated principals template. Every
+// entry must contain %u: a static principal would be written into the
+// authorized_keys of every account, letting one certificate log in as anyone.
+func ValidatePrincipals(template string) error {
+	if !principalsRegexp.MatchString(template) {
+		return fmt.Errorf("principals must be a comma-separated list of names made of letters, digits and . _ @ %% + = -")
+	}
+	for _, p := range strings.Split(template, ",") {
+		if !strings.Contains(p, "%u") {
+			return fmt.Errorf("principal %q does not contain %%u: every principal must map to the bastion username", p)
+		}
+	}
+	return nil
+}
+
+// ExpandPrincipals returns the principals template with %u replaced by the
+// bastion username.
+func ExpandPrincipals(template, username string) string {
+	return strings.ReplaceAll(template, "%u", username)
+}
+
+// ParseCAKey parses a CA public key in authorized_keys format and returns it
+// without options or comment, with its fingerprint.
+func ParseCAKey(line string) (string, string, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+	if err != nil {
+		return "", "", fmt.Errorf("invalid CA public key: %w", err)
+	}
+	if _, ok := key.(*ssh.Certificate); ok {
+		return "", "", fmt.Errorf("a certificate is not a CA public key")
+	}
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))), ssh.FingerprintSHA256(key), nil
+}
+
+// AuthorizedKeysLines returns the cert-authority lines of user's
+// authorized_keys, one per trusted CA.
+func AuthorizedKeysLines(db *gorm.DB, user models.User) ([]string, error) {
+	var cas []models.IngressCA
+	if err := db.Order("name").Find(&cas).Error; err != nil {
+		return nil, fmt.Errorf("error retrieving ingress CAs: %w", err)
+	}
+	lines := make([]string, 0, len(cas))
+	for _, ca := range cas {
+		if err := ValidatePrincipals(ca.Principals); err != nil {
+			slog.Warn("ingress_ca_skipped", slog.String("ca", ca.Name), slog.Any("error", err))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf(`cert-authority,principals="%s" %s #CA:%s`,
+			ExpandPrincipals(ca.Principals, user.Username), ca.PublicKey, ca.ID))
+	}
+	return lines, nil
+}
+
+// SessionCertificate returns the certificate the current SSH session
+// authenticated with, read from the file sshd names in SSH_USER_AUTH when
+// ExposeAuthInfo is on. It returns nil when the session used no certificate.
+func SessionCertificate() (*ssh.Certificate, error) {
+	path := os.Getenv("SSH_USER_AUTH")
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return parseAuthInfo(bufio.NewScanner(f))
+}
+
+// parseAuthInfo returns the first certificate of the "publickey" methods of
+// an ExposeAuthInfo file.
+func parseAuthInfo(scanner *bufio.Scanner) (*ssh.Certificate, error) {
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[0] != "publickey" || !strings.Contains(fields[1], "-cert-") {
+			continue
+		}
+		blob, err := base64.StdEncoding.DecodeString(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("decode certificate: %w", err)
+		}
+		key, err := ssh.ParsePublicKey(blob)
+		if err != nil {
+			return nil, fmt.Errorf("parse certificate: %w", err)
+		}
+		if cert, ok := key.(*ssh.Certificate); ok {
+			return cert, nil
+		}
+	}
+	return nil, scanner.Err()
+}
+
+// LogAttrs describes cert for the login log.
+func LogAttrs(cert *ssh.Certificate) []any {
+	return []any{
+		slog.String("cert_key_id", cert.KeyId),
+		slog.Uint64("cert_serial", cert.Serial),
+		slog.String("cert_ca", ssh.FingerprintSHA256(cert.SignatureKey)),
+		slog.String("cert_principals", strings.Join(cert.ValidPrincipals, ",")),
+	}
+}
@@ -0,0 +1,118 @@
+package ingressca
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+
+	"goBastion/internal/models"
+)
+
+func newSigner(t *testing.T) ssh.Signer {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+	return signer
+}
+
+func TestAuthorizedKeysLines(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open test DB: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.IngressCA{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	admin := models.User{Username: "admin", Role: models.RoleAdmin, Enabled: true}
+	db.Create(&admin)
+	caKey, fingerprint, err := ParseCAKey(string(ssh.MarshalAuthorizedKey(newSigner(t).PublicKey())) + " corp-ca")
+	if err != nil {
+		t.Fatalf("ParseCAKey: %v", err)
+	}
+	ca := models.IngressCA{Name: "corp", PublicKey: caKey, Fingerprint: fingerprint, Principals: "%u,%u@corp.example", AddedByID: admin.ID}
+	if err := db.Create(&ca).Error; err != nil {
+		t.Fatalf("create CA: %v", err)
+	}
+	// A CA stored with a static principal is left out of authorized_keys.
+	staticKey, staticFingerprint, _ := ParseCAKey(string(ssh.MarshalAuthorizedKey(newSigner(t).PublicKey())))
+	if err := db.Create(&models.IngressCA{Name: "static", PublicKey: staticKey, Fingerprint: staticFingerprint, Principals: "ops", AddedByID: admin.ID}).Error; err != nil {
+		t.Fatalf("create static CA: %v", err)
+	}
+
+	lines, err := AuthorizedKeysLines(db, models.User{Username: "alice"})
+	if err != nil {
+		t.Fatalf("AuthorizedKeysLines: %v", err)
+	}
+	want := `cert-authority,principals="alice,alice@corp.example" ` + caKey + " #CA:" + ca.ID.String()
+	if len(lines) != 1 || lines[0] != want {
+		t.Fatalf("unexpected lines %q", lines)
+	}
+	if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(lines[0])); err != nil {
+		t.Fatalf("the line must be valid authorized_keys syntax: %v", err)
+	}
+}
+
+func TestValidatePrincipals(t *testing.T) {
+	for _, ok := range []string{"%u", "%u,%u@corp.example", "ops-%u"} {
+		if err := ValidatePrincipals(ok); err != nil {
+			t.Errorf("%q must be accepted: %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"", `%u" command="sh`, "a,,b", "a b", "ops", "%u,ops"} {
+		if err := ValidatePrincipals(bad); err == nil {
+			t.Errorf("%q must be refused", bad)
+		}
+	}
+}
+
+func TestParseCAKeyRefusesCertificates(t *testing.T) {
+	ca := newSigner(t)
+	cert := &ssh.Certificate{Key: newSigner(t).PublicKey(), CertType: ssh.UserCert, ValidBefore: ssh.CertTimeInfinity}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if _, _, err := ParseCAKey(string(ssh.MarshalAuthorizedKey(cert))); err == nil {
+		t.Fatal("a certificate must not be accepted as a CA key")
+	}
+}
+
+func TestParseAuthInfo(t *testing.T) {
+	ca := newSigner(t)
+	cert := &ssh.Certificate{
+		Key: newSigner(t).PublicKey(), CertType: ssh.UserCert, KeyId: "jdoe@corp", Serial: 42,
+		ValidPrincipals: []string{"alice"}, ValidBefore: uint64(time.Now().Add(time.Hour).Unix()),
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	plain := newSigner(t).PublicKey()
+	info := "publickey " + plain.Type() + " " + base64.StdEncoding.EncodeToString(plain.Marshal()) + "\n" +
+		"publickey " + cert.Type() + " " + base64.StdEncoding.EncodeToString(cert.Marshal()) + "\n"
+
+	got, err := parseAuthInfo(bufio.NewScanner(strings.NewReader(info)))
+	if err != nil || got == nil {
+		t.Fatalf("expected a certificate, got %v (%v)", got, err)
+	}
+	if got.KeyId != "jdoe@corp" || got.Serial != 42 {
+		t.Fatalf("unexpected certificate %q serial %d", got.KeyId, got.Serial)
+	}
+
+	got, err = parseAuthInfo(bufio.NewScanner(strings.NewReader("password\n")))
+	if err != nil || got != nil {
+		t.Fatalf("expected no certificate, got %v (%v)", got, err)
+	}
+}
@@ -0,0 +1,170 @@
+// Package justification handles the reason or ticket reference a user must
+// give before connecting through a group that requires one.
+//
+// A group pattern is a regular expression the whole answer must match
+// (e.g. "(INC|CHG)-[0-9]+"); an empty pattern accepts any non-empty answer.
+// Batch callers pass the answer with "--reason <value>" or "--reason=<value>";
+// interactive sessions are prompted for it instead.
+package justification
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Flag is the connection option that carries a justification.
+const Flag = "--reason"
+
+// MaxLength bounds the accepted justification length (in runes).
+const MaxLength = 200
+
+// CompilePattern validates pattern and returns the anchored expression used
+// to check answers. An empty pattern returns nil.
+func CompilePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid justification pattern %q: %w", pattern, err)
+	}
+	return re, nil
+}
+
+// Validate reports whether answer is an acceptable justification for pattern.
+func Validate(pattern, answer string) error {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return fmt.Errorf("a justification is required")
+	}
+	if len([]rune(answer)) > MaxLength {
+		return fmt.Errorf("justification is longer than %d characters", MaxLength)
+	}
+	for _, r := range answer {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("justification must not contain control characters")
+		}
+	}
+	re, err := CompilePattern(pattern)
+	if err != nil {
+		return err
+	}
+	if re != nil && !re.MatchString(answer) {
+		return fmt.Errorf("justification %q does not match the required format %q", answer, pattern)
+	}
+	return nil
+}
+
+// Extract removes the "--reason <value>" or "--reason=<value>" options from
+// the bastion part of a connection parameter string "[user@]host[:port]
+// [-p <port>] [remote command]": the options before the target and those
+// between the target and the remote command. The remote command is kept as
+// it is, even when it contains "--reason". It returns the remaining
+// parameters and the last value given (empty when the flag is absent).
+func Extract(params string) (string, string, error) {
+	tokens := strings.Fields(params)
+	clean := make([]string, 0, len(tokens))
+	reason := ""
+	seenTarget := false
+	for i := 0; i < len(tokens); i++ {
+		switch {
+		case tokens[i] == Flag:
+			if i+1 >= len(tokens) {
+				return "", "", fmt.Errorf("%s requires an argument", Flag)
+			}
+			i++
+			reason = tokens[i]
+		case strings.HasPrefix(tokens[i], Flag+"="):
+			reason = strings.TrimPrefix(tokens[i], Flag+"=")
+		case !seenTarget:
+			seenTarget = true
+			clean = append(clean, tokens[i])
+		case tokens[i] == "-p" && i+1 < len(tokens):
+			clean = append(clean, tokens[i], tokens[i+1])
+			i++
+		default:
+			// Start of the remote command: the rest belongs to the target.
+			clean = append(clean, tokens[i:]...)
+			return strings.Join(clean, " "), reason, nil
+		}
+	}
+	return strings.Join(clean, " "), reason, nil
+}
+
+// ExtractArgs is Extract for an already tokenised argument list.
+func ExtractArgs(args []string) ([]string, string, error) {
+	clean := make([]string, 0, len(args))
+	reason := ""
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == Flag:
+			if i+1 >= len(args) {
+				return nil, "", fmt.Errorf("%s requires an argument", Flag)
+			}
+			i++
+			reason = args[i]
+		case strings.HasPrefix(args[i], Flag+"="):
+			reason = strings.TrimPrefix(args[i], Flag+"=")
+		default:
+			clean = append(clean, args[i])
+		}
+	}
+	return clean, reason, nil
+}
+
+// Prompt asks for a justification on in/out until a valid answer is given or
+// attempts are exhausted.
+func Prompt(in io.Reader, out io.Writer, pattern string, attempts int) (string, error) {
+	if attempts < 1 {
+		attempts = 1
+	}
+	reader := bufio.NewReader(in)
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if pattern != "" {
+			_, _ = fmt.Fprintf(out, "📝 This group requires a justification matching %q [attempt %d/%d]: ", pattern, attempt, attempts)
+		} else {
+			_, _ = fmt.Fprintf(out, "📝 This group requires a justification (reason or ticket ID) [attempt %d/%d]: ", attempt, attempts)
+		}
+		answer, err := reader.ReadString('\n')
+		if err != nil && answer == "" {
+			return "", fmt.Errorf("could not read justification: %w", err)
+		}
+		answer = strings.TrimSpace(answer)
+		if lastErr = Validate(pattern, answer); lastErr == nil {
+			return answer, nil
+		}
+		_, _ = fmt.Fprintf(out, "⛔ %v\n", lastErr)
+		if err != nil {
+			break
+		}
+	}
+	return "", lastErr
+}
+
+// Sanitize reduces answer to printable ASCII so it can be stored in file
+// metadata such as the gzip header comment of a ttyrec recording.
+func Sanitize(answer string) string {
+	var b strings.Builder
+	for _, r := range answer {
+		if r >= 0x20 && r < 0x7f {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// RecordingComment returns the ttyrec metadata comment for answer, or "" when
+// no justification was given.
+func RecordingComment(answer string) string {
+	if answer == "" {
+		return ""
+	}
+	return "justification=" + Sanitize(answer)
+}
@@ -0,0 +1,85 @@
+package justification_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"goBastion/internal/utils/justification"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		pattern string
+		answer  string
+		ok      bool
+	}{
+		{"", "restarting nginx", true},
+		{"", "   ", false},
+		{"(INC|CHG)-[0-9]+", "CHG-1234", true},
+		{"(INC|CHG)-[0-9]+", " INC-7 ", true},
+		{"(INC|CHG)-[0-9]+", "see CHG-1234", false}, // the whole answer must match
+		{"(INC|CHG)-[0-9]+", "CHG-", false},
+		{"", "bad\x1b[2Jreason", false},
+		{"", strings.Repeat("x", justification.MaxLength+1), false},
+		{"(", "anything", false},
+	}
+	for _, tt := range tests {
+		err := justification.Validate(tt.pattern, tt.answer)
+		if (err == nil) != tt.ok {
+			t.Errorf("Validate(%q, %q) = %v, want ok=%v", tt.pattern, tt.answer, err, tt.ok)
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		in, clean, reason string
+		wantErr           bool
+	}{
+		{"web01", "web01", "", false},
+		{"web01 --reason CHG-1", "web01", "CHG-1", false},
+		{"--reason=INC-9 deploy@web01 -p 2222", "deploy@web01 -p 2222", "INC-9", false},
+		{"deploy@web01:22 --reason", "", "", true},
+		{"web01 -p 2222 --reason CHG-2 uptime", "web01 -p 2222 uptime", "CHG-2", false},
+		// --reason inside the remote command belongs to that command.
+		{"web01 grep --reason foo file", "web01 grep --reason foo file", "", false},
+		{"--reason CHG-3 web01 grep --reason=foo file", "web01 grep --reason=foo file", "CHG-3", false},
+	}
+	for _, tt := range tests {
+		clean, reason, err := justification.Extract(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("Extract(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if err == nil && (clean != tt.clean || reason != tt.reason) {
+			t.Errorf("Extract(%q) = (%q, %q), want (%q, %q)", tt.in, clean, reason, tt.clean, tt.reason)
+		}
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	var out bytes.Buffer
+	answer, err := justification.Prompt(strings.NewReader("nope\nCHG-42\n"), &out, "CHG-[0-9]+", 3)
+	if err != nil || answer != "CHG-42" {
+		t.Fatalf("Prompt = (%q, %v), want CHG-42", answer, err)
+	}
+	if !strings.Contains(out.String(), "attempt 2/3") {
+		t.Fatalf("expected a second attempt, output: %q", out.String())
+	}
+
+	if _, err := justification.Prompt(strings.NewReader("a\nb\n"), &out, "CHG-[0-9]+", 2); err == nil {
+		t.Fatal("expected Prompt to fail after exhausting attempts")
+	}
+	if _, err := justification.Prompt(strings.NewReader(""), &out, "", 3); err == nil {
+		t.Fatal("expected Prompt to fail on EOF")
+	}
+}
+
+func TestRecordingComment(t *testing.T) {
+	if got := justification.RecordingComment(""); got != "" {
+		t.Fatalf("RecordingComment(\"\") = %q, want empty", got)
+	}
+	if got := justification.RecordingComment("CHG-1 café"); got != "justification=CHG-1 caf?" {
+		t.Fatalf("RecordingComment = %q", got)
+	}
+}
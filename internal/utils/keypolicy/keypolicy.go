@@ -0,0 +1,111 @@
+// Package keypolicy checks ingress keys against the ingress_key_policy config
+// section, both when a key is added and when the sync reviews existing keys.
+package keypolicy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"goBastion/internal/config"
+	"goBastion/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Key describes an ingress key for the policy checks.
+type Key struct {
+	Type        string
+	Size        int
+	ExpiresAt   *time.Time
+	PIVAttested bool
+	// CreatedAt is the start of the key lifetime checked against
+	// max_expiry_days.
+	CreatedAt time.Time
+}
+
+// FromModel returns the policy view of an ingress key.
+func FromModel(k models.IngressKey) Key {
+	return Key{Type: k.Type, Size: k.Size, ExpiresAt: k.ExpiresAt, PIVAttested: k.PIVAttested, CreatedAt: k.CreatedAt}
+}
+
+// Violations returns the rules of policy that key breaks for an account that
+// is an admin or not, or nil when it complies. The key count is checked
+// separately by CheckCount.
+func Violations(policy config.IngressKeyPolicyConfig, key Key, admin bool) []string {
+	var v []string
+	if key.Type == "ssh-rsa" && policy.MinRSABits > 0 && key.Size < policy.MinRSABits {
+		v = append(v, fmt.Sprintf("RSA keys must have at least %d bits (got %d)", policy.MinRSABits, key.Size))
+	}
+	if allowed := AllowedAlgorithms(policy); len(allowed) > 0 && !contains(allowed, key.Type) {
+		v = append(v, fmt.Sprintf("key type %s is not allowed (allowed: %s)", key.Type, strings.Join(allowed, ", ")))
+	}
+	if policy.RequireExpiry && key.ExpiresAt == nil {
+		v = append(v, "keys must have an expiry")
+	}
+	if policy.MaxExpiryDays > 0 && key.ExpiresAt != nil && key.ExpiresAt.After(key.CreatedAt.AddDate(0, 0, policy.MaxExpiryDays)) {
+		v = append(v, fmt.Sprintf("keys must expire within %d days", policy.MaxExpiryDays))
+	}
+	if policy.AdminHardwareKeys && admin && !strings.HasPrefix(key.Type, "sk-") && !key.PIVAttested {
+		v = append(v, "admin keys must be FIDO (sk-) or PIV-attested keys")
+	}
+	return v
+}
+
+// Check returns an error listing the rules key breaks for user.
+func Check(policy config.IngressKeyPolicyConfig, key Key, user *models.User) error {
+	if v := Violations(policy, key, user.IsAdmin()); len(v) > 0 {
+		return fmt.Errorf("ingress key policy: %s", strings.Join(v, "; "))
+	}
+	return nil
+}
+
+// CheckCount returns an error when user already holds the maximum number of
+// active ingress keys.
+func CheckCount(db *gorm.DB, policy config.IngressKeyPolicyConfig, user *models.User) error {
+	if policy.MaxKeys <= 0 {
+		return nil
+	}
+	count, err := ActiveCount(db, user.ID.String(), time.Now())
+	if err != nil {
+		return err
+	}
+	if count >= int64(policy.MaxKeys) {
+		return fmt.Errorf("ingress key policy: accounts may hold at most %d keys", policy.MaxKeys)
+	}
+	return nil
+}
+
+// ActiveCount counts the ingress keys of userID that are neither expired nor
+// disabled.
+func ActiveCount(db *gorm.DB, userID string, now time.Time) (int64, error) {
+	var count int64
+	err := db.Model(&models.IngressKey{}).
+		Where("user_id = ? AND disabled_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, now).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("count ingress keys: %w", err)
+	}
+	return count, nil
+}
+
+// AllowedAlgorithms returns the key types of allowed_algorithms, empty when
+// any type is allowed.
+func AllowedAlgorithms(policy config.IngressKeyPolicyConfig) []string {
+	var allowed []string
+	for _, a := range strings.Split(policy.AllowedAlgorithms, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			allowed = append(allowed, a)
+		}
+	}
+	return allowed
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
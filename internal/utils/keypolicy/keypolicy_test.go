@@ -0,0 +1,59 @@
+package keypolicy
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"goBastion/internal/config"
+)
+
+func TestViolations(t *testing.T) {
+	now := time.Now()
+	inTen := now.AddDate(0, 0, 10)
+	inYear := now.AddDate(1, 0, 0)
+	policy := config.IngressKeyPolicyConfig{
+		MinRSABits:        3072,
+		AllowedAlgorithms: "ssh-ed25519, sk-ssh-ed25519@openssh.com, ssh-rsa",
+		RequireExpiry:     true,
+		MaxExpiryDays:     90,
+		AdminHardwareKeys: true,
+	}
+
+	tests := []struct {
+		name  string
+		key   Key
+		admin bool
+		want  string
+	}{
+		{"compliant", Key{Type: "ssh-ed25519", ExpiresAt: &inTen, CreatedAt: now}, false, ""},
+		{"small RSA", Key{Type: "ssh-rsa", Size: 2048, ExpiresAt: &inTen, CreatedAt: now}, false, "at least 3072 bits"},
+		{"algorithm", Key{Type: "ecdsa-sha2-nistp256", ExpiresAt: &inTen, CreatedAt: now}, false, "not allowed"},
+		{"no expiry", Key{Type: "ssh-ed25519", CreatedAt: now}, false, "must have an expiry"},
+		{"expiry too far", Key{Type: "ssh-ed25519", ExpiresAt: &inYear, CreatedAt: now}, false, "within 90 days"},
+		{"admin software key", Key{Type: "ssh-ed25519", ExpiresAt: &inTen, CreatedAt: now}, true, "FIDO (sk-) or PIV-attested"},
+		{"admin FIDO key", Key{Type: "sk-ssh-ed25519@openssh.com", ExpiresAt: &inTen, CreatedAt: now}, true, ""},
+		{"admin PIV key", Key{Type: "ssh-rsa", Size: 4096, PIVAttested: true, ExpiresAt: &inTen, CreatedAt: now}, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Join(Violations(policy, tt.key, tt.admin), "; ")
+			if tt.want == "" && got != "" {
+				t.Fatalf("expected no violation, got %q", got)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Fatalf("expected a violation containing %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDefaultPolicyOnlyKeepsRSAFloor(t *testing.T) {
+	policy := config.DefaultConfig().IngressKeyPolicy
+	if v := Violations(policy, Key{Type: "ecdsa-sha2-nistp256", CreatedAt: time.Now()}, true); len(v) != 0 {
+		t.Fatalf("the default policy must accept any key type without expiry, got %v", v)
+	}
+	if v := Violations(policy, Key{Type: "ssh-rsa", Size: 1024}, false); len(v) != 1 {
+		t.Fatalf("the default policy must refuse RSA keys under 2048 bits, got %v", v)
+	}
+}
@@ -0,0 +1,190 @@
+// Package revocation handles the bastion-wide list of compromised SSH keys:
+// keys on it cannot be added, are left out of authorized_keys and are never
+// used for egress connections.
+package revocation
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"goBastion/internal/models"
+
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+// ErrRevoked is returned by Check for a revoked key.
+var ErrRevoked = errors.New("key is revoked")
+
+// Fingerprint returns the fingerprint of pub in the format of the Fingerprint
+// columns of the key tables: the standard base64 of its SHA256.
+func Fingerprint(pub ssh.PublicKey) string {
+	sum := sha256.Sum256(pub.Marshal())
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// NormalizeFingerprint accepts a SHA256 fingerprint as printed by ssh-keygen
+// ("SHA256:..." without padding) or as stored by the bastion, and returns it
+// in the stored format.
+func NormalizeFingerprint(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	var sum []byte
+	var err error
+	if rest, ok := strings.CutPrefix(s, "SHA256:"); ok {
+		sum, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(rest, "="))
+	} else {
+		sum, err = base64.StdEncoding.DecodeString(s)
+	}
+	if err != nil || len(sum) != sha256.Size {
+		return "", fmt.Errorf("invalid fingerprint %q: expected a SHA256 fingerprint as printed by ssh-keygen -l", s)
+	}
+	return base64.StdEncoding.EncodeToString(sum), nil
+}
+
+// Check returns an error wrapping ErrRevoked when fingerprint is revoked.
+func Check(db *gorm.DB, fingerprint string) error {
+	var key models.RevokedKey
+	err := db.Where("fingerprint = ?", fingerprint).Limit(1).Find(&key).Error
+	if err != nil {
+		return fmt.Errorf("check key revocation: %w", err)
+	}
+	if key.Fingerprint == "" {
+		return nil
+	}
+	return fmt.Errorf("%w (%s): %s", ErrRevoked, Display(fingerprint), key.Reason)
+}
+
+// Display returns a stored fingerprint as printed by ssh-keygen -l.
+func Display(fingerprint string) string {
+	return "SHA256:" + strings.TrimRight(fingerprint, "=")
+}
+
+// Revoked returns the set of revoked fingerprints.
+func Revoked(db *gorm.DB) (map[string]bool, error) {
+	var fingerprints []string
+	if err := db.Model(&models.RevokedKey{}).Pluck("fingerprint", &fingerprints).Error; err != nil {
+		return nil, fmt.Errorf("load revoked keys: %w", err)
+	}
+	revoked := make(map[string]bool, len(fingerprints))
+	for _, f := range fingerprints {
+		revoked[f] = true
+	}
+	return revoked, nil
+}
+
+// NotRevoked scopes a query on a key table to the keys that are not revoked.
+func NotRevoked(db *gorm.DB) *gorm.DB {
+	return db.Where("fingerprint NOT IN (SELECT fingerprint FROM revoked_keys)")
+}
+
+// Holders describes every key row registered with fingerprint: the ingress
+// keys of accounts and the egress keys of accounts and groups.
+func Holders(db *gorm.DB, fingerprint string) ([]string, error) {
+	var holders []string
+	var ingress []models.IngressKey
+	if err := db.Preload("User").Where("fingerprint = ?", fingerprint).Find(&ingress).Error; err != nil {
+		return nil, fmt.Errorf("find ingress keys: %w", err)
+	}
+	for _, k := range ingress {
+		holders = append(holders, fmt.Sprintf("ingress key %s of account %s", k.ID, k.User.Username))
+	}
+	var self []models.SelfEgressKey
+	if err := db.Preload("User").Where("fingerprint = ?", fingerprint).Find(&self).Error; err != nil {
+		return nil, fmt.Errorf("find account egress keys: %w", err)
+	}
+	for _, k := range self {
+		holders = append(holders, fmt.Sprintf("egress key %s of account %s", k.ID, k.User.Username))
+	}
+	var group []models.GroupEgressKey
+	if err := db.Preload("Group").Where("fingerprint = ?", fingerprint).Find(&group).Error; err != nil {
+		return nil, fmt.Errorf("find group egress keys: %w", err)
+	}
+	for _, k := range group {
+		holders = append(holders, fmt.Sprintf("egress key %s of group %s (%s)", k.ID, k.Group.Name, k.State))
+	}
+	return holders, nil
+}
+
+// KRL sections and magic, from OpenSSH's PROTOCOL.krl.
+const (
+	krlMagic                    = 0x5353484b524c0a00
+	krlFormatVersion            = 1
+	krlSectionFingerprintSHA256 = 5
+)
+
+// KRL returns an OpenSSH key revocation list, as read by sshd's RevokedKeys,
+// revoking keys by their SHA256 fingerprint.
+func KRL(keys []models.RevokedKey, now time.Time) ([]byte, error) {
+	var hashes [][]byte
+	for _, k := range keys {
+		sum, err := base64.StdEncoding.DecodeString(k.Fingerprint)
+		if err != nil || len(sum) != sha256.Size {
+			return nil, fmt.Errorf("invalid revoked fingerprint %q", k.Fingerprint)
+		}
+		hashes = append(hashes, sum)
+	}
+	// ssh-keygen writes the hashes sorted; keep the same order.
+	sort.Slice(hashes, func(i, j int) bool { return bytes.Compare(hashes[i], hashes[j]) < 0 })
+
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.BigEndian, uint64(krlMagic))
+	_ = binary.Write(&buf, binary.BigEndian, uint32(krlFormatVersion))
+	_ = binary.Write(&buf, binary.BigEndian, uint64(now.Unix())) // krl_version
+	_ = binary.Write(&buf, binary.BigEndian, uint64(now.Unix())) // generated_date
+	_ = binary.Write(&buf, binary.BigEndian, uint64(0))          // flags
+	writeString(&buf, nil)                                       // reserved
+	writeString(&buf, []byte("goBastion revoked keys"))
+	if len(hashes) > 0 {
+		var section bytes.Buffer
+		for _, h := range hashes {
+			writeString(&section, h)
+		}
+		buf.WriteByte(krlSectionFingerprintSHA256)
+		writeString(&buf, section.Bytes())
+	}
+	return buf.Bytes(), nil
+}
+
+func writeString(buf *bytes.Buffer, s []byte) {
+	_ = binary.Write(buf, binary.BigEndian, uint32(len(s)))
+	buf.Write(s)
+}
+
+// WriteKRL writes the KRL of every revoked key to path, replacing it
+// atomically so that sshd never reads a partial file.
+func WriteKRL(db *gorm.DB, path string) error {
+	var keys []models.RevokedKey
+	if err := db.Find(&keys).Error; err != nil {
+		return fmt.Errorf("load revoked keys: %w", err)
+	}
+	krl, err := KRL(keys, time.Now())
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".revoked_keys.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp KRL: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(krl); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write KRL: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("chmod KRL: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close KRL: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
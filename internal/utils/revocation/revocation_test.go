@@ -0,0 +1,103 @@
+package revocation
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+
+	"goBastion/internal/models"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open test DB: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.RevokedKey{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func newKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatalf("NewPublicKey: %v", err)
+	}
+	return key
+}
+
+func TestNormalizeFingerprint(t *testing.T) {
+	key := newKey(t)
+	stored := Fingerprint(key)
+	for _, in := range []string{stored, ssh.FingerprintSHA256(key), " " + ssh.FingerprintSHA256(key) + " "} {
+		got, err := NormalizeFingerprint(in)
+		if err != nil || got != stored {
+			t.Fatalf("NormalizeFingerprint(%q) = %q, %v; want %q", in, got, err, stored)
+		}
+	}
+	if Display(stored) != ssh.FingerprintSHA256(key) {
+		t.Fatalf("Display(%q) = %q, want %q", stored, Display(stored), ssh.FingerprintSHA256(key))
+	}
+	for _, in := range []string{"", "SHA256:abc", "MD5:00:11", "not base64!"} {
+		if _, err := NormalizeFingerprint(in); err == nil {
+			t.Fatalf("expected %q to be refused", in)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	db := newTestDB(t)
+	key := newKey(t)
+	if err := Check(db, Fingerprint(key)); err != nil {
+		t.Fatalf("a key that is not revoked must pass: %v", err)
+	}
+	db.Create(&models.RevokedKey{Fingerprint: Fingerprint(key), Reason: "laptop stolen"})
+	if err := Check(db, Fingerprint(key)); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("expected ErrRevoked, got %v", err)
+	}
+	if err := Check(db, Fingerprint(newKey(t))); err != nil {
+		t.Fatalf("another key must pass: %v", err)
+	}
+}
+
+func TestKRLIsReadBySSHKeygen(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not available")
+	}
+	dir := t.TempDir()
+	revoked, kept := newKey(t), newKey(t)
+	krl, err := KRL([]models.RevokedKey{{Fingerprint: Fingerprint(revoked)}, {Fingerprint: Fingerprint(newKey(t))}}, time.Now())
+	if err != nil {
+		t.Fatalf("KRL: %v", err)
+	}
+	krlPath := filepath.Join(dir, "revoked.krl")
+	if err := os.WriteFile(krlPath, krl, 0644); err != nil {
+		t.Fatalf("write KRL: %v", err)
+	}
+	query := func(key ssh.PublicKey) error {
+		path := filepath.Join(dir, "key.pub")
+		if err := os.WriteFile(path, ssh.MarshalAuthorizedKey(key), 0644); err != nil {
+			t.Fatalf("write key: %v", err)
+		}
+		return exec.Command("ssh-keygen", "-Q", "-f", krlPath, path).Run()
+	}
+	if err := query(revoked); err == nil {
+		t.Fatal("ssh-keygen must report the revoked key")
+	}
+	if err := query(kept); err != nil {
+		t.Fatalf("ssh-keygen must accept a key that is not revoked: %v", err)
+	}
+}
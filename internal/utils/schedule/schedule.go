@@ -0,0 +1,167 @@
+// Package schedule parses and evaluates weekly access windows such as
+// "Mon-Fri 08:00-19:00 Europe/Paris".
+//
+// A schedule is "<days> <HH:MM-HH:MM> [timezone]":
+//   - days is a comma-separated list of weekdays or weekday ranges
+//     (Mon-Fri, Sat,Sun, Mon-Wed,Fri) or "*" for every day;
+//   - hours is a start and end time; an end before the start makes an
+//     overnight window that belongs to the day it starts on;
+//   - timezone is an IANA name and defaults to UTC.
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // embedded zone database: the container image may lack /usr/share/zoneinfo
+)
+
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Schedule is a parsed weekly access window.
+type Schedule struct {
+	days  [7]bool
+	start int // minutes since midnight, inclusive
+	end   int // minutes since midnight, exclusive
+	loc   *time.Location
+	raw   string
+}
+
+// Parse validates spec and returns the corresponding Schedule.
+func Parse(spec string) (Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) < 2 || len(fields) > 3 {
+		return Schedule{}, fmt.Errorf("schedule must be \"<days> <HH:MM-HH:MM> [timezone]\", got %q", spec)
+	}
+	var s Schedule
+	if err := s.parseDays(fields[0]); err != nil {
+		return Schedule{}, err
+	}
+	if err := s.parseHours(fields[1]); err != nil {
+		return Schedule{}, err
+	}
+	s.loc = time.UTC
+	if len(fields) == 3 {
+		loc, err := time.LoadLocation(fields[2])
+		if err != nil {
+			return Schedule{}, fmt.Errorf("unknown timezone %q", fields[2])
+		}
+		s.loc = loc
+	}
+	s.raw = strings.Join(fields, " ")
+	return s, nil
+}
+
+func (s *Schedule) parseDays(field string) error {
+	if field == "*" {
+		for i := range s.days {
+			s.days[i] = true
+		}
+		return nil
+	}
+	for _, part := range strings.Split(field, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, err := parseDay(from)
+		if err != nil {
+			return err
+		}
+		last := first
+		if isRange {
+			if last, err = parseDay(to); err != nil {
+				return err
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			s.days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func parseDay(name string) (int, error) {
+	n := strings.ToLower(name)
+	for i, d := range dayNames {
+		if n == d {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekday %q (use Mon, Tue, Wed, Thu, Fri, Sat, Sun)", name)
+}
+
+func (s *Schedule) parseHours(field string) error {
+	from, to, ok := strings.Cut(field, "-")
+	if !ok {
+		return fmt.Errorf("hours must be HH:MM-HH:MM, got %q", field)
+	}
+	var err error
+	if s.start, err = parseClock(from); err != nil {
+		return err
+	}
+	if s.end, err = parseClock(to); err != nil {
+		return err
+	}
+	if s.start == s.end {
+		return fmt.Errorf("hours %q describe an empty window", field)
+	}
+	return nil
+}
+
+func parseClock(v string) (int, error) {
+	if v == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (use HH:MM)", v)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Allows reports whether t falls inside the window.
+func (s Schedule) Allows(t time.Time) bool {
+	local := t.In(s.loc)
+	day := int(local.Weekday())
+	minute := local.Hour()*60 + local.Minute()
+	if s.start < s.end {
+		return s.days[day] && minute >= s.start && minute < s.end
+	}
+	// Overnight window: the late part belongs to today, the early part to yesterday.
+	return (s.days[day] && minute >= s.start) || (s.days[(day+6)%7] && minute < s.end)
+}
+
+// String returns the normalised schedule spec.
+func (s Schedule) String() string {
+	return s.raw
+}
+
+// Location returns the timezone the window is evaluated in.
+func (s Schedule) Location() *time.Location {
+	return s.loc
+}
+
+// Allows reports whether spec permits access at t. An empty spec means no
+// restriction. An unparsable spec denies access (fail-closed).
+func Allows(spec string, t time.Time) bool {
+	if strings.TrimSpace(spec) == "" {
+		return true
+	}
+	s, err := Parse(spec)
+	if err != nil {
+		return false
+	}
+	return s.Allows(t)
+}
+
+// DenialMessage returns the user-facing explanation for a connection refused
+// by spec at t.
+func DenialMessage(spec string, t time.Time) string {
+	s, err := Parse(spec)
+	if err != nil {
+		return fmt.Sprintf("⛔ Access denied: the access schedule %q is invalid — contact your admin", spec)
+	}
+	return fmt.Sprintf("⛔ Access denied: this access is only allowed during %s (it is now %s)",
+		s.String(), t.In(s.loc).Format("Mon 15:04 MST"))
+}
@@ -0,0 +1,56 @@
+package schedule_test
+
+import (
+	"testing"
+	"time"
+
+	"goBastion/internal/utils/schedule"
+)
+
+func TestParseRejectsInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"Mon-Fri",
+		"Mon-Fri 08:00",
+		"Mon-Fri 08:00-08:00",
+		"Funday 08:00-19:00",
+		"Mon-Fri 8h-19h",
+		"Mon-Fri 08:00-19:00 Mars/Olympus",
+		"Mon-Fri 08:00-19:00 UTC extra",
+	} {
+		if _, err := schedule.Parse(spec); err == nil {
+			t.Errorf("Parse(%q) = nil error, want error", spec)
+		}
+	}
+}
+
+func TestAllows(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	tests := []struct {
+		spec string
+		at   time.Time
+		want bool
+	}{
+		// 2026-10-14 is a Wednesday.
+		{"Mon-Fri 08:00-19:00 Europe/Paris", time.Date(2026, 10, 14, 9, 0, 0, 0, paris), true},
+		{"Mon-Fri 08:00-19:00 Europe/Paris", time.Date(2026, 10, 14, 19, 0, 0, 0, paris), false},
+		{"Mon-Fri 08:00-19:00 Europe/Paris", time.Date(2026, 10, 14, 7, 30, 0, 0, time.UTC), true},
+		{"Mon-Fri 08:00-19:00 Europe/Paris", time.Date(2026, 10, 17, 10, 0, 0, 0, paris), false},
+		{"sat,sun 00:00-24:00", time.Date(2026, 10, 18, 23, 59, 0, 0, time.UTC), true},
+		{"Fri-Mon 10:00-12:00", time.Date(2026, 10, 19, 11, 0, 0, 0, time.UTC), true},
+		{"Fri-Mon 10:00-12:00", time.Date(2026, 10, 20, 11, 0, 0, 0, time.UTC), false},
+		// Overnight window started on Friday covers Saturday early morning.
+		{"Fri 22:00-06:00", time.Date(2026, 10, 17, 3, 0, 0, 0, time.UTC), true},
+		{"Fri 22:00-06:00", time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC), false},
+		{"* 09:00-17:00", time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC), true},
+		{"", time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC), true},
+		{"garbage", time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC), false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.spec+"@"+tc.at.String(), func(t *testing.T) {
+			if got := schedule.Allows(tc.spec, tc.at); got != tc.want {
+				t.Errorf("Allows(%q, %v) = %v, want %v", tc.spec, tc.at, got, tc.want)
+			}
+		})
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"goBastion/internal/models"
 	"goBastion/internal/utils"
 	"goBastion/internal/utils/sshHostKey"
+	"goBastion/internal/utils/sshca"
 
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/knownhosts"
@@ -50,6 +51,31 @@ func Proxy(db *gorm.DB, access models.AccessRight) error {
 	if err != nil {
 		return fmt.Errorf("parse egress key: %w", err)
 	}
+	signers := []ssh.Signer{signer}
+	for _, fallback := range access.FallbackKeys {
+		s, err := ssh.ParsePrivateKey([]byte(fallback))
+		if err != nil {
+			return fmt.Errorf("parse egress key: %w", err)
+		}
+		signers = append(signers, s)
+	}
+
+	currentUser, err := user.Current()
+	if err != nil {
+		return fmt.Errorf("resolve current user: %w", err)
+	}
+	bastionUser := utils.NormalizeUsername(currentUser.Username)
+	// The user CA certificate is offered first; targets that do not trust
+	// the CA fall back to the plain keys.
+	if cert, certErr := sshca.IssueUserCertificate(db, access.PrivateKey, access.Username, bastionUser); certErr != nil {
+		slog.Warn("user_cert_issue_failed", slog.String("user", bastionUser), slog.String("error", certErr.Error()))
+	} else if cert != nil {
+		certSigner, err := ssh.NewCertSigner(cert, signer)
+		if err != nil {
+			return fmt.Errorf("certificate signer: %w", err)
+		}
+		signers = append([]ssh.Signer{certSigner}, signers...)
+	}
 
 	targetAddr := net.JoinHostPort(access.Server, fmt.Sprintf("%d", access.Port))
 	netConn, err := net.DialTimeout("tcp", targetAddr, time.Duration(config.Get().Proxy.SFTPDialTimeout))
@@ -58,11 +84,7 @@ func Proxy(db *gorm.DB, access models.AccessRight) error {
 	}
 	defer func() { _ = netConn.Close() }()
 
-	currentUser, err := user.Current()
-	if err != nil {
-		return fmt.Errorf("resolve current user: %w", err)
-	}
-	knownHostsFile := filepath.Join(config.Get().Paths.HomeBaseDir, utils.NormalizeUsername(currentUser.Username), ".ssh", "known_hosts")
+	knownHostsFile := filepath.Join(config.Get().Paths.HomeBaseDir, bastionUser, ".ssh", "known_hosts")
 	hostKeyCallback, err := knownhosts.New(knownHostsFile)
 	if err != nil {
 		return fmt.Errorf("load known_hosts callback: %w", err)
@@ -75,7 +97,7 @@ func Proxy(db *gorm.DB, access models.AccessRight) error {
 
 	clientConfig := &ssh.ClientConfig{
 		User:            access.Username,
-		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signers...)},
 		HostKeyCallback: hostKeyCallback,
 		Timeout:         time.Duration(config.Get().Proxy.SFTPSSHTimeout),
 	}
@@ -108,8 +130,9 @@ func Proxy(db *gorm.DB, access models.AccessRight) error {
 	}
 
 	// 3. Load the stable host key used by the fake SSH server presented to the
-	// local sftp client. This allows clients to pin the host key in known_hosts.
-	hostSigner, _, _, err := sshHostKey.EnsureSFTPProxyHostKey(db, false)
+	// local sftp client. This allows clients to pin the host key in known_hosts,
+	// or to trust the host CA that certifies it.
+	hostSigners, err := sshHostKey.SFTPProxyHostSigners(db)
 	if err != nil {
 		return fmt.Errorf("load SFTP proxy host key: %w", err)
 	}
@@ -122,7 +145,9 @@ func Proxy(db *gorm.DB, access models.AccessRight) error {
 			return nil, nil
 		},
 	}
-	serverConfig.AddHostKey(hostSigner)
+	for _, hostSigner := range hostSigners {
+		serverConfig.AddHostKey(hostSigner)
+	}
 
 	serverConn, newChans, globalReqs, err := ssh.NewServerConn(&stdinoutConn{}, serverConfig)
 	if err != nil {
@@ -18,9 +18,12 @@ import (
 	"goBastion/internal/config"
 	"goBastion/internal/models"
 	"goBastion/internal/osadapter"
+	"goBastion/internal/utils/justification"
+	"goBastion/internal/utils/sshca"
 	bastionSync "goBastion/internal/utils/sync"
 
 	"github.com/google/uuid"
+	"golang.org/x/crypto/ssh"
 	"gorm.io/gorm"
 )
 
@@ -72,13 +75,39 @@ func SshConnection(db *gorm.DB, user models.User, access models.AccessRight) err
 	defer func(name string) {
 		_ = os.Remove(name)
 	}(tmpFilePath)
+	identityArgs := []string{"-i", tmpFilePath}
+	// A short-lived certificate of the user CA lets targets that trust it
+	// accept the key without having it in authorized_keys.
+	if cert, certErr := sshca.IssueUserCertificate(db, access.PrivateKey, access.Username, user.Username); certErr != nil {
+		slog.Warn("user_cert_issue_failed", slog.String("user", user.Username), slog.String("error", certErr.Error()))
+	} else if cert != nil {
+		certPath := strings.TrimSuffix(tmpFilePath, ".pem") + "-cert.pub"
+		if err = os.WriteFile(certPath, ssh.MarshalAuthorizedKey(cert), 0600); err != nil {
+			return fmt.Errorf("error writing certificate: %w", err)
+		}
+		defer func(name string) {
+			_ = os.Remove(name)
+		}(certPath)
+		identityArgs = append(identityArgs, "-o", "CertificateFile="+certPath)
+	}
+	// During a group key rotation the other usable keys are offered after
+	// the primary one, in order.
+	for i, fallback := range access.FallbackKeys {
+		path := strings.TrimSuffix(tmpFilePath, ".pem") + fmt.Sprintf("-%d.pem", i+1)
+		if err = os.WriteFile(path, []byte(fallback+"\n"), 0600); err != nil {
+			return fmt.Errorf("error writing private key: %w", err)
+		}
+		defer func(name string) {
+			_ = os.Remove(name)
+		}(path)
+		identityArgs = append(identityArgs, "-i", path)
+	}
 
 	knownHostsFile := filepath.Join(config.Get().Paths.HomeBaseDir, strings.ToLower(user.Username), ".ssh", "known_hosts")
-	sshArgs := []string{
-		"-i", tmpFilePath,
+	sshArgs := append(identityArgs,
 		"-o", "StrictHostKeyChecking=yes",
-		"-o", "UserKnownHostsFile=" + knownHostsFile,
-	}
+		"-o", "UserKnownHostsFile="+knownHostsFile,
+	)
 	// Idle timeout: if configured, have the SSH client send keepalive
 	// probes so the connection is killed when idle.
 	if idleTimeout := time.Duration(config.Get().Session.IdleTimeout); idleTimeout > 0 {
@@ -119,8 +148,9 @@ func SshConnection(db *gorm.DB, user models.User, access models.AccessRight) err
 	}
 
 	// Recording disabled: run ssh directly (no ttyrec wrapper) but still honour
-	// the session duration limit via runCtx.
-	if !config.Get().TTYRec.Enabled {
+	// the session duration limit via runCtx. Break-glass sessions are always
+	// recorded.
+	if !config.Get().TTYRec.Enabled && access.BreakGlassID == uuid.Nil {
 		sshCmd := exec.CommandContext(runCtx, "ssh", sshArgs...)
 		sshCmd.Stdin = os.Stdin
 		sshCmd.Stdout = os.Stdout
@@ -179,6 +209,8 @@ func SshConnection(db *gorm.DB, user models.User, access models.AccessRight) err
 	tmpGzPath := tmpGz.Name()
 	defer func() { _ = os.Remove(tmpGzPath) }() // cleanup on failure
 	gzipWriter := gzip.NewWriter(tmpGz)
+	// The justification travels with the recording in the gzip header comment.
+	gzipWriter.Comment = justification.RecordingComment(access.Justification)
 
 	// Always remove the intermediate .ttyrec file on exit
 	defer func() { _ = os.Remove(ttyrecFile) }()
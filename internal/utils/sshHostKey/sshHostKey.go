@@ -6,14 +6,18 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"log/slog"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"os"
 	"os/exec"
 
 	"goBastion/internal/config"
 	"goBastion/internal/models"
+	"goBastion/internal/utils/cryptokey"
+	"goBastion/internal/utils/sshca"
 
 	"golang.org/x/crypto/ssh"
 	"gorm.io/gorm"
@@ -54,7 +58,13 @@ func GenerateSSHHostKeys(db *gorm.DB, force bool) error {
 		return err
 	}
 
-	return saveSSHHostKeys(db)
+	if err := saveSSHHostKeys(db); err != nil {
+		return err
+	}
+	if err := WriteHostCertificates(db); err != nil {
+		slog.Warn("host_cert_write_failed", slog.Any("error", err))
+	}
+	return nil
 }
 
 // EnsureSFTPProxyHostKey creates or loads the stable host key used by the
@@ -66,7 +76,11 @@ func EnsureSFTPProxyHostKey(db *gorm.DB, force bool) (ssh.Signer, string, string
 		return nil, "", "", err
 	}
 
-	signer, err := ssh.ParsePrivateKey(key.PrivateKey)
+	privateKey, err := openPrivateKey(key)
+	if err != nil {
+		return nil, "", "", err
+	}
+	signer, err := ssh.ParsePrivateKey(privateKey)
 	if err != nil {
 		return nil, "", "", fmt.Errorf("parse persisted SFTP proxy host key: %w", err)
 	}
@@ -79,11 +93,36 @@ func EnsureSFTPProxyHostKey(db *gorm.DB, force bool) (ssh.Signer, string, string
 	return signer, publicKey, fingerprint, nil
 }
 
+// SFTPProxyHostSigners returns the signers the SFTP proxy presents: the
+// stable key and, when host certificates are enabled, the same key certified
+// by the host CA. The certificate names no principal because clients reach
+// the proxy under the target's name.
+func SFTPProxyHostSigners(db *gorm.DB) ([]ssh.Signer, error) {
+	signer, _, _, err := EnsureSFTPProxyHostKey(db, false)
+	if err != nil {
+		return nil, err
+	}
+	signers := []ssh.Signer{signer}
+	if time.Duration(config.Get().SSH.HostCertValidity) <= 0 {
+		return signers, nil
+	}
+	cert, err := sshca.IssueHostCertificate(db, signer.PublicKey(), nil, "gobastion host=sftp-proxy")
+	if err != nil {
+		return nil, fmt.Errorf("certify SFTP proxy host key: %w", err)
+	}
+	certSigner, err := ssh.NewCertSigner(cert, signer)
+	if err != nil {
+		return nil, fmt.Errorf("SFTP proxy certificate signer: %w", err)
+	}
+	return append(signers, certSigner), nil
+}
+
 func loadOrCreateSFTPProxyHostKey(db *gorm.DB, force bool) (*models.SshHostKey, error) {
 	var key models.SshHostKey
 	err := db.Where("type = ?", sftpProxyHostKeyType).First(&key).Error
 	switch {
 	case err == nil && !force:
+		refreshPrivateKey(db, &key)
 		return &key, nil
 	case err != nil && err != gorm.ErrRecordNotFound:
 		return nil, fmt.Errorf("load SFTP proxy host key: %w", err)
@@ -93,6 +132,9 @@ func loadOrCreateSFTPProxyHostKey(db *gorm.DB, force bool) (*models.SshHostKey,
 	if err != nil {
 		return nil, err
 	}
+	if priv, err = sealPrivateKey(priv); err != nil {
+		return nil, err
+	}
 	key = models.SshHostKey{
 		Type:       sftpProxyHostKeyType,
 		PrivateKey: priv,
@@ -139,6 +181,9 @@ func saveSSHHostKeys(db *gorm.DB) error {
 		if err != nil {
 			continue
 		}
+		if privateKey, err = sealPrivateKey(privateKey); err != nil {
+			return err
+		}
 
 		sshKey := models.SshHostKey{
 			Type:       keyType,
@@ -153,6 +198,52 @@ func saveSSHHostKeys(db *gorm.DB) error {
 	return nil
 }
 
+// sealPrivateKey returns a private key as it is stored in the database:
+// encrypted when EGRESS_ENC_KEY is set.
+func sealPrivateKey(privateKey []byte) ([]byte, error) {
+	sealed, err := cryptokey.ReEncryptIfNeeded(string(privateKey))
+	if err != nil {
+		return nil, fmt.Errorf("encrypt SSH host key: %w", err)
+	}
+	return []byte(sealed), nil
+}
+
+// openPrivateKey returns the plaintext private key of key. Unlike the egress
+// keys, an encrypted host key that cannot be decrypted is an error: writing
+// the ciphertext to disk would leave sshd without its host key.
+func openPrivateKey(key *models.SshHostKey) ([]byte, error) {
+	raw := string(key.PrivateKey)
+	if !cryptokey.IsEncrypted(raw) {
+		return key.PrivateKey, nil
+	}
+	plain, err := cryptokey.Decrypt(raw)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt SSH host key %s: %w", key.Type, err)
+	}
+	return []byte(plain), nil
+}
+
+// refreshPrivateKey re-encrypts a host key stored in plaintext before
+// EGRESS_ENC_KEY was set, or with a key listed in EGRESS_ENC_KEY_PREVIOUS.
+func refreshPrivateKey(db *gorm.DB, key *models.SshHostKey) {
+	if !cryptokey.Enabled() {
+		return
+	}
+	encrypted, changed, err := cryptokey.Refresh(string(key.PrivateKey))
+	if err != nil {
+		slog.Warn("re_encrypt_host_key", slog.String("type", key.Type), slog.Any("error", err))
+		return
+	}
+	if !changed {
+		return
+	}
+	if err := db.Model(&models.SshHostKey{}).Where("type = ?", key.Type).Update("private_key", []byte(encrypted)).Error; err != nil {
+		slog.Warn("re_encrypt_host_key", slog.String("type", key.Type), slog.Any("error", err))
+		return
+	}
+	key.PrivateKey = []byte(encrypted)
+}
+
 // removeSSHHostKeys deletes the SSH host key files from disk.
 func removeSSHHostKeys() error {
 	pattern := config.Get().Paths.SshHostKeyDir + "/ssh_host_*"
@@ -188,7 +279,13 @@ func RestoreSSHHostKeys(db *gorm.DB) error {
 		privateKeyPath := fmt.Sprintf("%s/ssh_host_%s_key", config.Get().Paths.SshHostKeyDir, key.Type)
 		publicKeyPath := privateKeyPath + ".pub"
 
-		err := os.WriteFile(privateKeyPath, key.PrivateKey, 0600)
+		privateKey, err := openPrivateKey(&key)
+		if err != nil {
+			return err
+		}
+		refreshPrivateKey(db, &key)
+
+		err = os.WriteFile(privateKeyPath, privateKey, 0600)
 		if err != nil {
 			return err
 		}
@@ -198,5 +295,52 @@ func RestoreSSHHostKeys(db *gorm.DB) error {
 			return err
 		}
 	}
+	if err := WriteHostCertificates(db); err != nil {
+		slog.Warn("host_cert_write_failed", slog.Any("error", err))
+	}
+	return nil
+}
+
+// WriteHostCertificates writes ssh_host_<type>_key-cert.pub next to each sshd
+// host key, signed by the active host CA for ssh.host_cert_principals. A
+// certificate is only re-signed when it no longer matches the key, the CA or
+// the principals, or has used half of its validity; sshd reads the new one
+// when it is reloaded. With ssh.host_cert_validity at 0 the certificates are
+// removed.
+func WriteHostCertificates(db *gorm.DB) error {
+	var keys []models.SshHostKey
+	if err := db.Where("type != ?", sftpProxyHostKeyType).Find(&keys).Error; err != nil {
+		return err
+	}
+	enabled := time.Duration(config.Get().SSH.HostCertValidity) > 0
+	principals := sshca.HostPrincipals()
+	for _, key := range keys {
+		certPath := fmt.Sprintf("%s/ssh_host_%s_key-cert.pub", config.Get().Paths.SshHostKeyDir, key.Type)
+		if !enabled {
+			if err := os.Remove(certPath); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		pub, _, _, _, err := ssh.ParseAuthorizedKey(key.PublicKey)
+		if err != nil {
+			slog.Warn("host_cert_skipped", slog.String("type", key.Type), slog.Any("error", err))
+			continue
+		}
+		if current, err := os.ReadFile(certPath); err == nil {
+			if parsed, _, _, _, err := ssh.ParseAuthorizedKey(current); err == nil {
+				if cert, ok := parsed.(*ssh.Certificate); ok && sshca.HostCertificateCurrent(db, cert, pub, principals, time.Now()) {
+					continue
+				}
+			}
+		}
+		cert, err := sshca.IssueHostCertificate(db, pub, principals, "gobastion host="+key.Type)
+		if err != nil {
+			return fmt.Errorf("certify SSH host key %s: %w", key.Type, err)
+		}
+		if err := os.WriteFile(certPath, ssh.MarshalAuthorizedKey(cert), 0644); err != nil {
+			return err
+		}
+	}
 	return nil
 }
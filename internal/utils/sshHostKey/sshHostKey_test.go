@@ -1,24 +1,36 @@
 package sshHostKey
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/glebarez/sqlite"
+	"golang.org/x/crypto/ssh"
 	"gorm.io/gorm"
 
 	"goBastion/internal/config"
 	"goBastion/internal/models"
+	"goBastion/internal/utils/cryptokey"
 )
 
+func TestMain(m *testing.M) {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	_ = os.Setenv("EGRESS_ENC_KEY", base64.StdEncoding.EncodeToString(key))
+	os.Exit(m.Run())
+}
+
 func newHostKeyTestDB(t *testing.T) *gorm.DB {
 	t.Helper()
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	if err != nil {
 		t.Fatalf("open test DB: %v", err)
 	}
-	if err := db.AutoMigrate(&models.SshHostKey{}); err != nil {
+	if err := db.AutoMigrate(&models.SshHostKey{}, &models.CertAuthority{}); err != nil {
 		t.Fatalf("migrate ssh_host_keys: %v", err)
 	}
 	return db
@@ -98,3 +110,97 @@ func TestRestoreSSHHostKeys_IgnoresSFTPProxyHostKey(t *testing.T) {
 		t.Fatalf("expected no sshd host key file for SFTP proxy key, got err=%v", err)
 	}
 }
+
+func TestWriteHostCertificates_SignsOnceAndCertifiesSFTPProxy(t *testing.T) {
+	db := newHostKeyTestDB(t)
+	pub, priv, err := generateSFTPProxyHostKeyMaterial()
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	if err := db.Create(&models.SshHostKey{Type: "ed25519", PrivateKey: priv, PublicKey: pub}).Error; err != nil {
+		t.Fatalf("insert ssh host key: %v", err)
+	}
+
+	config.ResetForTesting()
+	t.Cleanup(config.ResetForTesting)
+	cfg := config.Load()
+	cfg.Paths.SshHostKeyDir = t.TempDir()
+	cfg.SSH.HostCertPrincipals = "bastion.example.com"
+
+	if err := WriteHostCertificates(db); err != nil {
+		t.Fatalf("WriteHostCertificates: %v", err)
+	}
+	certPath := filepath.Join(cfg.Paths.SshHostKeyDir, "ssh_host_ed25519_key-cert.pub")
+	first, err := os.ReadFile(certPath)
+	if err != nil {
+		t.Fatalf("read certificate: %v", err)
+	}
+	parsed, _, _, _, err := ssh.ParseAuthorizedKey(first)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	cert, ok := parsed.(*ssh.Certificate)
+	if !ok || cert.CertType != ssh.HostCert || len(cert.ValidPrincipals) != 1 || cert.ValidPrincipals[0] != "bastion.example.com" {
+		t.Fatalf("expected a host certificate for bastion.example.com, got %+v", parsed)
+	}
+
+	if err := WriteHostCertificates(db); err != nil {
+		t.Fatalf("second WriteHostCertificates: %v", err)
+	}
+	if second, _ := os.ReadFile(certPath); !bytes.Equal(first, second) {
+		t.Fatal("a current certificate must not be re-signed")
+	}
+
+	signers, err := SFTPProxyHostSigners(db)
+	if err != nil {
+		t.Fatalf("SFTPProxyHostSigners: %v", err)
+	}
+	if len(signers) != 2 {
+		t.Fatalf("expected the SFTP proxy key and its certificate, got %d signers", len(signers))
+	}
+	if _, ok := signers[1].PublicKey().(*ssh.Certificate); !ok {
+		t.Fatal("the second SFTP proxy signer must present a certificate")
+	}
+}
+
+func TestHostKeysStoredEncrypted(t *testing.T) {
+	db := newHostKeyTestDB(t)
+	pub, priv, err := generateSFTPProxyHostKeyMaterial()
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	// A host key stored before EGRESS_ENC_KEY was set.
+	if err := db.Create(&models.SshHostKey{Type: "ed25519", PrivateKey: priv, PublicKey: pub}).Error; err != nil {
+		t.Fatalf("insert ssh host key: %v", err)
+	}
+
+	config.ResetForTesting()
+	t.Cleanup(config.ResetForTesting)
+	cfg := config.Load()
+	cfg.Paths.SshHostKeyDir = t.TempDir()
+
+	if err := RestoreSSHHostKeys(db); err != nil {
+		t.Fatalf("RestoreSSHHostKeys: %v", err)
+	}
+	written, err := os.ReadFile(filepath.Join(cfg.Paths.SshHostKeyDir, "ssh_host_ed25519_key"))
+	if err != nil || !bytes.Equal(written, priv) {
+		t.Fatalf("sshd must get the plaintext host key, got %q (%v)", written, err)
+	}
+	var stored models.SshHostKey
+	db.Where("type = ?", "ed25519").First(&stored)
+	if !cryptokey.IsEncrypted(string(stored.PrivateKey)) {
+		t.Fatal("a plaintext host key must be re-encrypted once restored")
+	}
+
+	if _, _, _, err := EnsureSFTPProxyHostKey(db, false); err != nil {
+		t.Fatalf("EnsureSFTPProxyHostKey: %v", err)
+	}
+	var proxy models.SshHostKey
+	db.Where("type = ?", sftpProxyHostKeyType).First(&proxy)
+	if !cryptokey.IsEncrypted(string(proxy.PrivateKey)) {
+		t.Fatal("the SFTP proxy host key must be stored encrypted")
+	}
+	if _, _, _, err := EnsureSFTPProxyHostKey(db, false); err != nil {
+		t.Fatalf("an encrypted SFTP proxy host key must load: %v", err)
+	}
+}
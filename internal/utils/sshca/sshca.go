@@ -0,0 +1,327 @@
+// Package sshca lets the bastion act as an SSH certificate authority. The
+// user CA signs short-lived certificates for the egress keys, so targets
+// only need to trust the CA public key in TrustedUserCAKeys. The host CA
+// signs the bastion's own host keys, so clients only need one
+// @cert-authority line in known_hosts.
+package sshca
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"goBastion/internal/config"
+	"goBastion/internal/models"
+	"goBastion/internal/utils/cryptokey"
+
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrRotationInProgress is returned when a rotation is started while a
+	// next CA already exists.
+	ErrRotationInProgress = errors.New("a CA rotation is already in progress")
+	// ErrNoRotation is returned when there is no next CA to promote or cancel.
+	ErrNoRotation = errors.New("no CA rotation in progress")
+)
+
+// clockSkew backdates certificates so that targets with a slightly late
+// clock accept them.
+const clockSkew = time.Minute
+
+// EnsureActive returns the active CA of kind, creating it on first use.
+// A private key stored before EGRESS_ENC_KEY was set is encrypted on the way.
+func EnsureActive(db *gorm.DB, kind string) (models.CertAuthority, error) {
+	var ca models.CertAuthority
+	err := db.Where("kind = ? AND state = ?", kind, models.CertAuthorityActive).Order("created_at").First(&ca).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ca, err = newCertAuthority(kind, models.CertAuthorityActive)
+		if err != nil {
+			return ca, err
+		}
+		if err := db.Create(&ca).Error; err != nil {
+			return ca, fmt.Errorf("save %s CA: %w", kind, err)
+		}
+		return ca, nil
+	case err != nil:
+		return ca, fmt.Errorf("load %s CA: %w", kind, err)
+	}
+
+	if cryptokey.Enabled() && !cryptokey.IsEncrypted(ca.PrivateKey) {
+		if enc, encErr := cryptokey.Encrypt(ca.PrivateKey); encErr == nil {
+			if db.Model(&ca).Update("private_key", enc).Error == nil {
+				ca.PrivateKey = enc
+			}
+		}
+	}
+	return ca, nil
+}
+
+// Trusted returns the CAs of kind that targets must trust: the active one
+// and, during a rotation, the next one.
+func Trusted(db *gorm.DB, kind string) ([]models.CertAuthority, error) {
+	if _, err := EnsureActive(db, kind); err != nil {
+		return nil, err
+	}
+	var cas []models.CertAuthority
+	err := db.Where("kind = ? AND state IN ?", kind, []string{models.CertAuthorityActive, models.CertAuthorityNext}).
+		Order("created_at").Find(&cas).Error
+	return cas, err
+}
+
+// StartRotation creates the next CA of kind. It is published by Trusted but
+// only signs once promoted, which leaves time to add it to every target.
+func StartRotation(db *gorm.DB, log *slog.Logger, kind string) (models.CertAuthority, error) {
+	if _, err := EnsureActive(db, kind); err != nil {
+		return models.CertAuthority{}, err
+	}
+	var count int64
+	if err := db.Model(&models.CertAuthority{}).Where("kind = ? AND state = ?", kind, models.CertAuthorityNext).Count(&count).Error; err != nil {
+		return models.CertAuthority{}, err
+	}
+	if count > 0 {
+		return models.CertAuthority{}, ErrRotationInProgress
+	}
+	next, err := newCertAuthority(kind, models.CertAuthorityNext)
+	if err != nil {
+		return next, err
+	}
+	if err := db.Create(&next).Error; err != nil {
+		return next, fmt.Errorf("save %s CA: %w", kind, err)
+	}
+	log.Info("cert_authority_state", slog.String("kind", kind), slog.String("fingerprint", next.Fingerprint),
+		slog.String("to", models.CertAuthorityNext))
+	return next, nil
+}
+
+// Promote makes the next CA of kind the signing one and retires the
+// previous active CA.
+func Promote(db *gorm.DB, log *slog.Logger, kind string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var next models.CertAuthority
+		if err := tx.Where("kind = ? AND state = ?", kind, models.CertAuthorityNext).First(&next).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNoRotation
+			}
+			return err
+		}
+		var previous []models.CertAuthority
+		if err := tx.Where("kind = ? AND state = ?", kind, models.CertAuthorityActive).Find(&previous).Error; err != nil {
+			return err
+		}
+		for _, ca := range previous {
+			if err := tx.Model(&ca).Update("state", models.CertAuthorityRetired).Error; err != nil {
+				return err
+			}
+			log.Info("cert_authority_state", slog.String("kind", kind), slog.String("fingerprint", ca.Fingerprint),
+				slog.String("from", models.CertAuthorityActive), slog.String("to", models.CertAuthorityRetired))
+		}
+		if err := tx.Model(&next).Update("state", models.CertAuthorityActive).Error; err != nil {
+			return err
+		}
+		log.Info("cert_authority_state", slog.String("kind", kind), slog.String("fingerprint", next.Fingerprint),
+			slog.String("from", models.CertAuthorityNext), slog.String("to", models.CertAuthorityActive))
+		return nil
+	})
+}
+
+// Cancel retires the next CA of kind without promoting it.
+func Cancel(db *gorm.DB, log *slog.Logger, kind string) error {
+	var next models.CertAuthority
+	if err := db.Where("kind = ? AND state = ?", kind, models.CertAuthorityNext).First(&next).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNoRotation
+		}
+		return err
+	}
+	if err := db.Model(&next).Update("state", models.CertAuthorityRetired).Error; err != nil {
+		return err
+	}
+	log.Info("cert_authority_state", slog.String("kind", kind), slog.String("fingerprint", next.Fingerprint),
+		slog.String("from", models.CertAuthorityNext), slog.String("to", models.CertAuthorityRetired))
+	return nil
+}
+
+// Signer returns the signer of ca, decrypting its private key.
+func Signer(ca models.CertAuthority) (ssh.Signer, error) {
+	signer, err := ssh.ParsePrivateKey([]byte(cryptokey.DecryptOrPassThrough(ca.PrivateKey)))
+	if err != nil {
+		return nil, fmt.Errorf("parse %s CA key: %w", ca.Kind, err)
+	}
+	return signer, nil
+}
+
+// KeyID is the key ID of the certificates issued for a bastion session. It
+// ends up in the target's sshd log next to the serial.
+func KeyID(bastionUser, sessionID string) string {
+	if sessionID == "" {
+		sessionID = "none"
+	}
+	return fmt.Sprintf("gobastion user=%s session=%s", bastionUser, sessionID)
+}
+
+// SignUserKey returns a user certificate for pub valid from now for
+// validity, with principal as the only principal.
+func SignUserKey(ca ssh.Signer, pub ssh.PublicKey, principal, keyID string, validity time.Duration, now time.Time) (*ssh.Certificate, error) {
+	var serial [8]byte
+	if _, err := rand.Read(serial[:]); err != nil {
+		return nil, fmt.Errorf("generate serial: %w", err)
+	}
+	cert := &ssh.Certificate{
+		Key:             pub,
+		Serial:          binary.BigEndian.Uint64(serial[:]),
+		CertType:        ssh.UserCert,
+		KeyId:           keyID,
+		ValidPrincipals: []string{principal},
+		ValidAfter:      uint64(now.Add(-clockSkew).Unix()),
+		ValidBefore:     uint64(now.Add(validity).Unix()),
+		Permissions: ssh.Permissions{Extensions: map[string]string{
+			"permit-X11-forwarding":   "",
+			"permit-agent-forwarding": "",
+			"permit-port-forwarding":  "",
+			"permit-pty":              "",
+			"permit-user-rc":          "",
+		}},
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		return nil, fmt.Errorf("sign certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// SignHostKey returns a host certificate for pub valid from now for
+// validity. An empty principals list makes it valid for any host name.
+func SignHostKey(ca ssh.Signer, pub ssh.PublicKey, principals []string, keyID string, validity time.Duration, now time.Time) (*ssh.Certificate, error) {
+	var serial [8]byte
+	if _, err := rand.Read(serial[:]); err != nil {
+		return nil, fmt.Errorf("generate serial: %w", err)
+	}
+	cert := &ssh.Certificate{
+		Key:             pub,
+		Serial:          binary.BigEndian.Uint64(serial[:]),
+		CertType:        ssh.HostCert,
+		KeyId:           keyID,
+		ValidPrincipals: principals,
+		ValidAfter:      uint64(now.Add(-clockSkew).Unix()),
+		ValidBefore:     uint64(now.Add(validity).Unix()),
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		return nil, fmt.Errorf("sign certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// HostPrincipals returns the names of ssh.host_cert_principals.
+func HostPrincipals() []string {
+	var principals []string
+	for _, p := range strings.Split(config.Get().SSH.HostCertPrincipals, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			principals = append(principals, p)
+		}
+	}
+	return principals
+}
+
+// IssueHostCertificate signs pub with the active host CA for principals,
+// valid for ssh.host_cert_validity. keyID names the key in the certificate.
+func IssueHostCertificate(db *gorm.DB, pub ssh.PublicKey, principals []string, keyID string) (*ssh.Certificate, error) {
+	ca, err := EnsureActive(db, models.CertAuthorityHost)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := Signer(ca)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := SignHostKey(signer, pub, principals, keyID, time.Duration(config.Get().SSH.HostCertValidity), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("host_cert_issued", slog.String("key_id", keyID), slog.Uint64("serial", cert.Serial),
+		slog.String("ca", ca.Fingerprint), slog.String("principals", strings.Join(principals, ",")),
+		slog.Time("valid_before", time.Unix(int64(cert.ValidBefore), 0)))
+	return cert, nil
+}
+
+// HostCertificateCurrent reports whether cert still fits: it certifies pub
+// for exactly principals, is signed by the active host CA and has more than
+// half of its validity left at now.
+func HostCertificateCurrent(db *gorm.DB, cert *ssh.Certificate, pub ssh.PublicKey, principals []string, now time.Time) bool {
+	ca, err := EnsureActive(db, models.CertAuthorityHost)
+	if err != nil {
+		return false
+	}
+	if cert.CertType != ssh.HostCert || ssh.FingerprintSHA256(cert.SignatureKey) != ca.Fingerprint ||
+		string(cert.Key.Marshal()) != string(pub.Marshal()) ||
+		strings.Join(cert.ValidPrincipals, ",") != strings.Join(principals, ",") {
+		return false
+	}
+	after, before := int64(cert.ValidAfter), int64(cert.ValidBefore)
+	return now.Unix() < before-(before-after)/2
+}
+
+// IssueUserCertificate signs a certificate for the egress key privateKey,
+// valid for ssh.user_cert_validity, for principal on behalf of bastionUser
+// in the current session. It returns nil when certificates are disabled.
+func IssueUserCertificate(db *gorm.DB, privateKey, principal, bastionUser string) (*ssh.Certificate, error) {
+	validity := time.Duration(config.Get().SSH.UserCertValidity)
+	if validity <= 0 {
+		return nil, nil
+	}
+	key, err := ssh.ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		return nil, fmt.Errorf("parse egress key: %w", err)
+	}
+	ca, err := EnsureActive(db, models.CertAuthorityUser)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := Signer(ca)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := SignUserKey(signer, key.PublicKey(), principal, KeyID(bastionUser, os.Getenv("GOB_SESSION_ID")), validity, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("user_cert_issued", slog.String("user", bastionUser), slog.String("principal", principal),
+		slog.String("key_id", cert.KeyId), slog.Uint64("serial", cert.Serial), slog.String("ca", ca.Fingerprint),
+		slog.Time("valid_before", time.Unix(int64(cert.ValidBefore), 0)))
+	return cert, nil
+}
+
+// newCertAuthority generates an ed25519 CA key of kind in state.
+func newCertAuthority(kind, state string) (models.CertAuthority, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return models.CertAuthority{}, fmt.Errorf("generate %s CA key: %w", kind, err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "goBastion "+kind+" CA")
+	if err != nil {
+		return models.CertAuthority{}, fmt.Errorf("marshal %s CA key: %w", kind, err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		return models.CertAuthority{}, fmt.Errorf("%s CA signer: %w", kind, err)
+	}
+	privateKey, err := cryptokey.ReEncryptIfNeeded(string(pem.EncodeToMemory(block)))
+	if err != nil {
+		return models.CertAuthority{}, fmt.Errorf("encrypt %s CA key: %w", kind, err)
+	}
+	return models.CertAuthority{
+		Kind:        kind,
+		State:       state,
+		PublicKey:   strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey()))) + " goBastion-" + kind + "-ca",
+		PrivateKey:  privateKey,
+		Fingerprint: ssh.FingerprintSHA256(signer.PublicKey()),
+	}, nil
+}
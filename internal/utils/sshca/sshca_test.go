@@ -0,0 +1,182 @@
+package sshca
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/cryptokey"
+)
+
+var testLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestMain(m *testing.M) {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	_ = os.Setenv("EGRESS_ENC_KEY", base64.StdEncoding.EncodeToString(key))
+	os.Exit(m.Run())
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open test DB: %v", err)
+	}
+	if err := db.AutoMigrate(&models.CertAuthority{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func TestEnsureActiveStoresEncryptedKey(t *testing.T) {
+	db := newTestDB(t)
+	ca, err := EnsureActive(db, models.CertAuthorityUser)
+	if err != nil {
+		t.Fatalf("EnsureActive: %v", err)
+	}
+	if !cryptokey.IsEncrypted(ca.PrivateKey) {
+		t.Fatal("the CA private key must be stored encrypted")
+	}
+	again, err := EnsureActive(db, models.CertAuthorityUser)
+	if err != nil || again.ID != ca.ID {
+		t.Fatalf("expected the same CA, got %v (%v)", again.ID, err)
+	}
+	if _, err := Signer(ca); err != nil {
+		t.Fatalf("Signer: %v", err)
+	}
+}
+
+func TestSignedCertificateIsAcceptedForPrincipal(t *testing.T) {
+	db := newTestDB(t)
+	ca, _ := EnsureActive(db, models.CertAuthorityUser)
+	signer, err := Signer(ca)
+	if err != nil {
+		t.Fatalf("Signer: %v", err)
+	}
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	key, _ := ssh.NewPublicKey(pub)
+	now := time.Now()
+	cert, err := SignUserKey(signer, key, "root", KeyID("alice", "sid-1"), 5*time.Minute, now)
+	if err != nil {
+		t.Fatalf("SignUserKey: %v", err)
+	}
+	if cert.KeyId != "gobastion user=alice session=sid-1" {
+		t.Fatalf("unexpected key ID %q", cert.KeyId)
+	}
+
+	// TrustedUserCAKeys holds the published public key.
+	caKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(ca.PublicKey))
+	if err != nil {
+		t.Fatalf("parse CA public key: %v", err)
+	}
+	checker := &ssh.CertChecker{
+		IsUserAuthority: func(auth ssh.PublicKey) bool { return string(auth.Marshal()) == string(caKey.Marshal()) },
+		Clock:           func() time.Time { return now },
+	}
+	if err := checker.CheckCert("root", cert); err != nil {
+		t.Fatalf("the certificate must be valid for root: %v", err)
+	}
+	if err := checker.CheckCert("admin", cert); err == nil {
+		t.Fatal("the certificate must not be valid for another account")
+	}
+	checker.Clock = func() time.Time { return now.Add(6 * time.Minute) }
+	if err := checker.CheckCert("root", cert); err == nil {
+		t.Fatal("the certificate must expire")
+	}
+}
+
+func TestRotation(t *testing.T) {
+	db := newTestDB(t)
+	old, _ := EnsureActive(db, models.CertAuthorityUser)
+	next, err := StartRotation(db, testLog, models.CertAuthorityUser)
+	if err != nil {
+		t.Fatalf("StartRotation: %v", err)
+	}
+	if _, err := StartRotation(db, testLog, models.CertAuthorityUser); !errors.Is(err, ErrRotationInProgress) {
+		t.Fatalf("expected ErrRotationInProgress, got %v", err)
+	}
+
+	trusted, err := Trusted(db, models.CertAuthorityUser)
+	if err != nil || len(trusted) != 2 {
+		t.Fatalf("both CAs must be trusted during a rotation, got %d (%v)", len(trusted), err)
+	}
+	if active, _ := EnsureActive(db, models.CertAuthorityUser); active.ID != old.ID {
+		t.Fatal("the next CA must not sign before it is promoted")
+	}
+
+	if err := Promote(db, testLog, models.CertAuthorityUser); err != nil {
+		t.Fatalf("Promote: %v", err)
+	}
+	active, _ := EnsureActive(db, models.CertAuthorityUser)
+	if active.ID != next.ID {
+		t.Fatal("the next CA must sign once promoted")
+	}
+	trusted, _ = Trusted(db, models.CertAuthorityUser)
+	if len(trusted) != 1 || !strings.HasPrefix(trusted[0].PublicKey, "ssh-ed25519 ") {
+		t.Fatalf("only the new CA must be trusted after promotion, got %+v", trusted)
+	}
+	if err := Cancel(db, testLog, models.CertAuthorityUser); !errors.Is(err, ErrNoRotation) {
+		t.Fatalf("expected ErrNoRotation, got %v", err)
+	}
+}
+
+func TestHostCertificateIsAcceptedForHost(t *testing.T) {
+	db := newTestDB(t)
+	ca, _ := EnsureActive(db, models.CertAuthorityHost)
+	signer, err := Signer(ca)
+	if err != nil {
+		t.Fatalf("Signer: %v", err)
+	}
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	key, _ := ssh.NewPublicKey(pub)
+	now := time.Now()
+	principals := []string{"bastion.example.com"}
+	cert, err := SignHostKey(signer, key, principals, "gobastion host=ed25519", 24*time.Hour, now)
+	if err != nil {
+		t.Fatalf("SignHostKey: %v", err)
+	}
+
+	caKey, _, _, _, _ := ssh.ParseAuthorizedKey([]byte(ca.PublicKey))
+	checker := &ssh.CertChecker{
+		IsHostAuthority: func(auth ssh.PublicKey, _ string) bool { return string(auth.Marshal()) == string(caKey.Marshal()) },
+		Clock:           func() time.Time { return now },
+	}
+	if err := checker.CheckHostKey("bastion.example.com:22", nil, cert); err != nil {
+		t.Fatalf("the certificate must be valid for the bastion name: %v", err)
+	}
+	if err := checker.CheckHostKey("other.example.com:22", nil, cert); err == nil {
+		t.Fatal("the certificate must not be valid for another name")
+	}
+
+	if !HostCertificateCurrent(db, cert, key, principals, now) {
+		t.Fatal("a fresh certificate must be current")
+	}
+	if HostCertificateCurrent(db, cert, key, nil, now) {
+		t.Fatal("a certificate for other principals must be re-signed")
+	}
+	if HostCertificateCurrent(db, cert, key, principals, now.Add(13*time.Hour)) {
+		t.Fatal("a certificate past half of its validity must be re-signed")
+	}
+	if _, err := StartRotation(db, testLog, models.CertAuthorityHost); err != nil {
+		t.Fatalf("StartRotation: %v", err)
+	}
+	if err := Promote(db, testLog, models.CertAuthorityHost); err != nil {
+		t.Fatalf("Promote: %v", err)
+	}
+	if HostCertificateCurrent(db, cert, key, principals, now) {
+		t.Fatal("a certificate of the previous CA must be re-signed")
+	}
+}
@@ -0,0 +1,187 @@
+package state
+
+import (
+	"fmt"
+	"time"
+
+	"goBastion/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// owner is the group or user holding accesses and aliases. Groups and users
+// share the alias tables and have twin access tables.
+type owner struct {
+	column string // "group_id" or "user_id"
+	id     uuid.UUID
+	group  bool
+}
+
+// Rows as read from the database, in document form with their ID.
+type (
+	storedAccess struct {
+		Access
+		id uuid.UUID
+	}
+	storedAlias struct {
+		Alias
+		id uuid.UUID
+	}
+	storedDBAccess struct {
+		DBAccess
+		id uuid.UUID
+	}
+	storedDBAlias struct {
+		DBAlias
+		id uuid.UUID
+	}
+)
+
+func (o owner) accessModel() any {
+	if o.group {
+		return &models.GroupAccess{}
+	}
+	return &models.SelfAccess{}
+}
+
+func (o owner) dbAccessModel() any {
+	if o.group {
+		return &models.GroupDBAccess{}
+	}
+	return &models.SelfDBAccess{}
+}
+
+// own points a new alias at the owner.
+func (o owner) own(userID, groupID **uuid.UUID) {
+	id := o.id
+	if o.group {
+		*groupID = &id
+	} else {
+		*userID = &id
+	}
+}
+
+func (o owner) newAccess(a Access, startsAt, expiresAt *time.Time) any {
+	if o.group {
+		return &models.GroupAccess{
+			GroupID: o.id, Username: a.Username, Server: a.Server, Port: a.Port, Protocol: a.Protocol,
+			Comment: a.Comment, AllowedFrom: a.From, Schedule: a.Schedule, StartsAt: startsAt, ExpiresAt: expiresAt,
+		}
+	}
+	return &models.SelfAccess{
+		UserID: o.id, Username: a.Username, Server: a.Server, Port: a.Port, Protocol: a.Protocol,
+		Comment: a.Comment, AllowedFrom: a.From, Schedule: a.Schedule, StartsAt: startsAt, ExpiresAt: expiresAt,
+	}
+}
+
+func (o owner) newDBAccess(a DBAccess, startsAt, expiresAt *time.Time) any {
+	if o.group {
+		return &models.GroupDBAccess{
+			GroupID: o.id, Host: a.Host, Port: a.Port, Protocol: a.Protocol, Username: a.Username, Database: a.Database,
+			Comment: a.Comment, AllowedFrom: a.From, Schedule: a.Schedule, StartsAt: startsAt, ExpiresAt: expiresAt,
+		}
+	}
+	return &models.SelfDBAccess{
+		UserID: o.id, Host: a.Host, Port: a.Port, Protocol: a.Protocol, Username: a.Username, Database: a.Database,
+		Comment: a.Comment, AllowedFrom: a.From, Schedule: a.Schedule, StartsAt: startsAt, ExpiresAt: expiresAt,
+	}
+}
+
+func (o owner) accesses(db *gorm.DB) ([]storedAccess, error) {
+	var out []storedAccess
+	add := func(id uuid.UUID, server, username string, port int64, protocol, from, sched, comment string, startsAt, expiresAt *time.Time) {
+		if protocol == "" {
+			protocol = "ssh"
+		}
+		out = append(out, storedAccess{id: id, Access: Access{
+			Server: server, Username: username, Port: port, Protocol: protocol, From: from,
+			Schedule: sched, Comment: comment, Starts: formatTime(startsAt), Expires: formatTime(expiresAt),
+		}})
+	}
+	if o.group {
+		// Accesses restricted to one member come from approved access
+		// requests; they are not part of the declared state.
+		var rows []models.GroupAccess
+		if err := db.Where("group_id = ? AND member_id IS NULL", o.id).Order("created_at").Find(&rows).Error; err != nil {
+			return nil, fmt.Errorf("list accesses: %w", err)
+		}
+		for _, a := range rows {
+			add(a.ID, a.Server, a.Username, a.Port, a.Protocol, a.AllowedFrom, a.Schedule, a.Comment, a.StartsAt, a.ExpiresAt)
+		}
+		return out, nil
+	}
+	var rows []models.SelfAccess
+	if err := db.Where("user_id = ?", o.id).Order("created_at").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list accesses: %w", err)
+	}
+	for _, a := range rows {
+		add(a.ID, a.Server, a.Username, a.Port, a.Protocol, a.AllowedFrom, a.Schedule, a.Comment, a.StartsAt, a.ExpiresAt)
+	}
+	return out, nil
+}
+
+func (o owner) dbAccesses(db *gorm.DB) ([]storedDBAccess, error) {
+	var out []storedDBAccess
+	add := func(id uuid.UUID, host string, port int64, protocol, username, database, from, sched, comment string, startsAt, expiresAt *time.Time) {
+		out = append(out, storedDBAccess{id: id, DBAccess: DBAccess{
+			Host: host, Port: port, Protocol: protocol, Username: username, Database: database, From: from,
+			Schedule: sched, Comment: comment, Starts: formatTime(startsAt), Expires: formatTime(expiresAt),
+		}})
+	}
+	if o.group {
+		var rows []models.GroupDBAccess
+		if err := db.Where("group_id = ?", o.id).Order("created_at").Find(&rows).Error; err != nil {
+			return nil, fmt.Errorf("list db accesses: %w", err)
+		}
+		for _, a := range rows {
+			add(a.ID, a.Host, a.Port, a.Protocol, a.Username, a.Database, a.AllowedFrom, a.Schedule, a.Comment, a.StartsAt, a.ExpiresAt)
+		}
+		return out, nil
+	}
+	var rows []models.SelfDBAccess
+	if err := db.Where("user_id = ?", o.id).Order("created_at").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list db accesses: %w", err)
+	}
+	for _, a := range rows {
+		add(a.ID, a.Host, a.Port, a.Protocol, a.Username, a.Database, a.AllowedFrom, a.Schedule, a.Comment, a.StartsAt, a.ExpiresAt)
+	}
+	return out, nil
+}
+
+func (o owner) aliases(db *gorm.DB) ([]storedAlias, error) {
+	var rows []models.Aliases
+	if err := db.Where(o.column+" = ?", o.id).Order("created_at").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list aliases: %w", err)
+	}
+	out := make([]storedAlias, 0, len(rows))
+	for _, a := range rows {
+		out = append(out, storedAlias{id: a.ID, Alias: Alias{Name: a.ResolveFrom, Host: a.Host}})
+	}
+	return out, nil
+}
+
+func (o owner) dbAliases(db *gorm.DB) ([]storedDBAlias, error) {
+	var rows []models.DatabaseAlias
+	if err := db.Where(o.column+" = ?", o.id).Order("created_at").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list db aliases: %w", err)
+	}
+	out := make([]storedDBAlias, 0, len(rows))
+	for _, a := range rows {
+		out = append(out, storedDBAlias{id: a.ID, DBAlias: DBAlias{Name: a.ResolveFrom, Host: a.Host, Port: a.Port, Protocol: a.Protocol}})
+	}
+	return out, nil
+}
+
+// usernames maps the ID of every account to its username.
+func usernames(db *gorm.DB) (map[uuid.UUID]string, error) {
+	var users []models.User
+	if err := db.Select("id", "username").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	names := make(map[uuid.UUID]string, len(users))
+	for _, u := range users {
+		names[u.ID] = u.Username
+	}
+	return names, nil
+}
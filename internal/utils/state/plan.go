@@ -0,0 +1,533 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"goBastion/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Change operations, as printed at the start of a plan line.
+const (
+	OpCreate = "+"
+	OpUpdate = "~"
+	OpDelete = "-"
+)
+
+// Change is one step of a plan.
+type Change struct {
+	Op      string   // OpCreate, OpUpdate or OpDelete
+	Scope   string   // "group ops", "user alice"
+	Subject string   // "member bob", "access root@srv:22"…
+	Details []string // changed fields of an update, "field: old → new"
+}
+
+// String renders the change as a plan line.
+func (c Change) String() string {
+	line := fmt.Sprintf("%s %s: %s", c.Op, c.Scope, c.Subject)
+	if len(c.Details) > 0 {
+		line += " (" + strings.Join(c.Details, ", ") + ")"
+	}
+	return line
+}
+
+// Options tunes how a document is reconciled with the database.
+type Options struct {
+	// Prune deletes groups missing from the document, and the members,
+	// accesses and aliases of listed groups and users missing from it.
+	Prune bool
+}
+
+// Plan returns the changes Apply would make, without writing anything.
+func Plan(db *gorm.DB, doc *Document, opts Options) ([]Change, error) {
+	r := &reconciler{tx: db, opts: opts}
+	if err := r.run(doc); err != nil {
+		return nil, err
+	}
+	return r.changes, nil
+}
+
+// Apply makes the database match doc in a single transaction and returns the
+// changes made. Nothing is written if any step fails.
+func Apply(db *gorm.DB, doc *Document, opts Options) ([]Change, error) {
+	var r *reconciler
+	err := db.Transaction(func(tx *gorm.DB) error {
+		r = &reconciler{tx: tx, opts: opts, apply: true, members: make(map[uuid.UUID]bool)}
+		return r.run(doc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for userID := range r.members {
+		models.InvalidateGroupsCache(userID)
+	}
+	return r.changes, nil
+}
+
+// reconciler walks a document against the database, recording every change
+// and performing it when apply is set. In plan mode, groups still to be
+// created have a nil ID and therefore no existing content.
+type reconciler struct {
+	tx      *gorm.DB
+	opts    Options
+	apply   bool
+	changes []Change
+	names   map[uuid.UUID]string
+	users   map[string]uuid.UUID
+	members map[uuid.UUID]bool // users whose memberships changed
+}
+
+// record adds a change to the plan and, when applying, performs it.
+func (r *reconciler) record(c Change, do func() error) error {
+	r.changes = append(r.changes, c)
+	if !r.apply {
+		return nil
+	}
+	if err := do(); err != nil {
+		return fmt.Errorf("%s: %w", c, err)
+	}
+	return nil
+}
+
+func (r *reconciler) run(doc *Document) error {
+	err := doc.Validate()
+	if err != nil {
+		return err
+	}
+	if r.names, err = usernames(r.tx); err != nil {
+		return err
+	}
+	r.users = make(map[string]uuid.UUID, len(r.names))
+	for id, name := range r.names {
+		r.users[name] = id
+	}
+
+	// Resolve every account first so a typo fails before anything is written.
+	var unknown []error
+	for _, g := range doc.Groups {
+		for _, m := range g.Members {
+			if _, ok := r.users[m.User]; !ok {
+				unknown = append(unknown, fmt.Errorf("group %q: member %q: no such account", g.Name, m.User))
+			}
+		}
+	}
+	for _, u := range doc.Users {
+		if _, ok := r.users[u.Name]; !ok {
+			unknown = append(unknown, fmt.Errorf("user %q: no such account", u.Name))
+		}
+	}
+	if len(unknown) > 0 {
+		return errors.Join(unknown...)
+	}
+
+	listed := make(map[string]bool, len(doc.Groups))
+	for _, g := range doc.Groups {
+		listed[g.Name] = true
+		if err := r.group(g); err != nil {
+			return err
+		}
+	}
+	if r.opts.Prune {
+		var groups []models.Group
+		if err := r.tx.Order("name").Find(&groups).Error; err != nil {
+			return fmt.Errorf("list groups: %w", err)
+		}
+		for _, g := range groups {
+			if !listed[g.Name] {
+				if err := r.deleteGroup(g); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, u := range doc.Users {
+		o := owner{column: "user_id", id: r.users[u.Name]}
+		if err := r.targets("user "+u.Name, o, u.Accesses, u.Aliases, u.DBAccesses, u.DBAliases); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *reconciler) group(g Group) error {
+	scope := "group " + g.Name
+	var existing models.Group
+	err := r.tx.Where("name = ?", g.Name).First(&existing).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		existing = models.Group{
+			Name:                  g.Name,
+			MFARequired:           g.MFARequired,
+			JustificationRequired: g.JustificationRequired,
+			JustificationPattern:  g.JustificationPattern,
+		}
+		if err := r.record(Change{Op: OpCreate, Scope: scope, Subject: "group"}, func() error {
+			return r.tx.Create(&existing).Error
+		}); err != nil {
+			return err
+		}
+	case err != nil:
+		return fmt.Errorf("look up group %s: %w", g.Name, err)
+	default:
+		var d diff
+		d.bool("mfa_required", existing.MFARequired, g.MFARequired)
+		d.bool("justification_required", existing.JustificationRequired, g.JustificationRequired)
+		d.str("justification_pattern", existing.JustificationPattern, g.JustificationPattern)
+		if len(d) > 0 {
+			if err := r.record(Change{Op: OpUpdate, Scope: scope, Subject: "settings", Details: d}, func() error {
+				return r.tx.Model(&existing).Updates(map[string]any{
+					"mfa_required":           g.MFARequired,
+					"justification_required": g.JustificationRequired,
+					"justification_pattern":  g.JustificationPattern,
+				}).Error
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := r.groupMembers(scope, existing.ID, g.Members); err != nil {
+		return err
+	}
+	o := owner{column: "group_id", id: existing.ID, group: true}
+	return r.targets(scope, o, g.Accesses, g.Aliases, g.DBAccesses, g.DBAliases)
+}
+
+func (r *reconciler) groupMembers(scope string, groupID uuid.UUID, want []Member) error {
+	var rows []models.UserGroup
+	if err := r.tx.Where("group_id = ?", groupID).Find(&rows).Error; err != nil {
+		return fmt.Errorf("list members: %w", err)
+	}
+	have, extra := indexRows(rows, func(ug models.UserGroup) string { return r.username(ug.UserID) })
+
+	for _, m := range want {
+		userID := r.users[m.User]
+		expiresAt, err := parseTime(m.Expires)
+		if err != nil {
+			return err
+		}
+		row, ok := have[m.User]
+		if !ok {
+			if err := r.record(Change{Op: OpCreate, Scope: scope, Subject: fmt.Sprintf("member %s (%s)", m.User, m.Role)}, func() error {
+				r.members[userID] = true
+				return r.tx.Create(&models.UserGroup{UserID: userID, GroupID: groupID, Role: m.Role, ExpiresAt: expiresAt}).Error
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+		delete(have, m.User)
+		var d diff
+		d.str("role", row.Role, m.Role)
+		d.str("expires", formatTime(row.ExpiresAt), m.Expires)
+		if len(d) > 0 {
+			if err := r.record(Change{Op: OpUpdate, Scope: scope, Subject: "member " + m.User, Details: d}, func() error {
+				r.members[userID] = true
+				return r.tx.Model(&row).Updates(map[string]any{"role": m.Role, "expires_at": expiresAt}).Error
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if r.apply && r.opts.Prune {
+		for _, row := range rows {
+			r.members[row.UserID] = true
+		}
+	}
+	return prune(r, scope, extra, have, func(ug models.UserGroup) (string, uuid.UUID) {
+		return fmt.Sprintf("member %s (%s)", r.username(ug.UserID), ug.Role), ug.ID
+	}, func() any { return &models.UserGroup{} })
+}
+
+// username returns the name of an account, or its ID if it has been deleted.
+func (r *reconciler) username(id uuid.UUID) string {
+	if name, ok := r.names[id]; ok {
+		return name
+	}
+	return id.String()
+}
+
+// deleteGroup removes a group missing from the document together with
+// everything that only makes sense inside it.
+func (r *reconciler) deleteGroup(g models.Group) error {
+	return r.record(Change{Op: OpDelete, Scope: "group " + g.Name, Subject: "group with its members, accesses and aliases"}, func() error {
+		var memberIDs []uuid.UUID
+		if err := r.tx.Model(&models.UserGroup{}).Where("group_id = ?", g.ID).Pluck("user_id", &memberIDs).Error; err != nil {
+			return err
+		}
+		for _, id := range memberIDs {
+			r.members[id] = true
+		}
+		for _, model := range []any{
+			&models.UserGroup{}, &models.GroupAccess{}, &models.GroupDBAccess{},
+			&models.GroupGuestAccess{}, &models.GroupGuestDBAccess{},
+			&models.Aliases{}, &models.DatabaseAlias{},
+		} {
+			if err := r.tx.Where("group_id = ?", g.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := r.tx.Where("parent_id = ? OR child_id = ?", g.ID, g.ID).Delete(&models.GroupInclusion{}).Error; err != nil {
+			return err
+		}
+		return r.tx.Delete(&g).Error
+	})
+}
+
+// targets reconciles the accesses and aliases of a group or user.
+func (r *reconciler) targets(scope string, o owner, accesses []Access, aliases []Alias, dbAccesses []DBAccess, dbAliases []DBAlias) error {
+	if err := r.accesses(scope, o, accesses); err != nil {
+		return err
+	}
+	if err := r.aliases(scope, o, aliases); err != nil {
+		return err
+	}
+	if err := r.dbAccesses(scope, o, dbAccesses); err != nil {
+		return err
+	}
+	return r.dbAliases(scope, o, dbAliases)
+}
+
+func (r *reconciler) accesses(scope string, o owner, want []Access) error {
+	rows, err := o.accesses(r.tx)
+	if err != nil {
+		return err
+	}
+	have, extra := indexRows(rows, func(a storedAccess) string { return a.key() })
+
+	for _, a := range want {
+		startsAt, err := parseTime(a.Starts)
+		if err != nil {
+			return err
+		}
+		expiresAt, err := parseTime(a.Expires)
+		if err != nil {
+			return err
+		}
+		row, ok := have[a.key()]
+		if !ok {
+			if err := r.record(Change{Op: OpCreate, Scope: scope, Subject: "access " + a.key()}, func() error {
+				return r.tx.Create(o.newAccess(a, startsAt, expiresAt)).Error
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+		delete(have, a.key())
+		var d diff
+		d.str("protocol", row.Protocol, a.Protocol)
+		d.str("from", row.From, a.From)
+		d.str("schedule", row.Schedule, a.Schedule)
+		d.str("comment", row.Comment, a.Comment)
+		d.str("starts", row.Starts, a.Starts)
+		d.str("expires", row.Expires, a.Expires)
+		if len(d) > 0 {
+			if err := r.record(Change{Op: OpUpdate, Scope: scope, Subject: "access " + a.key(), Details: d}, func() error {
+				return r.tx.Model(o.accessModel()).Where("id = ?", row.id).Updates(map[string]any{
+					"protocol":     a.Protocol,
+					"allowed_from": a.From,
+					"schedule":     a.Schedule,
+					"comment":      a.Comment,
+					"starts_at":    startsAt,
+					"expires_at":   expiresAt,
+				}).Error
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return prune(r, scope, extra, have, func(a storedAccess) (string, uuid.UUID) { return "access " + a.key(), a.id }, o.accessModel)
+}
+
+func (r *reconciler) aliases(scope string, o owner, want []Alias) error {
+	rows, err := o.aliases(r.tx)
+	if err != nil {
+		return err
+	}
+	have, extra := indexRows(rows, func(a storedAlias) string { return strings.ToLower(a.Name) })
+
+	for _, a := range want {
+		row, ok := have[strings.ToLower(a.Name)]
+		if !ok {
+			if err := r.record(Change{Op: OpCreate, Scope: scope, Subject: fmt.Sprintf("alias %s → %s", a.Name, a.Host)}, func() error {
+				alias := models.Aliases{ResolveFrom: a.Name, Host: a.Host}
+				o.own(&alias.UserID, &alias.GroupID)
+				return r.tx.Create(&alias).Error
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+		delete(have, strings.ToLower(a.Name))
+		var d diff
+		d.str("name", row.Name, a.Name)
+		d.str("host", row.Host, a.Host)
+		if len(d) > 0 {
+			if err := r.record(Change{Op: OpUpdate, Scope: scope, Subject: "alias " + a.Name, Details: d}, func() error {
+				return r.tx.Model(&models.Aliases{}).Where("id = ?", row.id).
+					Updates(map[string]any{"resolve_from": a.Name, "host": a.Host}).Error
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return prune(r, scope, extra, have, func(a storedAlias) (string, uuid.UUID) { return "alias " + a.Name, a.id },
+		func() any { return &models.Aliases{} })
+}
+
+func (r *reconciler) dbAccesses(scope string, o owner, want []DBAccess) error {
+	rows, err := o.dbAccesses(r.tx)
+	if err != nil {
+		return err
+	}
+	have, extra := indexRows(rows, func(a storedDBAccess) string { return a.key() })
+
+	for _, a := range want {
+		startsAt, err := parseTime(a.Starts)
+		if err != nil {
+			return err
+		}
+		expiresAt, err := parseTime(a.Expires)
+		if err != nil {
+			return err
+		}
+		row, ok := have[a.key()]
+		if !ok {
+			if err := r.record(Change{Op: OpCreate, Scope: scope, Subject: "db access " + a.key()}, func() error {
+				return r.tx.Create(o.newDBAccess(a, startsAt, expiresAt)).Error
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+		delete(have, a.key())
+		var d diff
+		d.str("database", row.Database, a.Database)
+		d.str("from", row.From, a.From)
+		d.str("schedule", row.Schedule, a.Schedule)
+		d.str("comment", row.Comment, a.Comment)
+		d.str("starts", row.Starts, a.Starts)
+		d.str("expires", row.Expires, a.Expires)
+		if len(d) > 0 {
+			if err := r.record(Change{Op: OpUpdate, Scope: scope, Subject: "db access " + a.key(), Details: d}, func() error {
+				return r.tx.Model(o.dbAccessModel()).Where("id = ?", row.id).Updates(map[string]any{
+					"database":     a.Database,
+					"allowed_from": a.From,
+					"schedule":     a.Schedule,
+					"comment":      a.Comment,
+					"starts_at":    startsAt,
+					"expires_at":   expiresAt,
+				}).Error
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return prune(r, scope, extra, have, func(a storedDBAccess) (string, uuid.UUID) { return "db access " + a.key(), a.id }, o.dbAccessModel)
+}
+
+func (r *reconciler) dbAliases(scope string, o owner, want []DBAlias) error {
+	rows, err := o.dbAliases(r.tx)
+	if err != nil {
+		return err
+	}
+	have, extra := indexRows(rows, func(a storedDBAlias) string { return strings.ToLower(a.Name) })
+
+	for _, a := range want {
+		row, ok := have[strings.ToLower(a.Name)]
+		if !ok {
+			subject := fmt.Sprintf("db alias %s → %s://%s:%d", a.Name, a.Protocol, a.Host, a.Port)
+			if err := r.record(Change{Op: OpCreate, Scope: scope, Subject: subject}, func() error {
+				alias := models.DatabaseAlias{ResolveFrom: a.Name, Host: a.Host, Port: a.Port, Protocol: a.Protocol}
+				o.own(&alias.UserID, &alias.GroupID)
+				return r.tx.Create(&alias).Error
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+		delete(have, strings.ToLower(a.Name))
+		var d diff
+		d.str("name", row.Name, a.Name)
+		d.str("host", row.Host, a.Host)
+		d.str("port", fmt.Sprint(row.Port), fmt.Sprint(a.Port))
+		d.str("protocol", row.Protocol, a.Protocol)
+		if len(d) > 0 {
+			if err := r.record(Change{Op: OpUpdate, Scope: scope, Subject: "db alias " + a.Name, Details: d}, func() error {
+				return r.tx.Model(&models.DatabaseAlias{}).Where("id = ?", row.id).Updates(map[string]any{
+					"resolve_from": a.Name, "host": a.Host, "port": a.Port, "protocol": a.Protocol,
+				}).Error
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return prune(r, scope, extra, have, func(a storedDBAlias) (string, uuid.UUID) { return "db alias " + a.Name, a.id },
+		func() any { return &models.DatabaseAlias{} })
+}
+
+// indexRows maps rows by key. Rows sharing a key with an earlier one are
+// returned apart: the document can only describe one of them.
+func indexRows[T any](rows []T, key func(T) string) (map[string]T, []T) {
+	have := make(map[string]T, len(rows))
+	var extra []T
+	for _, row := range rows {
+		k := key(row)
+		if _, dup := have[k]; dup {
+			extra = append(extra, row)
+			continue
+		}
+		have[k] = row
+	}
+	return have, extra
+}
+
+// prune deletes, when pruning, the rows left over after matching the
+// document: duplicates first, then unmatched rows in key order.
+func prune[T any](r *reconciler, scope string, extra []T, have map[string]T, describe func(T) (string, uuid.UUID), model func() any) error {
+	if !r.opts.Prune {
+		return nil
+	}
+	keys := make([]string, 0, len(have))
+	for k := range have {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		extra = append(extra, have[k])
+	}
+	for _, row := range extra {
+		subject, id := describe(row)
+		if err := r.record(Change{Op: OpDelete, Scope: scope, Subject: subject}, func() error {
+			return r.tx.Delete(model(), "id = ?", id).Error
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diff collects the changed fields of an update.
+type diff []string
+
+func (d *diff) str(field, old, new string) {
+	if old != new {
+		*d = append(*d, fmt.Sprintf("%s: %q → %q", field, old, new))
+	}
+}
+
+func (d *diff) bool(field string, old, new bool) {
+	if old != new {
+		*d = append(*d, fmt.Sprintf("%s: %t → %t", field, old, new))
+	}
+}
@@ -0,0 +1,498 @@
+// Package state describes the bastion's groups, members, accesses and aliases
+// as a declarative YAML (or JSON) document that can be reviewed in git.
+//
+// Export renders the current database in that format; Plan computes the
+// changes needed to make the database match a document and Apply performs
+// them in one transaction. Objects missing from the document are only
+// deleted when pruning: groups not listed, and the members, accesses and
+// aliases of listed groups and users. Users not listed are left alone, and
+// accounts themselves are never created or deleted.
+//
+// Database passwords are never exported. Database accesses created from a
+// document have no stored password (the client prompts for it at connection)
+// and existing passwords are kept on update.
+package state
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	internaldb "goBastion/internal/db"
+	"goBastion/internal/models"
+	"goBastion/internal/utils/justification"
+	"goBastion/internal/utils/schedule"
+	"goBastion/internal/utils/validation"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// groupNameRegexp is the groupCreate naming rule.
+var groupNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// Document is the desired state of the bastion.
+type Document struct {
+	Groups []Group `yaml:"groups"`
+	Users  []User  `yaml:"users,omitempty"`
+}
+
+// Group is a group with its policy, members and targets.
+type Group struct {
+	Name                  string     `yaml:"name"`
+	MFARequired           bool       `yaml:"mfa_required,omitempty"`
+	JustificationRequired bool       `yaml:"justification_required,omitempty"`
+	JustificationPattern  string     `yaml:"justification_pattern,omitempty"`
+	Members               []Member   `yaml:"members,omitempty"`
+	Accesses              []Access   `yaml:"accesses,omitempty"`
+	Aliases               []Alias    `yaml:"aliases,omitempty"`
+	DBAccesses            []DBAccess `yaml:"db_accesses,omitempty"`
+	DBAliases             []DBAlias  `yaml:"db_aliases,omitempty"`
+}
+
+// User holds the personal accesses and aliases of an existing account.
+type User struct {
+	Name       string     `yaml:"name"`
+	Accesses   []Access   `yaml:"accesses,omitempty"`
+	Aliases    []Alias    `yaml:"aliases,omitempty"`
+	DBAccesses []DBAccess `yaml:"db_accesses,omitempty"`
+	DBAliases  []DBAlias  `yaml:"db_aliases,omitempty"`
+}
+
+// Member is a group membership, identified by the user.
+type Member struct {
+	User    string `yaml:"user"`
+	Role    string `yaml:"role"`
+	Expires string `yaml:"expires,omitempty"`
+}
+
+// Access is an SSH access, identified by server, username and port.
+type Access struct {
+	Server   string `yaml:"server"`
+	Username string `yaml:"username"`
+	Port     int64  `yaml:"port,omitempty"`
+	Protocol string `yaml:"protocol,omitempty"`
+	From     string `yaml:"from,omitempty"`
+	Schedule string `yaml:"schedule,omitempty"`
+	Comment  string `yaml:"comment,omitempty"`
+	Starts   string `yaml:"starts,omitempty"`
+	Expires  string `yaml:"expires,omitempty"`
+}
+
+// Alias is a server alias, identified by its name (case-insensitive).
+type Alias struct {
+	Name string `yaml:"name"`
+	Host string `yaml:"host"`
+}
+
+// DBAccess is a database access, identified by host, port, protocol and
+// username.
+type DBAccess struct {
+	Host     string `yaml:"host"`
+	Port     int64  `yaml:"port,omitempty"`
+	Protocol string `yaml:"protocol"`
+	Username string `yaml:"username"`
+	Database string `yaml:"database,omitempty"`
+	From     string `yaml:"from,omitempty"`
+	Schedule string `yaml:"schedule,omitempty"`
+	Comment  string `yaml:"comment,omitempty"`
+	Starts   string `yaml:"starts,omitempty"`
+	Expires  string `yaml:"expires,omitempty"`
+}
+
+// DBAlias is a database alias, identified by its name (case-insensitive).
+type DBAlias struct {
+	Name     string `yaml:"name"`
+	Host     string `yaml:"host"`
+	Port     int64  `yaml:"port,omitempty"`
+	Protocol string `yaml:"protocol"`
+}
+
+// Parse reads a YAML or JSON document, rejecting unknown fields, and
+// validates it.
+func Parse(r io.Reader) (*Document, error) {
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	var doc Document
+	if err := dec.Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("empty state document")
+		}
+		return nil, fmt.Errorf("parse state document: %w", err)
+	}
+	if err := doc.Validate(); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// Write renders doc as YAML.
+func Write(w io.Writer, doc *Document) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Validate checks every entry the way the matching add commands do and fills
+// in defaults (ssh protocol, default ports) and canonical forms (schedules,
+// UTC times), so that an applied document exports back unchanged.
+func (d *Document) Validate() error {
+	var errs []error
+	fail := func(where, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", where, fmt.Sprintf(format, args...)))
+	}
+
+	groups := make(map[string]bool)
+	for i := range d.Groups {
+		g := &d.Groups[i]
+		where := fmt.Sprintf("group %q", g.Name)
+		if len(g.Name) > 64 || !groupNameRegexp.MatchString(g.Name) {
+			fail(where, "invalid group name")
+		}
+		if groups[g.Name] {
+			fail(where, "listed twice")
+		}
+		groups[g.Name] = true
+		if _, err := justification.CompilePattern(g.JustificationPattern); err != nil {
+			fail(where, "%v", err)
+		}
+
+		members := make(map[string]bool)
+		for j := range g.Members {
+			m := &g.Members[j]
+			m.Role = strings.ToLower(strings.TrimSpace(m.Role))
+			if m.Role == "" {
+				m.Role = models.GroupRoleMember
+			}
+			mwhere := fmt.Sprintf("%s: member %q", where, m.User)
+			if !validation.IsValidUsername(m.User) {
+				fail(mwhere, "invalid username")
+			}
+			if members[m.User] {
+				fail(mwhere, "listed twice")
+			}
+			members[m.User] = true
+			if !isGroupRole(m.Role) {
+				fail(mwhere, "role must be one of: owner, aclkeeper, gatekeeper, member, guest")
+			}
+			if err := canonicalTime(&m.Expires); err != nil {
+				fail(mwhere, "expires: %v", err)
+			}
+		}
+		errs = append(errs, validateTargets(where, g.Accesses, g.Aliases, g.DBAccesses, g.DBAliases)...)
+	}
+
+	users := make(map[string]bool)
+	for i := range d.Users {
+		u := &d.Users[i]
+		where := fmt.Sprintf("user %q", u.Name)
+		if !validation.IsValidUsername(u.Name) {
+			fail(where, "invalid username")
+		}
+		if users[u.Name] {
+			fail(where, "listed twice")
+		}
+		users[u.Name] = true
+		errs = append(errs, validateTargets(where, u.Accesses, u.Aliases, u.DBAccesses, u.DBAliases)...)
+	}
+	return errors.Join(errs...)
+}
+
+// validateTargets checks the accesses and aliases of a group or user.
+func validateTargets(where string, accesses []Access, aliases []Alias, dbAccesses []DBAccess, dbAliases []DBAlias) []error {
+	var errs []error
+	fail := func(what, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s: %s", where, what, fmt.Sprintf(format, args...)))
+	}
+
+	seen := make(map[string]bool)
+	for i := range accesses {
+		a := &accesses[i]
+		if a.Port == 0 {
+			a.Port = 22
+		}
+		if a.Protocol == "" {
+			a.Protocol = "ssh"
+		}
+		what := "access " + a.key()
+		if !validation.IsValidServerPattern(a.Server) {
+			fail(what, "invalid server")
+		}
+		if !validation.IsValidUsername(a.Username) {
+			fail(what, "invalid username")
+		}
+		if !validation.IsValidPort(a.Port) {
+			fail(what, "port must be between 1 and 65535")
+		}
+		if !validation.IsValidProtocol(a.Protocol) {
+			fail(what, "protocol must be one of: ssh, scpupload, scpdownload, sftp, rsync")
+		}
+		if seen[a.key()] {
+			fail(what, "listed twice")
+		}
+		seen[a.key()] = true
+		if err := validateWindow(&a.From, &a.Schedule, &a.Starts, &a.Expires); err != nil {
+			fail(what, "%v", err)
+		}
+	}
+
+	seen = make(map[string]bool)
+	for i := range aliases {
+		al := &aliases[i]
+		what := fmt.Sprintf("alias %q", al.Name)
+		if strings.TrimSpace(al.Name) == "" {
+			fail(what, "name is required")
+		}
+		if !validation.IsValidHost(al.Host) {
+			fail(what, "invalid host")
+		}
+		if seen[strings.ToLower(al.Name)] {
+			fail(what, "listed twice")
+		}
+		seen[strings.ToLower(al.Name)] = true
+	}
+
+	seen = make(map[string]bool)
+	for i := range dbAccesses {
+		a := &dbAccesses[i]
+		if a.Port == 0 {
+			a.Port = validation.DBProtocolDefaultPort(a.Protocol)
+		}
+		what := "db access " + a.key()
+		if !validation.IsValidDBProtocol(a.Protocol) {
+			fail(what, "protocol must be one of: mysql, postgres, redis")
+		}
+		if !validation.IsValidHost(a.Host) {
+			fail(what, "invalid host")
+		}
+		if !validation.IsValidPort(a.Port) {
+			fail(what, "port must be between 1 and 65535")
+		}
+		if strings.TrimSpace(a.Username) == "" {
+			fail(what, "username is required")
+		}
+		if seen[a.key()] {
+			fail(what, "listed twice")
+		}
+		seen[a.key()] = true
+		if err := validateWindow(&a.From, &a.Schedule, &a.Starts, &a.Expires); err != nil {
+			fail(what, "%v", err)
+		}
+	}
+
+	seen = make(map[string]bool)
+	for i := range dbAliases {
+		al := &dbAliases[i]
+		if al.Port == 0 {
+			al.Port = validation.DBProtocolDefaultPort(al.Protocol)
+		}
+		what := fmt.Sprintf("db alias %q", al.Name)
+		if strings.TrimSpace(al.Name) == "" {
+			fail(what, "name is required")
+		}
+		if !validation.IsValidDBProtocol(al.Protocol) {
+			fail(what, "protocol must be one of: mysql, postgres, redis")
+		}
+		if !validation.IsValidHost(al.Host) {
+			fail(what, "invalid host")
+		}
+		if !validation.IsValidPort(al.Port) {
+			fail(what, "port must be between 1 and 65535")
+		}
+		if seen[strings.ToLower(al.Name)] {
+			fail(what, "listed twice")
+		}
+		seen[strings.ToLower(al.Name)] = true
+	}
+	return errs
+}
+
+// validateWindow checks the source, schedule and validity period shared by
+// SSH and database accesses.
+func validateWindow(from, spec, starts, expires *string) error {
+	if !validation.IsValidCIDRs(*from) {
+		return fmt.Errorf("from must be a comma-separated list of valid CIDR notation")
+	}
+	if s := strings.TrimSpace(*spec); s != "" {
+		sched, err := schedule.Parse(s)
+		if err != nil {
+			return fmt.Errorf("invalid schedule: %w", err)
+		}
+		*spec = sched.String()
+	}
+	if err := canonicalTime(starts); err != nil {
+		return fmt.Errorf("starts: %w", err)
+	}
+	if err := canonicalTime(expires); err != nil {
+		return fmt.Errorf("expires: %w", err)
+	}
+	startsAt, _ := parseTime(*starts)
+	expiresAt, _ := parseTime(*expires)
+	if startsAt != nil && expiresAt != nil && !expiresAt.After(*startsAt) {
+		return fmt.Errorf("expires before it starts")
+	}
+	return nil
+}
+
+func isGroupRole(role string) bool {
+	switch role {
+	case models.GroupRoleOwner, models.GroupRoleACLKeeper, models.GroupRoleGatekeeper, models.GroupRoleMember, models.GroupRoleGuest:
+		return true
+	}
+	return false
+}
+
+func (a Access) key() string {
+	return fmt.Sprintf("%s@%s:%d", a.Username, a.Server, a.Port)
+}
+
+func (a DBAccess) key() string {
+	return fmt.Sprintf("%s://%s@%s:%d", a.Protocol, a.Username, a.Host, a.Port)
+}
+
+// parseTime parses a document time: RFC 3339 or YYYY-MM-DD (midnight UTC).
+// An empty value is nil.
+func parseTime(s string) (*time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		if t, err = time.Parse("2006-01-02", s); err != nil {
+			return nil, fmt.Errorf("must be an RFC 3339 time or YYYY-MM-DD")
+		}
+	}
+	return &t, nil
+}
+
+// canonicalTime rewrites a document time in its exported form.
+func canonicalTime(s *string) error {
+	t, err := parseTime(*s)
+	if err != nil {
+		return err
+	}
+	*s = formatTime(t)
+	return nil
+}
+
+// formatTime renders a time the way Export does; nil is empty.
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Export builds the document describing the current database: every group,
+// and every non-system account holding personal accesses or aliases.
+func Export(db *gorm.DB) (*Document, error) {
+	doc := &Document{Groups: []Group{}}
+
+	var groups []models.Group
+	if err := db.Order("name").Find(&groups).Error; err != nil {
+		return nil, fmt.Errorf("list groups: %w", err)
+	}
+	names, err := usernames(db)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
+		o := owner{column: "group_id", id: g.ID, group: true}
+		entry := Group{
+			Name:                  g.Name,
+			MFARequired:           g.MFARequired,
+			JustificationRequired: g.JustificationRequired,
+			JustificationPattern:  g.JustificationPattern,
+		}
+		var members []models.UserGroup
+		if err := db.Where("group_id = ?", g.ID).Find(&members).Error; err != nil {
+			return nil, fmt.Errorf("list members of %s: %w", g.Name, err)
+		}
+		for _, m := range members {
+			if name, ok := names[m.UserID]; ok {
+				entry.Members = append(entry.Members, Member{User: name, Role: m.Role, Expires: formatTime(m.ExpiresAt)})
+			}
+		}
+		sort.Slice(entry.Members, func(i, j int) bool { return entry.Members[i].User < entry.Members[j].User })
+		if entry.Accesses, entry.Aliases, entry.DBAccesses, entry.DBAliases, err = exportTargets(db, o); err != nil {
+			return nil, fmt.Errorf("export group %s: %w", g.Name, err)
+		}
+		doc.Groups = append(doc.Groups, entry)
+	}
+
+	var users []models.User
+	if err := db.Where(internaldb.BoolFalseExpr(db, "system_user")).Order("username").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	for _, u := range users {
+		o := owner{column: "user_id", id: u.ID}
+		entry := User{Name: u.Username}
+		if entry.Accesses, entry.Aliases, entry.DBAccesses, entry.DBAliases, err = exportTargets(db, o); err != nil {
+			return nil, fmt.Errorf("export user %s: %w", u.Username, err)
+		}
+		if len(entry.Accesses)+len(entry.Aliases)+len(entry.DBAccesses)+len(entry.DBAliases) > 0 {
+			doc.Users = append(doc.Users, entry)
+		}
+	}
+	return doc, nil
+}
+
+// exportTargets returns the accesses and aliases of a group or user, sorted
+// by key so that exports diff cleanly.
+func exportTargets(db *gorm.DB, o owner) ([]Access, []Alias, []DBAccess, []DBAlias, error) {
+	accesses, err := o.accesses(db)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	var outAccesses []Access
+	for _, a := range accesses {
+		outAccesses = append(outAccesses, a.Access)
+	}
+	sort.Slice(outAccesses, func(i, j int) bool { return outAccesses[i].key() < outAccesses[j].key() })
+
+	aliases, err := o.aliases(db)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	var outAliases []Alias
+	for _, a := range aliases {
+		outAliases = append(outAliases, a.Alias)
+	}
+	sort.Slice(outAliases, func(i, j int) bool { return outAliases[i].Name < outAliases[j].Name })
+
+	dbAccesses, err := o.dbAccesses(db)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	var outDBAccesses []DBAccess
+	for _, a := range dbAccesses {
+		outDBAccesses = append(outDBAccesses, a.DBAccess)
+	}
+	sort.Slice(outDBAccesses, func(i, j int) bool { return outDBAccesses[i].key() < outDBAccesses[j].key() })
+
+	dbAliases, err := o.dbAliases(db)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	var outDBAliases []DBAlias
+	for _, a := range dbAliases {
+		outDBAliases = append(outDBAliases, a.DBAlias)
+	}
+	sort.Slice(outDBAliases, func(i, j int) bool { return outDBAliases[i].Name < outDBAliases[j].Name })
+
+	return outAccesses, outAliases, outDBAccesses, outDBAliases, nil
+}
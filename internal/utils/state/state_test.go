@@ -0,0 +1,282 @@
+package state
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"goBastion/internal/models"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open test DB: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sql DB: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(
+		&models.User{}, &models.Group{}, &models.UserGroup{}, &models.SelfAccess{}, &models.GroupAccess{},
+		&models.Aliases{}, &models.SelfDBAccess{}, &models.GroupDBAccess{}, &models.DatabaseAlias{},
+		&models.GroupGuestAccess{}, &models.GroupGuestDBAccess{}, &models.GroupInclusion{},
+	); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	for _, name := range []string{"alice", "bob"} {
+		if err := db.Create(&models.User{Username: name, Role: models.RoleUser, Enabled: true}).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	return db
+}
+
+func parse(t *testing.T, doc string) *Document {
+	t.Helper()
+	d, err := Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return d
+}
+
+func planLines(changes []Change) string {
+	var lines []string
+	for _, c := range changes {
+		lines = append(lines, c.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+const opsDoc = `
+groups:
+  - name: ops
+    mfa_required: true
+    members:
+      - user: alice
+        role: owner
+      - user: bob
+        expires: 2099-01-01
+    accesses:
+      - server: 10.0.0.0/24
+        username: root
+        schedule: Mon-Fri 08:00-19:00
+        comment: prod
+    aliases:
+      - name: web
+        host: web1.example
+    db_accesses:
+      - host: db1.example
+        protocol: postgres
+        username: app
+        database: orders
+users:
+  - name: alice
+    accesses:
+      - server: lab.example
+        username: alice
+        port: 2222
+`
+
+func TestApplyCreatesAndExportRoundTrips(t *testing.T) {
+	db := newTestDB(t)
+	doc := parse(t, opsDoc)
+
+	changes, err := Plan(db, doc, Options{})
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if len(changes) != 7 {
+		t.Fatalf("expected 7 planned changes, got:\n%s", planLines(changes))
+	}
+	var groups int64
+	db.Model(&models.Group{}).Count(&groups)
+	if groups != 0 {
+		t.Fatalf("Plan must not write, found %d groups", groups)
+	}
+
+	if _, err := Apply(db, doc, Options{}); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	var access models.GroupAccess
+	if err := db.First(&access).Error; err != nil {
+		t.Fatalf("group access not created: %v", err)
+	}
+	if access.Port != 22 || access.Protocol != "ssh" || access.Comment != "prod" {
+		t.Fatalf("unexpected access %+v", access)
+	}
+	var dbAccess models.GroupDBAccess
+	if err := db.First(&dbAccess).Error; err != nil || dbAccess.Port != 5432 {
+		t.Fatalf("group db access not created with the default port: %+v, %v", dbAccess, err)
+	}
+
+	// A re-applied document and its own export are both no-ops.
+	if changes, err := Plan(db, doc, Options{Prune: true}); err != nil || len(changes) != 0 {
+		t.Fatalf("expected no changes after apply, got %v:\n%s", err, planLines(changes))
+	}
+	exported, err := Export(db)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, exported); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if changes, err := Plan(db, parse(t, buf.String()), Options{Prune: true}); err != nil || len(changes) != 0 {
+		t.Fatalf("export does not round-trip (%v):\n%s\n%s", err, buf.String(), planLines(changes))
+	}
+	if !strings.Contains(buf.String(), "expires: \"2099-01-01T00:00:00Z\"") {
+		t.Fatalf("expected canonical member expiry in export:\n%s", buf.String())
+	}
+}
+
+func TestApplyUpdatesAndPrunes(t *testing.T) {
+	db := newTestDB(t)
+	if _, err := Apply(db, parse(t, opsDoc), Options{}); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	var legacy models.Group
+	if err := db.Create(&models.Group{Name: "legacy"}).Error; err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	db.Where("name = ?", "legacy").First(&legacy)
+	db.Create(&models.GroupAccess{GroupID: legacy.ID, Server: "old.example", Username: "root", Port: 22})
+
+	changed := `
+groups:
+  - name: ops
+    members:
+      - user: alice
+        role: member
+    accesses:
+      - server: 10.0.0.0/24
+        username: root
+        comment: prod
+`
+	doc := parse(t, changed)
+
+	// Without pruning only the listed objects change.
+	changes, err := Plan(db, doc, Options{})
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	plan := planLines(changes)
+	for _, want := range []string{
+		`~ group ops: settings (mfa_required: true → false)`,
+		`~ group ops: member alice (role: "owner" → "member")`,
+		`~ group ops: access root@10.0.0.0/24:22 (schedule: "Mon-Fri 08:00-19:00" → "")`,
+	} {
+		if !strings.Contains(plan, want) {
+			t.Fatalf("plan misses %q:\n%s", want, plan)
+		}
+	}
+	if strings.Contains(plan, "- ") {
+		t.Fatalf("plan without prune must not delete:\n%s", plan)
+	}
+
+	changes, err = Apply(db, doc, Options{Prune: true})
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	plan = planLines(changes)
+	for _, want := range []string{
+		`- group legacy: group with its members, accesses and aliases`,
+		`- group ops: member bob (member)`,
+		`- group ops: alias web`,
+		`- group ops: db access postgres://app@db1.example:5432`,
+	} {
+		if !strings.Contains(plan, want) {
+			t.Fatalf("applied changes miss %q:\n%s", want, plan)
+		}
+	}
+	if strings.Contains(plan, "user alice") {
+		t.Fatalf("users missing from the document must be left alone:\n%s", plan)
+	}
+
+	var count int64
+	db.Model(&models.UserGroup{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("expected 1 membership left, got %d", count)
+	}
+	db.Model(&models.GroupAccess{}).Where("group_id = ?", legacy.ID).Count(&count)
+	if count != 0 {
+		t.Fatalf("accesses of the pruned group should be deleted, got %d", count)
+	}
+	db.Model(&models.SelfAccess{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("personal access of an unlisted user should be kept, got %d", count)
+	}
+}
+
+func TestApplyIsAtomic(t *testing.T) {
+	db := newTestDB(t)
+	doc := parse(t, opsDoc)
+	// The document is valid but the second group collides with a row the
+	// reconciler cannot see, so the transaction must roll back entirely.
+	doc.Groups = append(doc.Groups, Group{Name: "second"})
+	if err := db.Exec("CREATE TRIGGER no_second BEFORE INSERT ON groups WHEN NEW.name = 'second' BEGIN SELECT RAISE(ABORT, 'refused'); END").Error; err != nil {
+		t.Fatalf("create trigger: %v", err)
+	}
+
+	if _, err := Apply(db, doc, Options{}); err == nil {
+		t.Fatal("expected Apply to fail")
+	}
+	var count int64
+	db.Model(&models.Group{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("a failed apply must not leave changes, found %d groups", count)
+	}
+}
+
+func TestParseRejectsInvalidDocuments(t *testing.T) {
+	tests := []struct {
+		name, doc, want string
+	}{
+		{"unknown field", "groups:\n  - name: ops\n    owner: alice\n", "field owner not found"},
+		{"bad role", "groups:\n  - name: ops\n    members:\n      - user: alice\n        role: boss\n", "role must be one of"},
+		{"bad port", "groups:\n  - name: ops\n    accesses:\n      - server: srv\n        username: root\n        port: 70000\n", "port must be between"},
+		{"duplicate access", "groups:\n  - name: ops\n    accesses:\n      - {server: srv, username: root}\n      - {server: srv, username: root, port: 22}\n", "listed twice"},
+		{"bad schedule", "users:\n  - name: alice\n    accesses:\n      - {server: srv, username: root, schedule: whenever}\n", "invalid schedule"},
+		{"expires before start", "users:\n  - name: alice\n    accesses:\n      - {server: srv, username: root, starts: 2030-01-02, expires: 2030-01-01}\n", "expires before it starts"},
+		{"bad group name", "groups:\n  - name: -ops\n", "invalid group name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.doc))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestParseAcceptsJSON(t *testing.T) {
+	doc := parse(t, `{"groups": [{"name": "ops", "db_aliases": [{"name": "orders", "host": "db1", "protocol": "mysql"}]}]}`)
+	if got := doc.Groups[0].DBAliases[0].Port; got != 3306 {
+		t.Fatalf("expected default mysql port, got %d", got)
+	}
+}
+
+func TestPlanRejectsUnknownAccounts(t *testing.T) {
+	db := newTestDB(t)
+	doc := parse(t, "groups:\n  - name: ops\n    members:\n      - user: carol\nusers:\n  - name: dave\n")
+	_, err := Plan(db, doc, Options{})
+	if err == nil || !strings.Contains(err.Error(), `member "carol": no such account`) || !strings.Contains(err.Error(), `user "dave": no such account`) {
+		t.Fatalf("expected unknown account errors, got %v", err)
+	}
+}
+
+func TestFormatTimeIsUTC(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*3600)
+	ts := time.Date(2030, 5, 1, 10, 0, 0, 0, loc)
+	if got := formatTime(&ts); got != "2030-05-01T08:00:00Z" {
+		t.Fatalf("formatTime = %q", got)
+	}
+}
@@ -19,6 +19,10 @@ import (
 	"goBastion/internal/models"
 	"goBastion/internal/osadapter"
 	"goBastion/internal/utils"
+	"goBastion/internal/utils/egresskey"
+	"goBastion/internal/utils/ingressca"
+	"goBastion/internal/utils/keypolicy"
+	"goBastion/internal/utils/revocation"
 	"goBastion/internal/utils/sshHostKey"
 )
 
@@ -145,10 +149,17 @@ func (s *Syncer) IngressKeyFromDB(user models.User) error {
 		}
 	}
 	var keys []models.IngressKey
-	if err := s.db.Where("user_id = ? AND (expires_at IS NULL OR expires_at > ?)", user.ID, time.Now()).Find(&keys).Error; err != nil {
+	if err := s.db.Scopes(revocation.NotRevoked).
+		Where("user_id = ? AND disabled_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", user.ID, time.Now()).
+		Find(&keys).Error; err != nil {
 		return fmt.Errorf("error retrieving keys for %s: %w", user.Username, err)
 	}
 
+	caLines, err := ingressca.AuthorizedKeysLines(s.db, user)
+	if err != nil {
+		return err
+	}
+
 	sshDir := filepath.Join(config.Get().Paths.HomeBaseDir, utils.NormalizeUsername(user.Username), ".ssh")
 	authorizedKeysPath := filepath.Join(sshDir, "authorized_keys")
 
@@ -184,18 +195,28 @@ func (s *Syncer) IngressKeyFromDB(user models.User) error {
 	}
 
 	for _, key := range keys {
+		entry := key.Key
+		if opts := key.AuthorizedKeysOptions(); opts != "" {
+			entry = opts + " " + key.Key
+		}
 		var line string
-		if existing, exists := existingKeys[key.Key]; exists {
+		if existing, exists := existingKeys[entry]; exists {
 			line = existing + "\n"
-			delete(existingKeys, key.Key)
+			delete(existingKeys, entry)
 		} else {
-			line = fmt.Sprintf("%s #ID:%s\n", key.Key, key.ID.String())
+			line = fmt.Sprintf("%s #ID:%s\n", entry, key.ID.String())
 		}
 		if _, err := tmpFile.WriteString(line); err != nil {
 			_ = tmpFile.Close()
 			return fmt.Errorf("error writing key to temp file: %w", err)
 		}
 	}
+	for _, line := range caLines {
+		if _, err := tmpFile.WriteString(line + "\n"); err != nil {
+			_ = tmpFile.Close()
+			return fmt.Errorf("error writing CA to temp file: %w", err)
+		}
+	}
 
 	if err = tmpFile.Close(); err != nil {
 		return fmt.Errorf("error closing temp file: %w", err)
@@ -355,6 +376,26 @@ func (s *Syncer) EnforceFromDB() error {
 		s.log.Error("sync_disable_inactive_failed", slog.Any("error", err))
 	}
 
+	if err := s.removeExpiredMemberships(); err != nil {
+		s.log.Error("sync_expired_memberships_failed", slog.Any("error", err))
+	}
+
+	if err := s.enforceReviewDeadlines(); err != nil {
+		s.log.Error("sync_review_deadlines_failed", slog.Any("error", err))
+	}
+
+	if err := egresskey.Advance(s.db, &s.log, egresskey.DialProbe, time.Now()); err != nil {
+		s.log.Error("sync_egress_key_rotation_failed", slog.Any("error", err))
+	}
+
+	if err := s.enforceIngressKeyPolicy(); err != nil {
+		s.log.Error("sync_ingress_key_policy_failed", slog.Any("error", err))
+	}
+
+	if err := s.enforceRevokedKeys(); err != nil {
+		s.log.Error("sync_revoked_keys_failed", slog.Any("error", err))
+	}
+
 	var dbUsers []models.User
 	if err := s.db.Where(internaldb.BoolFalseExpr(s.db, "system_user")).Find(&dbUsers).Error; err != nil {
 		return fmt.Errorf("[sync] error querying DB users: %w", err)
@@ -444,3 +485,124 @@ func (s *Syncer) disableInactiveUsers() error {
 	}
 	return nil
 }
+
+// removeExpiredMemberships deletes the group memberships whose expiry has
+// passed and drops the cached groups of the affected users.
+func (s *Syncer) removeExpiredMemberships() error {
+	var expired []models.UserGroup
+	if err := s.db.Preload("Group").Preload("User").
+		Where("expires_at IS NOT NULL AND expires_at <= ?", time.Now()).
+		Find(&expired).Error; err != nil {
+		return fmt.Errorf("error querying expired memberships: %w", err)
+	}
+	for _, ug := range expired {
+		if err := s.db.Delete(&ug).Error; err != nil {
+			s.log.Error("sync_expired_membership_remove_failed",
+				slog.String("user", ug.User.Username),
+				slog.String("group", ug.Group.Name),
+				slog.Any("error", err),
+			)
+			continue
+		}
+		models.InvalidateGroupsCache(ug.UserID)
+		s.log.Info("sync_expired_membership_removed",
+			slog.String("user", ug.User.Username),
+			slog.String("group", ug.Group.Name),
+			slog.String("role", ug.Role),
+			slog.Time("expired_at", *ug.ExpiresAt),
+		)
+	}
+	return nil
+}
+
+// enforceIngressKeyPolicy reports the ingress keys that break the ingress key
+// policy and, with disable_violating, disables them. Keys it disabled are
+// re-enabled once they comply or disable_violating is turned off. Accounts
+// over max_keys are only reported, as there is no telling which key to drop.
+func (s *Syncer) enforceIngressKeyPolicy() error {
+	policy := config.Get().IngressKeyPolicy
+	var keys []models.IngressKey
+	if err := s.db.Preload("User").Find(&keys).Error; err != nil {
+		return fmt.Errorf("error querying ingress keys: %w", err)
+	}
+	now := time.Now()
+	active := make(map[string]int)
+	for _, key := range keys {
+		violations := keypolicy.Violations(policy, keypolicy.FromModel(key), key.User.IsAdmin())
+		disable := len(violations) > 0 && policy.DisableViolating
+		switch {
+		case disable && key.DisabledAt == nil:
+			reason := strings.Join(violations, "; ")
+			if err := s.db.Model(&key).Updates(map[string]any{"disabled_at": now, "disabled_reason": reason}).Error; err != nil {
+				return fmt.Errorf("error disabling ingress key %s: %w", key.ID, err)
+			}
+			s.log.Warn("sync_ingress_key_disabled", slog.String("user", key.User.Username),
+				slog.String("key_id", key.ID.String()), slog.String("fingerprint", key.Fingerprint), slog.String("reason", reason))
+		case !disable && key.DisabledAt != nil:
+			if err := s.db.Model(&key).Updates(map[string]any{"disabled_at": nil, "disabled_reason": ""}).Error; err != nil {
+				return fmt.Errorf("error re-enabling ingress key %s: %w", key.ID, err)
+			}
+			s.log.Info("sync_ingress_key_reenabled", slog.String("user", key.User.Username), slog.String("key_id", key.ID.String()))
+		}
+		if disable {
+			continue
+		}
+		if len(violations) > 0 {
+			s.log.Warn("sync_ingress_key_policy_violation", slog.String("user", key.User.Username),
+				slog.String("key_id", key.ID.String()), slog.String("fingerprint", key.Fingerprint),
+				slog.String("reason", strings.Join(violations, "; ")))
+		}
+		if key.ExpiresAt == nil || key.ExpiresAt.After(now) {
+			active[key.User.Username]++
+		}
+	}
+	if policy.MaxKeys > 0 {
+		for username, count := range active {
+			if count > policy.MaxKeys {
+				s.log.Warn("sync_ingress_key_policy_max_keys", slog.String("user", username),
+					slog.Int("keys", count), slog.Int("max_keys", policy.MaxKeys))
+			}
+		}
+	}
+	return nil
+}
+
+// enforceRevokedKeys reports every key row that matches a revoked key, and
+// writes the KRL for sshd when ssh.revoked_keys_file is set. The rows are
+// kept: the authorized_keys sync and egress connections skip them, and they
+// are usable again if the key is taken off the list.
+func (s *Syncer) enforceRevokedKeys() error {
+	var revoked []models.RevokedKey
+	if err := s.db.Find(&revoked).Error; err != nil {
+		return fmt.Errorf("error querying revoked keys: %w", err)
+	}
+	for _, key := range revoked {
+		holders, err := revocation.Holders(s.db, key.Fingerprint)
+		if err != nil {
+			return err
+		}
+		for _, holder := range holders {
+			s.log.Warn("sync_revoked_key_present", slog.String("fingerprint", key.Fingerprint),
+				slog.String("holder", holder), slog.String("reason", key.Reason))
+		}
+	}
+	if path := config.Get().SSH.RevokedKeysFile; path != "" {
+		if err := revocation.WriteKRL(s.db, path); err != nil {
+			return fmt.Errorf("error writing %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// enforceReviewDeadlines closes the review campaigns past their deadline,
+// revoking or flagging the items nobody decided on.
+func (s *Syncer) enforceReviewDeadlines() error {
+	revoked, flagged, err := models.EnforceReviewDeadlines(s.db, time.Now())
+	if revoked > 0 || flagged > 0 {
+		s.log.Warn("sync_review_deadline_enforced",
+			slog.Int("revoked", revoked),
+			slog.Int("flagged", flagged),
+		)
+	}
+	return err
+}
@@ -0,0 +1,48 @@
+package totp
+
+import (
+	"fmt"
+	"log/slog"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/cryptokey"
+
+	"gorm.io/gorm"
+)
+
+// SealSecret returns secret as it is stored in User.TOTPSecret: encrypted
+// when EGRESS_ENC_KEY is set.
+func SealSecret(secret string) (string, error) {
+	sealed, err := cryptokey.ReEncryptIfNeeded(secret)
+	if err != nil {
+		return "", fmt.Errorf("encrypt TOTP secret: %w", err)
+	}
+	return sealed, nil
+}
+
+// VerifyUser checks code against the TOTP secret of user. A secret stored in
+// plaintext before EGRESS_ENC_KEY was set, or with a key listed in
+// EGRESS_ENC_KEY_PREVIOUS, is re-encrypted with the current key on the way.
+func VerifyUser(db *gorm.DB, log *slog.Logger, user *models.User, code string) bool {
+	maybeReEncryptSecret(db, log, user)
+	return Verify(cryptokey.DecryptOrPassThrough(user.TOTPSecret), code)
+}
+
+func maybeReEncryptSecret(db *gorm.DB, log *slog.Logger, user *models.User) {
+	if !cryptokey.Enabled() {
+		return
+	}
+	encrypted, changed, err := cryptokey.Refresh(user.TOTPSecret)
+	if err != nil {
+		log.Warn("re_encrypt_totp_secret", slog.String("user", user.Username), slog.String("error", err.Error()))
+		return
+	}
+	if !changed {
+		return
+	}
+	if err := db.Model(&models.User{}).Where("id = ?", user.ID).Update("totp_secret", encrypted).Error; err != nil {
+		log.Warn("re_encrypt_totp_secret", slog.String("user", user.Username), slog.String("error", err.Error()))
+		return
+	}
+	user.TOTPSecret = encrypted
+}
@@ -0,0 +1,62 @@
+package totp
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"goBastion/internal/models"
+	"goBastion/internal/utils/cryptokey"
+)
+
+func TestMain(m *testing.M) {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	_ = os.Setenv("EGRESS_ENC_KEY", base64.StdEncoding.EncodeToString(key))
+	os.Exit(m.Run())
+}
+
+func TestVerifyUser_ReEncryptsPlaintextSecret(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open test DB: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	secret, _ := GenerateSecret()
+	// A secret enrolled before EGRESS_ENC_KEY was set.
+	user := models.User{Username: "alice", Role: models.RoleUser, Enabled: true, TOTPEnabled: true, TOTPSecret: secret}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	code, _ := GenerateCode(secret, time.Now())
+	if !VerifyUser(db, log, &user, code) {
+		t.Fatal("a valid code must verify against a plaintext secret")
+	}
+	var stored models.User
+	db.First(&stored, "id = ?", user.ID)
+	if !cryptokey.IsEncrypted(stored.TOTPSecret) || stored.TOTPSecret != user.TOTPSecret {
+		t.Fatalf("the secret must be re-encrypted, got %q", stored.TOTPSecret)
+	}
+	if !VerifyUser(db, log, &stored, code) {
+		t.Fatal("a valid code must verify against an encrypted secret")
+	}
+	if VerifyUser(db, log, &stored, "000000") {
+		t.Fatal("a wrong code must not verify")
+	}
+
+	sealed, err := SealSecret(secret)
+	if err != nil || !cryptokey.IsEncrypted(sealed) {
+		t.Fatalf("SealSecret must encrypt, got %q (%v)", sealed, err)
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"net"
 	"regexp"
 	"strings"
+	"time"
+
+	"goBastion/internal/utils/hostmatch"
 )
 
 // EntityNameRegexp matches valid entity names (aliases, group names, realm names, etc.).
@@ -81,6 +84,13 @@ func IsValidHost(h string) bool {
 	return EntityNameRegexp.MatchString(host)
 }
 
+// IsValidServerPattern returns true when s is a valid host (see IsValidHost),
+// a CIDR block such as 10.20.0.0/16, or a hostname glob such as
+// *.db.prod.example. Used for the server of SSH access entries.
+func IsValidServerPattern(s string) bool {
+	return IsValidHost(s) || hostmatch.IsValidPattern(s)
+}
+
 // IsValidUsername returns true when u is a valid Linux username.
 // According to POSIX, usernames must contain only letters, digits,
 // underscores, hyphens, periods, and at signs, and must not start with a hyphen,
@@ -145,6 +155,21 @@ func IsValidCIDRs(cidrs string) bool {
 	return true
 }
 
+// NormalizeCIDRs validates a comma-separated CIDR list and returns it
+// without blanks or empty entries.
+func NormalizeCIDRs(cidrs string) (string, error) {
+	if !IsValidCIDRs(cidrs) {
+		return "", fmt.Errorf("must be a comma-separated list of valid CIDR notation (e.g. 10.0.0.0/8,192.168.1.0/24)")
+	}
+	var list []string
+	for _, c := range strings.Split(cidrs, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			list = append(list, c)
+		}
+	}
+	return strings.Join(list, ","), nil
+}
+
 // IsValidPort returns true when port is in the valid TCP/UDP range 1-65535.
 func IsValidPort(port int64) bool {
 	return port >= 1 && port <= 65535
@@ -165,3 +190,25 @@ func IsPrivateOrReservedTarget(server string) bool {
 	}
 	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
 }
+
+// ParseStartsAt parses an access --starts value: an RFC 3339 time, or a
+// local "YYYY-MM-DD HH:MM" or "YYYY-MM-DD" (midnight). An empty value means
+// the access is usable immediately; otherwise the start must be after now.
+func ParseStartsAt(s string, now time.Time) (*time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		if t, err = time.ParseInLocation("2006-01-02 15:04", s, time.Local); err != nil {
+			if t, err = time.ParseInLocation("2006-01-02", s, time.Local); err != nil {
+				return nil, fmt.Errorf("--starts must be an RFC 3339 time, \"YYYY-MM-DD HH:MM\" or YYYY-MM-DD")
+			}
+		}
+	}
+	if !t.After(now) {
+		return nil, fmt.Errorf("--starts must be in the future")
+	}
+	return &t, nil
+}
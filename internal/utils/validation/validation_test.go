@@ -2,6 +2,7 @@ package validation_test
 
 import (
 	"testing"
+	"time"
 
 	"goBastion/internal/utils/validation"
 )
@@ -47,3 +48,48 @@ func TestIsValidProtocol(t *testing.T) {
 		}
 	}
 }
+
+func TestIsValidServerPattern(t *testing.T) {
+	tests := []struct {
+		server string
+		want   bool
+	}{
+		{"example.com", true},
+		{"10.20.0.0/16", true},
+		{"*.db.prod.example", true},
+		{"*", false},
+		{"host/path", false},
+		{"*.exa@mple.com", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.server, func(t *testing.T) {
+			if got := validation.IsValidServerPattern(tc.server); got != tc.want {
+				t.Errorf("IsValidServerPattern(%q) = %v, want %v", tc.server, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseStartsAt(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.Local)
+
+	if got, err := validation.ParseStartsAt("", now); err != nil || got != nil {
+		t.Fatalf("empty value: got %v, %v; want nil, nil", got, err)
+	}
+	got, err := validation.ParseStartsAt("2026-05-11 08:30", now)
+	if err != nil || !got.Equal(time.Date(2026, 5, 11, 8, 30, 0, 0, time.Local)) {
+		t.Fatalf("local time: got %v, %v", got, err)
+	}
+	got, err = validation.ParseStartsAt("2026-06-01", now)
+	if err != nil || !got.Equal(time.Date(2026, 6, 1, 0, 0, 0, 0, time.Local)) {
+		t.Fatalf("date: got %v, %v", got, err)
+	}
+	if _, err := validation.ParseStartsAt("2026-05-12T09:00:00Z", now); err != nil {
+		t.Fatalf("RFC 3339: unexpected error %v", err)
+	}
+	for _, bad := range []string{"2026-05-10 11:59", "2026-05-01", "tomorrow", "12/05/2026"} {
+		if _, err := validation.ParseStartsAt(bad, now); err == nil {
+			t.Errorf("ParseStartsAt(%q) should fail", bad)
+		}
+	}
+}
@@ -10,6 +10,7 @@ fi
 DB_DRIVER_VALUE=""
 DB_DSN_VALUE=""
 EGRESS_ENC_KEY_VALUE=""
+EGRESS_ENC_KEY_PREVIOUS_VALUE=""
 INSTANCE_ID_VALUE=""
 
 while IFS= read -r line || [ -n "$line" ]; do
@@ -19,6 +20,7 @@ while IFS= read -r line || [ -n "$line" ]; do
     DB_DRIVER) DB_DRIVER_VALUE=$value ;;
     DB_DSN) DB_DSN_VALUE=$value ;;
     EGRESS_ENC_KEY) EGRESS_ENC_KEY_VALUE=$value ;;
+    EGRESS_ENC_KEY_PREVIOUS) EGRESS_ENC_KEY_PREVIOUS_VALUE=$value ;;
     INSTANCE_ID) INSTANCE_ID_VALUE=$value ;;
   esac
 done < /run/gobastion/db.conf
@@ -28,12 +30,14 @@ if [ -n "$original_command" ]; then
   exec /sbin/su-exec "$SUDO_USER" /usr/bin/env \
     "HOME=/home/$SUDO_USER" "USER=$SUDO_USER" "LOGNAME=$SUDO_USER" \
     "DB_DRIVER=$DB_DRIVER_VALUE" "DB_DSN=$DB_DSN_VALUE" \
-    "EGRESS_ENC_KEY=$EGRESS_ENC_KEY_VALUE" "INSTANCE_ID=$INSTANCE_ID_VALUE" \
+    "EGRESS_ENC_KEY=$EGRESS_ENC_KEY_VALUE" "EGRESS_ENC_KEY_PREVIOUS=$EGRESS_ENC_KEY_PREVIOUS_VALUE" \
+    "INSTANCE_ID=$INSTANCE_ID_VALUE" \
     /app/goBastion "$original_command"
 fi
 
 exec /sbin/su-exec "$SUDO_USER" /usr/bin/env \
   "HOME=/home/$SUDO_USER" "USER=$SUDO_USER" "LOGNAME=$SUDO_USER" \
   "DB_DRIVER=$DB_DRIVER_VALUE" "DB_DSN=$DB_DSN_VALUE" \
-  "EGRESS_ENC_KEY=$EGRESS_ENC_KEY_VALUE" "INSTANCE_ID=$INSTANCE_ID_VALUE" \
+  "EGRESS_ENC_KEY=$EGRESS_ENC_KEY_VALUE" "EGRESS_ENC_KEY_PREVIOUS=$EGRESS_ENC_KEY_PREVIOUS_VALUE" \
+  "INSTANCE_ID=$INSTANCE_ID_VALUE" \
   /app/goBastion
@@ -16,6 +16,7 @@ fi
 DB_DRIVER_VALUE=""
 DB_DSN_VALUE=""
 EGRESS_ENC_KEY_VALUE=""
+EGRESS_ENC_KEY_PREVIOUS_VALUE=""
 INSTANCE_ID_VALUE=""
 while IFS= read -r line || [ -n "$line" ]; do
   key=${line%%=*}
@@ -24,11 +25,13 @@ while IFS= read -r line || [ -n "$line" ]; do
     DB_DRIVER) DB_DRIVER_VALUE=$value ;;
     DB_DSN) DB_DSN_VALUE=$value ;;
     EGRESS_ENC_KEY) EGRESS_ENC_KEY_VALUE=$value ;;
+    EGRESS_ENC_KEY_PREVIOUS) EGRESS_ENC_KEY_PREVIOUS_VALUE=$value ;;
     INSTANCE_ID) INSTANCE_ID_VALUE=$value ;;
   esac
 done < /run/gobastion/db.conf
 
 exec /usr/bin/env \
   "DB_DRIVER=$DB_DRIVER_VALUE" "DB_DSN=$DB_DSN_VALUE" \
-  "EGRESS_ENC_KEY=$EGRESS_ENC_KEY_VALUE" "INSTANCE_ID=$INSTANCE_ID_VALUE" \
+  "EGRESS_ENC_KEY=$EGRESS_ENC_KEY_VALUE" "EGRESS_ENC_KEY_PREVIOUS=$EGRESS_ENC_KEY_PREVIOUS_VALUE" \
+  "INSTANCE_ID=$INSTANCE_ID_VALUE" \
   /app/goBastion --syncUser "$user"
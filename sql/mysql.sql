@@ -56,12 +56,14 @@ CREATE TABLE IF NOT EXISTS users (
 
 -- ── groups ───────────────────────────────────────────────────────────────────
 CREATE TABLE IF NOT EXISTS `groups` (
-    id           varchar(36) NOT NULL PRIMARY KEY,
-    name         longtext NOT NULL,
-    mfa_required tinyint(1) NOT NULL DEFAULT 0,
-    created_at   datetime,
-    updated_at   datetime,
-    deleted_at   datetime,
+    id                     varchar(36) NOT NULL PRIMARY KEY,
+    name                   longtext NOT NULL,
+    mfa_required           tinyint(1) NOT NULL DEFAULT 0,
+    justification_required tinyint(1) NOT NULL DEFAULT 0,
+    justification_pattern  longtext,
+    created_at             datetime,
+    updated_at             datetime,
+    deleted_at             datetime,
     UNIQUE KEY idx_groupname_deletedat (name(255), deleted_at),
     KEY idx_groups_deleted_at (deleted_at)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -72,10 +74,12 @@ CREATE TABLE IF NOT EXISTS user_groups (
     user_id    varchar(36) NOT NULL,
     group_id   varchar(36) NOT NULL,
     role       longtext NOT NULL,
+    expires_at datetime,
     created_at datetime,
     updated_at datetime,
     deleted_at datetime,
     KEY idx_user_groups_user_id (user_id),
+    KEY idx_user_groups_expires_at (expires_at),
     KEY idx_user_groups_group_id (group_id),
     KEY idx_user_groups_deleted_at (deleted_at),
     KEY idx_user_group_lookup (user_id, group_id),
@@ -94,6 +98,11 @@ CREATE TABLE IF NOT EXISTS ingress_keys (
     comment      longtext,
     expires_at   datetime,
     piv_attested tinyint(1) NOT NULL DEFAULT 0,
+    allowed_from longtext,
+    no_port_forwarding  tinyint(1) NOT NULL DEFAULT 0,
+    no_agent_forwarding tinyint(1) NOT NULL DEFAULT 0,
+    disabled_at  datetime,
+    disabled_reason longtext,
     created_at   datetime,
     updated_at   datetime,
     deleted_at   datetime,
@@ -128,10 +137,14 @@ CREATE TABLE IF NOT EXISTS group_egress_keys (
     type        longtext NOT NULL,
     size        bigint NOT NULL,
     fingerprint longtext NOT NULL,
+    state       varchar(191) NOT NULL DEFAULT 'active',
+    state_changed_at datetime,
+    retire_after datetime,
     created_at  datetime,
     updated_at  datetime,
     deleted_at  datetime,
     KEY idx_group_egress_keys_group_id (group_id),
+    KEY idx_group_egress_keys_state (state),
     KEY idx_group_egress_keys_deleted_at (deleted_at),
     CONSTRAINT fk_group_egress_keys_group FOREIGN KEY (group_id) REFERENCES `groups`(id) ON DELETE CASCADE
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -146,6 +159,8 @@ CREATE TABLE IF NOT EXISTS self_accesses (
     protocol        longtext NOT NULL DEFAULT 'ssh',
     comment         longtext,
     allowed_from    longtext,
+    schedule        longtext,
+    starts_at       datetime,
     expires_at      datetime,
     last_connection datetime,
     created_at      datetime,
@@ -167,12 +182,16 @@ CREATE TABLE IF NOT EXISTS group_accesses (
     protocol        longtext NOT NULL DEFAULT 'ssh',
     comment         longtext,
     allowed_from    longtext,
+    schedule        longtext,
+    starts_at       datetime,
     expires_at      datetime,
+    member_id       varchar(36),
     last_connection datetime,
     created_at      datetime,
     updated_at      datetime,
     deleted_at      datetime,
     KEY idx_group_accesses_group_id (group_id),
+    KEY idx_group_accesses_member_id (member_id),
     KEY idx_group_accesses_deleted_at (deleted_at),
     KEY idx_group_access_lookup (group_id, server(191), port, username(191), protocol(32)),
     CONSTRAINT fk_group_accesses_group FOREIGN KEY (group_id) REFERENCES `groups`(id) ON DELETE CASCADE
@@ -191,6 +210,8 @@ CREATE TABLE IF NOT EXISTS group_guest_accesses (
     protocol      longtext NOT NULL DEFAULT 'ssh',
     comment       longtext,
     allowed_from  longtext,
+    schedule      longtext,
+    starts_at     datetime,
     expires_at    datetime,
     created_at    datetime,
     updated_at    datetime,
@@ -233,6 +254,8 @@ CREATE TABLE IF NOT EXISTS self_db_accesses (
     `database`      longtext,
     comment         longtext,
     allowed_from    longtext,
+    schedule        longtext,
+    starts_at       datetime,
     expires_at      datetime,
     last_connection datetime,
     created_at      datetime,
@@ -258,6 +281,8 @@ CREATE TABLE IF NOT EXISTS group_db_accesses (
     `database`      longtext,
     comment         longtext,
     allowed_from    longtext,
+    schedule        longtext,
+    starts_at       datetime,
     expires_at      datetime,
     last_connection datetime,
     created_at      datetime,
@@ -286,6 +311,8 @@ CREATE TABLE IF NOT EXISTS group_guest_db_accesses (
     `database`   longtext,
     comment      longtext,
     allowed_from longtext,
+    schedule     longtext,
+    starts_at    datetime,
     expires_at   datetime,
     created_at   datetime,
     updated_at   datetime,
@@ -383,6 +410,243 @@ CREATE TABLE IF NOT EXISTS restricted_command_grants (
     CONSTRAINT fk_restricted_command_grants_granted_by FOREIGN KEY (granted_by_id) REFERENCES users(id)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
 
+-- ── access_requests ──────────────────────────────────────────────────────────
+-- Just-in-time access requests, decided by group gatekeepers, aclkeepers
+-- and owners. Approval creates a time-boxed guest or group access.
+CREATE TABLE IF NOT EXISTS access_requests (
+    id                varchar(36) NOT NULL PRIMARY KEY,
+    group_id          varchar(36) NOT NULL,
+    requester_id      varchar(36) NOT NULL,
+    username          longtext NOT NULL,
+    server            longtext NOT NULL,
+    port              bigint NOT NULL,
+    protocol          longtext NOT NULL DEFAULT 'ssh',
+    duration_seconds  bigint NOT NULL,
+    reason            longtext NOT NULL,
+    status            varchar(191) NOT NULL DEFAULT 'pending',
+    decider_id        varchar(36),
+    decision_comment  longtext,
+    decided_at        datetime,
+    grant_type        longtext,
+    grant_id          varchar(36),
+    grant_expires_at  datetime,
+    created_at        datetime,
+    updated_at        datetime,
+    deleted_at        datetime,
+    KEY idx_access_requests_group_id (group_id),
+    KEY idx_access_requests_requester_id (requester_id),
+    KEY idx_access_requests_status (status),
+    KEY idx_access_requests_deleted_at (deleted_at),
+    CONSTRAINT fk_access_requests_group FOREIGN KEY (group_id) REFERENCES `groups`(id) ON DELETE CASCADE,
+    CONSTRAINT fk_access_requests_requester FOREIGN KEY (requester_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ── access_request_events ────────────────────────────────────────────────────
+-- Append-only audit trail of every step of an access request.
+CREATE TABLE IF NOT EXISTS access_request_events (
+    id          varchar(36) NOT NULL PRIMARY KEY,
+    request_id  varchar(36) NOT NULL,
+    actor_id    varchar(36) NOT NULL,
+    action      longtext NOT NULL,
+    comment     longtext,
+    created_at  datetime,
+    KEY idx_access_request_events_request_id (request_id),
+    KEY idx_access_request_events_actor_id (actor_id),
+    CONSTRAINT fk_access_request_events_request FOREIGN KEY (request_id) REFERENCES access_requests(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ── break_glasses ────────────────────────────────────────────────────────────
+-- Emergency (break-glass) accesses declared by restricted-grant holders.
+-- Each record must be acknowledged by an admin (acknowledged_at).
+CREATE TABLE IF NOT EXISTS break_glasses (
+    id                  varchar(36) NOT NULL PRIMARY KEY,
+    user_id             varchar(36) NOT NULL,
+    server              longtext NOT NULL,
+    port                bigint NOT NULL,
+    username            longtext,
+    reason              longtext NOT NULL,
+    client_ip           longtext,
+    expires_at          datetime NOT NULL,
+    acknowledged_by_id  varchar(36),
+    acknowledged_at     datetime,
+    ack_comment         longtext,
+    created_at          datetime,
+    updated_at          datetime,
+    deleted_at          datetime,
+    KEY idx_break_glasses_user_id (user_id),
+    KEY idx_break_glasses_expires_at (expires_at),
+    KEY idx_break_glasses_acknowledged_at (acknowledged_at),
+    KEY idx_break_glasses_deleted_at (deleted_at),
+    CONSTRAINT fk_break_glasses_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ── group_inclusions ─────────────────────────────────────────────────────────
+-- Nested groups: members of child_id inherit the accesses of parent_id.
+CREATE TABLE IF NOT EXISTS group_inclusions (
+    id         varchar(36) NOT NULL PRIMARY KEY,
+    parent_id  varchar(36) NOT NULL,
+    child_id   varchar(36) NOT NULL,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    KEY idx_group_inclusions_parent_id (parent_id),
+    KEY idx_group_inclusions_child_id (child_id),
+    KEY idx_group_inclusions_deleted_at (deleted_at),
+    CONSTRAINT fk_group_inclusions_parent FOREIGN KEY (parent_id) REFERENCES `groups`(id),
+    CONSTRAINT fk_group_inclusions_child FOREIGN KEY (child_id) REFERENCES `groups`(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ── custom_roles ─────────────────────────────────────────────────────────────
+-- Administrator-defined permission sets consulted by CanDo on top of built-in roles.
+CREATE TABLE IF NOT EXISTS custom_roles (
+    id          varchar(36) NOT NULL PRIMARY KEY,
+    name        longtext NOT NULL,
+    description longtext,
+    permissions longtext NOT NULL,
+    created_at  datetime,
+    updated_at  datetime,
+    deleted_at  datetime,
+    UNIQUE KEY idx_customrolename_deletedat (name(255), deleted_at),
+    KEY idx_custom_roles_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ── custom_role_assignments ──────────────────────────────────────────────────
+-- group_id NULL: the role applies to every target; otherwise to that group only.
+CREATE TABLE IF NOT EXISTS custom_role_assignments (
+    id            varchar(36) NOT NULL PRIMARY KEY,
+    role_id       varchar(36) NOT NULL,
+    user_id       varchar(36) NOT NULL,
+    group_id      varchar(36),
+    granted_by_id varchar(36) NOT NULL,
+    created_at    datetime,
+    updated_at    datetime,
+    deleted_at    datetime,
+    KEY idx_custom_role_assignments_role_id (role_id),
+    KEY idx_custom_role_assignments_user_id (user_id),
+    KEY idx_custom_role_assignments_group_id (group_id),
+    KEY idx_custom_role_assignments_deleted_at (deleted_at),
+    CONSTRAINT fk_custom_role_assignments_role FOREIGN KEY (role_id) REFERENCES custom_roles(id),
+    CONSTRAINT fk_custom_role_assignments_user FOREIGN KEY (user_id) REFERENCES users(id),
+    CONSTRAINT fk_custom_role_assignments_group FOREIGN KEY (group_id) REFERENCES `groups`(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ── hosts ────────────────────────────────────────────────────────────────────
+-- Server inventory; group accesses with server 'tag:k=v,...' select hosts by tag.
+CREATE TABLE IF NOT EXISTS hosts (
+    id             varchar(36) NOT NULL PRIMARY KEY,
+    name           longtext NOT NULL,
+    addresses      longtext NOT NULL,
+    tags           longtext,
+    owner_group_id varchar(36),
+    description    longtext,
+    created_at     datetime,
+    updated_at     datetime,
+    deleted_at     datetime,
+    UNIQUE KEY idx_hostname_deletedat (name(255), deleted_at),
+    KEY idx_hosts_owner_group_id (owner_group_id),
+    KEY idx_hosts_deleted_at (deleted_at),
+    CONSTRAINT fk_hosts_owner_group FOREIGN KEY (owner_group_id) REFERENCES `groups`(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ── review_campaigns ─────────────────────────────────────────────────────────
+-- Access recertification campaigns; undecided items are revoked or flagged
+-- by the sync cycle once the deadline has passed.
+CREATE TABLE IF NOT EXISTS review_campaigns (
+    id             varchar(36) NOT NULL PRIMARY KEY,
+    name           longtext NOT NULL,
+    description    longtext,
+    deadline       datetime NOT NULL,
+    on_expiry      longtext NOT NULL DEFAULT 'flag',
+    status         varchar(191) NOT NULL DEFAULT 'open',
+    created_by_id  varchar(36) NOT NULL,
+    closed_at      datetime,
+    created_at     datetime,
+    updated_at     datetime,
+    deleted_at     datetime,
+    UNIQUE KEY idx_reviewcampaignname_deletedat (name(255), deleted_at),
+    KEY idx_review_campaigns_status (status)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ── review_items ─────────────────────────────────────────────────────────────
+-- One membership, group access or guest grant under review in a campaign.
+CREATE TABLE IF NOT EXISTS review_items (
+    id           varchar(36) NOT NULL PRIMARY KEY,
+    campaign_id  varchar(36) NOT NULL,
+    group_id     varchar(36) NOT NULL,
+    kind         longtext NOT NULL,
+    subject_id   varchar(36) NOT NULL,
+    summary      longtext NOT NULL,
+    status       varchar(191) NOT NULL DEFAULT 'pending',
+    decider_id   varchar(36),
+    comment      longtext,
+    decided_at   datetime,
+    created_at   datetime,
+    updated_at   datetime,
+    KEY idx_review_items_campaign_id (campaign_id),
+    KEY idx_review_items_group_id (group_id),
+    KEY idx_review_items_status (status),
+    CONSTRAINT fk_review_items_campaign FOREIGN KEY (campaign_id) REFERENCES review_campaigns(id) ON DELETE CASCADE,
+    CONSTRAINT fk_review_items_group FOREIGN KEY (group_id) REFERENCES `groups`(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ── group_egress_key_verifications ───────────────────────────────────────────
+-- Targets on which a next group egress key has authenticated.
+CREATE TABLE IF NOT EXISTS group_egress_key_verifications (
+    id          varchar(36) NOT NULL PRIMARY KEY,
+    key_id      varchar(36) NOT NULL,
+    server      longtext NOT NULL,
+    port        bigint NOT NULL,
+    username    longtext NOT NULL,
+    verified_at datetime NOT NULL,
+    created_at  datetime,
+    KEY idx_group_egress_key_verifications_key_id (key_id),
+    CONSTRAINT fk_group_egress_key_verifications_key FOREIGN KEY (key_id) REFERENCES group_egress_keys(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ── cert_authorities ─────────────────────────────────────────────────────────
+-- SSH certificate authority keys of the bastion.
+CREATE TABLE IF NOT EXISTS cert_authorities (
+    id          varchar(36) NOT NULL PRIMARY KEY,
+    kind        varchar(191) NOT NULL,
+    state       varchar(191) NOT NULL,
+    public_key  longtext NOT NULL,
+    private_key longtext NOT NULL,
+    fingerprint longtext NOT NULL,
+    created_at  datetime,
+    updated_at  datetime,
+    KEY idx_cert_authorities_kind (kind),
+    KEY idx_cert_authorities_state (state)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ── ingress_cas ──────────────────────────────────────────────────────────────
+-- SSH user CAs whose certificates are accepted to log in to the bastion.
+CREATE TABLE IF NOT EXISTS ingress_cas (
+    id          varchar(36) NOT NULL PRIMARY KEY,
+    name        varchar(191) NOT NULL,
+    public_key  longtext NOT NULL,
+    fingerprint longtext NOT NULL,
+    principals  longtext NOT NULL,
+    added_by_id varchar(36) NOT NULL,
+    created_at  datetime,
+    updated_at  datetime,
+    UNIQUE KEY idx_ingress_cas_name (name),
+    CONSTRAINT fk_ingress_cas_user FOREIGN KEY (added_by_id) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ── revoked_keys ─────────────────────────────────────────────────────────────
+-- SSH public keys revoked bastion-wide, by base64 SHA256 fingerprint.
+CREATE TABLE IF NOT EXISTS revoked_keys (
+    id          varchar(36) NOT NULL PRIMARY KEY,
+    fingerprint varchar(191) NOT NULL,
+    public_key  longtext,
+    reason      longtext NOT NULL,
+    added_by_id varchar(36) NOT NULL,
+    created_at  datetime,
+    updated_at  datetime,
+    UNIQUE KEY idx_revoked_keys_fingerprint (fingerprint),
+    CONSTRAINT fk_revoked_keys_user FOREIGN KEY (added_by_id) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
 -- ── Done ─────────────────────────────────────────────────────────────────────
 -- Grant the goBastion app user minimal privileges:
 --   GRANT SELECT, INSERT, UPDATE, DELETE ON gobastion.* TO 'gobastion'@'%';
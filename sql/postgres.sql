@@ -59,12 +59,14 @@ CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
 
 -- ── groups ───────────────────────────────────────────────────────────────────
 CREATE TABLE IF NOT EXISTS groups (
-    id           uuid PRIMARY KEY,
-    name         text NOT NULL,
-    mfa_required boolean NOT NULL DEFAULT false,
-    created_at   timestamptz,
-    updated_at   timestamptz,
-    deleted_at   timestamptz
+    id                     uuid PRIMARY KEY,
+    name                   text NOT NULL,
+    mfa_required           boolean NOT NULL DEFAULT false,
+    justification_required boolean NOT NULL DEFAULT false,
+    justification_pattern  text,
+    created_at             timestamptz,
+    updated_at             timestamptz,
+    deleted_at             timestamptz
 );
 CREATE UNIQUE INDEX IF NOT EXISTS idx_groupname_deletedat ON groups (name, deleted_at);
 CREATE INDEX IF NOT EXISTS idx_groups_deleted_at ON groups (deleted_at);
@@ -75,11 +77,13 @@ CREATE TABLE IF NOT EXISTS user_groups (
     user_id    uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     group_id   uuid NOT NULL REFERENCES groups(id),
     role       text NOT NULL,
+    expires_at timestamptz,
     created_at timestamptz,
     updated_at timestamptz,
     deleted_at timestamptz
 );
 CREATE INDEX IF NOT EXISTS idx_user_groups_user_id ON user_groups (user_id);
+CREATE INDEX IF NOT EXISTS idx_user_groups_expires_at ON user_groups (expires_at);
 CREATE INDEX IF NOT EXISTS idx_user_groups_group_id ON user_groups (group_id);
 CREATE INDEX IF NOT EXISTS idx_user_groups_deleted_at ON user_groups (deleted_at);
 CREATE INDEX IF NOT EXISTS idx_user_group_lookup ON user_groups (user_id, group_id) WHERE deleted_at IS NULL;
@@ -95,6 +99,11 @@ CREATE TABLE IF NOT EXISTS ingress_keys (
     comment      text,
     expires_at   timestamptz,
     piv_attested boolean NOT NULL DEFAULT false,
+    allowed_from text,
+    no_port_forwarding  boolean NOT NULL DEFAULT false,
+    no_agent_forwarding boolean NOT NULL DEFAULT false,
+    disabled_at  timestamptz,
+    disabled_reason text,
     created_at   timestamptz,
     updated_at   timestamptz,
     deleted_at   timestamptz
@@ -127,11 +136,15 @@ CREATE TABLE IF NOT EXISTS group_egress_keys (
     type        text NOT NULL,
     size        integer NOT NULL,
     fingerprint text NOT NULL,
+    state       text NOT NULL DEFAULT 'active',
+    state_changed_at timestamptz,
+    retire_after timestamptz,
     created_at  timestamptz,
     updated_at  timestamptz,
     deleted_at  timestamptz
 );
 CREATE INDEX IF NOT EXISTS idx_group_egress_keys_group_id ON group_egress_keys (group_id);
+CREATE INDEX IF NOT EXISTS idx_group_egress_keys_state ON group_egress_keys (state);
 CREATE INDEX IF NOT EXISTS idx_group_egress_keys_deleted_at ON group_egress_keys (deleted_at);
 
 -- ── self_accesses ────────────────────────────────────────────────────────────
@@ -144,6 +157,8 @@ CREATE TABLE IF NOT EXISTS self_accesses (
     protocol       text NOT NULL DEFAULT 'ssh',
     comment        text,
     allowed_from   text,
+    schedule       text,
+    starts_at      timestamptz,
     expires_at     timestamptz,
     last_connection timestamptz,
     created_at     timestamptz,
@@ -164,13 +179,17 @@ CREATE TABLE IF NOT EXISTS group_accesses (
     protocol        text NOT NULL DEFAULT 'ssh',
     comment         text,
     allowed_from    text,
+    schedule        text,
+    starts_at       timestamptz,
     expires_at      timestamptz,
+    member_id       uuid,
     last_connection timestamptz,
     created_at      timestamptz,
     updated_at      timestamptz,
     deleted_at      timestamptz
 );
 CREATE INDEX IF NOT EXISTS idx_group_accesses_group_id ON group_accesses (group_id);
+CREATE INDEX IF NOT EXISTS idx_group_accesses_member_id ON group_accesses (member_id);
 CREATE INDEX IF NOT EXISTS idx_group_accesses_deleted_at ON group_accesses (deleted_at);
 CREATE INDEX IF NOT EXISTS idx_group_access_lookup ON group_accesses (group_id, server, port, username, protocol) WHERE deleted_at IS NULL;
 
@@ -187,6 +206,8 @@ CREATE TABLE IF NOT EXISTS group_guest_accesses (
     protocol      text NOT NULL DEFAULT 'ssh',
     comment       text,
     allowed_from  text,
+    schedule      text,
+    starts_at     timestamptz,
     expires_at    timestamptz,
     created_at    timestamptz,
     updated_at    timestamptz,
@@ -225,6 +246,8 @@ CREATE TABLE IF NOT EXISTS self_db_accesses (
     "database"      text,
     comment         text,
     allowed_from    text,
+    schedule        text,
+    starts_at       timestamptz,
     expires_at      timestamptz,
     last_connection timestamptz,
     created_at      timestamptz,
@@ -249,6 +272,8 @@ CREATE TABLE IF NOT EXISTS group_db_accesses (
     "database"      text,
     comment         text,
     allowed_from    text,
+    schedule        text,
+    starts_at       timestamptz,
     expires_at      timestamptz,
     last_connection timestamptz,
     created_at      timestamptz,
@@ -276,6 +301,8 @@ CREATE TABLE IF NOT EXISTS group_guest_db_accesses (
     "database"   text,
     comment      text,
     allowed_from text,
+    schedule     text,
+    starts_at    timestamptz,
     expires_at   timestamptz,
     created_at   timestamptz,
     updated_at   timestamptz,
@@ -365,6 +392,228 @@ CREATE TABLE IF NOT EXISTS restricted_command_grants (
 CREATE UNIQUE INDEX IF NOT EXISTS idx_user_command_grant ON restricted_command_grants (user_id, command, deleted_at);
 CREATE INDEX IF NOT EXISTS idx_restricted_command_grants_deleted_at ON restricted_command_grants (deleted_at);
 
+-- ── access_requests ──────────────────────────────────────────────────────────
+-- Just-in-time access requests, decided by group gatekeepers, aclkeepers
+-- and owners. Approval creates a time-boxed guest or group access.
+CREATE TABLE IF NOT EXISTS access_requests (
+    id                uuid PRIMARY KEY,
+    group_id          uuid NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    requester_id      uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    username          text NOT NULL,
+    server            text NOT NULL,
+    port              bigint NOT NULL,
+    protocol          text NOT NULL DEFAULT 'ssh',
+    duration_seconds  bigint NOT NULL,
+    reason            text NOT NULL,
+    status            text NOT NULL DEFAULT 'pending',
+    decider_id        uuid,
+    decision_comment  text,
+    decided_at        timestamptz,
+    grant_type        text,
+    grant_id          uuid,
+    grant_expires_at  timestamptz,
+    created_at        timestamptz,
+    updated_at        timestamptz,
+    deleted_at        timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_access_requests_group_id ON access_requests (group_id);
+CREATE INDEX IF NOT EXISTS idx_access_requests_requester_id ON access_requests (requester_id);
+CREATE INDEX IF NOT EXISTS idx_access_requests_status ON access_requests (status);
+CREATE INDEX IF NOT EXISTS idx_access_requests_deleted_at ON access_requests (deleted_at);
+
+-- ── access_request_events ────────────────────────────────────────────────────
+-- Append-only audit trail of every step of an access request.
+CREATE TABLE IF NOT EXISTS access_request_events (
+    id          uuid PRIMARY KEY,
+    request_id  uuid NOT NULL REFERENCES access_requests(id) ON DELETE CASCADE,
+    actor_id    uuid NOT NULL,
+    action      text NOT NULL,
+    comment     text,
+    created_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_access_request_events_request_id ON access_request_events (request_id);
+CREATE INDEX IF NOT EXISTS idx_access_request_events_actor_id ON access_request_events (actor_id);
+
+-- ── break_glasses ────────────────────────────────────────────────────────────
+-- Emergency (break-glass) accesses declared by restricted-grant holders.
+-- Each record must be acknowledged by an admin (acknowledged_at).
+CREATE TABLE IF NOT EXISTS break_glasses (
+    id                  uuid PRIMARY KEY,
+    user_id             uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    server              text NOT NULL,
+    port                bigint NOT NULL,
+    username            text,
+    reason              text NOT NULL,
+    client_ip           text,
+    expires_at          timestamptz NOT NULL,
+    acknowledged_by_id  uuid,
+    acknowledged_at     timestamptz,
+    ack_comment         text,
+    created_at          timestamptz,
+    updated_at          timestamptz,
+    deleted_at          timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_break_glasses_user_id ON break_glasses (user_id);
+CREATE INDEX IF NOT EXISTS idx_break_glasses_expires_at ON break_glasses (expires_at);
+CREATE INDEX IF NOT EXISTS idx_break_glasses_acknowledged_at ON break_glasses (acknowledged_at);
+CREATE INDEX IF NOT EXISTS idx_break_glasses_deleted_at ON break_glasses (deleted_at);
+
+-- ── group_inclusions ─────────────────────────────────────────────────────────
+-- Nested groups: members of child_id inherit the accesses of parent_id.
+CREATE TABLE IF NOT EXISTS group_inclusions (
+    id         uuid PRIMARY KEY,
+    parent_id  uuid NOT NULL REFERENCES groups(id),
+    child_id   uuid NOT NULL REFERENCES groups(id),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_group_inclusions_parent_id ON group_inclusions (parent_id);
+CREATE INDEX IF NOT EXISTS idx_group_inclusions_child_id ON group_inclusions (child_id);
+CREATE INDEX IF NOT EXISTS idx_group_inclusions_deleted_at ON group_inclusions (deleted_at);
+
+-- ── custom_roles ─────────────────────────────────────────────────────────────
+-- Administrator-defined permission sets consulted by CanDo on top of built-in roles.
+CREATE TABLE IF NOT EXISTS custom_roles (
+    id          uuid PRIMARY KEY,
+    name        text NOT NULL,
+    description text,
+    permissions text NOT NULL,
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    deleted_at  timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customrolename_deletedat ON custom_roles (name, deleted_at);
+CREATE INDEX IF NOT EXISTS idx_custom_roles_deleted_at ON custom_roles (deleted_at);
+
+-- ── custom_role_assignments ──────────────────────────────────────────────────
+-- group_id NULL: the role applies to every target; otherwise to that group only.
+CREATE TABLE IF NOT EXISTS custom_role_assignments (
+    id            uuid PRIMARY KEY,
+    role_id       uuid NOT NULL REFERENCES custom_roles(id),
+    user_id       uuid NOT NULL REFERENCES users(id),
+    group_id      uuid REFERENCES groups(id),
+    granted_by_id uuid NOT NULL,
+    created_at    timestamptz,
+    updated_at    timestamptz,
+    deleted_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_custom_role_assignments_role_id ON custom_role_assignments (role_id);
+CREATE INDEX IF NOT EXISTS idx_custom_role_assignments_user_id ON custom_role_assignments (user_id);
+CREATE INDEX IF NOT EXISTS idx_custom_role_assignments_group_id ON custom_role_assignments (group_id);
+CREATE INDEX IF NOT EXISTS idx_custom_role_assignments_deleted_at ON custom_role_assignments (deleted_at);
+
+-- ── hosts ────────────────────────────────────────────────────────────────────
+-- Server inventory; group accesses with server 'tag:k=v,...' select hosts by tag.
+CREATE TABLE IF NOT EXISTS hosts (
+    id             uuid PRIMARY KEY,
+    name           text NOT NULL,
+    addresses      text NOT NULL,
+    tags           text,
+    owner_group_id uuid REFERENCES groups(id),
+    description    text,
+    created_at     timestamptz,
+    updated_at     timestamptz,
+    deleted_at     timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_hostname_deletedat ON hosts (name, deleted_at);
+CREATE INDEX IF NOT EXISTS idx_hosts_owner_group_id ON hosts (owner_group_id);
+CREATE INDEX IF NOT EXISTS idx_hosts_deleted_at ON hosts (deleted_at);
+
+-- ── review_campaigns ─────────────────────────────────────────────────────────
+-- Access recertification campaigns; undecided items are revoked or flagged
+-- by the sync cycle once the deadline has passed.
+CREATE TABLE IF NOT EXISTS review_campaigns (
+    id             uuid PRIMARY KEY,
+    name           text NOT NULL,
+    description    text,
+    deadline       timestamptz NOT NULL,
+    on_expiry      text NOT NULL DEFAULT 'flag',
+    status         text NOT NULL DEFAULT 'open',
+    created_by_id  uuid NOT NULL,
+    closed_at      timestamptz,
+    created_at     timestamptz,
+    updated_at     timestamptz,
+    deleted_at     timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reviewcampaignname_deletedat ON review_campaigns (name, deleted_at);
+CREATE INDEX IF NOT EXISTS idx_review_campaigns_status ON review_campaigns (status);
+
+-- ── review_items ─────────────────────────────────────────────────────────────
+-- One membership, group access or guest grant under review in a campaign.
+CREATE TABLE IF NOT EXISTS review_items (
+    id           uuid PRIMARY KEY,
+    campaign_id  uuid NOT NULL REFERENCES review_campaigns(id) ON DELETE CASCADE,
+    group_id     uuid NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    kind         text NOT NULL,
+    subject_id   uuid NOT NULL,
+    summary      text NOT NULL,
+    status       text NOT NULL DEFAULT 'pending',
+    decider_id   uuid,
+    comment      text,
+    decided_at   timestamptz,
+    created_at   timestamptz,
+    updated_at   timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_review_items_campaign_id ON review_items (campaign_id);
+CREATE INDEX IF NOT EXISTS idx_review_items_group_id ON review_items (group_id);
+CREATE INDEX IF NOT EXISTS idx_review_items_status ON review_items (status);
+
+-- ── group_egress_key_verifications ───────────────────────────────────────────
+-- Targets on which a next group egress key has authenticated.
+CREATE TABLE IF NOT EXISTS group_egress_key_verifications (
+    id          uuid PRIMARY KEY,
+    key_id      uuid NOT NULL REFERENCES group_egress_keys(id) ON DELETE CASCADE,
+    server      text NOT NULL,
+    port        bigint NOT NULL,
+    username    text NOT NULL,
+    verified_at timestamptz NOT NULL,
+    created_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_group_egress_key_verifications_key_id ON group_egress_key_verifications (key_id);
+
+-- ── cert_authorities ─────────────────────────────────────────────────────────
+-- SSH certificate authority keys of the bastion.
+CREATE TABLE IF NOT EXISTS cert_authorities (
+    id          uuid PRIMARY KEY,
+    kind        text NOT NULL,
+    state       text NOT NULL,
+    public_key  text NOT NULL,
+    private_key text NOT NULL,
+    fingerprint text NOT NULL,
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_cert_authorities_kind ON cert_authorities (kind);
+CREATE INDEX IF NOT EXISTS idx_cert_authorities_state ON cert_authorities (state);
+
+-- ── ingress_cas ──────────────────────────────────────────────────────────────
+-- SSH user CAs whose certificates are accepted to log in to the bastion.
+CREATE TABLE IF NOT EXISTS ingress_cas (
+    id          uuid PRIMARY KEY,
+    name        text NOT NULL,
+    public_key  text NOT NULL,
+    fingerprint text NOT NULL,
+    principals  text NOT NULL,
+    added_by_id uuid NOT NULL REFERENCES users(id),
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ingress_cas_name ON ingress_cas (name);
+
+-- ── revoked_keys ─────────────────────────────────────────────────────────────
+-- SSH public keys revoked bastion-wide, by base64 SHA256 fingerprint.
+CREATE TABLE IF NOT EXISTS revoked_keys (
+    id          uuid PRIMARY KEY,
+    fingerprint text NOT NULL,
+    public_key  text,
+    reason      text NOT NULL,
+    added_by_id uuid NOT NULL REFERENCES users(id),
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_revoked_keys_fingerprint ON revoked_keys (fingerprint);
+
 -- ── PRAGMA equivalents (PostgreSQL) ──────────────────────────────────────────
 -- WAL is the default for PostgreSQL, no equivalent needed.
 -- Connection pooling should be configured in the application or via PgBouncer.